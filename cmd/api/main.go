@@ -4,31 +4,91 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	outbox "github.com/Ebrahim-hamdy/mastara-saas/internal/infra/events"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/logger"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/storage"
 
-	// "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam"
-	// iamHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/delivery/http"
-	// iamStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment"
+	appointmentHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/delivery/http"
+	appointmentStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit"
+	auditHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/delivery/http"
+	auditStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	clinicHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/delivery/http"
+	clinicStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig"
+	clinicConfigHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/delivery/http"
+	clinicConfigStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document"
+	documentHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/delivery/http"
+	documentStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam"
+	iamHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/delivery/http"
+	iamStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup"
+	lookupHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/delivery/http"
+	lookupStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/store"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
 	patientHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/delivery/http"
 	patientStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations"
+	relationsHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/delivery/http"
+	relationsStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports"
+	reportsHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/delivery/http"
+	reportsStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule"
+	scheduleHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/delivery/http"
+	scheduleStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	settingsStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload"
+	uploadHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/delivery/http"
+	uploadStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification"
+	verificationHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/delivery/http"
+	verificationStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks"
+	webhooksHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/delivery/http"
+	webhooksStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/store"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/router"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/migrations"
+	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
+	seedRBAC := flag.Bool("seed-rbac", false, "upsert the permission catalog and system roles, then exit")
+	flag.Parse()
+
+	// The first argument selects the mode: "serve" (the default), "migrate up | down [N] | status"
+	// or "seal-payloads [--unseal]".
+	command, commandArgs := "serve", []string(nil)
+	if args := flag.Args(); len(args) > 0 {
+		command, commandArgs = args[0], args[1:]
+	}
+	if command != "serve" && command != "migrate" && command != "seal-payloads" {
+		log.Fatal().Str("command", command).Msg("Unknown command; expected serve, migrate or seal-payloads")
+	}
+
 	// 1. Load environment variables from .env file for local development.
 	if err := godotenv.Load(); err != nil {
 		log.Info().Msg("No .env file found, relying on system environment variables.")
@@ -44,10 +104,42 @@ func main() {
 	logger.InitGlobalLogger(appConfig.Log)
 	log.Info().Msg("Logger initialized.")
 
-	dbProvider, err := database.NewProvider(appConfig.Database)
+	// Startup waits for the database, but a shutdown signal still stops it promptly.
+	startupCtx, stopStartup := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	dbProvider, err := database.NewProvider(startupCtx, appConfig.Database)
+	stopStartup()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Could not initialize database provider")
 	}
+
+	migrator, err := database.NewMigrator(dbProvider.Pool, migrations.FS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load embedded migrations")
+	}
+	if command == "migrate" {
+		err := runMigrate(context.Background(), migrator, commandArgs)
+		dbProvider.Close()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Migration command failed")
+		}
+		return
+	}
+	if appConfig.Database.AutoMigrate {
+		migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 5*time.Minute)
+		applied, err := migrator.Up(migrateCtx)
+		cancelMigrate()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to apply pending migrations")
+		}
+		log.Info().Int("applied", applied).Msg("Pending migrations applied.")
+	}
+
+	schemaCtx, cancelSchemaCheck := context.WithTimeout(context.Background(), 10*time.Second)
+	err = database.VerifySchema(schemaCtx, dbProvider.Pool, migrations.FS, appConfig.Database.FailOnSchemaAhead)
+	cancelSchemaCheck()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Database schema does not match this build")
+	}
 	defer dbProvider.Close()
 	log.Info().Msg("Database provider initialized.")
 
@@ -56,24 +148,163 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create token manager")
 	}
+	tokenDenylist := security.NewMemoryDenylist()
+	security.SetMaxConcurrentHashes(appConfig.Security.MaxConcurrentHashes)
 	log.Info().Msg("Security provider initialized.")
 
+	fileStore, err := storage.NewLocalStore(appConfig.Storage.Dir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize file storage")
+	}
+	// Document downloads are signed with a key of their own, derived from the server's secret.
+	blobStore, err := storage.NewBlobStore(appConfig.Storage, fileStore, security.DeriveKey([]byte(appConfig.Security.PasetoKey), "storage-url"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize blob storage")
+	}
+	log.Info().Str("backend", appConfig.Storage.Backend).Msg("File storage initialized.")
+
 	txManager := database.NewTxManager(dbProvider.Pool)
 	log.Info().Msg("Transaction manager initialized.")
 
+	if command == "seal-payloads" {
+		err := runSealPayloads(context.Background(), txManager, appConfig, commandArgs)
+		dbProvider.Close()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Sealing payloads failed")
+		}
+		return
+	}
+
+	payloadKeys := outbox.NewPayloadKeyring(appConfig)
+	outboxPublisher := outbox.NewPublisher(payloadKeys)
+	outboxDispatcher := outbox.NewDispatcher(txManager, appConfig.Outbox, payloadKeys)
+
+	if *seedRBAC {
+		seedCtx, cancelSeed := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancelSeed()
+		if err := iam.SeedRBAC(seedCtx, txManager, iamStore.NewPgxRepository(dbProvider.Pool)); err != nil {
+			log.Fatal().Err(err).Msg("Failed to seed roles and permissions")
+		}
+		log.Info().Msg("Roles and permissions seeded.")
+		return
+	}
+
 	// 4. Initialize Modules
-	// iamRepo := iamStore.NewPgxRepository(dbProvider.Pool)
-	// iamSvc := iam.NewService(iamRepo, tokenManager, appConfig)
-	// iamHandler := iamHttp.NewHandler(iamSvc)
-	// log.Info().Msg("IAM module initialized.")
+	// Modules react to each other's domain events through the bus instead of importing each other.
+	eventBus := events.NewBus()
+	// Modules holding patient data register their part of the patient export here.
+	patientExports := export.NewRegistry()
+
+	settingsRepo := settingsStore.NewPgxRepository()
+	settingsSvc := settings.NewService(txManager, settingsRepo, dbProvider.Pool)
+	languageResolver := settings.NewLanguageResolver(settingsSvc)
+	log.Info().Msg("Settings module initialized.")
+
+	// Signup writes the new clinic's settings, so the settings module comes first.
+	clinicRepo := clinicStore.NewPgxRepository(dbProvider.Pool)
+	clinicSvc := clinic.NewService(txManager, clinicRepo, settingsSvc, tokenManager, appConfig.Security.TokenDuration)
+	clinicHandler := clinicHttp.NewHandler(clinicSvc)
+	log.Info().Msg("Clinic module initialized.")
+
+	// Every module shares one notifier so a provider outage trips a single breaker per channel.
+	notifier := notification.NewGuarded(notification.New(appConfig.SMTP), appConfig.Notification)
+
+	iamRepo := iamStore.NewPgxRepository(dbProvider.Pool)
+	loginLockout := security.NewMemoryLockout(security.LockoutPolicy{
+		Threshold: appConfig.Security.LockoutThreshold,
+		Window:    appConfig.Security.LockoutWindow,
+		Duration:  appConfig.Security.LockoutDuration,
+	})
+	authFailures := iam.NewAuthFailureRecorder(iamRepo, []byte(appConfig.Security.PasetoKey))
+	iamSvc := iam.NewService(txManager, iamRepo, tokenManager, tokenDenylist, loginLockout, appConfig, authFailures, iam.NewMessageNotifier(notifier), settings.NewPhoneRegionResolver(settingsSvc), dbProvider.Pool, dbProvider.Reader(), outboxPublisher, outboxDispatcher)
+	iamHandler := iamHttp.NewHandler(iamSvc)
+	log.Info().Msg("IAM module initialized.")
 
 	patientRepo := patientStore.NewPgxProfileRepository(dbProvider.Pool)
-	patientSvc := patient.NewService(txManager, patientRepo, dbProvider.Pool)
+	patientSvc := patient.NewService(txManager, patientRepo, dbProvider.Pool, dbProvider.ClinicScopedReader(), settingsSvc, eventBus, patientExports)
 	patientHandler := patientHttp.NewHandler(patientSvc)
 	log.Info().Msg("Patient module initialized.")
 
+	lookupRepo := lookupStore.NewPgxRepository(dbProvider.Pool)
+	lookupSvc := lookup.NewService(lookupRepo)
+	lookupHandler := lookupHttp.NewHandler(lookupSvc)
+	log.Info().Msg("Lookup module initialized.")
+
+	relationsRepo := relationsStore.NewPgxRepository(dbProvider.Pool)
+	relationsSvc := relations.NewService(relationsRepo)
+	relationsHandler := relationsHttp.NewHandler(relationsSvc)
+	log.Info().Msg("Relations module initialized.")
+
+	reportsRepo := reportsStore.NewPgxRepository(dbProvider.ReaderPool())
+	reportsSvc := reports.NewService(reportsRepo)
+	reportsHandler := reportsHttp.NewHandler(reportsSvc)
+	log.Info().Msg("Reports module initialized.")
+
+	uploadRepo := uploadStore.NewPgxRepository()
+	uploadSvc := upload.NewService(txManager, uploadRepo, dbProvider.Pool, fileStore, appConfig.Storage.Upload)
+	uploadHandler := uploadHttp.NewHandler(uploadSvc)
+	log.Info().Msg("Upload module initialized.")
+
+	documentRepo := documentStore.NewPgxRepository()
+	documentSvc := document.NewService(documentRepo, dbProvider.Pool, blobStore, appConfig.Storage, patientExports)
+	documentHandler := documentHttp.NewHandler(documentSvc, appConfig.Storage.Documents)
+	log.Info().Msg("Document module initialized.")
+
+	clinicConfigRepo := clinicConfigStore.NewPgxRepository()
+	clinicConfigSvc := clinicconfig.NewService(txManager, clinicConfigRepo, dbProvider.Pool, settingsSvc)
+	clinicConfigHandler := clinicConfigHttp.NewHandler(clinicConfigSvc)
+	log.Info().Msg("Clinic configuration module initialized.")
+
+	verificationRepo := verificationStore.NewPgxRepository()
+	verificationSvc := verification.NewService(txManager, verificationRepo, notifier, tokenManager, appConfig)
+	verificationHandler := verificationHttp.NewHandler(verificationSvc)
+	log.Info().Msg("Verification module initialized.")
+
+	appointmentRepo := appointmentStore.NewPgxRepository()
+	appointmentSvc := appointment.NewService(txManager, appointmentRepo, dbProvider.Pool, patientSvc, verificationSvc, clinicSvc, tokenManager, appConfig, eventBus, patientExports)
+	appointmentHandler := appointmentHttp.NewHandler(appointmentSvc, tokenManager)
+	reminderScheduler := appointment.NewReminderScheduler(txManager, appointmentRepo, clinicSvc, notifier, appConfig.Booking)
+	log.Info().Msg("Appointment module initialized.")
+
+	scheduleRepo := scheduleStore.NewPgxRepository()
+	scheduleSvc := schedule.NewService(txManager, scheduleRepo, dbProvider.Pool, clinicSvc)
+	scheduleHandler := scheduleHttp.NewHandler(scheduleSvc)
+	log.Info().Msg("Schedule module initialized.")
+
+	webhooksRepo := webhooksStore.NewPgxRepository()
+	webhooksSvc := webhooks.NewService(txManager, webhooksRepo, dbProvider.Pool, appConfig, eventBus, outboxPublisher, outboxDispatcher)
+	webhooksHandler := webhooksHttp.NewHandler(webhooksSvc)
+	webhookWorker := webhooks.NewDeliveryWorker(txManager, webhooksRepo, appConfig)
+	log.Info().Msg("Webhooks module initialized.")
+
+	auditRepo := auditStore.NewPgxRepository(dbProvider.ReaderPool())
+	auditSvc := audit.NewService(auditRepo, patientExports)
+	auditHandler := auditHttp.NewHandler(auditSvc)
+	log.Info().Msg("Audit module initialized.")
+
 	// 4. Setup router with injected dependencies.
-	engine := router.New(dbProvider, tokenManager, nil, patientHandler)
+	// Every module with HTTP routes is listed here; the router logs how many routes each registered.
+	modules := []router.Module{
+		clinicHandler,
+		iamHandler,
+		patientHandler,
+		lookupHandler,
+		relationsHandler,
+		reportsHandler,
+		uploadHandler,
+		documentHandler,
+		clinicConfigHandler,
+		verificationHandler,
+		appointmentHandler,
+		scheduleHandler,
+		webhooksHandler,
+		auditHandler,
+	}
+	engine := router.New(appConfig, dbProvider, tokenManager, tokenDenylist, clinicSvc, languageResolver, modules...)
+	// The local backend serves signed document downloads itself; S3 URLs point at the bucket.
+	if local, ok := blobStore.(*storage.SignedLocalStore); ok {
+		engine.GET("/files/*key", gin.WrapH(http.StripPrefix("/files", local.ServeSigned())))
+	}
 	log.Info().Msg("Router initialized.")
 
 	// 5. Create and configure the HTTP server.
@@ -85,7 +316,37 @@ func main() {
 		IdleTimeout:  appConfig.Server.IdleTimeout,
 	}
 
-	// 6. Start the server and listen for shutdown signals.
+	// 6. Start background workers; they stop when the application shuts down.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go func() {
+		if err := settingsSvc.Listen(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msg("Settings change listener stopped unexpectedly")
+		}
+	}()
+	go tokenDenylist.Run(workerCtx, time.Minute)
+	go uploadSvc.Run(workerCtx, time.Hour)
+	go verificationSvc.Run(workerCtx, time.Hour)
+	// Shutdown waits for the workers that send messages, so a batch already sent is committed as sent.
+	var messageWorkers sync.WaitGroup
+	messageWorkers.Add(3)
+	go func() {
+		defer messageWorkers.Done()
+		reminderScheduler.Run(workerCtx, appConfig.Booking.ReminderInterval)
+	}()
+	go func() {
+		defer messageWorkers.Done()
+		outboxDispatcher.Run(workerCtx)
+	}()
+	go func() {
+		defer messageWorkers.Done()
+		webhookWorker.Run(workerCtx)
+	}()
+	go authFailures.Run(workerCtx, appConfig.Security.AuthFailureRetention)
+	go loginLockout.Run(workerCtx, time.Minute)
+
+	// 7. Start the server and listen for shutdown signals.
 	serverErrChan := make(chan error, 1)
 	go func() {
 		log.Info().Str("address", httpServer.Addr).Msg("Starting HTTP server")
@@ -115,5 +376,8 @@ func main() {
 		}
 	}
 
+	stopWorkers()
+	messageWorkers.Wait()
+
 	log.Info().Msg("Application has shut down.")
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+)
+
+const migrateUsage = "usage: api migrate up | down [N] | status"
+
+// runMigrate executes the "migrate" subcommand: "up" applies all pending migrations, "down N"
+// reverts the newest N (default 1) and "status" prints the applied and pending versions.
+func runMigrate(ctx context.Context, migrator *database.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s).\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid migration count %q; %s", args[1], migrateUsage)
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s).\n", reverted)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Current version: %d (dirty: %t)\n", status.Current, status.Dirty)
+		fmt.Printf("Latest version:  %d\n", status.Latest)
+		if len(status.Pending) == 0 {
+			fmt.Println("Pending:         none")
+		} else {
+			fmt.Printf("Pending:         %s\n", database.FormatVersions(status.Pending))
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q; %s", args[0], migrateUsage)
+	}
+	return nil
+}
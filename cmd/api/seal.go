@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	outbox "github.com/Ebrahim-hamdy/mastara-saas/internal/infra/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks"
+	webhooksStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+)
+
+const sealPayloadsUsage = "usage: api seal-payloads [--unseal]"
+
+// sealBatchSize is how many rows seal-payloads rewrites per transaction.
+const sealBatchSize = 500
+
+// runSealPayloads executes the "seal-payloads" subcommand: it seals outbox and webhook payloads
+// still stored in plaintext and reseals those and webhook signing secrets sealed under an older
+// key version, or with --unseal stores the payloads in plaintext again before migration 42 is
+// reverted. It works across all clinics.
+func runSealPayloads(ctx context.Context, txManager database.TxManager, appConfig *config.Config, args []string) error {
+	ctx = database.WithPlatformAccess(ctx)
+	keys := outbox.NewPayloadKeyring(appConfig)
+	switch {
+	case len(args) == 0:
+		changed, err := outbox.ResealPayloads(ctx, txManager, keys, sealBatchSize)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Sealed %d payload(s) under key version %d.\n", changed, keys.Current())
+		secrets, err := webhooks.ResealSecrets(ctx, txManager, webhooksStore.NewPgxRepository(), appConfig, sealBatchSize)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Resealed %d webhook secret(s) under key version %d.\n", secrets, keys.Current())
+	case len(args) == 1 && args[0] == "--unseal":
+		changed, err := outbox.UnsealPayloads(ctx, txManager, keys, sealBatchSize)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Unsealed %d payload(s).\n", changed)
+	default:
+		return errors.New(sealPayloadsUsage)
+	}
+	return nil
+}
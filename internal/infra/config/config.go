@@ -4,19 +4,28 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for the application.
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Security SecurityConfig `mapstructure:"security"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Security     SecurityConfig     `mapstructure:"security"`
+	Log          LogConfig          `mapstructure:"log"`
+	Storage      StorageConfig      `mapstructure:"storage"`
+	SMTP         SMTPConfig         `mapstructure:"smtp"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	Booking      BookingConfig      `mapstructure:"booking"`
+	Outbox       OutboxConfig       `mapstructure:"outbox"`
+	Webhooks     WebhookConfig      `mapstructure:"webhooks"`
 }
 
 type ServerConfig struct {
@@ -24,10 +33,40 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"readTimeout"`
 	WriteTimeout time.Duration `mapstructure:"writeTimeout"`
 	IdleTimeout  time.Duration `mapstructure:"idleTimeout"`
+	// BaseDomain is the apex domain clinic subdomains hang off (e.g. "mastara.com"). Empty disables host-based resolution.
+	BaseDomain  string            `mapstructure:"baseDomain"`
+	Concurrency ConcurrencyConfig `mapstructure:"concurrency"`
+	// QueryParams is what happens to unknown query parameters: "off", "warn" (log them) or
+	// "reject" (answer 400). Existing clients may send stray parameters, so production warns.
+	QueryParams string `mapstructure:"queryParams"`
+}
+
+// ConcurrencyConfig caps in-flight requests so bursts queue briefly instead of exhausting the database pool.
+// A limit of zero disables that limiter. Health checks are never limited.
+type ConcurrencyConfig struct {
+	// Global caps all API requests together; keep it below database.maxOpenConns.
+	Global int `mapstructure:"global"`
+	// Public caps unauthenticated /public requests (booking widget traffic) within the global cap.
+	Public int `mapstructure:"public"`
+	// Routes caps groups of routes within the global cap, as "<route prefix>=<limit>" entries
+	// matched against route patterns, e.g. "/api/v1/patients/:id/export=2". The longest
+	// matching prefix applies.
+	Routes []string `mapstructure:"routes"`
+	// WaitTimeout is how long a request may queue for a slot before it is rejected with 503.
+	WaitTimeout time.Duration `mapstructure:"waitTimeout"`
+	// RetryAfter is advertised to rejected clients in the Retry-After header.
+	RetryAfter time.Duration `mapstructure:"retryAfter"`
 }
 
 type DatabaseConfig struct {
-	Host            string        `mapstructure:"host"`
+	// URL is a full connection string, as injected by hosting platforms. When set it takes the
+	// place of the discrete connection fields, which may then only repeat what it says.
+	URL  string `mapstructure:"url"`
+	Host string `mapstructure:"host"`
+	// ReplicaURL or ReplicaHost configure an optional read replica. ReplicaHost reuses every
+	// other discrete connection field; with URL, use ReplicaURL.
+	ReplicaURL      string        `mapstructure:"replicaURL"`
+	ReplicaHost     string        `mapstructure:"replicaHost"`
 	Port            string        `mapstructure:"port"`
 	User            string        `mapstructure:"user"`
 	Password        string        `mapstructure:"password"`
@@ -37,16 +76,366 @@ type DatabaseConfig struct {
 	MaxIdleConns    int           `mapstructure:"maxIdleConns"`
 	ConnMaxIdleTime time.Duration `mapstructure:"connMaxIdleTime"`
 	ConnMaxLifetime time.Duration `mapstructure:"connMaxLifetime"`
+	// ConnectRetries is how many times an unreachable database is retried at startup.
+	ConnectRetries int `mapstructure:"connectRetries"`
+	// ConnectBackoff is the first wait between startup attempts; it doubles per attempt up to 30s.
+	ConnectBackoff time.Duration `mapstructure:"connectBackoff"`
+	// FailOnSchemaAhead refuses to start when the database has migrations this build does not know about.
+	FailOnSchemaAhead bool `mapstructure:"failOnSchemaAhead"`
+	// AutoMigrate applies pending embedded migrations at startup, before the schema is verified.
+	AutoMigrate bool `mapstructure:"automigrate"`
+	// AcquireTimeout bounds how long a query waits for a pooled connection, separately from how
+	// long the statement may run. Requests that hit it get a 503 database_busy; zero waits as
+	// long as the request context allows.
+	AcquireTimeout time.Duration `mapstructure:"acquireTimeout"`
+	// BusyRetryAfter is the Retry-After sent with database_busy responses.
+	BusyRetryAfter time.Duration `mapstructure:"busyRetryAfter"`
+	// DegradedAcquireWaitP95 makes /health report degraded while the p95 acquire wait exceeds it; zero disables.
+	DegradedAcquireWaitP95 time.Duration `mapstructure:"degradedAcquireWaitP95"`
+	// LogQueries logs every statement at debug level and failed statements at error level.
+	LogQueries bool `mapstructure:"logQueries"`
+	// SlowQueryThreshold logs slower statements at warn level even when LogQueries is off; zero disables.
+	SlowQueryThreshold time.Duration `mapstructure:"slowQueryThreshold"`
+	// ReadYourWritesWindow is how long a client's reads go to the primary after it writes, so
+	// replica lag cannot hide its own changes from it. It should exceed the usual replica lag;
+	// zero disables it. It only applies when a replica is configured.
+	ReadYourWritesWindow time.Duration `mapstructure:"readYourWritesWindow"`
 }
 
+// Defaults for the discrete connection fields. They are applied here rather than as viper
+// defaults so that validation can tell a value set alongside URL from one nobody set.
+const (
+	defaultDatabaseHost    = "localhost"
+	defaultDatabasePort    = "5432"
+	defaultDatabaseSSLMode = "disable"
+)
+
+// ConnectionString returns URL when set, and otherwise a key/value string built from the discrete fields.
 func (db *DatabaseConfig) ConnectionString() string {
+	if db.URL != "" {
+		return db.URL
+	}
 	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		db.Host, db.User, db.Password, db.DBName, db.Port, db.SSLMode)
+		valueOr(db.Host, defaultDatabaseHost), db.User, db.Password, db.DBName,
+		valueOr(db.Port, defaultDatabasePort), valueOr(db.SSLMode, defaultDatabaseSSLMode))
+}
+
+// ReplicaConnectionString returns the read replica's connection string, or "" when none is configured.
+func (db *DatabaseConfig) ReplicaConnectionString() string {
+	switch {
+	case db.ReplicaURL != "":
+		return db.ReplicaURL
+	case db.ReplicaHost != "":
+		replica := *db
+		replica.Host = db.ReplicaHost
+		return replica.ConnectionString()
+	}
+	return ""
+}
+
+// validateConnection checks URL parses and that any discrete field set alongside it agrees with it.
+func (db *DatabaseConfig) validateConnection() error {
+	if db.URL == "" {
+		if db.User == "" {
+			return fmt.Errorf("FATAL: Database user is not configured. Set DATABASE_USER or DATABASE_URL environment variable")
+		}
+		if db.Password == "" {
+			return fmt.Errorf("FATAL: Database password is not configured. Set DATABASE_PASSWORD or DATABASE_URL environment variable")
+		}
+		if db.DBName == "" {
+			return fmt.Errorf("FATAL: Database name is not configured. Set DATABASE_DBNAME or DATABASE_URL environment variable")
+		}
+		return nil
+	}
+
+	parsed, err := pgconn.ParseConfig(db.URL)
+	if err != nil {
+		// The parse error can echo the password, so it is not wrapped.
+		return fmt.Errorf("FATAL: DATABASE_URL is not a valid PostgreSQL connection string")
+	}
+	conflicts := []struct {
+		env, value, fromURL string
+	}{
+		{"DATABASE_HOST", db.Host, parsed.Host},
+		{"DATABASE_PORT", db.Port, strconv.Itoa(int(parsed.Port))},
+		{"DATABASE_USER", db.User, parsed.User},
+		{"DATABASE_PASSWORD", db.Password, parsed.Password},
+		{"DATABASE_DBNAME", db.DBName, parsed.Database},
+		{"DATABASE_SSLMODE", db.SSLMode, urlSSLMode(db.URL)},
+	}
+	for _, c := range conflicts {
+		if c.value != "" && c.value != c.fromURL {
+			return fmt.Errorf("FATAL: %s conflicts with DATABASE_URL. Unset it or make them agree", c.env)
+		}
+	}
+	return nil
+}
+
+// validateReplica checks the read replica is configured in exactly one way.
+func (db *DatabaseConfig) validateReplica() error {
+	if db.ReplicaURL != "" && db.ReplicaHost != "" {
+		return fmt.Errorf("FATAL: Set only one of DATABASE_REPLICAURL and DATABASE_REPLICAHOST")
+	}
+	if db.ReplicaHost != "" && db.URL != "" {
+		return fmt.Errorf("FATAL: DATABASE_REPLICAHOST cannot be combined with DATABASE_URL. Set DATABASE_REPLICAURL instead")
+	}
+	if db.ReplicaURL != "" {
+		if _, err := pgconn.ParseConfig(db.ReplicaURL); err != nil {
+			return fmt.Errorf("FATAL: DATABASE_REPLICAURL is not a valid PostgreSQL connection string")
+		}
+	}
+	return nil
+}
+
+// validatePool rejects pool sizes pgxpool would reject or silently clamp, and negative retry settings.
+func (db *DatabaseConfig) validatePool() error {
+	if db.ConnectRetries < 0 || db.ConnectBackoff < 0 {
+		return fmt.Errorf("FATAL: Database connect retries and backoff must not be negative. Check DATABASE_CONNECTRETRIES and DATABASE_CONNECTBACKOFF")
+	}
+	if db.MaxOpenConns < 1 {
+		return fmt.Errorf("FATAL: Database pool size must be at least 1. Check DATABASE_MAXOPENCONNS")
+	}
+	if db.MaxIdleConns < 0 {
+		return fmt.Errorf("FATAL: Database idle connections must not be negative. Check DATABASE_MAXIDLECONNS")
+	}
+	if db.MaxIdleConns > db.MaxOpenConns {
+		return fmt.Errorf("FATAL: Database idle connections (%d) exceed the pool size (%d). Check DATABASE_MAXIDLECONNS",
+			db.MaxIdleConns, db.MaxOpenConns)
+	}
+	return nil
+}
+
+// RouteLimits parses Routes into limits by route prefix.
+func (c ConcurrencyConfig) RouteLimits() (map[string]int, error) {
+	limits := make(map[string]int, len(c.Routes))
+	for _, entry := range c.Routes {
+		prefix, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		limit, err := strconv.Atoi(value)
+		if !ok || !strings.HasPrefix(prefix, "/") || err != nil || limit < 1 {
+			return nil, fmt.Errorf("FATAL: Invalid route concurrency limit %q; expected \"<route prefix>=<limit>\". Check SERVER_CONCURRENCY_ROUTES", entry)
+		}
+		limits[prefix] = limit
+	}
+	return limits, nil
+}
+
+// urlSSLMode returns the sslmode given in a URL or key/value connection string, or "" when absent.
+func urlSSLMode(conn string) string {
+	if u, err := url.Parse(conn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		return u.Query().Get("sslmode")
+	}
+	for _, field := range strings.Fields(conn) {
+		if mode, ok := strings.CutPrefix(field, "sslmode="); ok {
+			return mode
+		}
+	}
+	return ""
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
 }
 
 type SecurityConfig struct {
-	TokenDuration time.Duration `mapstructure:"tokenDuration"`
-	PasetoKey     string        `mapstructure:"pasetoKey"`
+	TokenDuration         time.Duration `mapstructure:"tokenDuration"`
+	RefreshTokenDuration  time.Duration `mapstructure:"refreshTokenDuration"`
+	PasetoKey             string        `mapstructure:"pasetoKey"`
+	ImpersonationDuration time.Duration `mapstructure:"impersonationDuration"`
+	ImpersonationReadOnly bool          `mapstructure:"impersonationReadOnly"`
+	AuthFailureRetention  time.Duration `mapstructure:"authFailureRetention"`
+	InviteTokenDuration   time.Duration `mapstructure:"inviteTokenDuration"`
+	// EmailChangeTokenDuration is how long the link sent to a new email address stays valid.
+	EmailChangeTokenDuration time.Duration `mapstructure:"emailChangeTokenDuration"`
+	// PasswordResetTokenDuration is how long a forgot-password link stays valid.
+	PasswordResetTokenDuration time.Duration `mapstructure:"passwordResetTokenDuration"`
+	// ActionLinkDuration is how long a signed action link in a staff notification email stays valid.
+	ActionLinkDuration time.Duration `mapstructure:"actionLinkDuration"`
+	// ActionLinkURL is the client landing page action links point to; the token is appended as ?token=.
+	ActionLinkURL string `mapstructure:"actionLinkURL"`
+	// GuestTokenDuration is how long the management token returned to a guest who books online
+	// stays valid. It never outlives the appointment's start.
+	GuestTokenDuration time.Duration `mapstructure:"guestTokenDuration"`
+	// PhoneTokenDuration is how long the phone_verified token a guest gets for entering their
+	// texted code stays valid for booking.
+	PhoneTokenDuration time.Duration `mapstructure:"phoneTokenDuration"`
+	// InviteURL is the client page where an invited employee sets their password; the token is appended as ?token=.
+	InviteURL string `mapstructure:"inviteURL"`
+	// MaxConcurrentHashes caps simultaneous Argon2 operations; each one allocates 64 MB.
+	MaxConcurrentHashes int `mapstructure:"maxConcurrentHashes"`
+	// TokenMode is "local" (v4.local, encrypted with PasetoKey) or "public" (v4.public, signed
+	// with PasetoPrivateKey so other services can verify tokens with PasetoPublicKey).
+	TokenMode string `mapstructure:"tokenMode"`
+	// PasetoPrivateKey is the hex-encoded Ed25519 seed or private key; required in public mode.
+	PasetoPrivateKey string `mapstructure:"pasetoPrivateKey"`
+	// PasetoPublicKey is optional; when set it must match PasetoPrivateKey.
+	PasetoPublicKey string `mapstructure:"pasetoPublicKey"`
+	// TokenIssuer and TokenAudience are stamped into every token and required on verification,
+	// so tokens minted by another environment are rejected even if it shares the key.
+	TokenIssuer   string `mapstructure:"tokenIssuer"`
+	TokenAudience string `mapstructure:"tokenAudience"`
+	// TokenLeeway tolerates clock drift between pods when checking exp and nbf.
+	TokenLeeway time.Duration `mapstructure:"tokenLeeway"`
+	// LockoutThreshold failed logins within LockoutWindow lock an employee account for
+	// LockoutDuration, until it expires or an administrator unlocks it. Zero disables lockout.
+	LockoutThreshold int           `mapstructure:"lockoutThreshold"`
+	LockoutWindow    time.Duration `mapstructure:"lockoutWindow"`
+	LockoutDuration  time.Duration `mapstructure:"lockoutDuration"`
+	// SealKeys encrypt what is stored sealed (outbox payloads, queued invitations and webhook
+	// signing secrets). Each entry is "v<version>:<secret>", e.g.
+	// "v1:<secret>,v2:<secret>" in the environment. They are separate from PasetoKey so either can
+	// be rotated alone. List the PASETO key as v1 to keep values sealed before SealKeys existed readable.
+	SealKeys []string `mapstructure:"sealKeys"`
+	// SealKeyVersion is the version of SealKeys new values are sealed under. To rotate, add the
+	// new key on every instance, then raise this; "api seal-payloads" reseals older values.
+	SealKeyVersion int16 `mapstructure:"sealKeyVersion"`
+}
+
+// SealKeySecrets parses SealKeys into secrets by version.
+func (c SecurityConfig) SealKeySecrets() (map[int16][]byte, error) {
+	if len(c.SealKeys) == 0 {
+		return nil, fmt.Errorf("FATAL: Seal keys are not configured. Set SECURITY_SEALKEYS to \"v1:<secret>\"")
+	}
+	secrets := make(map[int16][]byte, len(c.SealKeys))
+	for i, entry := range c.SealKeys {
+		// The secret is left out of errors so it never reaches the logs.
+		label, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		digits, versioned := strings.CutPrefix(label, "v")
+		version, err := strconv.ParseInt(digits, 10, 16)
+		if !ok || !versioned || err != nil || version < 1 {
+			return nil, fmt.Errorf("FATAL: Seal key %d is not of the form \"v<version>:<secret>\". Check SECURITY_SEALKEYS", i+1)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("FATAL: Seal key v%d must be at least 32 characters long. Check SECURITY_SEALKEYS", version)
+		}
+		if _, dup := secrets[int16(version)]; dup {
+			return nil, fmt.Errorf("FATAL: Seal key v%d is listed twice. Check SECURITY_SEALKEYS", version)
+		}
+		secrets[int16(version)] = []byte(secret)
+	}
+	return secrets, nil
+}
+
+// BookingConfig limits public guest bookings and paces appointment reminders.
+type BookingConfig struct {
+	// MaxPendingPerPhone caps the upcoming, uncancelled appointments one phone number may hold
+	// in a clinic through public booking.
+	MaxPendingPerPhone int `mapstructure:"maxPendingPerPhone"`
+	// ReminderInterval is how often the reminder scheduler looks for appointments due a reminder.
+	ReminderInterval time.Duration `mapstructure:"reminderInterval"`
+	// ReminderBatchSize caps how many reminders one transaction locks and sends.
+	ReminderBatchSize int `mapstructure:"reminderBatchSize"`
+	// OTPTTL is how long a code texted to a guest can be entered.
+	OTPTTL time.Duration `mapstructure:"otpTTL"`
+	// OTPMaxAttempts wrong entries lock a code; the guest must request a new one.
+	OTPMaxAttempts int `mapstructure:"otpMaxAttempts"`
+	// OTPRequestLimit codes may be texted to one phone number per clinic within OTPRequestWindow.
+	OTPRequestLimit  int           `mapstructure:"otpRequestLimit"`
+	OTPRequestWindow time.Duration `mapstructure:"otpRequestWindow"`
+}
+
+// OutboxConfig paces the dispatcher that delivers outbox events to their handlers.
+type OutboxConfig struct {
+	// PollInterval is how often the dispatcher looks for due events.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// BatchSize caps how many events one transaction claims.
+	BatchSize int `mapstructure:"batchSize"`
+	// MaxAttempts is how many failed deliveries an event gets before it is marked DEAD.
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// RetryBackoff is the wait after the first failure; it doubles with each further failure.
+	// The second half of every wait is random so events that failed together spread out.
+	RetryBackoff time.Duration `mapstructure:"retryBackoff"`
+	// MaxRetryBackoff caps the wait between attempts.
+	MaxRetryBackoff time.Duration `mapstructure:"maxRetryBackoff"`
+}
+
+// WebhookConfig paces the worker that POSTs events to clinics' webhook endpoints.
+type WebhookConfig struct {
+	// PollInterval is how often the worker looks for due deliveries.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// BatchSize caps how many deliveries one transaction claims.
+	BatchSize int `mapstructure:"batchSize"`
+	// Timeout bounds each POST, including connecting and reading the response.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxAttempts is how many POSTs a delivery gets before it is marked FAILED.
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// RetryBackoff is the wait after the first failed attempt; it doubles with each further one.
+	RetryBackoff time.Duration `mapstructure:"retryBackoff"`
+	// MaxRetryBackoff caps the wait between attempts.
+	MaxRetryBackoff time.Duration `mapstructure:"maxRetryBackoff"`
+}
+
+// SMTPConfig configures the mail relay for outgoing email. Without a Host, email is only logged.
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// From is the sender, e.g. "Mastara <no-reply@example.com>".
+	From string `mapstructure:"from"`
+}
+
+// NotificationConfig tunes the circuit breaker kept for each notification channel, email and
+// SMS, so an outage at the provider fails sends fast instead of every caller waiting out a timeout.
+type NotificationConfig struct {
+	// FailureRate opens a channel's breaker once this fraction of its sends in a Window fail.
+	FailureRate float64 `mapstructure:"failureRate"`
+	// MinRequests is how many sends a Window needs before its failure rate is judged.
+	MinRequests int `mapstructure:"minRequests"`
+	// Window is how long sends are tallied before the count starts over.
+	Window time.Duration `mapstructure:"window"`
+	// CoolDown is how long an open breaker refuses sends before letting one through as a probe.
+	CoolDown time.Duration `mapstructure:"coolDown"`
+}
+
+// StorageConfig configures where uploaded files are kept and the limits on chunked uploads.
+type StorageConfig struct {
+	// Dir is the root directory of the local file store.
+	Dir string `mapstructure:"dir"`
+	// Backend keeps blobs clients download directly, such as patient documents: "local" (under
+	// Dir, served by the API at PublicURL) or "s3". Chunked uploads always stage under Dir.
+	Backend string `mapstructure:"backend"`
+	// PublicURL is where the API serves local blobs, e.g. "https://api.example.com/files".
+	PublicURL string `mapstructure:"publicURL"`
+	// SignedURLTTL is how long a download URL handed to a client stays valid.
+	SignedURLTTL time.Duration  `mapstructure:"signedURLTTL"`
+	S3           S3Config       `mapstructure:"s3"`
+	Upload       UploadConfig   `mapstructure:"upload"`
+	Documents    DocumentConfig `mapstructure:"documents"`
+}
+
+// S3Config addresses a bucket of an S3-compatible object store.
+type S3Config struct {
+	// Endpoint is the store's base URL, e.g. "https://s3.eu-central-1.amazonaws.com".
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	AccessKeyID     string `mapstructure:"accessKeyID"`
+	SecretAccessKey string `mapstructure:"secretAccessKey"`
+	// UsePathStyle addresses objects as endpoint/bucket/key, as MinIO expects, instead of bucket.endpoint/key.
+	UsePathStyle bool `mapstructure:"usePathStyle"`
+}
+
+// DocumentConfig limits the documents attached to patient profiles.
+type DocumentConfig struct {
+	// MaxSize caps one document.
+	MaxSize int64 `mapstructure:"maxSize"`
+	// AllowedTypes lists the accepted MIME types, as detected from the file's content.
+	AllowedTypes []string `mapstructure:"allowedTypes"`
+}
+
+// UploadConfig limits chunked upload sessions.
+type UploadConfig struct {
+	// ChunkSize is the size of every chunk but the last; it must fit within the 1 MiB request body limit.
+	ChunkSize int64 `mapstructure:"chunkSize"`
+	// MaxSize caps the declared size of a whole file.
+	MaxSize int64 `mapstructure:"maxSize"`
+	// AllowedTypes lists the accepted MIME types, e.g. "image/jpeg,application/pdf" in the environment.
+	AllowedTypes []string `mapstructure:"allowedTypes"`
+	// SessionTTL is how long an upload may take to complete before its chunks are discarded.
+	SessionTTL time.Duration `mapstructure:"sessionTTL"`
 }
 
 type LogConfig struct {
@@ -81,14 +470,83 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.readTimeout", "5s")
 	v.SetDefault("server.writeTimeout", "10s")
 	v.SetDefault("server.idleTimeout", "120s")
-	v.SetDefault("database.host", "localhost")
-	v.SetDefault("database.port", "5432")
-	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("server.concurrency.global", 20)
+	v.SetDefault("server.concurrency.public", 8)
+	// Exports hold a connection for the whole download.
+	v.SetDefault("server.concurrency.routes", []string{"/api/v1/patients/:id/export=2"})
+	v.SetDefault("server.concurrency.waitTimeout", "2s")
+	v.SetDefault("server.concurrency.retryAfter", "2s")
+	v.SetDefault("server.queryParams", "warn")
 	v.SetDefault("database.maxOpenConns", 25)
 	v.SetDefault("database.maxIdleConns", 25)
 	v.SetDefault("database.connMaxIdleTime", "15m")
 	v.SetDefault("database.connMaxLifetime", "2h")
+	v.SetDefault("database.connectRetries", 5)
+	v.SetDefault("database.connectBackoff", "1s")
+	v.SetDefault("database.failOnSchemaAhead", false)
+	v.SetDefault("database.automigrate", false)
+	v.SetDefault("database.acquireTimeout", "3s")
+	v.SetDefault("database.busyRetryAfter", "2s")
+	v.SetDefault("database.degradedAcquireWaitP95", "500ms")
+	v.SetDefault("database.logQueries", false)
+	v.SetDefault("database.slowQueryThreshold", "500ms")
+	v.SetDefault("database.readYourWritesWindow", "5s")
 	v.SetDefault("security.tokenDuration", "15m")
+	v.SetDefault("security.sealKeyVersion", 1)
+	v.SetDefault("security.tokenMode", "local")
+	v.SetDefault("security.tokenIssuer", "mastara")
+	v.SetDefault("security.tokenAudience", "mastara-api")
+	v.SetDefault("security.tokenLeeway", "30s")
+	v.SetDefault("security.refreshTokenDuration", "720h") // 30 days
+	v.SetDefault("security.impersonationDuration", "30m")
+	v.SetDefault("security.impersonationReadOnly", true)
+	v.SetDefault("security.authFailureRetention", "2160h") // 90 days
+	v.SetDefault("security.inviteTokenDuration", "72h")
+	v.SetDefault("security.emailChangeTokenDuration", "24h")
+	v.SetDefault("security.passwordResetTokenDuration", "30m")
+	v.SetDefault("security.actionLinkDuration", "1h")
+	v.SetDefault("security.actionLinkURL", "http://localhost:3000/action-link")
+	v.SetDefault("security.guestTokenDuration", "72h")
+	v.SetDefault("security.phoneTokenDuration", "15m")
+	v.SetDefault("security.inviteURL", "http://localhost:3000/accept-invite")
+	v.SetDefault("security.maxConcurrentHashes", 4)
+	v.SetDefault("security.lockoutThreshold", 5)
+	v.SetDefault("security.lockoutWindow", "15m")
+	v.SetDefault("security.lockoutDuration", "15m")
+	v.SetDefault("storage.dir", "./data/uploads")
+	v.SetDefault("storage.upload.chunkSize", 1<<20) // 1 MiB
+	v.SetDefault("storage.upload.maxSize", 50<<20)  // 50 MiB
+	v.SetDefault("storage.upload.allowedTypes", []string{"image/jpeg", "image/png", "application/pdf"})
+	v.SetDefault("storage.upload.sessionTTL", "24h")
+	v.SetDefault("storage.backend", "local")
+	v.SetDefault("storage.publicURL", "http://localhost:8080/files")
+	v.SetDefault("storage.signedURLTTL", "5m")
+	v.SetDefault("storage.documents.maxSize", 10<<20) // 10 MiB
+	v.SetDefault("storage.documents.allowedTypes", []string{"image/jpeg", "image/png", "application/pdf"})
+	v.SetDefault("booking.maxPendingPerPhone", 3)
+	v.SetDefault("booking.reminderInterval", "5m")
+	v.SetDefault("booking.reminderBatchSize", 50)
+	v.SetDefault("booking.otpTTL", "5m")
+	v.SetDefault("booking.otpMaxAttempts", 5)
+	v.SetDefault("booking.otpRequestLimit", 3)
+	v.SetDefault("booking.otpRequestWindow", "15m")
+	v.SetDefault("outbox.pollInterval", "2s")
+	v.SetDefault("outbox.batchSize", 50)
+	v.SetDefault("outbox.maxAttempts", 8)
+	v.SetDefault("outbox.retryBackoff", "30s")
+	v.SetDefault("outbox.maxRetryBackoff", "1h")
+	v.SetDefault("webhooks.pollInterval", "5s")
+	v.SetDefault("webhooks.batchSize", 20)
+	v.SetDefault("webhooks.timeout", "10s")
+	v.SetDefault("webhooks.maxAttempts", 6)
+	v.SetDefault("webhooks.retryBackoff", "1m")
+	v.SetDefault("webhooks.maxRetryBackoff", "2h")
+	v.SetDefault("smtp.port", "587")
+	v.SetDefault("smtp.from", "Mastara <no-reply@localhost>")
+	v.SetDefault("notification.failureRate", 0.5)
+	v.SetDefault("notification.minRequests", 5)
+	v.SetDefault("notification.window", "1m")
+	v.SetDefault("notification.coolDown", "30s")
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.format", "json")
 }
@@ -129,14 +587,17 @@ func bindEnvs(v *viper.Viper, iface interface{}, parts ...string) error {
 
 // validateCriticalConfigs checks for the presence of essential configuration values.
 func validateCriticalConfigs(c *Config) error {
-	if c.Database.User == "" {
-		return fmt.Errorf("FATAL: Database user is not configured. Set DATABASE_USER environment variable")
+	if err := c.Database.validateConnection(); err != nil {
+		return err
+	}
+	if err := c.Database.validateReplica(); err != nil {
+		return err
 	}
-	if c.Database.Password == "" {
-		return fmt.Errorf("FATAL: Database password is not configured. Set DATABASE_PASSWORD environment variable")
+	if err := c.Database.validatePool(); err != nil {
+		return err
 	}
-	if c.Database.DBName == "" {
-		return fmt.Errorf("FATAL: Database name is not configured. Set DATABASE_DBNAME environment variable")
+	if _, err := c.Server.Concurrency.RouteLimits(); err != nil {
+		return err
 	}
 	if c.Security.PasetoKey == "" {
 		return fmt.Errorf("FATAL: PASETO key is not configured. Set SECURITY_PASETOKEY environment variable")
@@ -144,5 +605,44 @@ func validateCriticalConfigs(c *Config) error {
 	if len(c.Security.PasetoKey) != 32 {
 		return fmt.Errorf("FATAL: PASETO key must be exactly 32 characters long")
 	}
+	sealKeys, err := c.Security.SealKeySecrets()
+	if err != nil {
+		return err
+	}
+	if _, ok := sealKeys[c.Security.SealKeyVersion]; !ok {
+		return fmt.Errorf("FATAL: SECURITY_SEALKEYVERSION is %d but SECURITY_SEALKEYS has no v%d key", c.Security.SealKeyVersion, c.Security.SealKeyVersion)
+	}
+	switch c.Server.QueryParams {
+	case "off", "warn", "reject":
+	default:
+		return fmt.Errorf("FATAL: Unknown query parameter mode %q. Set SERVER_QUERYPARAMS to off, warn or reject", c.Server.QueryParams)
+	}
+	if c.Security.TokenMode == "public" && c.Security.PasetoPrivateKey == "" {
+		return fmt.Errorf("FATAL: PASETO private key is not configured for public token mode. Set SECURITY_PASETOPRIVATEKEY environment variable")
+	}
+	if c.Storage.Upload.ChunkSize <= 0 || c.Storage.Upload.ChunkSize > 1<<20 {
+		return fmt.Errorf("FATAL: Upload chunk size must be between 1 byte and 1 MiB. Check STORAGE_UPLOAD_CHUNKSIZE")
+	}
+	if c.Storage.Backend != "local" && c.Storage.Backend != "s3" {
+		return fmt.Errorf("FATAL: Unknown storage backend %q. Set STORAGE_BACKEND to local or s3", c.Storage.Backend)
+	}
+	if c.Storage.Documents.MaxSize <= 0 || c.Storage.SignedURLTTL <= 0 {
+		return fmt.Errorf("FATAL: Document size limit and signed URL TTL must be positive. Check STORAGE_DOCUMENTS_MAXSIZE and STORAGE_SIGNEDURLTTL")
+	}
+	if c.Booking.ReminderInterval <= 0 || c.Booking.ReminderBatchSize <= 0 {
+		return fmt.Errorf("FATAL: Reminder interval and batch size must be positive. Check BOOKING_REMINDERINTERVAL and BOOKING_REMINDERBATCHSIZE")
+	}
+	if c.Booking.OTPTTL <= 0 || c.Booking.OTPMaxAttempts <= 0 || c.Booking.OTPRequestLimit <= 0 || c.Booking.OTPRequestWindow <= 0 || c.Security.PhoneTokenDuration <= 0 {
+		return fmt.Errorf("FATAL: Phone verification TTL, attempts, request limit and window, and token duration must be positive. Check the BOOKING_OTP* settings and SECURITY_PHONETOKENDURATION")
+	}
+	if c.Outbox.PollInterval <= 0 || c.Outbox.BatchSize <= 0 || c.Outbox.MaxAttempts <= 0 || c.Outbox.RetryBackoff <= 0 {
+		return fmt.Errorf("FATAL: Outbox poll interval, batch size, max attempts and retry backoff must be positive. Check the OUTBOX_* settings")
+	}
+	if c.Webhooks.PollInterval <= 0 || c.Webhooks.BatchSize <= 0 || c.Webhooks.Timeout <= 0 || c.Webhooks.MaxAttempts <= 0 || c.Webhooks.RetryBackoff <= 0 {
+		return fmt.Errorf("FATAL: Webhook poll interval, batch size, timeout, max attempts and retry backoff must be positive. Check the WEBHOOKS_* settings")
+	}
+	if n := c.Notification; n.FailureRate <= 0 || n.FailureRate > 1 || n.MinRequests <= 0 || n.Window <= 0 || n.CoolDown <= 0 {
+		return fmt.Errorf("FATAL: Notification failure rate must be in (0, 1], and its minimum requests, window and cool-down must be positive. Check the NOTIFICATION_* settings")
+	}
 	return nil
 }
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// clinicFromContext returns the clinic the request acts for: an explicit database.WithClinic,
+// else the caller's clinic when authenticated, else the clinic a public route resolved from the host.
+func clinicFromContext(ctx context.Context) (uuid.UUID, bool) {
+	if clinicID, ok := database.ClinicFromContext(ctx); ok {
+		return clinicID, true
+	}
+	if payload, err := middleware.GetAuthPayload(ctx); err == nil && payload.ClinicID != uuid.Nil {
+		return payload.ClinicID, true
+	}
+	if clinicID, err := middleware.GetClinicID(ctx); err == nil && clinicID != uuid.Nil {
+		return clinicID, true
+	}
+	return uuid.Nil, false
+}
+
+// PlatformRole is the database role platform jobs assume for work across clinics (see
+// database.WithPlatformAccess). It has BYPASSRLS; migration 44 creates it.
+const PlatformRole = "mastara_platform"
+
+// ScopeConnections makes every connection cfg's pool hands out act for the acquiring context's
+// clinic: app.clinic_id is set to it, or cleared when the context has none, so queries run
+// straight on the pool see only that clinic's rows. NewProvider installs it on its pools.
+func ScopeConnections(cfg *pgxpool.Config) {
+	cfg.PrepareConn = scopeConnection
+}
+
+// scopeConnection is the PrepareConn hook installed by ScopeConnections. A failure is returned
+// to the caller; the connection stays in the pool and is scoped again on its next use.
+func scopeConnection(ctx context.Context, conn *pgx.Conn) (bool, error) {
+	var clinic string
+	if clinicID, ok := clinicFromContext(ctx); ok {
+		clinic = clinicID.String()
+	}
+	if _, err := conn.Exec(ctx, "SELECT set_config('app.clinic_id', $1, false)", clinic); err != nil {
+		return true, fmt.Errorf("failed to set clinic context: %w", err)
+	}
+	return true, nil
+}
+
+// ClinicScopedPool is a Querier for reads outside a transaction that row-level security
+// still applies to. Each statement runs on a connection with app.clinic_id set to the
+// request's clinic, reset before the connection goes back to the pool. Without a clinic
+// in the context it queries the pool directly.
+type ClinicScopedPool struct {
+	pool *pgxpool.Pool
+}
+
+var _ database.Querier = (*ClinicScopedPool)(nil)
+
+// NewClinicScopedPool wraps pool.
+func NewClinicScopedPool(pool *pgxpool.Pool) *ClinicScopedPool {
+	return &ClinicScopedPool{pool: pool}
+}
+
+// Exec implements database.Querier.
+func (p *ClinicScopedPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	conn, scoped, err := p.acquire(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	if !scoped {
+		return p.pool.Exec(ctx, sql, args...)
+	}
+	defer release(conn)
+	return conn.Exec(ctx, sql, args...)
+}
+
+// Query implements database.Querier. The connection is released when the rows are closed
+// or fully read.
+func (p *ClinicScopedPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	conn, scoped, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !scoped {
+		return p.pool.Query(ctx, sql, args...)
+	}
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		release(conn)
+		return nil, err
+	}
+	return &scopedRows{Rows: rows, conn: conn}, nil
+}
+
+// QueryRow implements database.Querier. The connection is released by Scan.
+func (p *ClinicScopedPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	conn, scoped, err := p.acquire(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	if !scoped {
+		return p.pool.QueryRow(ctx, sql, args...)
+	}
+	return &scopedRow{row: conn.QueryRow(ctx, sql, args...), conn: conn}
+}
+
+// acquire returns a connection scoped to the context's clinic, or scoped=false when the
+// context has none.
+func (p *ClinicScopedPool) acquire(ctx context.Context) (*pgxpool.Conn, bool, error) {
+	clinicID, ok := clinicFromContext(ctx)
+	if !ok {
+		return nil, false, nil
+	}
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	if _, err := conn.Exec(ctx, "SELECT set_config('app.clinic_id', $1, false)", clinicID.String()); err != nil {
+		release(conn)
+		return nil, false, fmt.Errorf("failed to set clinic context: %w", err)
+	}
+	return conn, true, nil
+}
+
+// release clears the clinic setting and returns conn to the pool. A connection whose setting
+// could not be cleared is closed rather than reused under the wrong clinic.
+func release(conn *pgxpool.Conn) {
+	if _, err := conn.Exec(context.Background(), "SELECT set_config('app.clinic_id', '', false)"); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset clinic context; discarding connection")
+		_ = conn.Hijack().Close(context.Background())
+		return
+	}
+	conn.Release()
+}
+
+// scopedRows releases its connection once the rows are done.
+type scopedRows struct {
+	pgx.Rows
+	conn *pgxpool.Conn
+}
+
+func (r *scopedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.Close()
+	return false
+}
+
+func (r *scopedRows) Close() {
+	r.Rows.Close()
+	if r.conn != nil {
+		release(r.conn)
+		r.conn = nil
+	}
+}
+
+// scopedRow releases its connection after Scan.
+type scopedRow struct {
+	row  pgx.Row
+	conn *pgxpool.Conn
+}
+
+func (r *scopedRow) Scan(dest ...any) error {
+	defer release(r.conn)
+	return r.row.Scan(dest...)
+}
+
+// errRow is a pgx.Row that failed before the query ran.
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error { return r.err }
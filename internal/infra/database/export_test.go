@@ -0,0 +1,15 @@
+package database
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxTxAttempts exposes the serialization retry limit to the external tests.
+const MaxTxAttempts = maxTxAttempts
+
+// NewQueryLogger exposes the statement tracer to the external tests.
+func NewQueryLogger(enabled bool, slowThreshold time.Duration) pgx.QueryTracer {
+	return &queryLogger{enabled: enabled, slowThreshold: slowThreshold}
+}
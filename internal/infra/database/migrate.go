@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// migrationLockID keys the advisory lock that serializes migration runs, so several instances
+// starting with auto-migrate enabled apply each migration once.
+const migrationLockID int64 = 0x6d617374617261 // "mastara"
+
+// migration is one numbered step, read from "<version>_<name>.up.sql" and its ".down.sql" pair.
+type migration struct {
+	version uint64
+	name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus describes how the database schema relates to the embedded migrations.
+type MigrationStatus struct {
+	// Current is the applied version; zero when no migration has run.
+	Current uint64
+	Dirty   bool
+	// Latest is the newest embedded version.
+	Latest uint64
+	// Pending lists the embedded versions newer than Current.
+	Pending []uint64
+}
+
+// Migrator applies the embedded SQL migrations. It keeps its state in the same schema_migrations
+// table golang-migrate uses, so databases migrated by either tool stay interchangeable.
+// Each step runs in its own transaction together with its version bump; a failed step rolls
+// back cleanly instead of leaving the schema dirty.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []migration
+}
+
+// NewMigrator reads every migration in migrationsFS. Each up file must have a matching down file.
+func NewMigrator(pool *pgxpool.Pool, migrationsFS fs.FS) (*Migrator, error) {
+	versions, err := EmbeddedMigrationVersions(migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(versions))
+	for _, v := range versions {
+		m, err := readMigration(migrationsFS, v)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+func readMigration(migrationsFS fs.FS, version uint64) (migration, error) {
+	ups, err := fs.Glob(migrationsFS, fmt.Sprintf("%06d_*.up.sql", version))
+	if err != nil || len(ups) != 1 {
+		return migration{}, fmt.Errorf("expected exactly one up migration for version %d, found %d", version, len(ups))
+	}
+	base := strings.TrimSuffix(ups[0], ".up.sql")
+
+	up, err := fs.ReadFile(migrationsFS, ups[0])
+	if err != nil {
+		return migration{}, fmt.Errorf("failed to read migration %s: %w", ups[0], err)
+	}
+	down, err := fs.ReadFile(migrationsFS, base+".down.sql")
+	if err != nil {
+		return migration{}, fmt.Errorf("failed to read down migration for %s: %w", base, err)
+	}
+
+	_, name, _ := strings.Cut(base, "_")
+	return migration{version: version, name: name, up: string(up), down: string(down)}, nil
+}
+
+// Status reports the applied version and which embedded migrations are still pending.
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if err := ensureMigrationsTable(ctx, conn.Conn()); err != nil {
+		return nil, err
+	}
+	current, dirty, err := readVersion(ctx, conn.Conn())
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Current: current, Dirty: dirty}
+	if len(m.migrations) > 0 {
+		status.Latest = m.migrations[len(m.migrations)-1].version
+	}
+	for _, mig := range m.migrations {
+		if mig.version > current {
+			status.Pending = append(status.Pending, mig.version)
+		}
+	}
+	return status, nil
+}
+
+// Up applies every pending migration in order and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *pgx.Conn, current uint64) error {
+		for _, mig := range m.migrations {
+			if mig.version <= current {
+				continue
+			}
+			if err := m.step(ctx, conn, mig.up, mig.version); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", mig.version, mig.name, err)
+			}
+			log.Info().Uint64("version", mig.version).Str("name", mig.name).Msg("Migration applied.")
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the newest steps applied migrations and returns how many were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps < 1 {
+		return 0, errors.New("the number of migrations to revert must be at least 1")
+	}
+
+	reverted := 0
+	err := m.locked(ctx, func(conn *pgx.Conn, current uint64) error {
+		for reverted < steps && current > 0 {
+			i := slices.IndexFunc(m.migrations, func(mig migration) bool { return mig.version == current })
+			if i < 0 {
+				return fmt.Errorf("database is at version %d, which this build does not contain", current)
+			}
+			var previous uint64
+			if i > 0 {
+				previous = m.migrations[i-1].version
+			}
+
+			mig := m.migrations[i]
+			if err := m.step(ctx, conn, mig.down, previous); err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", mig.version, mig.name, err)
+			}
+			log.Info().Uint64("version", mig.version).Str("name", mig.name).Msg("Migration reverted.")
+			current = previous
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// locked runs fn on a dedicated connection holding the migration advisory lock, after checking
+// the schema is not left dirty by an earlier failed run.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgx.Conn, current uint64) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		// The lock is session-scoped; release it even when ctx is already done.
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			log.Error().Err(err).Msg("Failed to release migration lock")
+		}
+	}()
+
+	if err := ensureMigrationsTable(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	current, dirty, err := readVersion(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("database schema is dirty at version %d; fix the failed migration and reset the dirty flag first", current)
+	}
+	return fn(conn.Conn(), current)
+}
+
+// step runs sql and records version in a single transaction. Version zero clears the table,
+// which is how golang-migrate records an empty schema.
+func (m *Migrator) step(ctx context.Context, conn *pgx.Conn, sql string, version uint64) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE schema_migrations`); err != nil {
+			return fmt.Errorf("failed to clear schema version: %w", err)
+		}
+		if version == 0 {
+			return nil
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, int64(version)); err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+		return nil
+	})
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func readVersion(ctx context.Context, conn *pgx.Conn) (uint64, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return uint64(version), dirty, nil
+}
+
+// FormatVersions renders versions as a comma-separated list for logs and CLI output.
+func FormatVersions(versions []uint64) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = strconv.FormatUint(v, 10)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// publicTables lists the tables in the public schema other than the migrator's own.
+func publicTables(t *testing.T, pool *pgxpool.Pool) []string {
+	t.Helper()
+	rows, err := pool.Query(context.Background(), `
+        SELECT table_name FROM information_schema.tables
+        WHERE table_schema = 'public' AND table_name <> 'schema_migrations'
+        ORDER BY table_name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tables
+}
+
+func TestMigrationsRunDownAndBackUp(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	migrator, err := database.NewMigrator(pool, migrations.FS)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	versions, err := database.EmbeddedMigrationVersions(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := versions[len(versions)-1]
+	migrated := publicTables(t, pool)
+	if !slices.Contains(migrated, "profiles") || !slices.Contains(migrated, "employees") {
+		t.Fatalf("tables after migrating = %v, want profiles and employees among them", migrated)
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Current != latest || status.Latest != latest || status.Dirty || len(status.Pending) != 0 {
+		t.Fatalf("status after dbtest migrated = %+v, want at %d with nothing pending", status, latest)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != 0 {
+		t.Fatalf("Up on a migrated database = %d, %v; want nothing applied", applied, err)
+	}
+
+	// One step down and back up leaves the schema as it was.
+	if reverted, err := migrator.Down(ctx, 1); err != nil || reverted != 1 {
+		t.Fatalf("Down(1) = %d, %v; want 1 reverted", reverted, err)
+	}
+	if status, err := migrator.Status(ctx); err != nil || !slices.Equal(status.Pending, []uint64{latest}) {
+		t.Fatalf("status after Down(1) = %+v, %v; want only %d pending", status, err, latest)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != 1 {
+		t.Fatalf("Up = %d, %v; want 1 applied", applied, err)
+	}
+
+	// Every down migration undoes its up: reverting them all empties the schema, and the ups
+	// rebuild it.
+	if reverted, err := migrator.Down(ctx, len(versions)); err != nil || reverted != len(versions) {
+		t.Fatalf("Down(all) = %d, %v; want %d reverted", reverted, err, len(versions))
+	}
+	if tables := publicTables(t, pool); len(tables) != 0 {
+		t.Errorf("tables left after reverting every migration: %v", tables)
+	}
+	if status, err := migrator.Status(ctx); err != nil || status.Current != 0 || len(status.Pending) != len(versions) {
+		t.Fatalf("status after Down(all) = %+v, %v; want version 0 with every migration pending", status, err)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != len(versions) {
+		t.Fatalf("Up = %d, %v; want %d applied", applied, err, len(versions))
+	}
+	if tables := publicTables(t, pool); !slices.Equal(tables, migrated) {
+		t.Errorf("tables after migrating again = %v, want %v", tables, migrated)
+	}
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// poolVars publishes every pool's counters under /debug/vars as "database_pool".
+var poolVars = expvar.NewMap("database_pool")
+
+// acquireWaitSamples is how many recent acquire waits the p95 is computed over.
+const acquireWaitSamples = 512
+
+// poolMonitor bounds and measures connection acquisition. It is installed among the pool's
+// tracers, so it sees every acquire, whether from Query on the pool, Begin or Acquire.
+type poolMonitor struct {
+	timeout    time.Duration
+	retryAfter time.Duration
+	busy       atomic.Int64
+
+	mu    sync.Mutex
+	waits [acquireWaitSamples]time.Duration
+	next  int
+	full  bool
+}
+
+var (
+	_ pgx.QueryTracer       = (*poolMonitor)(nil)
+	_ pgxpool.AcquireTracer = (*poolMonitor)(nil)
+)
+
+type acquireStartKey struct{}
+
+// TraceAcquireStart bounds the acquire by the configured timeout.
+func (m *poolMonitor) TraceAcquireStart(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireStartData) context.Context {
+	ctx = context.WithValue(ctx, acquireStartKey{}, time.Now())
+	if m.timeout <= 0 {
+		return ctx
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	return &acquireContext{
+		Context: timeoutCtx,
+		parent:  ctx,
+		cancel:  cancel,
+		busy:    &database.BusyError{Waited: m.timeout, RetryAfter: m.retryAfter},
+	}
+}
+
+// TraceAcquireEnd records how long the acquire waited and counts acquire timeouts.
+func (m *poolMonitor) TraceAcquireEnd(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	if ac, ok := ctx.(*acquireContext); ok {
+		ac.cancel()
+	}
+	start, _ := ctx.Value(acquireStartKey{}).(time.Time)
+	m.record(time.Since(start))
+
+	var busy *database.BusyError
+	if errors.As(data.Err, &busy) {
+		m.busy.Add(1)
+		stat := pool.Stat()
+		log.Warn().
+			Dur("waited", m.timeout).
+			Int32("acquired_conns", stat.AcquiredConns()).
+			Int32("max_conns", stat.MaxConns()).
+			Msg("Database connection pool exhausted")
+	}
+}
+
+// TraceQueryStart is a no-op; ConnConfig.Tracer must be a QueryTracer to be installed.
+func (m *poolMonitor) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+// TraceQueryEnd is a no-op.
+func (m *poolMonitor) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func (m *poolMonitor) record(wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.waits[m.next] = wait
+	m.next = (m.next + 1) % acquireWaitSamples
+	if m.next == 0 {
+		m.full = true
+	}
+}
+
+// waitP95 returns the 95th percentile of the recent acquire waits.
+func (m *poolMonitor) waitP95() time.Duration {
+	m.mu.Lock()
+	n := m.next
+	if m.full {
+		n = acquireWaitSamples
+	}
+	waits := slices.Clone(m.waits[:n])
+	m.mu.Unlock()
+
+	if len(waits) == 0 {
+		return 0
+	}
+	slices.Sort(waits)
+	return waits[(len(waits)*95-1)/100]
+}
+
+// publish registers the pool's metrics under name.
+func (m *poolMonitor) publish(name string, pool *pgxpool.Pool) {
+	poolVars.Set(name, expvar.Func(func() any {
+		stat := pool.Stat()
+		return map[string]int64{
+			"max_conns":           int64(stat.MaxConns()),
+			"acquired_conns":      int64(stat.AcquiredConns()),
+			"idle_conns":          int64(stat.IdleConns()),
+			"acquire_count":       stat.AcquireCount(),
+			"empty_acquire_count": stat.EmptyAcquireCount(),
+			"busy":                m.busy.Load(),
+			"acquire_wait_p95_ms": m.waitP95().Milliseconds(),
+		}
+	}))
+}
+
+// acquireContext is the context an acquire runs under. When its own timeout fires while the
+// caller's context is still live, Err reports a *database.BusyError instead of
+// context.DeadlineExceeded; the pool returns ctx.Err() as is, so the classification reaches
+// repositories and the TxManager already made, and nothing downstream has to guess.
+type acquireContext struct {
+	context.Context
+	parent context.Context
+	cancel context.CancelFunc
+	busy   *database.BusyError
+}
+
+// Err returns the caller's error if it has one, and the busy error if only the timeout fired.
+func (c *acquireContext) Err() error {
+	if err := c.parent.Err(); err != nil {
+		return err
+	}
+	if c.Context.Err() != nil {
+		return c.busy
+	}
+	return nil
+}
@@ -5,62 +5,190 @@ package database
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
 )
 
-// Provider holds the active database connection pool.
+// Provider holds the active database connection pools.
 // It is the central point for all database interactions.
 type Provider struct {
 	Pool *pgxpool.Pool
+	// ReadPool connects to the read replica; nil when none is configured. Use Reader, which
+	// falls back to Pool.
+	ReadPool *pgxpool.Pool
+
+	monitor         *poolMonitor
+	readMonitor     *poolMonitor
+	degradedWaitP95 time.Duration
 }
 
 // NewProvider creates and returns a new database provider.
-// It initializes the connection pool based on the provided configuration and performs
-// a health check to ensure the database is reachable before returning.
-// It will return a non-nil error if the connection cannot be established.
-func NewProvider(cfg config.DatabaseConfig) (*Provider, error) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.ConnectionString())
+// It initializes the connection pools based on the provided configuration and performs
+// a health check to ensure the databases are reachable before returning. An unreachable database
+// is retried up to cfg.ConnectRetries times with exponential backoff, so the API can start
+// alongside Postgres; it returns an error once the retries are exhausted or ctx is cancelled.
+func NewProvider(ctx context.Context, cfg config.DatabaseConfig) (*Provider, error) {
+	pool, monitor, err := newPool(ctx, cfg, cfg.ConnectionString())
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msg("Database connection pool established successfully.")
+	monitor.publish("primary", pool)
+	provider := &Provider{Pool: pool, monitor: monitor, degradedWaitP95: cfg.DegradedAcquireWaitP95}
+
+	if replica := cfg.ReplicaConnectionString(); replica != "" {
+		readPool, readMonitor, err := newPool(ctx, cfg, replica)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("read replica: %w", err)
+		}
+		log.Info().Msg("Read replica connection pool established successfully.")
+		readMonitor.publish("replica", readPool)
+		provider.ReadPool, provider.readMonitor = readPool, readMonitor
+	}
+	return provider, nil
+}
+
+// newPool opens a pool to connString with the configured limits and tracers and waits until it answers.
+func newPool(ctx context.Context, cfg config.DatabaseConfig, connString string) (*pgxpool.Pool, *poolMonitor, error) {
+	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse database config: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse database config: %w", err)
 	}
 
 	poolConfig.MaxConns = int32(cfg.MaxOpenConns)
 	poolConfig.MinConns = int32(cfg.MaxIdleConns)
 	poolConfig.MaxConnIdleTime = cfg.ConnMaxIdleTime
 	poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+	// Sessions run in UTC so NOW()-derived values and DATE/TIMESTAMP casts never depend on the server's zone.
+	poolConfig.ConnConfig.RuntimeParams["timezone"] = "UTC"
+	ScopeConnections(poolConfig)
+	// Waiting for a connection is bounded separately from running a statement, so a saturated
+	// pool fails fast with a retryable 503 instead of holding requests until they time out.
+	monitor := &poolMonitor{timeout: cfg.AcquireTimeout, retryAfter: cfg.BusyRetryAfter}
+	queries := &queryLogger{enabled: cfg.LogQueries, slowThreshold: cfg.SlowQueryThreshold}
+	poolConfig.ConnConfig.Tracer = multitracer.New(monitor, queries)
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create database connection pool: %w", err)
+		return nil, nil, fmt.Errorf("failed to create database connection pool: %w", err)
 	}
 
-	pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := pool.Ping(pingCtx); err != nil {
+	if err := pingWithRetry(ctx, pool, cfg.ConnectRetries, cfg.ConnectBackoff); err != nil {
 		pool.Close()
-		return nil, fmt.Errorf("failed to ping database on startup: %w", err)
+		return nil, nil, err
 	}
+	return pool, monitor, nil
+}
 
-	log.Info().Msg("Database connection pool established successfully.")
+// ReaderPool returns the read replica pool, or the primary pool when no replica is configured.
+func (p *Provider) ReaderPool() *pgxpool.Pool {
+	if p.ReadPool != nil {
+		return p.ReadPool
+	}
+	return p.Pool
+}
+
+// Reader returns the querier for read-only queries that tolerate replication lag, such as
+// lists, searches and reports. Requests from a client that has just written read from the
+// primary instead (see middleware.ReadYourWrites). Reads that must see a write made in the
+// same request belong on Pool.
+func (p *Provider) Reader() database.Querier {
+	if p.ReadPool == nil {
+		return p.Pool
+	}
+	return NewReadRouter(p.Pool, p.ReadPool)
+}
 
-	return &Provider{Pool: pool}, nil
+// ClinicScopedReader is Reader for tables under row-level security; see ClinicScopedPool.
+func (p *Provider) ClinicScopedReader() database.Querier {
+	if p.ReadPool == nil {
+		return NewClinicScopedPool(p.Pool)
+	}
+	return NewReadRouter(NewClinicScopedPool(p.Pool), NewClinicScopedPool(p.ReadPool))
+}
+
+// maxConnectBackoff caps the wait between startup connection attempts.
+const maxConnectBackoff = 30 * time.Second
+
+// pingWithRetry pings the database, retrying failures up to retries more times. The wait starts
+// at backoff, doubles per attempt up to maxConnectBackoff and is jittered by ±20% so a fleet
+// restarting together does not reconnect in lockstep.
+func pingWithRetry(ctx context.Context, pool *pgxpool.Pool, retries int, backoff time.Duration) error {
+	wait := backoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := pool.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt > retries {
+			return fmt.Errorf("failed to ping database on startup after %d attempt(s): %w", attempt, err)
+		}
+
+		jittered := wait + time.Duration((rand.Float64()*0.4-0.2)*float64(wait))
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Int("max_attempts", retries+1).
+			Dur("retry_in", jittered).
+			Msg("Database is not reachable yet, retrying")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up connecting to the database: %w", ctx.Err())
+		case <-time.After(jittered):
+		}
+		wait = min(wait*2, maxConnectBackoff)
+	}
 }
 
-// HealthCheck performs a simple query to verify the database connection is alive.
+// HealthCheck performs a simple query to verify the database connections are alive.
 func (p *Provider) HealthCheck(ctx context.Context) error {
 	if err := p.Pool.Ping(ctx); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
+	if p.ReadPool != nil {
+		if err := p.ReadPool.Ping(ctx); err != nil {
+			return fmt.Errorf("read replica health check failed: %w", err)
+		}
+	}
 	return nil
 }
 
-// Close gracefully terminates the database connection pool.
+// HasReplica reports whether reads are served by a separate replica pool.
+func (p *Provider) HasReplica() bool {
+	return p.ReadPool != nil
+}
+
+// AcquireWaitP95 returns the 95th percentile of recent connection acquire waits, the
+// higher of the two pools when a replica is configured.
+func (p *Provider) AcquireWaitP95() time.Duration {
+	wait := p.monitor.waitP95()
+	if p.readMonitor != nil {
+		wait = max(wait, p.readMonitor.waitP95())
+	}
+	return wait
+}
+
+// Degraded reports whether requests are queueing for connections long enough that the
+// instance should stop receiving new traffic until the pool drains.
+func (p *Provider) Degraded() bool {
+	return p.degradedWaitP95 > 0 && p.AcquireWaitP95() > p.degradedWaitP95
+}
+
+// Close gracefully terminates the database connection pools.
 func (p *Provider) Close() {
 	log.Info().Msg("Closing database connection pool.")
 	p.Pool.Close()
+	if p.ReadPool != nil {
+		p.ReadPool.Close()
+	}
 }
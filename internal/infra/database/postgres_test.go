@@ -0,0 +1,80 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// closedPortConfig points at a local port nothing listens on, so every connection is refused at once.
+func closedPortConfig(t *testing.T, retries int, backoff time.Duration) config.DatabaseConfig {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return config.DatabaseConfig{
+		URL:             "postgres://mastara:secret@" + addr + "/mastara?sslmode=disable&connect_timeout=1",
+		MaxOpenConns:    1,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: time.Minute,
+		ConnectRetries:  retries,
+		ConnectBackoff:  backoff,
+	}
+}
+
+// captureLog sends the global logger's output to the returned buffer for the rest of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var logs bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&logs)
+	t.Cleanup(func() { log.Logger = previous })
+	return &logs
+}
+
+func TestNewProviderRetriesAnUnreachableDatabaseWithBackoff(t *testing.T) {
+	logs := captureLog(t)
+
+	start := time.Now()
+	_, err := database.NewProvider(context.Background(), closedPortConfig(t, 3, 50*time.Millisecond))
+	elapsed := time.Since(start)
+
+	if err == nil || !strings.Contains(err.Error(), "after 4 attempt(s)") {
+		t.Fatalf("NewProvider error = %v, want it to give up after 4 attempts", err)
+	}
+	if retries := strings.Count(logs.String(), "Database is not reachable yet"); retries != 3 {
+		t.Errorf("logged %d retries, want 3", retries)
+	}
+	// Waits of 50, 100 and 200ms, each jittered by up to ±20%.
+	if elapsed < 280*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("gave up after %s, want about 350ms of backoff", elapsed)
+	}
+}
+
+func TestNewProviderStopsRetryingWhenItsContextIsCancelled(t *testing.T) {
+	captureLog(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := database.NewProvider(ctx, closedPortConfig(t, 100, time.Minute))
+	elapsed := time.Since(start)
+
+	if err == nil || ctx.Err() == nil {
+		t.Fatalf("NewProvider error = %v, want it to give up with its context", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("NewProvider returned %s after its context was cancelled, want at once", elapsed-100*time.Millisecond)
+	}
+}
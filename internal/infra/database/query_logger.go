@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// maxLoggedSQLLength caps the statement text in log events; migrations and large CTEs are long.
+const maxLoggedSQLLength = 500
+
+// queryLogger logs statements through zerolog. Only the argument count is logged, never the
+// values, which routinely hold patient data.
+//   - enabled: every statement at debug level and failed statements at error level.
+//   - slowThreshold: statements that take longer are logged at warn level even when disabled;
+//     zero turns slow query logging off.
+type queryLogger struct {
+	enabled       bool
+	slowThreshold time.Duration
+}
+
+var _ pgx.QueryTracer = (*queryLogger)(nil)
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql      string
+	argCount int
+	at       time.Time
+}
+
+// TraceQueryStart remembers the statement and when it started.
+func (q *queryLogger) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, argCount: len(data.Args), at: time.Now()})
+}
+
+// TraceQueryEnd logs the statement at the level its outcome and duration call for.
+func (q *queryLogger) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(start.at)
+	slow := q.slowThreshold > 0 && duration > q.slowThreshold
+
+	var event *zerolog.Event
+	logger := contextLogger(ctx)
+	switch {
+	case data.Err != nil && q.enabled:
+		event = logger.Error().Err(data.Err)
+	case slow:
+		event = logger.Warn().Dur("slow_query_threshold", q.slowThreshold)
+	case q.enabled:
+		event = logger.Debug()
+	default:
+		return
+	}
+
+	event.
+		Str("sql", truncateSQL(start.sql)).
+		Int("args", start.argCount).
+		Dur("duration", duration).
+		Int64("rows_affected", data.CommandTag.RowsAffected()).
+		Msg("Database query")
+}
+
+// contextLogger prefers the request-scoped logger, which carries the request ID.
+func contextLogger(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &log.Logger
+}
+
+// truncateSQL collapses whitespace so a statement fits on one log line and caps its length.
+func truncateSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxLoggedSQLLength {
+		return sql[:maxLoggedSQLLength] + "…"
+	}
+	return sql
+}
@@ -0,0 +1,92 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+)
+
+// traceQuery runs one statement taking duration through tracer and returns the events it logged.
+func traceQuery(t *testing.T, tracer pgx.QueryTracer, duration time.Duration, err error) []map[string]any {
+	t.Helper()
+	var logs bytes.Buffer
+	ctx := zerolog.New(&logs).WithContext(context.Background())
+
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+		SQL:  "SELECT id\n        FROM profiles\n        WHERE phone_number = $1",
+		Args: []any{"+201012345678"},
+	})
+	time.Sleep(duration)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1"), Err: err})
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestSlowQueriesAreLoggedAtWarnEvenWithQueryLoggingOff(t *testing.T) {
+	events := traceQuery(t, database.NewQueryLogger(false, 5*time.Millisecond), 20*time.Millisecond, nil)
+	if len(events) != 1 {
+		t.Fatalf("logged %d event(s) for a slow query, want 1", len(events))
+	}
+	event := events[0]
+	if event["level"] != "warn" {
+		t.Errorf("level = %v, want warn", event["level"])
+	}
+	if event["sql"] != "SELECT id FROM profiles WHERE phone_number = $1" || event["args"] != float64(1) || event["rows_affected"] != float64(1) {
+		t.Errorf("event = %v, want the one-line SQL, its argument count and the rows affected", event)
+	}
+	if duration, _ := event["duration"].(float64); duration < 20 {
+		t.Errorf("duration = %v ms, want at least the 20ms the query took", event["duration"])
+	}
+	if line, _ := json.Marshal(event); strings.Contains(string(line), "201012345678") {
+		t.Errorf("event %s holds an argument value", line)
+	}
+}
+
+func TestQueryLoggingLevels(t *testing.T) {
+	failed := errors.New("relation \"users\" does not exist")
+	tests := []struct {
+		name      string
+		enabled   bool
+		err       error
+		wantLevel string
+	}{
+		{"off and fast", false, nil, ""},
+		{"off and failing", false, failed, ""},
+		{"on", true, nil, "debug"},
+		{"on and failing", true, failed, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := traceQuery(t, database.NewQueryLogger(tt.enabled, time.Minute), 0, tt.err)
+			if tt.wantLevel == "" {
+				if len(events) != 0 {
+					t.Errorf("logged %v, want nothing", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0]["level"] != tt.wantLevel {
+				t.Errorf("logged %v, want one %s event", events, tt.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ReadRouter is a Querier for reads that tolerate replication lag. It sends each statement
+// to the replica unless the context asks for primary reads (database.WithPrimaryReads), as
+// it does for a client that has just written.
+type ReadRouter struct {
+	primary, replica database.Querier
+}
+
+var _ database.Querier = (*ReadRouter)(nil)
+
+// NewReadRouter routes reads between primary and replica.
+func NewReadRouter(primary, replica database.Querier) *ReadRouter {
+	return &ReadRouter{primary: primary, replica: replica}
+}
+
+// Exec implements database.Querier.
+func (r *ReadRouter) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return r.pick(ctx).Exec(ctx, sql, args...)
+}
+
+// Query implements database.Querier.
+func (r *ReadRouter) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.pick(ctx).Query(ctx, sql, args...)
+}
+
+// QueryRow implements database.Querier.
+func (r *ReadRouter) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.pick(ctx).QueryRow(ctx, sql, args...)
+}
+
+func (r *ReadRouter) pick(ctx context.Context) database.Querier {
+	if database.PrimaryReads(ctx) {
+		return r.primary
+	}
+	return r.replica
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// store is a Querier over a set of names. Its rows are never read; QueryRow reports whether
+// the name passed as the first argument is present.
+type store struct {
+	database.Querier
+	names map[string]bool
+}
+
+func (s *store) QueryRow(_ context.Context, _ string, args ...any) pgx.Row {
+	if s.names[args[0].(string)] {
+		return errRow{}
+	}
+	return errRow{err: pgx.ErrNoRows}
+}
+
+// TestReadRouterHidesReplicaLagFromTheWriter simulates a replica that has not replayed a write
+// yet: the client that wrote reads it back from the primary, while other clients keep reading
+// from the replica.
+func TestReadRouterHidesReplicaLagFromTheWriter(t *testing.T) {
+	primary := &store{names: map[string]bool{}}
+	replica := &store{names: map[string]bool{}}
+	reader := NewReadRouter(primary, replica)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.ReadYourWrites([]byte("test-key"), time.Minute))
+	engine.POST("/patients/:name", func(c *gin.Context) {
+		primary.names[c.Param("name")] = true
+		c.Status(http.StatusCreated)
+	})
+	engine.GET("/patients/:name", func(c *gin.Context) {
+		err := reader.QueryRow(c.Request.Context(), "SELECT 1 FROM patients WHERE name = $1", c.Param("name")).Scan()
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	write := httptest.NewRecorder()
+	engine.ServeHTTP(write, httptest.NewRequest(http.MethodPost, "/patients/amira", nil))
+	token := write.Header().Get(middleware.PrimaryUntilHeader)
+
+	read := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/patients/amira", nil)
+		if token != "" {
+			req.Header.Set(middleware.PrimaryUntilHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := read(token); code != http.StatusOK {
+		t.Errorf("the writer read back %d, want %d from the primary", code, http.StatusOK)
+	}
+	if code := read(""); code != http.StatusNotFound {
+		t.Errorf("another client read %d, want %d from the lagging replica", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,146 @@
+package database_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	shared "github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// apiRole stands in for the role the API connects as. The test server's user is a superuser,
+// which row-level security never applies to.
+const apiRole = "mastara_rls_test"
+
+// apiPool returns a pool on admin's database whose sessions run as apiRole.
+func apiPool(t *testing.T, admin *pgxpool.Pool) *pgxpool.Pool {
+	t.Helper()
+	ctx := context.Background()
+	_, err := admin.Exec(ctx, `
+        DO $$
+        BEGIN
+            CREATE ROLE `+apiRole+` NOLOGIN;
+        EXCEPTION WHEN duplicate_object THEN NULL;
+        END
+        $$`)
+	if err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+	if _, err := admin.Exec(ctx, `GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO `+apiRole); err != nil {
+		t.Fatalf("failed to grant table privileges: %v", err)
+	}
+
+	cfg := admin.Config()
+	cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "SET ROLE "+apiRole)
+		return err
+	}
+	database.ScopeConnections(cfg)
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func createProfile(t *testing.T, pool *pgxpool.Pool, clinicID uuid.UUID) uuid.UUID {
+	t.Helper()
+	id := uuid.Must(uuid.NewV7())
+	_, err := pool.Exec(context.Background(), `
+        INSERT INTO profiles (id, clinic_id, full_name, phone_number, profile_status)
+        VALUES ($1, $2, 'Mona Adel', '+201012345678', 'GUEST')`, id, clinicID)
+	if err != nil {
+		t.Fatalf("failed to create profile: %v", err)
+	}
+	return id
+}
+
+func visibleProfiles(t *testing.T, ctx context.Context, querier shared.Querier) []uuid.UUID {
+	t.Helper()
+	rows, err := querier.Query(ctx, `SELECT id FROM profiles ORDER BY id`)
+	if err != nil {
+		t.Fatalf("failed to query profiles: %v", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		t.Fatalf("failed to read profiles: %v", err)
+	}
+	return ids
+}
+
+// TestClinicIsolation checks that the API's connections only ever see and write the rows of
+// the clinic they act for, none at all outside a clinic, and that only platform access spans
+// clinics.
+func TestClinicIsolation(t *testing.T) {
+	admin := dbtest.New(t)
+	clinicA, clinicB := dbtest.CreateClinic(t, admin), dbtest.CreateClinic(t, admin)
+	profileA, profileB := createProfile(t, admin, clinicA), createProfile(t, admin, clinicB)
+	pool := apiPool(t, admin)
+	txManager := database.NewTxManager(pool)
+
+	background := context.Background()
+	asClinicA := shared.WithClinic(background, clinicA)
+	platform := shared.WithPlatformAccess(background)
+
+	inTx := func(ctx context.Context) []uuid.UUID {
+		var ids []uuid.UUID
+		err := txManager.ExecTx(ctx, func(tx pgx.Tx) error {
+			ids = visibleProfiles(t, ctx, tx)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExecTx: %v", err)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name string
+		got  []uuid.UUID
+		want []uuid.UUID
+	}{
+		{"pool without a clinic", visibleProfiles(t, background, pool), nil},
+		{"pool as clinic A", visibleProfiles(t, asClinicA, pool), []uuid.UUID{profileA}},
+		{"transaction without a clinic", inTx(background), nil},
+		{"transaction as clinic A", inTx(asClinicA), []uuid.UUID{profileA}},
+		{"platform transaction", inTx(platform), []uuid.UUID{profileA, profileB}},
+		// The platform role ends with its transaction.
+		{"pool after a platform transaction", visibleProfiles(t, background, pool), nil},
+	}
+	for _, tt := range tests {
+		if !slices.Equal(tt.got, tt.want) {
+			t.Errorf("%s: visible profiles = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	err := txManager.ExecTx(asClinicA, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(asClinicA, `UPDATE profiles SET full_name = 'Changed' WHERE id = $1`, profileB)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() != 0 {
+			t.Errorf("clinic A updated %d of clinic B's profiles", tag.RowsAffected())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExecTx: %v", err)
+	}
+
+	err = txManager.ExecTx(asClinicA, func(tx pgx.Tx) error {
+		_, err := tx.Exec(asClinicA, `
+            INSERT INTO profiles (clinic_id, full_name, phone_number, profile_status)
+            VALUES ($1, 'Planted', '+201098765432', 'GUEST')`, clinicB)
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "row-level security") {
+		t.Errorf("clinic A inserting a profile into clinic B: error = %v, want a row-level security violation", err)
+	}
+}
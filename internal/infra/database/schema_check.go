@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// criticalTables are probed at boot so a missing table fails startup instead of the first request.
+var criticalTables = []string{"clinics", "profiles", "employees", "roles"}
+
+// EmbeddedMigrationVersions returns the sorted versions of the *.up.sql files in migrationsFS.
+func EmbeddedMigrationVersions(migrationsFS fs.FS) ([]uint64, error) {
+	names, err := fs.Glob(migrationsFS, "*.up.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embedded migrations: %w", err)
+	}
+
+	versions := make([]uint64, 0, len(names))
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("malformed migration file name %q", name)
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed migration version in %q: %w", name, err)
+		}
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	return versions, nil
+}
+
+// VerifySchema compares the database schema version recorded by golang-migrate with the
+// migrations embedded in the binary and probes the critical tables.
+//   - DB behind the binary: always an error naming the missing versions.
+//   - DB dirty (a failed migration): always an error.
+//   - DB ahead of the binary (older binary, newer schema): a warning, or an error when failOnAhead is set.
+func VerifySchema(ctx context.Context, pool *pgxpool.Pool, migrationsFS fs.FS, failOnAhead bool) error {
+	versions, err := EmbeddedMigrationVersions(migrationsFS)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return errors.New("no embedded migrations found")
+	}
+	expected := versions[len(versions)-1]
+
+	var current uint64
+	var dirty bool
+	err = pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&current, &dirty)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to read schema_migrations (have migrations been run?): %w", err)
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("database schema is dirty at version %d; fix the failed migration before starting", current)
+	case current < expected:
+		var missing []uint64
+		for _, v := range versions {
+			if v > current {
+				missing = append(missing, v)
+			}
+		}
+		return fmt.Errorf("database schema is at version %d but this build requires %d; missing migrations: %s",
+			current, expected, FormatVersions(missing))
+	case current > expected:
+		msg := fmt.Sprintf("database schema is at version %d, ahead of this build (%d)", current, expected)
+		if failOnAhead {
+			return errors.New(msg)
+		}
+		log.Warn().Uint64("db_version", current).Uint64("build_version", expected).Msg(msg)
+	}
+
+	for _, table := range criticalTables {
+		if _, err := pool.Exec(ctx, fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", pgx.Identifier{table}.Sanitize())); err != nil {
+			return fmt.Errorf("canary query against table %q failed: %w", table, err)
+		}
+	}
+
+	log.Info().Uint64("schema_version", current).Msg("Database schema verified.")
+	return nil
+}
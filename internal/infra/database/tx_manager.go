@@ -3,7 +3,10 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
@@ -23,16 +26,88 @@ func NewTxManager(pool *pgxpool.Pool) database.TxManager {
 }
 
 type auditContextPayload struct {
-	UserID   string `json:"user_id"`
-	ClinicID string `json:"clinic_id"`
+	UserID             string `json:"user_id"`
+	ClinicID           string `json:"clinic_id"`
+	ImpersonatedUserID string `json:"impersonated_user_id,omitempty"`
 }
 
+// Serialization failures are retried up to maxTxAttempts times in total, waiting
+// txRetryBackoff times the attempt number, plus up to as much again in jitter, in between.
+const (
+	maxTxAttempts  = 3
+	txRetryBackoff = 10 * time.Millisecond
+)
+
 func (m *pgxTxManager) ExecTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
-	tx, err := m.pool.Begin(ctx)
+	return m.ExecTxOpts(ctx, pgx.TxOptions{}, fn)
+}
+
+// ExecTxOpts implements database.TxManager. Each attempt is a complete transaction: the audit
+// context is set again and post-commit hooks registered by failed attempts are dropped.
+func (m *pgxTxManager) ExecTxOpts(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	if outer, ok := database.TxFromContext(ctx); ok {
+		return m.execSavepoint(ctx, outer, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.execTxOnce(ctx, opts, fn)
+		if err == nil || attempt == maxTxAttempts || !database.SerializationFailure(err) {
+			return err
+		}
+
+		wait := txRetryBackoff * time.Duration(attempt)
+		wait += rand.N(wait)
+		contextLogger(ctx).Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Str("isolation_level", string(opts.IsoLevel)).
+			Dur("retry_in", wait).
+			Msg("tx_manager: transaction aborted by a serialization failure, retrying")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// execSavepoint runs fn in a savepoint of outer. The outer transaction's isolation level and
+// access mode apply, and serialization failures are not retried here: they abort the whole
+// transaction, so only the outermost ExecTx can retry them.
+func (m *pgxTxManager) execSavepoint(ctx context.Context, outer pgx.Tx, fn func(tx pgx.Tx) error) error {
+	sp, err := outer.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("tx_manager: failed to create savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sp.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(sp); err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("tx_manager: failed to roll back savepoint: %w", rbErr))
+		}
+		return err
+	}
+	if err := sp.Commit(ctx); err != nil {
+		return fmt.Errorf("tx_manager: failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+func (m *pgxTxManager) execTxOnce(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := m.pool.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("tx_manager: failed to begin transaction: %w", err)
 	}
 
+	htx := &hookedTx{Tx: tx}
+
 	// 1. SAFETY NET (DEFER)
 	// Only responsible for Rollback in case of Panic or Early Exit.
 	defer func() {
@@ -52,16 +127,22 @@ func (m *pgxTxManager) ExecTx(ctx context.Context, fn func(tx pgx.Tx) error) err
 	// 2. AUDIT CONTEXT INJECTION
 	// Strict check: Only proceed if system user, or if injection works.
 	if payload, authErr := middleware.GetAuthPayload(ctx); authErr == nil {
-		auditJSON, err := json.Marshal(auditContextPayload{
-			UserID:   payload.UserID.String(),
+		// During impersonation the audit trail must name the real operator.
+		auditCtx := auditContextPayload{
+			UserID:   payload.ActorID().String(),
 			ClinicID: payload.ClinicID.String(),
-		})
+		}
+		if payload.IsImpersonated() {
+			auditCtx.ImpersonatedUserID = payload.UserID.String()
+		}
+		auditJSON, err := json.Marshal(auditCtx)
 		if err != nil {
 			return fmt.Errorf("tx_manager: failed to marshal audit context: %w", err)
 		}
 
 		// STRICT SECURITY: Do not swallow error here.
-		if _, err := tx.Exec(ctx, "SET LOCAL app.audit_context = $1", string(auditJSON)); err != nil {
+		// set_config(..., true) is SET LOCAL; SET itself cannot take bind parameters.
+		if _, err := tx.Exec(ctx, "SELECT set_config('app.audit_context', $1, true)", string(auditJSON)); err != nil {
 			return fmt.Errorf("tx_manager: failed to set audit context (audit log integrity risk): %w", err)
 		}
 	} else {
@@ -69,16 +150,94 @@ func (m *pgxTxManager) ExecTx(ctx context.Context, fn func(tx pgx.Tx) error) err
 		log.Trace().Msg("tx_manager: executing transaction without user context")
 	}
 
-	// 3. EXECUTE BUSINESS LOGIC
-	if err := fn(tx); err != nil {
+	// 3. CLINIC CONTEXT INJECTION
+	// Row-level security policies hide every row outside the clinic in app.clinic_id, and all
+	// rows when it is unset. Platform jobs switch to the platform role, which bypasses them.
+	if database.PlatformAccess(ctx) {
+		if _, err := tx.Exec(ctx, "SELECT set_config('role', $1, true)", PlatformRole); err != nil {
+			return fmt.Errorf("tx_manager: failed to assume the platform role: %w", err)
+		}
+	} else if clinicID, ok := clinicFromContext(ctx); ok {
+		if _, err := tx.Exec(ctx, "SELECT set_config('app.clinic_id', $1, true)", clinicID.String()); err != nil {
+			return fmt.Errorf("tx_manager: failed to set clinic context: %w", err)
+		}
+	}
+
+	// 4. EXECUTE BUSINESS LOGIC
+	if err := fn(htx); err != nil {
 		return err // Returns original error, Defer triggers Rollback
 	}
 
-	// 4. EXPLICIT COMMIT
+	// 5. EXPLICIT COMMIT
 	// We handle the happy path explicitly for readability
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("tx_manager: failed to commit transaction: %w", err)
 	}
 
+	// 6. POST-COMMIT HOOKS
+	htx.runHooks()
+
 	return nil
 }
+
+// AfterCommit implements database.TxManager.
+func (m *pgxTxManager) AfterCommit(tx pgx.Tx, fn func()) {
+	htx, ok := tx.(*hookedTx)
+	if !ok {
+		panic("tx_manager: AfterCommit called with a transaction not started by ExecTx")
+	}
+	htx.hooks = append(htx.hooks, fn)
+}
+
+// hookedTx wraps the transaction handed to ExecTx closures and collects post-commit callbacks.
+// Savepoints opened with Begin get their own hookedTx whose callbacks are handed to the parent
+// on release and dropped on rollback.
+type hookedTx struct {
+	pgx.Tx
+	parent *hookedTx
+	hooks  []func()
+}
+
+// Begin opens a savepoint that tracks its own post-commit callbacks.
+func (t *hookedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	child, err := t.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedTx{Tx: child, parent: t}, nil
+}
+
+// Commit releases a savepoint and promotes its callbacks to the enclosing transaction.
+func (t *hookedTx) Commit(ctx context.Context) error {
+	if err := t.Tx.Commit(ctx); err != nil {
+		return err
+	}
+	if t.parent != nil {
+		t.parent.hooks = append(t.parent.hooks, t.hooks...)
+	}
+	t.hooks = nil
+	return nil
+}
+
+// Rollback discards the callbacks registered since the transaction or savepoint began.
+func (t *hookedTx) Rollback(ctx context.Context) error {
+	t.hooks = nil
+	return t.Tx.Rollback(ctx)
+}
+
+// runHooks executes the callbacks in registration order. A panicking callback is logged and
+// does not prevent the remaining ones from running; the transaction is already committed.
+func (t *hookedTx) runHooks() {
+	hooks := t.hooks
+	t.hooks = nil
+	for _, fn := range hooks {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Error().Msgf("tx_manager: panic recovered in after-commit hook: %v", p)
+				}
+			}()
+			fn()
+		}()
+	}
+}
@@ -0,0 +1,295 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	shared "github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// raiseSerializationFailure aborts the transaction the way a conflicting serializable
+// transaction would.
+const raiseSerializationFailure = `DO $$ BEGIN RAISE EXCEPTION 'conflict' USING ERRCODE = 'serialization_failure'; END $$`
+
+// txTestPool returns a pool on a fresh database with a scratch table, tx_rows.
+func txTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	pool := dbtest.New(t)
+	if _, err := pool.Exec(context.Background(), `CREATE TABLE tx_rows (id int PRIMARY KEY)`); err != nil {
+		t.Fatalf("failed to create scratch table: %v", err)
+	}
+	return pool
+}
+
+func insertRow(ctx context.Context, tx pgx.Tx, id int) error {
+	_, err := tx.Exec(ctx, `INSERT INTO tx_rows (id) VALUES ($1)`, id)
+	return err
+}
+
+// storedRows returns the committed rows of tx_rows in order.
+func storedRows(t *testing.T, pool *pgxpool.Pool) []int {
+	t.Helper()
+	rows, err := pool.Query(context.Background(), `SELECT id FROM tx_rows ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestExecTxOptsRetriesSerializationFailures(t *testing.T) {
+	pool := txTestPool(t)
+	txm := database.NewTxManager(pool)
+	ctx := context.Background()
+	serializable := pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+	attempts := 0
+	err := txm.ExecTxOpts(ctx, serializable, func(tx pgx.Tx) error {
+		attempts++
+		if err := insertRow(ctx, tx, attempts); err != nil {
+			return err
+		}
+		if attempts < database.MaxTxAttempts {
+			_, err := tx.Exec(ctx, raiseSerializationFailure)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExecTxOpts: %v", err)
+	}
+	if attempts != database.MaxTxAttempts {
+		t.Errorf("fn ran %d times, want %d", attempts, database.MaxTxAttempts)
+	}
+	// Only the attempt that committed left a row.
+	if got := storedRows(t, pool); !slices.Equal(got, []int{database.MaxTxAttempts}) {
+		t.Errorf("stored rows = %v, want [%d]", got, database.MaxTxAttempts)
+	}
+}
+
+func TestExecTxOptsGivesUpAfterTheRetryLimit(t *testing.T) {
+	pool := txTestPool(t)
+	txm := database.NewTxManager(pool)
+	ctx := context.Background()
+
+	attempts := 0
+	err := txm.ExecTxOpts(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+		attempts++
+		_, err := tx.Exec(ctx, raiseSerializationFailure)
+		return err
+	})
+	if !shared.SerializationFailure(err) {
+		t.Fatalf("ExecTxOpts error = %v, want the serialization failure", err)
+	}
+	if attempts != database.MaxTxAttempts {
+		t.Errorf("fn ran %d times, want %d", attempts, database.MaxTxAttempts)
+	}
+
+	// Other errors are returned at once.
+	attempts = 0
+	errOther := errors.New("not a conflict")
+	err = txm.ExecTx(ctx, func(pgx.Tx) error {
+		attempts++
+		return errOther
+	})
+	if !errors.Is(err, errOther) || attempts != 1 {
+		t.Errorf("ExecTx = %v after %d attempt(s), want %v after 1", err, attempts, errOther)
+	}
+}
+
+func TestNestedExecTxRollsBackOnlyItsSavepoint(t *testing.T) {
+	pool := txTestPool(t)
+	txm := database.NewTxManager(pool)
+	ctx := context.Background()
+
+	err := txm.ExecTx(ctx, func(tx pgx.Tx) error {
+		if err := insertRow(ctx, tx, 1); err != nil {
+			return err
+		}
+		// The inner insert fails in the database, which would abort a transaction without
+		// the savepoint, and the row it wrote before that goes with it.
+		innerErr := txm.ExecTx(shared.WithTx(ctx, tx), func(sp pgx.Tx) error {
+			if err := insertRow(ctx, sp, 2); err != nil {
+				return err
+			}
+			return insertRow(ctx, sp, 1)
+		})
+		if _, ok := shared.UniqueViolation(innerErr); !ok {
+			t.Errorf("inner ExecTx error = %v, want the unique violation", innerErr)
+		}
+		// The outer transaction carries on.
+		return insertRow(ctx, tx, 3)
+	})
+	if err != nil {
+		t.Fatalf("ExecTx: %v", err)
+	}
+	if got := storedRows(t, pool); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("stored rows = %v, want [1 3]", got)
+	}
+}
+
+func TestAfterCommitRunsOnceAfterTheOutermostCommit(t *testing.T) {
+	pool := txTestPool(t)
+	txm := database.NewTxManager(pool)
+	ctx := context.Background()
+
+	// committed records, for each hook run, how many rows another connection could see then.
+	var committed []int
+	hook := func() {
+		var n int
+		if err := pool.QueryRow(ctx, `SELECT count(*) FROM tx_rows`).Scan(&n); err != nil {
+			t.Errorf("count rows in hook: %v", err)
+		}
+		committed = append(committed, n)
+	}
+
+	err := txm.ExecTx(ctx, func(tx pgx.Tx) error {
+		if err := insertRow(ctx, tx, 1); err != nil {
+			return err
+		}
+		// A hook registered in a released savepoint waits for the outer commit.
+		if err := txm.ExecTx(shared.WithTx(ctx, tx), func(sp pgx.Tx) error {
+			txm.AfterCommit(sp, hook)
+			return nil
+		}); err != nil {
+			return err
+		}
+		// One registered in a savepoint that rolls back never runs.
+		_ = txm.ExecTx(shared.WithTx(ctx, tx), func(sp pgx.Tx) error {
+			txm.AfterCommit(sp, func() { t.Error("hook of a rolled-back savepoint ran") })
+			return errors.New("inner failure")
+		})
+		if len(committed) != 0 {
+			t.Error("hook ran before the outer transaction committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExecTx: %v", err)
+	}
+	if !slices.Equal(committed, []int{1}) {
+		t.Errorf("hook runs saw %v committed rows, want a single run seeing [1]", committed)
+	}
+}
+
+func TestAfterCommitNeverRunsAfterARollback(t *testing.T) {
+	pool := txTestPool(t)
+	txm := database.NewTxManager(pool)
+	ctx := context.Background()
+
+	ran := 0
+	errFail := errors.New("fail")
+	err := txm.ExecTx(ctx, func(tx pgx.Tx) error {
+		txm.AfterCommit(tx, func() { ran++ })
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("ExecTx error = %v, want %v", err, errFail)
+	}
+	if ran != 0 {
+		t.Errorf("hook ran %d time(s) after a rollback", ran)
+	}
+
+	// Attempts aborted by serialization failures drop their hooks; only the committed one runs.
+	attempts := 0
+	err = txm.ExecTxOpts(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+		attempts++
+		txm.AfterCommit(tx, func() { ran++ })
+		if attempts == 1 {
+			_, err := tx.Exec(ctx, raiseSerializationFailure)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExecTxOpts: %v", err)
+	}
+	if attempts != 2 || ran != 1 {
+		t.Errorf("%d attempt(s) ran the hook %d time(s), want 2 attempts and 1 run", attempts, ran)
+	}
+}
+
+// TestSaturatedPoolAnswersDatabaseBusy holds both connections of a 2-connection pool and checks
+// a request needing a third is answered 503 database_busy once the acquire timeout passes, and
+// that readiness then reports the pool degraded.
+func TestSaturatedPoolAnswersDatabaseBusy(t *testing.T) {
+	ctx := context.Background()
+	provider, err := database.NewProvider(ctx, config.DatabaseConfig{
+		URL:                    dbtest.New(t).Config().ConnString(),
+		MaxOpenConns:           2,
+		ConnMaxLifetime:        time.Hour,
+		ConnMaxIdleTime:        time.Minute,
+		AcquireTimeout:         100 * time.Millisecond,
+		BusyRetryAfter:         2 * time.Second,
+		DegradedAcquireWaitP95: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	defer provider.Close()
+
+	for range 2 {
+		conn, err := provider.Pool.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		defer conn.Release()
+	}
+
+	txManager := database.NewTxManager(provider.Pool)
+	var txErr error
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/patients", middleware.ErrorHandler(func(c *gin.Context) *apierror.APIError {
+		txErr = txManager.ExecTx(c.Request.Context(), func(tx pgx.Tx) error {
+			_, err := tx.Exec(c.Request.Context(), `SELECT 1`)
+			return err
+		})
+		if txErr != nil {
+			return apierror.NewInternalServer(txErr)
+		}
+		return nil
+	}))
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/patients", nil))
+	waited := time.Since(start)
+
+	var busy *shared.BusyError
+	if !errors.As(txErr, &busy) {
+		t.Fatalf("ExecTx error = %v, want a *BusyError", txErr)
+	}
+	if waited < 100*time.Millisecond || waited > 5*time.Second {
+		t.Errorf("request waited %s for a connection, want about the 100ms acquire timeout", waited)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want %q", got, "2")
+	}
+	if !strings.Contains(rec.Body.String(), apierror.CodeDatabaseBusy) {
+		t.Errorf("body = %s, want error_code %s", rec.Body.String(), apierror.CodeDatabaseBusy)
+	}
+	if !provider.Degraded() {
+		t.Errorf("Degraded = false with an acquire wait p95 of %s, want true", provider.AcquireWaitP95())
+	}
+}
@@ -0,0 +1,242 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// maxErrorLength caps the handler error stored with a failed event.
+const maxErrorLength = 1000
+
+// Event is an outbox event as handed to its handlers.
+type Event struct {
+	ID      uuid.UUID
+	Type    string
+	Payload json.RawMessage
+	// Attempts is how many deliveries have failed before this one.
+	Attempts  int
+	CreatedAt time.Time
+
+	// sealed and keyVersion hold the payload as stored until the dispatcher opens it; events
+	// written before payloads were sealed have a plaintext Payload instead.
+	sealed     *string
+	keyVersion *int16
+}
+
+// Handler reacts to an event. It runs inside the dispatcher's transaction, in a savepoint that
+// is rolled back if it fails, so its database writes commit only together with the event being
+// marked processed. Returning an error schedules the event for another attempt.
+type Handler func(ctx context.Context, tx pgx.Tx, event Event) error
+
+// deferral is implemented by handler errors meaning the event could not be handled yet for a
+// reason outside it, such as notification.CircuitOpenError while a provider is down. The event
+// is put off until DeferUntil without using up one of its attempts.
+type deferral interface {
+	DeferUntil() time.Time
+}
+
+// Dispatcher delivers outbox events to their handlers. Any number of instances may run one:
+// claimed events stay locked until their outcome is committed, and other dispatchers skip them.
+// A handler's side effects outside the database happen before that commit, so an event whose
+// transaction fails to commit is delivered again.
+type Dispatcher struct {
+	tx    database.TxManager
+	store store
+	keys  *security.Keyring
+	cfg   config.OutboxConfig
+	// now is the dispatcher's clock.
+	now func() time.Time
+	// jitter returns a random duration in [0, n).
+	jitter func(n time.Duration) time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewDispatcher creates a dispatcher that claims events in transactions started by txManager
+// and opens their payloads with keys.
+func NewDispatcher(txManager database.TxManager, cfg config.OutboxConfig, keys *security.Keyring) *Dispatcher {
+	return &Dispatcher{
+		tx:       txManager,
+		store:    pgStore{},
+		keys:     keys,
+		cfg:      cfg,
+		now:      time.Now,
+		jitter:   rand.N[time.Duration],
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Register adds handler for events of eventType. Handlers run in registration order and an event
+// is retried from the first one if any fails. Registrations are made at startup.
+func (d *Dispatcher) Register(eventType string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventType] = append(d.handlers[eventType], handler)
+}
+
+// Run delivers due events every PollInterval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	d.dispatch(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.dispatch(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	if _, err := d.DispatchPending(ctx); err != nil {
+		log.Error().Err(err).Msg("outbox: failed to dispatch events")
+	}
+}
+
+// DispatchPending delivers the events due now, a batch per transaction, and returns how many were
+// handled successfully. Cancelling ctx stops it between batches; a batch in progress is finished
+// and committed so its side effects are not repeated because of a shutdown.
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	batchCtx := context.WithoutCancel(ctx)
+
+	total := 0
+	for ctx.Err() == nil {
+		var claimed, processed int
+		err := d.tx.ExecTx(batchCtx, func(tx pgx.Tx) error {
+			now := d.now()
+			events, err := d.store.claim(batchCtx, tx, now, d.cfg.BatchSize)
+			if err != nil {
+				return err
+			}
+			claimed = len(events)
+			for _, event := range events {
+				if handleErr := d.handle(batchCtx, tx, event); handleErr != nil {
+					if err := d.fail(batchCtx, tx, event, now, handleErr); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := d.store.markProcessed(batchCtx, tx, event.ID, now); err != nil {
+					return err
+				}
+				processed++
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += processed
+		if claimed < d.cfg.BatchSize {
+			return total, nil
+		}
+	}
+	return total, nil
+}
+
+// handle opens the event's payload and runs its handlers in a savepoint. An event nobody
+// handles is simply processed.
+func (d *Dispatcher) handle(ctx context.Context, tx pgx.Tx, event Event) error {
+	if event.sealed != nil {
+		payload, err := d.keys.Open(*event.keyVersion, *event.sealed)
+		if err != nil {
+			return fmt.Errorf("events: failed to open payload: %w", err)
+		}
+		event.Payload = payload
+		event.sealed, event.keyVersion = nil, nil
+	}
+
+	d.mu.RLock()
+	handlers := d.handlers[event.Type]
+	d.mu.RUnlock()
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("events: failed to create savepoint: %w", err)
+	}
+	for _, h := range handlers {
+		if err := h(ctx, sp, event); err != nil {
+			_ = sp.Rollback(ctx)
+			return err
+		}
+	}
+	if err := sp.Commit(ctx); err != nil {
+		return fmt.Errorf("events: failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// fail schedules the event's next attempt with exponential backoff, or marks it DEAD once it has
+// failed MaxAttempts times. A deferred event is only rescheduled.
+func (d *Dispatcher) fail(ctx context.Context, tx pgx.Tx, event Event, now time.Time, handleErr error) error {
+	message := handleErr.Error()
+	if len(message) > maxErrorLength {
+		message = strings.ToValidUTF8(message[:maxErrorLength], "")
+	}
+
+	var deferred deferral
+	if errors.As(handleErr, &deferred) {
+		next := deferred.DeferUntil()
+		if next.Before(now) {
+			next = now
+		}
+		log.Debug().Err(handleErr).
+			Str("event_id", event.ID.String()).
+			Str("event_type", event.Type).
+			Time("next_attempt_at", next).
+			Msg("outbox: event deferred")
+		return d.store.markDeferred(ctx, tx, event.ID, next, message)
+	}
+
+	attempts := event.Attempts + 1
+	dead := attempts >= d.cfg.MaxAttempts
+	next := now.Add(d.backoff(attempts))
+
+	logEvent := log.Warn()
+	if dead {
+		logEvent = log.Error()
+	}
+	logEvent.Err(handleErr).
+		Str("event_id", event.ID.String()).
+		Str("event_type", event.Type).
+		Int("attempts", attempts).
+		Bool("dead", dead).
+		Msg("outbox: event handler failed")
+	return d.store.markFailed(ctx, tx, event.ID, attempts, next, dead, message)
+}
+
+// backoff is the wait after the given number of failed attempts: RetryBackoff doubled for each
+// failure after the first, capped at MaxRetryBackoff. Its second half is random, so events that
+// failed together, as they do when a provider goes down, are not all retried together.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.cfg.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if d.cfg.MaxRetryBackoff > 0 && wait >= d.cfg.MaxRetryBackoff {
+			wait = d.cfg.MaxRetryBackoff
+			break
+		}
+	}
+	half := wait / 2
+	if half <= 0 {
+		return wait
+	}
+	return wait - half + d.jitter(half)
+}
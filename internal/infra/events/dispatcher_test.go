@@ -0,0 +1,305 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// memTx is a transaction whose savepoints always succeed.
+type memTx struct{ pgx.Tx }
+
+func (t memTx) Begin(context.Context) (pgx.Tx, error) { return t, nil }
+func (memTx) Commit(context.Context) error            { return nil }
+func (memTx) Rollback(context.Context) error          { return nil }
+
+// memTxManager runs closures on a memTx.
+type memTxManager struct{}
+
+func (memTxManager) ExecTx(_ context.Context, fn func(tx pgx.Tx) error) error { return fn(memTx{}) }
+func (memTxManager) ExecTxOpts(_ context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return fn(memTx{})
+}
+func (memTxManager) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+// memEvent is an outbox row held by memStore.
+type memEvent struct {
+	Event
+	status    string
+	next      time.Time
+	lastError string
+}
+
+// memStore is an outbox table in memory, claimed in insertion order.
+type memStore struct {
+	events []*memEvent
+}
+
+func (s *memStore) add(eventType string) *memEvent {
+	e := &memEvent{Event: Event{ID: uuid.New(), Type: eventType, Payload: []byte(`{}`)}, status: StatusPending}
+	s.events = append(s.events, e)
+	return e
+}
+
+func (s *memStore) claim(_ context.Context, _ pgx.Tx, now time.Time, limit int) ([]Event, error) {
+	var claimed []Event
+	for _, e := range s.events {
+		if len(claimed) < limit && e.status == StatusPending && !e.next.After(now) {
+			claimed = append(claimed, e.Event)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *memStore) find(id uuid.UUID) *memEvent {
+	for _, e := range s.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	panic("unknown event " + id.String())
+}
+
+func (s *memStore) markProcessed(_ context.Context, _ pgx.Tx, id uuid.UUID, _ time.Time) error {
+	s.find(id).status = StatusProcessed
+	return nil
+}
+
+func (s *memStore) markFailed(_ context.Context, _ pgx.Tx, id uuid.UUID, attempts int, next time.Time, dead bool, lastError string) error {
+	e := s.find(id)
+	e.Attempts, e.next, e.lastError = attempts, next, lastError
+	if dead {
+		e.status = StatusDead
+	}
+	return nil
+}
+
+func (s *memStore) markDeferred(_ context.Context, _ pgx.Tx, id uuid.UUID, next time.Time, reason string) error {
+	e := s.find(id)
+	e.next, e.lastError = next, reason
+	return nil
+}
+
+// smsProvider is an httptest SMS provider that is down, and a notifier sending to it.
+type smsProvider struct {
+	url      string
+	requests atomic.Int32
+}
+
+func (p *smsProvider) SendEmail(context.Context, notification.Email) error {
+	return notification.ErrSMSUnsupported
+}
+
+func (p *smsProvider) SendSMS(ctx context.Context, sms notification.SMS) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, strings.NewReader(sms.Body))
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("provider answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func TestDispatcherDefersEventsWhileTheProviderCircuitIsOpen(t *testing.T) {
+	provider := &smsProvider{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		provider.requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	provider.url = server.URL
+
+	notifier := notification.NewGuarded(provider, config.NotificationConfig{
+		FailureRate: 1,
+		MinRequests: 2,
+		Window:      time.Minute,
+		CoolDown:    time.Hour,
+	})
+	store := &memStore{}
+	d := NewDispatcher(memTxManager{}, testOutboxConfig, nil)
+	d.store = store
+	d.Register("reminder.due", func(ctx context.Context, _ pgx.Tx, event Event) error {
+		if err := notifier.SendSMS(ctx, notification.SMS{To: testPhone, Body: "reminder"}); err != nil {
+			return fmt.Errorf("failed to send reminder for %s: %w", event.ID, err)
+		}
+		return nil
+	})
+	for range 5 {
+		store.add("reminder.due")
+	}
+
+	start := time.Now()
+	if _, err := d.DispatchPending(context.Background()); err != nil {
+		t.Fatalf("DispatchPending: %v", err)
+	}
+
+	if n := provider.requests.Load(); n != 2 {
+		t.Errorf("provider received %d requests, want 2 before the circuit opened", n)
+	}
+	for i, e := range store.events {
+		if e.status != StatusPending {
+			t.Errorf("event %d: status = %s, want %s", i, e.status, StatusPending)
+		}
+		if i < 2 {
+			// Sent and failed: these used up an attempt and back off.
+			if e.Attempts != 1 {
+				t.Errorf("event %d: attempts = %d, want 1", i, e.Attempts)
+			}
+			continue
+		}
+		// Refused by the open circuit: no attempt is used and the event waits out the cool-down.
+		if e.Attempts != 0 {
+			t.Errorf("event %d: attempts = %d, want 0 while the circuit is open", i, e.Attempts)
+		}
+		if e.next.Before(start.Add(time.Hour)) {
+			t.Errorf("event %d: next attempt at %s, want after the cool-down", i, e.next)
+		}
+		if !strings.Contains(e.lastError, "circuit is open") {
+			t.Errorf("event %d: last error = %q, want the open circuit", i, e.lastError)
+		}
+	}
+}
+
+func TestBackoffIsJitteredWithinItsSecondHalf(t *testing.T) {
+	d := NewDispatcher(memTxManager{}, testOutboxConfig, nil)
+	tests := []struct {
+		attempts int
+		wait     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		// Capped at MaxRetryBackoff.
+		{20, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempts), func(t *testing.T) {
+			d.jitter = func(time.Duration) time.Duration { return 0 }
+			if got := d.backoff(tt.attempts); got != tt.wait/2 {
+				t.Errorf("backoff with no jitter = %s, want %s", got, tt.wait/2)
+			}
+			d.jitter = func(n time.Duration) time.Duration { return n - 1 }
+			if got := d.backoff(tt.attempts); got != tt.wait-1 {
+				t.Errorf("backoff with the most jitter = %s, want %s", got, tt.wait-1)
+			}
+		})
+	}
+
+	// Events failing together are spread over the window rather than retried in lockstep.
+	d = NewDispatcher(memTxManager{}, testOutboxConfig, nil)
+	seen := make(map[time.Duration]bool)
+	for range 50 {
+		wait := d.backoff(3)
+		if wait < 2*time.Second || wait >= 4*time.Second {
+			t.Fatalf("backoff(3) = %s, want within [2s, 4s)", wait)
+		}
+		seen[wait] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("50 backoffs took %d distinct value(s), want them jittered", len(seen))
+	}
+}
+
+func TestFailedEventsAreRedeliveredWithTheirAttemptCount(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	txManager := database.NewTxManager(pool)
+	keys := security.NewKeyring(testSecrets, payloadSealPurpose, 1)
+
+	err := txManager.ExecTx(ctx, func(tx pgx.Tx) error {
+		publisher := NewPublisher(keys)
+		if err := publisher.Publish(ctx, tx, "flaky", map[string]string{}); err != nil {
+			return err
+		}
+		return publisher.Publish(ctx, tx, "broken", map[string]string{})
+	})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	now := time.Now()
+	d := NewDispatcher(txManager, testOutboxConfig, keys)
+	d.now = func() time.Time { return now }
+	// flaky fails on its first delivery only; broken always fails.
+	var flakySeen, brokenSeen []int
+	d.Register("flaky", func(_ context.Context, _ pgx.Tx, event Event) error {
+		flakySeen = append(flakySeen, event.Attempts)
+		if event.Attempts == 0 {
+			return fmt.Errorf("provider unavailable")
+		}
+		return nil
+	})
+	d.Register("broken", func(_ context.Context, _ pgx.Tx, event Event) error {
+		brokenSeen = append(brokenSeen, event.Attempts)
+		return fmt.Errorf("malformed event")
+	})
+
+	type row struct {
+		status    string
+		attempts  int
+		lastError *string
+	}
+	stored := func(eventType string) row {
+		t.Helper()
+		var r row
+		err := pool.QueryRow(ctx, `SELECT status, attempts, last_error FROM outbox_events WHERE event_type = $1`, eventType).
+			Scan(&r.status, &r.attempts, &r.lastError)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	if _, err := d.DispatchPending(ctx); err != nil {
+		t.Fatalf("DispatchPending: %v", err)
+	}
+	if r := stored("flaky"); r.status != StatusPending || r.attempts != 1 || r.lastError == nil || *r.lastError != "provider unavailable" {
+		t.Errorf("after a failure: status %s, %d attempt(s), last error %v; want PENDING, 1, the handler's error", r.status, r.attempts, r.lastError)
+	}
+
+	// Nothing is redelivered before its backoff has passed.
+	if _, err := d.DispatchPending(ctx); err != nil {
+		t.Fatalf("DispatchPending: %v", err)
+	}
+	if len(flakySeen) != 1 {
+		t.Fatalf("flaky handler ran %d times before the backoff passed, want 1", len(flakySeen))
+	}
+
+	for range testOutboxConfig.MaxAttempts {
+		now = now.Add(testOutboxConfig.MaxRetryBackoff)
+		if _, err := d.DispatchPending(ctx); err != nil {
+			t.Fatalf("DispatchPending: %v", err)
+		}
+	}
+	if fmt.Sprint(flakySeen) != "[0 1]" {
+		t.Errorf("flaky handler saw attempts %v, want [0 1]", flakySeen)
+	}
+	if r := stored("flaky"); r.status != StatusProcessed || r.attempts != 1 || r.lastError != nil {
+		t.Errorf("after redelivery: status %s, %d attempt(s), last error %v; want PROCESSED, 1, none", r.status, r.attempts, r.lastError)
+	}
+	if fmt.Sprint(brokenSeen) != "[0 1 2]" {
+		t.Errorf("broken handler saw attempts %v, want [0 1 2]", brokenSeen)
+	}
+	if r := stored("broken"); r.status != StatusDead || r.attempts != testOutboxConfig.MaxAttempts {
+		t.Errorf("status %s after %d attempt(s), want DEAD after %d", r.status, r.attempts, testOutboxConfig.MaxAttempts)
+	}
+}
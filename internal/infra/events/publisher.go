@@ -0,0 +1,57 @@
+// Package events is the transactional outbox. A service publishes an event inside the transaction
+// that makes the change it describes, so the event exists exactly when the change committed; the
+// Dispatcher then delivers it to the handlers registered for its type. Use it for side effects
+// outside the database (email, SMS, webhooks), which must not run for rolled-back work.
+//
+// Delivery is at least once: a handler that fails, or a dispatcher that stops before recording
+// success, sees the event again. Handlers must therefore be idempotent or tolerate repeats.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// payloadSealPurpose keys the keyring outbox and webhook payloads are sealed with.
+const payloadSealPurpose = "mastara:outbox:payload"
+
+// NewPayloadKeyring returns the keyring outbox event payloads and webhook delivery bodies are
+// sealed with, from the configured seal keys.
+func NewPayloadKeyring(config *config.Config) *security.Keyring {
+	return security.NewSealKeyring(config.Security, payloadSealPurpose)
+}
+
+// Publisher writes events to the outbox.
+type Publisher struct {
+	keys *security.Keyring
+}
+
+// NewPublisher creates an outbox publisher that seals payloads with keys.
+func NewPublisher(keys *security.Keyring) *Publisher {
+	return &Publisher{keys: keys}
+}
+
+// Publish stores an event of eventType with payload encoded as JSON within tx. Payloads often
+// name patients, so they are stored sealed and only opened by the dispatcher. The event is
+// delivered only if tx commits.
+func (p *Publisher) Publish(ctx context.Context, tx pgx.Tx, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("events: failed to encode %q payload: %w", eventType, err)
+	}
+	version, sealed, err := p.keys.Seal(data)
+	if err != nil {
+		return fmt.Errorf("events: failed to seal %q payload: %w", eventType, err)
+	}
+	query := `INSERT INTO outbox_events (id, event_type, payload_sealed, key_version) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, uuid.Must(uuid.NewV7()), eventType, sealed, version); err != nil {
+		return fmt.Errorf("events: failed to store %q event: %w", eventType, err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const testPhone = "+201012345678"
+
+// testSecrets are two versions of the seal key, as rotating from v1 to v2 leaves them configured.
+var testSecrets = map[int16][]byte{
+	1: []byte("0123456789abcdef0123456789abcdef"),
+	2: []byte("fedcba9876543210fedcba9876543210"),
+}
+
+var testOutboxConfig = config.OutboxConfig{
+	PollInterval:    time.Second,
+	BatchSize:       10,
+	MaxAttempts:     3,
+	RetryBackoff:    time.Second,
+	MaxRetryBackoff: time.Minute,
+}
+
+// dumpOutbox returns every outbox row as JSON, the way a database dump or backup would hold it.
+func dumpOutbox(t *testing.T, pool *pgxpool.Pool) string {
+	t.Helper()
+	rows, err := pool.Query(context.Background(), `SELECT to_jsonb(o)::text FROM outbox_events o`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dumped, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(dumped, "\n")
+}
+
+// received registers a handler for eventType that records the payloads it is given.
+func received(d *Dispatcher, eventType string) *[]string {
+	var payloads []string
+	d.Register(eventType, func(_ context.Context, _ pgx.Tx, event Event) error {
+		payloads = append(payloads, string(event.Payload))
+		return nil
+	})
+	return &payloads
+}
+
+func TestPublishSealsPayloadAtRest(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	txManager := database.NewTxManager(pool)
+	keys := security.NewKeyring(testSecrets, payloadSealPurpose, 1)
+
+	err := txManager.ExecTx(ctx, func(tx pgx.Tx) error {
+		return NewPublisher(keys).Publish(ctx, tx, "patient.registered", map[string]string{"phone_number": testPhone})
+	})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if dump := dumpOutbox(t, pool); strings.Contains(dump, testPhone) {
+		t.Errorf("the phone number is stored in plaintext:\n%s", dump)
+	}
+
+	dispatcher := NewDispatcher(txManager, testOutboxConfig, keys)
+	payloads := received(dispatcher, "patient.registered")
+	if _, err := dispatcher.DispatchPending(ctx); err != nil {
+		t.Fatalf("DispatchPending: %v", err)
+	}
+	if len(*payloads) != 1 || !strings.Contains((*payloads)[0], testPhone) {
+		t.Errorf("handler received %q, want the plaintext payload", *payloads)
+	}
+}
+
+func TestResealPayloads(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	txManager := database.NewTxManager(pool)
+
+	// One event from before payloads were sealed and one sealed under the first key.
+	if _, err := pool.Exec(ctx, `INSERT INTO outbox_events (id, event_type, payload) VALUES ($1, 'legacy', $2)`,
+		uuid.Must(uuid.NewV7()), `{"phone_number":"`+testPhone+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	err := txManager.ExecTx(ctx, func(tx pgx.Tx) error {
+		return NewPublisher(security.NewKeyring(testSecrets, payloadSealPurpose, 1)).Publish(ctx, tx, "sealed", map[string]string{"phone_number": testPhone})
+	})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	rotated := security.NewKeyring(testSecrets, payloadSealPurpose, 2)
+	changed, err := ResealPayloads(ctx, txManager, rotated, 1)
+	if err != nil {
+		t.Fatalf("ResealPayloads: %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("ResealPayloads changed %d rows, want 2", changed)
+	}
+	if dump := dumpOutbox(t, pool); strings.Contains(dump, testPhone) || strings.Contains(dump, `"key_version": 1`) {
+		t.Errorf("a payload was left in plaintext or under the old key:\n%s", dump)
+	}
+
+	dispatcher := NewDispatcher(txManager, testOutboxConfig, rotated)
+	legacy, sealed := received(dispatcher, "legacy"), received(dispatcher, "sealed")
+	if _, err := dispatcher.DispatchPending(ctx); err != nil {
+		t.Fatalf("DispatchPending: %v", err)
+	}
+	for _, payloads := range []*[]string{legacy, sealed} {
+		if len(*payloads) != 1 || !strings.Contains((*payloads)[0], testPhone) {
+			t.Errorf("handler received %q, want the plaintext payload", *payloads)
+		}
+	}
+
+	unsealed, err := UnsealPayloads(ctx, txManager, rotated, 10)
+	if err != nil {
+		t.Fatalf("UnsealPayloads: %v", err)
+	}
+	if unsealed != 2 || !strings.Contains(dumpOutbox(t, pool), testPhone) {
+		t.Errorf("UnsealPayloads changed %d rows, want 2 restored to plaintext", unsealed)
+	}
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// payloadTables are the tables whose payloads are sealed with the payload keyring.
+var payloadTables = []string{"outbox_events", "webhook_deliveries"}
+
+// storedPayload is a row's payload as stored: in plaintext, or sealed under keyVersion.
+type storedPayload struct {
+	id         uuid.UUID
+	plaintext  json.RawMessage
+	sealed     *string
+	keyVersion *int16
+}
+
+// open returns the row's payload in plaintext.
+func (p storedPayload) open(keys *security.Keyring) ([]byte, error) {
+	if p.sealed == nil {
+		return p.plaintext, nil
+	}
+	return keys.Open(*p.keyVersion, *p.sealed)
+}
+
+// ResealPayloads seals the outbox and webhook payloads still stored in plaintext and reseals
+// those sealed under an older key version than the keyring's current one. It works through
+// batchSize rows per transaction and returns how many rows it changed.
+func ResealPayloads(ctx context.Context, txManager database.TxManager, keys *security.Keyring, batchSize int) (int, error) {
+	return rewritePayloads(ctx, txManager, batchSize,
+		`payload IS NOT NULL OR key_version < $1`, []any{keys.Current()},
+		func(tx pgx.Tx, table string, row storedPayload) error {
+			plaintext, err := row.open(keys)
+			if err != nil {
+				return err
+			}
+			version, sealed, err := keys.Seal(plaintext)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `UPDATE `+table+` SET payload = NULL, payload_sealed = $2, key_version = $3 WHERE id = $1`,
+				row.id, sealed, version)
+			return err
+		})
+}
+
+// UnsealPayloads stores every sealed outbox and webhook payload in plaintext again, which
+// reverting the migration that introduced sealing requires. It returns how many rows it changed.
+func UnsealPayloads(ctx context.Context, txManager database.TxManager, keys *security.Keyring, batchSize int) (int, error) {
+	return rewritePayloads(ctx, txManager, batchSize,
+		`payload_sealed IS NOT NULL`, nil,
+		func(tx pgx.Tx, table string, row storedPayload) error {
+			plaintext, err := row.open(keys)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `UPDATE `+table+` SET payload = $2, payload_sealed = NULL, key_version = NULL WHERE id = $1`,
+				row.id, json.RawMessage(plaintext))
+			return err
+		})
+}
+
+// rewritePayloads applies rewrite to each row of the payload tables matching where, with args as
+// its parameters, until none is left. A rewritten row must no longer match.
+func rewritePayloads(ctx context.Context, txManager database.TxManager, batchSize int, where string, args []any,
+	rewrite func(tx pgx.Tx, table string, row storedPayload) error) (int, error) {
+	args = append(args, batchSize)
+	total := 0
+	for _, table := range payloadTables {
+		query := `SELECT id, payload, payload_sealed, key_version FROM ` + table + `
+            WHERE ` + where + `
+            ORDER BY id
+            LIMIT $` + strconv.Itoa(len(args)) + `
+            FOR UPDATE SKIP LOCKED`
+		for {
+			var changed int
+			err := txManager.ExecTx(ctx, func(tx pgx.Tx) error {
+				rows, err := tx.Query(ctx, query, args...)
+				if err != nil {
+					return fmt.Errorf("events: failed to query %s payloads: %w", table, err)
+				}
+				batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storedPayload, error) {
+					var p storedPayload
+					err := row.Scan(&p.id, &p.plaintext, &p.sealed, &p.keyVersion)
+					return p, err
+				})
+				if err != nil {
+					return fmt.Errorf("events: failed to scan %s payloads: %w", table, err)
+				}
+				for _, row := range batch {
+					if err := rewrite(tx, table, row); err != nil {
+						return fmt.Errorf("events: failed to rewrite %s payload %s: %w", table, row.id, err)
+					}
+				}
+				changed = len(batch)
+				return nil
+			})
+			if err != nil {
+				return total, err
+			}
+			total += changed
+			if changed < batchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Event statuses.
+const (
+	StatusPending   = "PENDING"
+	StatusProcessed = "PROCESSED"
+	StatusDead      = "DEAD"
+)
+
+// store is the outbox table as the dispatcher sees it.
+type store interface {
+	// claim locks up to limit pending events due at now, oldest first, skipping events another
+	// dispatcher has locked.
+	claim(ctx context.Context, tx pgx.Tx, now time.Time, limit int) ([]Event, error)
+	markProcessed(ctx context.Context, tx pgx.Tx, id uuid.UUID, now time.Time) error
+	// markFailed records a failed delivery: the event is retried at next, or given up on when dead.
+	markFailed(ctx context.Context, tx pgx.Tx, id uuid.UUID, attempts int, next time.Time, dead bool, lastError string) error
+	// markDeferred puts the event off until next without counting an attempt.
+	markDeferred(ctx context.Context, tx pgx.Tx, id uuid.UUID, next time.Time, reason string) error
+}
+
+// pgStore is the PostgreSQL outbox_events table.
+type pgStore struct{}
+
+func (pgStore) claim(ctx context.Context, tx pgx.Tx, now time.Time, limit int) ([]Event, error) {
+	query := `
+        SELECT id, event_type, payload, payload_sealed, key_version, attempts, created_at FROM outbox_events
+        WHERE status = 'PENDING' AND next_attempt_at <= $1
+        ORDER BY next_attempt_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("events: failed to claim events: %w", err)
+	}
+	defer rows.Close()
+
+	claimed := []Event{}
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.sealed, &e.keyVersion, &e.Attempts, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("events: failed to scan event: %w", err)
+		}
+		claimed = append(claimed, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("events: error iterating events: %w", err)
+	}
+	return claimed, nil
+}
+
+func (pgStore) markProcessed(ctx context.Context, tx pgx.Tx, id uuid.UUID, now time.Time) error {
+	query := `UPDATE outbox_events SET status = 'PROCESSED', processed_at = $2, last_error = NULL WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, id, now); err != nil {
+		return fmt.Errorf("events: failed to mark event processed: %w", err)
+	}
+	return nil
+}
+
+func (pgStore) markFailed(ctx context.Context, tx pgx.Tx, id uuid.UUID, attempts int, next time.Time, dead bool, lastError string) error {
+	status := StatusPending
+	if dead {
+		status = StatusDead
+	}
+	query := `UPDATE outbox_events SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5 WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, id, status, attempts, next, lastError); err != nil {
+		return fmt.Errorf("events: failed to record event failure: %w", err)
+	}
+	return nil
+}
+
+func (pgStore) markDeferred(ctx context.Context, tx pgx.Tx, id uuid.UUID, next time.Time, reason string) error {
+	query := `UPDATE outbox_events SET next_attempt_at = $2, last_error = $3 WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, id, next, reason); err != nil {
+		return fmt.Errorf("events: failed to defer event: %w", err)
+	}
+	return nil
+}
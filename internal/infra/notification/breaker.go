@@ -0,0 +1,232 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/rs/zerolog/log"
+)
+
+// breakerVars publishes each channel's breaker with the process metrics.
+var breakerVars = expvar.NewMap("notification_breakers")
+
+// ErrCircuitOpen matches, with errors.Is, the *CircuitOpenError returned for a send refused by
+// an open breaker.
+var ErrCircuitOpen = errors.New("notification: provider circuit is open")
+
+// ErrInvalidMessage is wrapped by errors caused by the message itself, such as a malformed
+// address. They say nothing about the provider, so they never trip a breaker.
+var ErrInvalidMessage = errors.New("notification: invalid message")
+
+// CircuitOpenError is returned without contacting the provider while a channel's breaker is open.
+type CircuitOpenError struct {
+	Channel string
+	// RetryAt is when the breaker next lets a send through.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("notification: %s provider circuit is open until %s", e.Channel, e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+// Is makes the error match ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// DeferUntil tells the outbox dispatcher to put the event off until the breaker may let it
+// through, without counting the refusal as a failed attempt.
+func (e *CircuitOpenError) DeferUntil() time.Time {
+	return e.RetryAt
+}
+
+// BreakerState is where a breaker is in its cycle.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every send through and tallies their failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen refuses every send until the cool-down has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe through; its outcome closes or reopens the breaker.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker is a circuit breaker for one provider. Sends are tallied in fixed windows; once at
+// least MinRequests of a window's sends were made and FailureRate of them failed, the breaker
+// opens for CoolDown, after which one probe decides whether it closes again.
+type Breaker struct {
+	channel string
+	cfg     config.NotificationConfig
+	// now is the breaker's clock.
+	now func() time.Time
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	opened      int64
+	rejected    int64
+}
+
+// NewBreaker creates a closed breaker for channel and registers its metrics under that name.
+func NewBreaker(channel string, cfg config.NotificationConfig) *Breaker {
+	b := &Breaker{channel: channel, cfg: cfg, now: time.Now}
+	breakerVars.Set(channel, expvar.Func(func() any {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return map[string]any{
+			"state":    b.state.String(),
+			"opened":   b.opened,
+			"rejected": b.rejected,
+		}
+	}))
+	return b
+}
+
+// State returns the breaker's current state.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Do runs send unless the breaker refuses it, and tallies the outcome.
+func (b *Breaker) Do(ctx context.Context, send func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := send()
+	b.record(err != nil && tripsBreaker(ctx, err))
+	return err
+}
+
+// allow reserves a send, turning an open breaker half-open once its cool-down has passed.
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case BreakerOpen:
+		retryAt := b.openedAt.Add(b.cfg.CoolDown)
+		if now.Before(retryAt) {
+			b.rejected++
+			return &CircuitOpenError{Channel: b.channel, RetryAt: retryAt}
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		log.Info().Str("channel", b.channel).Msg("notification: provider circuit half-open; sending a probe")
+		return nil
+	case BreakerHalfOpen:
+		// Another send is probing the provider; this one waits for its verdict.
+		if b.probing {
+			b.rejected++
+			return &CircuitOpenError{Channel: b.channel, RetryAt: now.Add(b.cfg.CoolDown)}
+		}
+		b.probing = true
+		return nil
+	}
+	if now.Sub(b.windowStart) >= b.cfg.Window {
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	return nil
+}
+
+// record tallies a send allow let through.
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probing = false
+		if failed {
+			b.trip(now)
+			return
+		}
+		b.state = BreakerClosed
+		b.windowStart, b.requests, b.failures = now, 0, 0
+		log.Info().Str("channel", b.channel).Msg("notification: provider circuit closed")
+		return
+	case BreakerOpen:
+		// A send that started before the breaker tripped; the outage is already known.
+		return
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.cfg.MinRequests && float64(b.failures) >= b.cfg.FailureRate*float64(b.requests) {
+		b.trip(now)
+	}
+}
+
+// trip opens the breaker. The caller holds b.mu.
+func (b *Breaker) trip(now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.opened++
+	log.Error().
+		Str("channel", b.channel).
+		Int("requests", b.requests).
+		Int("failures", b.failures).
+		Dur("cool_down", b.cfg.CoolDown).
+		Msg("notification: provider circuit opened; sends are refused until the cool-down passes")
+}
+
+// tripsBreaker reports whether err counts against the provider. Bad messages, a missing
+// transport and callers giving up are not the provider's fault.
+func tripsBreaker(ctx context.Context, err error) bool {
+	if errors.Is(err, ErrInvalidMessage) || errors.Is(err, ErrSMSUnsupported) {
+		return false
+	}
+	return !errors.Is(ctx.Err(), context.Canceled)
+}
+
+// GuardedNotifier wraps a Notifier with a breaker per channel, so an email outage does not stop
+// text messages and the other way round.
+type GuardedNotifier struct {
+	next  Notifier
+	email *Breaker
+	sms   *Breaker
+}
+
+// NewGuarded wraps next with an email and an SMS breaker configured by cfg.
+func NewGuarded(next Notifier, cfg config.NotificationConfig) *GuardedNotifier {
+	return &GuardedNotifier{
+		next:  next,
+		email: NewBreaker("email", cfg),
+		sms:   NewBreaker("sms", cfg),
+	}
+}
+
+// SendEmail sends the email unless the email breaker is open.
+func (g *GuardedNotifier) SendEmail(ctx context.Context, email Email) error {
+	return g.email.Do(ctx, func() error { return g.next.SendEmail(ctx, email) })
+}
+
+// SendSMS sends the text message unless the SMS breaker is open.
+func (g *GuardedNotifier) SendSMS(ctx context.Context, sms SMS) error {
+	return g.sms.Do(ctx, func() error { return g.next.SendSMS(ctx, sms) })
+}
@@ -0,0 +1,241 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+)
+
+// httpNotifier stands in for an HTTP messaging provider, sending each message to url.
+type httpNotifier struct {
+	url string
+}
+
+func (n httpNotifier) SendEmail(ctx context.Context, email Email) error {
+	return n.post(ctx, email)
+}
+
+func (n httpNotifier) SendSMS(ctx context.Context, sms SMS) error {
+	return n.post(ctx, sms)
+}
+
+func (n httpNotifier) post(ctx context.Context, message any) error {
+	body, _ := json.Marshal(message)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("provider answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// provider is an httptest messaging provider that fails while down is set.
+type provider struct {
+	*httptest.Server
+	down     atomic.Bool
+	requests atomic.Int32
+}
+
+func newProvider(t *testing.T) *provider {
+	p := &provider{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		p.requests.Add(1)
+		if p.down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+var testBreakerConfig = config.NotificationConfig{
+	FailureRate: 0.5,
+	MinRequests: 4,
+	Window:      time.Minute,
+	CoolDown:    30 * time.Second,
+}
+
+// guarded wraps a notifier for url in breakers that read the returned clock.
+func guarded(url string) (*GuardedNotifier, *time.Time) {
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	g := NewGuarded(httpNotifier{url: url}, testBreakerConfig)
+	g.email.now = func() time.Time { return now }
+	g.sms.now = func() time.Time { return now }
+	return g, &now
+}
+
+func TestBreakerOpensHalfOpensAndRecovers(t *testing.T) {
+	ctx := context.Background()
+	p := newProvider(t)
+	g, now := guarded(p.URL)
+	sms := SMS{To: "+201000000001", Body: "hello"}
+
+	// Half of the window's sends failing, once four were made, opens the breaker.
+	for _, down := range []bool{false, true, false, true} {
+		p.down.Store(down)
+		if err := g.SendSMS(ctx, sms); (err != nil) != down {
+			t.Fatalf("SendSMS error = %v with the provider down = %t", err, down)
+		}
+	}
+	if state := g.sms.State(); state != BreakerOpen {
+		t.Fatalf("state = %s after 2 of 4 sends failed, want open", state)
+	}
+
+	// While open, sends are refused without reaching the provider.
+	err := g.SendSMS(ctx, sms)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("SendSMS error = %v, want a *CircuitOpenError", err)
+	}
+	if want := now.Add(testBreakerConfig.CoolDown); !openErr.DeferUntil().Equal(want) {
+		t.Errorf("DeferUntil = %s, want the end of the cool-down %s", openErr.DeferUntil(), want)
+	}
+	if n := p.requests.Load(); n != 4 {
+		t.Errorf("provider received %d requests, want 4", n)
+	}
+
+	// After the cool-down a probe goes through; it fails, so the breaker opens again.
+	*now = now.Add(testBreakerConfig.CoolDown)
+	if err := g.SendSMS(ctx, sms); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("probe error = %v, want the provider's failure", err)
+	}
+	if state := g.sms.State(); state != BreakerOpen {
+		t.Fatalf("state = %s after a failed probe, want open", state)
+	}
+	if err := g.SendSMS(ctx, sms); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("SendSMS error = %v right after a failed probe, want the circuit open", err)
+	}
+
+	// The provider recovers: the next probe closes the breaker and sends flow again.
+	p.down.Store(false)
+	*now = now.Add(testBreakerConfig.CoolDown)
+	if err := g.SendSMS(ctx, sms); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if state := g.sms.State(); state != BreakerClosed {
+		t.Fatalf("state = %s after a successful probe, want closed", state)
+	}
+	for range 3 {
+		if err := g.SendSMS(ctx, sms); err != nil {
+			t.Fatalf("SendSMS after recovery: %v", err)
+		}
+	}
+	if n := p.requests.Load(); n != 9 {
+		t.Errorf("provider received %d requests, want 9", n)
+	}
+}
+
+func TestBreakerLetsOneProbeThroughAtATime(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	probing := make(chan struct{})
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) <= 4 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		close(probing)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	g, now := guarded(server.URL)
+	email := Email{To: "ahmed@example.com", Subject: "Hi", Body: "hello"}
+
+	for range 4 {
+		_ = g.SendEmail(ctx, email)
+	}
+	if state := g.email.State(); state != BreakerOpen {
+		t.Fatalf("state = %s, want open", state)
+	}
+
+	*now = now.Add(testBreakerConfig.CoolDown)
+	probeErr := make(chan error, 1)
+	go func() { probeErr <- g.SendEmail(ctx, email) }()
+	<-probing
+	if state := g.email.State(); state != BreakerHalfOpen {
+		t.Errorf("state = %s during the probe, want half-open", state)
+	}
+	if err := g.SendEmail(ctx, email); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("SendEmail during the probe: error = %v, want the circuit open", err)
+	}
+	close(release)
+	if err := <-probeErr; err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if state := g.email.State(); state != BreakerClosed {
+		t.Errorf("state = %s after the probe succeeded, want closed", state)
+	}
+	if n := calls.Load(); n != 5 {
+		t.Errorf("provider received %d requests, want 5", n)
+	}
+}
+
+func TestBreakerKeepsChannelsApart(t *testing.T) {
+	ctx := context.Background()
+	p := newProvider(t)
+	g, _ := guarded(p.URL)
+
+	p.down.Store(true)
+	for range 4 {
+		_ = g.SendEmail(ctx, Email{To: "mona@example.com", Subject: "Hi", Body: "hello"})
+	}
+	p.down.Store(false)
+	if err := g.SendSMS(ctx, SMS{To: "+201000000002", Body: "hello"}); err != nil {
+		t.Errorf("SendSMS with the email circuit open: %v", err)
+	}
+	if g.email.State() != BreakerOpen || g.sms.State() != BreakerClosed {
+		t.Errorf("states = email %s, sms %s; want email open, sms closed", g.email.State(), g.sms.State())
+	}
+}
+
+// rejecting fails every send with err.
+type rejecting struct{ err error }
+
+func (r rejecting) SendEmail(context.Context, Email) error { return r.err }
+func (r rejecting) SendSMS(context.Context, SMS) error     { return r.err }
+
+func TestBreakerIgnoresFailuresThatAreNotTheProviders(t *testing.T) {
+	errs := []error{
+		fmt.Errorf("%w: invalid recipient address", ErrInvalidMessage),
+		ErrSMSUnsupported,
+	}
+	for _, err := range errs {
+		g := NewGuarded(rejecting{err: err}, testBreakerConfig)
+		for range 10 {
+			_ = g.SendSMS(context.Background(), SMS{To: "+201000000003"})
+		}
+		if state := g.sms.State(); state != BreakerClosed {
+			t.Errorf("state = %s after sends failed with %v, want closed", state, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g := NewGuarded(rejecting{err: context.Canceled}, testBreakerConfig)
+	for range 10 {
+		_ = g.SendEmail(ctx, Email{To: "salma@example.com"})
+	}
+	if state := g.email.State(); state != BreakerClosed {
+		t.Errorf("state = %s after callers gave up, want closed", state)
+	}
+}
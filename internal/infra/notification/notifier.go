@@ -0,0 +1,61 @@
+// Package notification delivers plain-text email and SMS messages. Modules render their own
+// messages and hand them to a Notifier; the transport behind it is chosen by configuration.
+package notification
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrSMSUnsupported is returned by transports that can only send email.
+var ErrSMSUnsupported = errors.New("notification: no SMS transport configured")
+
+// Email is a plain-text email.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// SMS is a text message to a phone number in E.164 form.
+type SMS struct {
+	To   string
+	Body string
+}
+
+// Notifier sends messages. Implementations must be safe for concurrent use.
+type Notifier interface {
+	SendEmail(ctx context.Context, email Email) error
+	SendSMS(ctx context.Context, sms SMS) error
+}
+
+// New returns an SMTP notifier when an SMTP host is configured and a LogNotifier otherwise.
+func New(cfg config.SMTPConfig) Notifier {
+	if cfg.Host == "" {
+		log.Warn().Msg("No SMTP host configured; notifications are only logged.")
+		return LogNotifier{}
+	}
+	return NewSMTPNotifier(cfg)
+}
+
+// LogNotifier is a no-op Notifier for development. Recipients and subjects are logged at info
+// level; bodies, which often carry single-use tokens, only at debug level so they never reach
+// production logs, which run at info or above.
+type LogNotifier struct{}
+
+// SendEmail logs the email instead of sending it.
+func (LogNotifier) SendEmail(_ context.Context, email Email) error {
+	log.Info().Str("to", email.To).Str("subject", email.Subject).Msg("Email not sent (no mail transport configured)")
+	log.Debug().Str("to", email.To).Str("body", email.Body).Msg("Email body")
+	return nil
+}
+
+// SendSMS logs the text message instead of sending it.
+func (LogNotifier) SendSMS(_ context.Context, sms SMS) error {
+	log.Info().Str("to", sms.To).Msg("SMS not sent (no SMS transport configured)")
+	log.Debug().Str("to", sms.To).Str("body", sms.Body).Msg("SMS body")
+	return nil
+}
@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+)
+
+// SMTPNotifier sends email through an SMTP relay. It has no SMS transport.
+type SMTPNotifier struct {
+	cfg config.SMTPConfig
+}
+
+// NewSMTPNotifier creates a notifier that relays through cfg.Host.
+func NewSMTPNotifier(cfg config.SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg}
+}
+
+// SendEmail delivers the email over a new connection, upgrading it with STARTTLS when the
+// server offers it. The whole exchange is bounded by ctx's deadline.
+func (n *SMTPNotifier) SendEmail(ctx context.Context, email Email) error {
+	from, err := mail.ParseAddress(n.cfg.From)
+	if err != nil {
+		return fmt.Errorf("notification: invalid sender address %q: %w", n.cfg.From, err)
+	}
+	to, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return fmt.Errorf("%w: invalid recipient address: %w", ErrInvalidMessage, err)
+	}
+	if strings.ContainsAny(email.Subject, "\r\n") {
+		return fmt.Errorf("%w: subject must be a single line", ErrInvalidMessage)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.cfg.Host, n.cfg.Port))
+	if err != nil {
+		return fmt.Errorf("notification: failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return fmt.Errorf("notification: failed to set SMTP deadline: %w", err)
+		}
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("notification: SMTP handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("notification: STARTTLS failed: %w", err)
+		}
+	}
+	if n.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return fmt.Errorf("notification: SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("notification: SMTP MAIL FROM rejected: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("notification: SMTP RCPT TO rejected: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("notification: SMTP DATA rejected: %w", err)
+	}
+	if _, err := w.Write(buildMessage(from, to, email, time.Now())); err != nil {
+		w.Close()
+		return fmt.Errorf("notification: failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("notification: SMTP server rejected message: %w", err)
+	}
+	return client.Quit()
+}
+
+// SendSMS always fails: SMTP cannot deliver text messages.
+func (n *SMTPNotifier) SendSMS(_ context.Context, _ SMS) error {
+	return ErrSMSUnsupported
+}
+
+// buildMessage renders a plain-text UTF-8 message with CRLF line endings.
+func buildMessage(from, to *mail.Address, email Email, now time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + to.String() + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", email.Subject) + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(email.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,119 @@
+package security
+
+import (
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+// actionLinkAssertion is bound to every action link as the PASETO implicit assertion. An action
+// link therefore never verifies as an access token, and an access token never as an action link,
+// even though both are minted with the same key.
+var actionLinkAssertion = []byte("mastara:action-link:v1")
+
+// ActionLinkClaims identify the staff member an emailed link was issued to and the action it opens.
+type ActionLinkClaims struct {
+	TokenID   uuid.UUID
+	ProfileID uuid.UUID
+	ClinicID  uuid.UUID
+	Action    string
+	EntityID  uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// NewActionLinkClaims creates the claims for a link that expires after duration.
+func NewActionLinkClaims(profileID, clinicID uuid.UUID, action string, entityID uuid.UUID, duration time.Duration) (*ActionLinkClaims, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now().UTC()
+	return &ActionLinkClaims{
+		TokenID:   tokenID,
+		ProfileID: profileID,
+		ClinicID:  clinicID,
+		Action:    action,
+		EntityID:  entityID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(duration),
+	}, nil
+}
+
+// CreateActionLink encodes the claims as a PASETO token of the manager's mode.
+func (m *PasetoManager) CreateActionLink(claims *ActionLinkClaims) (string, error) {
+	token := paseto.NewToken()
+	token.SetJti(claims.TokenID.String())
+	token.SetIssuer(m.issuer)
+	token.SetAudience(m.audience)
+	token.SetIssuedAt(claims.IssuedAt)
+	token.SetNotBefore(claims.IssuedAt)
+	token.SetExpiration(claims.ExpiresAt)
+	token.SetSubject(claims.ProfileID.String())
+	token.SetString("cid", claims.ClinicID.String())
+	token.SetString("act", claims.Action)
+	token.SetString("eid", claims.EntityID.String())
+
+	if m.mode == TokenModePublic {
+		return token.V4Sign(m.secretKey, actionLinkAssertion), nil
+	}
+	return token.V4Encrypt(m.symmetricKey, actionLinkAssertion), nil
+}
+
+// VerifyActionLink checks an action link's signature, issuer, audience and expiry and returns its
+// claims. It does not know whether the link was already used; callers record TokenID for that.
+func (m *PasetoManager) VerifyActionLink(tokenString string) (*ActionLinkClaims, error) {
+	parser := paseto.MakeParser([]paseto.Rule{
+		paseto.IssuedBy(m.issuer),
+		paseto.ForAudience(m.audience),
+		validWithLeeway(m.leeway),
+	})
+	var (
+		token *paseto.Token
+		err   error
+	)
+	if m.mode == TokenModePublic {
+		token, err = parser.ParseV4Public(m.publicKey, tokenString, actionLinkAssertion)
+	} else {
+		token, err = parser.ParseV4Local(m.symmetricKey, tokenString, actionLinkAssertion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse or validate action link: %w", err)
+	}
+
+	claims := &ActionLinkClaims{}
+	if claims.TokenID, err = parseUUIDClaim(token.GetJti); err != nil {
+		return nil, fmt.Errorf("invalid jti in action link: %w", err)
+	}
+	if claims.ProfileID, err = parseUUIDClaim(token.GetSubject); err != nil {
+		return nil, fmt.Errorf("invalid subject in action link: %w", err)
+	}
+	if claims.ClinicID, err = parseUUIDClaim(func() (string, error) { return token.GetString("cid") }); err != nil {
+		return nil, fmt.Errorf("invalid clinic id in action link: %w", err)
+	}
+	if claims.EntityID, err = parseUUIDClaim(func() (string, error) { return token.GetString("eid") }); err != nil {
+		return nil, fmt.Errorf("invalid entity id in action link: %w", err)
+	}
+	if claims.Action, err = token.GetString("act"); err != nil {
+		return nil, fmt.Errorf("failed to get action from action link: %w", err)
+	}
+	if claims.IssuedAt, err = token.GetIssuedAt(); err != nil {
+		return nil, fmt.Errorf("failed to get action link iat: %w", err)
+	}
+	if claims.ExpiresAt, err = token.GetExpiration(); err != nil {
+		return nil, fmt.Errorf("failed to get action link exp: %w", err)
+	}
+	return claims, nil
+}
+
+// parseUUIDClaim reads a string claim with get and parses it as a UUID.
+func parseUUIDClaim(get func() (string, error)) (uuid.UUID, error) {
+	value, err := get()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(value)
+}
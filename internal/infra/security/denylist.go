@@ -0,0 +1,102 @@
+package security
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Denylist records access tokens that must be rejected before their natural expiry.
+// Implementations must forget entries once the tokens they cover could no longer be valid anyway.
+type Denylist interface {
+	// RevokeToken rejects the token with the given jti until it expires.
+	RevokeToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error
+	// RevokeSubject rejects every token of userID issued before the given instant.
+	// The entry is kept until retainUntil, which must be at least the longest token lifetime from now.
+	RevokeSubject(ctx context.Context, userID uuid.UUID, issuedBefore, retainUntil time.Time) error
+	// IsRevoked reports whether the token described by payload has been revoked.
+	IsRevoked(ctx context.Context, payload *AuthPayload) (bool, error)
+}
+
+type subjectRevocation struct {
+	issuedBefore time.Time
+	retainUntil  time.Time
+}
+
+// MemoryDenylist is a process-local Denylist. It is suitable for single-instance deployments;
+// multi-instance deployments should plug in a shared store (e.g. Redis) behind the same interface.
+type MemoryDenylist struct {
+	mu       sync.RWMutex
+	tokens   map[uuid.UUID]time.Time
+	subjects map[uuid.UUID]subjectRevocation
+}
+
+// NewMemoryDenylist creates an empty in-memory denylist.
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		tokens:   make(map[uuid.UUID]time.Time),
+		subjects: make(map[uuid.UUID]subjectRevocation),
+	}
+}
+
+// RevokeToken implements Denylist.
+func (d *MemoryDenylist) RevokeToken(_ context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tokens[tokenID] = expiresAt
+	return nil
+}
+
+// RevokeSubject implements Denylist. Token issue times only have second precision,
+// so the cutoff is truncated to the second to avoid rejecting tokens minted right after revocation.
+func (d *MemoryDenylist) RevokeSubject(_ context.Context, userID uuid.UUID, issuedBefore, retainUntil time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subjects[userID] = subjectRevocation{issuedBefore: issuedBefore.Truncate(time.Second), retainUntil: retainUntil}
+	return nil
+}
+
+// IsRevoked implements Denylist.
+func (d *MemoryDenylist) IsRevoked(_ context.Context, payload *AuthPayload) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if _, ok := d.tokens[payload.TokenID]; ok {
+		return true, nil
+	}
+	if rev, ok := d.subjects[payload.UserID]; ok && payload.IssuedAt.Before(rev.issuedBefore) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// Prune drops entries whose tokens have expired on their own.
+func (d *MemoryDenylist) Prune(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, expiresAt := range d.tokens {
+		if now.After(expiresAt) {
+			delete(d.tokens, id)
+		}
+	}
+	for id, rev := range d.subjects {
+		if now.After(rev.retainUntil) {
+			delete(d.subjects, id)
+		}
+	}
+}
+
+// Run prunes the denylist every interval until ctx is cancelled.
+func (d *MemoryDenylist) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.Prune(now)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package security
+
+import (
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+// GuestPurposeManage is the purpose of the token a guest manages their own booking with.
+const GuestPurposeManage = "guest_manage"
+
+// guestTokenAssertion is bound to every guest token as the PASETO implicit assertion, so a guest
+// token never verifies as a staff access token or an action link, and neither verifies as a guest token.
+var guestTokenAssertion = []byte("mastara:guest:v1")
+
+// GuestPayload scopes a guest token to one appointment and one purpose.
+type GuestPayload struct {
+	TokenID       uuid.UUID
+	AppointmentID uuid.UUID
+	ClinicID      uuid.UUID
+	Purpose       string
+	IssuedAt      time.Time
+	ExpiresAt     time.Time
+}
+
+// NewGuestPayload creates the payload for a guest token that expires at expiresAt.
+func NewGuestPayload(appointmentID, clinicID uuid.UUID, purpose string, expiresAt time.Time) (*GuestPayload, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	return &GuestPayload{
+		TokenID:       tokenID,
+		AppointmentID: appointmentID,
+		ClinicID:      clinicID,
+		Purpose:       purpose,
+		IssuedAt:      time.Now().UTC(),
+		ExpiresAt:     expiresAt.UTC(),
+	}, nil
+}
+
+// CreateGuestToken encodes the payload as a PASETO token of the manager's mode.
+func (m *PasetoManager) CreateGuestToken(payload *GuestPayload) (string, error) {
+	token := paseto.NewToken()
+	token.SetJti(payload.TokenID.String())
+	token.SetIssuer(m.issuer)
+	token.SetAudience(m.audience)
+	token.SetIssuedAt(payload.IssuedAt)
+	token.SetNotBefore(payload.IssuedAt)
+	token.SetExpiration(payload.ExpiresAt)
+	token.SetSubject(payload.AppointmentID.String())
+	token.SetString("cid", payload.ClinicID.String())
+	token.SetString("purpose", payload.Purpose)
+
+	if m.mode == TokenModePublic {
+		return token.V4Sign(m.secretKey, guestTokenAssertion), nil
+	}
+	return token.V4Encrypt(m.symmetricKey, guestTokenAssertion), nil
+}
+
+// VerifyGuestToken checks a guest token's signature, issuer, audience and expiry and returns its
+// payload. Callers check Purpose; whether the token was since replaced is recorded with the appointment.
+func (m *PasetoManager) VerifyGuestToken(tokenString string) (*GuestPayload, error) {
+	parser := paseto.MakeParser([]paseto.Rule{
+		paseto.IssuedBy(m.issuer),
+		paseto.ForAudience(m.audience),
+		validWithLeeway(m.leeway),
+	})
+	var (
+		token *paseto.Token
+		err   error
+	)
+	if m.mode == TokenModePublic {
+		token, err = parser.ParseV4Public(m.publicKey, tokenString, guestTokenAssertion)
+	} else {
+		token, err = parser.ParseV4Local(m.symmetricKey, tokenString, guestTokenAssertion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse or validate guest token: %w", err)
+	}
+
+	payload := &GuestPayload{}
+	if payload.TokenID, err = parseUUIDClaim(token.GetJti); err != nil {
+		return nil, fmt.Errorf("invalid jti in guest token: %w", err)
+	}
+	if payload.AppointmentID, err = parseUUIDClaim(token.GetSubject); err != nil {
+		return nil, fmt.Errorf("invalid subject in guest token: %w", err)
+	}
+	if payload.ClinicID, err = parseUUIDClaim(func() (string, error) { return token.GetString("cid") }); err != nil {
+		return nil, fmt.Errorf("invalid clinic id in guest token: %w", err)
+	}
+	if payload.Purpose, err = token.GetString("purpose"); err != nil {
+		return nil, fmt.Errorf("failed to get purpose from guest token: %w", err)
+	}
+	if payload.IssuedAt, err = token.GetIssuedAt(); err != nil {
+		return nil, fmt.Errorf("failed to get guest token iat: %w", err)
+	}
+	if payload.ExpiresAt, err = token.GetExpiration(); err != nil {
+		return nil, fmt.Errorf("failed to get guest token exp: %w", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,77 @@
+package security
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaxConcurrentHashes is the number of Argon2 operations allowed to run at once
+// until SetMaxConcurrentHashes is called. Each one allocates defaultParams.Memory.
+const DefaultMaxConcurrentHashes = 4
+
+// hashLimiter bounds concurrent Argon2 work so a burst of logins queues instead of
+// allocating 64 MB per request until the process runs out of memory.
+type hashLimiter struct {
+	mu       sync.RWMutex
+	slots    chan struct{}
+	inFlight atomic.Int64
+	waiting  atomic.Int64
+}
+
+var hashes = &hashLimiter{slots: make(chan struct{}, DefaultMaxConcurrentHashes)}
+
+func init() {
+	expvar.Publish("password_hashing", expvar.Func(func() any {
+		return map[string]int64{
+			"limit":     int64(hashes.limit()),
+			"in_flight": hashes.inFlight.Load(),
+			"waiting":   hashes.waiting.Load(),
+		}
+	}))
+}
+
+// SetMaxConcurrentHashes sets how many Argon2 operations may run at once. It is meant to be
+// called once at startup; operations already holding a slot finish under the old limit.
+func SetMaxConcurrentHashes(n int) {
+	if n < 1 {
+		n = 1
+	}
+	hashes.mu.Lock()
+	hashes.slots = make(chan struct{}, n)
+	hashes.mu.Unlock()
+}
+
+func (l *hashLimiter) limit() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return cap(l.slots)
+}
+
+// acquire blocks until a slot is free or ctx ends, and returns the function that frees it.
+func (l *hashLimiter) acquire(ctx context.Context) (release func(), err error) {
+	l.mu.RLock()
+	slots := l.slots
+	l.mu.RUnlock()
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		l.waiting.Add(1)
+		select {
+		case slots <- struct{}{}:
+			l.waiting.Add(-1)
+		case <-ctx.Done():
+			l.waiting.Add(-1)
+			return nil, fmt.Errorf("security: gave up waiting for a password hashing slot: %w", ctx.Err())
+		}
+	}
+
+	l.inFlight.Add(1)
+	return func() {
+		l.inFlight.Add(-1)
+		<-slots
+	}, nil
+}
@@ -0,0 +1,106 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHashLimiterRespectsCeiling(t *testing.T) {
+	const limit, workers = 2, 10
+	limiter := &hashLimiter{slots: make(chan struct{}, limit)}
+
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer release()
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != limit {
+		t.Errorf("peak concurrency = %d, want the ceiling %d", got, limit)
+	}
+	if limiter.inFlight.Load() != 0 || limiter.waiting.Load() != 0 {
+		t.Errorf("in_flight = %d, waiting = %d after every worker finished, want 0",
+			limiter.inFlight.Load(), limiter.waiting.Load())
+	}
+}
+
+func TestHashLimiterGivesUpWhenContextEnds(t *testing.T) {
+	limiter := &hashLimiter{slots: make(chan struct{}, 1)}
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire on a full limiter = %v, want context.DeadlineExceeded", err)
+	}
+	if got := limiter.waiting.Load(); got != 0 {
+		t.Errorf("waiting = %d after giving up, want 0", got)
+	}
+}
+
+// TestPasswordHashingRespectsCeiling runs real Argon2 hashes past the configured limit and
+// samples how many hold a slot at once.
+func TestPasswordHashingRespectsCeiling(t *testing.T) {
+	const limit = 2
+	SetMaxConcurrentHashes(limit)
+	t.Cleanup(func() { SetMaxConcurrentHashes(DefaultMaxConcurrentHashes) })
+
+	done, sampled := make(chan struct{}), make(chan int64)
+	go func() {
+		var peak int64
+		for {
+			select {
+			case <-done:
+				sampled <- peak
+				return
+			default:
+				peak = max(peak, hashes.inFlight.Load())
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range 3 * limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := HashPassword("correct horse battery staple"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+
+	if peak := <-sampled; peak > limit {
+		t.Errorf("%d hashes ran at once, want at most %d", peak, limit)
+	}
+}
@@ -0,0 +1,16 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// HashIdentifier returns a hex-encoded HMAC-SHA256 of a normalized login identifier (email or phone).
+// It lets us correlate attempts for the same identifier without ever storing it in plaintext.
+func HashIdentifier(key []byte, identifier string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(identifier))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
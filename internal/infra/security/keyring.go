@@ -0,0 +1,63 @@
+package security
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+)
+
+// Keyring seals values under the current one of several numbered keys and opens values sealed
+// under any of them. Stored rows record the version they were sealed with, so the current
+// version can be raised and older rows resealed at leisure while all of them stay readable.
+type Keyring struct {
+	current int16
+	sealers map[int16]*Sealer
+}
+
+// NewKeyring creates a keyring with a Sealer for each version in secrets, keyed by that version's
+// secret and purpose with the version appended, e.g. "mastara:outbox:payload:v2". New values are
+// sealed under current, which must be one of the versions.
+func NewKeyring(secrets map[int16][]byte, purpose string, current int16) *Keyring {
+	if _, ok := secrets[current]; !ok {
+		panic(fmt.Sprintf("security: keyring has no key version %d", current))
+	}
+	sealers := make(map[int16]*Sealer, len(secrets))
+	for v, secret := range secrets {
+		sealers[v] = NewSealer(secret, purpose+":v"+strconv.Itoa(int(v)))
+	}
+	return &Keyring{current: current, sealers: sealers}
+}
+
+// NewSealKeyring creates the keyring for purpose from the configured seal keys, which config.New
+// has already validated.
+func NewSealKeyring(cfg config.SecurityConfig, purpose string) *Keyring {
+	secrets, err := cfg.SealKeySecrets()
+	if err != nil {
+		panic(fmt.Sprintf("security: %v", err))
+	}
+	return NewKeyring(secrets, purpose, cfg.SealKeyVersion)
+}
+
+// Current is the version new values are sealed under.
+func (k *Keyring) Current() int16 {
+	return k.current
+}
+
+// Seal encrypts plaintext under the current key and returns the version used with the sealed value.
+func (k *Keyring) Seal(plaintext []byte) (int16, string, error) {
+	sealed, err := k.sealers[k.current].Seal(plaintext)
+	if err != nil {
+		return 0, "", err
+	}
+	return k.current, sealed, nil
+}
+
+// Open decrypts a value sealed under version.
+func (k *Keyring) Open(version int16, sealed string) ([]byte, error) {
+	sealer, ok := k.sealers[version]
+	if !ok {
+		return nil, fmt.Errorf("security: unknown key version %d", version)
+	}
+	return sealer.Open(sealed)
+}
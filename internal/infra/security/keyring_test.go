@@ -0,0 +1,50 @@
+package security
+
+import "testing"
+
+func TestKeyringOpensEveryConfiguredVersion(t *testing.T) {
+	secrets := map[int16][]byte{
+		1: []byte("0123456789abcdef0123456789abcdef"),
+		2: []byte("fedcba9876543210fedcba9876543210"),
+	}
+	old := NewKeyring(secrets, "test:purpose", 1)
+	rotated := NewKeyring(secrets, "test:purpose", 2)
+
+	version, sealed, err := old.Seal([]byte("hello"))
+	if err != nil || version != 1 {
+		t.Fatalf("Seal = %d, %v; want version 1", version, err)
+	}
+	if plaintext, err := rotated.Open(version, sealed); err != nil || string(plaintext) != "hello" {
+		t.Errorf("Open with the rotated keyring = %q, %v; want the plaintext", plaintext, err)
+	}
+	if _, err := rotated.Open(2, sealed); err == nil {
+		t.Error("a value sealed under v1 opened as v2")
+	}
+
+	// Each version has its own secret, not one derived from a shared secret.
+	alone := NewKeyring(map[int16][]byte{2: secrets[2]}, "test:purpose", 2)
+	version, sealed, err = rotated.Seal([]byte("hello"))
+	if err != nil || version != 2 {
+		t.Fatalf("Seal = %d, %v; want version 2", version, err)
+	}
+	if plaintext, err := alone.Open(version, sealed); err != nil || string(plaintext) != "hello" {
+		t.Errorf("Open with only v2's secret = %q, %v; want the plaintext", plaintext, err)
+	}
+	if _, err := NewKeyring(map[int16][]byte{2: secrets[1]}, "test:purpose", 2).Open(version, sealed); err == nil {
+		t.Error("a value sealed under v2 opened with another secret")
+	}
+}
+
+// Version 1 derives the key a plain Sealer for "<purpose>:v1" does, so listing the key values
+// were sealed with before the keyring existed as v1 keeps them readable.
+func TestKeyringVersionOneMatchesTheLegacySealer(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	sealed, err := NewSealer(secret, "mastara:webhooks:secret:v1").Seal([]byte("whsec_legacy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewKeyring(map[int16][]byte{1: secret, 2: []byte("fedcba9876543210fedcba9876543210")}, "mastara:webhooks:secret", 2)
+	if plaintext, err := keys.Open(1, sealed); err != nil || string(plaintext) != "whsec_legacy" {
+		t.Errorf("Open(1) = %q, %v; want the legacy secret", plaintext, err)
+	}
+}
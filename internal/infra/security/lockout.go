@@ -0,0 +1,146 @@
+package security
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LockoutState is an account's recent failed logins and whether they have locked it.
+type LockoutState struct {
+	// FailedAttempts counts failures inside the window, including the one that locked the account.
+	FailedAttempts int
+	// LockedUntil is set while the account is locked.
+	LockedUntil *time.Time
+}
+
+// Locked reports whether the account is locked at now.
+func (s LockoutState) Locked(now time.Time) bool {
+	return s.LockedUntil != nil && now.Before(*s.LockedUntil)
+}
+
+// Lockout counts failed logins per account and locks the account once they reach a threshold.
+// Implementations must be shared by every instance that serves logins for the same accounts.
+type Lockout interface {
+	// RecordFailure counts a failed attempt against the account and returns the resulting state.
+	RecordFailure(ctx context.Context, accountID uuid.UUID) (LockoutState, error)
+	// Status returns the account's current state without changing it.
+	Status(ctx context.Context, accountID uuid.UUID) (LockoutState, error)
+	// Clear forgets the account's failures and lifts any lock, after a successful login or
+	// when an administrator unlocks the account.
+	Clear(ctx context.Context, accountID uuid.UUID) error
+}
+
+// LockoutPolicy decides when failures lock an account and for how long.
+type LockoutPolicy struct {
+	// Threshold is the number of failures within Window that locks the account; zero disables locking.
+	Threshold int
+	Window    time.Duration
+	Duration  time.Duration
+}
+
+type lockoutEntry struct {
+	failures    []time.Time
+	lockedUntil time.Time
+}
+
+// MemoryLockout is a process-local Lockout. It is suitable for single-instance deployments;
+// multi-instance deployments should plug in a shared store (e.g. Redis) behind the same interface.
+type MemoryLockout struct {
+	policy  LockoutPolicy
+	mu      sync.Mutex
+	entries map[uuid.UUID]*lockoutEntry
+}
+
+// NewMemoryLockout creates an empty in-memory lockout store enforcing policy.
+func NewMemoryLockout(policy LockoutPolicy) *MemoryLockout {
+	return &MemoryLockout{
+		policy:  policy,
+		entries: make(map[uuid.UUID]*lockoutEntry),
+	}
+}
+
+// RecordFailure implements Lockout.
+func (l *MemoryLockout) RecordFailure(_ context.Context, accountID uuid.UUID) (LockoutState, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := l.entries[accountID]
+	if !ok {
+		entry = &lockoutEntry{}
+		l.entries[accountID] = entry
+	}
+	entry.failures = append(l.recent(entry.failures, now), now)
+	if l.policy.Threshold > 0 && len(entry.failures) >= l.policy.Threshold && !now.Before(entry.lockedUntil) {
+		entry.lockedUntil = now.Add(l.policy.Duration)
+	}
+	return l.state(entry, now), nil
+}
+
+// Status implements Lockout.
+func (l *MemoryLockout) Status(_ context.Context, accountID uuid.UUID) (LockoutState, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[accountID]
+	if !ok {
+		return LockoutState{}, nil
+	}
+	return l.state(entry, time.Now()), nil
+}
+
+// Clear implements Lockout.
+func (l *MemoryLockout) Clear(_ context.Context, accountID uuid.UUID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, accountID)
+	return nil
+}
+
+// recent drops failures that have left the window.
+func (l *MemoryLockout) recent(failures []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-l.policy.Window)
+	i := 0
+	for i < len(failures) && !failures[i].After(cutoff) {
+		i++
+	}
+	return failures[i:]
+}
+
+func (l *MemoryLockout) state(entry *lockoutEntry, now time.Time) LockoutState {
+	state := LockoutState{FailedAttempts: len(l.recent(entry.failures, now))}
+	if now.Before(entry.lockedUntil) {
+		lockedUntil := entry.lockedUntil
+		state.LockedUntil = &lockedUntil
+	}
+	return state
+}
+
+// Prune drops accounts with no recent failures and no active lock.
+func (l *MemoryLockout) Prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, entry := range l.entries {
+		entry.failures = l.recent(entry.failures, now)
+		if len(entry.failures) == 0 && !now.Before(entry.lockedUntil) {
+			delete(l.entries, id)
+		}
+	}
+}
+
+// Run prunes the store every interval until ctx is cancelled.
+func (l *MemoryLockout) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.Prune(now)
+		}
+	}
+}
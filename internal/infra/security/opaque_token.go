@@ -0,0 +1,29 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const opaqueTokenBytes = 32
+
+// NewOpaqueToken generates a random, URL-safe single-use token and the hash to persist for it.
+// Only the hash should be stored; the token itself is handed to the user once.
+func NewOpaqueToken() (token string, hash string, err error) {
+	buf := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the hex SHA-256 of a token produced by NewOpaqueToken.
+// A fast hash is sufficient because the token carries 256 bits of entropy.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,11 +2,15 @@
 package security
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
 	"golang.org/x/crypto/argon2"
@@ -32,13 +36,24 @@ var defaultParams = &Argon2idParams{
 
 // HashPassword creates a secure Argon2id hash of a given password.
 // The output format is "argon2id$v=19$m=[memory],t=[iterations],p=[parallelism]$[salt]$[hash]".
+// It blocks while the maximum number of concurrent hash operations are running.
 func HashPassword(password string) (string, error) {
+	return HashPasswordContext(context.Background(), password)
+}
+
+// HashPasswordContext is HashPassword that stops waiting for a free hashing slot once ctx ends.
+func HashPasswordContext(ctx context.Context, password string) (string, error) {
 	salt := make([]byte, defaultParams.SaltLength)
 	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to generate salt: %w", err)
 	}
 
+	release, err := hashes.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
 	hash := argon2.IDKey([]byte(password), salt, defaultParams.Iterations, defaultParams.Memory, defaultParams.Parallelism, defaultParams.KeyLength)
+	release()
 
 	// Encode salt and hash to Base64
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
@@ -53,19 +68,76 @@ func HashPassword(password string) (string, error) {
 
 // ComparePasswordAndHash securely compares a plaintext password with a stored Argon2id hash.
 // It returns an error if the password does not match or if the hash is malformed.
+// Like HashPassword, it blocks while the hashing limit is reached.
 func ComparePasswordAndHash(password, encodedHash string) error {
+	return ComparePasswordAndHashContext(context.Background(), password, encodedHash)
+}
+
+// ComparePasswordAndHashContext is ComparePasswordAndHash that stops waiting for a free
+// hashing slot once ctx ends.
+func ComparePasswordAndHashContext(ctx context.Context, password, encodedHash string) error {
+	_, err := comparePasswordAndHash(ctx, password, encodedHash)
+	return err
+}
+
+// ComparePasswordAndHashWithUpgrade is ComparePasswordAndHashContext that also reports whether
+// a matching hash was created with weaker parameters than the current ones. Callers should then
+// re-hash the verified password with HashPasswordContext and store the result.
+func ComparePasswordAndHashWithUpgrade(ctx context.Context, password, encodedHash string) (needsRehash bool, err error) {
+	params, err := comparePasswordAndHash(ctx, password, encodedHash)
+	if err != nil {
+		return false, err
+	}
+	return params.weakerThan(defaultParams), nil
+}
+
+func comparePasswordAndHash(ctx context.Context, password, encodedHash string) (*Argon2idParams, error) {
 	params, salt, hash, err := decodeHash(encodedHash)
 	if err != nil {
-		return apierror.NewInternalServer(fmt.Errorf("failed to decode hash: %w", err))
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to decode hash: %w", err))
 	}
 
+	release, err := hashes.acquire(ctx)
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
 	otherHash := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	release()
 
 	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
-		return nil
+		return params, nil
 	}
 
-	return apierror.NewUnauthorized("invalid credentials", nil)
+	return nil, apierror.NewUnauthorized("invalid credentials", nil)
+}
+
+// weakerThan reports whether any cost or length parameter of p is below that of target.
+func (p *Argon2idParams) weakerThan(target *Argon2idParams) bool {
+	return p.Memory < target.Memory ||
+		p.Iterations < target.Iterations ||
+		p.Parallelism < target.Parallelism ||
+		p.SaltLength < target.SaltLength ||
+		p.KeyLength < target.KeyLength
+}
+
+// dummyHash is a hash of a random password with the default parameters, computed on first use.
+var dummyHash = sync.OnceValue(func() string {
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		panic(fmt.Sprintf("security: failed to generate dummy password: %v", err))
+	}
+	hash, err := HashPassword(base64.RawStdEncoding.EncodeToString(password))
+	if err != nil {
+		panic(fmt.Sprintf("security: failed to compute dummy hash: %v", err))
+	}
+	return hash
+})
+
+// CompareDummyHash performs the same Argon2id work as ComparePasswordAndHashContext against a
+// hash no password matches. Login paths call it when there is no real hash to check, so response
+// timing does not reveal whether an account exists. It queues for a hashing slot like a real check.
+func CompareDummyHash(ctx context.Context, password string) {
+	_ = ComparePasswordAndHashContext(ctx, password, dummyHash())
 }
 
 // decodeHash parses the modular crypt format hash string.
@@ -105,3 +177,29 @@ func decodeHash(encodedHash string) (*Argon2idParams, []byte, []byte, error) {
 
 	return params, salt, hash, nil
 }
+
+// MinPasswordLength is the shortest password accepted when a user sets or changes a password.
+const MinPasswordLength = 10
+
+// CheckPasswordStrength enforces the baseline password policy: a minimum length and a mix of
+// lower-case letters, upper-case letters and digits. The returned error is safe to show to users.
+func CheckPasswordStrength(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return apierror.NewBadRequest(fmt.Sprintf("Password must be at least %d characters long.", MinPasswordLength), nil)
+	}
+	var hasLower, hasUpper, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLower || !hasUpper || !hasDigit {
+		return apierror.NewBadRequest("Password must contain upper-case and lower-case letters and at least one digit.", nil)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+// PhonePurposeVerified is the purpose of the token a guest receives for entering the code texted
+// to their phone. Public booking requires one for the number being booked.
+const PhonePurposeVerified = "phone_verified"
+
+// phoneTokenAssertion is bound to every phone token as the PASETO implicit assertion, so it never
+// verifies as any other kind of token, and no other kind verifies as a phone token.
+var phoneTokenAssertion = []byte("mastara:phone:v1")
+
+// otpDigits is the length of the codes texted to guests.
+const otpDigits = 6
+
+// NewOTPCode returns a random numeric code of six digits, leading zeros included.
+func NewOTPCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
+
+// HashOTPCode returns the hex HMAC-SHA256 of a code under key, bound to the verification it was
+// issued for. A six-digit code has too little entropy for a plain hash to protect it, so the key
+// must be a server secret.
+func HashOTPCode(key []byte, verificationID uuid.UUID, code string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(verificationID[:])
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PhonePayload proves its holder entered the code texted to PhoneNumber for one clinic.
+type PhonePayload struct {
+	TokenID     uuid.UUID
+	ClinicID    uuid.UUID
+	PhoneNumber string
+	Purpose     string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+}
+
+// NewPhonePayload creates the payload for a phone token that expires after duration.
+func NewPhonePayload(clinicID uuid.UUID, phoneNumber, purpose string, duration time.Duration) (*PhonePayload, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now().UTC()
+	return &PhonePayload{
+		TokenID:     tokenID,
+		ClinicID:    clinicID,
+		PhoneNumber: phoneNumber,
+		Purpose:     purpose,
+		IssuedAt:    now,
+		ExpiresAt:   now.Add(duration),
+	}, nil
+}
+
+// CreatePhoneToken encodes the payload as a PASETO token of the manager's mode.
+func (m *PasetoManager) CreatePhoneToken(payload *PhonePayload) (string, error) {
+	token := paseto.NewToken()
+	token.SetJti(payload.TokenID.String())
+	token.SetIssuer(m.issuer)
+	token.SetAudience(m.audience)
+	token.SetIssuedAt(payload.IssuedAt)
+	token.SetNotBefore(payload.IssuedAt)
+	token.SetExpiration(payload.ExpiresAt)
+	token.SetSubject(payload.PhoneNumber)
+	token.SetString("cid", payload.ClinicID.String())
+	token.SetString("purpose", payload.Purpose)
+
+	if m.mode == TokenModePublic {
+		return token.V4Sign(m.secretKey, phoneTokenAssertion), nil
+	}
+	return token.V4Encrypt(m.symmetricKey, phoneTokenAssertion), nil
+}
+
+// VerifyPhoneToken checks a phone token's signature, issuer, audience and expiry and returns its
+// payload. It does not know whether the token was already used; callers record TokenID for that.
+func (m *PasetoManager) VerifyPhoneToken(tokenString string) (*PhonePayload, error) {
+	parser := paseto.MakeParser([]paseto.Rule{
+		paseto.IssuedBy(m.issuer),
+		paseto.ForAudience(m.audience),
+		validWithLeeway(m.leeway),
+	})
+	var (
+		token *paseto.Token
+		err   error
+	)
+	if m.mode == TokenModePublic {
+		token, err = parser.ParseV4Public(m.publicKey, tokenString, phoneTokenAssertion)
+	} else {
+		token, err = parser.ParseV4Local(m.symmetricKey, tokenString, phoneTokenAssertion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse or validate phone token: %w", err)
+	}
+
+	payload := &PhonePayload{}
+	if payload.TokenID, err = parseUUIDClaim(token.GetJti); err != nil {
+		return nil, fmt.Errorf("invalid jti in phone token: %w", err)
+	}
+	if payload.PhoneNumber, err = token.GetSubject(); err != nil {
+		return nil, fmt.Errorf("failed to get subject from phone token: %w", err)
+	}
+	if payload.ClinicID, err = parseUUIDClaim(func() (string, error) { return token.GetString("cid") }); err != nil {
+		return nil, fmt.Errorf("invalid clinic id in phone token: %w", err)
+	}
+	if payload.Purpose, err = token.GetString("purpose"); err != nil {
+		return nil, fmt.Errorf("failed to get purpose from phone token: %w", err)
+	}
+	if payload.IssuedAt, err = token.GetIssuedAt(); err != nil {
+		return nil, fmt.Errorf("failed to get phone token iat: %w", err)
+	}
+	if payload.ExpiresAt, err = token.GetExpiration(); err != nil {
+		return nil, fmt.Errorf("failed to get phone token exp: %w", err)
+	}
+	return payload, nil
+}
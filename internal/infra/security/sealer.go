@@ -0,0 +1,68 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Sealer encrypts small secrets that must be stored for a while, such as a queued message carrying
+// a single-use token, with AES-256-GCM. Each purpose derives its own key from the shared secret,
+// so a value sealed for one purpose cannot be opened as another.
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+// NewSealer creates a sealer whose key is the HMAC-SHA256 of purpose under secret, such as the
+// configured PASETO key.
+func NewSealer(secret []byte, purpose string) *Sealer {
+	// A 32-byte key always makes a valid AES-256 block, and AES always supports GCM.
+	block, err := aes.NewCipher(DeriveKey(secret, purpose))
+	if err != nil {
+		panic(fmt.Sprintf("security: failed to create sealer cipher: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("security: failed to create sealer cipher: %v", err))
+	}
+	return &Sealer{aead: aead}
+}
+
+// DeriveKey returns a 32-byte key for one purpose, the HMAC-SHA256 of purpose under secret.
+// Keys derived for different purposes are unrelated, so one shared secret can back several.
+func DeriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Seal encrypts plaintext and returns it with its nonce, URL-safe base64 encoded.
+func (s *Sealer) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("security: failed to generate nonce: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Open decrypts a value returned by Seal, failing if it was altered or sealed under another key.
+func (s *Sealer) Open(sealed string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("security: malformed sealed value: %w", err)
+	}
+	if len(data) < s.aead.NonceSize() {
+		return nil, errors.New("security: sealed value is too short")
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("security: failed to open sealed value: %w", err)
+	}
+	return plaintext, nil
+}
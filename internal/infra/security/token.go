@@ -1,6 +1,7 @@
 package security
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"time"
 
@@ -18,6 +19,10 @@ type AuthPayload struct {
 	Permissions []string    `json:"perms"`
 	IssuedAt    time.Time   `json:"iat"`
 	ExpiresAt   time.Time   `json:"exp"`
+	// ImpersonatorID is the real operator's ID when a platform admin is acting as UserID.
+	ImpersonatorID *uuid.UUID `json:"imp,omitempty"`
+	// Sandbox marks tokens issued for a demo/trial clinic so UIs can badge them.
+	Sandbox bool `json:"sbx,omitempty"`
 }
 
 // NewAuthPayload creates a new payload for a user token.
@@ -48,32 +53,132 @@ func (p *AuthPayload) IsValid() error {
 	return nil
 }
 
+// IsImpersonated reports whether the token was minted for an operator acting as another user.
+func (p *AuthPayload) IsImpersonated() bool {
+	return p.ImpersonatorID != nil
+}
+
+// ActorID returns the identity that is really performing the request.
+// During impersonation this is the operator, otherwise it is the token's user.
+func (p *AuthPayload) ActorID() uuid.UUID {
+	if p.ImpersonatorID != nil {
+		return *p.ImpersonatorID
+	}
+	return p.UserID
+}
+
+// Token modes. Local tokens are encrypted with the shared symmetric key; public tokens are
+// signed with an Ed25519 key so other services can verify them holding only the public key.
+const (
+	TokenModeLocal  = "local"
+	TokenModePublic = "public"
+)
+
 // PasetoManager is a PASETO token manager using the aidantwoods/go-paseto library.
+// It issues and accepts either v4.local or v4.public tokens, never both.
 type PasetoManager struct {
+	mode         string
 	symmetricKey paseto.V4SymmetricKey
+	secretKey    paseto.V4AsymmetricSecretKey
+	publicKey    paseto.V4AsymmetricPublicKey
+	issuer       string
+	audience     string
+	leeway       time.Duration
 }
 
-// NewPasetoManager creates a new PasetoManager.
+// NewPasetoManager creates a new PasetoManager for cfg.TokenMode, loading the key material that
+// mode needs. In public mode the optional public key, when configured, must match the private key.
 func NewPasetoManager(cfg config.SecurityConfig) (*PasetoManager, error) {
-	if len(cfg.PasetoKey) != 32 {
-		return nil, fmt.Errorf("invalid paseto key size: must be exactly 32 characters")
+	if cfg.TokenIssuer == "" || cfg.TokenAudience == "" {
+		return nil, fmt.Errorf("token issuer and audience must be configured")
+	}
+	if cfg.TokenLeeway < 0 {
+		return nil, fmt.Errorf("token leeway cannot be negative")
 	}
 
-	key, err := paseto.V4SymmetricKeyFromBytes([]byte(cfg.PasetoKey))
+	m, err := newKeyedManager(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to construct paseto symmetric key: %w", err)
+		return nil, err
 	}
+	m.issuer = cfg.TokenIssuer
+	m.audience = cfg.TokenAudience
+	m.leeway = cfg.TokenLeeway
+	return m, nil
+}
+
+// newKeyedManager creates a manager holding the key material for cfg.TokenMode.
+func newKeyedManager(cfg config.SecurityConfig) (*PasetoManager, error) {
+	switch cfg.TokenMode {
+	case TokenModeLocal, "":
+		if len(cfg.PasetoKey) != 32 {
+			return nil, fmt.Errorf("invalid paseto key size: must be exactly 32 characters")
+		}
+		key, err := paseto.V4SymmetricKeyFromBytes([]byte(cfg.PasetoKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct paseto symmetric key: %w", err)
+		}
+		return &PasetoManager{mode: TokenModeLocal, symmetricKey: key}, nil
 
-	return &PasetoManager{
-		symmetricKey: key,
-	}, nil
+	case TokenModePublic:
+		secretKey, err := parseSecretKey(cfg.PasetoPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		publicKey := secretKey.Public()
+		if cfg.PasetoPublicKey != "" {
+			configured, err := paseto.NewV4AsymmetricPublicKeyFromHex(cfg.PasetoPublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to construct paseto public key: %w", err)
+			}
+			if configured.ExportHex() != publicKey.ExportHex() {
+				return nil, fmt.Errorf("paseto public key does not match the private key")
+			}
+		}
+		return &PasetoManager{mode: TokenModePublic, secretKey: secretKey, publicKey: publicKey}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown token mode %q: must be %q or %q", cfg.TokenMode, TokenModeLocal, TokenModePublic)
+	}
 }
 
-// CreateToken creates a new PASETO v4.local token for a given payload.
+// parseSecretKey accepts a hex-encoded Ed25519 private key, either as a 32-byte seed or in its
+// 64-byte expanded form.
+func parseSecretKey(hexKey string) (paseto.V4AsymmetricSecretKey, error) {
+	var (
+		key paseto.V4AsymmetricSecretKey
+		err error
+	)
+	switch len(hexKey) {
+	case 2 * ed25519.SeedSize:
+		key, err = paseto.NewV4AsymmetricSecretKeyFromSeed(hexKey)
+	case 2 * ed25519.PrivateKeySize:
+		key, err = paseto.NewV4AsymmetricSecretKeyFromHex(hexKey)
+	default:
+		return key, fmt.Errorf("invalid paseto private key: must be a hex-encoded 32-byte seed or 64-byte Ed25519 key")
+	}
+	if err != nil {
+		return key, fmt.Errorf("failed to construct paseto private key: %w", err)
+	}
+	return key, nil
+}
+
+// PublicKeyHex returns the hex-encoded key other services verify public tokens with.
+// It reports false in local mode, where no key can be shared safely.
+func (m *PasetoManager) PublicKeyHex() (string, bool) {
+	if m.mode != TokenModePublic {
+		return "", false
+	}
+	return m.publicKey.ExportHex(), true
+}
+
+// CreateToken creates a new PASETO v4.local or v4.public token, depending on the mode, for a given payload.
 func (m *PasetoManager) CreateToken(payload *AuthPayload) (string, error) {
 	token := paseto.NewToken()
 	token.SetJti(payload.TokenID.String())
+	token.SetIssuer(m.issuer)
+	token.SetAudience(m.audience)
 	token.SetIssuedAt(payload.IssuedAt)
+	token.SetNotBefore(payload.IssuedAt)
 	token.SetExpiration(payload.ExpiresAt)
 
 	// --- THIS IS THE CRITICAL CORRECTION ---
@@ -82,17 +187,39 @@ func (m *PasetoManager) CreateToken(payload *AuthPayload) (string, error) {
 	token.SetString("cid", payload.ClinicID.String())
 	token.Set("roles", payload.RoleIDs)
 	token.Set("perms", payload.Permissions)
+	if payload.ImpersonatorID != nil {
+		token.SetString("imp", payload.ImpersonatorID.String())
+	}
+	if payload.Sandbox {
+		token.Set("sbx", true)
+	}
 
-	// V4Encrypt returns a single string value.
-	encryptedToken := token.V4Encrypt(m.symmetricKey, nil)
-	return encryptedToken, nil
+	// V4Sign and V4Encrypt return a single string value.
+	if m.mode == TokenModePublic {
+		return token.V4Sign(m.secretKey, nil), nil
+	}
+	return token.V4Encrypt(m.symmetricKey, nil), nil
 	// --- END CORRECTION ---
 }
 
-// VerifyToken checks if the token is valid and returns its payload.
+// VerifyToken checks if the token is valid and returns its payload. The issuer and audience
+// must match this manager's, and exp and nbf are checked with the configured leeway.
+// Tokens of the other mode are rejected.
 func (m *PasetoManager) VerifyToken(tokenString string) (*AuthPayload, error) {
-	parser := paseto.NewParser()
-	token, err := parser.ParseV4Local(m.symmetricKey, tokenString, nil)
+	parser := paseto.MakeParser([]paseto.Rule{
+		paseto.IssuedBy(m.issuer),
+		paseto.ForAudience(m.audience),
+		validWithLeeway(m.leeway),
+	})
+	var (
+		token *paseto.Token
+		err   error
+	)
+	if m.mode == TokenModePublic {
+		token, err = parser.ParseV4Public(m.publicKey, tokenString, nil)
+	} else {
+		token, err = parser.ParseV4Local(m.symmetricKey, tokenString, nil)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse or validate token: %w", err)
 	}
@@ -140,9 +267,45 @@ func (m *PasetoManager) VerifyToken(tokenString string) (*AuthPayload, error) {
 		return nil, fmt.Errorf("failed to get permissions from token: %w", err)
 	}
 
-	if err := payload.IsValid(); err != nil {
-		return nil, err
+	// The impersonation claim is optional; only regular tokens omit it.
+	if impersonatorStr, err := token.GetString("imp"); err == nil {
+		impersonatorID, err := uuid.Parse(impersonatorStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid impersonator id in token: %w", err)
+		}
+		payload.ImpersonatorID = &impersonatorID
+	}
+
+	// The sandbox claim is only present on tokens for sandbox clinics.
+	var sandbox bool
+	if err := token.Get("sbx", &sandbox); err == nil {
+		payload.Sandbox = sandbox
 	}
 
 	return payload, nil
 }
+
+// validWithLeeway requires exp and nbf and accepts tokens up to leeway past expiry or before
+// their start, so small clock differences between pods do not reject fresh tokens.
+func validWithLeeway(leeway time.Duration) paseto.Rule {
+	return func(token paseto.Token) error {
+		now := time.Now()
+
+		exp, err := token.GetExpiration()
+		if err != nil {
+			return fmt.Errorf("token has no valid exp claim: %w", err)
+		}
+		if now.After(exp.Add(leeway)) {
+			return fmt.Errorf("token has expired")
+		}
+
+		nbf, err := token.GetNotBefore()
+		if err != nil {
+			return fmt.Errorf("token has no valid nbf claim: %w", err)
+		}
+		if now.Add(leeway).Before(nbf) {
+			return fmt.Errorf("token is not valid yet")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,204 @@
+package security
+
+import (
+	"encoding/hex"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/google/uuid"
+)
+
+const testPasetoKey = "0123456789abcdef0123456789abcdef"
+
+// testSeed is a fixed Ed25519 seed, hex encoded.
+var testSeed = hex.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+
+func tokenConfig(mode string) config.SecurityConfig {
+	return config.SecurityConfig{
+		TokenMode:        mode,
+		PasetoKey:        testPasetoKey,
+		PasetoPrivateKey: testSeed,
+		TokenIssuer:      "mastara-test",
+		TokenAudience:    "mastara-api",
+		TokenLeeway:      30 * time.Second,
+	}
+}
+
+func newManager(t *testing.T, cfg config.SecurityConfig) *PasetoManager {
+	t.Helper()
+	m, err := NewPasetoManager(cfg)
+	if err != nil {
+		t.Fatalf("NewPasetoManager: %v", err)
+	}
+	return m
+}
+
+func newPayload(t *testing.T, duration time.Duration) *AuthPayload {
+	t.Helper()
+	payload, err := NewAuthPayload(uuid.New(), uuid.New(), []uuid.UUID{uuid.New()}, []string{"patients.read"}, duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonator := uuid.New()
+	payload.ImpersonatorID = &impersonator
+	payload.Sandbox = true
+	return payload
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	for _, mode := range []string{TokenModeLocal, TokenModePublic} {
+		t.Run(mode, func(t *testing.T) {
+			m := newManager(t, tokenConfig(mode))
+			want := newPayload(t, time.Hour)
+
+			token, err := m.CreateToken(want)
+			if err != nil {
+				t.Fatalf("CreateToken: %v", err)
+			}
+			got, err := m.VerifyToken(token)
+			if err != nil {
+				t.Fatalf("VerifyToken: %v", err)
+			}
+
+			if got.TokenID != want.TokenID || got.UserID != want.UserID || got.ClinicID != want.ClinicID ||
+				!slices.Equal(got.RoleIDs, want.RoleIDs) || !slices.Equal(got.Permissions, want.Permissions) ||
+				*got.ImpersonatorID != *want.ImpersonatorID || got.Sandbox != want.Sandbox {
+				t.Errorf("VerifyToken = %+v, want %+v", got, want)
+			}
+			if !got.ExpiresAt.Equal(want.ExpiresAt.Truncate(time.Second)) {
+				t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, want.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestPublicTokensVerifyWithThePublicKeyOnly(t *testing.T) {
+	signer := newManager(t, tokenConfig(TokenModePublic))
+	publicKey, ok := signer.PublicKeyHex()
+	if !ok {
+		t.Fatal("PublicKeyHex reported no key in public mode")
+	}
+	token, err := signer.CreateToken(newPayload(t, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A manager with a key pair of its own neither accepts the signer's public key nor its tokens.
+	otherConfig := tokenConfig(TokenModePublic)
+	otherConfig.PasetoPrivateKey = hex.EncodeToString([]byte("0000000000000000000000000000000a"))
+	if _, err := NewPasetoManager(withPublicKey(otherConfig, publicKey)); err == nil {
+		t.Error("NewPasetoManager accepted a public key that does not match the private key")
+	}
+	if _, err := newManager(t, otherConfig).VerifyToken(token); err == nil {
+		t.Error("a token signed with another key verified")
+	}
+	if _, err := newManager(t, withPublicKey(tokenConfig(TokenModePublic), publicKey)).VerifyToken(token); err != nil {
+		t.Errorf("VerifyToken with the matching key pair: %v", err)
+	}
+}
+
+func withPublicKey(cfg config.SecurityConfig, publicKey string) config.SecurityConfig {
+	cfg.PasetoPublicKey = publicKey
+	return cfg
+}
+
+func TestTokenModesRejectEachOther(t *testing.T) {
+	local := newManager(t, tokenConfig(TokenModeLocal))
+	public := newManager(t, tokenConfig(TokenModePublic))
+
+	localToken, err := local.CreateToken(newPayload(t, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := public.VerifyToken(localToken); err == nil {
+		t.Error("public mode accepted a local token")
+	}
+
+	publicToken, err := public.CreateToken(newPayload(t, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.VerifyToken(publicToken); err == nil {
+		t.Error("local mode accepted a public token")
+	}
+
+	if _, ok := local.PublicKeyHex(); ok {
+		t.Error("PublicKeyHex reported a key in local mode")
+	}
+}
+
+func TestNewPasetoManagerRejectsBadKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*config.SecurityConfig)
+	}{
+		{"short local key", func(c *config.SecurityConfig) { c.TokenMode, c.PasetoKey = TokenModeLocal, "short" }},
+		{"missing private key", func(c *config.SecurityConfig) { c.TokenMode, c.PasetoPrivateKey = TokenModePublic, "" }},
+		{"private key not hex", func(c *config.SecurityConfig) { c.TokenMode, c.PasetoPrivateKey = TokenModePublic, testSeed[:62]+"zz" }},
+		{"unknown mode", func(c *config.SecurityConfig) { c.TokenMode = "v2" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tokenConfig(TokenModeLocal)
+			tt.mutate(&cfg)
+			if _, err := NewPasetoManager(cfg); err == nil {
+				t.Error("NewPasetoManager succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestVerifyTokenChecksIssuerAndAudience(t *testing.T) {
+	token, err := newManager(t, tokenConfig(TokenModeLocal)).CreateToken(newPayload(t, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*config.SecurityConfig)
+	}{
+		{"wrong issuer", func(c *config.SecurityConfig) { c.TokenIssuer = "mastara-staging" }},
+		{"wrong audience", func(c *config.SecurityConfig) { c.TokenAudience = "mastara-reports" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tokenConfig(TokenModeLocal)
+			tt.mutate(&cfg)
+			if _, err := newManager(t, cfg).VerifyToken(token); err == nil {
+				t.Error("VerifyToken accepted a token minted for another issuer or audience")
+			}
+		})
+	}
+}
+
+func TestVerifyTokenLeeway(t *testing.T) {
+	m := newManager(t, tokenConfig(TokenModeLocal))
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		issued, expires time.Time
+		wantValid       bool
+	}{
+		{"just past expiry within leeway", now.Add(-time.Hour), now.Add(-10 * time.Second), true},
+		{"past expiry beyond leeway", now.Add(-time.Hour), now.Add(-time.Minute), false},
+		{"issued slightly in the future", now.Add(10 * time.Second), now.Add(time.Hour), true},
+		{"issued beyond leeway in the future", now.Add(time.Minute), now.Add(time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := newPayload(t, time.Hour)
+			payload.IssuedAt, payload.ExpiresAt = tt.issued, tt.expires
+			token, err := m.CreateToken(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.VerifyToken(token); (err == nil) != tt.wantValid {
+				t.Errorf("VerifyToken error = %v, want valid %t", err, tt.wantValid)
+			}
+		})
+	}
+}
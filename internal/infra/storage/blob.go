@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+)
+
+// Backends a BlobStore can be configured with.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// BlobStore keeps files that clients download directly, such as patient documents. Clients never
+// read through the API: they get a signed URL that stops working after a while.
+type BlobStore interface {
+	// Put writes r under key, replacing any existing blob, and returns the number of bytes written.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// GetSignedURL returns a URL the blob under key can be downloaded from until ttl has passed.
+	GetSignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore returns the blob store cfg.Backend selects. The local backend shares local, and
+// signs its URLs with signingKey.
+func NewBlobStore(cfg config.StorageConfig, local *LocalStore, signingKey []byte) (BlobStore, error) {
+	switch cfg.Backend {
+	case BackendLocal, "":
+		return NewSignedLocalStore(local, cfg.PublicURL, signingKey), nil
+	case BackendS3:
+		return NewS3Store(cfg.S3, http.DefaultClient)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Backend)
+	}
+}
+
+// SignedLocalStore is a LocalStore whose files are downloaded from the API itself, through
+// ServeSigned, at URLs signed with an HMAC over the key and expiry.
+type SignedLocalStore struct {
+	*LocalStore
+	// baseURL is where ServeSigned is mounted, e.g. "https://api.example.com/files".
+	baseURL string
+	key     []byte
+}
+
+// NewSignedLocalStore creates a store that signs download URLs under baseURL with key.
+func NewSignedLocalStore(local *LocalStore, baseURL string, key []byte) *SignedLocalStore {
+	return &SignedLocalStore{LocalStore: local, baseURL: strings.TrimSuffix(baseURL, "/"), key: key}
+}
+
+// GetSignedURL returns baseURL/key?expires=&signature=.
+func (s *SignedLocalStore) GetSignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(ttl).Unix()
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {s.sign(key, expires)},
+	}
+	return s.baseURL + "/" + key + "?" + query.Encode(), nil
+}
+
+// ServeSigned serves the file under the request path, relative to where it is mounted, if the
+// URL carries a valid, unexpired signature. Mount it with http.StripPrefix.
+func (s *SignedLocalStore) ServeSigned() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		if err != nil || !s.validSignature(key, expires, r.URL.Query().Get("signature")) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		if time.Now().Unix() > expires {
+			http.Error(w, "link expired", http.StatusForbidden)
+			return
+		}
+
+		f, err := s.Open(r.Context(), key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, "failed to open file", http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		// Uploaded content is never rendered in the API's origin.
+		w.Header().Set("Content-Disposition", "attachment")
+		w.Header().Set("Cache-Control", "private, no-store")
+		if seeker, ok := f.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, "", time.Time{}, seeker)
+			return
+		}
+		_, _ = io.Copy(w, f)
+	})
+}
+
+func (s *SignedLocalStore) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *SignedLocalStore) validSignature(key string, expires int64, signature string) bool {
+	return hmac.Equal([]byte(s.sign(key, expires)), []byte(signature))
+}
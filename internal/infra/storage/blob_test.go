@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "clinics/c1/documents/d1"
+
+func newTestSignedStore(t *testing.T) (*SignedLocalStore, *httptest.Server) {
+	t.Helper()
+	local, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	store := NewSignedLocalStore(local, srv.URL+"/files/", []byte("test-signing-key"))
+	mux.Handle("/files/", http.StripPrefix("/files", store.ServeSigned()))
+	return store, srv
+}
+
+func fetch(t *testing.T, rawURL string) (int, http.Header, string) {
+	t.Helper()
+	resp, err := http.Get(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header, string(body)
+}
+
+func TestSignedLocalStoreRoundTrip(t *testing.T) {
+	store, _ := newTestSignedStore(t)
+	ctx := context.Background()
+
+	n, err := store.Put(ctx, testKey, strings.NewReader("%PDF-1.7 lab result"))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if n != 19 {
+		t.Fatalf("Put wrote %d bytes, want 19", n)
+	}
+
+	signed, err := store.GetSignedURL(ctx, testKey, time.Minute)
+	if err != nil {
+		t.Fatalf("GetSignedURL: %v", err)
+	}
+	status, header, body := fetch(t, signed)
+	if status != http.StatusOK || body != "%PDF-1.7 lab result" {
+		t.Fatalf("GET signed URL = %d %q, want 200 with the file", status, body)
+	}
+	if got := header.Get("Content-Disposition"); got != "attachment" {
+		t.Errorf("Content-Disposition = %q, want attachment", got)
+	}
+
+	if err := store.Delete(ctx, testKey); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if status, _, _ := fetch(t, signed); status != http.StatusNotFound {
+		t.Errorf("GET after Delete = %d, want 404", status)
+	}
+	if err := store.Delete(ctx, testKey); err != nil {
+		t.Errorf("deleting a missing blob: %v", err)
+	}
+}
+
+func TestSignedLocalStoreRejectsBadLinks(t *testing.T) {
+	store, _ := newTestSignedStore(t)
+	ctx := context.Background()
+	if _, err := store.Put(ctx, testKey, strings.NewReader("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put(ctx, "clinics/c2/documents/d2", strings.NewReader("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := store.GetSignedURL(ctx, testKey, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := store.GetSignedURL(ctx, testKey, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(u *url.URL)
+	}{
+		{"expired", func(u *url.URL) { *u = *mustParse(t, expired) }},
+		{"tampered signature", func(u *url.URL) {
+			q := u.Query()
+			q.Set("signature", strings.Repeat("0", 64))
+			u.RawQuery = q.Encode()
+		}},
+		{"extended expiry", func(u *url.URL) {
+			q := u.Query()
+			q.Set("expires", "99999999999")
+			u.RawQuery = q.Encode()
+		}},
+		{"other key", func(u *url.URL) { u.Path = "/files/clinics/c2/documents/d2" }},
+		{"missing signature", func(u *url.URL) { u.RawQuery = "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := mustParse(t, signed)
+			tt.mutate(u)
+			status, _, body := fetch(t, u.String())
+			if status != http.StatusForbidden {
+				t.Fatalf("GET = %d %q, want 403", status, body)
+			}
+		})
+	}
+}
+
+func TestSignedLocalStoreRejectsEscapingKeys(t *testing.T) {
+	store, _ := newTestSignedStore(t)
+	for _, key := range []string{"../outside", "clinics/../../etc/passwd", "/absolute", `clinics\c1`, ""} {
+		if _, err := store.GetSignedURL(context.Background(), key, time.Minute); err == nil {
+			t.Errorf("GetSignedURL(%q) succeeded, want an invalid key error", key)
+		}
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("Put(%q) succeeded, want an invalid key error", key)
+		}
+	}
+}
+
+func mustParse(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
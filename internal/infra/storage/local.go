@@ -0,0 +1,123 @@
+// Package storage keeps uploaded files. Files are addressed by slash-separated keys
+// such as "clinics/<clinic_id>/files/<id>", relative to the store's root.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when no file exists under a key.
+var ErrNotFound = errors.New("storage: file not found")
+
+// LocalStore keeps files in a directory on the local filesystem.
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore creates a store rooted at dir, creating the directory if needed.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("storage: failed to create root %q: %w", dir, err)
+	}
+	return &LocalStore{root: dir}, nil
+}
+
+// Put writes r under key, replacing any existing file, and returns the number of bytes written.
+// The data is written to a temporary file first so readers never see a partial file.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return 0, fmt.Errorf("storage: failed to create directory for %q: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".put-*")
+	if err != nil {
+		return 0, fmt.Errorf("storage: failed to create temp file for %q: %w", key, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed.
+
+	n, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("storage: failed to write %q: %w", key, err)
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return 0, fmt.Errorf("storage: failed to move %q into place: %w", key, err)
+	}
+	return n, nil
+}
+
+// Open returns a reader for the file under key, or ErrNotFound.
+func (s *LocalStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("storage: failed to open %q: %w", key, err)
+	}
+	return f, nil
+}
+
+// Delete removes the file under key. Deleting a missing file is not an error.
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: failed to delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// DeletePrefix removes every file whose key starts with prefix + "/".
+func (s *LocalStore) DeletePrefix(_ context.Context, prefix string) error {
+	target, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("storage: failed to delete %q: %w", prefix, err)
+	}
+	return nil
+}
+
+// path maps a key to a filesystem path, rejecting keys that would escape the root.
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+// contextReader stops a copy once its context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+)
+
+// s3Service is the service name in Signature Version 4 scopes.
+const s3Service = "s3"
+
+// maxPresignTTL is the longest expiry S3 accepts for a presigned URL.
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// S3Store keeps blobs in a bucket of an S3-compatible object store (AWS S3, MinIO, R2, ...),
+// signing every request with AWS Signature Version 4.
+type S3Store struct {
+	client    *http.Client
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	// now is the store's clock; signatures embed the current time.
+	now func() time.Time
+}
+
+// NewS3Store creates a store for cfg.Bucket at cfg.Endpoint, e.g. "https://s3.eu-central-1.amazonaws.com".
+func NewS3Store(cfg config.S3Config, client *http.Client) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.Region == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("storage: the s3 backend needs an endpoint, region, bucket and credentials")
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	return &S3Store{
+		client:    client,
+		endpoint:  endpoint,
+		region:    cfg.Region,
+		bucket:    cfg.Bucket,
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey,
+		pathStyle: cfg.UsePathStyle,
+		now:       time.Now,
+	}, nil
+}
+
+// Put uploads r under key. S3 needs the length and, for a signed payload, the hash up front, so
+// the body is spooled to a temporary file first.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	spool, err := os.CreateTemp("", "s3-put-*")
+	if err != nil {
+		return 0, fmt.Errorf("storage: failed to spool %q: %w", key, err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hasher := sha256.New()
+	n, err := io.Copy(io.MultiWriter(spool, hasher), contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return 0, fmt.Errorf("storage: failed to spool %q: %w", key, err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("storage: failed to rewind %q: %w", key, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), spool)
+	if err != nil {
+		return 0, fmt.Errorf("storage: failed to build request for %q: %w", key, err)
+	}
+	req.ContentLength = n
+	s.signRequest(req, hex.EncodeToString(hasher.Sum(nil)))
+
+	if err := s.do(req, http.StatusOK); err != nil {
+		return 0, fmt.Errorf("storage: failed to put %q: %w", key, err)
+	}
+	return n, nil
+}
+
+// Delete removes the object under key. S3 answers 204 whether or not it existed.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("storage: failed to build request for %q: %w", key, err)
+	}
+	s.signRequest(req, emptyPayloadHash)
+
+	if err := s.do(req, http.StatusNoContent, http.StatusOK, http.StatusNotFound); err != nil {
+		return fmt.Errorf("storage: failed to delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// GetSignedURL returns a presigned GET URL for the object under key.
+func (s *S3Store) GetSignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > maxPresignTTL {
+		return "", fmt.Errorf("storage: presigned URL expiry must be between 1s and %s", maxPresignTTL)
+	}
+	return s.presign(http.MethodGet, s.objectURL(key), ttl).String(), nil
+}
+
+// emptyPayloadHash is the hex SHA-256 of an empty body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// objectURL addresses key in the bucket, path-style (endpoint/bucket/key) for MinIO and most
+// self-hosted stores, or virtual-hosted (bucket.endpoint/key) for AWS.
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = s.endpoint.Path + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + s.endpoint.Host
+		u.Path = s.endpoint.Path + "/" + key
+	}
+	return &u
+}
+
+// signRequest adds the Signature Version 4 Authorization header for a body hashing to payloadHash.
+func (s *S3Store) signRequest(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// presign returns u with the Signature Version 4 query parameters for method, valid for ttl.
+func (s *S3Store) presign(method string, u *url.URL, ttl time.Duration) *url.URL {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(ttl/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI(u),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonicalRequest))
+
+	signed := *u
+	signed.RawQuery = canonicalQuery(query)
+	return &signed
+}
+
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+// signature signs canonicalRequest with the key derived for the request's date and region.
+func (s *S3Store) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// do sends req and fails unless the response status is one of ok.
+func (s *S3Store) do(req *http.Request, ok ...int) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	for _, status := range ok {
+		if resp.StatusCode == status {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalURI is the URI-encoded path, each segment encoded but the slashes kept.
+func canonicalURI(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery is the query sorted by name, names and values URI-encoded.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape percent-encodes every byte except the RFC 3986 unreserved characters, as SigV4 requires.
+func awsEscape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
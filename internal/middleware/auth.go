@@ -3,11 +3,13 @@ package middleware
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strings"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 // contextKey is an unexported type to be used as a key for context values.
@@ -16,25 +18,28 @@ type contextKey string
 
 const (
 	authPayloadKey = contextKey("auth_payload")
+	// ImpersonationHeader is set on every response served with an impersonation token.
+	ImpersonationHeader = "X-Impersonation"
 	// Error message constant
 	ErrAuthPayloadNotFoundMsg = "auth payload not found in context"
 )
 
 // Authenticator is a middleware that verifies the authentication token and injects
 // the security context (AuthPayload) into the request.
-func Authenticator(tokenManager *security.PasetoManager) gin.HandlerFunc {
+// Tokens whose jti (or subject) appears in the denylist are rejected.
+func Authenticator(tokenManager *security.PasetoManager, denylist security.Denylist) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			err := apierror.NewUnauthorized("authorization header is required", nil)
-			c.AbortWithStatusJSON(err.StatusCode, gin.H{"error": err.PublicMessage})
+			abortWithAPIError(c, err)
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
 			err := apierror.NewUnauthorized("invalid authorization header format", nil)
-			c.AbortWithStatusJSON(err.StatusCode, gin.H{"error": err.PublicMessage})
+			abortWithAPIError(c, err)
 			return
 		}
 
@@ -42,10 +47,28 @@ func Authenticator(tokenManager *security.PasetoManager) gin.HandlerFunc {
 		payload, err := tokenManager.VerifyToken(token)
 		if err != nil {
 			apiErr := apierror.NewUnauthorized("invalid or expired token", err)
-			c.AbortWithStatusJSON(apiErr.StatusCode, gin.H{"error": apiErr.PublicMessage})
+			abortWithAPIError(c, apiErr)
 			return
 		}
 
+		revoked, err := denylist.IsRevoked(c.Request.Context(), payload)
+		if err != nil {
+			log.Error().Err(err).Msg("Token denylist lookup failed")
+			apiErr := apierror.NewInternalServer(err)
+			abortWithAPIError(c, apiErr)
+			return
+		}
+		if revoked {
+			apiErr := apierror.NewUnauthorized("token has been revoked", nil)
+			abortWithAPIError(c, apiErr)
+			return
+		}
+
+		// Let front-ends render an impersonation banner.
+		if payload.IsImpersonated() {
+			c.Header(ImpersonationHeader, payload.ImpersonatorID.String())
+		}
+
 		// Inject the payload into the request context.
 		ctx := context.WithValue(c.Request.Context(), authPayloadKey, payload)
 		c.Request = c.Request.WithContext(ctx)
@@ -54,6 +77,32 @@ func Authenticator(tokenManager *security.PasetoManager) gin.HandlerFunc {
 	}
 }
 
+// ImpersonationGuard blocks state-changing requests made with an impersonation token
+// when readOnly is true. It must run after the Authenticator.
+func ImpersonationGuard(readOnly bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, err := GetAuthPayload(c.Request.Context())
+		if err != nil || !payload.IsImpersonated() || !readOnly {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+		default:
+			log.Warn().
+				Str("operator_id", payload.ImpersonatorID.String()).
+				Str("user_id", payload.UserID.String()).
+				Str("method", c.Request.Method).
+				Str("path", c.Request.URL.Path).
+				Msg("Blocked state-changing request during impersonation")
+			apiErr := apierror.NewForbidden("This action is not allowed while impersonating a user.", nil)
+			abortWithAPIError(c, apiErr)
+		}
+	}
+}
+
 // GetAuthPayload retrieves the authenticated user's payload from the context.
 // It returns nil if the payload is not present.
 func GetAuthPayload(ctx context.Context) (*security.AuthPayload, error) {
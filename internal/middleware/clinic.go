@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ClinicSlugHeader lets clients that are not served from a clinic subdomain name the clinic explicitly.
+const ClinicSlugHeader = "X-Clinic-Slug"
+
+const (
+	clinicIDKey   = contextKey("clinic_id")
+	clinicSlugKey = contextKey("clinic_slug")
+	// ErrClinicNotResolvedMsg is returned when a handler expects a resolved clinic that is missing.
+	ErrClinicNotResolvedMsg = "clinic not resolved in context"
+)
+
+// ClinicResolver maps a public clinic slug to its ID.
+type ClinicResolver interface {
+	ResolveClinicID(ctx context.Context, slug string) (uuid.UUID, error)
+}
+
+// ResolveClinic identifies the tenant of an unauthenticated request and injects its ID into the context.
+// A :slug path parameter takes precedence, then the X-Clinic-Slug header; otherwise the first label
+// of the Host is used when the host is a subdomain of baseDomain (e.g. "clinic-a" in clinic-a.mastara.com).
+func ResolveClinic(resolver ClinicResolver, baseDomain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := strings.ToLower(strings.TrimSpace(c.Param("slug")))
+		if slug == "" {
+			slug = strings.ToLower(strings.TrimSpace(c.GetHeader(ClinicSlugHeader)))
+		}
+		if slug == "" {
+			slug = slugFromHost(c.Request.Host, baseDomain)
+		}
+		if slug == "" {
+			err := apierror.NewBadRequest("Unable to determine the clinic for this request.", nil)
+			abortWithAPIError(c, err)
+			return
+		}
+
+		clinicID, err := resolver.ResolveClinicID(c.Request.Context(), slug)
+		if err != nil {
+			var apiErr *apierror.APIError
+			if !errors.As(err, &apiErr) {
+				apiErr = apierror.NewInternalServer(err)
+			}
+			abortWithAPIError(c, apiErr)
+			return
+		}
+
+		ctx := context.WithValue(c.Request.Context(), clinicIDKey, clinicID)
+		ctx = context.WithValue(ctx, clinicSlugKey, slug)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// GetClinicID retrieves the clinic resolved by ResolveClinic from the context.
+func GetClinicID(ctx context.Context) (uuid.UUID, error) {
+	clinicID, ok := ctx.Value(clinicIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil, errors.New(ErrClinicNotResolvedMsg)
+	}
+	return clinicID, nil
+}
+
+// GetClinicSlug returns the slug the clinic was resolved from, or "" if none.
+func GetClinicSlug(ctx context.Context) string {
+	slug, _ := ctx.Value(clinicSlugKey).(string)
+	return slug
+}
+
+// slugFromHost extracts the single subdomain label in front of baseDomain.
+func slugFromHost(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	label, ok := strings.CutSuffix(host, "."+strings.ToLower(baseDomain))
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"expvar"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// concurrencyVars publishes every limiter's counters under /debug/vars as "http_concurrency".
+var concurrencyVars = expvar.NewMap("http_concurrency")
+
+// ConcurrencyLimiter is a semaphore bounding how many requests run at once.
+// Requests over the limit wait up to a timeout for a slot and are then rejected with 503.
+type ConcurrencyLimiter struct {
+	name       string
+	slots      chan struct{}
+	wait       time.Duration
+	retryAfter time.Duration
+	inFlight   atomic.Int64
+	rejected   atomic.Int64
+}
+
+// NewConcurrencyLimiter creates a limiter and registers its metrics under name.
+// A non-positive limit returns nil, and a nil limiter's Middleware is a no-op.
+func NewConcurrencyLimiter(name string, limit int, wait, retryAfter time.Duration) *ConcurrencyLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	l := &ConcurrencyLimiter{
+		name:       name,
+		slots:      make(chan struct{}, limit),
+		wait:       wait,
+		retryAfter: retryAfter,
+	}
+	concurrencyVars.Set(name, expvar.Func(func() any {
+		return map[string]int64{
+			"limit":     int64(cap(l.slots)),
+			"in_flight": l.inFlight.Load(),
+			"rejected":  l.rejected.Load(),
+		}
+	}))
+	return l
+}
+
+// InFlight returns the number of requests currently holding a slot.
+func (l *ConcurrencyLimiter) InFlight() int64 {
+	return l.inFlight.Load()
+}
+
+// Middleware acquires a slot for the duration of the request.
+func (l *ConcurrencyLimiter) Middleware() gin.HandlerFunc {
+	if l == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		if !l.acquire(c) {
+			return
+		}
+		defer l.release()
+		c.Next()
+	}
+}
+
+func (l *ConcurrencyLimiter) acquire(c *gin.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	case <-c.Request.Context().Done():
+		c.AbortWithStatus(apierror.StatusClientClosedRequest)
+		return false
+	case <-timer.C:
+		l.rejected.Add(1)
+		log.Warn().Str("limiter", l.name).Str("path", c.FullPath()).Msg("Request rejected: concurrency limit reached")
+		err := apierror.NewServiceUnavailable("Too many requests are in progress. Please retry shortly.", nil)
+		err.RetryAfter = l.retryAfter
+		abortWithAPIError(c, err)
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	l.inFlight.Add(-1)
+	<-l.slots
+}
+
+// RouteLimiter caps the routes whose pattern starts with Prefix, e.g. "/api/v1/patients/:id/export".
+type RouteLimiter struct {
+	Prefix  string
+	Limiter *ConcurrencyLimiter
+}
+
+// RouteLimits runs each request under the limiter of the longest prefix matching its route
+// pattern, in addition to the limiters of its group. Requests no prefix matches pass through.
+func RouteLimits(limiters []RouteLimiter) gin.HandlerFunc {
+	sorted := slices.Clone(limiters)
+	slices.SortFunc(sorted, func(a, b RouteLimiter) int { return len(b.Prefix) - len(a.Prefix) })
+	handlers := make([]gin.HandlerFunc, len(sorted))
+	for i, r := range sorted {
+		handlers[i] = r.Limiter.Middleware()
+	}
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		for i, r := range sorted {
+			if strings.HasPrefix(route, r.Prefix) {
+				handlers[i](c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// blockingEngine serves path with a handler that holds its slot until release is closed.
+func blockingEngine(path string, release <-chan struct{}, middleware ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware...)
+	engine.GET(path, func(c *gin.Context) {
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return engine
+}
+
+func get(engine *gin.Engine, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// hold starts a request to path in the background and waits until limiter counts it in flight.
+func hold(t *testing.T, engine *gin.Engine, path string, limiter *ConcurrencyLimiter) <-chan *httptest.ResponseRecorder {
+	t.Helper()
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- get(engine, path) }()
+	deadline := time.Now().Add(time.Second)
+	for limiter.InFlight() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the held request never acquired a slot")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return done
+}
+
+func TestConcurrencyLimiterRejectsWhenSaturated(t *testing.T) {
+	limiter := NewConcurrencyLimiter("test-saturated", 1, 20*time.Millisecond, 3*time.Second)
+	release := make(chan struct{})
+	engine := blockingEngine("/slow", release, limiter.Middleware())
+
+	held := hold(t, engine, "/slow", limiter)
+
+	rec := get(engine, "/slow")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3" {
+		t.Errorf("Retry-After = %q, want %q", got, "3")
+	}
+	if !strings.Contains(rec.Body.String(), "Too many requests are in progress") {
+		t.Errorf("body = %s, want the busy message", rec.Body.String())
+	}
+
+	close(release)
+	if rec := <-held; rec.Code != http.StatusOK {
+		t.Errorf("held request status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	// Once the burst has drained the limiter admits requests again.
+	if rec := get(engine, "/slow"); rec.Code != http.StatusOK {
+		t.Errorf("status after the burst = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if limiter.InFlight() != 0 {
+		t.Errorf("InFlight = %d after every request finished, want 0", limiter.InFlight())
+	}
+}
+
+func TestConcurrencyLimiterQueuesWithinWait(t *testing.T) {
+	limiter := NewConcurrencyLimiter("test-queue", 1, time.Second, time.Second)
+	release := make(chan struct{})
+	engine := blockingEngine("/slow", release, limiter.Middleware())
+
+	held := hold(t, engine, "/slow", limiter)
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+
+	if rec := get(engine, "/slow"); rec.Code != http.StatusOK {
+		t.Errorf("queued request status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	<-held
+}
+
+func TestNilConcurrencyLimiterPassesThrough(t *testing.T) {
+	limiter := NewConcurrencyLimiter("test-disabled", 0, time.Millisecond, time.Second)
+	release := make(chan struct{})
+	close(release)
+	engine := blockingEngine("/slow", release, limiter.Middleware())
+
+	if rec := get(engine, "/slow"); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRouteLimitsApplyToMatchingRoutesOnly(t *testing.T) {
+	exports := NewConcurrencyLimiter("test-exports", 1, 20*time.Millisecond, 2*time.Second)
+	release := make(chan struct{})
+	defer close(release)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RouteLimits([]RouteLimiter{
+		{Prefix: "/patients", Limiter: NewConcurrencyLimiter("test-patients", 100, time.Millisecond, time.Second)},
+		{Prefix: "/patients/:id/export", Limiter: exports},
+	}))
+	engine.GET("/patients/:id/export", func(c *gin.Context) {
+		<-release
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/patients/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	hold(t, engine, "/patients/1/export", exports)
+
+	if rec := get(engine, "/patients/2/export"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("second export status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	// Saturated exports leave the rest of the API unaffected.
+	if rec := get(engine, "/patients/2"); rec.Code != http.StatusOK {
+		t.Errorf("patient status while exports are saturated = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
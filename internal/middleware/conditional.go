@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/gin-gonic/gin"
+)
+
+// TotalCountHeader carries the size of the whole filtered set, not just the returned page.
+const TotalCountHeader = "X-Total-Count"
+
+// ConditionalList sets the ETag and X-Total-Count headers of a list response from its version.
+// It answers HEAD requests and GETs whose If-None-Match matches by itself, and then reports
+// true: the handler must return without loading or writing the list.
+//
+// The ETag also covers the query string, the negotiated API version and any variant the
+// handler passes (e.g. whether the caller may see contact details), since each changes the body.
+func ConditionalList(c *gin.Context, version database.ListVersion, variant ...string) bool {
+	etag := listETag(c, version, variant)
+	c.Header("ETag", etag)
+	c.Header(TotalCountHeader, strconv.FormatInt(version.Count, 10))
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return true
+	}
+	return false
+}
+
+// listETag returns a weak ETag: the body is equivalent, not byte-for-byte identical, across instances.
+func listETag(c *gin.Context, version database.ListVersion, variant []string) string {
+	var lastUpdated int64
+	if version.LastUpdatedAt != nil {
+		lastUpdated = version.LastUpdatedAt.UnixMicro()
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%d|%d|%s|%s|%s",
+		version.Count, lastUpdated, version.Checksum,
+		GetAPIVersion(c.Request.Context()), c.Request.URL.RawQuery, strings.Join(variant, ","))
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches applies the weak comparison If-None-Match requires to a header that may list several tags.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
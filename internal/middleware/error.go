@@ -2,7 +2,15 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
@@ -14,23 +22,94 @@ type APIHandlerFunc func(c *gin.Context) *apierror.APIError
 // It centrally handles the logic for logging internal errors and sending a clean JSON response to the client.
 func ErrorHandler(h APIHandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Routes that never declared their query parameters with AllowQuery accept none.
+		if !checkQuery(c, nil) {
+			return
+		}
+
 		if err := h(c); err != nil {
-			// Log the internal, detailed error for debugging.
-			// The public message is intentionally not logged here as it's for the client.
-			log.Error().
-				Err(err). // This logs the full internal error chain
-				Str("method", c.Request.Method).
-				Str("path", c.Request.URL.Path).
-				Int("status_code", err.StatusCode).
-				Msg("API error occurred")
-
-			// Send a structured, public-facing error response to the client.
-			c.AbortWithStatusJSON(err.StatusCode, gin.H{
-				"error": gin.H{
-					"message": err.PublicMessage,
-					"code":    err.StatusCode,
-				},
-			})
+			abortWithAPIError(c, err)
 		}
 	}
 }
+
+// abortWithAPIError stops the request with err in the error envelope every route answers with:
+// the translated message, the status code, the error code, the request ID and any fields or
+// details. Middleware rejecting a request uses it too, so clients parse one error shape.
+func abortWithAPIError(c *gin.Context, err *apierror.APIError) {
+	err = classifyError(c.Request.Context(), err)
+
+	// A disconnected client is routine: log it quietly and skip writing a body nobody will read.
+	if err.StatusCode == apierror.StatusClientClosedRequest {
+		log.Info().
+			Err(err).
+			Str("request_id", GetRequestID(c.Request.Context())).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Msg("Client closed request")
+		c.AbortWithStatus(err.StatusCode)
+		return
+	}
+
+	// Log the internal, detailed error for debugging.
+	// The public message is intentionally not logged here as it's for the client.
+	event := log.Error().
+		Err(err). // This logs the full internal error chain
+		Str("request_id", GetRequestID(c.Request.Context())).
+		Str("method", c.Request.Method).
+		Str("path", c.Request.URL.Path).
+		Int("status_code", err.StatusCode)
+	if err.Fields != nil {
+		event = event.Interface("fields", err.Fields)
+	}
+	event.Msg("API error occurred")
+
+	// Public, patient-facing routes carry a locale; translate the message when we have one.
+	message := err.PublicMessage
+	if lang, ok := GetLocale(c.Request.Context()); ok {
+		message = locale.Translate(lang, message)
+	}
+
+	body := gin.H{
+		"message": message,
+		"code":    err.StatusCode,
+	}
+	if err.Code != "" {
+		body["error_code"] = err.Code
+	}
+	if err.Fields != nil {
+		body["fields"] = err.Fields
+	}
+	if err.Details != nil {
+		body["details"] = err.Details
+	}
+	if id := GetRequestID(c.Request.Context()); id != "" {
+		body["request_id"] = id
+	}
+	if err.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	}
+
+	// Send a structured, public-facing error response to the client.
+	c.AbortWithStatusJSON(err.StatusCode, gin.H{"error": body})
+}
+
+// classifyError gives an unexpected error the status its cause calls for, so handlers can wrap
+// any error in apierror.NewInternalServer and still answer correctly. However deeply the cause
+// is wrapped, a saturated connection pool becomes a 503, an exceeded deadline a 504, and a
+// client disconnect a 499. Errors a handler classified itself are returned unchanged.
+func classifyError(ctx context.Context, err *apierror.APIError) *apierror.APIError {
+	if err.StatusCode != http.StatusInternalServerError {
+		return err
+	}
+	// A saturated connection pool is shed load, not a server fault.
+	var busy *database.BusyError
+	if errors.As(err, &busy) {
+		return apierror.NewDatabaseBusy(busy.RetryAfter, err)
+	}
+	// Errors caused by the request context ending are not server faults either.
+	if ctxErr, ok := apierror.FromContext(ctx, err); ok {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestClassifyError(t *testing.T) {
+	busy := &database.BusyError{Waited: 2 * time.Second, RetryAfter: 3 * time.Second}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		err        *apierror.APIError
+		wantStatus int
+	}{
+		{"deadline exceeded", context.Background(), apierror.NewInternalServer(fmt.Errorf("store.Find: failed: %w", context.DeadlineExceeded)), http.StatusGatewayTimeout},
+		{"client went away", canceled, apierror.NewInternalServer(fmt.Errorf("store.Find: failed: %w", context.Canceled)), apierror.StatusClientClosedRequest},
+		{"canceled while the request is live", context.Background(), apierror.NewInternalServer(context.Canceled), http.StatusInternalServerError},
+		{"pool exhausted", context.Background(), apierror.NewInternalServer(fmt.Errorf("store.Find: failed: %w", busy)), http.StatusServiceUnavailable},
+		{"unclassified", context.Background(), apierror.NewInternalServer(errors.New("boom")), http.StatusInternalServerError},
+		{"already classified", context.Background(), apierror.NewNotFound("Patient", context.DeadlineExceeded), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.ctx, tt.err); got.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", got.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// serve runs a handler returning err behind ErrorHandler and returns the response and log output.
+func serve(t *testing.T, ctx context.Context, err *apierror.APIError) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	var logs bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&logs)
+	t.Cleanup(func() { log.Logger = previous })
+
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	ErrorHandler(func(*gin.Context) *apierror.APIError { return err })(c)
+	return rec, logs.String()
+}
+
+func TestErrorHandlerPoolExhausted(t *testing.T) {
+	busy := &database.BusyError{Waited: 2 * time.Second, RetryAfter: 3 * time.Second}
+	rec, _ := serve(t, context.Background(), apierror.NewInternalServer(fmt.Errorf("tx: %w", busy)))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3" {
+		t.Errorf("Retry-After = %q, want %q", got, "3")
+	}
+	if !strings.Contains(rec.Body.String(), apierror.CodeDatabaseBusy) {
+		t.Errorf("body = %s, want error_code %s", rec.Body.String(), apierror.CodeDatabaseBusy)
+	}
+}
+
+func TestErrorHandlerDeadlineExceeded(t *testing.T) {
+	rec, logs := serve(t, context.Background(), apierror.NewInternalServer(context.DeadlineExceeded))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+	if !strings.Contains(logs, `"level":"error"`) {
+		t.Errorf("a timeout was not logged at error level: %s", logs)
+	}
+}
+
+func TestErrorHandlerClientClosedRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec, logs := serve(t, ctx, apierror.NewInternalServer(fmt.Errorf("query: %w", context.Canceled)))
+
+	if rec.Code != apierror.StatusClientClosedRequest {
+		t.Errorf("status = %d, want %d", rec.Code, apierror.StatusClientClosedRequest)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %s, want none", rec.Body.String())
+	}
+	if !strings.Contains(logs, `"level":"info"`) || strings.Contains(logs, `"level":"error"`) {
+		t.Errorf("a client disconnect was not logged at info level: %s", logs)
+	}
+}
+
+func TestErrorHandlerUnexpectedError(t *testing.T) {
+	rec, logs := serve(t, context.Background(), apierror.NewInternalServer(errors.New("boom")))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if strings.Contains(rec.Body.String(), "boom") {
+		t.Errorf("the internal error leaked into the body: %s", rec.Body.String())
+	}
+	if !strings.Contains(logs, "boom") {
+		t.Errorf("the internal error was not logged: %s", logs)
+	}
+}
+
+func TestMiddlewareRejectionsUseTheErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestID(), StrictQuery(QueryParamsReject))
+	ok := ErrorHandler(func(c *gin.Context) *apierror.APIError {
+		c.Status(http.StatusNoContent)
+		return nil
+	})
+	engine.GET("/staff", Authenticator(nil, nil), ok)
+	engine.GET("/guest", GuestTokenAuthenticator(nil), ok)
+	engine.GET("/permission", RequirePermission("patients.read"), ok)
+	engine.GET("/clinic", ResolveClinic(nil, ""), ok)
+	engine.GET("/version", APIVersion(), ok)
+	engine.GET("/query", ok)
+
+	tests := []struct {
+		path       string
+		header     string
+		wantStatus int
+	}{
+		{"/staff", "", http.StatusUnauthorized},
+		{"/staff", "Basic abc", http.StatusUnauthorized},
+		{"/guest", "", http.StatusUnauthorized},
+		{"/permission", "", http.StatusInternalServerError},
+		{"/clinic", "", http.StatusBadRequest},
+		{"/version", "", http.StatusBadRequest},
+		{"/query?pagesize=10", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.path == "/version" {
+				req.Header.Set(APIVersionHeader, "99")
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			var body struct {
+				Error struct {
+					Message   string `json:"message"`
+					Code      int    `json:"code"`
+					RequestID string `json:"request_id"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response %s is not JSON: %v", rec.Body.String(), err)
+			}
+			if rec.Code != tt.wantStatus || body.Error.Code != tt.wantStatus {
+				t.Errorf("status = %d, code = %d; want %d", rec.Code, body.Error.Code, tt.wantStatus)
+			}
+			if body.Error.Message == "" || body.Error.RequestID != rec.Header().Get(RequestIDHeader) {
+				t.Errorf("error = %+v, want a message and the request ID %q", body.Error, rec.Header().Get(RequestIDHeader))
+			}
+		})
+	}
+}
+
+func TestRequestIDReachesTheHeaderLogsAndErrorBody(t *testing.T) {
+	var logs bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&logs)
+	t.Cleanup(func() { log.Logger = previous })
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestID(), AccessLog())
+	engine.GET("/fail", ErrorHandler(func(c *gin.Context) *apierror.APIError {
+		log.Ctx(c.Request.Context()).Info().Msg("handler ran")
+		return apierror.NewNotFound("Patient", nil)
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		// passthrough says whether the incoming ID is kept rather than replaced.
+		passthrough bool
+	}{
+		{"passed through", "clinic-7f3a:req.42", true},
+		{"generated when absent", "", false},
+		{"replaced when malformed", "evil\" injected=1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if tt.passthrough {
+				if id != tt.incoming {
+					t.Errorf("%s = %q, want the incoming %q", RequestIDHeader, id, tt.incoming)
+				}
+			} else if parsed, err := uuid.Parse(id); err != nil || parsed.Version() != 7 {
+				t.Errorf("%s = %q, want a generated UUIDv7", RequestIDHeader, id)
+			}
+
+			var body struct {
+				Error struct {
+					RequestID string `json:"request_id"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response %s is not JSON: %v", rec.Body.String(), err)
+			}
+			if body.Error.RequestID != id {
+				t.Errorf("error.request_id = %q, want %q", body.Error.RequestID, id)
+			}
+
+			events := strings.Split(strings.TrimSpace(logs.String()), "\n")
+			if len(events) < 2 {
+				t.Fatalf("logged %d event(s), want the handler's and the access log's", len(events))
+			}
+			for _, line := range events {
+				var event map[string]any
+				if err := json.Unmarshal([]byte(line), &event); err != nil {
+					t.Fatalf("log line %q is not JSON: %v", line, err)
+				}
+				if event["request_id"] != id {
+					t.Errorf("log event %s does not carry request_id %q", line, id)
+				}
+			}
+		})
+	}
+}
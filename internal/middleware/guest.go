@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	guestPayloadKey = contextKey("guest_payload")
+	// ErrGuestPayloadNotFoundMsg is returned when a handler expects a guest payload that is missing.
+	ErrGuestPayloadNotFoundMsg = "guest payload not found in context"
+)
+
+// GuestTokenAuthenticator verifies the management token a guest received when booking and injects
+// its GuestPayload into the request. Only tokens with the guest_manage purpose for the resolved
+// clinic are accepted; staff access tokens are rejected. It must run after ResolveClinic.
+func GuestTokenAuthenticator(tokenManager *security.PasetoManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+			err := apierror.NewUnauthorized("a bearer management token is required", nil)
+			abortWithAPIError(c, err)
+			return
+		}
+
+		payload, err := tokenManager.VerifyGuestToken(token)
+		if err != nil {
+			apiErr := apierror.NewUnauthorized("invalid or expired management token", err)
+			if _, staffErr := tokenManager.VerifyToken(token); staffErr == nil {
+				apiErr = apierror.NewForbidden("staff tokens cannot be used to manage guest bookings", nil)
+			}
+			abortWithAPIError(c, apiErr)
+			return
+		}
+		if payload.Purpose != security.GuestPurposeManage {
+			apiErr := apierror.NewUnauthorized("invalid or expired management token", nil)
+			abortWithAPIError(c, apiErr)
+			return
+		}
+		if clinicID, err := GetClinicID(c.Request.Context()); err != nil || clinicID != payload.ClinicID {
+			apiErr := apierror.NewUnauthorized("management token was issued by another clinic", nil)
+			abortWithAPIError(c, apiErr)
+			return
+		}
+
+		ctx := context.WithValue(c.Request.Context(), guestPayloadKey, payload)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// GetGuestPayload retrieves the payload injected by GuestTokenAuthenticator from the context.
+func GetGuestPayload(ctx context.Context) (*security.GuestPayload, error) {
+	payload, ok := ctx.Value(guestPayloadKey).(*security.GuestPayload)
+	if !ok || payload == nil {
+		return nil, errors.New(ErrGuestPayloadNotFoundMsg)
+	}
+	return payload, nil
+}
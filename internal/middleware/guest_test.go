@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// guestEngine serves GET /manage behind GuestTokenAuthenticator for requests resolved to clinicID.
+func guestEngine(t *testing.T, tokens *security.PasetoManager, clinicID uuid.UUID) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), clinicIDKey, clinicID))
+	})
+	engine.Use(GuestTokenAuthenticator(tokens))
+	engine.GET("/manage", func(c *gin.Context) {
+		payload, err := GetGuestPayload(c.Request.Context())
+		if err != nil {
+			t.Errorf("GetGuestPayload: %v", err)
+		}
+		c.String(http.StatusOK, payload.AppointmentID.String())
+	})
+	return engine
+}
+
+func TestGuestTokenAuthenticator(t *testing.T) {
+	tokens, err := security.NewPasetoManager(config.SecurityConfig{
+		TokenMode:     security.TokenModeLocal,
+		PasetoKey:     "0123456789abcdef0123456789abcdef",
+		TokenIssuer:   "mastara-test",
+		TokenAudience: "mastara-api",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clinicID, appointmentID := uuid.New(), uuid.New()
+	engine := guestEngine(t, tokens, clinicID)
+
+	guestToken := func(clinicID uuid.UUID, purpose string, issuedAgo, lifetime time.Duration) string {
+		t.Helper()
+		payload, err := security.NewGuestPayload(appointmentID, clinicID, purpose, time.Now().Add(lifetime-issuedAgo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload.IssuedAt = payload.IssuedAt.Add(-issuedAgo)
+		token, err := tokens.CreateGuestToken(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	staffPayload, err := security.NewAuthPayload(uuid.New(), clinicID, nil, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staffToken, err := tokens.CreateToken(staffPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"management token", guestToken(clinicID, security.GuestPurposeManage, 0, time.Hour), http.StatusOK},
+		{"expired", guestToken(clinicID, security.GuestPurposeManage, 2*time.Hour, time.Hour), http.StatusUnauthorized},
+		{"wrong purpose", guestToken(clinicID, "guest_review", 0, time.Hour), http.StatusUnauthorized},
+		{"another clinic", guestToken(uuid.New(), security.GuestPurposeManage, 0, time.Hour), http.StatusUnauthorized},
+		{"staff access token", staffToken, http.StatusForbidden},
+		{"no token", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/manage", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != appointmentID.String() {
+				t.Errorf("handler saw appointment %s, want %s", rec.Body, appointmentID)
+			}
+		})
+	}
+}
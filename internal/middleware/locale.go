@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const localeKey = contextKey("locale")
+
+// LanguageResolver returns a clinic's default content language.
+type LanguageResolver interface {
+	DefaultLanguage(ctx context.Context, clinicID uuid.UUID) (locale.Language, error)
+}
+
+// Locale picks the language for public, patient-facing responses and stores it in the context.
+// The Accept-Language header wins; otherwise the resolved clinic's default language is used.
+// Handlers that know the patient should prefer the profile's language via ResolveLanguage.
+// It must run after ResolveClinic.
+func Locale(resolver LanguageResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang, ok := locale.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+		if !ok {
+			lang = clinicDefaultLanguage(c.Request.Context(), resolver)
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), localeKey, lang))
+		c.Header("Content-Language", string(lang))
+
+		c.Next()
+	}
+}
+
+// GetLocale returns the language chosen by Locale and whether one was set.
+func GetLocale(ctx context.Context) (locale.Language, bool) {
+	lang, ok := ctx.Value(localeKey).(locale.Language)
+	return lang, ok
+}
+
+// ResolveLanguage applies the full precedence for a known patient: their stored preference,
+// then the request's language chosen by Locale, then the fallback.
+func ResolveLanguage(ctx context.Context, preferred *string) locale.Language {
+	if preferred != nil {
+		if lang, ok := locale.Parse(*preferred); ok {
+			return lang
+		}
+	}
+	if lang, ok := GetLocale(ctx); ok {
+		return lang
+	}
+	return locale.Fallback
+}
+
+// clinicDefaultLanguage resolves the clinic default, degrading to the fallback on any error
+// since the language of a response is never worth failing the request over.
+func clinicDefaultLanguage(ctx context.Context, resolver LanguageResolver) locale.Language {
+	clinicID, err := GetClinicID(ctx)
+	if err != nil || resolver == nil {
+		return locale.Fallback
+	}
+
+	lang, err := resolver.DefaultLanguage(ctx, clinicID)
+	if err != nil {
+		log.Warn().Err(err).Str("clinic_id", clinicID.String()).Msg("Failed to resolve clinic default language")
+		return locale.Fallback
+	}
+	return lang
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// RequirePermission allows the request only if the caller holds every one of keys.
+// It must run after the Authenticator.
+func RequirePermission(keys ...string) gin.HandlerFunc {
+	return requirePermissions(keys, true)
+}
+
+// RequireAnyPermission allows the request if the caller holds at least one of keys.
+// It must run after the Authenticator.
+func RequireAnyPermission(keys ...string) gin.HandlerFunc {
+	return requirePermissions(keys, false)
+}
+
+func requirePermissions(keys []string, all bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, err := GetAuthPayload(c.Request.Context())
+		if err != nil {
+			// The route is misconfigured: this middleware was mounted without the Authenticator.
+			apiErr := apierror.NewInternalServer(err)
+			abortWithAPIError(c, apiErr)
+			return
+		}
+
+		var missing []string
+		for _, key := range keys {
+			if !slices.Contains(payload.Permissions, key) {
+				missing = append(missing, key)
+			}
+		}
+
+		allowed := len(missing) == 0
+		if !all {
+			allowed = len(missing) < len(keys)
+		}
+		if allowed {
+			c.Next()
+			return
+		}
+
+		log.Warn().
+			Str("user_id", payload.UserID.String()).
+			Str("clinic_id", payload.ClinicID.String()).
+			Str("denied_permission", strings.Join(missing, ",")).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Msg("Permission denied")
+		apiErr := apierror.NewForbidden("", nil)
+		abortWithAPIError(c, apiErr)
+	}
+}
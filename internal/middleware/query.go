@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// QueryParamMode decides what happens to query parameters a route does not accept.
+type QueryParamMode string
+
+const (
+	// QueryParamsOff ignores unknown query parameters.
+	QueryParamsOff QueryParamMode = "off"
+	// QueryParamsWarn logs unknown query parameters and serves the request anyway.
+	QueryParamsWarn QueryParamMode = "warn"
+	// QueryParamsReject answers requests carrying unknown query parameters with a 400.
+	QueryParamsReject QueryParamMode = "reject"
+)
+
+const queryCheckKey = contextKey("query_check")
+
+// queryCheck carries the mode through a request and records whether a route declared its parameters.
+type queryCheck struct {
+	mode    QueryParamMode
+	checked bool
+}
+
+// StrictQuery turns on query-parameter checking for a route group. Routes declare what they
+// accept with AllowQuery; routes that declare nothing accept no query parameters, which
+// ErrorHandler enforces just before the handler runs.
+func StrictQuery(mode QueryParamMode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mode == QueryParamsOff {
+			c.Next()
+			return
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), queryCheckKey, &queryCheck{mode: mode}))
+		c.Next()
+	}
+}
+
+// AllowQuery declares the query parameters a route accepts, e.g. AllowQuery("limit", "offset").
+// It must run after StrictQuery; without it the route's parameters are not checked.
+func AllowQuery(names ...string) gin.HandlerFunc {
+	accepted := slices.Sorted(slices.Values(names))
+	return func(c *gin.Context) {
+		if !checkQuery(c, accepted) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkQuery compares the request's query parameters against accepted, once per request.
+// It reports false when the request was rejected and must not continue.
+func checkQuery(c *gin.Context, accepted []string) bool {
+	check, ok := c.Request.Context().Value(queryCheckKey).(*queryCheck)
+	if !ok || check.checked {
+		return true
+	}
+	check.checked = true
+
+	var unexpected []string
+	for name := range c.Request.URL.Query() {
+		if !slices.Contains(accepted, name) {
+			unexpected = append(unexpected, name)
+		}
+	}
+	if len(unexpected) == 0 {
+		return true
+	}
+	slices.Sort(unexpected)
+	if accepted == nil {
+		accepted = []string{}
+	}
+
+	if check.mode != QueryParamsReject {
+		log.Warn().
+			Strs("unexpected", unexpected).
+			Strs("accepted", accepted).
+			Str("method", c.Request.Method).
+			Str("path", c.FullPath()).
+			Msg("Request has unknown query parameters")
+		return true
+	}
+
+	abortWithAPIError(c, apierror.NewBadRequest("The request has query parameters this endpoint does not accept.", nil).WithDetails(map[string]any{
+		"unexpected_query_params": unexpected,
+		"accepted_query_params":   accepted,
+	}))
+	return false
+}
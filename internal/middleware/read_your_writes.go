@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/gin-gonic/gin"
+)
+
+// PrimaryUntilHeader and PrimaryUntilCookie carry the signed deadline until which a client
+// that has just written reads from the primary. Browsers return the cookie on their own;
+// other clients echo the response header on their next requests.
+const (
+	PrimaryUntilHeader = "X-Primary-Until"
+	PrimaryUntilCookie = "mastara_primary_until"
+)
+
+// ReadYourWrites makes a client's reads see its own writes while a read replica lags. A request
+// that may write (anything but GET, HEAD and OPTIONS) is answered with a token, signed with key,
+// that is valid for window; requests presenting a valid token have their reads routed to the
+// primary through database.WithPrimaryReads. A zero window disables it.
+func ReadYourWrites(key []byte, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if window <= 0 {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		if primaryUntil(c, key).After(now) {
+			c.Request = c.Request.WithContext(database.WithPrimaryReads(c.Request.Context()))
+		}
+
+		// The token is issued before the handler runs, since its response is written by then.
+		// A failed write only costs the client a few reads from the primary.
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			token := signPrimaryUntil(key, now.Add(window))
+			c.Header(PrimaryUntilHeader, token)
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     PrimaryUntilCookie,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   int(window.Round(time.Second).Seconds()) + 1,
+				HttpOnly: true,
+				Secure:   true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		c.Next()
+	}
+}
+
+// primaryUntil returns the deadline of the request's token, or the zero time when it has
+// none or its signature does not verify.
+func primaryUntil(c *gin.Context, key []byte) time.Time {
+	token := c.GetHeader(PrimaryUntilHeader)
+	if token == "" {
+		token, _ = c.Cookie(PrimaryUntilCookie)
+	}
+	millis, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(primaryUntilMAC(key, millis))) {
+		return time.Time{}
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// signPrimaryUntil returns the token for deadline: its Unix milliseconds and their MAC.
+func signPrimaryUntil(key []byte, deadline time.Time) string {
+	millis := strconv.FormatInt(deadline.UnixMilli(), 10)
+	return millis + "." + primaryUntilMAC(key, millis)
+}
+
+func primaryUntilMAC(key []byte, millis string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(millis))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/gin-gonic/gin"
+)
+
+var readYourWritesKey = []byte("test-key")
+
+// pinningEngine answers every method on /patients with whether the request's reads were
+// pinned to the primary.
+func pinningEngine(window time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ReadYourWrites(readYourWritesKey, window))
+	engine.Any("/patients", func(c *gin.Context) {
+		if database.PrimaryReads(c.Request.Context()) {
+			c.String(http.StatusOK, "primary")
+			return
+		}
+		c.String(http.StatusOK, "replica")
+	})
+	return engine
+}
+
+func sendWithToken(engine *gin.Engine, method string, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/patients", nil)
+	if token != "" {
+		req.Header.Set(PrimaryUntilHeader, token)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReadYourWritesPinsReadsAfterAWrite(t *testing.T) {
+	engine := pinningEngine(time.Minute)
+
+	if rec := sendWithToken(engine, http.MethodGet, ""); rec.Body.String() != "replica" || rec.Header().Get(PrimaryUntilHeader) != "" {
+		t.Fatalf("a read without a token went to the %s and got token %q", rec.Body.String(), rec.Header().Get(PrimaryUntilHeader))
+	}
+
+	write := sendWithToken(engine, http.MethodPost, "")
+	token := write.Header().Get(PrimaryUntilHeader)
+	if token == "" {
+		t.Fatal("a write got no token")
+	}
+	if cookie := write.Header().Get("Set-Cookie"); !strings.Contains(cookie, PrimaryUntilCookie+"="+token) {
+		t.Errorf("Set-Cookie = %q, want the token in %s", cookie, PrimaryUntilCookie)
+	}
+
+	if rec := sendWithToken(engine, http.MethodGet, token); rec.Body.String() != "primary" {
+		t.Errorf("a read after the write went to the %s, want the primary", rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/patients", nil)
+	req.AddCookie(&http.Cookie{Name: PrimaryUntilCookie, Value: token})
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Body.String() != "primary" {
+		t.Errorf("a read with the cookie went to the %s, want the primary", rec.Body.String())
+	}
+}
+
+func TestReadYourWritesRejectsBadTokens(t *testing.T) {
+	engine := pinningEngine(time.Minute)
+	valid := signPrimaryUntil(readYourWritesKey, time.Now().Add(time.Minute))
+	millis, _, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", signPrimaryUntil(readYourWritesKey, time.Now().Add(-time.Second))},
+		{"other key", signPrimaryUntil([]byte("other-key"), time.Now().Add(time.Minute))},
+		{"extended deadline", strings.Replace(valid, millis, millis+"0", 1)},
+		{"unsigned", millis},
+		{"garbage", "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := sendWithToken(engine, http.MethodGet, tt.token); rec.Body.String() != "replica" {
+				t.Errorf("read went to the %s, want the replica", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestReadYourWritesDisabledByZeroWindow(t *testing.T) {
+	engine := pinningEngine(0)
+
+	write := sendWithToken(engine, http.MethodPatch, "")
+	if token := write.Header().Get(PrimaryUntilHeader); token != "" {
+		t.Errorf("a write got token %q with stickiness disabled", token)
+	}
+	token := signPrimaryUntil(readYourWritesKey, time.Now().Add(time.Minute))
+	if rec := sendWithToken(engine, http.MethodGet, token); rec.Body.String() != "replica" {
+		t.Errorf("read went to the %s with stickiness disabled, want the replica", rec.Body.String())
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey = contextKey("request_id")
+
+// requestIDRegex bounds the IDs accepted from clients, so a caller cannot inject arbitrary
+// text into logs or response headers.
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID tags every request with an ID, honouring a well-formed incoming X-Request-ID and
+// otherwise generating a UUIDv7. The ID is stored in the context, echoed in the response
+// header and attached to the context logger, so log.Ctx(ctx) events carry it.
+// It must run before any middleware that logs or writes errors.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = uuid.Must(uuid.NewV7()).String()
+		}
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey, id)
+		ctx = log.With().Str("request_id", id).Logger().WithContext(ctx)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID RequestID assigned to the request, or "" outside a request.
+// Services can use it to tag background work started on behalf of a request.
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// AccessLog writes one log event per request once it has been served. It must run after RequestID.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		log.Info().
+			Str("request_id", GetRequestID(c.Request.Context())).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Int("status_code", c.Writer.Status()).
+			Dur("duration", time.Since(start)).
+			Int("bytes", c.Writer.Size()).
+			Msg("Request served")
+	}
+}
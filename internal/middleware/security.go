@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -27,10 +28,28 @@ func SecurityHeaders() gin.HandlerFunc {
 	}
 }
 
+// unlimitedBodyKey holds the request body as it was before BodyLimiter capped it.
+const unlimitedBodyKey = "unlimited_body"
+
 // BodyLimiter restricts the size of incoming request bodies to prevent DoS attacks.
+// Routes that accept larger bodies raise the cap with RaiseBodyLimit.
 func BodyLimiter(limit int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		c.Set(unlimitedBodyKey, c.Request.Body)
 		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
 		c.Next()
 	}
 }
+
+// RaiseBodyLimit replaces the global BodyLimiter cap with limit for one route, such as a file
+// upload. It must run before anything reads the body.
+func RaiseBodyLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if body, ok := c.Get(unlimitedBodyKey); ok {
+			if original, ok := body.(io.ReadCloser); ok {
+				c.Request.Body = http.MaxBytesReader(c.Writer, original, limit)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionHeader is the request header clients use to pin a response shape.
+const APIVersionHeader = "X-API-Version"
+
+// Supported response versions. Old versions keep their exact field names and shapes forever.
+const (
+	APIVersion1      = "1"
+	APIVersion2      = "2"
+	LatestAPIVersion = APIVersion2
+)
+
+// SupportedAPIVersions lists every version a client may request, oldest first.
+var SupportedAPIVersions = []string{APIVersion1, APIVersion2}
+
+const apiVersionKey = contextKey("api_version")
+
+// APIVersion resolves the requested response version from the X-API-Version header,
+// defaulting to the latest, and rejects unknown versions with the supported list.
+func APIVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := strings.TrimSpace(c.GetHeader(APIVersionHeader))
+		if version == "" {
+			version = LatestAPIVersion
+		}
+
+		if !slices.Contains(SupportedAPIVersions, version) {
+			msg := fmt.Sprintf("Unsupported API version '%s'. Supported versions: %s.", version, strings.Join(SupportedAPIVersions, ", "))
+			err := apierror.NewBadRequest(msg, nil)
+			abortWithAPIError(c, err)
+			return
+		}
+
+		c.Header(APIVersionHeader, version)
+		ctx := context.WithValue(c.Request.Context(), apiVersionKey, version)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// GetAPIVersion returns the response version negotiated for the request.
+// It falls back to the latest version when the middleware did not run.
+func GetAPIVersion(ctx context.Context) string {
+	if version, ok := ctx.Value(apiVersionKey).(string); ok {
+		return version
+	}
+	return LatestAPIVersion
+}
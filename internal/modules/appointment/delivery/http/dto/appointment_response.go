@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// AppointmentResponse defines the publicly exposed fields of an appointment.
+type AppointmentResponse struct {
+	ID             uuid.UUID    `json:"id"`
+	ClinicID       uuid.UUID    `json:"clinic_id"`
+	PatientID      uuid.UUID    `json:"patient_id"`
+	PractitionerID uuid.UUID    `json:"practitioner_id"`
+	StartsAt       apitime.Time `json:"starts_at"`
+	EndsAt         apitime.Time `json:"ends_at"`
+	Status         string       `json:"status"`
+	SubStatus      *string      `json:"sub_status"`
+	Reason         *string      `json:"reason"`
+	// StatusChangedAt and StatusChangedBy are null until the status first changes.
+	StatusChangedAt *apitime.Time `json:"status_changed_at"`
+	StatusChangedBy *uuid.UUID    `json:"status_changed_by"`
+	CreatedAt       apitime.Time  `json:"created_at"`
+	UpdatedAt       apitime.Time  `json:"updated_at"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+// ChangeStatusRequest moves an appointment to another status of its lifecycle.
+type ChangeStatusRequest struct {
+	Status string `json:"status"`
+}
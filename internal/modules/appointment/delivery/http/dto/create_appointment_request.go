@@ -0,0 +1,16 @@
+// Package dto contains the Data Transfer Objects for the appointment module's API contract.
+package dto
+
+import "time"
+
+// CreateAppointmentRequest books an appointment. Either PatientID or both FullName and
+// PhoneNumber must be given; the latter finds or creates a guest patient.
+type CreateAppointmentRequest struct {
+	PatientID      string    `json:"patient_id"`
+	FullName       string    `json:"full_name"`
+	PhoneNumber    string    `json:"phone_number"`
+	PractitionerID string    `json:"practitioner_id"`
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	Reason         string    `json:"reason"`
+}
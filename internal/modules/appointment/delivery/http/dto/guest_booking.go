@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// GuestBookingRequest books an appointment from the public booking page. PhoneVerificationToken
+// is the token POST /public/otp/verify returned for PhoneNumber.
+type GuestBookingRequest struct {
+	FullName               string    `json:"full_name"`
+	PhoneNumber            string    `json:"phone_number"`
+	PhoneVerificationToken string    `json:"phone_verification_token"`
+	PractitionerID         string    `json:"practitioner_id"`
+	StartsAt               time.Time `json:"starts_at"`
+	EndsAt                 time.Time `json:"ends_at"`
+	Reason                 string    `json:"reason"`
+}
+
+// GuestBookingResponse confirms a guest booking. It carries the management token instead of
+// the guest's profile, which is never exposed publicly.
+type GuestBookingResponse struct {
+	AppointmentID            uuid.UUID    `json:"appointment_id"`
+	PractitionerID           uuid.UUID    `json:"practitioner_id"`
+	StartsAt                 apitime.Time `json:"starts_at"`
+	EndsAt                   apitime.Time `json:"ends_at"`
+	Status                   string       `json:"status"`
+	ManagementToken          string       `json:"management_token"`
+	ManagementTokenExpiresAt apitime.Time `json:"management_token_expires_at"`
+}
+
+// GuestRescheduleRequest moves a guest's appointment to a new slot.
+type GuestRescheduleRequest struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// GuestAppointmentResponse is the view of an appointment given to the guest who booked it. It
+// carries nothing about the patient beyond what they submitted.
+type GuestAppointmentResponse struct {
+	ID             uuid.UUID    `json:"id"`
+	PractitionerID uuid.UUID    `json:"practitioner_id"`
+	StartsAt       apitime.Time `json:"starts_at"`
+	EndsAt         apitime.Time `json:"ends_at"`
+	Status         string       `json:"status"`
+	Reason         *string      `json:"reason"`
+}
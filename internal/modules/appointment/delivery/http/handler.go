@@ -0,0 +1,266 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	z "github.com/Oudwins/zog"
+	"github.com/Oudwins/zog/zhttp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler holds the dependencies for the appointment HTTP handlers.
+type Handler struct {
+	service appointment.Service
+	// tokenManager verifies the management tokens on the guest routes.
+	tokenManager *security.PasetoManager
+}
+
+// NewHandler creates a new appointment handler.
+func NewHandler(service appointment.Service, tokenManager *security.PasetoManager) *Handler {
+	return &Handler{service: service, tokenManager: tokenManager}
+}
+
+// CreateAppointment books an appointment from the staff calendar.
+func (h *Handler) CreateAppointment(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var req dto.CreateAppointmentRequest
+	if issues := createAppointmentSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := appointment.CreateAppointmentRequest{
+		ClinicID:         payload.ClinicID,
+		GuestFullName:    req.FullName,
+		GuestPhoneNumber: req.PhoneNumber,
+		PractitionerID:   uuid.MustParse(req.PractitionerID),
+		StartTime:        req.StartsAt,
+		EndTime:          req.EndsAt,
+	}
+	if req.PatientID != "" {
+		patientID := uuid.MustParse(req.PatientID)
+		serviceReq.PatientID = &patientID
+	}
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		serviceReq.Reason = &reason
+	}
+
+	created, err := h.service.CreateAppointment(c.Request.Context(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusCreated, toAppointmentResponse(created))
+	return nil
+}
+
+// BookAsGuest books an appointment from the public booking page of the resolved clinic.
+func (h *Handler) BookAsGuest(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var req dto.GuestBookingRequest
+	if issues := guestBookingSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := appointment.GuestBookingRequest{
+		ClinicID:               clinicID,
+		FullName:               req.FullName,
+		PhoneNumber:            req.PhoneNumber,
+		PhoneVerificationToken: req.PhoneVerificationToken,
+		PractitionerID:         uuid.MustParse(req.PractitionerID),
+		StartTime:              req.StartsAt,
+		EndTime:                req.EndsAt,
+	}
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		serviceReq.Reason = &reason
+	}
+
+	booking, err := h.service.BookAsGuest(c.Request.Context(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusCreated, toGuestBookingResponse(booking))
+	return nil
+}
+
+// GetGuestAppointment returns the appointment the guest's management token was issued for.
+func (h *Handler) GetGuestAppointment(c *gin.Context) *apierror.APIError {
+	guest, err := middleware.GetGuestPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	found, err := h.service.GetGuestAppointment(c.Request.Context(), guest)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toGuestAppointmentResponse(found))
+	return nil
+}
+
+// CancelGuestAppointment cancels the appointment the guest's management token was issued for.
+func (h *Handler) CancelGuestAppointment(c *gin.Context) *apierror.APIError {
+	guest, err := middleware.GetGuestPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	cancelled, err := h.service.CancelAsGuest(c.Request.Context(), guest)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toGuestAppointmentResponse(cancelled))
+	return nil
+}
+
+// RescheduleGuestAppointment moves the guest's appointment and returns a new management token.
+func (h *Handler) RescheduleGuestAppointment(c *gin.Context) *apierror.APIError {
+	guest, err := middleware.GetGuestPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var req dto.GuestRescheduleRequest
+	if issues := guestRescheduleSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	booking, err := h.service.RescheduleAsGuest(c.Request.Context(), guest, req.StartsAt, req.EndsAt)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toGuestBookingResponse(booking))
+	return nil
+}
+
+// GetAppointment returns a single appointment of the caller's clinic.
+func (h *Handler) GetAppointment(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid appointment ID format.", err)
+	}
+
+	found, err := h.service.GetAppointment(c.Request.Context(), payload.ClinicID, appointmentID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toAppointmentResponse(found))
+	return nil
+}
+
+// ChangeAppointmentStatus moves an appointment of the caller's clinic to another status, recording
+// the caller as the employee who changed it.
+func (h *Handler) ChangeAppointmentStatus(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid appointment ID format.", err)
+	}
+
+	var req dto.ChangeStatusRequest
+	if issues := changeStatusSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	// An impersonating operator has no profile in the clinic, so the change is recorded against
+	// the employee they act as.
+	updated, err := h.service.ChangeStatus(c.Request.Context(), payload.ClinicID, appointmentID, req.Status, payload.ActorID())
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toAppointmentResponse(updated))
+	return nil
+}
+
+// ListAppointments returns the appointments of one calendar day, optionally for one practitioner.
+func (h *Handler) ListAppointments(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	day, err := time.Parse(time.DateOnly, c.Query("date"))
+	if err != nil {
+		return apierror.NewBadRequest("The 'date' parameter is required in YYYY-MM-DD format.", err)
+	}
+
+	var practitionerID *uuid.UUID
+	if raw := c.Query("practitioner_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return apierror.NewBadRequest("Invalid 'practitioner_id' parameter.", err)
+		}
+		practitionerID = &id
+	}
+
+	appointments, err := h.service.ListAppointmentsForDay(c.Request.Context(), payload.ClinicID, day, practitionerID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toAppointmentResponses(appointments))
+	return nil
+}
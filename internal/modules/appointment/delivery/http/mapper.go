@@ -0,0 +1,57 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+)
+
+func toAppointmentResponse(a *model.Appointment) dto.AppointmentResponse {
+	return dto.AppointmentResponse{
+		ID:              a.ID,
+		ClinicID:        a.ClinicID,
+		PatientID:       a.PatientID,
+		PractitionerID:  a.PractitionerID,
+		StartsAt:        apitime.New(a.StartTime),
+		EndsAt:          apitime.New(a.EndTime),
+		Status:          a.Status,
+		SubStatus:       a.SubStatus,
+		Reason:          a.Reason,
+		StatusChangedAt: apitime.NewPtr(a.StatusChangedAt),
+		StatusChangedBy: a.StatusChangedBy,
+		CreatedAt:       apitime.New(a.CreatedAt),
+		UpdatedAt:       apitime.New(a.UpdatedAt),
+	}
+}
+
+func toAppointmentResponses(appointments []model.Appointment) []dto.AppointmentResponse {
+	responses := make([]dto.AppointmentResponse, len(appointments))
+	for i := range appointments {
+		responses[i] = toAppointmentResponse(&appointments[i])
+	}
+	return responses
+}
+
+func toGuestBookingResponse(b *appointment.GuestBooking) dto.GuestBookingResponse {
+	return dto.GuestBookingResponse{
+		AppointmentID:            b.Appointment.ID,
+		PractitionerID:           b.Appointment.PractitionerID,
+		StartsAt:                 apitime.New(b.Appointment.StartTime),
+		EndsAt:                   apitime.New(b.Appointment.EndTime),
+		Status:                   b.Appointment.Status,
+		ManagementToken:          b.ManagementToken,
+		ManagementTokenExpiresAt: apitime.New(b.ManagementTokenExpires),
+	}
+}
+
+func toGuestAppointmentResponse(a *model.Appointment) dto.GuestAppointmentResponse {
+	return dto.GuestAppointmentResponse{
+		ID:             a.ID,
+		PractitionerID: a.PractitionerID,
+		StartsAt:       apitime.New(a.StartTime),
+		EndsAt:         apitime.New(a.EndTime),
+		Status:         a.Status,
+		Reason:         a.Reason,
+	}
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/golden"
+	"github.com/google/uuid"
+)
+
+// goldenAppointment sets every field an appointment response can carry, with times read back
+// in a zone other than UTC and with sub-second precision.
+func goldenAppointment() *model.Appointment {
+	cairo := time.FixedZone("EET", 2*60*60)
+	reason, subStatus := "Toothache", "waiting_for_xray"
+	changedAt := time.Date(2025, 3, 1, 8, 0, 0, 250000000, time.UTC)
+	changedBy := uuid.MustParse("0190b5a0-0000-7000-8000-0000000000a1")
+	start := time.Date(2025, 3, 2, 11, 0, 0, 0, cairo)
+	return &model.Appointment{
+		ID:              uuid.MustParse("0190b5a0-0000-7000-8000-000000000003"),
+		ClinicID:        uuid.MustParse("0190b5a0-0000-7000-8000-0000000000c1"),
+		PatientID:       uuid.MustParse("0190b5a0-0000-7000-8000-000000000001"),
+		PractitionerID:  uuid.MustParse("0190b5a0-0000-7000-8000-000000000002"),
+		StartTime:       start,
+		EndTime:         start.Add(30 * time.Minute),
+		Status:          model.StatusConfirmed,
+		SubStatus:       &subStatus,
+		Reason:          &reason,
+		StatusChangedAt: &changedAt,
+		StatusChangedBy: &changedBy,
+		CreatedAt:       time.Date(2025, 2, 20, 14, 5, 9, 123456000, time.UTC),
+		UpdatedAt:       changedAt,
+	}
+}
+
+func TestAppointmentResponseFormats(t *testing.T) {
+	golden.AssertJSON(t, "appointment_response.json", toAppointmentResponse(goldenAppointment()))
+	golden.AssertJSON(t, "guest_appointment_response.json", toGuestAppointmentResponse(goldenAppointment()))
+	golden.AssertJSON(t, "guest_booking_response.json", toGuestBookingResponse(&appointment.GuestBooking{
+		Appointment:            goldenAppointment(),
+		ManagementToken:        "v4.local.token",
+		ManagementTokenExpires: time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC),
+	}))
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the appointment module in the router's startup log.
+func (h *Handler) Name() string {
+	return "appointment"
+}
+
+// RegisterProtectedRoutes sets up the staff routes for the appointment module.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	appointmentGroup := router.Group("/appointments")
+	{
+		// POST /api/v1/appointments - Book an appointment for a patient or a new guest
+		appointmentGroup.POST("/", middleware.RequirePermission(model.PermissionAppointmentsCreate), middleware.ErrorHandler(h.CreateAppointment))
+		// GET /api/v1/appointments?date=YYYY-MM-DD&practitioner_id= - One day of the calendar, in the clinic's timezone
+		appointmentGroup.GET("/", middleware.RequirePermission(model.PermissionAppointmentsRead), middleware.AllowQuery("date", "practitioner_id"), middleware.ErrorHandler(h.ListAppointments))
+		// GET /api/v1/appointments/:id - Get an appointment
+		appointmentGroup.GET("/:id", middleware.RequirePermission(model.PermissionAppointmentsRead), middleware.ErrorHandler(h.GetAppointment))
+		// PATCH /api/v1/appointments/:id/status - Move an appointment along its status lifecycle
+		appointmentGroup.PATCH("/:id/status", middleware.RequirePermission(model.PermissionAppointmentsUpdate), middleware.ErrorHandler(h.ChangeAppointmentStatus))
+	}
+}
+
+// RegisterPublicRoutes sets up the unauthenticated booking routes. The router resolves the
+// clinic before these run.
+func (h *Handler) RegisterPublicRoutes(router *gin.RouterGroup) {
+	publicGroup := router.Group("/appointments")
+	{
+		// POST /public/appointments - Book as a guest; returns a token for managing the booking
+		publicGroup.POST("/", middleware.ErrorHandler(h.BookAsGuest))
+	}
+
+	// The manage routes authenticate with the guest's management token instead of a staff token.
+	manageGroup := publicGroup.Group("/manage", middleware.GuestTokenAuthenticator(h.tokenManager))
+	{
+		// GET /public/appointments/manage - View the booked appointment
+		manageGroup.GET("", middleware.ErrorHandler(h.GetGuestAppointment))
+		// POST /public/appointments/manage/cancel - Cancel, unless within the clinic's cancellation cutoff
+		manageGroup.POST("/cancel", middleware.ErrorHandler(h.CancelGuestAppointment))
+		// POST /public/appointments/manage/reschedule - Move to a free slot; returns a new management token
+		manageGroup.POST("/reschedule", middleware.ErrorHandler(h.RescheduleGuestAppointment))
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	z "github.com/Oudwins/zog"
+)
+
+// Guests type their own number, so unlike staff they must give it in international form.
+var e164Regex = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// Schema for booking an appointment from the staff calendar. The phone number may be in
+// national form; the patient service normalizes it with the clinic's default phone region.
+var createAppointmentSchema = z.Struct(z.Shape{
+	"patientID":      z.String().Trim().UUID(z.Message("patient_id must be a valid ID.")).Optional(),
+	"fullName":       z.String().Trim().Min(4, z.Message("Full name must be at least 4 characters.")).Optional(),
+	"phoneNumber":    z.String().Trim().Optional(),
+	"practitionerID": z.String().Trim().UUID(z.Message("practitioner_id must be a valid ID.")).Required(z.Message("A practitioner_id is required.")),
+	"startsAt":       z.Time(z.Time.Format(time.RFC3339)).Required(z.Message("starts_at is required, e.g. 2025-01-31T09:00:00+02:00.")),
+	"endsAt":         z.Time(z.Time.Format(time.RFC3339)).Required(z.Message("ends_at is required, e.g. 2025-01-31T09:30:00+02:00.")),
+	"reason":         z.String().Trim().Max(500, z.Message("Reason must be at most 500 characters.")).Optional(),
+}).TestFunc(
+	func(data any, ctx z.Ctx) bool {
+		req, ok := data.(*dto.CreateAppointmentRequest)
+		if !ok {
+			return false
+		}
+		return req.PatientID != "" || (req.FullName != "" && req.PhoneNumber != "")
+	},
+	z.Message("Either patient_id or both full_name and phone_number must be provided."),
+)
+
+// Schema for booking an appointment from the public booking page.
+var guestBookingSchema = z.Struct(z.Shape{
+	"fullName":               z.String().Trim().Min(4, z.Message("Full name must be at least 4 characters.")).Required(z.Message("A full_name is required.")),
+	"phoneNumber":            z.String().Trim().Match(e164Regex, z.Message("A valid E.164 phone number is required, e.g. +201012345678.")).Required(z.Message("A phone_number is required.")),
+	"practitionerID":         z.String().Trim().UUID(z.Message("practitioner_id must be a valid ID.")).Required(z.Message("A practitioner_id is required.")),
+	"startsAt":               z.Time(z.Time.Format(time.RFC3339)).Required(z.Message("starts_at is required, e.g. 2025-01-31T09:00:00+02:00.")),
+	"endsAt":                 z.Time(z.Time.Format(time.RFC3339)).Required(z.Message("ends_at is required, e.g. 2025-01-31T09:30:00+02:00.")),
+	"reason":                 z.String().Trim().Max(500, z.Message("Reason must be at most 500 characters.")).Optional(),
+	"phoneVerificationToken": z.String().Trim().Required(z.Message("A phone_verification_token is required; verify the phone number with POST /public/otp/verify first.")),
+})
+
+// Schema for a guest moving their appointment to a new slot.
+var guestRescheduleSchema = z.Struct(z.Shape{
+	"startsAt": z.Time(z.Time.Format(time.RFC3339)).Required(z.Message("starts_at is required, e.g. 2025-01-31T09:00:00+02:00.")),
+	"endsAt":   z.Time(z.Time.Format(time.RFC3339)).Required(z.Message("ends_at is required, e.g. 2025-01-31T09:30:00+02:00.")),
+})
+
+// Schema for moving an appointment to another status. Whether the move is allowed from the
+// current status is the service's call.
+var changeStatusSchema = z.Struct(z.Shape{
+	"status": z.String().Trim().OneOf(
+		[]string{model.StatusScheduled, model.StatusConfirmed, model.StatusCheckedIn, model.StatusCompleted, model.StatusCancelled, model.StatusNoShow},
+		z.Message("status must be one of SCHEDULED, CONFIRMED, CHECKED_IN, COMPLETED, CANCELLED or NO_SHOW."),
+	).Required(z.Message("A status is required.")),
+})
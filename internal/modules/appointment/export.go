@@ -0,0 +1,51 @@
+package appointment
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// exportSection names the appointments' part of a patient export.
+const exportSection = "appointments"
+
+// appointmentExport is an appointment as it appears in a patient export, staff notes included.
+type appointmentExport struct {
+	ID              uuid.UUID  `json:"id"`
+	PractitionerID  uuid.UUID  `json:"practitioner_id"`
+	StartTime       time.Time  `json:"start_time"`
+	EndTime         time.Time  `json:"end_time"`
+	Status          string     `json:"status"`
+	SubStatus       *string    `json:"sub_status"`
+	Reason          *string    `json:"reason"`
+	Notes           *string    `json:"notes"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	StatusChangedAt *time.Time `json:"status_changed_at"`
+}
+
+// exportAppointments is the appointments export section.
+func (s *defaultService) exportAppointments(ctx context.Context, clinicID, profileID uuid.UUID) (any, error) {
+	appointments, err := s.repo.ListByPatient(ctx, s.db, clinicID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]appointmentExport, len(appointments))
+	for i, a := range appointments {
+		exported[i] = appointmentExport{
+			ID:              a.ID,
+			PractitionerID:  a.PractitionerID,
+			StartTime:       a.StartTime,
+			EndTime:         a.EndTime,
+			Status:          a.Status,
+			SubStatus:       a.SubStatus,
+			Reason:          a.Reason,
+			Notes:           a.Notes,
+			CreatedAt:       a.CreatedAt,
+			UpdatedAt:       a.UpdatedAt,
+			StatusChangedAt: a.StatusChangedAt,
+		}
+	}
+	return exported, nil
+}
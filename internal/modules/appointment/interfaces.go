@@ -0,0 +1,115 @@
+// Package appointment contains all business logic for booking and listing appointments.
+package appointment
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for the appointment module's business logic.
+type Service interface {
+	// CreateAppointment books a slot for an existing patient, or for a guest found or created
+	// by phone number when no patient ID is given.
+	CreateAppointment(ctx context.Context, req CreateAppointmentRequest) (*model.Appointment, error)
+	// GetAppointment returns one of the clinic's appointments.
+	GetAppointment(ctx context.Context, clinicID, appointmentID uuid.UUID) (*model.Appointment, error)
+	// ListAppointmentsForDay returns the appointments starting on day, a calendar date in the
+	// clinic's timezone, ordered by start time. A non-nil practitionerID narrows the list.
+	ListAppointmentsForDay(ctx context.Context, clinicID uuid.UUID, day time.Time, practitionerID *uuid.UUID) ([]model.Appointment, error)
+	// BookAsGuest books a slot from the public booking page under the clinic's booking rules and
+	// returns a management token in place of the guest's profile. The request's phone
+	// verification token must be unused and issued for its phone number.
+	BookAsGuest(ctx context.Context, req GuestBookingRequest) (*GuestBooking, error)
+	// GetGuestAppointment returns the appointment a guest's management token was issued for.
+	GetGuestAppointment(ctx context.Context, guest *security.GuestPayload) (*model.Appointment, error)
+	// CancelAsGuest cancels the guest's appointment unless its start is within the clinic's
+	// cancellation cutoff.
+	CancelAsGuest(ctx context.Context, guest *security.GuestPayload) (*model.Appointment, error)
+	// RescheduleAsGuest moves the guest's appointment to a free slot under the same rules as
+	// booking and cancelling, and issues a new management token that replaces the old one.
+	RescheduleAsGuest(ctx context.Context, guest *security.GuestPayload, start, end time.Time) (*GuestBooking, error)
+	// ChangeStatus moves one of the clinic's appointments to status on behalf of the employee
+	// changedBy. A transition the appointment's lifecycle does not allow is rejected with 409.
+	ChangeStatus(ctx context.Context, clinicID, appointmentID uuid.UUID, status string, changedBy uuid.UUID) (*model.Appointment, error)
+}
+
+// CreateAppointmentRequest contains the data for booking an appointment. Either PatientID or
+// both GuestFullName and GuestPhoneNumber must be set.
+type CreateAppointmentRequest struct {
+	ClinicID         uuid.UUID
+	PatientID        *uuid.UUID
+	GuestFullName    string
+	GuestPhoneNumber string
+	PractitionerID   uuid.UUID
+	StartTime        time.Time
+	EndTime          time.Time
+	Reason           *string
+}
+
+// GuestBookingRequest contains the data a guest submits from the public booking page.
+// PhoneVerificationToken proves the guest holds PhoneNumber and is used up by the booking.
+type GuestBookingRequest struct {
+	ClinicID               uuid.UUID
+	FullName               string
+	PhoneNumber            string
+	PhoneVerificationToken string
+	PractitionerID         uuid.UUID
+	StartTime              time.Time
+	EndTime                time.Time
+	Reason                 *string
+}
+
+// GuestBooking is the confirmation of a guest booking. ManagementToken lets the guest view,
+// cancel and reschedule the appointment until ManagementTokenExpires.
+type GuestBooking struct {
+	Appointment            *model.Appointment
+	ManagementToken        string
+	ManagementTokenExpires time.Time
+}
+
+// Repository defines the data access contract for appointments.
+type Repository interface {
+	Create(ctx context.Context, querier database.Querier, appointment *model.Appointment) error
+	FindByID(ctx context.Context, querier database.Querier, clinicID, appointmentID uuid.UUID) (*model.Appointment, error)
+	// FindByIDForUpdate is FindByID that also locks the appointment.
+	FindByIDForUpdate(ctx context.Context, querier database.Querier, clinicID, appointmentID uuid.UUID) (*model.Appointment, error)
+	// ListByClinicAndDay returns the appointments starting in [from, to), ordered by start time.
+	ListByClinicAndDay(ctx context.Context, querier database.Querier, clinicID uuid.UUID, from, to time.Time, practitionerID *uuid.UUID) ([]model.Appointment, error)
+	// ListByPatient returns all of the patient's appointments, oldest first.
+	ListByPatient(ctx context.Context, querier database.Querier, clinicID, patientID uuid.UUID) ([]model.Appointment, error)
+
+	// FindByGuestToken returns the appointment tokenID was issued for, unless a newer token replaced it.
+	FindByGuestToken(ctx context.Context, querier database.Querier, clinicID, appointmentID, tokenID uuid.UUID) (*model.Appointment, error)
+	// FindByGuestTokenForUpdate is FindByGuestToken that also locks the appointment.
+	FindByGuestTokenForUpdate(ctx context.Context, querier database.Querier, clinicID, appointmentID, tokenID uuid.UUID) (*model.Appointment, error)
+	// UpdateStatus saves the appointment's Status and StatusChangedBy, and sets StatusChangedAt.
+	UpdateStatus(ctx context.Context, querier database.Querier, appointment *model.Appointment) error
+	// Reschedule saves the appointment's StartTime and EndTime.
+	Reschedule(ctx context.Context, querier database.Querier, appointment *model.Appointment) error
+
+	// LockOverlapping reports whether the practitioner has a live appointment other than excludeID
+	// overlapping [start, end), locking it for the rest of the transaction.
+	LockOverlapping(ctx context.Context, querier database.Querier, clinicID, practitionerID uuid.UUID, start, end time.Time, excludeID uuid.UUID) (bool, error)
+	// PatientExists reports whether profileID is an active profile of the clinic.
+	PatientExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error)
+	// PractitionerExists reports whether profileID is an active employee of the clinic.
+	PractitionerExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error)
+	// LockPatient locks the patient's profile for the rest of the transaction.
+	LockPatient(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error
+	// CountPendingForPatient counts the patient's scheduled or confirmed appointments starting after now.
+	CountPendingForPatient(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, now time.Time) (int, error)
+	// SetGuestToken records the ID and expiry of the guest management token issued for the appointment.
+	SetGuestToken(ctx context.Context, querier database.Querier, clinicID, appointmentID, tokenID uuid.UUID, expiresAt time.Time) error
+	// LockDueReminders locks up to limit appointments due a reminder at now, skipping rows another
+	// transaction has locked. defaults stand in for clinics that never saved their notification settings.
+	LockDueReminders(ctx context.Context, querier database.Querier, now time.Time, defaults model.ReminderDefaults, window time.Duration, limit int) ([]model.Reminder, error)
+	// MarkReminderSent records that the appointment's reminder was sent.
+	MarkReminderSent(ctx context.Context, querier database.Querier, appointmentID uuid.UUID, sentAt time.Time) error
+	// ReassignPatient moves every appointment of fromProfileID to toProfileID.
+	ReassignPatient(ctx context.Context, querier database.Querier, clinicID, fromProfileID, toProfileID uuid.UUID) (int64, error)
+}
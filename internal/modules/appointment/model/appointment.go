@@ -0,0 +1,64 @@
+// Package model contains the domain models for the appointment module.
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Core appointment statuses; see settings.CoreAppointmentStatuses.
+const (
+	StatusScheduled = "SCHEDULED"
+	StatusConfirmed = "CONFIRMED"
+	StatusCheckedIn = "CHECKED_IN"
+	StatusCompleted = "COMPLETED"
+	StatusCancelled = "CANCELLED"
+	StatusNoShow    = "NO_SHOW"
+)
+
+// Appointment is a booked slot of a practitioner's time for a patient.
+type Appointment struct {
+	ID       uuid.UUID `db:"id"`
+	ClinicID uuid.UUID `db:"clinic_id"`
+	// PatientID is the patient's profile.
+	PatientID uuid.UUID `db:"patient_id"`
+	// PractitionerID is the treating employee's profile.
+	PractitionerID uuid.UUID `db:"doctor_id"`
+	StartTime      time.Time `db:"start_time"`
+	EndTime        time.Time `db:"end_time"`
+	Status         string    `db:"status"`
+	// SubStatus is the id of a clinic-defined sub-status refining Status, if any.
+	SubStatus *string `db:"sub_status"`
+	Reason    *string `db:"reason"`
+	// Notes are the staff's notes on the appointment, never shown to the guest.
+	Notes *string `db:"notes"`
+	// StatusChangedAt is when Status last changed; nil while it is still the booking status.
+	StatusChangedAt *time.Time `db:"status_changed_at"`
+	// StatusChangedBy is the employee who last changed Status; nil when the guest or nobody did.
+	StatusChangedBy *uuid.UUID `db:"status_changed_by"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+}
+
+// transitions lists the statuses each status may move to. COMPLETED, CANCELLED and NO_SHOW
+// are final.
+var transitions = map[string][]string{
+	StatusScheduled: {StatusConfirmed, StatusCheckedIn, StatusCancelled, StatusNoShow},
+	StatusConfirmed: {StatusCheckedIn, StatusCancelled, StatusNoShow},
+	StatusCheckedIn: {StatusCompleted},
+}
+
+// CanTransition reports whether an appointment in status from may move to status to. It does not
+// check the time: NO_SHOW is also only allowed once the appointment has started.
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// Permissions checked by the appointment routes, seeded in the IAM schema migration.
+const (
+	PermissionAppointmentsCreate = "appointments.create"
+	PermissionAppointmentsRead   = "appointments.read"
+	PermissionAppointmentsUpdate = "appointments.update"
+)
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reminder is an upcoming appointment due its reminder, with what the message needs.
+type Reminder struct {
+	AppointmentID    uuid.UUID
+	ClinicID         uuid.UUID
+	StartTime        time.Time
+	PatientPhone     string
+	PatientLanguage  *string
+	PractitionerName string
+	ClinicName       string
+	ClinicPhone      string
+}
+
+// ReminderDefaults are the reminder settings of a clinic that has not saved its own.
+type ReminderDefaults struct {
+	Enabled   bool
+	LeadHours int
+}
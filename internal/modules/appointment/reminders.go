@@ -0,0 +1,161 @@
+package appointment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	sharedNotification "github.com/Ebrahim-hamdy/mastara-saas/internal/shared/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// reminderWindow is how far either side of its clinic's lead time an appointment is still
+// reminded, so a scheduler that was down or slow for a while catches up.
+const reminderWindow = time.Hour
+
+// reminderSendTimeout bounds one SMS; the appointment stays locked while it is sent.
+const reminderSendTimeout = 10 * time.Second
+
+// reminderTimeFormat is how the appointment's start reads in the message, in the clinic's timezone.
+const reminderTimeFormat = "Mon 2 Jan 15:04"
+
+// ReminderScheduler texts patients a reminder their clinic's lead time before each scheduled
+// or confirmed appointment. Any number of API instances may run one: an appointment is locked
+// while its reminder is sent and marked sent in the same transaction.
+type ReminderScheduler struct {
+	tx       database.TxManager
+	repo     Repository
+	clinics  clinic.Service
+	notifier notification.Notifier
+	// batchSize caps the appointments one transaction locks.
+	batchSize int
+	// now is the scheduler's clock.
+	now func() time.Time
+}
+
+// NewReminderScheduler creates a scheduler that sends reminders through notifier.
+func NewReminderScheduler(txManager database.TxManager, repo Repository, clinics clinic.Service, notifier notification.Notifier, cfg config.BookingConfig) *ReminderScheduler {
+	return &ReminderScheduler{
+		tx:        txManager,
+		repo:      repo,
+		clinics:   clinics,
+		notifier:  notifier,
+		batchSize: cfg.ReminderBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Run sends due reminders every interval until ctx is cancelled.
+func (r *ReminderScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.send(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.send(ctx)
+		}
+	}
+}
+
+func (r *ReminderScheduler) send(ctx context.Context) {
+	sent, err := r.SendDue(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("appointment reminders: failed to send due reminders")
+	}
+	if sent > 0 {
+		log.Info().Int("sent", sent).Msg("appointment reminders: sent due reminders")
+	}
+}
+
+// SendDue sends the reminders due now, a batch per transaction, and returns how many were sent.
+// A reminder that fails to send is logged and left for the next run while it is still due. If
+// the batch's transaction fails to commit, its reminders are sent again on the next run.
+// Cancelling ctx stops SendDue between batches; a batch in progress is finished and committed,
+// so its texts are never sent twice because of a shutdown.
+func (r *ReminderScheduler) SendDue(ctx context.Context) (int, error) {
+	defaults := settings.Notifications.Defaults()
+	reminderDefaults := model.ReminderDefaults{Enabled: defaults.RemindersEnabled, LeadHours: defaults.ReminderLeadHours}
+	// Reminders are due across every clinic, so the batches run as the platform role.
+	batchCtx := database.WithPlatformAccess(context.WithoutCancel(ctx))
+
+	total := 0
+	for ctx.Err() == nil {
+		var locked, sent int
+		err := r.tx.ExecTx(batchCtx, func(tx pgx.Tx) error {
+			now := r.now()
+			due, err := r.repo.LockDueReminders(batchCtx, tx, now, reminderDefaults, reminderWindow, r.batchSize)
+			if err != nil {
+				return err
+			}
+			locked = len(due)
+			for _, reminder := range due {
+				if err := r.dispatch(batchCtx, reminder); err != nil {
+					log.Error().Err(err).Str("appointment_id", reminder.AppointmentID.String()).Msg("appointment reminders: failed to send reminder")
+					continue
+				}
+				if err := r.repo.MarkReminderSent(batchCtx, tx, reminder.AppointmentID, now); err != nil {
+					return err
+				}
+				sent++
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += sent
+		// Failed reminders are locked again by the next batch, so only a clean, full batch
+		// means more may be waiting.
+		if locked < r.batchSize || sent < locked {
+			return total, nil
+		}
+	}
+	return total, nil
+}
+
+// dispatch renders the reminder in the patient's language and texts it.
+func (r *ReminderScheduler) dispatch(ctx context.Context, reminder model.Reminder) error {
+	clinicSettings, err := r.clinics.GetSettings(ctx, reminder.ClinicID)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(clinicSettings.Timezone)
+	if err != nil {
+		log.Warn().Err(err).Str("clinic_id", reminder.ClinicID.String()).Str("timezone", clinicSettings.Timezone).Msg("Unknown clinic timezone; using UTC")
+		loc = time.UTC
+	}
+
+	clinicDefault := locale.Resolve(clinicSettings.Locale)
+	lang := clinicDefault
+	if reminder.PatientLanguage != nil {
+		lang = locale.Resolve(*reminder.PatientLanguage, clinicSettings.Locale)
+	}
+	body, err := sharedNotification.Render(sharedNotification.TemplateAppointmentReminder, lang, clinicDefault, sharedNotification.AppointmentData{
+		ClinicName:       reminder.ClinicName,
+		ClinicPhone:      reminder.ClinicPhone,
+		PractitionerName: reminder.PractitionerName,
+		StartsAt:         reminder.StartTime.In(loc).Format(reminderTimeFormat),
+	})
+	if err != nil {
+		return err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, reminderSendTimeout)
+	defer cancel()
+	if err := r.notifier.SendSMS(sendCtx, notification.SMS{To: reminder.PatientPhone, Body: body}); err != nil {
+		return fmt.Errorf("failed to send reminder SMS: %w", err)
+	}
+	return nil
+}
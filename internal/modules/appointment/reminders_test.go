@@ -0,0 +1,90 @@
+package appointment
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	clinicModel "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/fixtures"
+	"github.com/google/uuid"
+)
+
+// cairoClinics serves every clinic's settings as Cairo in English.
+type cairoClinics struct{ clinic.Service }
+
+func (cairoClinics) GetSettings(context.Context, uuid.UUID) (*clinicModel.ClinicSettings, error) {
+	return &clinicModel.ClinicSettings{Timezone: "Africa/Cairo", Locale: "en"}, nil
+}
+
+// slowSMS counts the texts sent to each number. Each send takes a while, so a second scheduler
+// runs while the first still holds its batch.
+type slowSMS struct {
+	notification.Notifier
+	mu   sync.Mutex
+	sent map[string]int
+}
+
+func (n *slowSMS) SendSMS(_ context.Context, sms notification.SMS) error {
+	time.Sleep(20 * time.Millisecond)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent[sms.To]++
+	return nil
+}
+
+func TestConcurrentSchedulersSendEachReminderOnce(t *testing.T) {
+	pool := dbtest.New(t)
+	seeded, err := fixtures.Clinic().WithPractitioners(1).WithPatients(4).WithAppointmentsToday(4).Apply(context.Background(), pool)
+	if err != nil {
+		t.Fatalf("seed clinic: %v", err)
+	}
+	// Every appointment, 09:00 to 10:30, is within an hour of being 24 hours away.
+	now := seeded.Appointments[1].StartTime.Add(-24 * time.Hour)
+
+	notifier := &slowSMS{sent: make(map[string]int)}
+	newScheduler := func() *ReminderScheduler {
+		r := NewReminderScheduler(database.NewTxManager(pool), store.NewPgxRepository(), cairoClinics{}, notifier, config.BookingConfig{ReminderBatchSize: 2})
+		r.now = func() time.Time { return now }
+		return r
+	}
+	schedulers := []*ReminderScheduler{newScheduler(), newScheduler()}
+
+	sent := make([]int, len(schedulers))
+	var wg sync.WaitGroup
+	for i, r := range schedulers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := r.SendDue(context.Background())
+			if err != nil {
+				t.Errorf("scheduler %d: SendDue: %v", i, err)
+			}
+			sent[i] = n
+		}()
+	}
+	wg.Wait()
+
+	if total := sent[0] + sent[1]; total != len(seeded.Appointments) {
+		t.Errorf("schedulers sent %d and %d reminders, want %d in total", sent[0], sent[1], len(seeded.Appointments))
+	}
+	for _, patient := range seeded.Patients {
+		if n := notifier.sent[*patient.PhoneNumber]; n != 1 {
+			t.Errorf("patient %s was texted %d time(s), want once", patient.ID, n)
+		}
+	}
+	var pending int
+	if err := pool.QueryRow(context.Background(), `SELECT count(*) FROM appointments WHERE clinic_id = $1 AND reminder_sent_at IS NULL`, seeded.Clinic.ID).Scan(&pending); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 {
+		t.Errorf("%d appointment(s) still have no reminder recorded", pending)
+	}
+}
@@ -0,0 +1,482 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultService is the concrete implementation of the appointment.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo Repository
+	db   database.Querier
+	// patients finds or creates the guest profile of a booking by phone number.
+	patients patient.Service
+	// phones checks and uses up the phone verification a guest books with.
+	phones verification.Service
+	// clinics supplies the clinic timezone that calendar days are read in.
+	clinics clinic.Service
+	// sec mints the management tokens handed to guests who book online.
+	sec    *security.PasetoManager
+	config *config.Config
+	bus    *events.Bus
+}
+
+// NewService creates a new instance of the appointment service, subscribes it to the patient
+// events that move appointments between profiles and registers the appointments export section.
+func NewService(txManager database.TxManager, repo Repository, db database.Querier, patients patient.Service, phones verification.Service, clinics clinic.Service, sec *security.PasetoManager, config *config.Config, bus *events.Bus, exports *export.Registry) Service {
+	s := &defaultService{
+		BaseService: service.BaseService{Tx: txManager},
+		repo:        repo,
+		db:          db,
+		patients:    patients,
+		phones:      phones,
+		clinics:     clinics,
+		sec:         sec,
+		config:      config,
+		bus:         bus,
+	}
+	events.Subscribe(bus, s.onProfilesMerged)
+	exports.Register(exportSection, s.exportAppointments)
+	return s
+}
+
+// CreateAppointment checks the patient and practitioner belong to the clinic and books the slot
+// in one transaction. A guest profile created for the booking rolls back with it.
+func (s *defaultService) CreateAppointment(ctx context.Context, req CreateAppointmentRequest) (*model.Appointment, error) {
+	if !req.StartTime.Before(req.EndTime) {
+		return nil, apierror.NewBadRequest("The appointment must end after it starts.", nil)
+	}
+	guestName := strings.TrimSpace(req.GuestFullName)
+	if req.PatientID == nil && (guestName == "" || strings.TrimSpace(req.GuestPhoneNumber) == "") {
+		return nil, apierror.NewBadRequest("Either a patient ID or the guest's full name and phone number is required.", nil)
+	}
+
+	appointment := &model.Appointment{
+		ID:             uuid.Must(uuid.NewV7()),
+		ClinicID:       req.ClinicID,
+		PractitionerID: req.PractitionerID,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Status:         model.StatusScheduled,
+		Reason:         req.Reason,
+	}
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if req.PatientID != nil {
+			ok, err := s.repo.PatientExists(ctx, tx, req.ClinicID, *req.PatientID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return apierror.NewUnprocessable("The patient does not exist in this clinic.", nil)
+			}
+			appointment.PatientID = *req.PatientID
+		} else {
+			// Joining tx makes the guest profile part of this booking.
+			profile, err := s.patients.FindOrCreateGuestForBooking(database.WithTx(ctx, tx), req.ClinicID, guestName, req.GuestPhoneNumber, nil)
+			if err != nil {
+				return err
+			}
+			appointment.PatientID = profile.ID
+		}
+		return s.book(ctx, tx, appointment)
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to create appointment: %w", err))
+	}
+	return appointment, nil
+}
+
+// BookAsGuest books under the clinic's booking rules: guest booking must be enabled, the slot must
+// respect the minimum notice and booking horizon, and one phone number may hold only a few
+// pending bookings. The phone verification is used up only if the booking commits, so a guest
+// whose slot was taken can retry with it. The guest gets a management token scoped to the new appointment.
+func (s *defaultService) BookAsGuest(ctx context.Context, req GuestBookingRequest) (*GuestBooking, error) {
+	now := time.Now()
+	if err := s.checkGuestSlot(ctx, req.ClinicID, req.StartTime, req.EndTime, now); err != nil {
+		return nil, err
+	}
+
+	appointment := &model.Appointment{
+		ID:             uuid.Must(uuid.NewV7()),
+		ClinicID:       req.ClinicID,
+		PractitionerID: req.PractitionerID,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Status:         model.StatusScheduled,
+		Reason:         req.Reason,
+	}
+	var booking *GuestBooking
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		// Guest profiles are only created for phone numbers the guest proved they hold.
+		if err := s.phones.ConsumeToken(database.WithTx(ctx, tx), req.ClinicID, req.PhoneVerificationToken, req.PhoneNumber); err != nil {
+			return err
+		}
+		profile, err := s.patients.FindOrCreateGuestForBooking(database.WithTx(ctx, tx), req.ClinicID, strings.TrimSpace(req.FullName), req.PhoneNumber, nil)
+		if err != nil {
+			return err
+		}
+		// Concurrent bookings from one phone number are counted one after the other.
+		if err := s.repo.LockPatient(ctx, tx, req.ClinicID, profile.ID); err != nil {
+			return err
+		}
+		pending, err := s.repo.CountPendingForPatient(ctx, tx, req.ClinicID, profile.ID, now)
+		if err != nil {
+			return err
+		}
+		if pending >= s.config.Booking.MaxPendingPerPhone {
+			return apierror.NewTooManyRequests("This phone number already has the maximum number of upcoming bookings.", 0, nil)
+		}
+		appointment.PatientID = profile.ID
+
+		if err := s.book(ctx, tx, appointment); err != nil {
+			return err
+		}
+		booking, err = s.issueGuestToken(ctx, tx, appointment, now)
+		return err
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to book guest appointment: %w", err))
+	}
+	return booking, nil
+}
+
+// GetGuestAppointment returns the appointment the guest's token was issued for.
+func (s *defaultService) GetGuestAppointment(ctx context.Context, guest *security.GuestPayload) (*model.Appointment, error) {
+	appointment, err := s.repo.FindByGuestToken(ctx, s.db, guest.ClinicID, guest.AppointmentID, guest.TokenID)
+	if err != nil {
+		return nil, guestLookupError(err)
+	}
+	return appointment, nil
+}
+
+// CancelAsGuest cancels a scheduled or confirmed appointment that starts after the clinic's
+// cancellation cutoff. Later cancellations must go through the clinic.
+func (s *defaultService) CancelAsGuest(ctx context.Context, guest *security.GuestPayload) (*model.Appointment, error) {
+	clinicSettings, err := s.clinics.GetSettings(ctx, guest.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	cutoffHours := clinicSettings.BookingRules.CancellationCutoffHours
+
+	var appointment *model.Appointment
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		appointment, err = s.repo.FindByGuestTokenForUpdate(ctx, tx, guest.ClinicID, guest.AppointmentID, guest.TokenID)
+		if err != nil {
+			return guestLookupError(err)
+		}
+		now := time.Now()
+		if err := checkGuestChangeable(appointment, cutoffHours, now); err != nil {
+			return err
+		}
+		return s.changeStatus(ctx, tx, appointment, model.StatusCancelled, nil, now)
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to cancel guest appointment: %w", err))
+	}
+	return appointment, nil
+}
+
+// RescheduleAsGuest moves the appointment when both its current start is outside the cancellation
+// cutoff and the new slot is one the guest could book. The new token replaces the old one.
+func (s *defaultService) RescheduleAsGuest(ctx context.Context, guest *security.GuestPayload, start, end time.Time) (*GuestBooking, error) {
+	now := time.Now()
+	if err := s.checkGuestSlot(ctx, guest.ClinicID, start, end, now); err != nil {
+		return nil, err
+	}
+	clinicSettings, err := s.clinics.GetSettings(ctx, guest.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	cutoffHours := clinicSettings.BookingRules.CancellationCutoffHours
+
+	var booking *GuestBooking
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		appointment, err := s.repo.FindByGuestTokenForUpdate(ctx, tx, guest.ClinicID, guest.AppointmentID, guest.TokenID)
+		if err != nil {
+			return guestLookupError(err)
+		}
+		if err := checkGuestChangeable(appointment, cutoffHours, now); err != nil {
+			return err
+		}
+
+		taken, err := s.repo.LockOverlapping(ctx, tx, appointment.ClinicID, appointment.PractitionerID, start, end, appointment.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return apierror.NewConflict("The practitioner already has an appointment at this time.", nil).WithCode(apierror.CodeSlotTaken)
+		}
+		appointment.StartTime, appointment.EndTime = start, end
+		if err := s.repo.Reschedule(ctx, tx, appointment); err != nil {
+			return err
+		}
+		booking, err = s.issueGuestToken(ctx, tx, appointment, now)
+		return err
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to reschedule guest appointment: %w", err))
+	}
+	return booking, nil
+}
+
+// ChangeStatus locks the appointment, checks its lifecycle allows the move and saves it, publishing
+// the change in the same transaction.
+func (s *defaultService) ChangeStatus(ctx context.Context, clinicID, appointmentID uuid.UUID, status string, changedBy uuid.UUID) (*model.Appointment, error) {
+	var appointment *model.Appointment
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		appointment, err = s.repo.FindByIDForUpdate(ctx, tx, clinicID, appointmentID)
+		if err != nil {
+			return err
+		}
+		return s.changeStatus(ctx, tx, appointment, status, &changedBy, time.Now())
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to change appointment status: %w", err))
+	}
+	return appointment, nil
+}
+
+// changeStatus moves the locked appointment to status within tx and publishes the change.
+// Completing an appointment is also published as AppointmentCompleted.
+func (s *defaultService) changeStatus(ctx context.Context, tx pgx.Tx, appointment *model.Appointment, status string, changedBy *uuid.UUID, now time.Time) error {
+	if err := checkTransition(appointment, status, now); err != nil {
+		return err
+	}
+	from := appointment.Status
+	appointment.Status = status
+	appointment.StatusChangedBy = changedBy
+	if err := s.repo.UpdateStatus(ctx, tx, appointment); err != nil {
+		return err
+	}
+
+	err := events.Publish(ctx, s.bus, tx, events.AppointmentStatusChanged{
+		ClinicID:         appointment.ClinicID,
+		AppointmentID:    appointment.ID,
+		PatientProfileID: appointment.PatientID,
+		PractitionerID:   appointment.PractitionerID,
+		From:             from,
+		To:               status,
+		ChangedBy:        changedBy,
+		ChangedAt:        *appointment.StatusChangedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if status == model.StatusCompleted {
+		return events.Publish(ctx, s.bus, tx, events.AppointmentCompleted{
+			ClinicID:         appointment.ClinicID,
+			AppointmentID:    appointment.ID,
+			PatientProfileID: appointment.PatientID,
+		})
+	}
+	return nil
+}
+
+// checkTransition reports why the appointment may not move to status, if it may not. A patient
+// can only be marked a no-show once the appointment has started.
+func checkTransition(appointment *model.Appointment, status string, now time.Time) error {
+	if !model.CanTransition(appointment.Status, status) {
+		return apierror.NewConflict(fmt.Sprintf("The appointment is %s and cannot be changed to %s.", appointment.Status, status), nil).
+			WithCode(apierror.CodeInvalidStatusTransition).
+			WithDetails(map[string]any{"current_status": appointment.Status})
+	}
+	if status == model.StatusNoShow && now.Before(appointment.StartTime) {
+		return apierror.NewConflict("The appointment has not started yet, so the patient cannot be marked as a no-show.", nil).
+			WithCode(apierror.CodeInvalidStatusTransition).
+			WithDetails(map[string]any{"current_status": appointment.Status})
+	}
+	return nil
+}
+
+// checkGuestSlot applies the clinic's booking rules to a slot a guest asks for.
+func (s *defaultService) checkGuestSlot(ctx context.Context, clinicID uuid.UUID, start, end, now time.Time) error {
+	if !start.Before(end) {
+		return apierror.NewBadRequest("The appointment must end after it starts.", nil)
+	}
+	clinicSettings, err := s.clinics.GetSettings(ctx, clinicID)
+	if err != nil {
+		return err
+	}
+	rules := clinicSettings.BookingRules
+	if !rules.GuestBookingEnabled {
+		return apierror.NewForbidden("This clinic does not accept online bookings.", nil)
+	}
+	if start.Before(now.Add(time.Duration(rules.MinNoticeMinutes) * time.Minute)) {
+		return apierror.NewUnprocessable(fmt.Sprintf("Appointments must be booked at least %d minutes in advance.", rules.MinNoticeMinutes), nil)
+	}
+	if start.After(now.AddDate(0, 0, rules.MaxAdvanceDays)) {
+		return apierror.NewUnprocessable(fmt.Sprintf("Appointments can be booked at most %d days in advance.", rules.MaxAdvanceDays), nil)
+	}
+	return nil
+}
+
+// checkGuestChangeable reports why a guest may not cancel or move the appointment, if they may not.
+func checkGuestChangeable(appointment *model.Appointment, cutoffHours int, now time.Time) error {
+	if appointment.Status != model.StatusScheduled && appointment.Status != model.StatusConfirmed {
+		return apierror.NewConflict(fmt.Sprintf("The appointment is %s and can no longer be changed.", appointment.Status), nil)
+	}
+	if appointment.StartTime.Before(now.Add(time.Duration(cutoffHours) * time.Hour)) {
+		return apierror.NewUnprocessable(fmt.Sprintf("Appointments cannot be changed online less than %d hours before they start. Please contact the clinic.", cutoffHours), nil)
+	}
+	return nil
+}
+
+// issueGuestToken mints a management token for the appointment and records it, replacing any
+// earlier token. The token is useless once the appointment has started, so it expires then at the latest.
+func (s *defaultService) issueGuestToken(ctx context.Context, tx pgx.Tx, appointment *model.Appointment, now time.Time) (*GuestBooking, error) {
+	expiresAt := now.Add(s.config.Security.GuestTokenDuration)
+	if appointment.StartTime.Before(expiresAt) {
+		expiresAt = appointment.StartTime
+	}
+	payload, err := security.NewGuestPayload(appointment.ID, appointment.ClinicID, security.GuestPurposeManage, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.sec.CreateGuestToken(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create guest token: %w", err)
+	}
+	if err := s.repo.SetGuestToken(ctx, tx, appointment.ClinicID, appointment.ID, payload.TokenID, payload.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &GuestBooking{
+		Appointment:            appointment,
+		ManagementToken:        token,
+		ManagementTokenExpires: payload.ExpiresAt,
+	}, nil
+}
+
+// guestLookupError turns a missing appointment into a rejected token: the token was replaced
+// by a newer one, or its appointment was deleted.
+func guestLookupError(err error) error {
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return apierror.NewUnauthorized("This management link is no longer valid.", err).WithCode(apierror.CodeTokenExpired)
+	}
+	return err
+}
+
+// book checks the practitioner can take the appointment, inserts it within tx and announces it.
+func (s *defaultService) book(ctx context.Context, tx pgx.Tx, appointment *model.Appointment) error {
+	ok, err := s.repo.PractitionerExists(ctx, tx, appointment.ClinicID, appointment.PractitionerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apierror.NewUnprocessable("The practitioner is not an active employee of this clinic.", nil)
+	}
+
+	// Locking the overlapping rows makes concurrent bookings of the same slot queue up here;
+	// the exclusion constraint backs this up when the slot was still empty.
+	taken, err := s.repo.LockOverlapping(ctx, tx, appointment.ClinicID, appointment.PractitionerID, appointment.StartTime, appointment.EndTime, uuid.Nil)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apierror.NewConflict("The practitioner already has an appointment at this time.", nil).WithCode(apierror.CodeSlotTaken)
+	}
+	if err := s.repo.Create(ctx, tx, appointment); err != nil {
+		return err
+	}
+	return events.Publish(ctx, s.bus, tx, events.AppointmentBooked{
+		ClinicID:         appointment.ClinicID,
+		AppointmentID:    appointment.ID,
+		PatientProfileID: appointment.PatientID,
+		PractitionerID:   appointment.PractitionerID,
+		StartTime:        appointment.StartTime,
+		EndTime:          appointment.EndTime,
+		Status:           appointment.Status,
+	})
+}
+
+// GetAppointment returns one of the clinic's appointments.
+func (s *defaultService) GetAppointment(ctx context.Context, clinicID, appointmentID uuid.UUID) (*model.Appointment, error) {
+	appointment, err := s.repo.FindByID(ctx, s.db, clinicID, appointmentID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to get appointment: %w", err))
+	}
+	return appointment, nil
+}
+
+// ListAppointmentsForDay reads day's date in the clinic's timezone, so the calendar shows the
+// clinic's local day whatever the caller's offset.
+func (s *defaultService) ListAppointmentsForDay(ctx context.Context, clinicID uuid.UUID, day time.Time, practitionerID *uuid.UUID) ([]model.Appointment, error) {
+	loc, err := s.clinicLocation(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+
+	appointments, err := s.repo.ListByClinicAndDay(ctx, s.db, clinicID, from, to, practitionerID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list appointments: %w", err))
+	}
+	return appointments, nil
+}
+
+// clinicLocation returns the clinic's timezone. A zone the server cannot load falls back to UTC
+// rather than failing every calendar request.
+func (s *defaultService) clinicLocation(ctx context.Context, clinicID uuid.UUID) (*time.Location, error) {
+	clinicSettings, err := s.clinics.GetSettings(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(clinicSettings.Timezone)
+	if err != nil {
+		log.Warn().Err(err).Str("clinic_id", clinicID.String()).Str("timezone", clinicSettings.Timezone).Msg("Unknown clinic timezone; using UTC")
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// onProfilesMerged moves the merged-away profile's appointments to the surviving profile.
+func (s *defaultService) onProfilesMerged(ctx context.Context, tx pgx.Tx, event events.ProfilesMerged) error {
+	_, err := s.repo.ReassignPatient(ctx, tx, event.ClinicID, event.SourceProfileID, event.TargetProfileID)
+	return err
+}
@@ -0,0 +1,307 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
+	patientModel "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestCheckTransitionCoversEveryStatusPair(t *testing.T) {
+	statuses := []string{
+		model.StatusScheduled, model.StatusConfirmed, model.StatusCheckedIn,
+		model.StatusCompleted, model.StatusCancelled, model.StatusNoShow,
+	}
+	allowed := map[[2]string]bool{
+		{model.StatusScheduled, model.StatusConfirmed}: true,
+		{model.StatusScheduled, model.StatusCheckedIn}: true,
+		{model.StatusScheduled, model.StatusCancelled}: true,
+		{model.StatusScheduled, model.StatusNoShow}:    true,
+		{model.StatusConfirmed, model.StatusCheckedIn}: true,
+		{model.StatusConfirmed, model.StatusCancelled}: true,
+		{model.StatusConfirmed, model.StatusNoShow}:    true,
+		{model.StatusCheckedIn, model.StatusCompleted}: true,
+	}
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			t.Run(from+"->"+to, func(t *testing.T) {
+				// The appointment has started, so the no-show timing rule never applies here.
+				appointment := &model.Appointment{Status: from, StartTime: now.Add(-time.Hour)}
+				err := checkTransition(appointment, to, now)
+				if allowed[[2]string{from, to}] {
+					if err != nil {
+						t.Errorf("checkTransition: %v, want the change allowed", err)
+					}
+					return
+				}
+				assertInvalidTransition(t, err, from)
+			})
+		}
+	}
+}
+
+func TestCheckTransitionRejectsNoShowBeforeTheStart(t *testing.T) {
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	for _, from := range []string{model.StatusScheduled, model.StatusConfirmed} {
+		appointment := &model.Appointment{Status: from, StartTime: now.Add(time.Minute)}
+		assertInvalidTransition(t, checkTransition(appointment, model.StatusNoShow, now), from)
+	}
+}
+
+func assertInvalidTransition(t *testing.T, err error, from string) {
+	t.Helper()
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want an apierror", err)
+	}
+	if apiErr.StatusCode != http.StatusConflict || apiErr.Code != apierror.CodeInvalidStatusTransition {
+		t.Errorf("error = %d %q, want %d %q", apiErr.StatusCode, apiErr.Code, http.StatusConflict, apierror.CodeInvalidStatusTransition)
+	}
+	if got := apiErr.Details["current_status"]; got != from {
+		t.Errorf("details current_status = %v, want %s", got, from)
+	}
+}
+
+func TestCheckGuestChangeableEnforcesTheCancellationCutoff(t *testing.T) {
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	const cutoffHours = 24
+	tests := []struct {
+		name     string
+		status   string
+		startsIn time.Duration
+		want     int
+	}{
+		{"well before the cutoff", model.StatusScheduled, 48 * time.Hour, 0},
+		{"exactly at the cutoff", model.StatusConfirmed, cutoffHours * time.Hour, 0},
+		{"just inside the cutoff", model.StatusScheduled, cutoffHours*time.Hour - time.Minute, http.StatusUnprocessableEntity},
+		{"already started", model.StatusConfirmed, -time.Hour, http.StatusUnprocessableEntity},
+		{"cancelled", model.StatusCancelled, 48 * time.Hour, http.StatusConflict},
+		{"checked in", model.StatusCheckedIn, 48 * time.Hour, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appointment := &model.Appointment{Status: tt.status, StartTime: now.Add(tt.startsIn)}
+			err := checkGuestChangeable(appointment, cutoffHours, now)
+			if tt.want == 0 {
+				if err != nil {
+					t.Errorf("checkGuestChangeable: %v, want the change allowed", err)
+				}
+				return
+			}
+			var apiErr *apierror.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.want {
+				t.Errorf("checkGuestChangeable error = %v, want a %d", err, tt.want)
+			}
+		})
+	}
+}
+
+// fakeTx runs closures directly.
+type fakeTx struct{}
+
+func (fakeTx) ExecTx(_ context.Context, fn func(tx pgx.Tx) error) error { return fn(nil) }
+func (fakeTx) ExecTxOpts(_ context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+func (fakeTx) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+// bookingRepo knows one clinic's patients and practitioners and keeps what is booked.
+type bookingRepo struct {
+	Repository
+	clinicID      uuid.UUID
+	patients      []uuid.UUID
+	practitioners []uuid.UUID
+	booked        []*model.Appointment
+}
+
+func (r *bookingRepo) PatientExists(_ context.Context, _ database.Querier, clinicID, profileID uuid.UUID) (bool, error) {
+	return clinicID == r.clinicID && slices.Contains(r.patients, profileID), nil
+}
+
+func (r *bookingRepo) PractitionerExists(_ context.Context, _ database.Querier, clinicID, profileID uuid.UUID) (bool, error) {
+	return clinicID == r.clinicID && slices.Contains(r.practitioners, profileID), nil
+}
+
+func (r *bookingRepo) LockOverlapping(_ context.Context, _ database.Querier, _, practitionerID uuid.UUID, start, end time.Time, _ uuid.UUID) (bool, error) {
+	for _, a := range r.booked {
+		if a.PractitionerID == practitionerID && a.StartTime.Before(end) && start.Before(a.EndTime) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *bookingRepo) Create(_ context.Context, _ database.Querier, appointment *model.Appointment) error {
+	r.booked = append(r.booked, appointment)
+	return nil
+}
+
+// guestPatients creates a guest profile for every booking by name and phone.
+type guestPatients struct {
+	patient.Service
+	guests []*patientModel.Profile
+}
+
+func (p *guestPatients) FindOrCreateGuestForBooking(_ context.Context, clinicID uuid.UUID, fullName, phoneNumber string, _ *string) (*patientModel.Profile, error) {
+	guest := &patientModel.Profile{ID: uuid.New(), ClinicID: clinicID, FullName: fullName, PhoneNumber: &phoneNumber, ProfileStatus: patientModel.ProfileStatusGuest}
+	p.guests = append(p.guests, guest)
+	return guest, nil
+}
+
+func TestCreateAppointment(t *testing.T) {
+	clinicID, patientID, practitionerID := uuid.New(), uuid.New(), uuid.New()
+	strangerID := uuid.New()
+	start := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+
+	tests := []struct {
+		name      string
+		req       CreateAppointmentRequest
+		want      int
+		wantGuest bool
+	}{
+		{"existing patient", CreateAppointmentRequest{PatientID: &patientID, PractitionerID: practitionerID, StartTime: start, EndTime: end}, 0, false},
+		{"guest by name and phone", CreateAppointmentRequest{GuestFullName: " Mona Adel ", GuestPhoneNumber: "+201012345678", PractitionerID: practitionerID, StartTime: start, EndTime: end}, 0, true},
+		{"ends when it starts", CreateAppointmentRequest{PatientID: &patientID, PractitionerID: practitionerID, StartTime: start, EndTime: start}, http.StatusBadRequest, false},
+		{"ends before it starts", CreateAppointmentRequest{PatientID: &patientID, PractitionerID: practitionerID, StartTime: end, EndTime: start}, http.StatusBadRequest, false},
+		{"no patient and no phone", CreateAppointmentRequest{GuestFullName: "Mona Adel", PractitionerID: practitionerID, StartTime: start, EndTime: end}, http.StatusBadRequest, false},
+		{"patient of another clinic", CreateAppointmentRequest{PatientID: &strangerID, PractitionerID: practitionerID, StartTime: start, EndTime: end}, http.StatusUnprocessableEntity, false},
+		{"practitioner of another clinic", CreateAppointmentRequest{PatientID: &patientID, PractitionerID: strangerID, StartTime: start, EndTime: end}, http.StatusUnprocessableEntity, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &bookingRepo{clinicID: clinicID, patients: []uuid.UUID{patientID}, practitioners: []uuid.UUID{practitionerID}}
+			patients := &guestPatients{}
+			svc := NewService(fakeTx{}, repo, nil, patients, nil, nil, nil, &config.Config{}, events.NewBus(), export.NewRegistry())
+
+			tt.req.ClinicID = clinicID
+			got, err := svc.CreateAppointment(context.Background(), tt.req)
+			if tt.want != 0 {
+				var apiErr *apierror.APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.want {
+					t.Fatalf("CreateAppointment error = %v, want a %d", err, tt.want)
+				}
+				if len(repo.booked) != 0 {
+					t.Errorf("%d appointments booked, want none", len(repo.booked))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAppointment: %v", err)
+			}
+			if len(repo.booked) != 1 || repo.booked[0] != got {
+				t.Fatalf("booked %v, want the returned appointment", repo.booked)
+			}
+			if got.Status != model.StatusScheduled || got.ClinicID != clinicID {
+				t.Errorf("appointment = %+v, want a scheduled appointment of the clinic", got)
+			}
+			if !tt.wantGuest {
+				if got.PatientID != patientID || len(patients.guests) != 0 {
+					t.Errorf("patient = %s with %d guests created, want %s and none", got.PatientID, len(patients.guests), patientID)
+				}
+				return
+			}
+			if len(patients.guests) != 1 || got.PatientID != patients.guests[0].ID {
+				t.Fatalf("patient = %s, want the one guest created for the booking", got.PatientID)
+			}
+			if name := patients.guests[0].FullName; name != "Mona Adel" {
+				t.Errorf("guest name = %q, want it trimmed", name)
+			}
+		})
+	}
+}
+
+func TestCreateAppointmentRefusesAnOverlappingSlot(t *testing.T) {
+	clinicID, patientID, practitionerID := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	repo := &bookingRepo{clinicID: clinicID, patients: []uuid.UUID{patientID}, practitioners: []uuid.UUID{practitionerID}}
+	svc := NewService(fakeTx{}, repo, nil, &guestPatients{}, nil, nil, nil, &config.Config{}, events.NewBus(), export.NewRegistry())
+
+	book := func(start time.Time) error {
+		_, err := svc.CreateAppointment(context.Background(), CreateAppointmentRequest{
+			ClinicID: clinicID, PatientID: &patientID, PractitionerID: practitionerID,
+			StartTime: start, EndTime: start.Add(30 * time.Minute),
+		})
+		return err
+	}
+	if err := book(start); err != nil {
+		t.Fatalf("CreateAppointment: %v", err)
+	}
+	// Back to back is fine; half an hour in is not.
+	if err := book(start.Add(30 * time.Minute)); err != nil {
+		t.Fatalf("CreateAppointment for the next slot: %v", err)
+	}
+	err := book(start.Add(15 * time.Minute))
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.Code != apierror.CodeSlotTaken {
+		t.Errorf("CreateAppointment error = %v, want a 409 %s", err, apierror.CodeSlotTaken)
+	}
+}
+
+// statusRepo holds a single appointment whose status changes are saved in place.
+type statusRepo struct {
+	Repository
+	appointment *model.Appointment
+}
+
+func (r *statusRepo) FindByIDForUpdate(context.Context, database.Querier, uuid.UUID, uuid.UUID) (*model.Appointment, error) {
+	return r.appointment, nil
+}
+
+func (r *statusRepo) UpdateStatus(_ context.Context, _ database.Querier, appointment *model.Appointment) error {
+	now := time.Now()
+	appointment.StatusChangedAt = &now
+	return nil
+}
+
+// TestOnlyCompletingAnAppointmentPublishesAppointmentCompleted checks the hook the patient module
+// promotes guests from, without the appointment module knowing about patients.
+func TestOnlyCompletingAnAppointmentPublishesAppointmentCompleted(t *testing.T) {
+	ctx := context.Background()
+	clinicID, staffID := uuid.New(), uuid.New()
+	appointment := &model.Appointment{
+		ID:             uuid.New(),
+		ClinicID:       clinicID,
+		PatientID:      uuid.New(),
+		PractitionerID: uuid.New(),
+		StartTime:      time.Now().Add(-time.Hour),
+		EndTime:        time.Now().Add(-30 * time.Minute),
+		Status:         model.StatusScheduled,
+	}
+	bus := events.NewBus()
+	var completed []events.AppointmentCompleted
+	events.Subscribe(bus, func(_ context.Context, _ pgx.Tx, e events.AppointmentCompleted) error {
+		completed = append(completed, e)
+		return nil
+	})
+	svc := NewService(fakeTx{}, &statusRepo{appointment: appointment}, nil, nil, nil, nil, nil, &config.Config{}, bus, export.NewRegistry())
+
+	if _, err := svc.ChangeStatus(ctx, clinicID, appointment.ID, model.StatusCheckedIn, staffID); err != nil {
+		t.Fatalf("ChangeStatus to %s: %v", model.StatusCheckedIn, err)
+	}
+	if len(completed) != 0 {
+		t.Fatalf("checking in published %v, want nothing", completed)
+	}
+	if _, err := svc.ChangeStatus(ctx, clinicID, appointment.ID, model.StatusCompleted, staffID); err != nil {
+		t.Fatalf("ChangeStatus to %s: %v", model.StatusCompleted, err)
+	}
+	want := events.AppointmentCompleted{ClinicID: clinicID, AppointmentID: appointment.ID, PatientProfileID: appointment.PatientID}
+	if len(completed) != 1 || completed[0] != want {
+		t.Errorf("completing published %v, want [%v]", completed, want)
+	}
+}
@@ -0,0 +1,337 @@
+// Package store provides the database implementation for the appointment repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pgxRepository is the PostgreSQL implementation of the appointment.Repository.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the appointment repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+// appointmentColumns selects an appointment in the order scanAppointment reads it.
+const appointmentColumns = `
+        id, clinic_id, patient_id, doctor_id, start_time, end_time, status, sub_status, reason, notes,
+        status_changed_at, status_changed_by, created_at, updated_at`
+
+func scanAppointment(row pgx.Row) (*model.Appointment, error) {
+	var a model.Appointment
+	err := row.Scan(&a.ID, &a.ClinicID, &a.PatientID, &a.PractitionerID, &a.StartTime, &a.EndTime,
+		&a.Status, &a.SubStatus, &a.Reason, &a.Notes, &a.StatusChangedAt, &a.StatusChangedBy, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Create inserts the appointment and fills in its status and timestamps.
+func (r *pgxRepository) Create(ctx context.Context, querier database.Querier, a *model.Appointment) error {
+	query := `
+        INSERT INTO appointments (id, clinic_id, patient_id, doctor_id, start_time, end_time, status, reason)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING created_at, updated_at`
+	err := querier.QueryRow(ctx, query, a.ID, a.ClinicID, a.PatientID, a.PractitionerID, a.StartTime, a.EndTime, a.Status, a.Reason).
+		Scan(&a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		// The exclusion constraint catches a booking that raced past the overlap check.
+		if _, ok := database.ExclusionViolation(err); ok {
+			return apierror.NewConflict("The practitioner already has an appointment at this time.", err).WithCode(apierror.CodeSlotTaken)
+		}
+		return fmt.Errorf("store.Create: failed to insert appointment: %w", err)
+	}
+	return nil
+}
+
+// FindByID retrieves one of the clinic's appointments.
+func (r *pgxRepository) FindByID(ctx context.Context, querier database.Querier, clinicID, appointmentID uuid.UUID) (*model.Appointment, error) {
+	return r.findByID(ctx, querier, clinicID, appointmentID, "")
+}
+
+// FindByIDForUpdate is FindByID that also locks the appointment for the rest of the transaction.
+func (r *pgxRepository) FindByIDForUpdate(ctx context.Context, querier database.Querier, clinicID, appointmentID uuid.UUID) (*model.Appointment, error) {
+	return r.findByID(ctx, querier, clinicID, appointmentID, " FOR UPDATE")
+}
+
+func (r *pgxRepository) findByID(ctx context.Context, querier database.Querier, clinicID, appointmentID uuid.UUID, lockClause string) (*model.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + `
+        FROM appointments
+        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL` + lockClause
+	a, err := scanAppointment(querier.QueryRow(ctx, query, clinicID, appointmentID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("appointment", err)
+		}
+		return nil, fmt.Errorf("store.FindByID: failed to query appointment: %w", err)
+	}
+	return a, nil
+}
+
+// LockOverlapping reports whether the practitioner has a live appointment overlapping [start, end),
+// locking it so a concurrent cancellation or reschedule settles first. Cancelled appointments no
+// longer hold their slot. excludeID skips the appointment being rescheduled; uuid.Nil skips none.
+func (r *pgxRepository) LockOverlapping(ctx context.Context, querier database.Querier, clinicID, practitionerID uuid.UUID, start, end time.Time, excludeID uuid.UUID) (bool, error) {
+	query := `
+        SELECT id FROM appointments
+        WHERE clinic_id = $1 AND doctor_id = $2 AND deleted_at IS NULL AND status <> 'CANCELLED'
+          AND tstzrange(start_time, end_time) && tstzrange($3, $4) AND id <> $5
+        LIMIT 1
+        FOR UPDATE`
+	var id uuid.UUID
+	if err := querier.QueryRow(ctx, query, clinicID, practitionerID, start, end, excludeID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("store.LockOverlapping: failed to lock overlapping appointments: %w", err)
+	}
+	return true, nil
+}
+
+// FindByGuestToken retrieves the appointment a guest token was issued for, provided the token
+// has not been replaced since.
+func (r *pgxRepository) FindByGuestToken(ctx context.Context, querier database.Querier, clinicID, appointmentID, tokenID uuid.UUID) (*model.Appointment, error) {
+	return r.findByGuestToken(ctx, querier, clinicID, appointmentID, tokenID, "")
+}
+
+// FindByGuestTokenForUpdate is FindByGuestToken that also locks the appointment for the rest of
+// the transaction.
+func (r *pgxRepository) FindByGuestTokenForUpdate(ctx context.Context, querier database.Querier, clinicID, appointmentID, tokenID uuid.UUID) (*model.Appointment, error) {
+	return r.findByGuestToken(ctx, querier, clinicID, appointmentID, tokenID, " FOR UPDATE")
+}
+
+func (r *pgxRepository) findByGuestToken(ctx context.Context, querier database.Querier, clinicID, appointmentID, tokenID uuid.UUID, lockClause string) (*model.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + `
+        FROM appointments
+        WHERE clinic_id = $1 AND id = $2 AND guest_management_token = $3 AND deleted_at IS NULL` + lockClause
+	a, err := scanAppointment(querier.QueryRow(ctx, query, clinicID, appointmentID, tokenID.String()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("appointment", err)
+		}
+		return nil, fmt.Errorf("store.FindByGuestToken: failed to query appointment: %w", err)
+	}
+	return a, nil
+}
+
+// UpdateStatus sets the appointment's status and who changed it, stamps status_changed_at and
+// refreshes its updated_at. A sub-status refines one status only, so it is cleared.
+func (r *pgxRepository) UpdateStatus(ctx context.Context, querier database.Querier, a *model.Appointment) error {
+	query := `
+        UPDATE appointments SET status = $3, status_changed_by = $4, status_changed_at = NOW(), sub_status = NULL
+        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL
+        RETURNING status_changed_at, updated_at`
+	var changedAt time.Time
+	if err := querier.QueryRow(ctx, query, a.ClinicID, a.ID, a.Status, a.StatusChangedBy).Scan(&changedAt, &a.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewNotFound("appointment", err)
+		}
+		return fmt.Errorf("store.UpdateStatus: failed to update appointment: %w", err)
+	}
+	a.StatusChangedAt = &changedAt
+	a.SubStatus = nil
+	return nil
+}
+
+// Reschedule moves the appointment to its new start and end time and refreshes its updated_at.
+func (r *pgxRepository) Reschedule(ctx context.Context, querier database.Querier, a *model.Appointment) error {
+	query := `
+        UPDATE appointments SET start_time = $3, end_time = $4
+        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL
+        RETURNING updated_at`
+	if err := querier.QueryRow(ctx, query, a.ClinicID, a.ID, a.StartTime, a.EndTime).Scan(&a.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewNotFound("appointment", err)
+		}
+		// The exclusion constraint catches a move that raced past the overlap check.
+		if _, ok := database.ExclusionViolation(err); ok {
+			return apierror.NewConflict("The practitioner already has an appointment at this time.", err).WithCode(apierror.CodeSlotTaken)
+		}
+		return fmt.Errorf("store.Reschedule: failed to update appointment: %w", err)
+	}
+	return nil
+}
+
+// ListByClinicAndDay returns the appointments starting in [from, to), ordered by start time.
+func (r *pgxRepository) ListByClinicAndDay(ctx context.Context, querier database.Querier, clinicID uuid.UUID, from, to time.Time, practitionerID *uuid.UUID) ([]model.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + `
+        FROM appointments
+        WHERE clinic_id = $1 AND start_time >= $2 AND start_time < $3 AND deleted_at IS NULL
+          AND ($4::uuid IS NULL OR doctor_id = $4)
+        ORDER BY start_time, id`
+	rows, err := querier.Query(ctx, query, clinicID, from, to, practitionerID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListByClinicAndDay: failed to query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	appointments := []model.Appointment{}
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store.ListByClinicAndDay: failed to scan appointment: %w", err)
+		}
+		appointments = append(appointments, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListByClinicAndDay: error iterating rows: %w", err)
+	}
+	return appointments, nil
+}
+
+// ListByPatient returns all of the patient's appointments, oldest first.
+func (r *pgxRepository) ListByPatient(ctx context.Context, querier database.Querier, clinicID, patientID uuid.UUID) ([]model.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + `
+        FROM appointments
+        WHERE clinic_id = $1 AND patient_id = $2 AND deleted_at IS NULL
+        ORDER BY start_time, id`
+	rows, err := querier.Query(ctx, query, clinicID, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListByPatient: failed to query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	appointments := []model.Appointment{}
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store.ListByPatient: failed to scan appointment: %w", err)
+		}
+		appointments = append(appointments, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListByPatient: error iterating rows: %w", err)
+	}
+	return appointments, nil
+}
+
+// LockPatient locks the patient's profile row for the rest of the transaction.
+func (r *pgxRepository) LockPatient(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error {
+	var id uuid.UUID
+	query := `SELECT id FROM profiles WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL FOR UPDATE`
+	if err := querier.QueryRow(ctx, query, clinicID, profileID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewNotFound("patient", err)
+		}
+		return fmt.Errorf("store.LockPatient: failed to lock profile: %w", err)
+	}
+	return nil
+}
+
+// CountPendingForPatient counts the patient's scheduled or confirmed appointments starting after now.
+func (r *pgxRepository) CountPendingForPatient(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, now time.Time) (int, error) {
+	var count int
+	query := `
+        SELECT COUNT(*) FROM appointments
+        WHERE clinic_id = $1 AND patient_id = $2 AND deleted_at IS NULL
+          AND status IN ('SCHEDULED', 'CONFIRMED') AND start_time > $3`
+	if err := querier.QueryRow(ctx, query, clinicID, profileID, now).Scan(&count); err != nil {
+		return 0, fmt.Errorf("store.CountPendingForPatient: failed to count appointments: %w", err)
+	}
+	return count, nil
+}
+
+// SetGuestToken records the guest management token issued for the appointment, replacing any earlier one.
+func (r *pgxRepository) SetGuestToken(ctx context.Context, querier database.Querier, clinicID, appointmentID, tokenID uuid.UUID, expiresAt time.Time) error {
+	query := `
+        UPDATE appointments SET guest_management_token = $3, guest_management_token_expires_at = $4
+        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL`
+	tag, err := querier.Exec(ctx, query, clinicID, appointmentID, tokenID.String(), expiresAt)
+	if err != nil {
+		return fmt.Errorf("store.SetGuestToken: failed to update appointment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewNotFound("appointment", nil)
+	}
+	return nil
+}
+
+// LockDueReminders returns, earliest first, the scheduled or confirmed appointments of patients
+// with a phone number that start within window of now plus their clinic's reminder lead time,
+// in clinics with reminders enabled. The rows stay locked until the transaction ends; rows
+// another scheduler holds are skipped, so concurrent schedulers never pick the same appointment.
+func (r *pgxRepository) LockDueReminders(ctx context.Context, querier database.Querier, now time.Time, defaults model.ReminderDefaults, window time.Duration, limit int) ([]model.Reminder, error) {
+	query := `
+        SELECT a.id, a.clinic_id, a.start_time, p.phone_number, p.preferred_language, d.full_name, c.name, c.phone_number
+        FROM appointments a
+        JOIN profiles p ON p.id = a.patient_id
+        JOIN profiles d ON d.id = a.doctor_id
+        JOIN clinics c ON c.id = a.clinic_id
+        LEFT JOIN clinic_settings s ON s.clinic_id = a.clinic_id AND s.section = 'notifications'
+        WHERE a.reminder_sent_at IS NULL AND a.deleted_at IS NULL AND a.status IN ('SCHEDULED', 'CONFIRMED')
+          AND p.phone_number IS NOT NULL
+          AND COALESCE((s.data->>'reminders_enabled')::boolean, $2)
+          AND a.start_time BETWEEN $1::timestamptz + make_interval(hours => COALESCE((s.data->>'reminder_lead_hours')::int, $3)) - $4::interval
+                               AND $1::timestamptz + make_interval(hours => COALESCE((s.data->>'reminder_lead_hours')::int, $3)) + $4::interval
+        ORDER BY a.start_time
+        LIMIT $5
+        FOR UPDATE OF a SKIP LOCKED`
+	rows, err := querier.Query(ctx, query, now, defaults.Enabled, defaults.LeadHours, window, limit)
+	if err != nil {
+		return nil, fmt.Errorf("store.LockDueReminders: failed to query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	reminders := []model.Reminder{}
+	for rows.Next() {
+		var rem model.Reminder
+		if err := rows.Scan(&rem.AppointmentID, &rem.ClinicID, &rem.StartTime, &rem.PatientPhone, &rem.PatientLanguage,
+			&rem.PractitionerName, &rem.ClinicName, &rem.ClinicPhone); err != nil {
+			return nil, fmt.Errorf("store.LockDueReminders: failed to scan appointment: %w", err)
+		}
+		reminders = append(reminders, rem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.LockDueReminders: error iterating rows: %w", err)
+	}
+	return reminders, nil
+}
+
+// MarkReminderSent records when the appointment's reminder was sent.
+func (r *pgxRepository) MarkReminderSent(ctx context.Context, querier database.Querier, appointmentID uuid.UUID, sentAt time.Time) error {
+	if _, err := querier.Exec(ctx, `UPDATE appointments SET reminder_sent_at = $2 WHERE id = $1`, appointmentID, sentAt); err != nil {
+		return fmt.Errorf("store.MarkReminderSent: failed to update appointment: %w", err)
+	}
+	return nil
+}
+
+// PatientExists reports whether profileID is an active profile of the clinic.
+func (r *pgxRepository) PatientExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM profiles WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL)`
+	if err := querier.QueryRow(ctx, query, clinicID, profileID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("store.PatientExists: failed to query profile: %w", err)
+	}
+	return exists, nil
+}
+
+// PractitionerExists reports whether profileID is an active employee of the clinic.
+func (r *pgxRepository) PractitionerExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM employees WHERE clinic_id = $1 AND profile_id = $2 AND status = 'ACTIVE')`
+	if err := querier.QueryRow(ctx, query, clinicID, profileID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("store.PractitionerExists: failed to query employee: %w", err)
+	}
+	return exists, nil
+}
+
+// ReassignPatient moves every appointment of fromProfileID, soft-deleted ones included, to toProfileID.
+func (r *pgxRepository) ReassignPatient(ctx context.Context, querier database.Querier, clinicID, fromProfileID, toProfileID uuid.UUID) (int64, error) {
+	query := `UPDATE appointments SET patient_id = $3 WHERE clinic_id = $1 AND patient_id = $2`
+	tag, err := querier.Exec(ctx, query, clinicID, fromProfileID, toProfileID)
+	if err != nil {
+		return 0, fmt.Errorf("store.ReassignPatient: failed to update appointments: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,192 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/fixtures"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedClinic applies the fixture and returns what it created.
+func seedClinic(t *testing.T, pool *pgxpool.Pool, fixture *fixtures.ClinicBuilder) *fixtures.Seeded {
+	t.Helper()
+	seeded, err := fixture.Apply(context.Background(), pool)
+	if err != nil {
+		t.Fatalf("seed clinic: %v", err)
+	}
+	return seeded
+}
+
+func TestListByClinicAndDayFiltersByPractitioner(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository()
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithPractitioners(2).WithPatients(4).WithAppointmentsToday(6))
+	seedClinic(t, pool, fixtures.Clinic().WithOwner().WithPatients(1).WithAppointmentsToday(2))
+
+	// The fixture books from 09:00 in the clinic's timezone.
+	from := seeded.Appointments[0].StartTime.Add(-9 * time.Hour)
+	to := from.Add(24 * time.Hour)
+
+	all, err := repo.ListByClinicAndDay(ctx, pool, seeded.Clinic.ID, from, to, nil)
+	if err != nil {
+		t.Fatalf("ListByClinicAndDay: %v", err)
+	}
+	if len(all) != len(seeded.Appointments) {
+		t.Fatalf("ListByClinicAndDay returned %d appointments, want %d", len(all), len(seeded.Appointments))
+	}
+	for i, a := range all {
+		if a.ID != seeded.Appointments[i].ID {
+			t.Errorf("appointment %d = %s, want %s in start order", i, a.ID, seeded.Appointments[i].ID)
+		}
+	}
+
+	practitioner := seeded.Practitioners[1].ProfileID
+	theirs, err := repo.ListByClinicAndDay(ctx, pool, seeded.Clinic.ID, from, to, &practitioner)
+	if err != nil {
+		t.Fatalf("ListByClinicAndDay: %v", err)
+	}
+	if len(theirs) != 3 {
+		t.Fatalf("ListByClinicAndDay for one practitioner returned %d appointments, want 3", len(theirs))
+	}
+	for _, a := range theirs {
+		if a.PractitionerID != practitioner {
+			t.Errorf("appointment %s is with %s, want %s", a.ID, a.PractitionerID, practitioner)
+		}
+	}
+}
+
+func TestCreateAndFindByIDStayWithinTheClinic(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository()
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithPractitioners(1).WithPatients(1))
+	other := seedClinic(t, pool, fixtures.Clinic().WithPractitioners(1).WithPatients(1))
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Minute).UTC()
+	reason := "Toothache"
+	created := &model.Appointment{
+		ID:             uuid.Must(uuid.NewV7()),
+		ClinicID:       seeded.Clinic.ID,
+		PatientID:      seeded.Patients[0].ID,
+		PractitionerID: seeded.Practitioners[0].ProfileID,
+		StartTime:      start,
+		EndTime:        start.Add(30 * time.Minute),
+		Status:         model.StatusScheduled,
+		Reason:         &reason,
+	}
+	if err := repo.Create(ctx, pool, created); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	found, err := repo.FindByID(ctx, pool, seeded.Clinic.ID, created.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if found.PatientID != created.PatientID || found.PractitionerID != created.PractitionerID ||
+		!found.StartTime.Equal(created.StartTime) || !found.EndTime.Equal(created.EndTime) ||
+		found.Status != model.StatusScheduled || found.Reason == nil || *found.Reason != reason {
+		t.Errorf("FindByID = %+v, want the created appointment %+v", found, created)
+	}
+
+	_, err = repo.FindByID(ctx, pool, other.Clinic.ID, created.ID)
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("FindByID from another clinic: error = %v, want a 404", err)
+	}
+
+	for _, check := range []struct {
+		name   string
+		exists func(context.Context, database.Querier, uuid.UUID, uuid.UUID) (bool, error)
+		own    uuid.UUID
+		theirs uuid.UUID
+	}{
+		{"PatientExists", repo.PatientExists, seeded.Patients[0].ID, other.Patients[0].ID},
+		{"PractitionerExists", repo.PractitionerExists, seeded.Practitioners[0].ProfileID, other.Practitioners[0].ProfileID},
+	} {
+		if ok, err := check.exists(ctx, pool, seeded.Clinic.ID, check.own); err != nil || !ok {
+			t.Errorf("%s for the clinic's own profile = %t, %v; want true", check.name, ok, err)
+		}
+		if ok, err := check.exists(ctx, pool, seeded.Clinic.ID, check.theirs); err != nil || ok {
+			t.Errorf("%s for another clinic's profile = %t, %v; want false", check.name, ok, err)
+		}
+	}
+}
+
+// TestConcurrentOverlappingBookingsConflictOnce books the same practitioner twice, for
+// overlapping times, from two transactions at once. Neither sees the other's row, so the
+// exclusion constraint is all that stops the double booking.
+func TestConcurrentOverlappingBookingsConflictOnce(t *testing.T) {
+	pool := dbtest.New(t)
+	repo := store.NewPgxRepository()
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithPractitioners(1).WithPatients(2))
+	ctx := database.WithClinic(context.Background(), seeded.Clinic.ID)
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	bookings := []*model.Appointment{
+		{StartTime: start, EndTime: start.Add(30 * time.Minute)},
+		{StartTime: start.Add(15 * time.Minute), EndTime: start.Add(45 * time.Minute)},
+	}
+	errs := make([]error, len(bookings))
+	ready := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, a := range bookings {
+		a.ID = uuid.Must(uuid.NewV7())
+		a.ClinicID = seeded.Clinic.ID
+		a.PatientID = seeded.Patients[i].ID
+		a.PractitionerID = seeded.Practitioners[0].ProfileID
+		a.Status = model.StatusScheduled
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := pool.Begin(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer tx.Rollback(ctx)
+			<-ready
+			if errs[i] = repo.Create(ctx, tx, a); errs[i] == nil {
+				errs[i] = tx.Commit(ctx)
+			}
+		}()
+	}
+	close(ready)
+	wg.Wait()
+
+	var booked, conflicts int
+	for i, err := range errs {
+		var apiErr *apierror.APIError
+		switch {
+		case err == nil:
+			booked++
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict && apiErr.Code == apierror.CodeSlotTaken:
+			conflicts++
+		default:
+			t.Errorf("booking %d: %v, want success or a 409", i, err)
+		}
+	}
+	if booked != 1 || conflicts != 1 {
+		t.Errorf("%d booked and %d conflicted, want exactly one of each", booked, conflicts)
+	}
+
+	var stored int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM appointments WHERE doctor_id = $1`, seeded.Practitioners[0].ProfileID).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != 1 {
+		t.Errorf("%d appointments stored for the practitioner, want 1", stored)
+	}
+}
@@ -0,0 +1,24 @@
+// Package dto contains the Data Transfer Objects for the audit module's API contract.
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// EntryResponse is one audit log entry. For an update, Old and New hold only the changed fields.
+type EntryResponse struct {
+	ID                 uuid.UUID       `json:"id"`
+	Action             string          `json:"action"`
+	Entity             model.Entity    `json:"entity"`
+	EntityID           *uuid.UUID      `json:"entity_id"`
+	UserID             *uuid.UUID      `json:"user_id"`
+	UserName           *string         `json:"user_name"`
+	ImpersonatedUserID *uuid.UUID      `json:"impersonated_user_id,omitempty"`
+	Old                json.RawMessage `json:"old"`
+	New                json.RawMessage `json:"new"`
+	OccurredAt         apitime.Time    `json:"occurred_at"`
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler holds the dependencies for the audit HTTP handlers.
+type Handler struct {
+	service audit.Service
+}
+
+// NewHandler creates a new audit handler with the given service.
+func NewHandler(service audit.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListEntries returns a page of the clinic's audit log, newest first. Optional query parameters:
+// `entity` and `entity_id` select one record's history, `from` (inclusive) and `to` (exclusive)
+// are RFC 3339 times, and `page` and `pageSize` paginate.
+func (h *Handler) ListEntries(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	filter := model.Filter{Entity: model.Entity(c.Query("entity"))}
+	if raw := c.Query("entity_id"); raw != "" {
+		entityID, err := uuid.Parse(raw)
+		if err != nil {
+			return apierror.NewBadRequest("Invalid entity_id format.", err)
+		}
+		filter.EntityID = &entityID
+	}
+	if filter.From, err = timeQuery(c, "from"); err != nil {
+		return apierror.NewBadRequest("The 'from' query parameter must be an RFC 3339 time, such as 2025-01-31T09:00:00Z.", err)
+	}
+	if filter.To, err = timeQuery(c, "to"); err != nil {
+		return apierror.NewBadRequest("The 'to' query parameter must be an RFC 3339 time, such as 2025-01-31T17:00:00Z.", err)
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "50"))
+
+	entries, err := h.service.ListEntries(c.Request.Context(), payload.ClinicID, filter, page, pageSize)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	response := make([]dto.EntryResponse, len(entries))
+	for i, e := range entries {
+		response[i] = dto.EntryResponse{
+			ID:                 e.ID,
+			Action:             e.Action,
+			Entity:             e.Entity,
+			EntityID:           e.EntityID,
+			UserID:             e.UserID,
+			UserName:           e.UserName,
+			ImpersonatedUserID: e.ImpersonatedUserID,
+			Old:                e.Old,
+			New:                e.New,
+			OccurredAt:         apitime.New(e.OccurredAt),
+		}
+	}
+	c.JSON(http.StatusOK, response)
+	return nil
+}
+
+func timeQuery(c *gin.Context, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the audit module in the router's startup log.
+func (h *Handler) Name() string {
+	return "audit"
+}
+
+// RegisterPublicRoutes registers nothing: the audit module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the audit module.
+// All these routes are protected and require an authenticated staff member.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	// GET /api/v1/audit-logs?entity=profile&entity_id=&from=&to=&page=&pageSize= - The clinic's audit log, newest first
+	router.GET("/audit-logs", middleware.RequirePermission(model.PermissionAuditRead), middleware.AllowQuery("entity", "entity_id", "from", "to", "page", "pageSize"), middleware.ErrorHandler(h.ListEntries))
+}
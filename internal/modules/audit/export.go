@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	"github.com/google/uuid"
+)
+
+// exportSection names the audit log's part of a patient export.
+const exportSection = "audit_log"
+
+// entryExport is an audit entry as it appears in a patient export.
+type entryExport struct {
+	ID                 uuid.UUID       `json:"id"`
+	Action             string          `json:"action"`
+	Entity             model.Entity    `json:"entity"`
+	EntityID           *uuid.UUID      `json:"entity_id"`
+	UserID             *uuid.UUID      `json:"user_id"`
+	UserName           *string         `json:"user_name"`
+	ImpersonatedUserID *uuid.UUID      `json:"impersonated_user_id,omitempty"`
+	Old                json.RawMessage `json:"old,omitempty"`
+	New                json.RawMessage `json:"new,omitempty"`
+	OccurredAt         time.Time       `json:"occurred_at"`
+}
+
+// exportEntries is the audit log export section.
+func (s *defaultService) exportEntries(ctx context.Context, clinicID, profileID uuid.UUID) (any, error) {
+	entries, err := s.repo.ListForPatient(ctx, clinicID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the patient's audit entries: %w", err)
+	}
+	exported := make([]entryExport, len(entries))
+	for i, e := range entries {
+		exported[i] = entryExport{
+			ID:                 e.ID,
+			Action:             e.Action,
+			Entity:             e.Entity,
+			EntityID:           e.EntityID,
+			UserID:             e.UserID,
+			UserName:           e.UserName,
+			ImpersonatedUserID: e.ImpersonatedUserID,
+			Old:                e.Old,
+			New:                e.New,
+			OccurredAt:         e.OccurredAt,
+		}
+	}
+	return exported, nil
+}
@@ -0,0 +1,25 @@
+// Package audit exposes the clinic's audit log: who changed which patient, employee, role or
+// appointment, when, and what the changed fields were before and after.
+package audit
+
+import (
+	"context"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for reading the audit log.
+type Service interface {
+	// ListEntries returns a page of the clinic's audit log matching filter, newest first.
+	ListEntries(ctx context.Context, clinicID uuid.UUID, filter model.Filter, page, pageSize int) ([]model.Entry, error)
+}
+
+// Repository defines the data access contract for the audit log.
+type Repository interface {
+	// ListEntries returns the clinic's entries logged under table (any table when empty) that
+	// match the rest of filter, newest first, skipping offset and returning at most limit.
+	ListEntries(ctx context.Context, clinicID uuid.UUID, table string, filter model.Filter, limit, offset int) ([]model.Entry, error)
+	// ListForPatient returns every entry about the patient's profile or their appointments, oldest first.
+	ListForPatient(ctx context.Context, clinicID, profileID uuid.UUID) ([]model.Entry, error)
+}
@@ -0,0 +1,77 @@
+// Package model contains the audit log models.
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PermissionAuditRead allows reading the clinic's audit log.
+const PermissionAuditRead = "audit.read"
+
+// Entity names a kind of audited record in the API.
+type Entity string
+
+const (
+	EntityProfile     Entity = "profile"
+	EntityEmployee    Entity = "employee"
+	EntityRole        Entity = "role"
+	EntityAppointment Entity = "appointment"
+	EntityService     Entity = "service"
+)
+
+// entityTables maps each entity to the table its changes are logged under.
+var entityTables = map[Entity]string{
+	EntityProfile:     "profiles",
+	EntityEmployee:    "employees",
+	EntityRole:        "roles",
+	EntityAppointment: "appointments",
+	EntityService:     "services",
+}
+
+// Entities lists the entities the audit log can be filtered by.
+var Entities = []Entity{EntityProfile, EntityEmployee, EntityRole, EntityAppointment, EntityService}
+
+// Table returns the table the entity's changes are logged under, and false for an unknown entity.
+func (e Entity) Table() (string, bool) {
+	table, ok := entityTables[e]
+	return table, ok
+}
+
+// EntityOf returns the entity logged under table, or the table name itself when no entity maps to it.
+func EntityOf(table string) Entity {
+	for entity, t := range entityTables {
+		if t == table {
+			return entity
+		}
+	}
+	return Entity(table)
+}
+
+// Entry is one audit log record. Row changes are written by the log_change trigger: an update
+// holds only the changed fields in Old and New, an insert only New and a delete only Old.
+// Modules also write entries of their own, such as PROFILE_REGISTERED, with details in New.
+type Entry struct {
+	ID uuid.UUID
+	// UserID is the employee who made the change, or the platform operator when impersonating;
+	// nil for changes the system made on its own.
+	UserID             *uuid.UUID
+	UserName           *string
+	ImpersonatedUserID *uuid.UUID
+	Action             string
+	Entity             Entity
+	EntityID           *uuid.UUID
+	Old                json.RawMessage
+	New                json.RawMessage
+	OccurredAt         time.Time
+}
+
+// Filter narrows the audit log. Zero fields do not filter; From is inclusive and To exclusive.
+type Filter struct {
+	Entity   Entity
+	EntityID *uuid.UUID
+	From     *time.Time
+	To       *time.Time
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+)
+
+// Page size limits of the audit log.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// defaultService is the concrete implementation of the audit.Service interface.
+type defaultService struct {
+	repo Repository
+}
+
+// NewService creates a new instance of the audit service and registers the audit log export section.
+func NewService(repo Repository, exports *export.Registry) Service {
+	s := &defaultService{repo: repo}
+	exports.Register(exportSection, s.exportEntries)
+	return s
+}
+
+// ListEntries checks the filter and returns the matching page of the clinic's audit log.
+func (s *defaultService) ListEntries(ctx context.Context, clinicID uuid.UUID, filter model.Filter, page, pageSize int) ([]model.Entry, error) {
+	var table string
+	if filter.Entity != "" {
+		t, ok := filter.Entity.Table()
+		if !ok {
+			return nil, apierror.NewBadRequest(fmt.Sprintf("Unknown entity '%s'.", filter.Entity), nil)
+		}
+		table = t
+	}
+	if filter.EntityID != nil && table == "" {
+		return nil, apierror.NewBadRequest("Filtering by entity_id requires an entity.", nil)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, apierror.NewBadRequest("The 'from' time must be before the 'to' time.", nil)
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+	page = max(page, 1)
+
+	entries, err := s.repo.ListEntries(ctx, clinicID, table, filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list audit entries: %w", err))
+	}
+	return entries, nil
+}
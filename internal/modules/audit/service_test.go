@@ -0,0 +1,77 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	auditStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
+	patientStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	settingsStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/fixtures"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+)
+
+func TestProfileUpdateIsAuditedOnceWithTheChangedFields(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	seeded, err := fixtures.Clinic().WithPatients(1).Apply(ctx, pool)
+	if err != nil {
+		t.Fatalf("seed clinic: %v", err)
+	}
+	clinicID, profile := seeded.Clinic.ID, seeded.Patients[0]
+
+	txManager := database.NewTxManager(pool)
+	settingsSvc := settings.NewService(txManager, settingsStore.NewPgxRepository(), pool)
+	patients := patient.NewService(txManager, patientStore.NewPgxProfileRepository(pool), pool, pool, settingsSvc, events.NewBus(), export.NewRegistry())
+	auditSvc := audit.NewService(auditStore.NewPgxRepository(pool), export.NewRegistry())
+
+	// The name is sent unchanged; only the email differs from the stored profile.
+	_, err = patients.UpdateProfile(ctx, clinicID, patient.UpdateProfileRequest{
+		ClinicID:  clinicID,
+		ProfileID: profile.ID,
+		FullName:  optional.Some(profile.FullName),
+		Email:     optional.Some("changed@example.com"),
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+
+	entries, err := auditSvc.ListEntries(ctx, clinicID, model.Filter{Entity: model.EntityProfile, EntityID: &profile.ID}, 1, 50)
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	var updates []model.Entry
+	for _, e := range entries {
+		if e.Action == "UPDATE" {
+			updates = append(updates, e)
+		}
+	}
+	if len(updates) != 1 {
+		t.Fatalf("audit log has %d updates of the profile, want 1", len(updates))
+	}
+
+	diff := func(raw json.RawMessage) map[string]any {
+		t.Helper()
+		var fields map[string]any
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			t.Fatalf("failed to decode %s: %v", raw, err)
+		}
+		return fields
+	}
+	old, updated := diff(updates[0].Old), diff(updates[0].New)
+	if len(old) != 1 || old["email"] != *profile.Email {
+		t.Errorf("old values = %v, want only the previous email %s", old, *profile.Email)
+	}
+	if len(updated) != 1 || updated["email"] != "changed@example.com" {
+		t.Errorf("new values = %v, want only the new email", updated)
+	}
+}
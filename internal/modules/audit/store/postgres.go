@@ -0,0 +1,95 @@
+// Package store provides the database implementation for the audit repository.
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxRepository is the PostgreSQL implementation of the audit.Repository, over the audit_log table.
+type pgxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPgxRepository creates a new instance of the audit repository.
+func NewPgxRepository(db *pgxpool.Pool) *pgxRepository {
+	return &pgxRepository{db: db}
+}
+
+// ListEntries returns a page of the clinic's audit entries, newest first, with the name of the
+// employee who made each change.
+func (r *pgxRepository) ListEntries(ctx context.Context, clinicID uuid.UUID, table string, filter model.Filter, limit, offset int) ([]model.Entry, error) {
+	query := `
+        SELECT l.id, l.user_id, u.full_name, l.impersonated_user_id, l.action, l.table_name, l.record_id,
+               l.old_record, l.new_record, l.timestamp
+        FROM audit_log l
+        LEFT JOIN profiles u ON u.id = l.user_id
+        WHERE l.clinic_id = $1
+          AND ($2 = '' OR l.table_name = $2)
+          AND ($3::uuid IS NULL OR l.record_id = $3)
+          AND ($4::timestamptz IS NULL OR l.timestamp >= $4)
+          AND ($5::timestamptz IS NULL OR l.timestamp < $5)
+        ORDER BY l.timestamp DESC, l.id DESC
+        LIMIT $6 OFFSET $7`
+	rows, err := r.db.Query(ctx, query, clinicID, table, filter.EntityID, filter.From, filter.To, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListEntries: failed to query audit log: %w", err)
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListEntries: %w", err)
+	}
+	return entries, nil
+}
+
+// ListForPatient returns the entries about the patient's profile and about any appointment of
+// theirs, including appointments since deleted, oldest first.
+func (r *pgxRepository) ListForPatient(ctx context.Context, clinicID, profileID uuid.UUID) ([]model.Entry, error) {
+	query := `
+        SELECT l.id, l.user_id, u.full_name, l.impersonated_user_id, l.action, l.table_name, l.record_id,
+               l.old_record, l.new_record, l.timestamp
+        FROM audit_log l
+        LEFT JOIN profiles u ON u.id = l.user_id
+        WHERE l.clinic_id = $1
+          AND ((l.table_name = 'profiles' AND l.record_id = $2)
+            OR (l.table_name = 'appointments' AND l.record_id IN (
+                SELECT id FROM appointments WHERE clinic_id = $1 AND patient_id = $2)))
+        ORDER BY l.timestamp, l.id`
+	rows, err := r.db.Query(ctx, query, clinicID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListForPatient: failed to query audit log: %w", err)
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListForPatient: %w", err)
+	}
+	return entries, nil
+}
+
+// scanEntries reads the rows of an audit log query and closes them.
+func scanEntries(rows pgx.Rows) ([]model.Entry, error) {
+	defer rows.Close()
+
+	entries := []model.Entry{}
+	for rows.Next() {
+		var (
+			e         model.Entry
+			tableName string
+		)
+		if err := rows.Scan(&e.ID, &e.UserID, &e.UserName, &e.ImpersonatedUserID, &e.Action, &tableName, &e.EntityID,
+			&e.Old, &e.New, &e.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		e.Entity = model.EntityOf(tableName)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return entries, nil
+}
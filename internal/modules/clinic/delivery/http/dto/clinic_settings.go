@@ -0,0 +1,40 @@
+package dto
+
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+
+// ClinicSettings is the response of GET and PATCH /clinic/settings.
+type ClinicSettings struct {
+	// Timezone is an IANA time zone name, e.g. "Africa/Cairo".
+	Timezone string `json:"timezone"`
+	// Locale is an ISO 639-1 code, e.g. "ar" or "en".
+	Locale             string       `json:"locale"`
+	DefaultPhoneRegion string       `json:"default_phone_region"`
+	BookingRules       BookingRules `json:"booking_rules"`
+}
+
+// BookingRules controls how and when appointments may be booked.
+type BookingRules struct {
+	GuestBookingEnabled     bool `json:"guest_booking_enabled"`
+	MinNoticeMinutes        int  `json:"min_notice_minutes"`
+	MaxAdvanceDays          int  `json:"max_advance_days"`
+	CancellationCutoffHours int  `json:"cancellation_cutoff_hours"`
+}
+
+// UpdateClinicSettingsRequest is a partial update of the clinic's settings. Omitted fields,
+// including any booking rule, are left unchanged; every field is required, so none may be null.
+type UpdateClinicSettingsRequest struct {
+	Timezone optional.Optional[string] `json:"timezone"`
+	// Locale is an ISO 639-1 code, e.g. "ar" or "en".
+	Locale             optional.Optional[string] `json:"locale"`
+	DefaultPhoneRegion optional.Optional[string] `json:"default_phone_region" zog:"default_phone_region"`
+	// BookingRules is left unchanged when omitted or null.
+	BookingRules *UpdateBookingRulesRequest `json:"booking_rules" zog:"booking_rules"`
+}
+
+// UpdateBookingRulesRequest is a partial update of the booking rules.
+type UpdateBookingRulesRequest struct {
+	GuestBookingEnabled     optional.Optional[bool] `json:"guest_booking_enabled" zog:"guest_booking_enabled"`
+	MinNoticeMinutes        optional.Optional[int]  `json:"min_notice_minutes" zog:"min_notice_minutes"`
+	MaxAdvanceDays          optional.Optional[int]  `json:"max_advance_days" zog:"max_advance_days"`
+	CancellationCutoffHours optional.Optional[int]  `json:"cancellation_cutoff_hours" zog:"cancellation_cutoff_hours"`
+}
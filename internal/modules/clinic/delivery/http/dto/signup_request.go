@@ -0,0 +1,23 @@
+package dto
+
+// SignupRequest creates a clinic and its owner account.
+type SignupRequest struct {
+	ClinicName string `json:"clinic_name"`
+	// Slug is the clinic's subdomain; derived from ClinicName when omitted.
+	Slug string `json:"slug"`
+	// CountryCode is an ISO 3166-1 alpha-2 code, e.g. "EG".
+	CountryCode  string `json:"country_code"`
+	Timezone     string `json:"timezone"`
+	AddressLine1 string `json:"address_line1"`
+	City         string `json:"city"`
+	PostalCode   string `json:"postal_code"`
+
+	OwnerFullName string `json:"owner_full_name"`
+	Email         string `json:"email"`
+	PhoneNumber   string `json:"phone_number"`
+	Password      string `json:"password"`
+
+	// Sandbox creates a demo clinic filled with fake data, which can be reset at any time and is
+	// never billed.
+	Sandbox bool `json:"sandbox"`
+}
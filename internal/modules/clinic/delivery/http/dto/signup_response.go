@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// ClinicResponse is the public representation of a clinic.
+type ClinicResponse struct {
+	ID                 uuid.UUID    `json:"id"`
+	Name               string       `json:"name"`
+	Slug               string       `json:"slug"`
+	CountryCode        string       `json:"country_code"`
+	Timezone           string       `json:"timezone"`
+	DefaultPhoneRegion string       `json:"default_phone_region"`
+	Status             string       `json:"status"`
+	IsSandbox          bool         `json:"is_sandbox"`
+	CreatedAt          apitime.Time `json:"created_at"`
+}
+
+// SignupResponse returns the new clinic and an access token for its owner.
+type SignupResponse struct {
+	Clinic               ClinicResponse `json:"clinic"`
+	OwnerID              uuid.UUID      `json:"owner_id"`
+	AccessToken          string         `json:"access_token"`
+	AccessTokenExpiresAt apitime.Time   `json:"access_token_expires_at"`
+}
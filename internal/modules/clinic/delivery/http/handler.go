@@ -0,0 +1,168 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/httpjson"
+	z "github.com/Oudwins/zog"
+	"github.com/Oudwins/zog/zhttp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service clinic.Service
+}
+
+func NewHandler(service clinic.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ResetClinic wipes and reseeds the caller's clinic, which must be a sandbox.
+func (h *Handler) ResetClinic(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	return h.resetClinic(c, clinic.ResetSandboxRequest{ClinicID: payload.ClinicID})
+}
+
+// ForceResetClinic wipes and reseeds any clinic for platform staff. A non-sandbox clinic is
+// only reset with ?force=true.
+func (h *Handler) ForceResetClinic(c *gin.Context) *apierror.APIError {
+	operator, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	// The route requires the permission; it must also be the operator's own, since an
+	// impersonation session only identifies who is acting.
+	if operator.IsImpersonated() || !slices.Contains(operator.Permissions, model.PermissionPlatformClinicReset) {
+		return apierror.NewForbidden("Only platform administrators can force a clinic reset.", nil)
+	}
+
+	clinicID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid clinic ID format.", err)
+	}
+	force := false
+	if raw := c.Query("force"); raw != "" {
+		if force, err = strconv.ParseBool(raw); err != nil {
+			return apierror.NewBadRequest("Invalid 'force' parameter.", err)
+		}
+	}
+
+	return h.resetClinic(c, clinic.ResetSandboxRequest{
+		ClinicID:           clinicID,
+		Force:              force,
+		ByPlatformOperator: true,
+	})
+}
+
+func (h *Handler) resetClinic(c *gin.Context, req clinic.ResetSandboxRequest) *apierror.APIError {
+	if err := h.service.ResetSandbox(c.Request.Context(), req); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// Signup creates a clinic with its owner account and logs the owner in.
+func (h *Handler) Signup(c *gin.Context) *apierror.APIError {
+	var req dto.SignupRequest
+	if issues := signupSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := clinic.SignupRequest{
+		ClinicName:    req.ClinicName,
+		Slug:          req.Slug,
+		CountryCode:   req.CountryCode,
+		Timezone:      req.Timezone,
+		AddressLine1:  req.AddressLine1,
+		City:          req.City,
+		PostalCode:    req.PostalCode,
+		OwnerFullName: req.OwnerFullName,
+		Email:         req.Email,
+		PhoneNumber:   req.PhoneNumber,
+		Password:      req.Password,
+		Sandbox:       req.Sandbox,
+	}
+
+	result, err := h.service.Signup(c.Request.Context(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusCreated, toSignupResponse(result))
+	return nil
+}
+
+// GetSettings returns the caller's clinic settings.
+func (h *Handler) GetSettings(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	clinicSettings, err := h.service.GetSettings(c.Request.Context(), payload.ClinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toClinicSettingsResponse(clinicSettings))
+	return nil
+}
+
+// UpdateSettings applies a partial update to the caller's clinic settings.
+func (h *Handler) UpdateSettings(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	// Decoded with encoding/json rather than zog so that omitted and null fields stay distinguishable.
+	req, apiErr := httpjson.DecodeJSONGin[dto.UpdateClinicSettingsRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := updateClinicSettingsSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	clinicSettings, err := h.service.UpdateSettings(c.Request.Context(), clinic.UpdateSettingsRequest{
+		ClinicID:  payload.ClinicID,
+		UpdatedBy: payload.ActorID(),
+		Patch:     toClinicSettingsPatch(req),
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toClinicSettingsResponse(clinicSettings))
+	return nil
+}
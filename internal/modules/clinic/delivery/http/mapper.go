@@ -0,0 +1,60 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+)
+
+func toSignupResponse(result *clinic.SignupResult) dto.SignupResponse {
+	c := result.Clinic
+	return dto.SignupResponse{
+		Clinic: dto.ClinicResponse{
+			ID:                 c.ID,
+			Name:               c.Name,
+			Slug:               c.Slug,
+			CountryCode:        c.CountryCode,
+			Timezone:           c.Timezone,
+			DefaultPhoneRegion: result.DefaultPhoneRegion,
+			Status:             c.SubscriptionStatus,
+			IsSandbox:          c.IsSandbox,
+			CreatedAt:          apitime.New(c.CreatedAt),
+		},
+		OwnerID:              result.OwnerID,
+		AccessToken:          result.AccessToken,
+		AccessTokenExpiresAt: apitime.New(result.AccessTokenExpires),
+	}
+}
+
+func toClinicSettingsResponse(s *model.ClinicSettings) dto.ClinicSettings {
+	return dto.ClinicSettings{
+		Timezone:           s.Timezone,
+		Locale:             s.Locale,
+		DefaultPhoneRegion: s.DefaultPhoneRegion,
+		BookingRules: dto.BookingRules{
+			GuestBookingEnabled:     s.BookingRules.GuestBookingEnabled,
+			MinNoticeMinutes:        s.BookingRules.MinNoticeMinutes,
+			MaxAdvanceDays:          s.BookingRules.MaxAdvanceDays,
+			CancellationCutoffHours: s.BookingRules.CancellationCutoffHours,
+		},
+	}
+}
+
+func toClinicSettingsPatch(req dto.UpdateClinicSettingsRequest) model.ClinicSettingsPatch {
+	patch := model.ClinicSettingsPatch{
+		Timezone:           optional.BlankAsNull(req.Timezone),
+		Locale:             optional.BlankAsNull(req.Locale),
+		DefaultPhoneRegion: optional.BlankAsNull(req.DefaultPhoneRegion),
+	}
+	if rules := req.BookingRules; rules != nil {
+		patch.BookingRules = model.BookingRulesPatch{
+			GuestBookingEnabled:     rules.GuestBookingEnabled,
+			MinNoticeMinutes:        rules.MinNoticeMinutes,
+			MaxAdvanceDays:          rules.MaxAdvanceDays,
+			CancellationCutoffHours: rules.CancellationCutoffHours,
+		}
+	}
+	return patch
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the clinic module in the router's startup log.
+func (h *Handler) Name() string {
+	return "clinic"
+}
+
+// RegisterPublicRoutes registers nothing: the clinic module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterSignupRoutes sets up the unauthenticated signup route. The group must not resolve a
+// clinic from the request, since the clinic does not exist yet.
+func (h *Handler) RegisterSignupRoutes(router *gin.RouterGroup) {
+	// POST /public/clinics/signup - Create a clinic and its owner account
+	router.POST("/clinics/signup", middleware.ErrorHandler(h.Signup))
+}
+
+// RegisterProtectedRoutes sets up the authenticated routes that act on the caller's own clinic.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	clinicGroup := router.Group("/clinic")
+	{
+		// GET /api/v1/clinic/settings - Read the clinic's timezone, locale, phone region and booking rules
+		clinicGroup.GET("/settings", middleware.ErrorHandler(h.GetSettings))
+		// PATCH /api/v1/clinic/settings - Change the settings sent; omitted fields keep their value
+		clinicGroup.PATCH("/settings", middleware.RequirePermission(model.PermissionClinicManage), middleware.ErrorHandler(h.UpdateSettings))
+		// PUT /api/v1/clinic/settings - Same as PATCH, kept for existing clients sending every field
+		clinicGroup.PUT("/settings", middleware.RequirePermission(model.PermissionClinicManage), middleware.ErrorHandler(h.UpdateSettings))
+		// POST /api/v1/clinic/reset - Wipe and reseed a sandbox clinic (owner only)
+		clinicGroup.POST("/reset", middleware.RequirePermission(model.PermissionClinicReset), middleware.ErrorHandler(h.ResetClinic))
+	}
+}
+
+// RegisterInternalRoutes sets up the platform-operator routes for the clinic module.
+func (h *Handler) RegisterInternalRoutes(router *gin.RouterGroup) {
+	// POST /internal/v1/clinics/:id/reset?force=true - Wipe and reseed any clinic, live ones only with force.
+	router.POST("/clinics/:id/reset", middleware.RequirePermission(model.PermissionPlatformClinicReset), middleware.AllowQuery("force"), middleware.ErrorHandler(h.ForceResetClinic))
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/phone"
+	z "github.com/Oudwins/zog"
+)
+
+var countryCodeRegex = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// Schema for signing up a new clinic. The slug is normalized by the service, and the phone
+// number may be in the national form of the clinic's country.
+var signupSchema = z.Struct(z.Shape{
+	"clinicName":    z.String().Trim().Min(2, z.Message("Clinic name must be at least 2 characters.")).Max(255).Required(z.Message("A clinic name is required.")),
+	"slug":          z.String().Trim().Max(63, z.Message("Slug must be at most 63 characters.")).Optional(),
+	"countryCode":   z.String().Trim().Match(countryCodeRegex, z.Message("A two-letter ISO country code is required.")).Required(z.Message("A two-letter ISO country code is required.")),
+	"timezone":      z.String().Trim().Required(z.Message("A timezone is required.")),
+	"addressLine1":  z.String().Trim().Required(z.Message("An address is required.")),
+	"city":          z.String().Trim().Required(z.Message("A city is required.")).Max(100),
+	"postalCode":    z.String().Trim().Required(z.Message("A postal code is required.")).Max(20),
+	"ownerFullName": z.String().Trim().Min(4, z.Message("Full name must be at least 4 characters.")).Required(z.Message("The owner's full name is required.")),
+	"email":         z.String().Trim().Email(z.Message("A valid email address is required.")).Required(z.Message("A valid email address is required.")),
+	"phoneNumber":   z.String().Trim().Required(z.Message("A phone number is required.")),
+	"password":      z.String().Required(z.Message("Password is required.")),
+	"sandbox":       z.Bool().Optional(),
+})
+
+// Schema for a partial update of the clinic's settings. Omitted fields pass; none may be null.
+var updateClinicSettingsSchema = z.Struct(z.Shape{
+	"timezone": optional.String(z.String().TestFunc(
+		func(val *string, ctx z.Ctx) bool {
+			_, err := time.LoadLocation(*val)
+			return err == nil
+		},
+	), false, "Timezone must be a valid IANA time zone, e.g. Africa/Cairo."),
+	"locale":             optional.String(z.String().OneOf(locale.Codes()), false, "Locale must be one of: "+strings.Join(locale.Codes(), ", ")+"."),
+	"defaultPhoneRegion": optional.String(z.String().OneOf(phone.RegionCodes()), false, "Default phone region must be one of: "+strings.Join(phone.RegionCodes(), ", ")+"."),
+	"bookingRules": z.Ptr(z.Struct(z.Shape{
+		"guestBookingEnabled":     optional.Bool(false, "guest_booking_enabled cannot be null."),
+		"minNoticeMinutes":        optional.Int(z.Int().GTE(0), false, "min_notice_minutes cannot be negative."),
+		"maxAdvanceDays":          optional.Int(z.Int().GTE(1).LTE(365).Required(), false, "max_advance_days must be between 1 and 365."),
+		"cancellationCutoffHours": optional.Int(z.Int().GTE(0), false, "cancellation_cutoff_hours cannot be negative."),
+	})),
+})
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/delivery/http/dto"
+	z "github.com/Oudwins/zog"
+)
+
+func TestUpdateClinicSettingsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		// invalid lists the fields reported, in sorted order.
+		invalid []string
+	}{
+		{"empty patch", `{}`, nil},
+		{"valid patch", `{"timezone": "Asia/Riyadh", "locale": "en", "default_phone_region": "SA", "booking_rules": {"max_advance_days": 90}}`, nil},
+		{"null booking rules", `{"booking_rules": null}`, nil},
+		{"unknown timezone", `{"timezone": "Mars/Olympus_Mons"}`, []string{"timezone"}},
+		{"null timezone", `{"timezone": null}`, []string{"timezone"}},
+		{"locale outside the allow-list", `{"locale": "fr"}`, []string{"locale"}},
+		{"unsupported phone region", `{"default_phone_region": "ZZ"}`, []string{"default_phone_region"}},
+		{"null booking rule", `{"booking_rules": {"guest_booking_enabled": null}}`, []string{"booking_rules.guest_booking_enabled"}},
+		{"out-of-range booking rules", `{"booking_rules": {"min_notice_minutes": -1, "max_advance_days": 0, "cancellation_cutoff_hours": -2}}`,
+			[]string{"booking_rules.cancellation_cutoff_hours", "booking_rules.max_advance_days", "booking_rules.min_notice_minutes"}},
+		{"max advance over a year", `{"booking_rules": {"max_advance_days": 366}}`, []string{"booking_rules.max_advance_days"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req dto.UpdateClinicSettingsRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+			issues := z.Issues.Flatten(updateClinicSettingsSchema.Validate(&req))
+			if got := slices.Sorted(maps.Keys(issues)); !slices.Equal(got, tt.invalid) {
+				t.Errorf("invalid fields = %v, want %v (issues: %v)", got, tt.invalid, issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package clinic owns the tenant (clinic) records: signing new clinics up and resolving them
+// from public identifiers.
+package clinic
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Service defines the business logic contract for the clinic module.
+type Service interface {
+	// ResolveClinicID returns the ID of the active clinic with the given slug.
+	// It returns a 404 APIError when no such clinic exists.
+	ResolveClinicID(ctx context.Context, slug string) (uuid.UUID, error)
+	// ResetSandbox wipes the clinic's patients and appointments and reseeds the demo data.
+	// Non-sandbox clinics are refused unless a platform operator explicitly forces the reset.
+	ResetSandbox(ctx context.Context, req ResetSandboxRequest) error
+	// SeedSandbox fills a freshly created sandbox clinic with demo data.
+	SeedSandbox(ctx context.Context, clinicID uuid.UUID) error
+	// Signup creates a clinic together with its owner and returns an access token for the owner.
+	// A sandbox clinic is seeded with the demo data. It fails with 409 if the slug or email is taken.
+	Signup(ctx context.Context, req SignupRequest) (*SignupResult, error)
+	// GetSettings returns the clinic's settings with defaults applied. Results are cached briefly.
+	GetSettings(ctx context.Context, clinicID uuid.UUID) (*model.ClinicSettings, error)
+	// UpdateSettings changes the settings fields the patch sets in one transaction, drops the
+	// cached copy and returns the resulting settings.
+	UpdateSettings(ctx context.Context, req UpdateSettingsRequest) (*model.ClinicSettings, error)
+}
+
+// UpdateSettingsRequest describes who changes which of a clinic's settings.
+type UpdateSettingsRequest struct {
+	ClinicID  uuid.UUID
+	UpdatedBy uuid.UUID
+	Patch     model.ClinicSettingsPatch
+}
+
+// SignupRequest holds the details of a new clinic and its owner. The owner's email and phone
+// number double as the clinic's contact details.
+type SignupRequest struct {
+	ClinicName string
+	// Slug is optional and derived from ClinicName when empty.
+	Slug         string
+	CountryCode  string
+	Timezone     string
+	AddressLine1 string
+	City         string
+	PostalCode   string
+
+	OwnerFullName string
+	Email         string
+	PhoneNumber   string
+	Password      string
+
+	// Sandbox creates a demo clinic, filled with fake data and left out of metrics and billing.
+	Sandbox bool
+}
+
+// SignupResult is the new clinic with an access token for its owner.
+type SignupResult struct {
+	Clinic             *model.Clinic
+	DefaultPhoneRegion string
+	OwnerID            uuid.UUID
+	AccessToken        string
+	AccessTokenExpires time.Time
+}
+
+// ResetSandboxRequest describes who is resetting which clinic.
+type ResetSandboxRequest struct {
+	ClinicID uuid.UUID
+	// Force allows resetting a non-sandbox clinic; it is only honored for platform operators.
+	Force bool
+	// ByPlatformOperator is true when the caller holds model.PermissionPlatformClinicReset in
+	// their own right, not through an impersonation session.
+	ByPlatformOperator bool
+}
+
+// Repository defines the data access contract for clinics.
+type Repository interface {
+	FindBySlug(ctx context.Context, slug string) (*model.Clinic, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Clinic, error)
+	FindByIDForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*model.Clinic, error)
+	DeleteClinicData(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID) error
+	SeedDemoData(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID) error
+	UpdateTimezone(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, timezone string) error
+	// CountClinics counts the live clinics, telling billable ones from sandboxes.
+	CountClinics(ctx context.Context) (*model.ClinicCounts, error)
+
+	// Signup.
+	CreateClinic(ctx context.Context, tx pgx.Tx, c *model.Clinic, contact model.ContactDetails) error
+	CreateOwner(ctx context.Context, tx pgx.Tx, owner *model.Owner) error
+	FindSystemRole(ctx context.Context, tx pgx.Tx, name string) (*model.SystemRoleGrant, error)
+	AssignRole(ctx context.Context, tx pgx.Tx, profileID, roleID uuid.UUID) error
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clinic is a tenant of the platform.
+type Clinic struct {
+	ID   uuid.UUID `db:"id"`
+	Name string    `db:"name"`
+	Slug string    `db:"slug"`
+	// CountryCode is the ISO 3166-1 alpha-2 country the clinic operates in.
+	CountryCode        string    `db:"country_code"`
+	Timezone           string    `db:"timezone"`
+	SubscriptionStatus string    `db:"subscription_status"`
+	IsSandbox          bool      `db:"is_sandbox"`
+	CreatedAt          time.Time `db:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at"`
+}
+
+// ClinicCounts are the platform-wide clinic totals. Sandbox clinics are kept apart from the
+// billable ones so they never inflate metrics or invoices.
+type ClinicCounts struct {
+	Billable int64
+	Sandbox  int64
+}
+
+// ContactDetails are the clinic's required contact and address columns, captured at signup.
+type ContactDetails struct {
+	PhoneNumber  string
+	Email        string
+	AddressLine1 string
+	City         string
+	PostalCode   string
+}
+
+// Owner is the first employee of a new clinic. It is created active, with its password set.
+type Owner struct {
+	ProfileID    uuid.UUID
+	ClinicID     uuid.UUID
+	FullName     string
+	Email        string
+	PhoneNumber  string
+	PasswordHash string
+}
+
+// SystemRoleGrant is a global system role with the permission keys it grants.
+type SystemRoleGrant struct {
+	ID             uuid.UUID
+	PermissionKeys []string
+}
+
+// OwnerRoleName is the system role every new clinic's owner receives.
+const OwnerRoleName = "Owner"
+
+// PermissionClinicReset allows wiping a sandbox clinic's data. It is meant for the clinic owner role only.
+const PermissionClinicReset = "clinic.reset"
+
+// PermissionPlatformClinicReset allows platform staff to force a reset of any clinic, sandbox or
+// not, through the internal API.
+const PermissionPlatformClinicReset = "platform.clinic.reset"
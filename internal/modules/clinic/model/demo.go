@@ -0,0 +1,18 @@
+package model
+
+// DemoPatient is a fake patient seeded into sandbox clinics.
+type DemoPatient struct {
+	FullName    string
+	PhoneNumber string
+	Email       string
+}
+
+// DemoPatients is the fixed demo data set seeded into sandbox clinics on onboarding and on every reset.
+// Phone numbers use a reserved fictional range so they can never reach a real person.
+var DemoPatients = []DemoPatient{
+	{FullName: "Sara Ahmed", PhoneNumber: "+201000000001", Email: "sara.ahmed@example.com"},
+	{FullName: "Omar Hassan", PhoneNumber: "+201000000002", Email: "omar.hassan@example.com"},
+	{FullName: "Mona Khaled", PhoneNumber: "+201000000003"},
+	{FullName: "Youssef Adel", PhoneNumber: "+201000000004", Email: "youssef.adel@example.com"},
+	{FullName: "Laila Mahmoud", PhoneNumber: "+201000000005"},
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+)
+
+// ClinicSettings gathers the per-clinic behavior most requests depend on. Timezone is stored
+// on the clinic row; the rest lives in the settings module's localization and booking_rules sections.
+type ClinicSettings struct {
+	// Timezone is an IANA time zone name, e.g. "Africa/Cairo".
+	Timezone string
+	// Locale is the default language of patient-facing content, e.g. "ar".
+	Locale string
+	// DefaultPhoneRegion is the ISO 3166-1 country locally typed phone numbers are read in.
+	DefaultPhoneRegion string
+	BookingRules       settings.BookingRulesSettings
+}
+
+// ClinicSettingsPatch is a partial update of ClinicSettings. Omitted fields keep their stored
+// value; every field is required, so null is treated like omitted.
+type ClinicSettingsPatch struct {
+	Timezone           optional.Optional[string]
+	Locale             optional.Optional[string]
+	DefaultPhoneRegion optional.Optional[string]
+	BookingRules       BookingRulesPatch
+}
+
+// BookingRulesPatch is a partial update of the booking rules.
+type BookingRulesPatch struct {
+	GuestBookingEnabled     optional.Optional[bool]
+	MinNoticeMinutes        optional.Optional[int]
+	MaxAdvanceDays          optional.Optional[int]
+	CancellationCutoffHours optional.Optional[int]
+}
+
+// ApplyTimezone copies the patched timezone onto timezone and reports whether it changed.
+func (p ClinicSettingsPatch) ApplyTimezone(timezone *string) bool {
+	return apply(p.Timezone, timezone)
+}
+
+// ApplyLocalization copies the patched locale and phone region onto l and reports whether
+// either changed.
+func (p ClinicSettingsPatch) ApplyLocalization(l *settings.LocalizationSettings) bool {
+	language := apply(p.Locale, &l.DefaultLanguage)
+	region := apply(p.DefaultPhoneRegion, &l.DefaultPhoneRegion)
+	return language || region
+}
+
+// Apply copies the patched rules onto r and reports whether any of them changed.
+func (p BookingRulesPatch) Apply(r *settings.BookingRulesSettings) bool {
+	changed := apply(p.GuestBookingEnabled, &r.GuestBookingEnabled)
+	changed = apply(p.MinNoticeMinutes, &r.MinNoticeMinutes) || changed
+	changed = apply(p.MaxAdvanceDays, &r.MaxAdvanceDays) || changed
+	changed = apply(p.CancellationCutoffHours, &r.CancellationCutoffHours) || changed
+	return changed
+}
+
+// apply copies a present, non-null value onto dst and reports whether dst changed.
+func apply[T comparable](o optional.Optional[T], dst *T) bool {
+	v, _ := o.Get()
+	if v == nil || *v == *dst {
+		return false
+	}
+	*dst = *v
+	return true
+}
+
+// PermissionClinicManage allows changing the clinic's settings.
+const PermissionClinicManage = "clinic.manage"
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+)
+
+func TestBookingRulesPatchKeepsOmittedRules(t *testing.T) {
+	stored := settings.BookingRulesSettings{GuestBookingEnabled: true, MinNoticeMinutes: 60, MaxAdvanceDays: 30, CancellationCutoffHours: 24}
+
+	rules := stored
+	patch := BookingRulesPatch{MinNoticeMinutes: optional.Some(15), GuestBookingEnabled: optional.Null[bool]()}
+	if !patch.Apply(&rules) {
+		t.Error("Apply reported no change for a new min_notice_minutes")
+	}
+	want := stored
+	want.MinNoticeMinutes = 15
+	if rules != want {
+		t.Errorf("rules = %+v, want %+v", rules, want)
+	}
+
+	if (BookingRulesPatch{MaxAdvanceDays: optional.Some(30)}).Apply(&rules) {
+		t.Error("Apply reported a change for an unchanged value")
+	}
+}
+
+func TestClinicSettingsPatchKeepsOmittedLocalization(t *testing.T) {
+	localization := settings.LocalizationSettings{DefaultLanguage: "ar", DefaultPhoneRegion: "EG"}
+
+	if (ClinicSettingsPatch{}).ApplyLocalization(&localization) {
+		t.Error("an empty patch reported a change")
+	}
+	if !(ClinicSettingsPatch{DefaultPhoneRegion: optional.Some("SA")}).ApplyLocalization(&localization) {
+		t.Error("Apply reported no change for a new phone region")
+	}
+	if want := (settings.LocalizationSettings{DefaultLanguage: "ar", DefaultPhoneRegion: "SA"}); localization != want {
+		t.Errorf("localization = %+v, want %+v", localization, want)
+	}
+}
@@ -0,0 +1,387 @@
+package clinic
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	settingsModel "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/phone"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/ttlcache"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// slugCacheTTL bounds how long a renamed or deleted clinic keeps resolving on this instance.
+const slugCacheTTL = 5 * time.Minute
+
+// settingsCacheTTL bounds how stale cached settings can be on an instance that missed a change
+// notification; settings are read on nearly every request.
+const settingsCacheTTL = 30 * time.Second
+
+// maxSlugLength is the longest DNS label, which is what a slug is used as.
+const maxSlugLength = 63
+
+// countTimeout bounds the query behind the clinic counts published with the process metrics.
+const countTimeout = 2 * time.Second
+
+// clinicVars publishes the platform-wide clinic counts with the process metrics.
+var clinicVars = expvar.NewMap("clinics")
+
+// slugRegex mirrors the chk_clinics_slug_format constraint.
+var slugRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// defaultService is the concrete implementation of the clinic.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo      Repository
+	settings  settings.Service
+	sec       *security.PasetoManager
+	slugCache *ttlcache.Cache[string, uuid.UUID]
+	// settingsCache holds GetSettings results by clinic ID.
+	settingsCache *ttlcache.Cache[uuid.UUID, model.ClinicSettings]
+	// tokenDuration is the lifetime of the access token issued on signup.
+	tokenDuration time.Duration
+}
+
+// NewService creates a new instance of the clinic service.
+func NewService(txManager database.TxManager, repo Repository, settingsSvc settings.Service, sec *security.PasetoManager, tokenDuration time.Duration) Service {
+	s := &defaultService{
+		BaseService:   service.BaseService{Tx: txManager},
+		repo:          repo,
+		settings:      settingsSvc,
+		sec:           sec,
+		slugCache:     ttlcache.New[string, uuid.UUID](slugCacheTTL),
+		settingsCache: ttlcache.New[uuid.UUID, model.ClinicSettings](settingsCacheTTL),
+		tokenDuration: tokenDuration,
+	}
+	clinicVars.Set("counts", expvar.Func(s.publishCounts))
+	// Sections can change through other instances or the config import; drop the cached copy
+	// as soon as the change is committed.
+	settingsSvc.Subscribe(func(e settingsModel.ChangeEvent) {
+		if e.Section == settings.Localization.Name || e.Section == settings.BookingRules.Name {
+			s.settingsCache.Delete(e.ClinicID)
+		}
+	})
+	return s
+}
+
+// publishCounts reports the billable and sandbox clinic counts, or nil when they cannot be read.
+// Sandbox clinics are reported apart and never included in the billable count.
+func (s *defaultService) publishCounts() any {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+	counts, err := s.repo.CountClinics(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count clinics for metrics")
+		return nil
+	}
+	return map[string]int64{"billable": counts.Billable, "sandbox": counts.Sandbox}
+}
+
+// ResolveClinicID looks the slug up in the cache before falling back to the database.
+// Only hits are cached so a newly created clinic resolves immediately.
+func (s *defaultService) ResolveClinicID(ctx context.Context, slug string) (uuid.UUID, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" {
+		return uuid.Nil, apierror.NewBadRequest("A clinic slug is required.", nil)
+	}
+	if id, ok := s.slugCache.Get(slug); ok {
+		return id, nil
+	}
+
+	c, err := s.repo.FindBySlug(ctx, slug)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return uuid.Nil, apiErr
+		}
+		return uuid.Nil, apierror.NewInternalServer(fmt.Errorf("failed to resolve clinic: %w", err))
+	}
+
+	s.slugCache.Set(slug, c.ID)
+	return c.ID, nil
+}
+
+// ResetSandbox wipes and reseeds the clinic in one transaction, holding the clinic row lock
+// so concurrent resets serialize.
+func (s *defaultService) ResetSandbox(ctx context.Context, req ResetSandboxRequest) error {
+	// Platform operators reset clinics other than their own.
+	ctx = database.WithClinic(ctx, req.ClinicID)
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		c, err := s.repo.FindByIDForUpdate(ctx, tx, req.ClinicID)
+		if err != nil {
+			return err
+		}
+		if !c.IsSandbox {
+			if !req.Force || !req.ByPlatformOperator {
+				return apierror.NewForbidden("Only sandbox clinics can be reset.", nil)
+			}
+			log.Warn().Str("clinic_id", c.ID.String()).Msg("Forced data reset of a non-sandbox clinic")
+		}
+
+		if err := s.repo.DeleteClinicData(ctx, tx, c.ID); err != nil {
+			return err
+		}
+		return s.repo.SeedDemoData(ctx, tx, c.ID)
+	})
+}
+
+// SeedSandbox inserts the demo data set into a sandbox clinic.
+func (s *defaultService) SeedSandbox(ctx context.Context, clinicID uuid.UUID) error {
+	ctx = database.WithClinic(ctx, clinicID)
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.seedSandbox(ctx, tx, clinicID)
+	})
+}
+
+// seedSandbox inserts the demo data set within tx, refusing clinics that are not sandboxes.
+func (s *defaultService) seedSandbox(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID) error {
+	c, err := s.repo.FindByIDForUpdate(ctx, tx, clinicID)
+	if err != nil {
+		return err
+	}
+	if !c.IsSandbox {
+		return apierror.NewBadRequest("Demo data can only be seeded into sandbox clinics.", nil)
+	}
+	return s.repo.SeedDemoData(ctx, tx, c.ID)
+}
+
+// GetSettings assembles the clinic's settings from its row and settings sections.
+func (s *defaultService) GetSettings(ctx context.Context, clinicID uuid.UUID) (*model.ClinicSettings, error) {
+	if cached, ok := s.settingsCache.Get(clinicID); ok {
+		return &cached, nil
+	}
+
+	c, err := s.repo.FindByID(ctx, clinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to load clinic: %w", err))
+	}
+	localization, _, err := settings.Localization.Get(ctx, s.settings, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	bookingRules, _, err := settings.BookingRules.Get(ctx, s.settings, clinicID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := model.ClinicSettings{
+		Timezone:           c.Timezone,
+		Locale:             localization.DefaultLanguage,
+		DefaultPhoneRegion: localization.DefaultPhoneRegion,
+		BookingRules:       bookingRules,
+	}
+	s.settingsCache.Set(clinicID, result)
+	return &result, nil
+}
+
+// UpdateSettings applies the fields the patch sets while holding the clinic row lock, so
+// concurrent updates apply one after the other instead of failing on stale versions. Sections
+// the patch leaves unchanged are not written.
+func (s *defaultService) UpdateSettings(ctx context.Context, req UpdateSettingsRequest) (*model.ClinicSettings, error) {
+	if tz, _ := req.Patch.Timezone.Get(); tz != nil {
+		if _, err := time.LoadLocation(*tz); err != nil {
+			return nil, apierror.NewBadRequest("Timezone must be a valid IANA time zone, e.g. Africa/Cairo.", err)
+		}
+	}
+
+	var next model.ClinicSettings
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		c, err := s.repo.FindByIDForUpdate(ctx, tx, req.ClinicID)
+		if err != nil {
+			return err
+		}
+		next.Timezone = c.Timezone
+		if req.Patch.ApplyTimezone(&next.Timezone) {
+			if err := s.repo.UpdateTimezone(ctx, tx, req.ClinicID, next.Timezone); err != nil {
+				return err
+			}
+		}
+
+		localization, version, err := settings.Localization.Get(ctx, s.settings, req.ClinicID)
+		if err != nil {
+			return err
+		}
+		if req.Patch.ApplyLocalization(&localization) {
+			if _, err := settings.Localization.SetTx(ctx, s.settings, tx, req.ClinicID, &req.UpdatedBy, localization, version); err != nil {
+				return err
+			}
+		}
+		next.Locale = localization.DefaultLanguage
+		next.DefaultPhoneRegion = localization.DefaultPhoneRegion
+
+		next.BookingRules, version, err = settings.BookingRules.Get(ctx, s.settings, req.ClinicID)
+		if err != nil {
+			return err
+		}
+		if req.Patch.BookingRules.Apply(&next.BookingRules) {
+			_, err = settings.BookingRules.SetTx(ctx, s.settings, tx, req.ClinicID, &req.UpdatedBy, next.BookingRules, version)
+		}
+		return err
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to update clinic settings: %w", err))
+	}
+
+	s.settingsCache.Delete(req.ClinicID)
+	return &next, nil
+}
+
+// Signup creates the clinic, its owner with the Owner system role and the clinic's localization
+// settings in one transaction, then issues the owner an access token. A sandbox clinic gets its
+// demo data in the same transaction.
+func (s *defaultService) Signup(ctx context.Context, req SignupRequest) (*SignupResult, error) {
+	slug := req.Slug
+	if slug == "" {
+		slug = req.ClinicName
+	}
+	slug = NormalizeSlug(slug)
+	if !slugRegex.MatchString(slug) {
+		return nil, apierror.NewBadRequest("The slug must contain at least one letter or digit.", nil)
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, apierror.NewBadRequest("Timezone must be a valid IANA time zone, e.g. Africa/Cairo.", err)
+	}
+	if err := security.CheckPasswordStrength(req.Password); err != nil {
+		return nil, err
+	}
+
+	countryCode := strings.ToUpper(req.CountryCode)
+	// Numbers typed in national form are read in the clinic's country when it is supported.
+	region := phone.DefaultRegion
+	if _, ok := phone.Regions[countryCode]; ok {
+		region = countryCode
+	}
+	phoneNumber, err := phone.Normalize(req.PhoneNumber, region)
+	if err != nil {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("Invalid phone number: %s.", err), err)
+	}
+
+	// Hashing is deliberately slow, so it happens before the transaction holds any locks.
+	passwordHash, err := security.HashPasswordContext(ctx, req.Password)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to hash password: %w", err))
+	}
+
+	c := &model.Clinic{
+		ID:          uuid.Must(uuid.NewV7()),
+		Name:        strings.TrimSpace(req.ClinicName),
+		Slug:        slug,
+		CountryCode: countryCode,
+		Timezone:    req.Timezone,
+		IsSandbox:   req.Sandbox,
+	}
+	owner := &model.Owner{
+		ProfileID:    uuid.Must(uuid.NewV7()),
+		ClinicID:     c.ID,
+		FullName:     strings.TrimSpace(req.OwnerFullName),
+		Email:        strings.ToLower(strings.TrimSpace(req.Email)),
+		PhoneNumber:  phoneNumber,
+		PasswordHash: passwordHash,
+	}
+	contact := model.ContactDetails{
+		PhoneNumber:  phoneNumber,
+		Email:        owner.Email,
+		AddressLine1: req.AddressLine1,
+		City:         req.City,
+		PostalCode:   req.PostalCode,
+	}
+
+	// The new clinic is not in the request yet; scope the owner rows to it for row-level security.
+	ctx = database.WithClinic(ctx, c.ID)
+	var role *model.SystemRoleGrant
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.CreateClinic(ctx, tx, c, contact); err != nil {
+			return err
+		}
+		if err := s.repo.CreateOwner(ctx, tx, owner); err != nil {
+			return err
+		}
+		if role, err = s.repo.FindSystemRole(ctx, tx, model.OwnerRoleName); err != nil {
+			return err
+		}
+		if err := s.repo.AssignRole(ctx, tx, owner.ProfileID, role.ID); err != nil {
+			return err
+		}
+
+		localization := settings.Localization.Defaults()
+		localization.DefaultPhoneRegion = region
+		if _, err := settings.Localization.SetTx(ctx, s.settings, tx, c.ID, &owner.ProfileID, localization, 0); err != nil {
+			return err
+		}
+		if c.IsSandbox {
+			return s.seedSandbox(ctx, tx, c.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to sign up clinic: %w", err))
+	}
+
+	payload, err := security.NewAuthPayload(owner.ProfileID, c.ID, []uuid.UUID{role.ID}, role.PermissionKeys, s.tokenDuration)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to create auth payload: %w", err))
+	}
+	payload.Sandbox = c.IsSandbox
+	token, err := s.sec.CreateToken(payload)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to create token: %w", err))
+	}
+
+	log.Info().Str("clinic_id", c.ID.String()).Str("slug", c.Slug).Bool("sandbox", c.IsSandbox).Msg("Clinic signed up")
+	return &SignupResult{
+		Clinic:             c,
+		DefaultPhoneRegion: region,
+		OwnerID:            owner.ProfileID,
+		AccessToken:        token,
+		AccessTokenExpires: payload.ExpiresAt,
+	}, nil
+}
+
+// NormalizeSlug lowercases s and joins its runs of letters and digits with single dashes,
+// e.g. "Smile Dental  Clinic!" becomes "smile-dental-clinic". The result is cut to a DNS
+// label's length; it is empty if s has no ASCII letters or digits.
+func NormalizeSlug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
@@ -0,0 +1,223 @@
+package clinic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	settingsModel "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeTx runs closures directly.
+type fakeTx struct{}
+
+func (fakeTx) ExecTx(_ context.Context, fn func(tx pgx.Tx) error) error { return fn(nil) }
+func (fakeTx) ExecTxOpts(_ context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+func (fakeTx) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+// quietSettings accepts change subscriptions and never changes.
+type quietSettings struct{ settings.Service }
+
+func (quietSettings) Subscribe(func(settingsModel.ChangeEvent)) {}
+
+// resetRepo holds one clinic and records whether its data was wiped.
+type resetRepo struct {
+	Repository
+	clinic *model.Clinic
+	wiped  bool
+}
+
+func (r *resetRepo) FindByIDForUpdate(context.Context, pgx.Tx, uuid.UUID) (*model.Clinic, error) {
+	return r.clinic, nil
+}
+
+func (r *resetRepo) DeleteClinicData(context.Context, pgx.Tx, uuid.UUID) error {
+	r.wiped = true
+	return nil
+}
+
+func (r *resetRepo) SeedDemoData(context.Context, pgx.Tx, uuid.UUID) error { return nil }
+
+func TestResetSandboxOnlyForcesLiveClinicsForPlatformOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		sandbox  bool
+		force    bool
+		platform bool
+		wantWipe bool
+	}{
+		{"sandbox", true, false, false, true},
+		{"sandbox by a platform operator", true, false, true, true},
+		{"live clinic", false, false, false, false},
+		{"live clinic forced by a clinic employee", false, true, false, false},
+		{"live clinic by a platform operator without force", false, false, true, false},
+		{"live clinic forced by a platform operator", false, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clinicID := uuid.New()
+			repo := &resetRepo{clinic: &model.Clinic{ID: clinicID, IsSandbox: tt.sandbox}}
+			svc := NewService(fakeTx{}, repo, quietSettings{}, nil, 0)
+
+			err := svc.ResetSandbox(context.Background(), ResetSandboxRequest{
+				ClinicID:           clinicID,
+				Force:              tt.force,
+				ByPlatformOperator: tt.platform,
+			})
+			if repo.wiped != tt.wantWipe {
+				t.Errorf("clinic data wiped = %t, want %t", repo.wiped, tt.wantWipe)
+			}
+			if tt.wantWipe {
+				if err != nil {
+					t.Errorf("ResetSandbox: %v", err)
+				}
+				return
+			}
+			var apiErr *apierror.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+				t.Errorf("ResetSandbox error = %v, want a 403", err)
+			}
+		})
+	}
+}
+
+// memSettings keeps settings sections in memory and lets the test announce changes to them.
+type memSettings struct {
+	settings.Service
+	sections    map[string]*settingsModel.SectionRecord
+	subscribers []func(settingsModel.ChangeEvent)
+}
+
+func newMemSettings() *memSettings {
+	return &memSettings{sections: make(map[string]*settingsModel.SectionRecord)}
+}
+
+func (m *memSettings) GetSection(_ context.Context, clinicID uuid.UUID, section string) (*settingsModel.SectionRecord, error) {
+	if record, ok := m.sections[section]; ok {
+		return record, nil
+	}
+	return &settingsModel.SectionRecord{ClinicID: clinicID, Section: section}, nil
+}
+
+func (m *memSettings) SaveSectionTx(_ context.Context, _ pgx.Tx, clinicID uuid.UUID, _ *uuid.UUID, section string, data json.RawMessage, _ int) (*settingsModel.SectionRecord, error) {
+	record, _ := m.GetSection(context.Background(), clinicID, section)
+	m.sections[section] = &settingsModel.SectionRecord{ClinicID: clinicID, Section: section, Data: data, Version: record.Version + 1}
+	return m.sections[section], nil
+}
+
+func (m *memSettings) Subscribe(fn func(settingsModel.ChangeEvent)) {
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// publish announces a committed change the way the settings service does.
+func (m *memSettings) publish(e settingsModel.ChangeEvent) {
+	for _, fn := range m.subscribers {
+		fn(e)
+	}
+}
+
+// settingsRepo holds one clinic and counts how often it is loaded for GetSettings.
+type settingsRepo struct {
+	Repository
+	clinic *model.Clinic
+	loads  int
+}
+
+func (r *settingsRepo) FindByID(context.Context, uuid.UUID) (*model.Clinic, error) {
+	r.loads++
+	c := *r.clinic
+	return &c, nil
+}
+
+func (r *settingsRepo) FindByIDForUpdate(context.Context, pgx.Tx, uuid.UUID) (*model.Clinic, error) {
+	c := *r.clinic
+	return &c, nil
+}
+
+func (r *settingsRepo) UpdateTimezone(_ context.Context, _ pgx.Tx, _ uuid.UUID, timezone string) error {
+	r.clinic.Timezone = timezone
+	return nil
+}
+
+func TestSettingsAreCachedUntilTheyChange(t *testing.T) {
+	ctx := context.Background()
+	clinicID := uuid.New()
+	repo := &settingsRepo{clinic: &model.Clinic{ID: clinicID, Timezone: "Africa/Cairo"}}
+	mem := newMemSettings()
+	svc := NewService(fakeTx{}, repo, mem, nil, 0)
+
+	get := func() *model.ClinicSettings {
+		t.Helper()
+		got, err := svc.GetSettings(ctx, clinicID)
+		if err != nil {
+			t.Fatalf("GetSettings: %v", err)
+		}
+		return got
+	}
+
+	first := get()
+	get()
+	if repo.loads != 1 {
+		t.Fatalf("clinic loaded %d times for two reads, want once", repo.loads)
+	}
+
+	// An update through this service drops the cached copy at once.
+	_, err := svc.UpdateSettings(ctx, UpdateSettingsRequest{
+		ClinicID: clinicID,
+		Patch: model.ClinicSettingsPatch{
+			Timezone:     optional.Some("Asia/Riyadh"),
+			BookingRules: model.BookingRulesPatch{MinNoticeMinutes: optional.Some(first.BookingRules.MinNoticeMinutes + 15)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	updated := get()
+	if repo.loads != 2 || updated.Timezone != "Asia/Riyadh" || updated.BookingRules.MinNoticeMinutes != first.BookingRules.MinNoticeMinutes+15 {
+		t.Errorf("after the update: %d loads, settings %+v; want a reload showing the update", repo.loads, updated)
+	}
+
+	// Changes to other sections leave the cache alone.
+	mem.publish(settingsModel.ChangeEvent{ClinicID: clinicID, Section: settings.AppointmentSubStatuses.Name})
+	get()
+	if repo.loads != 2 {
+		t.Errorf("clinic loaded %d times after an unrelated change, want the cached copy", repo.loads)
+	}
+
+	// A change committed elsewhere, announced through the settings service, drops it too.
+	if _, err := settings.Localization.SetTx(ctx, mem, nil, clinicID, nil, settings.LocalizationSettings{DefaultLanguage: "en", DefaultPhoneRegion: "SA"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	mem.publish(settingsModel.ChangeEvent{ClinicID: clinicID, Section: settings.Localization.Name})
+	if got := get(); got.Locale != "en" || got.DefaultPhoneRegion != "SA" {
+		t.Errorf("after a localization change: locale %q, region %q; want en, SA", got.Locale, got.DefaultPhoneRegion)
+	}
+}
+
+func TestUpdateSettingsRejectsAnUnknownTimezone(t *testing.T) {
+	clinicID := uuid.New()
+	repo := &settingsRepo{clinic: &model.Clinic{ID: clinicID, Timezone: "Africa/Cairo"}}
+	svc := NewService(fakeTx{}, repo, newMemSettings(), nil, 0)
+
+	_, err := svc.UpdateSettings(context.Background(), UpdateSettingsRequest{
+		ClinicID: clinicID,
+		Patch:    model.ClinicSettingsPatch{Timezone: optional.Some("Mars/Olympus_Mons")},
+	})
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("UpdateSettings error = %v, want a 400", err)
+	}
+	if repo.clinic.Timezone != "Africa/Cairo" {
+		t.Errorf("timezone = %s, want it unchanged", repo.clinic.Timezone)
+	}
+}
@@ -0,0 +1,12 @@
+package store
+
+// ClinicDataDeleteQueries returns the statements DeleteClinicData runs, in order. It is exported
+// for the tests in package store_test, which seed through the fixtures package and so cannot be
+// part of package store.
+func ClinicDataDeleteQueries() []string {
+	queries := make([]string, len(clinicDataDeletes))
+	for i, d := range clinicDataDeletes {
+		queries[i] = d.query
+	}
+	return queries
+}
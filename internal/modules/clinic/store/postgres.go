@@ -0,0 +1,209 @@
+// Package store provides the database implementation for the clinic repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxRepository is the PostgreSQL implementation of the clinic.Repository.
+type pgxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPgxRepository creates a new instance of the clinic repository.
+func NewPgxRepository(db *pgxpool.Pool) *pgxRepository {
+	return &pgxRepository{db: db}
+}
+
+// FindBySlug retrieves an active clinic by its slug.
+func (r *pgxRepository) FindBySlug(ctx context.Context, slug string) (*model.Clinic, error) {
+	query := `
+        SELECT id, name, slug, country_code, timezone, subscription_status, is_sandbox, created_at, updated_at
+        FROM clinics
+        WHERE slug = $1 AND deleted_at IS NULL
+    `
+	var c model.Clinic
+	err := r.db.QueryRow(ctx, query, slug).Scan(&c.ID, &c.Name, &c.Slug, &c.CountryCode, &c.Timezone, &c.SubscriptionStatus, &c.IsSandbox, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("clinic", err)
+		}
+		return nil, fmt.Errorf("store.FindBySlug: failed to query clinic: %w", err)
+	}
+	return &c, nil
+}
+
+// FindByID retrieves an active clinic by its ID.
+func (r *pgxRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Clinic, error) {
+	query := `
+        SELECT id, name, slug, country_code, timezone, subscription_status, is_sandbox, created_at, updated_at
+        FROM clinics
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+	var c model.Clinic
+	err := r.db.QueryRow(ctx, query, id).Scan(&c.ID, &c.Name, &c.Slug, &c.CountryCode, &c.Timezone, &c.SubscriptionStatus, &c.IsSandbox, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("clinic", err)
+		}
+		return nil, fmt.Errorf("store.FindByID: failed to query clinic: %w", err)
+	}
+	return &c, nil
+}
+
+// FindByIDForUpdate loads an active clinic and locks its row for the rest of the transaction.
+func (r *pgxRepository) FindByIDForUpdate(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*model.Clinic, error) {
+	query := `
+        SELECT id, name, slug, country_code, timezone, subscription_status, is_sandbox, created_at, updated_at
+        FROM clinics
+        WHERE id = $1 AND deleted_at IS NULL
+        FOR UPDATE
+    `
+	var c model.Clinic
+	err := tx.QueryRow(ctx, query, id).Scan(&c.ID, &c.Name, &c.Slug, &c.CountryCode, &c.Timezone, &c.SubscriptionStatus, &c.IsSandbox, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("clinic", err)
+		}
+		return nil, fmt.Errorf("store.FindByIDForUpdate: failed to query clinic: %w", err)
+	}
+	return &c, nil
+}
+
+// UpdateTimezone sets the clinic's IANA time zone.
+func (r *pgxRepository) UpdateTimezone(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, timezone string) error {
+	query := `UPDATE clinics SET timezone = $2 WHERE id = $1 AND deleted_at IS NULL`
+	if _, err := tx.Exec(ctx, query, clinicID, timezone); err != nil {
+		return fmt.Errorf("store.UpdateTimezone: failed to update clinic: %w", err)
+	}
+	return nil
+}
+
+// CountClinics counts the billable clinics through the billable_clinics view, and the live sandboxes.
+func (r *pgxRepository) CountClinics(ctx context.Context) (*model.ClinicCounts, error) {
+	query := `
+        SELECT (SELECT count(*) FROM billable_clinics),
+               (SELECT count(*) FROM clinics WHERE is_sandbox AND deleted_at IS NULL)`
+	var counts model.ClinicCounts
+	if err := r.db.QueryRow(ctx, query).Scan(&counts.Billable, &counts.Sandbox); err != nil {
+		return nil, fmt.Errorf("store.CountClinics: failed to count clinics: %w", err)
+	}
+	return &counts, nil
+}
+
+// CreateClinic inserts a new clinic and fills in the columns the database defaults.
+func (r *pgxRepository) CreateClinic(ctx context.Context, tx pgx.Tx, c *model.Clinic, contact model.ContactDetails) error {
+	query := `
+        INSERT INTO clinics (id, name, slug, country_code, timezone, phone_number, email, address_line1, city, postal_code, is_sandbox)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING subscription_status, created_at, updated_at`
+	err := tx.QueryRow(ctx, query, c.ID, c.Name, c.Slug, c.CountryCode, c.Timezone,
+		contact.PhoneNumber, contact.Email, contact.AddressLine1, contact.City, contact.PostalCode, c.IsSandbox,
+	).Scan(&c.SubscriptionStatus, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if constraint, ok := database.UniqueViolation(err); ok {
+			switch constraint {
+			case "idx_clinics_unique_active_slug":
+				return apierror.NewConflict("A clinic with this slug already exists.", err).WithCode(apierror.CodeDuplicateResource)
+			case "idx_clinics_unique_active_email":
+				return apierror.NewConflict("A clinic with this email already exists.", err).WithCode(apierror.CodeDuplicateEmail)
+			}
+			return apierror.NewConflict("This clinic already exists.", err).WithCode(apierror.CodeDuplicateResource)
+		}
+		return fmt.Errorf("store.CreateClinic: failed to insert clinic: %w", err)
+	}
+	return nil
+}
+
+// CreateOwner inserts the owner's profile and an active employee record with its password.
+func (r *pgxRepository) CreateOwner(ctx context.Context, tx pgx.Tx, owner *model.Owner) error {
+	profileQuery := `
+        INSERT INTO profiles (id, clinic_id, full_name, email, phone_number, profile_status)
+        VALUES ($1, $2, $3, $4, $5, 'REGISTERED')`
+	if _, err := tx.Exec(ctx, profileQuery, owner.ProfileID, owner.ClinicID, owner.FullName, owner.Email, owner.PhoneNumber); err != nil {
+		return fmt.Errorf("store.CreateOwner: failed to insert profile: %w", err)
+	}
+
+	employeeQuery := `
+        INSERT INTO employees (profile_id, clinic_id, status, password_hash)
+        VALUES ($1, $2, 'ACTIVE', $3)`
+	if _, err := tx.Exec(ctx, employeeQuery, owner.ProfileID, owner.ClinicID, owner.PasswordHash); err != nil {
+		return fmt.Errorf("store.CreateOwner: failed to insert employee: %w", err)
+	}
+	return nil
+}
+
+// FindSystemRole returns the global system role with the given name and its permission keys.
+func (r *pgxRepository) FindSystemRole(ctx context.Context, tx pgx.Tx, name string) (*model.SystemRoleGrant, error) {
+	query := `
+        SELECT r.id, COALESCE(array_agg(p.permission_key ORDER BY p.permission_key) FILTER (WHERE p.id IS NOT NULL), '{}')
+        FROM roles r
+        LEFT JOIN role_permissions rp ON rp.role_id = r.id
+        LEFT JOIN permissions p ON p.id = rp.permission_id
+        WHERE r.name = $1 AND r.is_system_role AND r.clinic_id IS NULL AND r.deleted_at IS NULL
+        GROUP BY r.id`
+	var role model.SystemRoleGrant
+	if err := tx.QueryRow(ctx, query, name).Scan(&role.ID, &role.PermissionKeys); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("store.FindSystemRole: system role %q is not seeded: %w", name, err)
+		}
+		return nil, fmt.Errorf("store.FindSystemRole: failed to query role: %w", err)
+	}
+	return &role, nil
+}
+
+// AssignRole grants roleID to the employee.
+func (r *pgxRepository) AssignRole(ctx context.Context, tx pgx.Tx, profileID, roleID uuid.UUID) error {
+	query := `INSERT INTO employee_roles (employee_profile_id, role_id) VALUES ($1, $2)`
+	if _, err := tx.Exec(ctx, query, profileID, roleID); err != nil {
+		return fmt.Errorf("store.AssignRole: failed to assign role: %w", err)
+	}
+	return nil
+}
+
+// clinicDataDeletes removes a clinic's patient-side data, children before parents.
+// Staff profiles (those with an employees row) are kept so the clinic stays usable.
+var clinicDataDeletes = []struct {
+	table string
+	query string
+}{
+	{"appointments", `DELETE FROM appointments WHERE clinic_id = $1`},
+	{"profiles", `
+        DELETE FROM profiles p
+        WHERE p.clinic_id = $1
+          AND NOT EXISTS (SELECT 1 FROM employees e WHERE e.profile_id = p.id)`},
+}
+
+// DeleteClinicData hard-deletes the clinic's patients, appointments and their dependent rows.
+func (r *pgxRepository) DeleteClinicData(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID) error {
+	for _, d := range clinicDataDeletes {
+		if _, err := tx.Exec(ctx, d.query, clinicID); err != nil {
+			return fmt.Errorf("store.DeleteClinicData: failed to delete %s: %w", d.table, err)
+		}
+	}
+	return nil
+}
+
+// SeedDemoData inserts the fixed demo patients into the clinic.
+func (r *pgxRepository) SeedDemoData(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID) error {
+	query := `
+        INSERT INTO profiles (clinic_id, full_name, phone_number, email, profile_status)
+        VALUES ($1, $2, $3, NULLIF($4, ''), 'REGISTERED')`
+	batch := &pgx.Batch{}
+	for _, p := range model.DemoPatients {
+		batch.Queue(query, clinicID, p.FullName, p.PhoneNumber, p.Email)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("store.SeedDemoData: failed to insert demo patients: %w", err)
+	}
+	return nil
+}
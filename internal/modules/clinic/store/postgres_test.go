@@ -0,0 +1,98 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/fixtures"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedClinic applies the fixture and returns what it created.
+func seedClinic(t *testing.T, pool *pgxpool.Pool, fixture *fixtures.ClinicBuilder) *fixtures.Seeded {
+	t.Helper()
+	seeded, err := fixture.Apply(context.Background(), pool)
+	if err != nil {
+		t.Fatalf("seed clinic: %v", err)
+	}
+	return seeded
+}
+
+// countRows returns how many profiles and appointments the clinic has.
+func countRows(t *testing.T, pool *pgxpool.Pool, clinicID uuid.UUID) (profiles, appointments int) {
+	t.Helper()
+	err := pool.QueryRow(context.Background(), `
+        SELECT (SELECT COUNT(*) FROM profiles WHERE clinic_id = $1),
+               (SELECT COUNT(*) FROM appointments WHERE clinic_id = $1)`, clinicID).Scan(&profiles, &appointments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return profiles, appointments
+}
+
+func TestDeleteClinicDataKeepsStaffAndOtherClinics(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository(pool)
+	fixture := func() *fixtures.ClinicBuilder {
+		return fixtures.Clinic().Sandbox().WithOwner().WithPractitioners(1).WithPatients(3).WithAppointmentsToday(4)
+	}
+	seeded := seedClinic(t, pool, fixture())
+	other := seedClinic(t, pool, fixture())
+
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		return repo.DeleteClinicData(ctx, tx, seeded.Clinic.ID)
+	})
+	if err != nil {
+		t.Fatalf("DeleteClinicData: %v", err)
+	}
+
+	if profiles, appointments := countRows(t, pool, seeded.Clinic.ID); profiles != 2 || appointments != 0 {
+		t.Errorf("after DeleteClinicData the clinic has %d profiles and %d appointments, want its 2 staff and none", profiles, appointments)
+	}
+	var staff []uuid.UUID
+	if err := pool.QueryRow(ctx, `SELECT array_agg(id ORDER BY id) FROM profiles WHERE clinic_id = $1`, seeded.Clinic.ID).Scan(&staff); err != nil {
+		t.Fatal(err)
+	}
+	want := seeded.Staff()
+	slices.SortFunc(want, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	if !slices.Equal(staff, want) {
+		t.Errorf("remaining profiles = %v, want the staff %v", staff, want)
+	}
+	if profiles, appointments := countRows(t, pool, other.Clinic.ID); profiles != 5 || appointments != 4 {
+		t.Errorf("the other clinic has %d profiles and %d appointments, want 5 and 4", profiles, appointments)
+	}
+}
+
+// TestClinicDataDeletesOrderIsRequired shows the order matters: appointments.patient_id is
+// ON DELETE RESTRICT, so deleting the patients first fails while their appointments remain.
+func TestClinicDataDeletesOrderIsRequired(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithOwner().WithPatients(2).WithAppointmentsToday(2))
+
+	reversed := store.ClinicDataDeleteQueries()
+	slices.Reverse(reversed)
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		for _, query := range reversed {
+			if _, err := tx.Exec(ctx, query, seeded.Clinic.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23503" || pgErr.TableName != "appointments" {
+		t.Fatalf("deleting patients before their appointments: error = %v, want a foreign key violation from appointments", err)
+	}
+	if profiles, appointments := countRows(t, pool, seeded.Clinic.ID); profiles != 3 || appointments != 2 {
+		t.Errorf("after the failed delete the clinic has %d profiles and %d appointments, want 3 and 2", profiles, appointments)
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/httpjson"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler holds the dependencies for the clinic configuration HTTP handlers.
+type Handler struct {
+	service clinicconfig.Service
+}
+
+// NewHandler creates a new clinic configuration handler with the given service.
+func NewHandler(service clinicconfig.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ExportConfig returns the caller's clinic configuration as a portable bundle.
+func (h *Handler) ExportConfig(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	bundle, err := h.service.Export(c.Request.Context(), payload.ClinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="clinic-config.json"`)
+	c.JSON(http.StatusOK, bundle)
+	return nil
+}
+
+// ImportConfig applies a bundle to the caller's clinic, or with ?dry_run=true only reports what
+// would change. An import blocked by conflicting items answers 409 with the same report.
+func (h *Handler) ImportConfig(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	dryRun := false
+	if raw := c.Query("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			return apierror.NewBadRequest("Invalid 'dry_run' parameter.", err)
+		}
+	}
+
+	bundle, apiErr := httpjson.DecodeJSON[model.Bundle](c.Writer, c.Request)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	report, err := h.service.Import(c.Request.Context(), clinicconfig.ImportRequest{
+		ClinicID:  payload.ClinicID,
+		UpdatedBy: payload.ActorID(),
+		Bundle:    &bundle,
+		DryRun:    dryRun,
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	status := http.StatusOK
+	if !report.DryRun && !report.Applied {
+		status = http.StatusConflict
+	}
+	c.JSON(status, report)
+	return nil
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the clinic configuration module in the router's startup log.
+func (h *Handler) Name() string {
+	return "clinicconfig"
+}
+
+// RegisterPublicRoutes registers nothing: the clinic configuration module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the clinic configuration module.
+// All these routes are protected and act on the caller's own clinic.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	// GET /api/v1/clinic/config-export - Download roles and settings as a portable bundle.
+	router.GET("/clinic/config-export", middleware.RequirePermission(model.PermissionConfigExport), middleware.ErrorHandler(h.ExportConfig))
+	// POST /api/v1/clinic/config-import?dry_run= - Apply a bundle, or preview what it would change.
+	router.POST("/clinic/config-import", middleware.RequirePermission(model.PermissionConfigImport), middleware.AllowQuery("dry_run"), middleware.ErrorHandler(h.ImportConfig))
+}
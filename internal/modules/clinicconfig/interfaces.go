@@ -0,0 +1,39 @@
+// Package clinicconfig exports a clinic's configuration (roles and settings) as a portable bundle
+// and imports such a bundle into another clinic, typically from staging into production.
+package clinicconfig
+
+import (
+	"context"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Service defines the contract for the clinic configuration module.
+type Service interface {
+	// Export returns the clinic's roles and every settings section with defaults applied.
+	Export(ctx context.Context, clinicID uuid.UUID) (*model.Bundle, error)
+	// Import applies a bundle to the clinic in one transaction, or only reports what it would
+	// change when req.DryRun is set. Roles missing from the bundle are left alone.
+	Import(ctx context.Context, req ImportRequest) (*model.ImportReport, error)
+}
+
+// ImportRequest describes a bundle to apply and who applies it.
+type ImportRequest struct {
+	ClinicID  uuid.UUID
+	UpdatedBy uuid.UUID
+	Bundle    *model.Bundle
+	DryRun    bool
+}
+
+// Repository defines the data access contract for the portable parts of a clinic's configuration.
+type Repository interface {
+	// ListRoles returns the clinic's own active roles with sorted permission keys. System roles are excluded.
+	ListRoles(ctx context.Context, querier database.Querier, clinicID uuid.UUID) ([]model.StoredRole, error)
+	// ListAssignablePermissions returns every permission key a clinic role may hold.
+	ListAssignablePermissions(ctx context.Context, querier database.Querier) ([]string, error)
+	CreateRole(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, role model.Role) error
+	UpdateRoleDescription(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, description *string) error
+}
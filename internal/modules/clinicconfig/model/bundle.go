@@ -0,0 +1,86 @@
+// Package model contains the domain models for the clinic configuration bundle.
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permissions guarding the configuration bundle endpoints. Both are meant for the clinic owner role.
+const (
+	PermissionConfigExport = "clinic.config.export"
+	PermissionConfigImport = "clinic.config.import"
+)
+
+// BundleVersion is the bundle format this build exports and accepts.
+const BundleVersion = 1
+
+// Bundle is a clinic's portable configuration. It deliberately carries no IDs and no
+// tenant data (patients, employees), so it can be applied to a clinic in another environment.
+type Bundle struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Roles      []Role    `json:"roles"`
+	// Settings holds every settings section by name, booking questions included.
+	Settings map[string]json.RawMessage `json:"settings"`
+}
+
+// Role is a clinic role identified by name, with its permissions by key rather than ID.
+type Role struct {
+	Name        string   `json:"name"`
+	Description *string  `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// StoredRole is a clinic role as found in the target clinic.
+type StoredRole struct {
+	ID uuid.UUID
+	Role
+}
+
+// Action is what an import does, or would do, to one item.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	// ActionConflict marks an item that cannot be applied; any conflict blocks the whole import.
+	ActionConflict Action = "conflict"
+)
+
+// ItemResult describes the effect of the import on one role or settings section.
+type ItemResult struct {
+	Name    string `json:"name"`
+	Action  Action `json:"action"`
+	Message string `json:"message,omitempty"`
+	// For roles: permission keys the bundle adds to or removes from the existing role.
+	AddedPermissions   []string `json:"added_permissions,omitempty"`
+	RemovedPermissions []string `json:"removed_permissions,omitempty"`
+	// For settings: the section before and after the import.
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// ImportReport is the per-item outcome of an import. Applied is false for dry runs and for
+// imports blocked by a conflict, in which case nothing was changed.
+type ImportReport struct {
+	DryRun   bool         `json:"dry_run"`
+	Applied  bool         `json:"applied"`
+	Roles    []ItemResult `json:"roles"`
+	Settings []ItemResult `json:"settings"`
+}
+
+// HasConflicts reports whether any item blocks the import.
+func (r *ImportReport) HasConflicts() bool {
+	for _, items := range [][]ItemResult{r.Roles, r.Settings} {
+		for _, item := range items {
+			if item.Action == ActionConflict {
+				return true
+			}
+		}
+	}
+	return false
+}
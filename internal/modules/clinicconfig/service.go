@@ -0,0 +1,275 @@
+package clinicconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultService is the concrete implementation of the clinicconfig.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo     Repository
+	db       *pgxpool.Pool
+	settings settings.Service
+}
+
+// NewService creates a new instance of the clinic configuration service.
+func NewService(txManager database.TxManager, repo Repository, db *pgxpool.Pool, settingsSvc settings.Service) Service {
+	return &defaultService{
+		BaseService: service.BaseService{Tx: txManager},
+		repo:        repo,
+		db:          db,
+		settings:    settingsSvc,
+	}
+}
+
+// Export assembles the clinic's bundle. Sections the clinic never saved are exported with their
+// defaults, so importing the bundle reproduces the same effective configuration.
+func (s *defaultService) Export(ctx context.Context, clinicID uuid.UUID) (*model.Bundle, error) {
+	roles, err := s.repo.ListRoles(ctx, s.db, clinicID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list roles: %w", err))
+	}
+
+	bundle := &model.Bundle{
+		Version:    model.BundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Roles:      make([]model.Role, len(roles)),
+		Settings:   make(map[string]json.RawMessage),
+	}
+	for i, role := range roles {
+		bundle.Roles[i] = role.Role
+	}
+	for _, name := range settings.RegisteredSections() {
+		data, _, err := s.currentSection(ctx, clinicID, name)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Settings[name] = data
+	}
+	return bundle, nil
+}
+
+// Import plans the bundle against the clinic and, unless this is a dry run or an item
+// conflicts, applies the plan in the same transaction it was computed in.
+func (s *defaultService) Import(ctx context.Context, req ImportRequest) (*model.ImportReport, error) {
+	if req.Bundle.Version != model.BundleVersion {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("Unsupported bundle version %d; this server accepts version %d.", req.Bundle.Version, model.BundleVersion), nil)
+	}
+
+	if req.DryRun {
+		p, err := s.plan(ctx, s.db, req)
+		if err != nil {
+			return nil, err
+		}
+		p.report.DryRun = true
+		return p.report, nil
+	}
+
+	var report *model.ImportReport
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		p, err := s.plan(ctx, tx, req)
+		if err != nil {
+			return err
+		}
+		report = p.report
+		if report.HasConflicts() {
+			return nil
+		}
+
+		for _, role := range p.createRoles {
+			if err := s.repo.CreateRole(ctx, tx, req.ClinicID, role); err != nil {
+				return err
+			}
+		}
+		for _, role := range p.describeRoles {
+			if err := s.repo.UpdateRoleDescription(ctx, tx, role.ID, role.Description); err != nil {
+				return err
+			}
+		}
+		for _, section := range p.sections {
+			if _, err := s.settings.SaveSectionTx(ctx, tx, req.ClinicID, &req.UpdatedBy, section.name, section.data, section.version); err != nil {
+				return err
+			}
+		}
+		report.Applied = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importPlan is the report for an import plus the writes needed to carry it out.
+type importPlan struct {
+	report        *model.ImportReport
+	createRoles   []model.Role
+	describeRoles []model.StoredRole
+	sections      []sectionWrite
+}
+
+// sectionWrite is a settings section to save, guarded by the version it was planned against.
+type sectionWrite struct {
+	name    string
+	data    json.RawMessage
+	version int
+}
+
+func (s *defaultService) plan(ctx context.Context, querier database.Querier, req ImportRequest) (*importPlan, error) {
+	p := &importPlan{report: &model.ImportReport{Roles: []model.ItemResult{}, Settings: []model.ItemResult{}}}
+	if err := s.planRoles(ctx, querier, req, p); err != nil {
+		return nil, err
+	}
+	if err := s.planSettings(ctx, req, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// planRoles matches bundle roles to the clinic's roles by name. A role whose name exists with
+// different permissions is a conflict: silently changing what existing holders may do is never
+// what an environment copy intends.
+func (s *defaultService) planRoles(ctx context.Context, querier database.Querier, req ImportRequest, p *importPlan) error {
+	existing, err := s.repo.ListRoles(ctx, querier, req.ClinicID)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to list roles: %w", err))
+	}
+	assignable, err := s.repo.ListAssignablePermissions(ctx, querier)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to list permissions: %w", err))
+	}
+
+	byName := make(map[string]model.StoredRole, len(existing))
+	for _, role := range existing {
+		byName[role.Name] = role
+	}
+	seen := make(map[string]bool, len(req.Bundle.Roles))
+
+	for _, role := range req.Bundle.Roles {
+		role.Name = strings.TrimSpace(role.Name)
+		role.Permissions = slices.Compact(slices.Sorted(slices.Values(role.Permissions)))
+		item := model.ItemResult{Name: role.Name, Action: model.ActionConflict}
+
+		var unknown []string
+		for _, key := range role.Permissions {
+			if !slices.Contains(assignable, key) {
+				unknown = append(unknown, key)
+			}
+		}
+
+		current, exists := byName[role.Name]
+		switch {
+		case role.Name == "":
+			item.Message = "A role name is required."
+		case seen[role.Name]:
+			item.Message = "The role appears more than once in the bundle."
+		case len(unknown) > 0:
+			item.Message = fmt.Sprintf("Unknown or non-assignable permissions: %s.", strings.Join(unknown, ", "))
+		case !exists:
+			item.Action = model.ActionCreate
+			p.createRoles = append(p.createRoles, role)
+		default:
+			item.AddedPermissions = difference(role.Permissions, current.Permissions)
+			item.RemovedPermissions = difference(current.Permissions, role.Permissions)
+			switch {
+			case len(item.AddedPermissions) > 0 || len(item.RemovedPermissions) > 0:
+				item.Message = "A role with this name already exists with different permissions."
+			case !sameDescription(current.Description, role.Description):
+				item.Action = model.ActionUpdate
+				current.Description = role.Description
+				p.describeRoles = append(p.describeRoles, current)
+			default:
+				item.Action = model.ActionUnchanged
+			}
+		}
+		seen[role.Name] = true
+		p.report.Roles = append(p.report.Roles, item)
+	}
+	return nil
+}
+
+// planSettings compares each bundled section with the clinic's current one after both are
+// normalized, so key order and omitted defaults never show up as changes.
+func (s *defaultService) planSettings(ctx context.Context, req ImportRequest, p *importPlan) error {
+	for _, name := range slices.Sorted(maps.Keys(req.Bundle.Settings)) {
+		item := model.ItemResult{Name: name, Action: model.ActionConflict}
+		if !slices.Contains(settings.RegisteredSections(), name) {
+			item.Message = "Unknown settings section."
+			p.report.Settings = append(p.report.Settings, item)
+			continue
+		}
+
+		after, err := settings.Normalize(name, req.Bundle.Settings[name])
+		if err != nil {
+			var apiErr *apierror.APIError
+			if !errors.As(err, &apiErr) {
+				return apierror.NewInternalServer(err)
+			}
+			item.Message = apiErr.PublicMessage
+			p.report.Settings = append(p.report.Settings, item)
+			continue
+		}
+
+		before, version, err := s.currentSection(ctx, req.ClinicID, name)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(before, after) {
+			item.Action = model.ActionUnchanged
+		} else {
+			item.Action = model.ActionUpdate
+			item.Before, item.After = before, after
+			p.sections = append(p.sections, sectionWrite{name: name, data: after, version: version})
+		}
+		p.report.Settings = append(p.report.Settings, item)
+	}
+	return nil
+}
+
+// currentSection returns the clinic's normalized section and its version.
+func (s *defaultService) currentSection(ctx context.Context, clinicID uuid.UUID, name string) (json.RawMessage, int, error) {
+	record, err := s.settings.GetSection(ctx, clinicID, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := settings.Normalize(name, record.Data)
+	if err != nil {
+		return nil, 0, apierror.NewInternalServer(fmt.Errorf("settings: corrupt %q section for clinic %s: %w", name, clinicID, err))
+	}
+	return data, record.Version, nil
+}
+
+// difference returns the sorted keys in a that are not in b.
+func difference(a, b []string) []string {
+	var out []string
+	for _, key := range a {
+		if !slices.Contains(b, key) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func sameDescription(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,110 @@
+// Package store provides the database implementation for the clinic configuration repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgxRepository is the PostgreSQL implementation of the clinicconfig.Repository.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the clinic configuration repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+// ListRoles returns the clinic's own active roles, ordered by name, with sorted permission keys.
+func (r *pgxRepository) ListRoles(ctx context.Context, querier database.Querier, clinicID uuid.UUID) ([]model.StoredRole, error) {
+	query := `
+        SELECT r.id, r.name, r.description,
+               COALESCE(array_agg(p.permission_key ORDER BY p.permission_key) FILTER (WHERE p.id IS NOT NULL), '{}')
+        FROM roles r
+        LEFT JOIN role_permissions rp ON rp.role_id = r.id
+        LEFT JOIN permissions p ON p.id = rp.permission_id
+        WHERE r.clinic_id = $1 AND NOT r.is_system_role AND r.deleted_at IS NULL
+        GROUP BY r.id
+        ORDER BY r.name
+    `
+	rows, err := querier.Query(ctx, query, clinicID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListRoles: failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []model.StoredRole{}
+	for rows.Next() {
+		var role model.StoredRole
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.Permissions); err != nil {
+			return nil, fmt.Errorf("store.ListRoles: failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListRoles: error iterating roles: %w", err)
+	}
+	return roles, nil
+}
+
+// ListAssignablePermissions returns every permission key except the platform ones, which
+// clinic roles can never hold.
+func (r *pgxRepository) ListAssignablePermissions(ctx context.Context, querier database.Querier) ([]string, error) {
+	rows, err := querier.Query(ctx, `
+        SELECT permission_key FROM permissions
+        WHERE permission_key NOT LIKE 'platform.%'
+        ORDER BY permission_key`)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListAssignablePermissions: failed to query permissions: %w", err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("store.ListAssignablePermissions: failed to scan permissions: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateRole inserts a clinic role and grants it the given permission keys, which the caller
+// has already checked against ListAssignablePermissions.
+func (r *pgxRepository) CreateRole(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, role model.Role) error {
+	roleID := uuid.Must(uuid.NewV7())
+	_, err := tx.Exec(ctx, `
+        INSERT INTO roles (id, clinic_id, name, description, is_system_role)
+        VALUES ($1, $2, $3, $4, FALSE)`, roleID, clinicID, role.Name, role.Description)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return apierror.NewConflict(fmt.Sprintf("A role named '%s' already exists.", role.Name), err).WithCode(apierror.CodeDuplicateResource)
+		}
+		return fmt.Errorf("store.CreateRole: failed to insert role: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO role_permissions (role_id, permission_id)
+        SELECT $1, id FROM permissions WHERE permission_key = ANY($2)`, roleID, role.Permissions)
+	if err != nil {
+		return fmt.Errorf("store.CreateRole: failed to grant permissions: %w", err)
+	}
+	return nil
+}
+
+// UpdateRoleDescription changes the description of a clinic role.
+func (r *pgxRepository) UpdateRoleDescription(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, description *string) error {
+	cmdTag, err := tx.Exec(ctx, `
+        UPDATE roles SET description = $2
+        WHERE id = $1 AND NOT is_system_role AND deleted_at IS NULL`, roleID, description)
+	if err != nil {
+		return fmt.Errorf("store.UpdateRoleDescription: failed to update role: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return apierror.NewNotFound("role", nil)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// DocumentResponse describes a document attached to a patient. The content is downloaded
+// through the document's signed URL.
+type DocumentResponse struct {
+	ID          uuid.UUID    `json:"id"`
+	PatientID   uuid.UUID    `json:"patient_id"`
+	FileName    string       `json:"file_name"`
+	ContentType string       `json:"content_type"`
+	SizeBytes   int64        `json:"size_bytes"`
+	Checksum    string       `json:"checksum"`
+	UploadedBy  *uuid.UUID   `json:"uploaded_by,omitempty"`
+	CreatedAt   apitime.Time `json:"created_at"`
+}
+
+// DownloadURLResponse is a time-limited link to a document's content.
+type DownloadURLResponse struct {
+	URL       string       `json:"url"`
+	ExpiresAt apitime.Time `json:"expires_at"`
+}
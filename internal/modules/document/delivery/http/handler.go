@@ -0,0 +1,188 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is the room left in the body limit for the multipart boundaries and headers.
+const multipartOverhead = 64 << 10
+
+// maxFileNameLength caps the stored file name, in characters.
+const maxFileNameLength = 255
+
+// Handler holds the dependencies for the document HTTP handlers.
+type Handler struct {
+	service document.Service
+	// maxBody caps an upload request: one document plus the multipart framing.
+	maxBody int64
+}
+
+// NewHandler creates a new document handler for documents limited by cfg.
+func NewHandler(service document.Service, cfg config.DocumentConfig) *Handler {
+	return &Handler{service: service, maxBody: cfg.MaxSize + multipartOverhead}
+}
+
+// UploadDocument streams the multipart field "file" to the service without buffering it.
+func (h *Handler) UploadDocument(c *gin.Context) *apierror.APIError {
+	clinicID, actorID, profileID, apiErr := patientTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if c.Request.ContentLength > h.maxBody {
+		return apierror.NewPayloadTooLarge("The file exceeds the maximum document size.", nil)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(c.ContentType())
+	if err != nil || mediaType != "multipart/form-data" {
+		return apierror.NewUnsupportedMediaType("Documents must be sent as multipart/form-data.", err)
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return apierror.NewBadRequest("The multipart body is malformed.", err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return apierror.NewBadRequest("The request has no 'file' field.", nil)
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return apierror.NewPayloadTooLarge("The file exceeds the maximum document size.", err)
+			}
+			return apierror.NewBadRequest("The multipart body is malformed.", err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		fileName, apiErr := cleanFileName(part.FileName())
+		if apiErr != nil {
+			return apiErr
+		}
+		doc, err := h.service.Upload(c.Request.Context(), clinicID, actorID, profileID, document.UploadRequest{
+			FileName: fileName,
+			Content:  part,
+		})
+		if err != nil {
+			if errors.As(err, &apiErr) {
+				return apiErr
+			}
+			return apierror.NewInternalServer(err)
+		}
+
+		c.JSON(http.StatusCreated, toDocumentResponse(doc))
+		return nil
+	}
+}
+
+// ListDocuments returns the metadata of the patient's documents.
+func (h *Handler) ListDocuments(c *gin.Context) *apierror.APIError {
+	clinicID, _, profileID, apiErr := patientTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	documents, err := h.service.List(c.Request.Context(), clinicID, profileID)
+	if err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	response := make([]dto.DocumentResponse, len(documents))
+	for i := range documents {
+		response[i] = toDocumentResponse(&documents[i])
+	}
+	c.JSON(http.StatusOK, response)
+	return nil
+}
+
+// GetDocumentURL returns a signed, time-limited download URL for a document.
+func (h *Handler) GetDocumentURL(c *gin.Context) *apierror.APIError {
+	clinicID, _, profileID, apiErr := patientTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+	documentID, err := uuid.Parse(c.Param("docId"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid document ID format.", err)
+	}
+
+	link, err := h.service.GetDownloadURL(c.Request.Context(), clinicID, profileID, documentID)
+	if err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, dto.DownloadURLResponse{URL: link.URL, ExpiresAt: apitime.New(link.ExpiresAt)})
+	return nil
+}
+
+// DeleteDocument deletes a document and its file.
+func (h *Handler) DeleteDocument(c *gin.Context) *apierror.APIError {
+	clinicID, _, profileID, apiErr := patientTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+	documentID, err := uuid.Parse(c.Param("docId"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid document ID format.", err)
+	}
+
+	if err := h.service.Delete(c.Request.Context(), clinicID, profileID, documentID); err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// patientTarget reads the caller's clinic and ID and the patient ID from the request.
+func patientTarget(c *gin.Context) (clinicID, actorID, profileID uuid.UUID, apiErr *apierror.APIError) {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, apierror.NewInternalServer(err)
+	}
+	profileID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, apierror.NewBadRequest("Invalid patient ID format.", err)
+	}
+	return payload.ClinicID, payload.ActorID(), profileID, nil
+}
+
+// cleanFileName keeps the base name of an uploaded file, which browsers may send with a path.
+func cleanFileName(name string) (string, *apierror.APIError) {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		return "", apierror.NewBadRequest("The 'file' field must carry a file name.", nil)
+	}
+	if !utf8.ValidString(name) || utf8.RuneCountInString(name) > maxFileNameLength {
+		return "", apierror.NewBadRequest("The file name must be valid UTF-8 of at most 255 characters.", nil)
+	}
+	return name, nil
+}
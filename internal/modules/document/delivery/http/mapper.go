@@ -0,0 +1,21 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+)
+
+// toDocumentResponse maps a document to its API representation.
+func toDocumentResponse(d *model.Document) dto.DocumentResponse {
+	return dto.DocumentResponse{
+		ID:          d.ID,
+		PatientID:   d.ProfileID,
+		FileName:    d.FileName,
+		ContentType: d.ContentType,
+		SizeBytes:   d.SizeBytes,
+		Checksum:    d.Checksum,
+		UploadedBy:  d.UploadedBy,
+		CreatedAt:   apitime.New(d.CreatedAt),
+	}
+}
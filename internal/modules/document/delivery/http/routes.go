@@ -0,0 +1,32 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the document module in the router's startup log.
+func (h *Handler) Name() string {
+	return "document"
+}
+
+// RegisterPublicRoutes registers nothing: the document module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the document module, nested under a patient.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	documentGroup := router.Group("/patients/:id/documents")
+	{
+		// POST /api/v1/patients/:id/documents - Attach a file, sent as the multipart field "file"
+		documentGroup.POST("", middleware.RequirePermission("patients.update"), middleware.RaiseBodyLimit(h.maxBody), middleware.ErrorHandler(h.UploadDocument))
+
+		// GET /api/v1/patients/:id/documents - List the patient's documents, newest first
+		documentGroup.GET("", middleware.RequirePermission("patients.read"), middleware.ErrorHandler(h.ListDocuments))
+
+		// GET /api/v1/patients/:id/documents/:docId/url - A signed download URL that expires shortly
+		documentGroup.GET("/:docId/url", middleware.RequirePermission("patients.read"), middleware.ErrorHandler(h.GetDocumentURL))
+
+		// DELETE /api/v1/patients/:id/documents/:docId - Delete the document and its file
+		documentGroup.DELETE("/:docId", middleware.RequirePermission("patients.update"), middleware.ErrorHandler(h.DeleteDocument))
+	}
+}
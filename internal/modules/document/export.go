@@ -0,0 +1,44 @@
+package document
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// exportSection names the documents' part of a patient export.
+const exportSection = "documents"
+
+// documentExport describes a document in a patient export. The content itself is not
+// included; it can be downloaded through the documents API.
+type documentExport struct {
+	ID          uuid.UUID  `json:"id"`
+	FileName    string     `json:"file_name"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Checksum    string     `json:"checksum_sha256"`
+	UploadedBy  *uuid.UUID `json:"uploaded_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// exportDocuments is the documents export section.
+func (s *defaultService) exportDocuments(ctx context.Context, clinicID, profileID uuid.UUID) (any, error) {
+	documents, err := s.repo.List(ctx, s.db, clinicID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]documentExport, len(documents))
+	for i, d := range documents {
+		exported[i] = documentExport{
+			ID:          d.ID,
+			FileName:    d.FileName,
+			ContentType: d.ContentType,
+			SizeBytes:   d.SizeBytes,
+			Checksum:    d.Checksum,
+			UploadedBy:  d.UploadedBy,
+			CreatedAt:   d.CreatedAt,
+		}
+	}
+	return exported, nil
+}
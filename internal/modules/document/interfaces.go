@@ -0,0 +1,50 @@
+// Package document attaches files such as scanned ID cards and lab results to patient profiles.
+// The files live in a storage.BlobStore and are downloaded through time-limited signed URLs;
+// the database only holds their metadata.
+package document
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for the document module.
+type Service interface {
+	// Upload stores the file and records it against the patient. Files over the configured size,
+	// or whose content is not of an allowed type, are rejected before they reach the store.
+	Upload(ctx context.Context, clinicID, actorID, profileID uuid.UUID, req UploadRequest) (*model.Document, error)
+	// List returns the patient's documents, newest first.
+	List(ctx context.Context, clinicID, profileID uuid.UUID) ([]model.Document, error)
+	// GetDownloadURL returns a signed URL the document can be downloaded from for a limited time.
+	GetDownloadURL(ctx context.Context, clinicID, profileID, documentID uuid.UUID) (*DownloadURL, error)
+	// Delete removes the document and its file.
+	Delete(ctx context.Context, clinicID, profileID, documentID uuid.UUID) error
+}
+
+// UploadRequest carries one uploaded file.
+type UploadRequest struct {
+	FileName string
+	Content  io.Reader
+}
+
+// DownloadURL is a signed link to a document's content.
+type DownloadURL struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// Repository defines the data access contract for documents.
+type Repository interface {
+	// PatientExists reports whether profileID is an active profile of the clinic.
+	PatientExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error)
+	Create(ctx context.Context, querier database.Querier, document *model.Document) error
+	List(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) ([]model.Document, error)
+	FindByID(ctx context.Context, querier database.Querier, clinicID, profileID, documentID uuid.UUID) (*model.Document, error)
+	// Delete deletes the document and returns it, or a NotFound apierror.
+	Delete(ctx context.Context, querier database.Querier, clinicID, profileID, documentID uuid.UUID) (*model.Document, error)
+}
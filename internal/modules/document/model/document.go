@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Document is a file attached to a patient profile, such as a scanned ID card or a lab result.
+// The content lives in the blob store under StorageKey.
+type Document struct {
+	ID        uuid.UUID
+	ClinicID  uuid.UUID
+	ProfileID uuid.UUID
+	// FileName is the name the file was uploaded with, for display only.
+	FileName string
+	// ContentType is detected from the content, not taken from the client.
+	ContentType string
+	SizeBytes   int64
+	// Checksum is the hex-encoded SHA-256 of the content.
+	Checksum   string
+	StorageKey string
+	UploadedBy *uuid.UUID
+	CreatedAt  time.Time
+}
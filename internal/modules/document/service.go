@@ -0,0 +1,218 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/storage"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// sniffLen is how much of a file http.DetectContentType looks at.
+const sniffLen = 512
+
+// compensationTimeout bounds the cleanup of a blob whose row could not be written; it runs
+// even if the request was cancelled.
+const compensationTimeout = 10 * time.Second
+
+// errTooLarge stops a Put once the file grows past the configured limit.
+var errTooLarge = errors.New("document exceeds the maximum size")
+
+// defaultService is the concrete implementation of the document.Service interface.
+type defaultService struct {
+	repo  Repository
+	db    database.Querier
+	blobs storage.BlobStore
+	cfg   config.DocumentConfig
+	// urlTTL is how long a download URL stays valid.
+	urlTTL time.Duration
+}
+
+// NewService creates a new instance of the document service that keeps files in blobs, and
+// registers the documents export section.
+func NewService(repo Repository, db database.Querier, blobs storage.BlobStore, cfg config.StorageConfig, exports *export.Registry) Service {
+	s := &defaultService{
+		repo:   repo,
+		db:     db,
+		blobs:  blobs,
+		cfg:    cfg.Documents,
+		urlTTL: cfg.SignedURLTTL,
+	}
+	exports.Register(exportSection, s.exportDocuments)
+	return s
+}
+
+// documentKey is the storage key of a document's content.
+func documentKey(d *model.Document) string {
+	return fmt.Sprintf("clinics/%s/documents/%s", d.ClinicID, d.ID)
+}
+
+// Upload detects the type from the first bytes before anything is written, and caps the stream
+// so an oversized file fails the Put instead of landing in the store. If the row cannot be
+// written, the stored blob is deleted again.
+func (s *defaultService) Upload(ctx context.Context, clinicID, actorID, profileID uuid.UUID, req UploadRequest) (*model.Document, error) {
+	exists, err := s.repo.PatientExists(ctx, s.db, clinicID, profileID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
+	if !exists {
+		return nil, apierror.NewNotFound("patient", nil)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(req.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, uploadError(err, s.cfg.MaxSize)
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, apierror.NewBadRequest("The file is empty.", nil)
+	}
+	contentType, err := s.detectType(head)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &model.Document{
+		ID:          uuid.Must(uuid.NewV7()),
+		ClinicID:    clinicID,
+		ProfileID:   profileID,
+		FileName:    req.FileName,
+		ContentType: contentType,
+		UploadedBy:  &actorID,
+	}
+	doc.StorageKey = documentKey(doc)
+
+	hasher := sha256.New()
+	content := &cappedReader{r: io.MultiReader(bytes.NewReader(head), req.Content), remaining: s.cfg.MaxSize, hash: hasher}
+	size, err := s.blobs.Put(ctx, doc.StorageKey, content)
+	if err != nil {
+		// A failed Put leaves nothing behind in the local store, but an object store may
+		// have kept a partial object.
+		s.deleteBlob(ctx, doc.StorageKey)
+		return nil, uploadError(err, s.cfg.MaxSize)
+	}
+	doc.SizeBytes = size
+	doc.Checksum = hex.EncodeToString(hasher.Sum(nil))
+
+	if err := s.repo.Create(ctx, s.db, doc); err != nil {
+		s.deleteBlob(ctx, doc.StorageKey)
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to record document: %w", err))
+	}
+	return doc, nil
+}
+
+// List returns the patient's documents, newest first.
+func (s *defaultService) List(ctx context.Context, clinicID, profileID uuid.UUID) ([]model.Document, error) {
+	documents, err := s.repo.List(ctx, s.db, clinicID, profileID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
+	return documents, nil
+}
+
+// GetDownloadURL signs a URL for the document that expires after the configured TTL.
+func (s *defaultService) GetDownloadURL(ctx context.Context, clinicID, profileID, documentID uuid.UUID) (*DownloadURL, error) {
+	doc, err := s.repo.FindByID(ctx, s.db, clinicID, profileID, documentID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(err)
+	}
+
+	expiresAt := time.Now().Add(s.urlTTL)
+	url, err := s.blobs.GetSignedURL(ctx, doc.StorageKey, s.urlTTL)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to sign document URL: %w", err))
+	}
+	return &DownloadURL{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+// Delete deletes the row before the blob. If the row cannot be deleted the blob is untouched;
+// if the blob cannot be deleted afterwards it is only orphaned, since URLs are only ever signed
+// for keys that have a row, and it is logged for cleanup.
+func (s *defaultService) Delete(ctx context.Context, clinicID, profileID, documentID uuid.UUID) error {
+	doc, err := s.repo.Delete(ctx, s.db, clinicID, profileID, documentID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+	s.deleteBlob(ctx, doc.StorageKey)
+	return nil
+}
+
+// detectType sniffs the content type from the file's first bytes and checks it against the
+// allow-list; the type the client declared is not trusted.
+func (s *defaultService) detectType(head []byte) (string, error) {
+	detected := http.DetectContentType(head)
+	contentType, _, err := mime.ParseMediaType(detected)
+	if err != nil || !slices.Contains(s.cfg.AllowedTypes, contentType) {
+		return "", apierror.NewUnsupportedMediaType(fmt.Sprintf("Files of type '%s' cannot be attached. Allowed types: %s.", detected, strings.Join(s.cfg.AllowedTypes, ", ")), err)
+	}
+	return contentType, nil
+}
+
+// deleteBlob deletes key even if ctx was cancelled, logging a failure: the blob is then orphaned.
+func (s *defaultService) deleteBlob(ctx context.Context, key string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+	defer cancel()
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		log.Error().Err(err).Str("storage_key", key).Msg("documents: failed to delete blob, it is orphaned")
+	}
+}
+
+// uploadError maps a failure to read or store the upload to an API error.
+func uploadError(err error, maxSize int64) *apierror.APIError {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, errTooLarge) || errors.As(err, &maxBytesErr) {
+		return apierror.NewPayloadTooLarge(fmt.Sprintf("The file exceeds the maximum size of %d bytes.", maxSize), err)
+	}
+	return apierror.NewInternalServer(fmt.Errorf("failed to store document: %w", err))
+}
+
+// cappedReader hashes what it reads and fails with errTooLarge once more than remaining bytes
+// were read.
+type cappedReader struct {
+	r         io.Reader
+	remaining int64
+	hash      hash.Hash
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.remaining < 0 {
+		return 0, errTooLarge
+	}
+	// Read one byte past the limit, so a file of exactly the limit still succeeds.
+	if int64(len(p)) > c.remaining+1 {
+		p = p[:c.remaining+1]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining < 0 {
+		return 0, errTooLarge
+	}
+	c.hash.Write(p[:n])
+	return n, err
+}
@@ -0,0 +1,285 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/storage"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+)
+
+// pdf is the smallest content http.DetectContentType reports as application/pdf.
+var pdf = []byte("%PDF-1.7\n1 0 obj\n")
+
+type fakeRepo struct {
+	mu        sync.Mutex
+	patients  map[uuid.UUID]bool
+	rows      []model.Document
+	createErr error
+	deleteErr error
+}
+
+func (r *fakeRepo) PatientExists(_ context.Context, _ database.Querier, _, profileID uuid.UUID) (bool, error) {
+	return r.patients[profileID], nil
+}
+
+func (r *fakeRepo) Create(_ context.Context, _ database.Querier, d *model.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	d.CreatedAt = time.Now()
+	r.rows = append(r.rows, *d)
+	return nil
+}
+
+func (r *fakeRepo) List(_ context.Context, _ database.Querier, clinicID, profileID uuid.UUID) ([]model.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var documents []model.Document
+	for _, d := range r.rows {
+		if d.ClinicID == clinicID && d.ProfileID == profileID {
+			documents = append(documents, d)
+		}
+	}
+	return documents, nil
+}
+
+func (r *fakeRepo) FindByID(_ context.Context, _ database.Querier, clinicID, profileID, documentID uuid.UUID) (*model.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.rows {
+		if d.ClinicID == clinicID && d.ProfileID == profileID && d.ID == documentID {
+			return &d, nil
+		}
+	}
+	return nil, apierror.NewNotFound("document", nil)
+}
+
+func (r *fakeRepo) Delete(_ context.Context, _ database.Querier, clinicID, profileID, documentID uuid.UUID) (*model.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deleteErr != nil {
+		return nil, r.deleteErr
+	}
+	for i, d := range r.rows {
+		if d.ClinicID == clinicID && d.ProfileID == profileID && d.ID == documentID {
+			r.rows = append(r.rows[:i], r.rows[i+1:]...)
+			return &d, nil
+		}
+	}
+	return nil, apierror.NewNotFound("document", nil)
+}
+
+type fixture struct {
+	svc      Service
+	repo     *fakeRepo
+	root     string
+	clinic   uuid.UUID
+	actor    uuid.UUID
+	patient  uuid.UUID
+	maxBytes int64
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	root := t.TempDir()
+	local, err := storage.NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fixture{
+		root:     root,
+		clinic:   uuid.New(),
+		actor:    uuid.New(),
+		patient:  uuid.New(),
+		maxBytes: 1024,
+	}
+	f.repo = &fakeRepo{patients: map[uuid.UUID]bool{f.patient: true}}
+	blobs := storage.NewSignedLocalStore(local, "http://api.test/files", []byte("key"))
+	f.svc = NewService(f.repo, nil, blobs, config.StorageConfig{
+		SignedURLTTL: 5 * time.Minute,
+		Documents:    config.DocumentConfig{MaxSize: f.maxBytes, AllowedTypes: []string{"application/pdf", "image/png"}},
+	}, export.NewRegistry())
+	return f
+}
+
+// blobs lists every file in the store, relative to its root, temporary files included.
+func (f *fixture) blobs(t *testing.T) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(f.root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(f.root, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func (f *fixture) upload(content []byte) (*model.Document, error) {
+	return f.svc.Upload(context.Background(), f.clinic, f.actor, f.patient, UploadRequest{FileName: "lab.pdf", Content: bytes.NewReader(content)})
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+		t.Fatalf("error = %v, want a %d apierror", err, status)
+	}
+}
+
+func TestUploadStoresFileAndMetadata(t *testing.T) {
+	f := newFixture(t)
+	content := append(append([]byte{}, pdf...), bytes.Repeat([]byte("x"), 600)...)
+
+	doc, err := f.upload(content)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	if doc.ContentType != "application/pdf" || doc.SizeBytes != int64(len(content)) || doc.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("document = %+v, want a %d byte application/pdf with its checksum", doc, len(content))
+	}
+	if want := "clinics/" + f.clinic.String() + "/documents/" + doc.ID.String(); doc.StorageKey != want {
+		t.Errorf("StorageKey = %s, want %s", doc.StorageKey, want)
+	}
+
+	stored, err := os.ReadFile(filepath.Join(f.root, filepath.FromSlash(doc.StorageKey)))
+	if err != nil || !bytes.Equal(stored, content) {
+		t.Fatalf("stored blob differs from the upload (err %v)", err)
+	}
+
+	listed, err := f.svc.List(context.Background(), f.clinic, f.patient)
+	if err != nil || len(listed) != 1 || listed[0].ID != doc.ID {
+		t.Fatalf("List = %v, %v; want the uploaded document", listed, err)
+	}
+
+	link, err := f.svc.GetDownloadURL(context.Background(), f.clinic, f.patient, doc.ID)
+	if err != nil {
+		t.Fatalf("GetDownloadURL: %v", err)
+	}
+	if !strings.HasPrefix(link.URL, "http://api.test/files/"+doc.StorageKey+"?") || !strings.Contains(link.URL, "signature=") {
+		t.Errorf("URL = %s, want a signed link to the blob", link.URL)
+	}
+}
+
+func TestUploadAcceptsFileOfExactlyMaxSize(t *testing.T) {
+	f := newFixture(t)
+	content := append(append([]byte{}, pdf...), bytes.Repeat([]byte("x"), int(f.maxBytes)-len(pdf))...)
+	if _, err := f.upload(content); err != nil {
+		t.Fatalf("Upload of exactly MaxSize bytes: %v", err)
+	}
+}
+
+func TestUploadRejectsBeforeStoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		status  int
+	}{
+		{"too large", append(append([]byte{}, pdf...), bytes.Repeat([]byte("x"), 1024)...), http.StatusRequestEntityTooLarge},
+		{"disallowed type", []byte("<html><script>alert(1)</script></html>"), http.StatusUnsupportedMediaType},
+		{"declared pdf but plain text", []byte("just some text pretending to be a PDF"), http.StatusUnsupportedMediaType},
+		{"empty", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			_, err := f.upload(tt.content)
+			assertStatus(t, err, tt.status)
+			if blobs := f.blobs(t); len(blobs) != 0 {
+				t.Errorf("store holds %v after a rejected upload", blobs)
+			}
+			if len(f.repo.rows) != 0 {
+				t.Errorf("repository holds %d rows after a rejected upload", len(f.repo.rows))
+			}
+		})
+	}
+}
+
+func TestUploadUnknownPatient(t *testing.T) {
+	f := newFixture(t)
+	_, err := f.svc.Upload(context.Background(), f.clinic, f.actor, uuid.New(), UploadRequest{FileName: "lab.pdf", Content: bytes.NewReader(pdf)})
+	assertStatus(t, err, http.StatusNotFound)
+}
+
+func TestUploadDeletesBlobWhenRowFails(t *testing.T) {
+	f := newFixture(t)
+	f.repo.createErr = errors.New("connection reset")
+
+	_, err := f.upload(pdf)
+	assertStatus(t, err, http.StatusInternalServerError)
+	if blobs := f.blobs(t); len(blobs) != 0 {
+		t.Errorf("store holds %v after the row failed to insert", blobs)
+	}
+}
+
+func TestDeleteRemovesRowAndBlob(t *testing.T) {
+	f := newFixture(t)
+	doc, err := f.upload(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.svc.Delete(context.Background(), f.clinic, f.patient, doc.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if blobs := f.blobs(t); len(blobs) != 0 {
+		t.Errorf("store holds %v after Delete", blobs)
+	}
+	_, err = f.svc.GetDownloadURL(context.Background(), f.clinic, f.patient, doc.ID)
+	assertStatus(t, err, http.StatusNotFound)
+
+	err = f.svc.Delete(context.Background(), f.clinic, f.patient, doc.ID)
+	assertStatus(t, err, http.StatusNotFound)
+}
+
+func TestDeleteKeepsBlobWhenRowFails(t *testing.T) {
+	f := newFixture(t)
+	doc, err := f.upload(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.repo.deleteErr = errors.New("connection reset")
+
+	err = f.svc.Delete(context.Background(), f.clinic, f.patient, doc.ID)
+	assertStatus(t, err, http.StatusInternalServerError)
+	if blobs := f.blobs(t); len(blobs) != 1 || blobs[0] != doc.StorageKey {
+		t.Errorf("store holds %v, want the document's blob kept", blobs)
+	}
+}
+
+func TestDocumentsAreScopedToTheirPatient(t *testing.T) {
+	f := newFixture(t)
+	doc, err := f.upload(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.svc.GetDownloadURL(context.Background(), f.clinic, uuid.New(), doc.ID)
+	assertStatus(t, err, http.StatusNotFound)
+	_, err = f.svc.GetDownloadURL(context.Background(), uuid.New(), f.patient, doc.ID)
+	assertStatus(t, err, http.StatusNotFound)
+}
@@ -0,0 +1,118 @@
+// Package store provides the database implementation for the document repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// documentColumns selects documents in the order scanDocument reads them.
+const documentColumns = `
+        id, clinic_id, profile_id, file_name, content_type, size_bytes, checksum, storage_key,
+        uploaded_by, created_at`
+
+// pgxRepository is the PostgreSQL implementation of the document.Repository.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the document repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+func scanDocument(row pgx.Row) (*model.Document, error) {
+	var d model.Document
+	err := row.Scan(&d.ID, &d.ClinicID, &d.ProfileID, &d.FileName, &d.ContentType, &d.SizeBytes, &d.Checksum,
+		&d.StorageKey, &d.UploadedBy, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// PatientExists reports whether profileID is an active profile of the clinic.
+func (r *pgxRepository) PatientExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM profiles WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL)`
+	if err := querier.QueryRow(ctx, query, clinicID, profileID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("store.PatientExists: failed to query profile: %w", err)
+	}
+	return exists, nil
+}
+
+// Create inserts the document and fills in its creation time.
+func (r *pgxRepository) Create(ctx context.Context, querier database.Querier, d *model.Document) error {
+	query := `
+        INSERT INTO profile_documents (id, clinic_id, profile_id, file_name, content_type, size_bytes, checksum, storage_key, uploaded_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING created_at`
+	err := querier.QueryRow(ctx, query, d.ID, d.ClinicID, d.ProfileID, d.FileName, d.ContentType, d.SizeBytes,
+		d.Checksum, d.StorageKey, d.UploadedBy).Scan(&d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("store.Create: failed to insert document: %w", err)
+	}
+	return nil
+}
+
+// List returns the patient's documents, newest first.
+func (r *pgxRepository) List(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) ([]model.Document, error) {
+	query := `SELECT ` + documentColumns + `
+        FROM profile_documents
+        WHERE clinic_id = $1 AND profile_id = $2
+        ORDER BY created_at DESC, id DESC`
+	rows, err := querier.Query(ctx, query, clinicID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("store.List: failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := []model.Document{}
+	for rows.Next() {
+		d, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store.List: failed to scan document: %w", err)
+		}
+		documents = append(documents, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.List: failed to read documents: %w", err)
+	}
+	return documents, nil
+}
+
+// FindByID returns the patient's document, or a NotFound apierror.
+func (r *pgxRepository) FindByID(ctx context.Context, querier database.Querier, clinicID, profileID, documentID uuid.UUID) (*model.Document, error) {
+	query := `SELECT ` + documentColumns + `
+        FROM profile_documents
+        WHERE clinic_id = $1 AND profile_id = $2 AND id = $3`
+	d, err := scanDocument(querier.QueryRow(ctx, query, clinicID, profileID, documentID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("document", err)
+		}
+		return nil, fmt.Errorf("store.FindByID: failed to query document: %w", err)
+	}
+	return d, nil
+}
+
+// Delete deletes the patient's document and returns it, or a NotFound apierror.
+func (r *pgxRepository) Delete(ctx context.Context, querier database.Querier, clinicID, profileID, documentID uuid.UUID) (*model.Document, error) {
+	query := `
+        DELETE FROM profile_documents
+        WHERE clinic_id = $1 AND profile_id = $2 AND id = $3
+        RETURNING ` + documentColumns
+	d, err := scanDocument(querier.QueryRow(ctx, query, clinicID, profileID, documentID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("document", err)
+		}
+		return nil, fmt.Errorf("store.Delete: failed to delete document: %w", err)
+	}
+	return d, nil
+}
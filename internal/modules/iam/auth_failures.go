@@ -0,0 +1,125 @@
+package iam
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	authFailureQueueSize     = 1024
+	authFailureWriteTimeout  = 5 * time.Second
+	authFailureCleanupPeriod = time.Hour
+)
+
+// AuthFailureRecorder writes authentication failures asynchronously so a slow or
+// unavailable audit table never adds latency to (or breaks) the login path.
+type AuthFailureRecorder struct {
+	repo    Repository
+	hashKey []byte
+	jobs    chan func(ctx context.Context) error
+}
+
+// NewAuthFailureRecorder creates a recorder that hashes identifiers with hashKey.
+func NewAuthFailureRecorder(repo Repository, hashKey []byte) *AuthFailureRecorder {
+	return &AuthFailureRecorder{
+		repo:    repo,
+		hashKey: hashKey,
+		jobs:    make(chan func(ctx context.Context) error, authFailureQueueSize),
+	}
+}
+
+// HashIdentifier returns the stored form of a login identifier.
+func (r *AuthFailureRecorder) HashIdentifier(identifier string) string {
+	return security.HashIdentifier(r.hashKey, identifier)
+}
+
+// RecordFailure queues a rejected attempt. It never blocks; if the queue is full the event is dropped and logged.
+func (r *AuthFailureRecorder) RecordFailure(req LoginEmployeeRequest, identifier string, reason model.AuthFailureReason) {
+	failure := &model.AuthFailure{
+		IdentifierHash: r.HashIdentifier(identifier),
+		Reason:         reason,
+	}
+	if req.ClinicSlug != "" {
+		failure.ClinicSlug = &req.ClinicSlug
+	}
+	if req.IPAddress != "" {
+		failure.IPAddress = &req.IPAddress
+	}
+	if req.UserAgent != "" {
+		failure.UserAgent = &req.UserAgent
+	}
+
+	log.Warn().
+		Str("clinic_slug", req.ClinicSlug).
+		Str("identifier_hash", failure.IdentifierHash).
+		Str("ip", req.IPAddress).
+		Str("reason", string(reason)).
+		Msg("Authentication attempt rejected")
+
+	r.enqueue(func(ctx context.Context) error {
+		return r.repo.CreateAuthFailure(ctx, failure)
+	})
+}
+
+// RecordEmployeeFailure records a rejected attempt whose identifier matched an employee,
+// additionally adding it to that employee's login history.
+func (r *AuthFailureRecorder) RecordEmployeeFailure(req LoginEmployeeRequest, identifier string, profileID uuid.UUID, reason model.AuthFailureReason) {
+	r.RecordFailure(req, identifier, reason)
+	r.enqueue(func(ctx context.Context) error {
+		return r.repo.RecordFailedLogin(ctx, profileID, req.IPAddress, req.UserAgent, reason)
+	})
+}
+
+// RecordSuccess queues the linking of any open failure streak for the identifier to the successful login.
+func (r *AuthFailureRecorder) RecordSuccess(identifier string, profileID uuid.UUID) {
+	hash := r.HashIdentifier(identifier)
+	r.enqueue(func(ctx context.Context) error {
+		return r.repo.ResolveAuthFailureStreak(ctx, hash, profileID)
+	})
+}
+
+func (r *AuthFailureRecorder) enqueue(job func(ctx context.Context) error) {
+	select {
+	case r.jobs <- job:
+	default:
+		log.Error().Msg("auth failure recorder: queue full, dropping event")
+	}
+}
+
+// Run processes queued writes and enforces the retention window until ctx is cancelled.
+func (r *AuthFailureRecorder) Run(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(authFailureCleanupPeriod)
+	defer ticker.Stop()
+
+	r.cleanup(ctx, retention)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-r.jobs:
+			jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), authFailureWriteTimeout)
+			if err := job(jobCtx); err != nil {
+				log.Error().Err(err).Msg("auth failure recorder: failed to persist event")
+			}
+			cancel()
+		case <-ticker.C:
+			r.cleanup(ctx, retention)
+		}
+	}
+}
+
+func (r *AuthFailureRecorder) cleanup(ctx context.Context, retention time.Duration) {
+	deleted, err := r.repo.DeleteAuthFailuresBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		log.Error().Err(err).Msg("auth failure recorder: retention cleanup failed")
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("auth failure recorder: purged expired records")
+	}
+}
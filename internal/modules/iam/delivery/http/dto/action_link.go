@@ -0,0 +1,12 @@
+package dto
+
+// RedeemActionLinkRequest carries the token from a signed action link in a notification email.
+type RedeemActionLinkRequest struct {
+	Token string `json:"token"`
+}
+
+// ActionLinkResponse is a login response plus the client route the link was issued for.
+type ActionLinkResponse struct {
+	LoginResponse
+	RedirectTo string `json:"redirect_to"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
+	"github.com/google/uuid"
+)
+
+// AuthFailureResponse is a single rejected authentication attempt. The identifier is only exposed as its hash.
+type AuthFailureResponse struct {
+	ID                uuid.UUID     `json:"id"`
+	ClinicSlug        *string       `json:"clinic_slug,omitempty"`
+	IdentifierHash    string        `json:"identifier_hash"`
+	IPAddress         *string       `json:"ip_address,omitempty"`
+	UserAgent         *string       `json:"user_agent,omitempty"`
+	Reason            string        `json:"reason"`
+	ResolvedAt        *apitime.Time `json:"resolved_at,omitempty"`
+	ResolvedProfileID *uuid.UUID    `json:"resolved_profile_id,omitempty"`
+	OccurredAt        apitime.Time  `json:"occurred_at"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// EmailChangeRequest starts a change of an employee's email address.
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email" zog:"new_email"`
+}
+
+// EmailChangeTokenRequest carries the token from a verification or undo link.
+type EmailChangeTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// EmailChangeResponse is a pending email change as shown on the employee record.
+type EmailChangeResponse struct {
+	ID          uuid.UUID    `json:"id"`
+	NewEmail    string       `json:"new_email"`
+	RequestedBy *uuid.UUID   `json:"requested_by,omitempty"`
+	ExpiresAt   apitime.Time `json:"expires_at"`
+	CreatedAt   apitime.Time `json:"created_at"`
+}
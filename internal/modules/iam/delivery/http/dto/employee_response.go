@@ -1,9 +1,15 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
 
-// EmployeeResponse defines the publicly exposed fields of an employee.
+	"github.com/google/uuid"
+)
+
+// EmployeeResponse defines the publicly exposed fields of an employee (API version 1).
 // It combines data from both the 'profiles' and 'employees' tables.
+// This shape is frozen: integrators pinned to version 1 depend on it exactly, so contact
+// details the caller may not see are sent as null rather than left out.
 type EmployeeResponse struct {
 	ID          uuid.UUID `json:"id"` // This is the Profile ID
 	ClinicID    uuid.UUID `json:"clinic_id"`
@@ -13,3 +19,41 @@ type EmployeeResponse struct {
 	JobTitle    *string   `json:"job_title"`
 	Status      string    `json:"status"`
 }
+
+// EmployeeResponseV2 is the enriched employee shape (API version 2).
+// Email, phone_number, last_login_at and invited_by are present only on the caller's own
+// record or for callers holding employees.read_contact; otherwise they are omitted.
+type EmployeeResponseV2 struct {
+	ID          uuid.UUID     `json:"id"` // This is the Profile ID
+	ClinicID    uuid.UUID     `json:"clinic_id"`
+	Email       *string       `json:"email,omitempty"`
+	PhoneNumber *string       `json:"phone_number,omitempty"`
+	FullName    string        `json:"full_name"`
+	JobTitle    *string       `json:"job_title"`
+	Status      string        `json:"status"`
+	LastLoginAt *apitime.Time `json:"last_login_at,omitempty"`
+	InvitedBy   *uuid.UUID    `json:"invited_by,omitempty"`
+	Roles       []RoleSummary `json:"roles"`
+}
+
+// RoleSummary is the compact representation of a role embedded in other responses.
+type RoleSummary struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// MeResponse describes the signed-in employee: their own record with contact details, the
+// names of their roles and the permission keys those roles grant, so clients can build menus
+// without decoding the access token.
+type MeResponse struct {
+	ID          uuid.UUID     `json:"id"` // This is the Profile ID
+	ClinicID    uuid.UUID     `json:"clinic_id"`
+	Email       *string       `json:"email"`
+	PhoneNumber *string       `json:"phone_number"`
+	FullName    string        `json:"full_name"`
+	JobTitle    *string       `json:"job_title"`
+	Status      string        `json:"status"`
+	LastLoginAt *apitime.Time `json:"last_login_at"`
+	Roles       []RoleSummary `json:"roles"`
+	Permissions []string      `json:"permissions"`
+}
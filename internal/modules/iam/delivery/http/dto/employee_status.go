@@ -0,0 +1,17 @@
+package dto
+
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
+// ChangeEmployeeStatusRequest defines the API contract for suspending, reactivating or terminating an employee.
+type ChangeEmployeeStatusRequest struct {
+	Status string  `json:"status"`
+	Reason *string `json:"reason"`
+}
+
+// SecurityStatusResponse is an employee's lockout state and active session count.
+type SecurityStatusResponse struct {
+	Locked         bool          `json:"locked"`
+	LockedUntil    *apitime.Time `json:"locked_until"`
+	FailedAttempts int           `json:"failed_attempts"`
+	ActiveSessions int           `json:"active_sessions"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+
+// UpdateEmployeeRequest defines the API contract for partially updating an employee.
+// Omitted fields are left unchanged; a null or empty job_title clears it.
+type UpdateEmployeeRequest struct {
+	FullName optional.Optional[string] `json:"full_name" zog:"full_name"`
+	JobTitle optional.Optional[string] `json:"job_title" zog:"job_title"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonateRequest defines the API contract for starting an impersonation session.
+type ImpersonateRequest struct {
+	ClinicID   string `json:"clinic_id" zog:"clinic_id"`
+	EmployeeID string `json:"employee_id" zog:"employee_id"`
+}
+
+// ImpersonateResponse carries the impersonation token and when it stops being valid.
+type ImpersonateResponse struct {
+	Token      string       `json:"token"`
+	ExpiresAt  apitime.Time `json:"expires_at"`
+	EmployeeID uuid.UUID    `json:"employee_id"`
+	ClinicID   uuid.UUID    `json:"clinic_id"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
+// AcceptInviteRequest defines the API contract for activating an invited employee.
+type AcceptInviteRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// InvitationResponse carries the single-use invitation token. It is only ever returned once.
+type InvitationResponse struct {
+	Token     string       `json:"token"`
+	ExpiresAt apitime.Time `json:"expires_at"`
+}
+
+// InviteEmployeeResponse is returned after inviting an employee.
+type InviteEmployeeResponse struct {
+	Employee   any                `json:"employee"`
+	Invitation InvitationResponse `json:"invitation"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// LoginEventResponse is a single entry in an employee's login history.
+type LoginEventResponse struct {
+	ID            uuid.UUID    `json:"id"`
+	Succeeded     bool         `json:"succeeded"`
+	FailureReason *string      `json:"failure_reason,omitempty"`
+	IPAddress     *string      `json:"ip_address,omitempty"`
+	UserAgent     *string      `json:"user_agent,omitempty"`
+	OccurredAt    apitime.Time `json:"occurred_at"`
+}
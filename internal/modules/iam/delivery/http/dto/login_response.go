@@ -1,7 +1,24 @@
 package dto
 
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
 // LoginResponse defines the shape of a successful login response.
+// Employee holds the versioned employee representation selected by the X-API-Version header.
 type LoginResponse struct {
-	Token    string           `json:"token"`
-	Employee EmployeeResponse `json:"user"`
+	SessionResponse
+	Employee any `json:"user"`
+}
+
+// SessionResponse carries the access/refresh token pair issued on login and refresh.
+type SessionResponse struct {
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  apitime.Time `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt apitime.Time `json:"refresh_token_expires_at"`
+	Sandbox               bool         `json:"sandbox"`
+}
+
+// RefreshRequest defines the API contract for refreshing or revoking a session.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" zog:"refresh_token"`
 }
@@ -0,0 +1,12 @@
+package dto
+
+// ForgotPasswordRequest asks for a password reset link.
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest sets a new password using the token from a reset link.
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
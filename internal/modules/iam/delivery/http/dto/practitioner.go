@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/google/uuid"
+
+// PublicPractitionerResponse is the only shape in which employees are exposed to unauthenticated
+// callers. Contact details and employment status must never be added here.
+type PublicPractitionerResponse struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"display_name"`
+	JobTitle    *string   `json:"job_title,omitempty"`
+	PhotoURL    *string   `json:"photo_url,omitempty"`
+	Bio         *string   `json:"bio,omitempty"`
+}
+
+// PublicProfileRequest defines the API contract for changing an employee's public listing.
+type PublicProfileRequest struct {
+	IsPubliclyListed *bool   `json:"is_publicly_listed" zog:"is_publicly_listed"`
+	Bio              *string `json:"bio"`
+	PhotoURL         *string `json:"photo_url" zog:"photo_url"`
+	SortIndex        int     `json:"sort_index" zog:"sort_index"`
+}
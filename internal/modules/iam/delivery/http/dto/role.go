@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
+	"github.com/google/uuid"
+)
+
+// RoleRequest defines the API contract for creating or replacing a clinic role.
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleResponse is the full representation of a role and its permission keys.
+type RoleResponse struct {
+	ID           uuid.UUID    `json:"id"`
+	Name         string       `json:"name"`
+	Description  *string      `json:"description,omitempty"`
+	IsSystemRole bool         `json:"is_system_role"`
+	Permissions  []string     `json:"permissions"`
+	CreatedAt    apitime.Time `json:"created_at"`
+	UpdatedAt    apitime.Time `json:"updated_at"`
+}
+
+// AssignRoleRequest defines the API contract for granting a role to an employee.
+type AssignRoleRequest struct {
+	RoleID string `json:"role_id" zog:"role_id"`
+}
+
+// PermissionResponse describes a permission a role can be granted.
+type PermissionResponse struct {
+	ID          int16  `json:"id"`
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+// PermissionGroup holds the permissions of one module, e.g. "patients".
+type PermissionGroup struct {
+	Module      string               `json:"module"`
+	Permissions []PermissionResponse `json:"permissions"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+// ReassignRoleRequest defines the API contract for moving all holders of a role to another role.
+type ReassignRoleRequest struct {
+	TargetRoleID string `json:"target_role_id" zog:"target_role_id"`
+}
+
+// RoleReassignmentResponse reports how many employees were moved to the target role.
+type RoleReassignmentResponse struct {
+	Moved int `json:"moved"`
+}
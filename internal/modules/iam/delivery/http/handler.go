@@ -1,17 +1,26 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/delivery/http/dto"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/httpjson"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	z "github.com/Oudwins/zog"
-	"github.com/Oudwins/zog/zhttp"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // Handler holds the dependencies for the IAM HTTP handlers.
@@ -32,10 +41,12 @@ func (h *Handler) InviteEmployee(c *gin.Context) *apierror.APIError {
 		// reached if the authenticator middleware has already run successfully.
 		return apierror.NewInternalServer(err)
 	}
-	var req dto.InviteEmployeeRequest
-	if issues := inviteEmployeeSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"validation_errors": z.Issues.Flatten(issues)})
-		return nil
+	req, apiErr := httpjson.DecodeJSONGin[dto.InviteEmployeeRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := inviteEmployeeSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
 	}
 
 	serviceReq := iam.InviteEmployeeRequest{
@@ -45,8 +56,144 @@ func (h *Handler) InviteEmployee(c *gin.Context) *apierror.APIError {
 		JobTitle:    req.JobTitle,
 	}
 
-	employee, err := h.service.InviteEmployee(c.Request.Context(), inviterPayload.ClinicID, inviterPayload.UserID, serviceReq)
+	employee, invitation, err := h.service.InviteEmployee(c.Request.Context(), inviterPayload.ClinicID, inviterPayload.UserID, serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusCreated, dto.InviteEmployeeResponse{
+		Employee: toEmployeeResponse(c.Request.Context(), employee, canSeeContact(inviterPayload, employee)),
+		Invitation: dto.InvitationResponse{
+			Token:     invitation.Token,
+			ExpiresAt: apitime.New(invitation.ExpiresAt),
+		},
+	})
+	return nil
+}
+
+// ResendInvite issues a fresh invitation token for an employee who has not accepted yet and sends it again.
+func (h *Handler) ResendInvite(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	invitation, err := h.service.ResendInvite(c.Request.Context(), payload.ClinicID, payload.ActorID(), profileID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, dto.InvitationResponse{
+		Token:     invitation.Token,
+		ExpiresAt: apitime.New(invitation.ExpiresAt),
+	})
+	return nil
+}
+
+// AcceptInvite handles the public request for an invited employee to set their password and activate.
+func (h *Handler) AcceptInvite(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return apierror.NewInternalServer(err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.AcceptInviteRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := acceptInviteSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.AcceptInviteRequest{
+		ClinicID: clinicID,
+		Token:    req.Token,
+		Password: req.Password,
+	}
+
+	if err := h.service.AcceptInvite(c.Request.Context(), serviceReq); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// ForgotPassword sends a password reset link if the email belongs to an active employee.
+// The response is the same whether or not it does.
+func (h *Handler) ForgotPassword(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return apierror.NewInternalServer(err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.ForgotPasswordRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := forgotPasswordSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.ForgotPasswordRequest{
+		ClinicID: clinicID,
+		Email:    req.Email,
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), serviceReq); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, gin.H{"message": "If an account with this email exists, a password reset link has been sent."})
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the employee out everywhere.
+func (h *Handler) ResetPassword(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
 	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return apierror.NewInternalServer(err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.ResetPasswordRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := resetPasswordSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.ResetPasswordRequest{
+		ClinicID: clinicID,
+		Token:    req.Token,
+		Password: req.Password,
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), serviceReq); err != nil {
 		var apiErr *apierror.APIError
 		if errors.As(err, &apiErr) {
 			return apiErr
@@ -54,25 +201,38 @@ func (h *Handler) InviteEmployee(c *gin.Context) *apierror.APIError {
 		return apierror.NewInternalServer(err)
 	}
 
-	c.JSON(http.StatusCreated, toEmployeeResponse(employee))
+	httpjson.RespondNoContent(c)
 	return nil
 }
 
 // LoginEmployee handles the HTTP request for staff authentication.
 func (h *Handler) LoginEmployee(c *gin.Context) *apierror.APIError {
-	var req dto.LoginRequest
-	if issues := loginRequestSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"validation_errors": z.Issues.Flatten(issues)})
-		return nil
+	req, apiErr := httpjson.DecodeJSONGin[dto.LoginRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := loginRequestSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return apierror.NewInternalServer(err)
 	}
 
 	serviceReq := iam.LoginEmployeeRequest{
+		ClinicID: clinicID,
 		Email:    req.Email,
 		Phone:    req.Phone,
 		Password: req.Password,
+
+		ClinicSlug: middleware.GetClinicSlug(c.Request.Context()),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
 	}
 
-	token, employee, err := h.service.LoginEmployee(c.Request.Context(), serviceReq)
+	session, employee, err := h.service.LoginEmployee(c.Request.Context(), serviceReq)
 	if err != nil {
 		var apiErr *apierror.APIError
 		if errors.As(err, &apiErr) {
@@ -82,23 +242,960 @@ func (h *Handler) LoginEmployee(c *gin.Context) *apierror.APIError {
 	}
 
 	response := dto.LoginResponse{
-		Token:    token,
-		Employee: toEmployeeResponse(employee),
+		SessionResponse: toSessionResponse(session),
+		// The employee is the caller signing in, so the record is their own.
+		Employee: toEmployeeResponse(c.Request.Context(), employee, true),
+	}
+
+	httpjson.RespondData(c, http.StatusOK, response)
+	return nil
+}
+
+// RedeemActionLink exchanges a signed action link for a session and tells the client where to go.
+func (h *Handler) RedeemActionLink(c *gin.Context) *apierror.APIError {
+	req, apiErr := httpjson.DecodeJSONGin[dto.RedeemActionLinkRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := redeemActionLinkSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return apierror.NewInternalServer(err)
+	}
+
+	serviceReq := iam.RedeemActionLinkRequest{
+		ClinicID:  clinicID,
+		Token:     req.Token,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	session, employee, redirectTo, err := h.service.RedeemActionLink(c.Request.Context(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, dto.ActionLinkResponse{
+		LoginResponse: dto.LoginResponse{
+			SessionResponse: toSessionResponse(session),
+			// The link signs its own recipient in, so the record is their own.
+			Employee: toEmployeeResponse(c.Request.Context(), employee, true),
+		},
+		RedirectTo: redirectTo,
+	})
+	return nil
+}
+
+// RefreshSession exchanges a refresh token for a new token pair.
+func (h *Handler) RefreshSession(c *gin.Context) *apierror.APIError {
+	serviceReq, apiErr := bindRefreshRequest(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	session, err := h.service.RefreshSession(c.Request.Context(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toSessionResponse(session))
+	return nil
+}
+
+// RevokeSession ends the session a refresh token belongs to. It always answers 204 for well-formed requests.
+func (h *Handler) RevokeSession(c *gin.Context) *apierror.APIError {
+	serviceReq, apiErr := bindRefreshRequest(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if err := h.service.RevokeSession(c.Request.Context(), serviceReq); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// bindRefreshRequest validates the body of the refresh/revoke endpoints.
+func bindRefreshRequest(c *gin.Context) (iam.RefreshSessionRequest, *apierror.APIError) {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return iam.RefreshSessionRequest{}, apierror.NewInternalServer(err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.RefreshRequest](c)
+	if apiErr != nil {
+		return iam.RefreshSessionRequest{}, apiErr
+	}
+	if issues := refreshRequestSchema.Validate(&req); issues != nil {
+		return iam.RefreshSessionRequest{}, apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	return iam.RefreshSessionRequest{ClinicID: clinicID, RefreshToken: req.RefreshToken}, nil
+}
+
+// Impersonate handles the internal request for a platform operator to act as a clinic employee.
+func (h *Handler) Impersonate(c *gin.Context) *apierror.APIError {
+	operator, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	// The permission itself is enforced by the route; an impersonation token can never start another one.
+	if operator.IsImpersonated() {
+		return apierror.NewForbidden("Only platform administrators can impersonate employees.", nil)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.ImpersonateRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := impersonateSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.ImpersonateEmployeeRequest{
+		ClinicID:   uuid.MustParse(req.ClinicID),
+		EmployeeID: uuid.MustParse(req.EmployeeID),
+	}
+
+	token, payload, err := h.service.ImpersonateEmployee(c.Request.Context(), operator.UserID, serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusCreated, dto.ImpersonateResponse{
+		Token:      token,
+		ExpiresAt:  apitime.New(payload.ExpiresAt),
+		EmployeeID: payload.UserID,
+		ClinicID:   payload.ClinicID,
+	})
+	return nil
+}
+
+// ListAuthFailures lets platform security staff investigate rejected login attempts.
+// Supported query filters: clinic_slug, identifier, ip, reason, from, to (RFC 3339) and limit.
+func (h *Handler) ListAuthFailures(c *gin.Context) *apierror.APIError {
+	operator, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	if operator.IsImpersonated() {
+		return apierror.NewForbidden("You do not have permission to view authentication failures.", nil)
+	}
+
+	filter := model.AuthFailureFilter{
+		ClinicSlug: c.Query("clinic_slug"),
+		Identifier: c.Query("identifier"),
+		IPAddress:  c.Query("ip"),
+		Reason:     model.AuthFailureReason(strings.ToUpper(c.Query("reason"))),
+	}
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return apierror.NewBadRequest(fmt.Sprintf("The '%s' query parameter must be an RFC 3339 timestamp.", param), err)
+		}
+		*dst = &t
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return apierror.NewBadRequest("The 'limit' query parameter must be a positive integer.", err)
+		}
+		filter.Limit = limit
+	}
+
+	failures, err := h.service.ListAuthFailures(c.Request.Context(), filter)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	response := make([]dto.AuthFailureResponse, len(failures))
+	for i, f := range failures {
+		response[i] = dto.AuthFailureResponse{
+			ID:                f.ID,
+			ClinicSlug:        f.ClinicSlug,
+			IdentifierHash:    f.IdentifierHash,
+			IPAddress:         f.IPAddress,
+			UserAgent:         f.UserAgent,
+			Reason:            string(f.Reason),
+			ResolvedAt:        apitime.NewPtr(f.ResolvedAt),
+			ResolvedProfileID: f.ResolvedProfileID,
+			OccurredAt:        apitime.New(f.OccurredAt),
+		}
+	}
+
+	httpjson.RespondList(c, response, nil)
+	return nil
+}
+
+// CreateRole handles the request to create a clinic role.
+func (h *Handler) CreateRole(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.RoleRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := roleSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	role, err := h.service.CreateRole(c.Request.Context(), payload.ClinicID, iam.RoleRequest(req))
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusCreated, toRoleResponse(role))
+	return nil
+}
+
+// GetMe returns the signed-in employee with their roles and permissions. It answers 404 if the
+// employee was deleted after the token was issued.
+func (h *Handler) GetMe(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	employee, err := h.service.GetEmployee(c.Request.Context(), payload.ClinicID, payload.UserID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toMeResponse(employee))
+	return nil
+}
+
+// ListEmployees returns the clinic's employees. Contact details are included only where the
+// caller may see them. HEAD requests and GETs with a matching If-None-Match are answered from
+// the list version without loading any rows.
+func (h *Handler) ListEmployees(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	version, err := h.service.ListEmployeesVersion(c.Request.Context(), payload.ClinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+	// Which contact details are shown depends on who is asking, so the ETag does too.
+	readContact := slices.Contains(payload.Permissions, model.PermissionEmployeesReadContact)
+	if middleware.ConditionalList(c, version, payload.UserID.String(), strconv.FormatBool(readContact)) {
+		return nil
+	}
+
+	employees, err := h.service.ListEmployees(c.Request.Context(), payload.ClinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
 	}
 
-	c.JSON(http.StatusOK, response)
+	httpjson.RespondList(c, toEmployeeResponses(c.Request.Context(), payload, employees), nil)
 	return nil
 }
 
-// toEmployeeResponse maps the internal employee and its nested profile to the public DTO.
-func toEmployeeResponse(employee *model.Employee) dto.EmployeeResponse {
-	return dto.EmployeeResponse{
-		ID:          employee.ProfileID,
-		ClinicID:    employee.ClinicID,
-		Email:       employee.Profile.Email,
-		PhoneNumber: employee.Profile.PhoneNumber,
-		FullName:    employee.Profile.FullName,
-		JobTitle:    employee.JobTitle,
-		Status:      string(employee.Status),
+// GetEmployee returns a single employee, with contact details if the caller may see them.
+func (h *Handler) GetEmployee(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	employeeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
 	}
+
+	employee, err := h.service.GetEmployee(c.Request.Context(), payload.ClinicID, employeeID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toEmployeeResponse(c.Request.Context(), employee, canSeeContact(payload, employee)))
+	return nil
+}
+
+// ListPermissions returns the grantable permissions grouped by module, for role editors.
+func (h *Handler) ListPermissions(c *gin.Context) *apierror.APIError {
+	permissions, err := h.service.ListPermissions(c.Request.Context())
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondList(c, toPermissionGroups(permissions), nil)
+	return nil
+}
+
+// ListRoles returns the system roles and the clinic's own roles.
+func (h *Handler) ListRoles(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	roles, err := h.service.ListRoles(c.Request.Context(), payload.ClinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondList(c, toRoleResponses(roles), nil)
+	return nil
+}
+
+// GetRole returns a single role.
+func (h *Handler) GetRole(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid role ID format.", err)
+	}
+
+	role, err := h.service.GetRole(c.Request.Context(), payload.ClinicID, roleID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toRoleResponse(role))
+	return nil
+}
+
+// UpdateRole replaces a clinic role's name, description and permission set.
+func (h *Handler) UpdateRole(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid role ID format.", err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.RoleRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := roleSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	role, err := h.service.UpdateRole(c.Request.Context(), payload.ClinicID, roleID, iam.RoleRequest(req))
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toRoleResponse(role))
+	return nil
+}
+
+// ReassignRole moves every employee holding the role in the URL to the target role.
+func (h *Handler) ReassignRole(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid role ID format.", err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.ReassignRoleRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := reassignRoleSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.ReassignRoleRequest{
+		RoleID:       roleID,
+		TargetRoleID: uuid.MustParse(req.TargetRoleID),
+	}
+
+	moved, err := h.service.ReassignRole(c.Request.Context(), payload.ClinicID, payload.ActorID(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, dto.RoleReassignmentResponse{Moved: moved})
+	return nil
+}
+
+// DeleteRole deletes a clinic role. Holders must be moved via the `reassign_to` query parameter.
+func (h *Handler) DeleteRole(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid role ID format.", err)
+	}
+
+	serviceReq := iam.DeleteRoleRequest{RoleID: roleID}
+	if raw := c.Query("reassign_to"); raw != "" {
+		target, err := uuid.Parse(raw)
+		if err != nil {
+			return apierror.NewBadRequest("The 'reassign_to' query parameter must be a valid role ID.", err)
+		}
+		serviceReq.ReassignTo = &target
+	}
+
+	moved, err := h.service.DeleteRole(c.Request.Context(), payload.ClinicID, payload.ActorID(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, dto.RoleReassignmentResponse{Moved: moved})
+	return nil
+}
+
+// Logout revokes the access token used to make this request.
+func (h *Handler) Logout(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	if err := h.service.Logout(c.Request.Context(), payload); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// RevokeEmployeeSessions signs an employee out everywhere, e.g. after termination.
+func (h *Handler) RevokeEmployeeSessions(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	if err := h.service.RevokeAllSessionsForEmployee(c.Request.Context(), payload.ClinicID, profileID); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// GetEmployeeSecurityStatus shows whether an employee is locked out and how many sessions they have.
+func (h *Handler) GetEmployeeSecurityStatus(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	status, err := h.service.GetSecurityStatus(c.Request.Context(), payload.ClinicID, profileID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, dto.SecurityStatusResponse{
+		Locked:         status.Locked,
+		LockedUntil:    apitime.NewPtr(status.LockedUntil),
+		FailedAttempts: status.FailedAttempts,
+		ActiveSessions: status.ActiveSessions,
+	})
+	return nil
+}
+
+// UnlockEmployee lifts an employee's login lockout.
+func (h *Handler) UnlockEmployee(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	if err := h.service.UnlockEmployee(c.Request.Context(), payload.ClinicID, payload.ActorID(), profileID); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// ListEmployeeLogins returns an employee's recent login attempts. Supports an optional limit query parameter.
+func (h *Handler) ListEmployeeLogins(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return apierror.NewBadRequest("The 'limit' query parameter must be a positive integer.", err)
+		}
+	}
+
+	events, err := h.service.ListLoginEvents(c.Request.Context(), payload.ClinicID, profileID, limit)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	response := make([]dto.LoginEventResponse, len(events))
+	for i, e := range events {
+		response[i] = toLoginEventResponse(e)
+	}
+
+	httpjson.RespondList(c, response, nil)
+	return nil
+}
+
+// ListPublicPractitioners returns the practitioners a clinic has chosen to publish.
+func (h *Handler) ListPublicPractitioners(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return apierror.NewInternalServer(err)
+	}
+
+	practitioners, err := h.service.ListPublicPractitioners(c.Request.Context(), clinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	resp := make([]dto.PublicPractitionerResponse, len(practitioners))
+	for i, p := range practitioners {
+		resp[i] = toPublicPractitionerResponse(p)
+	}
+	c.Header("Cache-Control", "public, max-age=60")
+	httpjson.RespondList(c, resp, nil)
+	return nil
+}
+
+// UpdatePublicProfile changes whether and how an employee appears in the public practitioner directory.
+func (h *Handler) UpdatePublicProfile(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.PublicProfileRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := publicProfileSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	profile := model.PublicProfile{
+		IsPubliclyListed: *req.IsPubliclyListed,
+		Bio:              req.Bio,
+		PhotoURL:         req.PhotoURL,
+		SortIndex:        req.SortIndex,
+	}
+	if err := h.service.UpdatePublicProfile(c.Request.Context(), payload.ClinicID, profileID, profile); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// AssignEmployeeRole grants a role to an employee and returns the employee's roles.
+func (h *Handler) AssignEmployeeRole(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	employeeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.AssignRoleRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := assignRoleSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.EmployeeRoleRequest{
+		EmployeeID: employeeID,
+		RoleID:     uuid.MustParse(req.RoleID),
+	}
+	roles, err := h.service.AssignRole(c.Request.Context(), payload.ClinicID, payload.ActorID(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondList(c, toRoleResponses(roles), nil)
+	return nil
+}
+
+// RemoveEmployeeRole takes a role away from an employee and returns the employee's remaining roles.
+func (h *Handler) RemoveEmployeeRole(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	employeeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+	roleID, err := uuid.Parse(c.Param("roleId"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid role ID format.", err)
+	}
+
+	serviceReq := iam.EmployeeRoleRequest{EmployeeID: employeeID, RoleID: roleID}
+	roles, err := h.service.RemoveRole(c.Request.Context(), payload.ClinicID, payload.ActorID(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondList(c, toRoleResponses(roles), nil)
+	return nil
+}
+
+// ChangeEmployeeStatus suspends, reactivates or terminates an employee.
+func (h *Handler) ChangeEmployeeStatus(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	employeeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.ChangeEmployeeStatusRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := changeEmployeeStatusSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.ChangeEmployeeStatusRequest{
+		EmployeeID: employeeID,
+		Status:     model.EmployeeStatus(req.Status),
+		Reason:     req.Reason,
+	}
+	if err := h.service.ChangeEmployeeStatus(c.Request.Context(), payload.ClinicID, payload.ActorID(), serviceReq); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// UpdateEmployee partially updates an employee. Omitted fields are left unchanged,
+// and a null or empty job_title clears it.
+func (h *Handler) UpdateEmployee(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	employeeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	// Decoded with encoding/json rather than zog so that omitted and null fields stay distinguishable.
+	req, apiErr := httpjson.DecodeJSONGin[dto.UpdateEmployeeRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := updateEmployeeSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.UpdateEmployeeRequest{
+		EmployeeID: employeeID,
+		FullName:   optional.BlankAsNull(req.FullName),
+		JobTitle:   optional.BlankAsNull(req.JobTitle),
+	}
+	if err := h.service.UpdateEmployee(c.Request.Context(), payload.ClinicID, serviceReq); err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// emailChangeTarget parses the employee ID and checks that the caller may manage its email:
+// employees may change their own address, anyone else needs employees.update.
+func emailChangeTarget(c *gin.Context) (clinicID, actorID, employeeID uuid.UUID, apiErr *apierror.APIError) {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, apierror.NewInternalServer(err)
+	}
+
+	employeeID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, apierror.NewBadRequest("Invalid employee ID format.", err)
+	}
+
+	if employeeID != payload.UserID && !slices.Contains(payload.Permissions, model.PermissionEmployeesUpdate) {
+		return uuid.Nil, uuid.Nil, uuid.Nil, apierror.NewForbidden("You do not have permission to change this employee's email.", nil)
+	}
+	return payload.ClinicID, payload.ActorID(), employeeID, nil
+}
+
+// RequestEmailChange starts a two-step email change for an employee.
+func (h *Handler) RequestEmailChange(c *gin.Context) *apierror.APIError {
+	clinicID, actorID, employeeID, apiErr := emailChangeTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.EmailChangeRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := emailChangeSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := iam.EmailChangeRequest{
+		EmployeeID: employeeID,
+		NewEmail:   req.NewEmail,
+	}
+
+	change, err := h.service.RequestEmailChange(c.Request.Context(), clinicID, actorID, serviceReq)
+	if err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusAccepted, toEmailChangeResponse(change))
+	return nil
+}
+
+// GetPendingEmailChange returns the employee's open email change request, if any.
+func (h *Handler) GetPendingEmailChange(c *gin.Context) *apierror.APIError {
+	clinicID, _, employeeID, apiErr := emailChangeTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	change, err := h.service.GetPendingEmailChange(c.Request.Context(), clinicID, employeeID)
+	if err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toEmailChangeResponse(change))
+	return nil
+}
+
+// CancelEmailChange withdraws the employee's open email change request.
+func (h *Handler) CancelEmailChange(c *gin.Context) *apierror.APIError {
+	clinicID, actorID, employeeID, apiErr := emailChangeTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if err := h.service.CancelEmailChange(c.Request.Context(), clinicID, actorID, employeeID); err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// VerifyEmailChange applies a pending email change using the token sent to the new address.
+func (h *Handler) VerifyEmailChange(c *gin.Context) *apierror.APIError {
+	return h.consumeEmailChangeToken(c, h.service.VerifyEmailChange)
+}
+
+// UndoEmailChange reverts a verified email change using the token sent to the old address.
+func (h *Handler) UndoEmailChange(c *gin.Context) *apierror.APIError {
+	return h.consumeEmailChangeToken(c, h.service.UndoEmailChange)
+}
+
+// consumeEmailChangeToken runs one of the public, token-authenticated email change steps.
+func (h *Handler) consumeEmailChangeToken(c *gin.Context, consume func(ctx context.Context, clinicID uuid.UUID, token string) error) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		// The ResolveClinic middleware must run before this handler.
+		return apierror.NewInternalServer(err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.EmailChangeTokenRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := emailChangeTokenSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	if err := consume(c.Request.Context(), clinicID, req.Token); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
 }
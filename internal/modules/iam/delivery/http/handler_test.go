@@ -0,0 +1,333 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubService answers the handlers' reads from one clinic's employees held in memory.
+type stubService struct {
+	iam.Service
+	employees   []model.Employee
+	version     database.ListVersion
+	permissions []model.Permission
+}
+
+func (s *stubService) ListPermissions(context.Context) ([]model.Permission, error) {
+	return s.permissions, nil
+}
+
+func (s *stubService) ListEmployees(context.Context, uuid.UUID) ([]model.Employee, error) {
+	return s.employees, nil
+}
+
+func (s *stubService) ListEmployeesVersion(context.Context, uuid.UUID) (database.ListVersion, error) {
+	return s.version, nil
+}
+
+func (s *stubService) GetEmployee(_ context.Context, _, profileID uuid.UUID) (*model.Employee, error) {
+	for i := range s.employees {
+		if s.employees[i].ProfileID == profileID {
+			return &s.employees[i], nil
+		}
+	}
+	return nil, apierror.NewNotFound("Employee", nil)
+}
+
+// staffAPI serves the protected IAM routes behind the real Authenticator, and signs tokens for it.
+type staffAPI struct {
+	engine *gin.Engine
+	tokens *security.PasetoManager
+}
+
+func newStaffAPI(t *testing.T, svc iam.Service) *staffAPI {
+	t.Helper()
+	tokens, err := security.NewPasetoManager(config.SecurityConfig{
+		TokenMode:     security.TokenModeLocal,
+		PasetoKey:     "0123456789abcdef0123456789abcdef",
+		TokenIssuer:   "mastara-test",
+		TokenAudience: "mastara-api",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	NewHandler(svc).RegisterProtectedRoutes(engine.Group("/api/v1", middleware.Authenticator(tokens, security.NewMemoryDenylist())))
+	return &staffAPI{engine: engine, tokens: tokens}
+}
+
+// do sends the request as userID holding permissions.
+func (a *staffAPI) do(t *testing.T, req *http.Request, userID, clinicID uuid.UUID, permissions ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := security.NewAuthPayload(userID, clinicID, nil, permissions, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := a.tokens.CreateToken(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	a.engine.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeData returns the data member of a response envelope.
+func decodeData[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var body struct {
+		Data T `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode %s: %v", rec.Body, err)
+	}
+	return body.Data
+}
+
+// employeeDirectory is a clinic with two employees, each with contact details on file.
+func employeeDirectory() (clinicID uuid.UUID, svc *stubService) {
+	clinicID = uuid.New()
+	hala := goldenEmployee()
+	hala.ProfileID, hala.ClinicID = uuid.New(), clinicID
+	omar := goldenEmployee()
+	omar.ProfileID, omar.ClinicID, omar.Profile.FullName = uuid.New(), clinicID, "Omar Fathy"
+	return clinicID, &stubService{employees: []model.Employee{*hala, *omar}, version: database.ListVersion{Count: 2}}
+}
+
+// contactFields are the employee fields shown only to callers allowed to see contact details.
+var contactFields = []string{"email", "phone_number", "last_login_at", "invited_by"}
+
+func TestEmployeeContactDetailsFollowThePermission(t *testing.T) {
+	clinicID, svc := employeeDirectory()
+	api := newStaffAPI(t, svc)
+	self, colleague := svc.employees[0].ProfileID, svc.employees[1].ProfileID
+
+	tests := []struct {
+		name        string
+		permissions []string
+		// contact says, by employee, whether their contact details are shown.
+		contact map[uuid.UUID]bool
+	}{
+		{"employees.read", []string{model.PermissionEmployeesRead}, map[uuid.UUID]bool{self: true, colleague: false}},
+		{"employees.read_contact", []string{model.PermissionEmployeesRead, model.PermissionEmployeesReadContact}, map[uuid.UUID]bool{self: true, colleague: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(employee map[string]any) {
+				t.Helper()
+				id := uuid.MustParse(employee["id"].(string))
+				for _, field := range []string{"full_name", "job_title", "status"} {
+					if _, ok := employee[field]; !ok {
+						t.Errorf("employee %s has no %s, want it shown to anyone with %s", id, field, model.PermissionEmployeesRead)
+					}
+				}
+				for _, field := range contactFields {
+					if _, ok := employee[field]; ok != tt.contact[id] {
+						t.Errorf("employee %s: %s shown = %t, want %t", id, field, ok, tt.contact[id])
+					}
+				}
+			}
+
+			rec := api.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/employees/", nil), self, clinicID, tt.permissions...)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("list: status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			employees := decodeData[[]map[string]any](t, rec)
+			if len(employees) != 2 {
+				t.Fatalf("list returned %d employees, want 2", len(employees))
+			}
+			for _, employee := range employees {
+				check(employee)
+			}
+
+			for _, id := range []uuid.UUID{self, colleague} {
+				rec := api.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/employees/"+id.String(), nil), self, clinicID, tt.permissions...)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("get: status = %d, want 200: %s", rec.Code, rec.Body)
+				}
+				check(decodeData[map[string]any](t, rec))
+			}
+		})
+	}
+}
+
+func TestEmployeeListETagDependsOnContactVisibility(t *testing.T) {
+	clinicID, svc := employeeDirectory()
+	api := newStaffAPI(t, svc)
+	caller := svc.employees[0].ProfileID
+
+	etag := func(permissions ...string) string {
+		t.Helper()
+		rec := api.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/employees/", nil), caller, clinicID, permissions...)
+		return rec.Header().Get("ETag")
+	}
+	redacted := etag(model.PermissionEmployeesRead)
+	full := etag(model.PermissionEmployeesRead, model.PermissionEmployeesReadContact)
+	if redacted == "" || redacted == full {
+		t.Errorf("ETags = %q without and %q with contact details, want two different tags", redacted, full)
+	}
+}
+
+func TestGetMe(t *testing.T) {
+	clinicID, svc := employeeDirectory()
+	me := &svc.employees[0]
+	me.Roles = []model.Role{
+		{Name: "Doctor", Permissions: []model.Permission{{PermissionKey: "patients.read"}, {PermissionKey: "appointments.read"}}},
+		{Name: "Front Desk", Permissions: []model.Permission{{PermissionKey: "patients.read"}, {PermissionKey: "appointments.create"}}},
+	}
+	api := newStaffAPI(t, svc)
+
+	// No permission is needed to read one's own record, contact details included.
+	rec := api.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/me", nil), me.ProfileID, clinicID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	got := decodeData[struct {
+		ID          uuid.UUID `json:"id"`
+		Email       *string   `json:"email"`
+		PhoneNumber *string   `json:"phone_number"`
+		FullName    string    `json:"full_name"`
+		JobTitle    *string   `json:"job_title"`
+		Status      string    `json:"status"`
+		Roles       []struct {
+			Name string `json:"name"`
+		} `json:"roles"`
+		Permissions []string `json:"permissions"`
+	}](t, rec)
+	if got.ID != me.ProfileID || got.FullName != me.Profile.FullName || got.Status != string(me.Status) || got.JobTitle == nil {
+		t.Errorf("me = %+v, want the caller's own record", got)
+	}
+	if got.Email == nil || *got.Email != *me.Profile.Email || got.PhoneNumber == nil || *got.PhoneNumber != *me.Profile.PhoneNumber {
+		t.Errorf("contact = %v %v, want the caller's own email and phone", got.Email, got.PhoneNumber)
+	}
+	var roles []string
+	for _, role := range got.Roles {
+		roles = append(roles, role.Name)
+	}
+	if !slices.Equal(roles, []string{"Doctor", "Front Desk"}) {
+		t.Errorf("roles = %v, want [Doctor Front Desk]", roles)
+	}
+	if want := []string{"appointments.create", "appointments.read", "patients.read"}; !slices.Equal(got.Permissions, want) {
+		t.Errorf("permissions = %v, want %v flattened from the roles", got.Permissions, want)
+	}
+
+	// An employee deleted after their token was issued gets a 404, not a 500.
+	rec = api.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/me", nil), uuid.New(), clinicID)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("deleted employee: status = %d, want 404: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestEmployeeListHeadAndConditionalGet(t *testing.T) {
+	clinicID, svc := employeeDirectory()
+	api := newStaffAPI(t, svc)
+	caller := svc.employees[0].ProfileID
+	list := func(method, ifNoneMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/v1/employees/", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		return api.do(t, req, caller, clinicID, model.PermissionEmployeesRead)
+	}
+
+	get, head := list(http.MethodGet, ""), list(http.MethodHead, "")
+	if get.Code != http.StatusOK || head.Code != http.StatusOK {
+		t.Fatalf("GET %d, HEAD %d; want 200 for both", get.Code, head.Code)
+	}
+	for _, header := range []string{"ETag", middleware.TotalCountHeader} {
+		if get.Header().Get(header) == "" || get.Header().Get(header) != head.Header().Get(header) {
+			t.Errorf("%s: GET %q, HEAD %q; want the same value", header, get.Header().Get(header), head.Header().Get(header))
+		}
+	}
+	if got := get.Header().Get(middleware.TotalCountHeader); got != "2" {
+		t.Errorf("%s = %s, want 2", middleware.TotalCountHeader, got)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("HEAD body = %s, want none", head.Body)
+	}
+	etag := get.Header().Get("ETag")
+
+	if rec := list(http.MethodGet, etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("GET with the current ETag: status %d, body %q; want an empty 304", rec.Code, rec.Body)
+	}
+
+	// A row changes: the old ETag no longer matches and the list is sent again.
+	changed := time.Now()
+	svc.version.LastUpdatedAt = &changed
+	rec := list(http.MethodGet, etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("GET with a stale ETag: status %d, ETag %q; want 200 with a new ETag", rec.Code, rec.Header().Get("ETag"))
+	}
+	if employees := decodeData[[]map[string]any](t, rec); len(employees) != 2 {
+		t.Errorf("GET after the change returned %d employees, want 2", len(employees))
+	}
+}
+
+func TestListPermissionsGroupsByModule(t *testing.T) {
+	svc := &stubService{permissions: []model.Permission{
+		{ID: 21, PermissionKey: "appointments.read", Description: "View the appointment calendar."},
+		{ID: 1, PermissionKey: "employees.invite", Description: "Invite new staff members to the clinic."},
+		{ID: 2, PermissionKey: "employees.read", Description: "See colleagues' names, job titles and status."},
+		{ID: 30, PermissionKey: "finance.invoice.create", Description: "Issue invoices."},
+	}}
+	api := newStaffAPI(t, svc)
+	clinicID, caller := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name        string
+		permissions []string
+		want        int
+	}{
+		{"roles.create", []string{model.PermissionRolesCreate}, http.StatusOK},
+		{"roles.update", []string{model.PermissionRolesUpdate}, http.StatusOK},
+		{"roles.read only", []string{model.PermissionRolesRead}, http.StatusForbidden},
+		{"no permissions", nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/permissions", nil), caller, clinicID, tt.permissions...)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+
+	rec := api.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/permissions", nil), caller, clinicID, model.PermissionRolesUpdate)
+	type permission struct {
+		ID          int16  `json:"id"`
+		Key         string `json:"key"`
+		Description string `json:"description"`
+	}
+	groups := decodeData[[]struct {
+		Module      string       `json:"module"`
+		Permissions []permission `json:"permissions"`
+	}](t, rec)
+	var modules []string
+	for _, g := range groups {
+		modules = append(modules, g.Module)
+	}
+	if want := []string{"appointments", "employees", "finance"}; !slices.Equal(modules, want) {
+		t.Fatalf("modules = %v, want %v", modules, want)
+	}
+	if got := groups[1].Permissions; len(got) != 2 || got[0] != (permission{1, "employees.invite", "Invite new staff members to the clinic."}) || got[1].Key != "employees.read" {
+		t.Errorf("employees group = %+v, want employees.invite then employees.read with ids and descriptions", got)
+	}
+}
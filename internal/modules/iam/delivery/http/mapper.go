@@ -0,0 +1,205 @@
+package http
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+)
+
+// employeeMappers holds one response mapper per supported API version.
+// Old mappers must never change; new fields go into a new version.
+var employeeMappers = map[string]func(e *model.Employee, withContact bool) any{
+	middleware.APIVersion1: func(e *model.Employee, withContact bool) any { return toEmployeeResponseV1(e, withContact) },
+	middleware.APIVersion2: func(e *model.Employee, withContact bool) any { return toEmployeeResponseV2(e, withContact) },
+}
+
+// toEmployeeResponse maps the employee to the shape of the API version negotiated for the request.
+// Contact details are included only when withContact is set; see canSeeContact.
+func toEmployeeResponse(ctx context.Context, employee *model.Employee, withContact bool) any {
+	mapper, ok := employeeMappers[middleware.GetAPIVersion(ctx)]
+	if !ok {
+		mapper = employeeMappers[middleware.LatestAPIVersion]
+	}
+	return mapper(employee, withContact)
+}
+
+// toEmployeeResponses maps a list of employees, deciding contact visibility per employee.
+func toEmployeeResponses(ctx context.Context, payload *security.AuthPayload, employees []model.Employee) []any {
+	response := make([]any, len(employees))
+	for i := range employees {
+		response[i] = toEmployeeResponse(ctx, &employees[i], canSeeContact(payload, &employees[i]))
+	}
+	return response
+}
+
+// canSeeContact reports whether the caller may see an employee's contact details: always on
+// their own record, and on anyone else's only with employees.read_contact.
+func canSeeContact(payload *security.AuthPayload, employee *model.Employee) bool {
+	return payload.UserID == employee.ProfileID || slices.Contains(payload.Permissions, model.PermissionEmployeesReadContact)
+}
+
+// toEmployeeResponseV1 maps the internal employee and its nested profile to the version 1 DTO.
+func toEmployeeResponseV1(employee *model.Employee, withContact bool) dto.EmployeeResponse {
+	if !withContact {
+		return dto.EmployeeResponse{
+			ID:       employee.ProfileID,
+			ClinicID: employee.ClinicID,
+			FullName: employee.Profile.FullName,
+			JobTitle: employee.JobTitle,
+			Status:   string(employee.Status),
+		}
+	}
+	return dto.EmployeeResponse{
+		ID:          employee.ProfileID,
+		ClinicID:    employee.ClinicID,
+		Email:       employee.Profile.Email,
+		PhoneNumber: employee.Profile.PhoneNumber,
+		FullName:    employee.Profile.FullName,
+		JobTitle:    employee.JobTitle,
+		Status:      string(employee.Status),
+	}
+}
+
+// toEmployeeResponseV2 maps the employee, including its roles, to the version 2 DTO.
+func toEmployeeResponseV2(employee *model.Employee, withContact bool) dto.EmployeeResponseV2 {
+	response := dto.EmployeeResponseV2{
+		ID:       employee.ProfileID,
+		ClinicID: employee.ClinicID,
+		FullName: employee.Profile.FullName,
+		JobTitle: employee.JobTitle,
+		Status:   string(employee.Status),
+		Roles:    toRoleSummaries(employee.Roles),
+	}
+	if withContact {
+		response.Email = employee.Profile.Email
+		response.PhoneNumber = employee.Profile.PhoneNumber
+		response.LastLoginAt = apitime.NewPtr(employee.LastLoginAt)
+		response.InvitedBy = employee.InvitedByID
+	}
+	return response
+}
+
+// toMeResponse maps the signed-in employee, with their roles and granted permissions.
+func toMeResponse(employee *model.Employee) dto.MeResponse {
+	return dto.MeResponse{
+		ID:          employee.ProfileID,
+		ClinicID:    employee.ClinicID,
+		Email:       employee.Profile.Email,
+		PhoneNumber: employee.Profile.PhoneNumber,
+		FullName:    employee.Profile.FullName,
+		JobTitle:    employee.JobTitle,
+		Status:      string(employee.Status),
+		LastLoginAt: apitime.NewPtr(employee.LastLoginAt),
+		Roles:       toRoleSummaries(employee.Roles),
+		Permissions: employee.PermissionKeys(),
+	}
+}
+
+func toRoleSummaries(roles []model.Role) []dto.RoleSummary {
+	summaries := make([]dto.RoleSummary, len(roles))
+	for i, role := range roles {
+		summaries[i] = dto.RoleSummary{ID: role.ID, Name: role.Name}
+	}
+	return summaries
+}
+
+// toSessionResponse maps an issued token pair to its API representation.
+func toSessionResponse(session *iam.Session) dto.SessionResponse {
+	return dto.SessionResponse{
+		AccessToken:           session.AccessToken,
+		AccessTokenExpiresAt:  apitime.New(session.AccessTokenExpiresAt),
+		RefreshToken:          session.RefreshToken,
+		RefreshTokenExpiresAt: apitime.New(session.RefreshTokenExpiresAt),
+		Sandbox:               session.Sandbox,
+	}
+}
+
+// toRoleResponse maps a role to its API representation.
+func toRoleResponse(role *model.Role) dto.RoleResponse {
+	permissions := make([]string, len(role.Permissions))
+	for i, p := range role.Permissions {
+		permissions[i] = p.PermissionKey
+	}
+	return dto.RoleResponse{
+		ID:           role.ID,
+		Name:         role.Name,
+		Description:  role.Description,
+		IsSystemRole: role.IsSystemRole,
+		Permissions:  permissions,
+		CreatedAt:    apitime.New(role.CreatedAt),
+		UpdatedAt:    apitime.New(role.UpdatedAt),
+	}
+}
+
+// toRoleResponses maps a list of roles to their API representation.
+func toRoleResponses(roles []model.Role) []dto.RoleResponse {
+	response := make([]dto.RoleResponse, len(roles))
+	for i := range roles {
+		response[i] = toRoleResponse(&roles[i])
+	}
+	return response
+}
+
+// toPermissionGroups groups key-ordered permissions by module, the part of the key before the first dot.
+func toPermissionGroups(permissions []model.Permission) []dto.PermissionGroup {
+	groups := []dto.PermissionGroup{}
+	for _, p := range permissions {
+		module, _, _ := strings.Cut(p.PermissionKey, ".")
+		if len(groups) == 0 || groups[len(groups)-1].Module != module {
+			groups = append(groups, dto.PermissionGroup{Module: module, Permissions: []dto.PermissionResponse{}})
+		}
+		last := &groups[len(groups)-1]
+		last.Permissions = append(last.Permissions, dto.PermissionResponse{
+			ID:          p.ID,
+			Key:         p.PermissionKey,
+			Description: p.Description,
+		})
+	}
+	return groups
+}
+
+// toPublicPractitionerResponse maps a practitioner to the public directory representation.
+func toPublicPractitionerResponse(p model.Practitioner) dto.PublicPractitionerResponse {
+	return dto.PublicPractitionerResponse{
+		ID:          p.ID,
+		DisplayName: p.DisplayName,
+		JobTitle:    p.JobTitle,
+		PhotoURL:    p.PhotoURL,
+		Bio:         p.Bio,
+	}
+}
+
+// toLoginEventResponse maps a login event to its API representation.
+func toLoginEventResponse(e model.LoginEvent) dto.LoginEventResponse {
+	var reason *string
+	if e.FailureReason != nil {
+		r := string(*e.FailureReason)
+		reason = &r
+	}
+	return dto.LoginEventResponse{
+		ID:            e.ID,
+		Succeeded:     e.Succeeded,
+		FailureReason: reason,
+		IPAddress:     e.IPAddress,
+		UserAgent:     e.UserAgent,
+		OccurredAt:    apitime.New(e.OccurredAt),
+	}
+}
+
+// toEmailChangeResponse maps a pending email change to its API representation.
+func toEmailChangeResponse(c *model.EmailChange) dto.EmailChangeResponse {
+	return dto.EmailChangeResponse{
+		ID:          c.ID,
+		NewEmail:    c.NewEmail,
+		RequestedBy: c.RequestedBy,
+		ExpiresAt:   apitime.New(c.VerifyExpiresAt),
+		CreatedAt:   apitime.New(c.CreatedAt),
+	}
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/golden"
+	"github.com/google/uuid"
+)
+
+// goldenEmployee sets every field an employee response can carry.
+func goldenEmployee() *model.Employee {
+	phone, email, jobTitle := "+201098765432", "hala@example.com", "Dentist"
+	lastLogin := time.Date(2025, 3, 2, 8, 15, 30, 999999999, time.UTC)
+	invitedBy := uuid.MustParse("0190b5a0-0000-7000-8000-0000000000a1")
+	clinicID := uuid.MustParse("0190b5a0-0000-7000-8000-0000000000c1")
+	return &model.Employee{
+		ProfileID:   uuid.MustParse("0190b5a0-0000-7000-8000-000000000002"),
+		ClinicID:    clinicID,
+		JobTitle:    &jobTitle,
+		Status:      model.EmployeeStatusActive,
+		LastLoginAt: &lastLogin,
+		InvitedByID: &invitedBy,
+		Profile: model.Profile{
+			FullName:    "Dr. Hala Samir",
+			PhoneNumber: &phone,
+			Email:       &email,
+		},
+		Roles: []model.Role{{ID: uuid.MustParse("0190b5a0-0000-7000-8000-0000000000b1"), Name: "Doctor"}},
+	}
+}
+
+// TestEmployeeResponseShapes pins the JSON of every API version's employee shape, with and
+// without contact details. A version's golden files only change when that version is
+// deliberately changed.
+func TestEmployeeResponseShapes(t *testing.T) {
+	for _, version := range slices.Sorted(maps.Keys(employeeMappers)) {
+		for _, withContact := range []bool{true, false} {
+			name := "employee_response.v" + version + ".json"
+			if !withContact {
+				name = "employee_response.v" + version + ".without_contact.json"
+			}
+			t.Run(name, func(t *testing.T) {
+				golden.AssertJSON(t, name, employeeMappers[version](goldenEmployee(), withContact))
+			})
+		}
+	}
+}
+
+// TestPublicPractitionerResponseCarriesNoPrivateFields checks the public directory shape, filled
+// in completely, holds only the fields a patient may see: no email, phone or status.
+func TestPublicPractitionerResponseCarriesNoPrivateFields(t *testing.T) {
+	bio, photo, jobTitle := "Twenty years of family dentistry.", "https://cdn.example.com/hala.jpg", "Dentist"
+	response := toPublicPractitionerResponse(model.Practitioner{
+		ID:          uuid.MustParse("0190b5a0-0000-7000-8000-000000000002"),
+		DisplayName: "Dr. Hala Samir",
+		JobTitle:    &jobTitle,
+		PhotoURL:    &photo,
+		Bio:         &bio,
+	})
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	got := slices.Sorted(maps.Keys(fields))
+	want := []string{"bio", "display_name", "id", "job_title", "photo_url"}
+	if !slices.Equal(got, want) {
+		t.Errorf("public practitioner fields = %v, want exactly %v", got, want)
+	}
+	for _, private := range []string{"email", "phone_number", "status"} {
+		if _, ok := fields[private]; ok {
+			t.Errorf("public practitioner response has %q: %s", private, body)
+		}
+	}
+}
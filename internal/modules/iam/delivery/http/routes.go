@@ -2,26 +2,103 @@ package http
 
 import (
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
 	"github.com/gin-gonic/gin"
 )
 
+// Name identifies the IAM module in the router's startup log.
+func (h *Handler) Name() string {
+	return "iam"
+}
+
 // RegisterPublicRoutes sets up the public-facing routes for the IAM module (e.g., login).
 func (h *Handler) RegisterPublicRoutes(router *gin.RouterGroup) {
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/login", middleware.ErrorHandler(h.LoginEmployee))
-		// The "Accept Invite" and "Set Password" routes would also be public.
-		// authGroup.POST("/accept-invite", middleware.ErrorHandler(h.AcceptInvite))
+		authGroup.POST("/refresh", middleware.ErrorHandler(h.RefreshSession))
+		authGroup.POST("/revoke", middleware.ErrorHandler(h.RevokeSession))
+		authGroup.POST("/accept-invite", middleware.ErrorHandler(h.AcceptInvite))
+		authGroup.POST("/forgot-password", middleware.ErrorHandler(h.ForgotPassword))
+		authGroup.POST("/reset-password", middleware.ErrorHandler(h.ResetPassword))
+		authGroup.POST("/verify-email", middleware.ErrorHandler(h.VerifyEmailChange))
+		authGroup.POST("/undo-email-change", middleware.ErrorHandler(h.UndoEmailChange))
+		// POST /public/auth/action-link - Exchange a signed link from a notification email for a session.
+		// It is a POST so mail scanners that prefetch links cannot burn the single-use token.
+		authGroup.POST("/action-link", middleware.ErrorHandler(h.RedeemActionLink))
 	}
+
+	// GET /public/clinics/:slug/practitioners - Public practitioner directory for the booking widget.
+	router.GET("/clinics/:slug/practitioners", middleware.ErrorHandler(h.ListPublicPractitioners))
 }
 
 // RegisterProtectedRoutes sets up the protected, staff-only routes for the IAM module.
 func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
 	// All routes in this group are protected by the Authenticator middleware.
+	// POST /api/v1/auth/logout - Revoke the caller's access token.
+	router.POST("/auth/logout", middleware.ErrorHandler(h.Logout))
+	// GET /api/v1/me - The signed-in employee with their role names and permission keys.
+	router.GET("/me", middleware.ErrorHandler(h.GetMe))
+
 	employeesGroup := router.Group("/employees")
 	{
 		// POST /api/v1/employees/invite - Invite a new staff member.
-		employeesGroup.POST("/invite", middleware.ErrorHandler(h.InviteEmployee))
-		// Other employee management routes (GET /, GET /:id, PUT /:id) would go here.
+		employeesGroup.POST("/invite", middleware.RequirePermission(model.PermissionEmployeesInvite), middleware.ErrorHandler(h.InviteEmployee))
+		// POST /api/v1/employees/:id/resend-invite - Replace an unaccepted invitation and send it again.
+		employeesGroup.POST("/:id/resend-invite", middleware.RequirePermission(model.PermissionEmployeesInvite), middleware.ErrorHandler(h.ResendInvite))
+		// POST /api/v1/employees/:id/revoke-sessions - Sign an employee out of every session.
+		employeesGroup.POST("/:id/revoke-sessions", middleware.RequirePermission(model.PermissionEmployeesDeactivate), middleware.ErrorHandler(h.RevokeEmployeeSessions))
+		// PUT /api/v1/employees/:id/public-profile - Control the employee's public directory listing.
+		employeesGroup.PUT("/:id/public-profile", middleware.RequirePermission(model.PermissionEmployeesUpdate), middleware.ErrorHandler(h.UpdatePublicProfile))
+		// POST /api/v1/employees/:id/roles - Grant a role to an employee.
+		employeesGroup.POST("/:id/roles", middleware.RequirePermission(model.PermissionRolesUpdate), middleware.ErrorHandler(h.AssignEmployeeRole))
+		// DELETE /api/v1/employees/:id/roles/:roleId - Take a role away from an employee.
+		employeesGroup.DELETE("/:id/roles/:roleId", middleware.RequirePermission(model.PermissionRolesUpdate), middleware.ErrorHandler(h.RemoveEmployeeRole))
+		// PATCH /api/v1/employees/:id/status - Suspend, reactivate or terminate an employee.
+		employeesGroup.PATCH("/:id/status", middleware.RequirePermission(model.PermissionEmployeesDeactivate), middleware.ErrorHandler(h.ChangeEmployeeStatus))
+		// GET /api/v1/employees/:id/security-status - Lockout state, recent failures and active sessions.
+		employeesGroup.GET("/:id/security-status", middleware.RequirePermission(model.PermissionEmployeesDeactivate), middleware.ErrorHandler(h.GetEmployeeSecurityStatus))
+		// POST /api/v1/employees/:id/unlock - Lift a login lockout.
+		employeesGroup.POST("/:id/unlock", middleware.RequirePermission(model.PermissionEmployeesDeactivate), middleware.ErrorHandler(h.UnlockEmployee))
+		// GET /api/v1/employees/:id/logins?limit= - Audit an employee's recent login attempts.
+		employeesGroup.GET("/:id/logins", middleware.RequirePermission(model.PermissionEmployeesRead), middleware.AllowQuery("limit"), middleware.ErrorHandler(h.ListEmployeeLogins))
+		// /api/v1/employees/:id/email-change - Start, inspect or cancel a verified email change.
+		// Employees may manage their own address; the handler requires employees.update for anyone else.
+		employeesGroup.POST("/:id/email-change", middleware.ErrorHandler(h.RequestEmailChange))
+		employeesGroup.GET("/:id/email-change", middleware.ErrorHandler(h.GetPendingEmailChange))
+		employeesGroup.DELETE("/:id/email-change", middleware.ErrorHandler(h.CancelEmailChange))
+		// PATCH /api/v1/employees/:id - Change the fields sent (full_name, job_title); null clears job_title.
+		employeesGroup.PATCH("/:id", middleware.RequirePermission(model.PermissionEmployeesUpdate), middleware.ErrorHandler(h.UpdateEmployee))
+		// GET|HEAD /api/v1/employees - List the clinic's employees. Responses carry an ETag and
+		// X-Total-Count; HEAD returns only those, and a matching If-None-Match gets a 304.
+		// GET /api/v1/employees/:id - Get one employee.
+		// Email, phone number, last login and inviter are shown only on the caller's own record
+		// or to holders of employees.read_contact.
+		employeesGroup.GET("/", middleware.RequirePermission(model.PermissionEmployeesRead), middleware.ErrorHandler(h.ListEmployees))
+		employeesGroup.HEAD("/", middleware.RequirePermission(model.PermissionEmployeesRead), middleware.ErrorHandler(h.ListEmployees))
+		employeesGroup.GET("/:id", middleware.RequirePermission(model.PermissionEmployeesRead), middleware.ErrorHandler(h.GetEmployee))
+	}
+
+	// GET /api/v1/permissions - The grantable permissions grouped by module, for role editors.
+	router.GET("/permissions", middleware.RequireAnyPermission(model.PermissionRolesCreate, model.PermissionRolesUpdate), middleware.ErrorHandler(h.ListPermissions))
+
+	rolesGroup := router.Group("/roles")
+	{
+		rolesGroup.POST("/", middleware.RequirePermission(model.PermissionRolesCreate), middleware.ErrorHandler(h.CreateRole))
+		rolesGroup.GET("/", middleware.RequirePermission(model.PermissionRolesRead), middleware.ErrorHandler(h.ListRoles))
+		rolesGroup.GET("/:id", middleware.RequirePermission(model.PermissionRolesRead), middleware.ErrorHandler(h.GetRole))
+		rolesGroup.PUT("/:id", middleware.RequirePermission(model.PermissionRolesUpdate), middleware.ErrorHandler(h.UpdateRole))
+		// POST /api/v1/roles/:id/reassign - Move every holder of a role to another role.
+		rolesGroup.POST("/:id/reassign", middleware.RequirePermission(model.PermissionRolesUpdate), middleware.ErrorHandler(h.ReassignRole))
+		// DELETE /api/v1/roles/:id?reassign_to= - Delete a role, moving its holders first.
+		rolesGroup.DELETE("/:id", middleware.RequirePermission(model.PermissionRolesDelete), middleware.AllowQuery("reassign_to"), middleware.ErrorHandler(h.DeleteRole))
 	}
 }
+
+// RegisterInternalRoutes sets up the platform-operator routes for the IAM module.
+func (h *Handler) RegisterInternalRoutes(router *gin.RouterGroup) {
+	// POST /internal/v1/impersonations - Mint a time-boxed impersonation token.
+	router.POST("/impersonations", middleware.RequirePermission(model.PermissionPlatformImpersonate), middleware.ErrorHandler(h.Impersonate))
+	// GET /internal/v1/auth-failures - Investigate rejected login attempts.
+	router.GET("/auth-failures", middleware.RequirePermission(model.PermissionPlatformAuthFailuresRead), middleware.AllowQuery("clinic_slug", "identifier", "ip", "reason", "from", "to", "limit"), middleware.ErrorHandler(h.ListAuthFailures))
+}
@@ -4,6 +4,8 @@ import (
 	"regexp"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	z "github.com/Oudwins/zog"
 )
 
@@ -12,9 +14,9 @@ var e164Regex = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
 
 // Defines the schema for the LoginRequest DTO.
 var loginRequestSchema = z.Struct(z.Shape{
-	"email":        z.String().Email(z.Message("A valid email address is required.")).Optional(),
-	"phone_number": z.String().Match(e164Regex, z.Message("A valid E.164 phone number is required.")).Optional(),
-	"password":     z.String().Required(z.Message("Password is required.")),
+	"email":    z.Ptr(z.String().Email(z.Message("A valid email address is required."))),
+	"phone":    z.Ptr(z.String().Match(e164Regex, z.Message("A valid E.164 phone number is required."))),
+	"password": z.String().Required(z.Message("Password is required.")),
 }).TestFunc( // Use TestFunc for cross-field validation on structs.
 	func(data any, ctx z.Ctx) bool {
 		req, ok := data.(*dto.LoginRequest)
@@ -26,12 +28,13 @@ var loginRequestSchema = z.Struct(z.Shape{
 	z.Message("Either email or phone_number must be provided."),
 )
 
-// Schema for inviting a new employee.
+// Schema for inviting a new employee. The phone number may be in national form; the service
+// normalizes it with the clinic's default phone region.
 var inviteEmployeeSchema = z.Struct(z.Shape{
-	"full_name":    z.String().Min(4, z.Message("Full name must be at least 4 characters.")),
-	"email":        z.String().Email(z.Message("A valid email address is required.")).Optional(),
-	"phone_number": z.String().Match(e164Regex, z.Message("A valid E.164 phone number is required.")).Optional(),
-	"job_title":    z.String().Optional(),
+	"fullName":    z.String().Min(4, z.Message("Full name must be at least 4 characters.")),
+	"email":       z.Ptr(z.String().Email(z.Message("A valid email address is required."))),
+	"phoneNumber": z.Ptr(z.String().Trim()),
+	"jobTitle":    z.Ptr(z.String()),
 }).TestFunc(
 	func(data any, ctx z.Ctx) bool {
 		req, ok := data.(*dto.InviteEmployeeRequest)
@@ -42,3 +45,87 @@ var inviteEmployeeSchema = z.Struct(z.Shape{
 	},
 	z.Message("Either email or phone_number must be provided for an invitation."),
 )
+
+// Schema for starting an impersonation session from the internal API.
+var impersonateSchema = z.Struct(z.Shape{
+	"clinicID":   z.String().UUID(z.Message("A valid clinic_id is required.")).Required(),
+	"employeeID": z.String().UUID(z.Message("A valid employee_id is required.")).Required(),
+})
+
+// Schema for moving all holders of a role to another role.
+var reassignRoleSchema = z.Struct(z.Shape{
+	"targetRoleID": z.String().UUID(z.Message("A valid target_role_id is required.")).Required(),
+})
+
+// Schema for granting a role to an employee.
+var assignRoleSchema = z.Struct(z.Shape{
+	"roleID": z.String().UUID(z.Message("A valid role_id is required.")).Required(),
+})
+
+// Schema for accepting an invitation. Password strength is enforced by the service.
+var acceptInviteSchema = z.Struct(z.Shape{
+	"token":    z.String().Required(z.Message("The invitation token is required.")),
+	"password": z.String().Required(z.Message("Password is required.")),
+})
+
+// Schema for the refresh and revoke endpoints.
+var refreshRequestSchema = z.Struct(z.Shape{
+	"refreshToken": z.String().Required(z.Message("The refresh_token is required.")),
+})
+
+// Schema for creating or replacing a clinic role.
+var roleSchema = z.Struct(z.Shape{
+	"name":        z.String().Trim().Min(2, z.Message("Role name must be at least 2 characters.")).Max(100, z.Message("Role name cannot exceed 100 characters.")).Required(),
+	"description": z.Ptr(z.String()),
+	"permissions": z.Slice(z.String()).Max(100, z.Message("Too many permissions.")),
+})
+
+// Schema for changing an employee's public practitioner listing.
+var publicProfileSchema = z.Struct(z.Shape{
+	"isPubliclyListed": z.Ptr(z.Bool()).NotNil(z.Message("is_publicly_listed is required.")),
+	"bio":              z.Ptr(z.String().Trim().Max(2000, z.Message("Bio cannot exceed 2000 characters."))),
+	"photoURL":         z.Ptr(z.String().URL(z.Message("photo_url must be a valid URL."))),
+	"sortIndex":        z.Int().GTE(0, z.Message("sort_index cannot be negative.")),
+})
+
+// Schema for an administrative employee status change. INVITED is only left via accept-invite.
+var changeEmployeeStatusSchema = z.Struct(z.Shape{
+	"status": z.String().OneOf(
+		[]string{string(model.EmployeeStatusActive), string(model.EmployeeStatusSuspended), string(model.EmployeeStatusTerminated)},
+		z.Message("status must be one of ACTIVE, SUSPENDED or TERMINATED."),
+	).Required(),
+	"reason": z.Ptr(z.String().Trim().Max(500, z.Message("Reason cannot exceed 500 characters."))),
+})
+
+// Schema for partially updating an employee. The request is decoded first and then
+// validated, so fields are only checked when sent; job_title may be cleared with null.
+var updateEmployeeSchema = z.Struct(z.Shape{
+	"fullName": optional.String(z.String().Min(2).Max(255), false, "full_name must be between 2 and 255 characters and cannot be cleared."),
+	"jobTitle": optional.String(z.String().Max(100), true, "job_title cannot exceed 100 characters."),
+})
+
+// Schema for starting an employee email change.
+var emailChangeSchema = z.Struct(z.Shape{
+	"newEmail": z.String().Trim().Email(z.Message("A valid new_email is required.")).Required(z.Message("new_email is required.")),
+})
+
+// Schema for the email change verify and undo endpoints.
+var emailChangeTokenSchema = z.Struct(z.Shape{
+	"token": z.String().Required(z.Message("The token is required.")),
+})
+
+// Schema for redeeming a signed action link.
+var redeemActionLinkSchema = z.Struct(z.Shape{
+	"token": z.String().Required(z.Message("The token is required.")),
+})
+
+// Schema for requesting a password reset link.
+var forgotPasswordSchema = z.Struct(z.Shape{
+	"email": z.String().Trim().Email(z.Message("A valid email address is required.")).Required(z.Message("Email is required.")),
+})
+
+// Schema for setting a new password with a reset token.
+var resetPasswordSchema = z.Struct(z.Shape{
+	"token":    z.String().Required(z.Message("The reset token is required.")),
+	"password": z.String().Required(z.Message("Password is required.")),
+})
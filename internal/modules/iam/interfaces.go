@@ -3,17 +3,99 @@ package iam
 
 import (
 	"context"
+	"time"
 
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
 // Service defines the contract for the IAM module's business logic (for employees).
 type Service interface {
-	InviteEmployee(ctx context.Context, clinicID, inviterID uuid.UUID, req InviteEmployeeRequest) (*model.Employee, error)
-	LoginEmployee(ctx context.Context, req LoginEmployeeRequest) (token string, employee *model.Employee, err error)
-	// We will add AcceptInvite and other methods later.
+	InviteEmployee(ctx context.Context, clinicID, inviterID uuid.UUID, req InviteEmployeeRequest) (*model.Employee, *IssuedInvitation, error)
+	// ResendInvite replaces an invited employee's invitation token and sends the new one.
+	// It fails with 409 once the employee is no longer INVITED.
+	ResendInvite(ctx context.Context, clinicID, actorID, profileID uuid.UUID) (*IssuedInvitation, error)
+	// AcceptInvite activates an invited employee using their single-use token and sets their password.
+	AcceptInvite(ctx context.Context, req AcceptInviteRequest) error
+	LoginEmployee(ctx context.Context, req LoginEmployeeRequest) (session *Session, employee *model.Employee, err error)
+	// RefreshSession exchanges a refresh token for a new access token, rotating the refresh token.
+	RefreshSession(ctx context.Context, req RefreshSessionRequest) (*Session, error)
+	// RevokeSession revokes the session the refresh token belongs to. Unknown tokens are ignored.
+	RevokeSession(ctx context.Context, req RefreshSessionRequest) error
+	// Logout revokes the caller's current access token.
+	Logout(ctx context.Context, payload *security.AuthPayload) error
+	// RevokeAllSessionsForEmployee revokes every access and refresh token held by an employee.
+	RevokeAllSessionsForEmployee(ctx context.Context, clinicID, profileID uuid.UUID) error
+	// ImpersonateEmployee mints a time-boxed token that lets a platform operator act as an employee.
+	ImpersonateEmployee(ctx context.Context, operatorID uuid.UUID, req ImpersonateEmployeeRequest) (token string, payload *security.AuthPayload, err error)
+	// ListAuthFailures returns rejected authentication attempts for security investigations.
+	ListAuthFailures(ctx context.Context, filter model.AuthFailureFilter) ([]model.AuthFailure, error)
+	// ForgotPassword sends a reset token to the employee with this email, if there is one.
+	// It reports success either way so callers cannot probe which addresses exist.
+	ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error
+	// ResetPassword consumes a reset token, sets the new password and revokes all sessions.
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+	// RequestEmailChange starts a two-step email change; the address only changes once the new one is verified.
+	RequestEmailChange(ctx context.Context, clinicID, actorID uuid.UUID, req EmailChangeRequest) (*model.EmailChange, error)
+	// GetPendingEmailChange returns the employee's open email change request.
+	GetPendingEmailChange(ctx context.Context, clinicID, employeeID uuid.UUID) (*model.EmailChange, error)
+	// CancelEmailChange withdraws the employee's open email change request.
+	CancelEmailChange(ctx context.Context, clinicID, actorID, employeeID uuid.UUID) error
+	// VerifyEmailChange applies a pending change using the token sent to the new address.
+	VerifyEmailChange(ctx context.Context, clinicID uuid.UUID, token string) error
+	// UndoEmailChange reverts a verified change using the token sent to the old address.
+	UndoEmailChange(ctx context.Context, clinicID uuid.UUID, token string) error
+	// ListLoginEvents returns an employee's most recent login attempts, newest first.
+	ListLoginEvents(ctx context.Context, clinicID, profileID uuid.UUID, limit int) ([]model.LoginEvent, error)
+	// UpdatePublicProfile changes whether and how an employee appears in the public practitioner directory.
+	UpdatePublicProfile(ctx context.Context, clinicID, profileID uuid.UUID, profile model.PublicProfile) error
+	// ListPublicPractitioners returns the clinic's publicly listed practitioners for the booking widget.
+	ListPublicPractitioners(ctx context.Context, clinicID uuid.UUID) ([]model.Practitioner, error)
+	// CreateRole creates a clinic role with the given permission set.
+	CreateRole(ctx context.Context, clinicID uuid.UUID, req RoleRequest) (*model.Role, error)
+	// ListPermissions returns the permissions clinic roles can be granted, for role editors.
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	// ListRoles returns the system roles and the clinic's own roles.
+	ListRoles(ctx context.Context, clinicID uuid.UUID) ([]model.Role, error)
+	// GetRole returns a single role visible to the clinic.
+	GetRole(ctx context.Context, clinicID, roleID uuid.UUID) (*model.Role, error)
+	// UpdateRole replaces a clinic role's name, description and permission set. System roles are read-only.
+	UpdateRole(ctx context.Context, clinicID, roleID uuid.UUID, req RoleRequest) (*model.Role, error)
+	// ReassignRole moves every employee holding one role to another and returns how many were moved.
+	ReassignRole(ctx context.Context, clinicID, actorID uuid.UUID, req ReassignRoleRequest) (moved int, err error)
+	// DeleteRole soft-deletes a clinic role, optionally reassigning its holders first in the same transaction.
+	DeleteRole(ctx context.Context, clinicID, actorID uuid.UUID, req DeleteRoleRequest) (moved int, err error)
+	// AssignRole grants a role to an employee and returns the employee's resulting roles. Re-assigning is a no-op.
+	AssignRole(ctx context.Context, clinicID, actorID uuid.UUID, req EmployeeRoleRequest) ([]model.Role, error)
+	// RemoveRole takes a role away from an employee and returns the employee's remaining roles.
+	RemoveRole(ctx context.Context, clinicID, actorID uuid.UUID, req EmployeeRoleRequest) ([]model.Role, error)
+	// ChangeEmployeeStatus suspends, reactivates or terminates an employee. Suspending or terminating
+	// also ends all of the employee's sessions.
+	ChangeEmployeeStatus(ctx context.Context, clinicID, actorID uuid.UUID, req ChangeEmployeeStatusRequest) error
+
+	// GetSecurityStatus returns an employee's lockout state and active session count.
+	GetSecurityStatus(ctx context.Context, clinicID, profileID uuid.UUID) (*model.SecurityStatus, error)
+	// UnlockEmployee lifts a login lockout and clears the failure count, recording who did it.
+	UnlockEmployee(ctx context.Context, clinicID, actorID, profileID uuid.UUID) error
+	// ListEmployees returns the clinic's employees with the names of their roles.
+	ListEmployees(ctx context.Context, clinicID uuid.UUID) ([]model.Employee, error)
+	// ListEmployeesVersion summarizes the set ListEmployees returns, for conditional requests.
+	ListEmployeesVersion(ctx context.Context, clinicID uuid.UUID) (database.ListVersion, error)
+	// GetEmployee returns a single employee with their roles.
+	GetEmployee(ctx context.Context, clinicID, profileID uuid.UUID) (*model.Employee, error)
+
+	// UpdateEmployee changes the fields present in the request and leaves the rest untouched.
+	UpdateEmployee(ctx context.Context, clinicID uuid.UUID, req UpdateEmployeeRequest) error
+
+	// SendActionLink emails an active employee a single-use link that signs them in and opens an action.
+	SendActionLink(ctx context.Context, req ActionLinkRequest) error
+	// RedeemActionLink consumes an action link and, if its employee is still active, starts a
+	// session for them and returns the client route the link was issued for.
+	RedeemActionLink(ctx context.Context, req RedeemActionLinkRequest) (session *Session, employee *model.Employee, redirectTo string, err error)
 }
 
 // Repository defines the data access contract for employees.
@@ -23,7 +105,167 @@ type Repository interface {
 	FindEmployeeByEmail(ctx context.Context, clinicID uuid.UUID, email string) (*model.Employee, error)
 	FindEmployeeByPhone(ctx context.Context, clinicID uuid.UUID, phone string) (*model.Employee, error)
 	FindEmployeeByIDWithDetails(ctx context.Context, clinicID, profileID uuid.UUID) (*model.Employee, error)
-	FindRolesForEmployee(ctx context.Context, employeeProfileID uuid.UUID) ([]model.Role, error)
+	FindRolesForEmployee(ctx context.Context, querier database.Querier, employeeProfileID uuid.UUID) ([]model.Role, error)
+	ListEmployees(ctx context.Context, clinicID uuid.UUID) ([]model.Employee, error)
+	// ListEmployeesVersion counts the employees ListEmployees returns, with their latest update
+	// and a checksum of their role assignments.
+	ListEmployeesVersion(ctx context.Context, clinicID uuid.UUID) (database.ListVersion, error)
+	IsSandboxClinic(ctx context.Context, clinicID uuid.UUID) (bool, error)
+	CreateAuditEvent(ctx context.Context, tx pgx.Tx, event *model.AuditEvent) error
+
+	// Refresh tokens.
+	CreateRefreshToken(ctx context.Context, tx pgx.Tx, token *model.RefreshToken) error
+	FindRefreshTokenForUpdate(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, tx pgx.Tx, oldID, newID uuid.UUID) error
+	RevokeRefreshSession(ctx context.Context, tx pgx.Tx, sessionID uuid.UUID) error
+	RevokeRefreshTokensForEmployee(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) error
+	CountActiveSessions(ctx context.Context, clinicID, profileID uuid.UUID) (int, error)
+
+	// Invitations.
+	CreateInviteToken(ctx context.Context, tx pgx.Tx, invitation *model.Invitation) error
+	ConsumeInviteToken(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.Invitation, error)
+	InvalidateInviteTokens(ctx context.Context, tx pgx.Tx, profileID uuid.UUID) error
+	ActivateEmployee(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, passwordHash string) error
+
+	// Public practitioner directory.
+	UpdatePublicProfile(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, profile *model.PublicProfile) error
+	ListPublicPractitioners(ctx context.Context, clinicID uuid.UUID) ([]model.Practitioner, error)
+
+	// Role management.
+	CreateRole(ctx context.Context, tx pgx.Tx, role *model.Role) error
+	UpdateRole(ctx context.Context, tx pgx.Tx, role *model.Role) error
+	UpdateRolePermissions(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, permissionKeys []string) ([]model.Permission, error)
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	UpsertPermissions(ctx context.Context, tx pgx.Tx, permissions []model.Permission) error
+	EnsureSystemRole(ctx context.Context, tx pgx.Tx, role *model.Role) (created bool, err error)
+	GrantRolePermissions(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, permissionKeys []string) (granted int64, err error)
+	ListRolesForClinic(ctx context.Context, clinicID uuid.UUID) ([]model.Role, error)
+	FindRoleByID(ctx context.Context, clinicID, roleID uuid.UUID) (*model.Role, error)
+	FindRoleForUpdate(ctx context.Context, tx pgx.Tx, clinicID, roleID uuid.UUID) (*model.Role, error)
+	CountRoleHolders(ctx context.Context, tx pgx.Tx, clinicID, roleID uuid.UUID) (int, error)
+	MoveRoleHolders(ctx context.Context, tx pgx.Tx, clinicID, fromRoleID, toRoleID uuid.UUID) ([]uuid.UUID, error)
+	SoftDeleteRole(ctx context.Context, tx pgx.Tx, clinicID, roleID uuid.UUID) error
+	LockEmployee(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) error
+	FindEmployeeStatusForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (model.EmployeeStatus, error)
+	UpdateEmployeeStatus(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, status model.EmployeeStatus) error
+	UpdateEmployeeDetails(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, patch *model.EmployeePatch) error
+	AddEmployeeRole(ctx context.Context, tx pgx.Tx, profileID, roleID uuid.UUID) (added bool, err error)
+	RemoveEmployeeRole(ctx context.Context, tx pgx.Tx, profileID, roleID uuid.UUID) (removed bool, err error)
+
+	// Authentication failure log.
+	CreateAuthFailure(ctx context.Context, failure *model.AuthFailure) error
+	ResolveAuthFailureStreak(ctx context.Context, identifierHash string, profileID uuid.UUID) error
+	ListAuthFailures(ctx context.Context, filter model.AuthFailureFilter) ([]model.AuthFailure, error)
+	DeleteAuthFailuresBefore(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// Login history.
+	RecordLogin(ctx context.Context, profileID uuid.UUID, ip, userAgent string) error
+	RecordFailedLogin(ctx context.Context, profileID uuid.UUID, ip, userAgent string, reason model.AuthFailureReason) error
+	ListLoginEvents(ctx context.Context, clinicID, profileID uuid.UUID, limit int) ([]model.LoginEvent, error)
+
+	// Email changes.
+	FindEmployeeEmailForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (*string, error)
+	IsEmailInUse(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, email string) (bool, error)
+	UpdateProfileEmail(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, email *string) error
+	CreateEmailChange(ctx context.Context, tx pgx.Tx, change *model.EmailChange) error
+	FindPendingEmailChange(ctx context.Context, clinicID, profileID uuid.UUID) (*model.EmailChange, error)
+	CancelPendingEmailChange(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (cancelled bool, err error)
+	FindEmailChangeByVerifyTokenForUpdate(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.EmailChange, error)
+	FindEmailChangeByUndoTokenForUpdate(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.EmailChange, error)
+	MarkEmailChangeVerified(ctx context.Context, tx pgx.Tx, change *model.EmailChange) error
+	MarkEmailChangeUndone(ctx context.Context, tx pgx.Tx, id uuid.UUID) error
+
+	// Password reset.
+	CreatePasswordResetToken(ctx context.Context, tx pgx.Tx, token *model.PasswordResetToken) error
+	ConsumePasswordResetToken(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.PasswordResetToken, error)
+	InvalidatePasswordResetTokens(ctx context.Context, tx pgx.Tx, profileID uuid.UUID) error
+	UpdateEmployeePassword(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, passwordHash string) error
+	// UpdatePasswordHash swaps in newHash only while currentHash is still stored, so an upgrade
+	// never overwrites a password changed concurrently. It reports whether the hash was replaced.
+	UpdatePasswordHash(ctx context.Context, profileID uuid.UUID, currentHash, newHash string) (bool, error)
+
+	// Action links.
+	// RedeemActionLink records a used action link and reports false if it had already been used.
+	RedeemActionLink(ctx context.Context, tx pgx.Tx, redemption *model.ActionLinkRedemption) (bool, error)
+}
+
+// PhoneRegionResolver returns the ISO 3166-1 region a clinic's locally typed phone numbers are read in.
+type PhoneRegionResolver interface {
+	DefaultPhoneRegion(ctx context.Context, clinicID uuid.UUID) (string, error)
+}
+
+// Notifier delivers account messages that carry single-use tokens. Each token must only
+// ever reach the mailbox it is addressed to, never the person who triggered the message.
+type Notifier interface {
+	// SendEmailChangeVerification sends the verification token to the new address.
+	SendEmailChangeVerification(ctx context.Context, to, token string, expiresAt time.Time) error
+	// SendEmailChangeNotice tells the old address about the change and how to undo it.
+	SendEmailChangeNotice(ctx context.Context, to, newEmail, undoToken string, expiresAt time.Time) error
+	// SendPasswordReset sends a password reset token to the employee's address.
+	SendPasswordReset(ctx context.Context, to, token string, expiresAt time.Time) error
+	// SendActionLink sends a signed link that takes a staff member straight to an action.
+	SendActionLink(ctx context.Context, to string, email ActionLinkEmail) error
+	// SendInvitation sends an invited employee the link to set their password.
+	SendInvitation(ctx context.Context, invitation InvitationMessage) error
+	// SendAccountLocked tells an employee their account was locked after repeated failed logins.
+	SendAccountLocked(ctx context.Context, to string, lockedUntil time.Time) error
+}
+
+// InvitationMessage holds the template variables of an invitation. It goes to Email when
+// set and to PhoneNumber otherwise.
+type InvitationMessage struct {
+	RecipientName string
+	Email         *string
+	PhoneNumber   *string
+	URL           string
+	ExpiresAt     time.Time
+}
+
+// ActionLinkEmail holds the template variables of a notification email carrying an action link.
+type ActionLinkEmail struct {
+	RecipientName string
+	Action        model.ActionLinkAction
+	// Summary is the sentence the email leads with, e.g. "A reschedule request needs your approval."
+	Summary   string
+	URL       string
+	ExpiresAt time.Time
+}
+
+// ActionLinkRequest asks for a signed action link to be emailed to an employee.
+type ActionLinkRequest struct {
+	ClinicID   uuid.UUID
+	EmployeeID uuid.UUID
+	Action     model.ActionLinkAction
+	EntityID   uuid.UUID
+	Summary    string
+}
+
+// RedeemActionLinkRequest carries an action link token presented to the public landing endpoint.
+type RedeemActionLinkRequest struct {
+	ClinicID uuid.UUID // Resolved from the request host by the handler.
+	Token    string
+
+	IPAddress string
+	UserAgent string
+}
+
+// ForgotPasswordRequest asks for a password reset token.
+type ForgotPasswordRequest struct {
+	ClinicID uuid.UUID // Resolved from the request host by the handler.
+	Email    string
+}
+
+// ResetPasswordRequest carries a password reset token and the new password.
+type ResetPasswordRequest struct {
+	ClinicID uuid.UUID // Resolved from the request host by the handler.
+	Token    string
+	Password string
+}
+
+// EmailChangeRequest asks to move an employee to a new email address.
+type EmailChangeRequest struct {
+	EmployeeID uuid.UUID
+	NewEmail   string
 }
 
 // InviteEmployeeRequest contains the data needed to invite a new staff member.
@@ -34,10 +276,92 @@ type InviteEmployeeRequest struct {
 	JobTitle    *string
 }
 
+// IssuedInvitation is the plaintext invitation token handed to the invitee exactly once.
+type IssuedInvitation struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// AcceptInviteRequest contains the invitation token and the password the employee chose.
+type AcceptInviteRequest struct {
+	ClinicID uuid.UUID // Resolved from the request host by the handler.
+	Token    string
+	Password string
+}
+
 // LoginEmployeeRequest contains credentials for an employee login.
 type LoginEmployeeRequest struct {
 	ClinicID uuid.UUID // This must be provided by the handler.
 	Email    *string
 	Phone    *string
 	Password string
+
+	// Request metadata used for the authentication failure log.
+	ClinicSlug string
+	IPAddress  string
+	UserAgent  string
+}
+
+// RefreshSessionRequest carries a refresh token presented to the public auth endpoints.
+type RefreshSessionRequest struct {
+	ClinicID     uuid.UUID // Resolved from the request host by the handler.
+	RefreshToken string
+}
+
+// Session is the token pair issued on login and on every refresh.
+type Session struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+	// Sandbox mirrors the token's sandbox claim, which clients cannot read from the encrypted token.
+	Sandbox bool
+
+	refreshTokenID uuid.UUID
+}
+
+// ImpersonateEmployeeRequest identifies the employee a platform operator wants to act as.
+type ImpersonateEmployeeRequest struct {
+	ClinicID   uuid.UUID
+	EmployeeID uuid.UUID
+}
+
+// RoleRequest carries the editable fields of a clinic role.
+type RoleRequest struct {
+	Name        string
+	Description *string
+	Permissions []string // Permission keys; replaces the existing set.
+}
+
+// ReassignRoleRequest moves all holders of RoleID to TargetRoleID.
+type ReassignRoleRequest struct {
+	RoleID       uuid.UUID
+	TargetRoleID uuid.UUID
+}
+
+// EmployeeRoleRequest names a role to grant to or take from an employee.
+type EmployeeRoleRequest struct {
+	EmployeeID uuid.UUID
+	RoleID     uuid.UUID
+}
+
+// ChangeEmployeeStatusRequest moves an employee to Status. Reason is kept in the audit log.
+type ChangeEmployeeStatusRequest struct {
+	EmployeeID uuid.UUID
+	Status     model.EmployeeStatus
+	Reason     *string
+}
+
+// UpdateEmployeeRequest partially updates EmployeeID. Omitted fields are left unchanged;
+// a null JobTitle clears it.
+type UpdateEmployeeRequest struct {
+	EmployeeID uuid.UUID
+	FullName   optional.Optional[string]
+	JobTitle   optional.Optional[string]
+}
+
+// DeleteRoleRequest deletes RoleID. ReassignTo is required when the role still has holders.
+type DeleteRoleRequest struct {
+	RoleID     uuid.UUID
+	ReassignTo *uuid.UUID
 }
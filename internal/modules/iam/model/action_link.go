@@ -0,0 +1,51 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions for signed action links in staff notification emails.
+const (
+	AuditActionActionLinkIssued   = "ACTION_LINK_ISSUED"
+	AuditActionActionLinkRedeemed = "ACTION_LINK_REDEEMED"
+)
+
+// ActionLinkAction names the staff action an emailed link opens.
+type ActionLinkAction string
+
+// Actions an action link can be issued for.
+const (
+	ActionLinkReviewReschedule      ActionLinkAction = "appointments.reschedule.review"
+	ActionLinkReviewEmployeeLockout ActionLinkAction = "employees.lockout.review"
+)
+
+// actionLinkRoutes maps every known action to the client route it lands on; ":id" is replaced
+// with the entity the link was issued for. Routes are fixed here, never taken from the link, so
+// a link cannot redirect anywhere else.
+var actionLinkRoutes = map[ActionLinkAction]string{
+	ActionLinkReviewReschedule:      "/appointments/:id/reschedule",
+	ActionLinkReviewEmployeeLockout: "/employees/:id/security",
+}
+
+// Route returns the client route for the action on entityID, or false for unknown actions.
+func (a ActionLinkAction) Route(entityID uuid.UUID) (string, bool) {
+	route, ok := actionLinkRoutes[a]
+	if !ok {
+		return "", false
+	}
+	return strings.Replace(route, ":id", entityID.String(), 1), true
+}
+
+// ActionLinkRedemption records that an action link was used, so it cannot be used again.
+type ActionLinkRedemption struct {
+	TokenID           uuid.UUID        `db:"token_id"`
+	EmployeeProfileID uuid.UUID        `db:"employee_profile_id"`
+	ClinicID          uuid.UUID        `db:"clinic_id"`
+	Action            ActionLinkAction `db:"action"`
+	EntityID          uuid.UUID        `db:"entity_id"`
+	ExpiresAt         time.Time        `db:"expires_at"`
+	RedeemedAt        time.Time        `db:"redeemed_at"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions written explicitly by the IAM module (row changes are captured by triggers).
+const (
+	AuditActionImpersonationIssued = "IMPERSONATION_ISSUED"
+	AuditActionRoleReassigned      = "ROLE_REASSIGNED"
+	AuditActionRoleAssigned        = "ROLE_ASSIGNED"
+	AuditActionRoleRemoved         = "ROLE_REMOVED"
+	AuditActionStatusChanged       = "EMPLOYEE_STATUS_CHANGED"
+	AuditActionEmployeeUnlocked    = "EMPLOYEE_UNLOCKED"
+	AuditActionInviteResent        = "INVITE_RESENT"
+)
+
+// AuditEvent is an application-level entry in the 'audit_log' table.
+type AuditEvent struct {
+	ClinicID   uuid.UUID       `db:"clinic_id"`
+	UserID     uuid.UUID       `db:"user_id"` // The real operator performing the action.
+	Action     string          `db:"action"`
+	TableName  string          `db:"table_name"`
+	RecordID   uuid.UUID       `db:"record_id"`
+	NewRecord  json.RawMessage `db:"new_record"`
+	OccurredAt time.Time       `db:"timestamp"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthFailureReason classifies why an authentication attempt was rejected.
+type AuthFailureReason string
+
+const (
+	AuthFailureUnknownIdentifier AuthFailureReason = "UNKNOWN_IDENTIFIER"
+	AuthFailureBadPassword       AuthFailureReason = "BAD_PASSWORD"
+	AuthFailureNoPassword        AuthFailureReason = "NO_PASSWORD"
+	AuthFailureLocked            AuthFailureReason = "LOCKED"
+	AuthFailureSuspended         AuthFailureReason = "SUSPENDED"
+	AuthFailureTerminated        AuthFailureReason = "TERMINATED"
+	AuthFailureSuspendedClinic   AuthFailureReason = "SUSPENDED_CLINIC"
+)
+
+// PermissionPlatformAuthFailuresRead allows platform security staff to inspect rejected logins.
+const PermissionPlatformAuthFailuresRead = "platform.auth_failures.read"
+
+// AuthFailure is a rejected authentication attempt. The identifier is only ever kept as a keyed hash.
+type AuthFailure struct {
+	ID                uuid.UUID         `db:"id"`
+	ClinicSlug        *string           `db:"clinic_slug"`
+	IdentifierHash    string            `db:"identifier_hash"`
+	IPAddress         *string           `db:"ip_address"`
+	UserAgent         *string           `db:"user_agent"`
+	Reason            AuthFailureReason `db:"reason"`
+	ResolvedAt        *time.Time        `db:"resolved_at"`
+	ResolvedProfileID *uuid.UUID        `db:"resolved_profile_id"`
+	OccurredAt        time.Time         `db:"occurred_at"`
+}
+
+// AuthFailureFilter narrows an auth failure listing. Zero values mean "no filter".
+type AuthFailureFilter struct {
+	ClinicSlug string
+	// Identifier is the raw email or phone; the service hashes it before querying.
+	Identifier     string
+	IdentifierHash string
+	IPAddress      string
+	Reason         AuthFailureReason
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailChangeUndoWindow is how long the previous address can revert a verified email change.
+const EmailChangeUndoWindow = 48 * time.Hour
+
+// Audit actions for the email change flow.
+const (
+	AuditActionEmailChangeRequested = "EMAIL_CHANGE_REQUESTED"
+	AuditActionEmailChangeVerified  = "EMAIL_CHANGE_VERIFIED"
+	AuditActionEmailChangeCancelled = "EMAIL_CHANGE_CANCELLED"
+	AuditActionEmailChangeUndone    = "EMAIL_CHANGE_UNDONE"
+)
+
+// EmailChange is a request to move an employee to a new email address. It applies only once
+// the new address is verified, and can then be undone from the old address for a while.
+type EmailChange struct {
+	ID                uuid.UUID  `db:"id"`
+	ClinicID          uuid.UUID  `db:"clinic_id"`
+	EmployeeProfileID uuid.UUID  `db:"employee_profile_id"`
+	OldEmail          *string    `db:"old_email"`
+	NewEmail          string     `db:"new_email"`
+	RequestedBy       *uuid.UUID `db:"requested_by"`
+	VerifyExpiresAt   time.Time  `db:"verify_expires_at"`
+	UndoExpiresAt     *time.Time `db:"undo_expires_at"`
+	VerifiedAt        *time.Time `db:"verified_at"`
+	CancelledAt       *time.Time `db:"cancelled_at"`
+	UndoneAt          *time.Time `db:"undone_at"`
+	CreatedAt         time.Time  `db:"created_at"`
+
+	// Token hashes are written on create/verify but never read back.
+	VerifyTokenHash string `db:"-"`
+	UndoTokenHash   string `db:"-"`
+}
+
+// IsPending reports whether the change still awaits verification at now.
+func (c *EmailChange) IsPending(now time.Time) bool {
+	return c.VerifiedAt == nil && c.CancelledAt == nil && now.Before(c.VerifyExpiresAt)
+}
+
+// IsUndoable reports whether the old address may still revert the change at now.
+func (c *EmailChange) IsUndoable(now time.Time) bool {
+	return c.VerifiedAt != nil && c.UndoneAt == nil && c.UndoExpiresAt != nil && now.Before(*c.UndoExpiresAt)
+}
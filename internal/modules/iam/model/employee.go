@@ -1,9 +1,11 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	"github.com/google/uuid"
 )
 
@@ -16,6 +18,19 @@ const (
 	EmployeeStatusTerminated EmployeeStatus = "TERMINATED"
 )
 
+// employeeTransitions lists the status changes an administrator may make directly.
+// INVITED becomes ACTIVE only by accepting the invitation, and TERMINATED is final.
+var employeeTransitions = map[EmployeeStatus][]EmployeeStatus{
+	EmployeeStatusInvited:   {EmployeeStatusTerminated},
+	EmployeeStatusActive:    {EmployeeStatusSuspended, EmployeeStatusTerminated},
+	EmployeeStatusSuspended: {EmployeeStatusActive, EmployeeStatusTerminated},
+}
+
+// CanTransitionTo reports whether an administrator may move an employee from s to next.
+func (s EmployeeStatus) CanTransitionTo(next EmployeeStatus) bool {
+	return slices.Contains(employeeTransitions[s], next)
+}
+
 type Employee struct {
 	ProfileID           uuid.UUID      `db:"profile_id"`
 	ClinicID            uuid.UUID      `db:"clinic_id"`
@@ -32,20 +47,29 @@ type Employee struct {
 	Roles               []Role         `db:"-"` // Loaded separately
 }
 
+// EmployeePatch is a partial update of an employee's details. Omitted fields keep their
+// stored value; a null JobTitle clears it. FullName cannot be cleared.
+type EmployeePatch struct {
+	FullName optional.Optional[string]
+	JobTitle optional.Optional[string]
+}
+
 func (e *Employee) ToAuthPayload(duration time.Duration) (*security.AuthPayload, error) {
 	roleIDs := make([]uuid.UUID, len(e.Roles))
-	permissionSet := make(map[string]struct{})
 	for i, role := range e.Roles {
 		roleIDs[i] = role.ID
-		for _, p := range role.Permissions {
-			permissionSet[p.PermissionKey] = struct{}{}
-		}
 	}
+	return security.NewAuthPayload(e.ProfileID, e.ClinicID, roleIDs, e.PermissionKeys(), duration)
+}
 
-	permissions := make([]string, 0, len(permissionSet))
-	for p := range permissionSet {
-		permissions = append(permissions, p)
+// PermissionKeys returns the union of the permissions granted by the employee's loaded roles, sorted.
+func (e *Employee) PermissionKeys() []string {
+	keys := []string{}
+	for _, role := range e.Roles {
+		for _, p := range role.Permissions {
+			keys = append(keys, p.PermissionKey)
+		}
 	}
-
-	return security.NewAuthPayload(e.ProfileID, e.ClinicID, roleIDs, permissions, duration)
+	slices.Sort(keys)
+	return slices.Compact(keys)
 }
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation is a single-use token that lets an invited employee activate their account.
+type Invitation struct {
+	ID                uuid.UUID  `db:"id"`
+	EmployeeProfileID uuid.UUID  `db:"employee_profile_id"`
+	ClinicID          uuid.UUID  `db:"clinic_id"`
+	TokenHash         string     `db:"token_hash"`
+	ExpiresAt         time.Time  `db:"expires_at"`
+	ConsumedAt        *time.Time `db:"consumed_at"`
+	CreatedAt         time.Time  `db:"created_at"`
+}
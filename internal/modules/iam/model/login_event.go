@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginEvent is a login attempt by a known employee, successful or not.
+type LoginEvent struct {
+	ID                uuid.UUID          `db:"id"`
+	ClinicID          uuid.UUID          `db:"clinic_id"`
+	EmployeeProfileID uuid.UUID          `db:"employee_profile_id"`
+	Succeeded         bool               `db:"succeeded"`
+	FailureReason     *AuthFailureReason `db:"failure_reason"`
+	IPAddress         *string            `db:"ip_address"`
+	UserAgent         *string            `db:"user_agent"`
+	OccurredAt        time.Time          `db:"occurred_at"`
+}
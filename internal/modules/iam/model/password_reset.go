@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditActionPasswordReset is written when an employee resets a forgotten password.
+const AuditActionPasswordReset = "PASSWORD_RESET"
+
+// PasswordResetToken is a single-use token that lets an employee choose a new password.
+type PasswordResetToken struct {
+	ID                uuid.UUID  `db:"id"`
+	EmployeeProfileID uuid.UUID  `db:"employee_profile_id"`
+	ClinicID          uuid.UUID  `db:"clinic_id"`
+	TokenHash         string     `db:"token_hash"`
+	ExpiresAt         time.Time  `db:"expires_at"`
+	ConsumedAt        *time.Time `db:"consumed_at"`
+	CreatedAt         time.Time  `db:"created_at"`
+}
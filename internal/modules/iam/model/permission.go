@@ -4,4 +4,69 @@ package model
 type Permission struct {
 	ID            int16  `db:"id"`
 	PermissionKey string `db:"permission_key"`
+	// Description explains the permission to whoever edits roles.
+	Description string `db:"description"`
+}
+
+// PlatformPermissionPrefix marks permissions reserved for platform staff roles.
+const PlatformPermissionPrefix = "platform."
+
+// PermissionPlatformImpersonate allows platform support staff to act as a clinic employee.
+const PermissionPlatformImpersonate = "platform.impersonate"
+
+// Permissions seeded in the IAM schema migration that the IAM routes require.
+const (
+	PermissionEmployeesInvite     = "employees.invite"
+	PermissionEmployeesRead       = "employees.read"
+	PermissionEmployeesUpdate     = "employees.update"
+	PermissionEmployeesDeactivate = "employees.deactivate"
+	PermissionRolesCreate         = "roles.create"
+	PermissionRolesRead           = "roles.read"
+	PermissionRolesUpdate         = "roles.update"
+	PermissionRolesDelete         = "roles.delete"
+)
+
+// PermissionEmployeesReadContact reveals colleagues' email, phone number, last login and
+// inviter in employee responses. Holders of employees.read alone see names, titles and status.
+const PermissionEmployeesReadContact = "employees.read_contact"
+
+// PermissionCatalog lists every permission the code checks, with the id it is stored under.
+// The RBAC seeder upserts it, so a permission added here exists after the next seed run;
+// ids are never reused for another key.
+var PermissionCatalog = []Permission{
+	{ID: 1, PermissionKey: PermissionEmployeesInvite, Description: "Invite new staff members to the clinic."},
+	{ID: 2, PermissionKey: PermissionEmployeesRead, Description: "See colleagues' names, job titles and status."},
+	{ID: 3, PermissionKey: PermissionEmployeesUpdate, Description: "Edit colleagues' details and public profiles."},
+	{ID: 4, PermissionKey: PermissionEmployeesDeactivate, Description: "Suspend, reactivate or terminate staff, sign them out and lift lockouts."},
+	{ID: 5, PermissionKey: PermissionEmployeesReadContact, Description: "See colleagues' email, phone number, last login and inviter."},
+	{ID: 10, PermissionKey: "patients.create", Description: "Register new patients."},
+	{ID: 11, PermissionKey: "patients.read", Description: "Look up patients and view their records."},
+	{ID: 12, PermissionKey: "patients.update", Description: "Edit patients' records."},
+	{ID: 13, PermissionKey: "patients.delete", Description: "Delete patients."},
+	{ID: 14, PermissionKey: "patients.archive", Description: "Archive and restore patients and see archived records."},
+	{ID: 15, PermissionKey: "patient.erase", Description: "Permanently erase a patient's personal data on request."},
+	{ID: 20, PermissionKey: "appointments.create", Description: "Book appointments."},
+	{ID: 21, PermissionKey: "appointments.read", Description: "View the appointment calendar."},
+	{ID: 22, PermissionKey: "appointments.update", Description: "Reschedule appointments and change their status."},
+	{ID: 23, PermissionKey: "appointments.delete", Description: "Delete appointments."},
+	{ID: 24, PermissionKey: "schedules.manage", Description: "Set practitioners' weekly working hours."},
+	{ID: 30, PermissionKey: "finance.invoice.create", Description: "Issue invoices."},
+	{ID: 31, PermissionKey: "finance.invoice.read", Description: "View invoices."},
+	{ID: 32, PermissionKey: "finance.payment.record", Description: "Record payments against invoices."},
+	{ID: 33, PermissionKey: "finance.reports.view", Description: "View financial reports."},
+	{ID: 40, PermissionKey: PermissionRolesCreate, Description: "Create clinic roles."},
+	{ID: 41, PermissionKey: PermissionRolesRead, Description: "View roles and their permissions."},
+	{ID: 42, PermissionKey: PermissionRolesUpdate, Description: "Edit roles and grant or remove them from staff."},
+	{ID: 43, PermissionKey: PermissionRolesDelete, Description: "Delete clinic roles."},
+	{ID: 50, PermissionKey: "clinic.reset", Description: "Wipe and reseed a sandbox clinic's data."},
+	{ID: 51, PermissionKey: "clinic.config.export", Description: "Export the clinic's configuration bundle."},
+	{ID: 52, PermissionKey: "clinic.config.import", Description: "Import a configuration bundle into the clinic."},
+	{ID: 53, PermissionKey: "clinic.manage", Description: "Change the clinic's timezone, language, phone region and booking rules."},
+	{ID: 54, PermissionKey: "webhooks.manage", Description: "Register webhook endpoints and inspect their deliveries."},
+	{ID: 60, PermissionKey: "reports.read", Description: "View operational reports such as appointment utilization."},
+	{ID: 70, PermissionKey: "audit.read", Description: "Read the audit log of changes to patients, staff, roles and appointments."},
+	{ID: 90, PermissionKey: PermissionPlatformImpersonate, Description: "Act as a clinic employee for support. Platform staff only."},
+	{ID: 91, PermissionKey: PermissionPlatformAuthFailuresRead, Description: "Investigate rejected login attempts. Platform staff only."},
+	{ID: 92, PermissionKey: "platform.metrics.read", Description: "Read process metrics from the internal API. Platform staff only."},
+	{ID: 93, PermissionKey: "platform.clinic.reset", Description: "Wipe and reseed any clinic, including live ones, from the internal API. Platform staff only."},
 }
@@ -0,0 +1,20 @@
+package model
+
+import "github.com/google/uuid"
+
+// PublicProfile holds the employee fields a clinic chooses to publish in the booking widget.
+type PublicProfile struct {
+	IsPubliclyListed bool    `db:"is_publicly_listed"`
+	Bio              *string `db:"public_bio"`
+	PhotoURL         *string `db:"photo_url"`
+	SortIndex        int     `db:"sort_index"`
+}
+
+// Practitioner is the public view of a listed employee. It deliberately has no contact or status fields.
+type Practitioner struct {
+	ID          uuid.UUID `db:"profile_id"`
+	DisplayName string    `db:"full_name"`
+	JobTitle    *string   `db:"job_title"`
+	PhotoURL    *string   `db:"photo_url"`
+	Bio         *string   `db:"public_bio"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is one link in a session's chain of rotating refresh tokens.
+type RefreshToken struct {
+	ID                uuid.UUID  `db:"id"`
+	SessionID         uuid.UUID  `db:"session_id"`
+	EmployeeProfileID uuid.UUID  `db:"employee_profile_id"`
+	ClinicID          uuid.UUID  `db:"clinic_id"`
+	TokenHash         string     `db:"token_hash"`
+	CreatedAt         time.Time  `db:"created_at"`
+	ExpiresAt         time.Time  `db:"expires_at"`
+	RevokedAt         *time.Time `db:"revoked_at"`
+	ReplacedBy        *uuid.UUID `db:"replaced_by"`
+}
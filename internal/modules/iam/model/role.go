@@ -17,3 +17,48 @@ type Role struct {
 	UpdatedAt    time.Time    `db:"updated_at"`
 	Permissions  []Permission `db:"-"` // Loaded separately
 }
+
+// SystemRole is a role every clinic can assign, created by the RBAC seeder.
+type SystemRole struct {
+	Name        string
+	Description string
+	// PermissionKeys must all appear in PermissionCatalog.
+	PermissionKeys []string
+}
+
+// SystemRoles are the baseline roles of a new deployment. The seeder only ever adds
+// permissions to them; it never revokes one that was granted outside this list.
+var SystemRoles = []SystemRole{
+	{
+		Name:        "Owner",
+		Description: "Full access to the clinic, including staff, roles, finance and configuration.",
+		PermissionKeys: []string{
+			PermissionEmployeesInvite, PermissionEmployeesRead, PermissionEmployeesUpdate, PermissionEmployeesDeactivate, PermissionEmployeesReadContact,
+			"patients.create", "patients.read", "patients.update", "patients.delete", "patients.archive", "patient.erase",
+			"appointments.create", "appointments.read", "appointments.update", "appointments.delete", "schedules.manage",
+			"finance.invoice.create", "finance.invoice.read", "finance.payment.record", "finance.reports.view",
+			PermissionRolesCreate, PermissionRolesRead, PermissionRolesUpdate, PermissionRolesDelete,
+			"clinic.reset", "clinic.config.export", "clinic.config.import", "clinic.manage", "webhooks.manage",
+			"reports.read", "audit.read",
+		},
+	},
+	{
+		Name:        "Doctor",
+		Description: "Sees and updates patient records and manages appointments.",
+		PermissionKeys: []string{
+			PermissionEmployeesRead,
+			"patients.create", "patients.read", "patients.update",
+			"appointments.create", "appointments.read", "appointments.update",
+		},
+	},
+	{
+		Name:        "Receptionist",
+		Description: "Registers patients, books appointments and takes payments.",
+		PermissionKeys: []string{
+			PermissionEmployeesRead,
+			"patients.create", "patients.read", "patients.update",
+			"appointments.create", "appointments.read", "appointments.update", "appointments.delete", "schedules.manage",
+			"finance.invoice.create", "finance.invoice.read", "finance.payment.record",
+		},
+	},
+}
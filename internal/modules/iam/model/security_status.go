@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// SecurityStatus summarizes an employee's sign-in state for clinic administrators.
+type SecurityStatus struct {
+	Locked         bool
+	LockedUntil    *time.Time
+	FailedAttempts int
+	ActiveSessions int
+}
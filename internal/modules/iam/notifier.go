@@ -0,0 +1,96 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
+)
+
+// messageTimeFormat is how expiry times appear in account messages.
+const messageTimeFormat = "2 Jan 2006 15:04 MST"
+
+// MessageNotifier implements Notifier by rendering plain-text account messages and handing
+// them to a notification transport.
+type MessageNotifier struct {
+	transport notification.Notifier
+}
+
+// NewMessageNotifier creates a Notifier that delivers through transport.
+func NewMessageNotifier(transport notification.Notifier) *MessageNotifier {
+	return &MessageNotifier{transport: transport}
+}
+
+// SendEmailChangeVerification emails the verification token to the new address.
+func (n *MessageNotifier) SendEmailChangeVerification(ctx context.Context, to, token string, expiresAt time.Time) error {
+	return n.transport.SendEmail(ctx, notification.Email{
+		To:      to,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Use this code to confirm your new email address: %s\n\nIt expires on %s. If you did not ask for this change, ignore this email.\n",
+			token, expiresAt.UTC().Format(messageTimeFormat)),
+	})
+}
+
+// SendEmailChangeNotice tells the old address about the change and how to undo it.
+func (n *MessageNotifier) SendEmailChangeNotice(ctx context.Context, to, newEmail, undoToken string, expiresAt time.Time) error {
+	return n.transport.SendEmail(ctx, notification.Email{
+		To:      to,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("The email address of your account was changed to %s.\n\nIf you did not make this change, undo it with this code before %s: %s\n",
+			newEmail, expiresAt.UTC().Format(messageTimeFormat), undoToken),
+	})
+}
+
+// SendPasswordReset emails a password reset token.
+func (n *MessageNotifier) SendPasswordReset(ctx context.Context, to, token string, expiresAt time.Time) error {
+	return n.transport.SendEmail(ctx, notification.Email{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use this code to choose a new password: %s\n\nIt expires on %s. If you did not ask to reset your password, ignore this email.\n",
+			token, expiresAt.UTC().Format(messageTimeFormat)),
+	})
+}
+
+// SendAccountLocked emails the notice that repeated failed logins locked the account.
+func (n *MessageNotifier) SendAccountLocked(ctx context.Context, to string, lockedUntil time.Time) error {
+	return n.transport.SendEmail(ctx, notification.Email{
+		To:      to,
+		Subject: "Your account was locked",
+		Body: fmt.Sprintf("Your account was locked after too many failed sign-in attempts. You can sign in again after %s, or ask your clinic administrator to unlock it.\n\nIf these attempts were not yours, reset your password once you can sign in.\n",
+			lockedUntil.UTC().Format(messageTimeFormat)),
+	})
+}
+
+// SendActionLink emails a signed link that takes a staff member straight to an action.
+func (n *MessageNotifier) SendActionLink(ctx context.Context, to string, email ActionLinkEmail) error {
+	return n.transport.SendEmail(ctx, notification.Email{
+		To:      to,
+		Subject: email.Summary,
+		Body: fmt.Sprintf("Hello %s,\n\n%s\n\nOpen it here: %s\n\nThe link works once and expires on %s.\n",
+			email.RecipientName, email.Summary, email.URL, email.ExpiresAt.UTC().Format(messageTimeFormat)),
+	})
+}
+
+// SendInvitation emails the invitation link, or texts it when the invitee has no email address.
+func (n *MessageNotifier) SendInvitation(ctx context.Context, invitation InvitationMessage) error {
+	expires := invitation.ExpiresAt.UTC().Format(messageTimeFormat)
+	if invitation.Email != nil {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Hello %s,\n\nYou have been invited to join your clinic's team on Mastara.\n\n", invitation.RecipientName)
+		fmt.Fprintf(&b, "Set your password here: %s\n\nThe link expires on %s.\n", invitation.URL, expires)
+		return n.transport.SendEmail(ctx, notification.Email{
+			To:      *invitation.Email,
+			Subject: "You're invited to Mastara",
+			Body:    b.String(),
+		})
+	}
+	if invitation.PhoneNumber != nil {
+		return n.transport.SendSMS(ctx, notification.SMS{
+			To:   *invitation.PhoneNumber,
+			Body: fmt.Sprintf("You're invited to join your clinic on Mastara. Set your password by %s: %s", expires, invitation.URL),
+		})
+	}
+	return fmt.Errorf("invitation has neither an email address nor a phone number")
+}
@@ -0,0 +1,55 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// SeedRBAC upserts model.PermissionCatalog and the model.SystemRoles in one transaction.
+// It is idempotent: re-running it adds whatever the catalog or a role gained since the last
+// run and changes nothing else.
+func SeedRBAC(ctx context.Context, txManager database.TxManager, repo Repository) error {
+	for _, role := range model.SystemRoles {
+		for _, key := range role.PermissionKeys {
+			if !slices.ContainsFunc(model.PermissionCatalog, func(p model.Permission) bool { return p.PermissionKey == key }) {
+				return fmt.Errorf("iam.SeedRBAC: system role %q grants %q, which is not in the permission catalog", role.Name, key)
+			}
+		}
+	}
+
+	return txManager.ExecTx(ctx, func(tx pgx.Tx) error {
+		if err := repo.UpsertPermissions(ctx, tx, model.PermissionCatalog); err != nil {
+			return err
+		}
+
+		for _, systemRole := range model.SystemRoles {
+			id, err := uuid.NewV7()
+			if err != nil {
+				return fmt.Errorf("iam.SeedRBAC: failed to generate role id: %w", err)
+			}
+			role := &model.Role{ID: id, Name: systemRole.Name, Description: &systemRole.Description, IsSystemRole: true}
+			created, err := repo.EnsureSystemRole(ctx, tx, role)
+			if err != nil {
+				return err
+			}
+			granted, err := repo.GrantRolePermissions(ctx, tx, role.ID, systemRole.PermissionKeys)
+			if err != nil {
+				return err
+			}
+			log.Info().
+				Str("role", role.Name).
+				Str("role_id", role.ID.String()).
+				Bool("created", created).
+				Int64("permissions_granted", granted).
+				Msg("Seeded system role")
+		}
+		return nil
+	})
+}
@@ -2,43 +2,121 @@ package iam
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/events"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/phone"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/ttlcache"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
 )
 
-// serviceImpl is the concrete implementation of the iam.Service interface.
+// practitionerCacheTTL bounds how stale the public practitioner directory can be on other instances.
+const practitionerCacheTTL = time.Minute
+
+// Invitations are sent through the outbox once the invite commits; each attempt is bounded by
+// invitationSendTimeout and the outbox retries failed ones.
+const invitationSendTimeout = 30 * time.Second
+
+// lockNoticeTimeout bounds sending the email that tells an employee their account was locked.
+const lockNoticeTimeout = 30 * time.Second
+
+// EventInvitationIssued is the outbox event that sends an invited employee their invitation.
+const EventInvitationIssued = "iam.invitation_issued"
+
+// invitationSealPurpose keys the encryption of queued invitations, which carry the plaintext token.
+const invitationSealPurpose = "mastara:outbox:invitation"
+
+// invitationIssued is the payload of EventInvitationIssued. Message is the InvitationMessage,
+// sealed under KeyVersion: the outbox is stored, and the token must only ever be stored hashed.
+type invitationIssued struct {
+	ClinicID  uuid.UUID `json:"clinic_id"`
+	ProfileID uuid.UUID `json:"profile_id"`
+	Message   string    `json:"message"`
+	// KeyVersion is zero in invitations queued before it was recorded, which were sealed under v1.
+	KeyVersion int16 `json:"key_version"`
+}
+
+// defaultService is the concrete implementation of the iam.Service interface.
 type defaultService struct {
 	service.BaseService
-	repo   Repository
-	sec    *security.PasetoManager
-	config *config.Config
-	// We need a way to find the clinic for a login request.
-	// This would be a repository from another module, injected here.
-	// For now, we'll assume a placeholder function signature.
-	// clinicRepo clinic.Repository
-
+	repo Repository
+	sec  *security.PasetoManager
+	// denylist rejects access tokens revoked before their expiry (logout, termination).
+	denylist security.Denylist
+	// lockout locks accounts after repeated failed logins.
+	lockout security.Lockout
+	config  *config.Config
+	// failures records rejected login attempts off the request path.
+	failures *AuthFailureRecorder
+	// notifier delivers account messages such as invitations and password reset tokens.
+	notifier Notifier
+	// outbox queues invitations for delivery after their transaction commits; keys encrypts them there.
+	outbox *events.Publisher
+	keys   *security.Keyring
+	// practitioners caches the public practitioner directory per clinic.
+	practitioners *ttlcache.Cache[uuid.UUID, []model.Practitioner]
+	// phoneRegions reads locally typed phone numbers in the clinic's country.
+	phoneRegions PhoneRegionResolver
+	// db is the primary; role lookups that issue tokens use it so a revoked role never
+	// survives replication lag. reader serves plain reads and may lag.
+	db     database.Querier
+	reader database.Querier
 }
 
 // NewService creates a new instance of the IAM service.
-func NewService(txManager database.TxManager, repo Repository, sec *security.PasetoManager, config *config.Config) Service {
-	return &defaultService{
-		BaseService: service.BaseService{Tx: txManager},
-		repo:        repo,
-		sec:         sec,
-		config:      config,
+// It registers the invitation sender with the outbox dispatcher.
+func NewService(txManager database.TxManager, repo Repository, sec *security.PasetoManager, denylist security.Denylist, lockout security.Lockout, config *config.Config, failures *AuthFailureRecorder, notifier Notifier, phoneRegions PhoneRegionResolver, db, reader database.Querier, outbox *events.Publisher, dispatcher *events.Dispatcher) Service {
+	s := &defaultService{
+		BaseService:  service.BaseService{Tx: txManager},
+		repo:         repo,
+		sec:          sec,
+		denylist:     denylist,
+		lockout:      lockout,
+		config:       config,
+		failures:     failures,
+		notifier:     notifier,
+		outbox:       outbox,
+		keys:         security.NewSealKeyring(config.Security, invitationSealPurpose),
+		phoneRegions: phoneRegions,
+		db:           db,
+		reader:       reader,
+
+		practitioners: ttlcache.New[uuid.UUID, []model.Practitioner](practitionerCacheTTL),
 	}
+	dispatcher.Register(EventInvitationIssued, s.onInvitationIssued)
+	return s
 }
 
 // InviteEmployee handles the business logic for creating a new employee in an 'INVITED' state.
-func (s *defaultService) InviteEmployee(ctx context.Context, clinicID, inviterID uuid.UUID, req InviteEmployeeRequest) (*model.Employee, error) {
+// The employee and their single-use invitation token are created in the same transaction.
+func (s *defaultService) InviteEmployee(ctx context.Context, clinicID, inviterID uuid.UUID, req InviteEmployeeRequest) (*model.Employee, *IssuedInvitation, error) {
+	if req.PhoneNumber != nil {
+		region, err := s.phoneRegions.DefaultPhoneRegion(ctx, clinicID)
+		if err != nil {
+			return nil, nil, err
+		}
+		normalized, err := phone.Normalize(*req.PhoneNumber, region)
+		if err != nil {
+			return nil, nil, apierror.NewBadRequest(fmt.Sprintf("Invalid phone number: %s.", err), err)
+		}
+		req.PhoneNumber = &normalized
+	}
+
 	profileID := uuid.Must(uuid.NewV7())
 
 	newProfile := &model.Profile{
@@ -58,77 +136,1508 @@ func (s *defaultService) InviteEmployee(ctx context.Context, clinicID, inviterID
 		Profile:     *newProfile, // Embed profile for response mapping
 	}
 
-	// Use the transaction helper
-	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
-		// The repository methods now need to accept a Querier (tx or pool)
-		return s.repo.CreateInvitedEmployee(ctx, tx, newProfile, newEmployee)
+	invitation, issued, err := s.newInvitation(clinicID, profileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	message := s.invitationMessage(newProfile, issued)
+
+	// The profile, employee and invitation rows commit or roll back together.
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.CreateInvitedEmployee(ctx, tx, newProfile, newEmployee); err != nil {
+			return err
+		}
+		if err := s.repo.CreateInviteToken(ctx, tx, invitation); err != nil {
+			return err
+		}
+		return s.queueInvitation(ctx, tx, clinicID, profileID, message)
 	})
 
 	if err != nil {
-		return nil, err // Error is already wrapped and transaction is rolled back.
+		return nil, nil, err // Error is already wrapped and transaction is rolled back.
 	}
 
 	newEmployee.Profile = *newProfile
-	// In a real flow, we would now generate an invitation token and send an email/SMS.
-	// For now, creating the record is sufficient.
-	return newEmployee, nil
+	// The token is also returned once to the caller; only its hash is stored.
+	return newEmployee, issued, nil
+}
+
+// ResendInvite retires the employee's outstanding invitation tokens, issues a fresh one and
+// sends it again. Only employees still in INVITED status can be re-invited.
+func (s *defaultService) ResendInvite(ctx context.Context, clinicID, actorID, profileID uuid.UUID) (*IssuedInvitation, error) {
+	employee, err := s.repo.FindEmployeeByIDWithDetails(ctx, clinicID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, issued, err := s.newInvitation(clinicID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	message := s.invitationMessage(&employee.Profile, issued)
+	details, err := json.Marshal(map[string]any{"expires_at": invitation.ExpiresAt})
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to encode audit details: %w", err))
+	}
+
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		status, err := s.repo.FindEmployeeStatusForUpdate(ctx, tx, clinicID, profileID)
+		if err != nil {
+			return err
+		}
+		if status != model.EmployeeStatusInvited {
+			return apierror.NewConflict("This employee has already accepted their invitation or is no longer invited.", nil)
+		}
+		if err := s.repo.InvalidateInviteTokens(ctx, tx, profileID); err != nil {
+			return err
+		}
+		if err := s.repo.CreateInviteToken(ctx, tx, invitation); err != nil {
+			return err
+		}
+		if err := s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   clinicID,
+			UserID:     actorID,
+			Action:     model.AuditActionInviteResent,
+			TableName:  "employees",
+			RecordID:   profileID,
+			NewRecord:  details,
+			OccurredAt: time.Now(),
+		}); err != nil {
+			return err
+		}
+		return s.queueInvitation(ctx, tx, clinicID, profileID, message)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// newInvitation creates an invitation token for the employee. Only the returned row, which
+// holds the token's hash, is stored; the plaintext is in the IssuedInvitation.
+func (s *defaultService) newInvitation(clinicID, profileID uuid.UUID) (*model.Invitation, *IssuedInvitation, error) {
+	token, tokenHash, err := security.NewOpaqueToken()
+	if err != nil {
+		return nil, nil, apierror.NewInternalServer(fmt.Errorf("failed to generate invitation token: %w", err))
+	}
+	invitation := &model.Invitation{
+		ID:                uuid.Must(uuid.NewV7()),
+		EmployeeProfileID: profileID,
+		ClinicID:          clinicID,
+		TokenHash:         tokenHash,
+		ExpiresAt:         time.Now().Add(s.config.Security.InviteTokenDuration),
+	}
+	return invitation, &IssuedInvitation{Token: token, ExpiresAt: invitation.ExpiresAt}, nil
+}
+
+// invitationMessage addresses an invitation to the profile's email, or phone number if it has none.
+func (s *defaultService) invitationMessage(profile *model.Profile, issued *IssuedInvitation) InvitationMessage {
+	return InvitationMessage{
+		RecipientName: profile.FullName,
+		Email:         profile.Email,
+		PhoneNumber:   profile.PhoneNumber,
+		URL:           s.config.Security.InviteURL + "?token=" + url.QueryEscape(issued.Token),
+		ExpiresAt:     issued.ExpiresAt,
+	}
+}
+
+// queueInvitation publishes the invitation to the outbox within tx, so it is sent only if the
+// invite commits.
+func (s *defaultService) queueInvitation(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, message InvitationMessage) error {
+	plaintext, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode invitation: %w", err)
+	}
+	version, sealed, err := s.keys.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+	return s.outbox.Publish(ctx, tx, EventInvitationIssued, invitationIssued{ClinicID: clinicID, ProfileID: profileID, Message: sealed, KeyVersion: version})
+}
+
+// onInvitationIssued sends a queued invitation. An error leaves it to the outbox to retry.
+func (s *defaultService) onInvitationIssued(ctx context.Context, _ pgx.Tx, event events.Event) error {
+	var issued invitationIssued
+	if err := json.Unmarshal(event.Payload, &issued); err != nil {
+		return fmt.Errorf("failed to decode invitation event: %w", err)
+	}
+	version := issued.KeyVersion
+	if version == 0 {
+		version = 1
+	}
+	plaintext, err := s.keys.Open(version, issued.Message)
+	if err != nil {
+		return err
+	}
+	var message InvitationMessage
+	if err := json.Unmarshal(plaintext, &message); err != nil {
+		return fmt.Errorf("failed to decode invitation: %w", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, invitationSendTimeout)
+	defer cancel()
+	if err := s.notifier.SendInvitation(sendCtx, message); err != nil {
+		return fmt.Errorf("failed to send invitation to employee %s: %w", issued.ProfileID, err)
+	}
+	return nil
+}
+
+// AcceptInvite consumes the invitation token, stores the employee's password and activates them.
+// Everything happens in one transaction so a half-activated employee can never exist.
+func (s *defaultService) AcceptInvite(ctx context.Context, req AcceptInviteRequest) error {
+	if err := security.CheckPasswordStrength(req.Password); err != nil {
+		return err
+	}
+
+	// Hash before opening the transaction so row locks are not held during the slow KDF.
+	passwordHash, err := security.HashPasswordContext(ctx, req.Password)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to hash password: %w", err))
+	}
+
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		invitation, err := s.repo.ConsumeInviteToken(ctx, tx, security.HashOpaqueToken(req.Token))
+		if err != nil {
+			return err
+		}
+		if invitation.ClinicID != req.ClinicID {
+			return apierror.NewBadRequest("This invitation link is invalid.", nil)
+		}
+		return s.repo.ActivateEmployee(ctx, tx, invitation.ClinicID, invitation.EmployeeProfileID, passwordHash)
+	})
+}
+
+// ForgotPassword issues a password reset token for an active employee and mails it to them.
+// Earlier unused tokens are retired. Unknown addresses and inactive accounts are silently ignored.
+func (s *defaultService) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error {
+	employee, err := s.repo.FindEmployeeByEmail(ctx, req.ClinicID, req.Email)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return apierror.NewInternalServer(fmt.Errorf("failed to find employee: %w", err))
+	}
+	if employee.Status != model.EmployeeStatusActive || employee.PasswordHash == nil {
+		return nil
+	}
+
+	token, tokenHash, err := security.NewOpaqueToken()
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to generate password reset token: %w", err))
+	}
+	resetToken := &model.PasswordResetToken{
+		ID:                uuid.Must(uuid.NewV7()),
+		EmployeeProfileID: employee.ProfileID,
+		ClinicID:          req.ClinicID,
+		TokenHash:         tokenHash,
+		ExpiresAt:         time.Now().Add(s.config.Security.PasswordResetTokenDuration),
+	}
+
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.InvalidatePasswordResetTokens(ctx, tx, employee.ProfileID); err != nil {
+			return err
+		}
+		if err := s.repo.CreatePasswordResetToken(ctx, tx, resetToken); err != nil {
+			return err
+		}
+		s.AfterCommit(tx, func() {
+			if err := s.notifier.SendPasswordReset(context.WithoutCancel(ctx), req.Email, token, resetToken.ExpiresAt); err != nil {
+				log.Error().Err(err).Str("employee_id", employee.ProfileID.String()).Msg("Failed to send password reset")
+			}
+		})
+		return nil
+	})
+}
+
+// ResetPassword sets a new password using a reset token. The token and any other outstanding
+// reset tokens are retired, and every existing session of the employee is revoked.
+func (s *defaultService) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	if err := security.CheckPasswordStrength(req.Password); err != nil {
+		return err
+	}
+
+	// Hash before opening the transaction so row locks are not held during the slow KDF.
+	passwordHash, err := security.HashPasswordContext(ctx, req.Password)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to hash password: %w", err))
+	}
+
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		resetToken, err := s.repo.ConsumePasswordResetToken(ctx, tx, security.HashOpaqueToken(req.Token))
+		if err != nil {
+			return err
+		}
+		if resetToken.ClinicID != req.ClinicID {
+			return apierror.NewBadRequest("This password reset link is invalid or has expired.", nil)
+		}
+		profileID := resetToken.EmployeeProfileID
+
+		if err := s.repo.UpdateEmployeePassword(ctx, tx, req.ClinicID, profileID, passwordHash); err != nil {
+			return err
+		}
+		if err := s.repo.InvalidatePasswordResetTokens(ctx, tx, profileID); err != nil {
+			return err
+		}
+		if err := s.repo.RevokeRefreshTokensForEmployee(ctx, tx, req.ClinicID, profileID); err != nil {
+			return err
+		}
+
+		event := model.AuditEvent{
+			ClinicID:   req.ClinicID,
+			UserID:     profileID,
+			Action:     model.AuditActionPasswordReset,
+			TableName:  "employees",
+			RecordID:   profileID,
+			NewRecord:  json.RawMessage(`{}`),
+			OccurredAt: time.Now(),
+		}
+		if err := s.repo.CreateAuditEvent(ctx, tx, &event); err != nil {
+			return err
+		}
+
+		s.AfterCommit(tx, func() {
+			now := time.Now()
+			longestLifetime := max(s.config.Security.TokenDuration, s.config.Security.ImpersonationDuration)
+			if err := s.denylist.RevokeSubject(context.WithoutCancel(ctx), profileID, now, now.Add(longestLifetime)); err != nil {
+				log.Error().Err(err).Str("employee_id", profileID.String()).Msg("Failed to revoke access tokens after password reset")
+			}
+		})
+		return nil
+	})
 }
 
 // LoginEmployee handles authentication for staff members.
-func (s *defaultService) LoginEmployee(ctx context.Context, req LoginEmployeeRequest) (string, *model.Employee, error) {
-	// Login is a public action, so it doesn't use the auth payload from context.
-	// It needs a clinic_id, which would typically be derived from a subdomain or a header.
-	// For now, we'll assume a placeholder. This needs to be addressed when we build the full login flow.
-	// A real implementation would require a `FindClinicByDomain` method.
-	// --- THIS IS THE CRITICAL CORRECTION ---
-	// A login request is unauthenticated. It cannot have an AuthPayload.
-	// The request must contain enough information to identify the clinic.
-	// A real-world app would get this from the request's hostname (e.g., clinic-a.mastara.com)
-	// or a non-sensitive header like `X-Clinic-ID`.
-	// For now, we will simulate this by requiring the DTO to carry it.
-	// This makes the dependency explicit.
-
-	placeholderClinicID := uuid.Must(uuid.NewV7()) // THIS IS A PLACEHOLDER
+func (s *defaultService) LoginEmployee(ctx context.Context, req LoginEmployeeRequest) (*Session, *model.Employee, error) {
+	// Login is a public action: the clinic is resolved from the request host or X-Clinic-Slug
+	// header by the ResolveClinic middleware before this runs.
+	if req.ClinicID == uuid.Nil {
+		return nil, nil, apierror.NewBadRequest("clinic is required for login", nil)
+	}
 
 	var employee *model.Employee
+	var identifier string
 	var err error
 	if req.Email != nil {
-		employee, err = s.repo.FindEmployeeByEmail(ctx, placeholderClinicID, *req.Email)
+		identifier = *req.Email
+		employee, err = s.repo.FindEmployeeByEmail(ctx, req.ClinicID, *req.Email)
 	} else if req.Phone != nil {
-		employee, err = s.repo.FindEmployeeByPhone(ctx, placeholderClinicID, *req.Phone)
+		identifier = *req.Phone
+		employee, err = s.repo.FindEmployeeByPhone(ctx, req.ClinicID, *req.Phone)
 	} else {
-		return "", nil, apierror.NewBadRequest("email or phone is required for login", nil)
+		return nil, nil, apierror.NewBadRequest("email or phone is required for login", nil)
 	}
 
+	// Unknown identifiers and accounts without a password get the same Argon2 work and the same
+	// error as a wrong password, so neither timing nor the response reveals which accounts exist.
 	if err != nil {
-		if _, ok := err.(*apierror.APIError); ok {
-			return "", nil, apierror.NewUnauthorized("invalid credentials", err)
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			security.CompareDummyHash(ctx, req.Password)
+			s.failures.RecordFailure(req, identifier, model.AuthFailureUnknownIdentifier)
+			return nil, nil, apierror.NewUnauthorized("invalid credentials", err)
 		}
-		return "", nil, apierror.NewInternalServer(fmt.Errorf("failed to find employee: %w", err))
+		return nil, nil, apierror.NewInternalServer(fmt.Errorf("failed to find employee: %w", err))
 	}
 
 	if employee.PasswordHash == nil {
-		return "", nil, apierror.NewUnauthorized("invalid credentials (account not fully set up)", nil)
+		security.CompareDummyHash(ctx, req.Password)
+		s.failures.RecordEmployeeFailure(req, identifier, employee.ProfileID, model.AuthFailureNoPassword)
+		return nil, nil, apierror.NewUnauthorized("invalid credentials", nil)
+	}
+
+	// The password is always checked, and a wrong one gets the same 401 whether or not the
+	// account is locked, so neither timing nor the response tells a guesser that the account
+	// exists or is locked. Only someone who knows the password learns of the lock; the employee
+	// is emailed when it starts. A failing lockout store must not lock everyone out, so its
+	// errors are only logged.
+	lockState, err := s.lockout.Status(ctx, employee.ProfileID)
+	if err != nil {
+		log.Error().Err(err).Str("profile_id", employee.ProfileID.String()).Msg("Failed to read login lockout state")
+	}
+	locked := lockState.Locked(time.Now())
+
+	needsRehash, err := security.ComparePasswordAndHashWithUpgrade(ctx, req.Password, *employee.PasswordHash)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			if locked {
+				s.failures.RecordEmployeeFailure(req, identifier, employee.ProfileID, model.AuthFailureLocked)
+			} else {
+				s.failures.RecordEmployeeFailure(req, identifier, employee.ProfileID, model.AuthFailureBadPassword)
+				s.recordLockoutFailure(ctx, employee)
+			}
+		}
+		return nil, nil, err
 	}
-	if err := security.ComparePasswordAndHash(req.Password, *employee.PasswordHash); err != nil {
+	if locked {
+		s.failures.RecordEmployeeFailure(req, identifier, employee.ProfileID, model.AuthFailureLocked)
+		return nil, nil, apierror.NewTooManyRequests("This account is temporarily locked after too many failed sign-in attempts. Try again later or contact your clinic administrator.", time.Until(*lockState.LockedUntil), nil).WithCode(apierror.CodeAccountLocked)
+	}
+	switch employee.Status {
+	case model.EmployeeStatusSuspended:
+		s.failures.RecordEmployeeFailure(req, identifier, employee.ProfileID, model.AuthFailureSuspended)
+		return nil, nil, apierror.NewUnauthorized("This account has been suspended. Contact your clinic administrator.", nil)
+	case model.EmployeeStatusTerminated:
+		s.failures.RecordEmployeeFailure(req, identifier, employee.ProfileID, model.AuthFailureTerminated)
+		return nil, nil, apierror.NewUnauthorized("This account has been deactivated.", nil)
+	}
+
+	roles, err := s.repo.FindRolesForEmployee(ctx, s.db, employee.ProfileID)
+	if err != nil {
+		return nil, nil, apierror.NewInternalServer(fmt.Errorf("failed to fetch employee roles: %w", err))
+	}
+	employee.Roles = roles
+
+	var session *Session
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		session, err = s.issueSession(ctx, tx, employee, uuid.Must(uuid.NewV7()))
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.failures.RecordSuccess(identifier, employee.ProfileID)
+	if lockState.FailedAttempts > 0 {
+		if err := s.lockout.Clear(ctx, employee.ProfileID); err != nil {
+			log.Error().Err(err).Str("profile_id", employee.ProfileID.String()).Msg("Failed to clear login failures")
+		}
+	}
+
+	// The session is already issued; a failed history write must not turn it into a failed login.
+	if err := s.repo.RecordLogin(ctx, employee.ProfileID, req.IPAddress, req.UserAgent); err != nil {
+		log.Error().Err(err).Str("profile_id", employee.ProfileID.String()).Msg("Failed to record login")
+	} else {
+		now := time.Now()
+		employee.LastLoginAt = &now
+	}
+
+	if needsRehash {
+		s.upgradePasswordHash(ctx, employee.ProfileID, *employee.PasswordHash, req.Password)
+	}
+
+	return session, employee, nil
+}
+
+// recordLockoutFailure counts a wrong password towards the account's lockout.
+func (s *defaultService) recordLockoutFailure(ctx context.Context, employee *model.Employee) {
+	state, err := s.lockout.RecordFailure(ctx, employee.ProfileID)
+	if err != nil {
+		log.Error().Err(err).Str("profile_id", employee.ProfileID.String()).Msg("Failed to record login failure for lockout")
+		return
+	}
+	// Failures are only recorded against unlocked accounts, so a lock here is new.
+	if state.LockedUntil == nil {
+		return
+	}
+	log.Warn().
+		Str("profile_id", employee.ProfileID.String()).
+		Time("locked_until", *state.LockedUntil).
+		Msg("Employee account locked after repeated failed logins")
+
+	// The login response must not reveal the lock, so the employee hears of it by email. It is
+	// sent in the background so this attempt takes no longer than any other failure.
+	if employee.Profile.Email == nil {
+		return
+	}
+	to, lockedUntil := *employee.Profile.Email, *state.LockedUntil
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockNoticeTimeout)
+		defer cancel()
+		if err := s.notifier.SendAccountLocked(sendCtx, to, lockedUntil); err != nil {
+			log.Error().Err(err).Str("profile_id", employee.ProfileID.String()).Msg("Failed to send account lock notice")
+		}
+	}()
+}
+
+// upgradePasswordHash re-hashes a just-verified password with the current Argon2 parameters.
+// Failures are only logged: the old hash still works and the upgrade is retried on the next login.
+func (s *defaultService) upgradePasswordHash(ctx context.Context, profileID uuid.UUID, currentHash, password string) {
+	newHash, err := security.HashPasswordContext(ctx, password)
+	if err != nil {
+		log.Error().Err(err).Str("profile_id", profileID.String()).Msg("Failed to re-hash password")
+		return
+	}
+	if _, err := s.repo.UpdatePasswordHash(ctx, profileID, currentHash, newHash); err != nil {
+		log.Error().Err(err).Str("profile_id", profileID.String()).Msg("Failed to store upgraded password hash")
+	}
+}
+
+// ListLoginEvents returns an employee's most recent login attempts, newest first.
+func (s *defaultService) ListLoginEvents(ctx context.Context, clinicID, profileID uuid.UUID, limit int) ([]model.LoginEvent, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return s.repo.ListLoginEvents(ctx, clinicID, profileID, limit)
+}
+
+// ListAuthFailures returns rejected authentication attempts matching the filter, newest first.
+func (s *defaultService) ListAuthFailures(ctx context.Context, filter model.AuthFailureFilter) ([]model.AuthFailure, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Limit > 200 {
+		filter.Limit = 200
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, apierror.NewBadRequest("'from' must not be after 'to'", nil)
+	}
+	if filter.Identifier != "" {
+		filter.IdentifierHash = s.failures.HashIdentifier(filter.Identifier)
+	}
+
+	failures, err := s.repo.ListAuthFailures(ctx, filter)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list auth failures: %w", err))
+	}
+	return failures, nil
+}
+
+// errRefreshTokenReuse signals that a rotated refresh token was presented again.
+var errRefreshTokenReuse = errors.New("refresh token reuse detected")
+
+// RefreshSession validates and rotates a refresh token, returning a fresh token pair for the same session.
+// Presenting an already-rotated token is treated as theft and revokes the whole session.
+func (s *defaultService) RefreshSession(ctx context.Context, req RefreshSessionRequest) (*Session, error) {
+	var session *Session
+	var reused *model.RefreshToken
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		current, err := s.repo.FindRefreshTokenForUpdate(ctx, tx, security.HashOpaqueToken(req.RefreshToken))
+		if err != nil {
+			return err
+		}
+		if current.ClinicID != req.ClinicID {
+			return apierror.NewUnauthorized("invalid refresh token", nil)
+		}
+		if current.RevokedAt != nil {
+			if current.ReplacedBy != nil {
+				reused = current
+				return errRefreshTokenReuse
+			}
+			return apierror.NewUnauthorized("refresh token has been revoked", nil)
+		}
+		if !time.Now().Before(current.ExpiresAt) {
+			return apierror.NewUnauthorized("refresh token has expired", nil).WithCode(apierror.CodeTokenExpired)
+		}
+
+		employee, err := s.repo.FindEmployeeByIDWithDetails(ctx, current.ClinicID, current.EmployeeProfileID)
+		if err != nil {
+			return apierror.NewUnauthorized("invalid refresh token", err)
+		}
+		if employee.Status != model.EmployeeStatusActive {
+			return apierror.NewUnauthorized("account is not active", nil)
+		}
+		roles, err := s.repo.FindRolesForEmployee(ctx, tx, employee.ProfileID)
+		if err != nil {
+			return apierror.NewInternalServer(fmt.Errorf("failed to fetch employee roles: %w", err))
+		}
+		employee.Roles = roles
+
+		if session, err = s.issueSession(ctx, tx, employee, current.SessionID); err != nil {
+			return err
+		}
+		return s.repo.RotateRefreshToken(ctx, tx, current.ID, session.refreshTokenID)
+	})
+
+	if errors.Is(err, errRefreshTokenReuse) {
+		log.Warn().
+			Str("session_id", reused.SessionID.String()).
+			Str("employee_id", reused.EmployeeProfileID.String()).
+			Msg("Rotated refresh token presented again; revoking session")
+		if err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+			return s.repo.RevokeRefreshSession(ctx, tx, reused.SessionID)
+		}); err != nil {
+			return nil, err
+		}
+		return nil, apierror.NewUnauthorized("refresh token has been revoked", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RevokeSession revokes every refresh token of the session the presented token belongs to.
+func (s *defaultService) RevokeSession(ctx context.Context, req RefreshSessionRequest) error {
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		current, err := s.repo.FindRefreshTokenForUpdate(ctx, tx, security.HashOpaqueToken(req.RefreshToken))
+		if err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) {
+				return nil // Unknown tokens are ignored so this endpoint cannot be used to probe tokens.
+			}
+			return err
+		}
+		if current.ClinicID != req.ClinicID {
+			return nil
+		}
+		return s.repo.RevokeRefreshSession(ctx, tx, current.SessionID)
+	})
+}
+
+// Logout revokes the presented access token until it expires.
+func (s *defaultService) Logout(ctx context.Context, payload *security.AuthPayload) error {
+	if err := s.denylist.RevokeToken(ctx, payload.TokenID, payload.ExpiresAt); err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to revoke token: %w", err))
+	}
+	return nil
+}
+
+// RevokeAllSessionsForEmployee invalidates every access token issued to the employee so far
+// and revokes all of their refresh tokens, e.g. when a staff member is terminated.
+func (s *defaultService) RevokeAllSessionsForEmployee(ctx context.Context, clinicID, profileID uuid.UUID) error {
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.RevokeRefreshTokensForEmployee(ctx, tx, clinicID, profileID)
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	longestLifetime := max(s.config.Security.TokenDuration, s.config.Security.ImpersonationDuration)
+	if err := s.denylist.RevokeSubject(ctx, profileID, now, now.Add(longestLifetime)); err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to revoke access tokens: %w", err))
+	}
+	return nil
+}
+
+// issueSession mints an access token and a new refresh token belonging to sessionID.
+func (s *defaultService) issueSession(ctx context.Context, tx pgx.Tx, employee *model.Employee, sessionID uuid.UUID) (*Session, error) {
+	authPayload, err := employee.ToAuthPayload(s.config.Security.TokenDuration)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to create auth payload: %w", err))
+	}
+	if authPayload.Sandbox, err = s.repo.IsSandboxClinic(ctx, employee.ClinicID); err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to load clinic sandbox flag: %w", err))
+	}
+	accessToken, err := s.sec.CreateToken(authPayload)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to create token: %w", err))
+	}
+
+	refreshToken, refreshHash, err := security.NewOpaqueToken()
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to generate refresh token: %w", err))
+	}
+	record := &model.RefreshToken{
+		ID:                uuid.Must(uuid.NewV7()),
+		SessionID:         sessionID,
+		EmployeeProfileID: employee.ProfileID,
+		ClinicID:          employee.ClinicID,
+		TokenHash:         refreshHash,
+		ExpiresAt:         time.Now().Add(s.config.Security.RefreshTokenDuration),
+	}
+	if err := s.repo.CreateRefreshToken(ctx, tx, record); err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  authPayload.ExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: record.ExpiresAt,
+		Sandbox:               authPayload.Sandbox,
+		refreshTokenID:        record.ID,
+	}, nil
+}
+
+// ImpersonateEmployee issues an impersonation token for the target employee on behalf of a platform operator.
+// The issuance is written to the audit log, with the token's expiry in its details: the audit log only
+// records what has already happened.
+func (s *defaultService) ImpersonateEmployee(ctx context.Context, operatorID uuid.UUID, req ImpersonateEmployeeRequest) (string, *security.AuthPayload, error) {
+	// Platform operators impersonate employees of clinics other than their own.
+	ctx = database.WithClinic(ctx, req.ClinicID)
+	employee, err := s.repo.FindEmployeeByIDWithDetails(ctx, req.ClinicID, req.EmployeeID)
+	if err != nil {
 		return "", nil, err
 	}
+	if employee.Status != model.EmployeeStatusActive {
+		return "", nil, apierror.NewBadRequest("Only active employees can be impersonated.", nil)
+	}
 
-	roles, err := s.repo.FindRolesForEmployee(ctx, employee.ProfileID)
+	roles, err := s.repo.FindRolesForEmployee(ctx, s.db, employee.ProfileID)
 	if err != nil {
 		return "", nil, apierror.NewInternalServer(fmt.Errorf("failed to fetch employee roles: %w", err))
 	}
 	employee.Roles = roles
 
-	authPayload, err := employee.ToAuthPayload(s.config.Security.TokenDuration)
+	authPayload, err := employee.ToAuthPayload(s.config.Security.ImpersonationDuration)
 	if err != nil {
 		return "", nil, apierror.NewInternalServer(fmt.Errorf("failed to create auth payload: %w", err))
 	}
+	authPayload.ImpersonatorID = &operatorID
+	if authPayload.Sandbox, err = s.repo.IsSandboxClinic(ctx, employee.ClinicID); err != nil {
+		return "", nil, apierror.NewInternalServer(fmt.Errorf("failed to load clinic sandbox flag: %w", err))
+	}
+
+	details, err := json.Marshal(map[string]any{
+		"token_id":   authPayload.TokenID,
+		"operator":   operatorID,
+		"expires_at": authPayload.ExpiresAt,
+	})
+	if err != nil {
+		return "", nil, apierror.NewInternalServer(fmt.Errorf("failed to marshal impersonation details: %w", err))
+	}
+
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   employee.ClinicID,
+			UserID:     operatorID,
+			Action:     model.AuditActionImpersonationIssued,
+			TableName:  "employees",
+			RecordID:   employee.ProfileID,
+			NewRecord:  details,
+			OccurredAt: authPayload.IssuedAt,
+		})
+	})
+	if err != nil {
+		return "", nil, err
+	}
 
 	token, err := s.sec.CreateToken(authPayload)
 	if err != nil {
 		return "", nil, apierror.NewInternalServer(fmt.Errorf("failed to create token: %w", err))
 	}
 
-	return token, employee, nil
+	return token, authPayload, nil
+}
+
+// UpdatePublicProfile stores the employee's public listing fields and drops the clinic's cached directory.
+func (s *defaultService) UpdatePublicProfile(ctx context.Context, clinicID, profileID uuid.UUID, profile model.PublicProfile) error {
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.UpdatePublicProfile(ctx, tx, clinicID, profileID, &profile); err != nil {
+			return err
+		}
+		s.AfterCommit(tx, func() { s.practitioners.Delete(clinicID) })
+		return nil
+	})
+}
+
+// ListPublicPractitioners serves the public practitioner directory from a short per-clinic cache.
+func (s *defaultService) ListPublicPractitioners(ctx context.Context, clinicID uuid.UUID) ([]model.Practitioner, error) {
+	if cached, ok := s.practitioners.Get(clinicID); ok {
+		return cached, nil
+	}
+	practitioners, err := s.repo.ListPublicPractitioners(ctx, clinicID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list practitioners: %w", err))
+	}
+	s.practitioners.Set(clinicID, practitioners)
+	return practitioners, nil
+}
+
+// CreateRole creates a clinic role and its permission set in one transaction.
+func (s *defaultService) CreateRole(ctx context.Context, clinicID uuid.UUID, req RoleRequest) (*model.Role, error) {
+	role := &model.Role{
+		ID:          uuid.Must(uuid.NewV7()),
+		ClinicID:    &clinicID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.CreateRole(ctx, tx, role); err != nil {
+			return err
+		}
+		permissions, err := s.repo.UpdateRolePermissions(ctx, tx, role.ID, slices.Compact(slices.Sorted(slices.Values(req.Permissions))))
+		role.Permissions = permissions
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// ListPermissions returns the permissions clinic roles can be granted.
+func (s *defaultService) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	permissions, err := s.repo.ListPermissions(ctx)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list permissions: %w", err))
+	}
+	return permissions, nil
+}
+
+// ListRoles returns every role the clinic can assign or inspect.
+func (s *defaultService) ListRoles(ctx context.Context, clinicID uuid.UUID) ([]model.Role, error) {
+	roles, err := s.repo.ListRolesForClinic(ctx, clinicID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list roles: %w", err))
+	}
+	return roles, nil
+}
+
+// GetRole returns a single role visible to the clinic.
+func (s *defaultService) GetRole(ctx context.Context, clinicID, roleID uuid.UUID) (*model.Role, error) {
+	return s.repo.FindRoleByID(ctx, clinicID, roleID)
+}
+
+// UpdateRole replaces the role's fields and permission set atomically.
+func (s *defaultService) UpdateRole(ctx context.Context, clinicID, roleID uuid.UUID, req RoleRequest) (*model.Role, error) {
+	role := &model.Role{
+		ID:          roleID,
+		ClinicID:    &clinicID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		existing, err := s.repo.FindRoleForUpdate(ctx, tx, clinicID, roleID)
+		if err != nil {
+			return err
+		}
+		if existing.IsSystemRole {
+			return apierror.NewForbidden("System roles are read-only.", nil)
+		}
+		if err := s.repo.UpdateRole(ctx, tx, role); err != nil {
+			return err
+		}
+		permissions, err := s.repo.UpdateRolePermissions(ctx, tx, role.ID, slices.Compact(slices.Sorted(slices.Values(req.Permissions))))
+		role.Permissions = permissions
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// ReassignRole moves every holder of req.RoleID to req.TargetRoleID in one transaction.
+func (s *defaultService) ReassignRole(ctx context.Context, clinicID, actorID uuid.UUID, req ReassignRoleRequest) (int, error) {
+	var moved int
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		moved, err = s.reassignRoleHolders(ctx, tx, clinicID, actorID, req.RoleID, req.TargetRoleID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// DeleteRole soft-deletes a clinic role. A role that still has holders can only be deleted
+// when a replacement role is given; the holders are moved and the role deleted atomically.
+func (s *defaultService) DeleteRole(ctx context.Context, clinicID, actorID uuid.UUID, req DeleteRoleRequest) (int, error) {
+	var moved int
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		role, err := s.repo.FindRoleForUpdate(ctx, tx, clinicID, req.RoleID)
+		if err != nil {
+			return err
+		}
+		if role.IsSystemRole {
+			return apierror.NewForbidden("System roles cannot be deleted.", nil)
+		}
+
+		if req.ReassignTo != nil {
+			if moved, err = s.reassignRoleHolders(ctx, tx, clinicID, actorID, req.RoleID, *req.ReassignTo); err != nil {
+				return err
+			}
+		} else {
+			holders, err := s.repo.CountRoleHolders(ctx, tx, clinicID, req.RoleID)
+			if err != nil {
+				return err
+			}
+			if holders > 0 {
+				return apierror.NewConflict(fmt.Sprintf("Role is still assigned to %d employee(s). Pass 'reassign_to' to move them first.", holders), nil)
+			}
+		}
+
+		return s.repo.SoftDeleteRole(ctx, tx, clinicID, req.RoleID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// reassignRoleHolders validates both roles and moves the holders within an existing transaction,
+// writing one audit event per affected employee.
+func (s *defaultService) reassignRoleHolders(ctx context.Context, tx pgx.Tx, clinicID, actorID, fromRoleID, toRoleID uuid.UUID) (int, error) {
+	if fromRoleID == toRoleID {
+		return 0, apierror.NewBadRequest("The target role must differ from the source role.", nil)
+	}
+	if _, err := s.repo.FindRoleForUpdate(ctx, tx, clinicID, fromRoleID); err != nil {
+		return 0, err
+	}
+	if _, err := s.repo.FindRoleForUpdate(ctx, tx, clinicID, toRoleID); err != nil {
+		return 0, err
+	}
+
+	employeeIDs, err := s.repo.MoveRoleHolders(ctx, tx, clinicID, fromRoleID, toRoleID)
+	if err != nil {
+		return 0, err
+	}
+
+	details, err := json.Marshal(map[string]any{
+		"from_role_id": fromRoleID,
+		"to_role_id":   toRoleID,
+	})
+	if err != nil {
+		return 0, apierror.NewInternalServer(fmt.Errorf("failed to marshal reassignment details: %w", err))
+	}
+	now := time.Now()
+	for _, employeeID := range employeeIDs {
+		event := model.AuditEvent{
+			ClinicID:   clinicID,
+			UserID:     actorID,
+			Action:     model.AuditActionRoleReassigned,
+			TableName:  "employee_roles",
+			RecordID:   employeeID,
+			NewRecord:  details,
+			OccurredAt: now,
+		}
+		if err := s.repo.CreateAuditEvent(ctx, tx, &event); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(employeeIDs), nil
+}
+
+// AssignRole grants a role visible to the clinic. Roles of other clinics are rejected, as are
+// roles carrying platform permissions, which only platform staff may hold.
+func (s *defaultService) AssignRole(ctx context.Context, clinicID, actorID uuid.UUID, req EmployeeRoleRequest) ([]model.Role, error) {
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.LockEmployee(ctx, tx, clinicID, req.EmployeeID); err != nil {
+			return err
+		}
+		if _, err := s.repo.FindRoleForUpdate(ctx, tx, clinicID, req.RoleID); err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return apierror.NewBadRequest("The role does not exist in this clinic.", err)
+			}
+			return err
+		}
+		role, err := s.repo.FindRoleByID(ctx, clinicID, req.RoleID)
+		if err != nil {
+			return err
+		}
+		for _, p := range role.Permissions {
+			if strings.HasPrefix(p.PermissionKey, model.PlatformPermissionPrefix) {
+				return apierror.NewForbidden("Roles with platform permissions cannot be assigned to clinic employees.", nil)
+			}
+		}
+
+		added, err := s.repo.AddEmployeeRole(ctx, tx, req.EmployeeID, req.RoleID)
+		if err != nil || !added {
+			return err
+		}
+		return s.recordEmployeeRoleChange(ctx, tx, clinicID, actorID, model.AuditActionRoleAssigned, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.employeeRoles(ctx, s.db, req.EmployeeID)
+}
+
+// RemoveRole takes a role away from an employee. Removing a role the employee does not hold is a no-op.
+// The employee's access tokens are revoked so the lost permissions stop working before the tokens expire;
+// clients obtain a fresh token through the refresh endpoint.
+func (s *defaultService) RemoveRole(ctx context.Context, clinicID, actorID uuid.UUID, req EmployeeRoleRequest) ([]model.Role, error) {
+	var removed bool
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.LockEmployee(ctx, tx, clinicID, req.EmployeeID); err != nil {
+			return err
+		}
+		var err error
+		if removed, err = s.repo.RemoveEmployeeRole(ctx, tx, req.EmployeeID, req.RoleID); err != nil || !removed {
+			return err
+		}
+		return s.recordEmployeeRoleChange(ctx, tx, clinicID, actorID, model.AuditActionRoleRemoved, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if removed {
+		now := time.Now()
+		longestLifetime := max(s.config.Security.TokenDuration, s.config.Security.ImpersonationDuration)
+		if err := s.denylist.RevokeSubject(ctx, req.EmployeeID, now, now.Add(longestLifetime)); err != nil {
+			return nil, apierror.NewInternalServer(fmt.Errorf("failed to revoke access tokens: %w", err))
+		}
+	}
+	return s.employeeRoles(ctx, s.db, req.EmployeeID)
+}
+
+// recordEmployeeRoleChange writes the audit event for a single role grant or removal.
+func (s *defaultService) recordEmployeeRoleChange(ctx context.Context, tx pgx.Tx, clinicID, actorID uuid.UUID, action string, req EmployeeRoleRequest) error {
+	details, err := json.Marshal(map[string]any{"role_id": req.RoleID})
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to marshal role change details: %w", err))
+	}
+	event := model.AuditEvent{
+		ClinicID:   clinicID,
+		UserID:     actorID,
+		Action:     action,
+		TableName:  "employee_roles",
+		RecordID:   req.EmployeeID,
+		NewRecord:  details,
+		OccurredAt: time.Now(),
+	}
+	return s.repo.CreateAuditEvent(ctx, tx, &event)
+}
+
+// employeeRoles loads the employee's roles through querier: the primary right after a role
+// change, the reader for plain reads.
+func (s *defaultService) employeeRoles(ctx context.Context, querier database.Querier, profileID uuid.UUID) ([]model.Role, error) {
+	roles, err := s.repo.FindRolesForEmployee(ctx, querier, profileID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to fetch employee roles: %w", err))
+	}
+	return roles, nil
+}
+
+// ChangeEmployeeStatus applies an administrative status change. Suspended and terminated employees
+// lose their refresh tokens in the same transaction and their access tokens once it commits.
+func (s *defaultService) ChangeEmployeeStatus(ctx context.Context, clinicID, actorID uuid.UUID, req ChangeEmployeeStatusRequest) error {
+	if req.EmployeeID == actorID {
+		return apierror.NewForbidden("You cannot change your own employment status.", nil)
+	}
+
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		current, err := s.repo.FindEmployeeStatusForUpdate(ctx, tx, clinicID, req.EmployeeID)
+		if err != nil {
+			return err
+		}
+		if current == req.Status {
+			return nil
+		}
+		if !current.CanTransitionTo(req.Status) {
+			return apierror.NewConflict(fmt.Sprintf("An employee cannot move from %s to %s.", current, req.Status), nil)
+		}
+		if err := s.repo.UpdateEmployeeStatus(ctx, tx, clinicID, req.EmployeeID, req.Status); err != nil {
+			return err
+		}
+
+		details, err := json.Marshal(map[string]any{
+			"from":   current,
+			"to":     req.Status,
+			"reason": req.Reason,
+		})
+		if err != nil {
+			return apierror.NewInternalServer(fmt.Errorf("failed to marshal status change details: %w", err))
+		}
+		event := model.AuditEvent{
+			ClinicID:   clinicID,
+			UserID:     actorID,
+			Action:     model.AuditActionStatusChanged,
+			TableName:  "employees",
+			RecordID:   req.EmployeeID,
+			NewRecord:  details,
+			OccurredAt: time.Now(),
+		}
+		if err := s.repo.CreateAuditEvent(ctx, tx, &event); err != nil {
+			return err
+		}
+
+		if req.Status == model.EmployeeStatusActive {
+			return nil
+		}
+		if err := s.repo.RevokeRefreshTokensForEmployee(ctx, tx, clinicID, req.EmployeeID); err != nil {
+			return err
+		}
+		s.AfterCommit(tx, func() {
+			now := time.Now()
+			longestLifetime := max(s.config.Security.TokenDuration, s.config.Security.ImpersonationDuration)
+			if err := s.denylist.RevokeSubject(context.WithoutCancel(ctx), req.EmployeeID, now, now.Add(longestLifetime)); err != nil {
+				log.Error().Err(err).Str("employee_id", req.EmployeeID.String()).Msg("Failed to revoke access tokens after status change")
+			}
+		})
+		return nil
+	})
+}
+
+// GetSecurityStatus combines the employee's lockout state with their active session count.
+func (s *defaultService) GetSecurityStatus(ctx context.Context, clinicID, profileID uuid.UUID) (*model.SecurityStatus, error) {
+	if _, err := s.repo.FindEmployeeByIDWithDetails(ctx, clinicID, profileID); err != nil {
+		return nil, err
+	}
+
+	state, err := s.lockout.Status(ctx, profileID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to read lockout state: %w", err))
+	}
+	sessions, err := s.repo.CountActiveSessions(ctx, clinicID, profileID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to count sessions: %w", err))
+	}
+
+	return &model.SecurityStatus{
+		Locked:         state.Locked(time.Now()),
+		LockedUntil:    state.LockedUntil,
+		FailedAttempts: state.FailedAttempts,
+		ActiveSessions: sessions,
+	}, nil
+}
+
+// UnlockEmployee clears the employee's lockout. The audit event is written first so an unlock
+// is never applied without a record of who did it.
+func (s *defaultService) UnlockEmployee(ctx context.Context, clinicID, actorID, profileID uuid.UUID) error {
+	if _, err := s.repo.FindEmployeeByIDWithDetails(ctx, clinicID, profileID); err != nil {
+		return err
+	}
+
+	state, err := s.lockout.Status(ctx, profileID)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to read lockout state: %w", err))
+	}
+	details, err := json.Marshal(map[string]any{
+		"was_locked":      state.Locked(time.Now()),
+		"failed_attempts": state.FailedAttempts,
+	})
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to encode audit details: %w", err))
+	}
+
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   clinicID,
+			UserID:     actorID,
+			Action:     model.AuditActionEmployeeUnlocked,
+			TableName:  "employees",
+			RecordID:   profileID,
+			NewRecord:  details,
+			OccurredAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.lockout.Clear(ctx, profileID); err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to clear lockout: %w", err))
+	}
+	return nil
+}
+
+// ListEmployees returns the clinic's employees. Which fields a caller may see is decided by the handler.
+func (s *defaultService) ListEmployees(ctx context.Context, clinicID uuid.UUID) ([]model.Employee, error) {
+	employees, err := s.repo.ListEmployees(ctx, clinicID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list employees: %w", err))
+	}
+	return employees, nil
+}
+
+// ListEmployeesVersion returns the count, latest update and role checksum of the clinic's employees.
+func (s *defaultService) ListEmployeesVersion(ctx context.Context, clinicID uuid.UUID) (database.ListVersion, error) {
+	version, err := s.repo.ListEmployeesVersion(ctx, clinicID)
+	if err != nil {
+		return database.ListVersion{}, apierror.NewInternalServer(fmt.Errorf("failed to probe employees: %w", err))
+	}
+	return version, nil
+}
+
+// GetEmployee returns a single employee with their roles.
+func (s *defaultService) GetEmployee(ctx context.Context, clinicID, profileID uuid.UUID) (*model.Employee, error) {
+	employee, err := s.repo.FindEmployeeByIDWithDetails(ctx, clinicID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if employee.Roles, err = s.employeeRoles(ctx, s.reader, employee.ProfileID); err != nil {
+		return nil, err
+	}
+	return employee, nil
+}
+
+// UpdateEmployee applies a partial update to an employee's details. Row changes are
+// captured by the audit triggers, so no explicit audit event is written.
+func (s *defaultService) UpdateEmployee(ctx context.Context, clinicID uuid.UUID, req UpdateEmployeeRequest) error {
+	if req.FullName.IsNull() {
+		return apierror.NewBadRequest("An employee's full name cannot be cleared.", nil)
+	}
+
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		// Locks the employee row and reports a 404 before anything is written.
+		if _, err := s.repo.FindEmployeeStatusForUpdate(ctx, tx, clinicID, req.EmployeeID); err != nil {
+			return err
+		}
+		return s.repo.UpdateEmployeeDetails(ctx, tx, clinicID, req.EmployeeID, &model.EmployeePatch{
+			FullName: req.FullName,
+			JobTitle: req.JobTitle,
+		})
+	})
+}
+
+// RequestEmailChange records a pending change and sends a verification token to the new address.
+// Any earlier open request for the employee is cancelled. Sessions are unaffected throughout,
+// since tokens are keyed on the profile ID rather than the email.
+func (s *defaultService) RequestEmailChange(ctx context.Context, clinicID, actorID uuid.UUID, req EmailChangeRequest) (*model.EmailChange, error) {
+	token, tokenHash, err := security.NewOpaqueToken()
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to generate email change token: %w", err))
+	}
+
+	var change *model.EmailChange
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		current, err := s.repo.FindEmployeeEmailForUpdate(ctx, tx, clinicID, req.EmployeeID)
+		if err != nil {
+			return err
+		}
+		if current != nil && strings.EqualFold(*current, req.NewEmail) {
+			return apierror.NewBadRequest("The new email address is the same as the current one.", nil)
+		}
+		inUse, err := s.repo.IsEmailInUse(ctx, tx, clinicID, req.NewEmail)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return apierror.NewConflict("This email address is already in use in this clinic.", nil)
+		}
+		if _, err := s.repo.CancelPendingEmailChange(ctx, tx, clinicID, req.EmployeeID); err != nil {
+			return err
+		}
+
+		change = &model.EmailChange{
+			ID:                uuid.Must(uuid.NewV7()),
+			ClinicID:          clinicID,
+			EmployeeProfileID: req.EmployeeID,
+			OldEmail:          current,
+			NewEmail:          req.NewEmail,
+			RequestedBy:       &actorID,
+			VerifyTokenHash:   tokenHash,
+			VerifyExpiresAt:   time.Now().Add(s.config.Security.EmailChangeTokenDuration),
+		}
+		if err := s.repo.CreateEmailChange(ctx, tx, change); err != nil {
+			return err
+		}
+		if err := s.recordEmailChange(ctx, tx, actorID, model.AuditActionEmailChangeRequested, change); err != nil {
+			return err
+		}
+
+		s.AfterCommit(tx, func() {
+			if err := s.notifier.SendEmailChangeVerification(context.WithoutCancel(ctx), change.NewEmail, token, change.VerifyExpiresAt); err != nil {
+				log.Error().Err(err).Str("employee_id", req.EmployeeID.String()).Msg("Failed to send email change verification")
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// GetPendingEmailChange returns the employee's open email change request.
+func (s *defaultService) GetPendingEmailChange(ctx context.Context, clinicID, employeeID uuid.UUID) (*model.EmailChange, error) {
+	return s.repo.FindPendingEmailChange(ctx, clinicID, employeeID)
+}
+
+// CancelEmailChange withdraws the employee's open email change request.
+func (s *defaultService) CancelEmailChange(ctx context.Context, clinicID, actorID, employeeID uuid.UUID) error {
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		change, err := s.repo.FindPendingEmailChange(ctx, clinicID, employeeID)
+		if err != nil {
+			return err
+		}
+		cancelled, err := s.repo.CancelPendingEmailChange(ctx, tx, clinicID, employeeID)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return apierror.NewNotFound("email change", nil)
+		}
+		return s.recordEmailChange(ctx, tx, actorID, model.AuditActionEmailChangeCancelled, change)
+	})
+}
+
+// VerifyEmailChange applies a pending change and sends the old address a notice with an undo token.
+// If the address was taken since the request, the transaction rolls back with a 409 and the
+// request stays pending until it expires or is cancelled.
+func (s *defaultService) VerifyEmailChange(ctx context.Context, clinicID uuid.UUID, token string) error {
+	undoToken, undoHash, err := security.NewOpaqueToken()
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to generate email change undo token: %w", err))
+	}
+
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		change, err := s.repo.FindEmailChangeByVerifyTokenForUpdate(ctx, tx, security.HashOpaqueToken(token))
+		if err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return apierror.NewBadRequest("This verification link is invalid.", err)
+			}
+			return err
+		}
+		now := time.Now()
+		if change.ClinicID != clinicID || !change.IsPending(now) {
+			return apierror.NewBadRequest("This verification link is invalid or has expired.", nil)
+		}
+
+		if err := s.repo.UpdateProfileEmail(ctx, tx, clinicID, change.EmployeeProfileID, &change.NewEmail); err != nil {
+			return err
+		}
+
+		change.VerifiedAt = &now
+		if change.OldEmail != nil {
+			undoExpiresAt := now.Add(model.EmailChangeUndoWindow)
+			change.UndoTokenHash = undoHash
+			change.UndoExpiresAt = &undoExpiresAt
+		}
+		if err := s.repo.MarkEmailChangeVerified(ctx, tx, change); err != nil {
+			return err
+		}
+		if err := s.recordEmailChange(ctx, tx, change.EmployeeProfileID, model.AuditActionEmailChangeVerified, change); err != nil {
+			return err
+		}
+
+		if change.OldEmail != nil {
+			s.AfterCommit(tx, func() {
+				if err := s.notifier.SendEmailChangeNotice(context.WithoutCancel(ctx), *change.OldEmail, change.NewEmail, undoToken, *change.UndoExpiresAt); err != nil {
+					log.Error().Err(err).Str("employee_id", change.EmployeeProfileID.String()).Msg("Failed to send email change notice")
+				}
+			})
+		}
+		return nil
+	})
+}
+
+// UndoEmailChange restores the previous address. Because an unexpected change suggests the
+// account was taken over, all of the employee's sessions are revoked as well.
+func (s *defaultService) UndoEmailChange(ctx context.Context, clinicID uuid.UUID, token string) error {
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		change, err := s.repo.FindEmailChangeByUndoTokenForUpdate(ctx, tx, security.HashOpaqueToken(token))
+		if err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return apierror.NewBadRequest("This undo link is invalid.", err)
+			}
+			return err
+		}
+		if change.ClinicID != clinicID || !change.IsUndoable(time.Now()) {
+			return apierror.NewBadRequest("This undo link is invalid or has expired.", nil)
+		}
+
+		current, err := s.repo.FindEmployeeEmailForUpdate(ctx, tx, clinicID, change.EmployeeProfileID)
+		if err != nil {
+			return err
+		}
+		if current == nil || *current != change.NewEmail {
+			return apierror.NewConflict("The email address has changed again since; it cannot be undone from this link.", nil)
+		}
+		if err := s.repo.UpdateProfileEmail(ctx, tx, clinicID, change.EmployeeProfileID, change.OldEmail); err != nil {
+			return err
+		}
+		if err := s.repo.MarkEmailChangeUndone(ctx, tx, change.ID); err != nil {
+			return err
+		}
+		if err := s.recordEmailChange(ctx, tx, change.EmployeeProfileID, model.AuditActionEmailChangeUndone, change); err != nil {
+			return err
+		}
+
+		if err := s.repo.RevokeRefreshTokensForEmployee(ctx, tx, clinicID, change.EmployeeProfileID); err != nil {
+			return err
+		}
+		s.AfterCommit(tx, func() {
+			now := time.Now()
+			longestLifetime := max(s.config.Security.TokenDuration, s.config.Security.ImpersonationDuration)
+			if err := s.denylist.RevokeSubject(context.WithoutCancel(ctx), change.EmployeeProfileID, now, now.Add(longestLifetime)); err != nil {
+				log.Error().Err(err).Str("employee_id", change.EmployeeProfileID.String()).Msg("Failed to revoke access tokens after email change undo")
+			}
+		})
+		return nil
+	})
+}
+
+// recordEmailChange writes the audit event for a step of the email change flow.
+func (s *defaultService) recordEmailChange(ctx context.Context, tx pgx.Tx, actorID uuid.UUID, action string, change *model.EmailChange) error {
+	details, err := json.Marshal(map[string]any{
+		"email_change_id": change.ID,
+		"old_email":       change.OldEmail,
+		"new_email":       change.NewEmail,
+	})
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to marshal email change details: %w", err))
+	}
+	event := model.AuditEvent{
+		ClinicID:   change.ClinicID,
+		UserID:     actorID,
+		Action:     action,
+		TableName:  "employee_email_changes",
+		RecordID:   change.EmployeeProfileID,
+		NewRecord:  details,
+		OccurredAt: time.Now(),
+	}
+	return s.repo.CreateAuditEvent(ctx, tx, &event)
+}
+
+// actionLinkInvalidMessage is returned for every unusable action link so the response does not
+// reveal whether a link was forged, expired, issued for another clinic or already used.
+const actionLinkInvalidMessage = "This link is invalid or has expired. Sign in to continue."
+
+// SendActionLink signs a link for the employee and emails it to them once the issuance is audited.
+// Only active employees with an email address can be sent one.
+func (s *defaultService) SendActionLink(ctx context.Context, req ActionLinkRequest) error {
+	if _, ok := req.Action.Route(req.EntityID); !ok {
+		return apierror.NewInternalServer(fmt.Errorf("unknown action link action %q", req.Action))
+	}
+	employee, err := s.repo.FindEmployeeByIDWithDetails(ctx, req.ClinicID, req.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if employee.Status != model.EmployeeStatusActive {
+		return apierror.NewConflict("Action links can only be sent to active employees.", nil)
+	}
+	if employee.Profile.Email == nil {
+		return apierror.NewConflict("The employee has no email address to send the link to.", nil)
+	}
+
+	claims, err := security.NewActionLinkClaims(employee.ProfileID, req.ClinicID, string(req.Action), req.EntityID, s.config.Security.ActionLinkDuration)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to create action link claims: %w", err))
+	}
+	token, err := s.sec.CreateActionLink(claims)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to create action link: %w", err))
+	}
+	details, err := json.Marshal(map[string]any{
+		"token_id":   claims.TokenID,
+		"action":     req.Action,
+		"entity_id":  req.EntityID,
+		"expires_at": claims.ExpiresAt,
+	})
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("failed to encode audit details: %w", err))
+	}
+	email := ActionLinkEmail{
+		RecipientName: employee.Profile.FullName,
+		Action:        req.Action,
+		Summary:       req.Summary,
+		URL:           s.config.Security.ActionLinkURL + "?token=" + url.QueryEscape(token),
+		ExpiresAt:     claims.ExpiresAt,
+	}
+
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   req.ClinicID,
+			UserID:     employee.ProfileID,
+			Action:     model.AuditActionActionLinkIssued,
+			TableName:  "employees",
+			RecordID:   employee.ProfileID,
+			NewRecord:  details,
+			OccurredAt: claims.IssuedAt,
+		}); err != nil {
+			return err
+		}
+		s.AfterCommit(tx, func() {
+			if err := s.notifier.SendActionLink(context.WithoutCancel(ctx), *employee.Profile.Email, email); err != nil {
+				log.Error().Err(err).Str("employee_id", employee.ProfileID.String()).Msg("Failed to send action link")
+			}
+		})
+		return nil
+	})
+}
+
+// RedeemActionLink verifies the link, records its jti so it cannot be used again, and issues a
+// normal session for its employee. The redemption, status check and audit event share one
+// transaction, so a link refused because its employee is no longer active stays unused.
+func (s *defaultService) RedeemActionLink(ctx context.Context, req RedeemActionLinkRequest) (*Session, *model.Employee, string, error) {
+	claims, err := s.sec.VerifyActionLink(req.Token)
+	if err != nil {
+		return nil, nil, "", apierror.NewUnauthorized(actionLinkInvalidMessage, err)
+	}
+	action := model.ActionLinkAction(claims.Action)
+	redirectTo, ok := action.Route(claims.EntityID)
+	if claims.ClinicID != req.ClinicID || !ok {
+		return nil, nil, "", apierror.NewUnauthorized(actionLinkInvalidMessage, nil)
+	}
+
+	employee, err := s.repo.FindEmployeeByIDWithDetails(ctx, claims.ClinicID, claims.ProfileID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil, "", apierror.NewUnauthorized(actionLinkInvalidMessage, nil)
+		}
+		return nil, nil, "", err
+	}
+	// Unlike a password login, a link signs in without a secret the employee typed, so a lock
+	// that cannot be checked refuses the link rather than letting it bypass the lock.
+	lockState, err := s.lockout.Status(ctx, employee.ProfileID)
+	if err != nil {
+		log.Error().Err(err).Str("profile_id", employee.ProfileID.String()).Msg("Failed to read login lockout state")
+		return nil, nil, "", apierror.NewServiceUnavailable("Sign-in links cannot be checked right now. Please try again shortly.", err)
+	}
+	if lockState.Locked(time.Now()) {
+		return nil, nil, "", apierror.NewUnauthorized("This account is temporarily locked after too many failed sign-in attempts. Try again later or contact your clinic administrator.", nil)
+	}
+	roles, err := s.repo.FindRolesForEmployee(ctx, s.db, employee.ProfileID)
+	if err != nil {
+		return nil, nil, "", apierror.NewInternalServer(fmt.Errorf("failed to fetch employee roles: %w", err))
+	}
+	employee.Roles = roles
+
+	details, err := json.Marshal(map[string]any{
+		"token_id":   claims.TokenID,
+		"action":     action,
+		"entity_id":  claims.EntityID,
+		"ip_address": req.IPAddress,
+		"user_agent": req.UserAgent,
+	})
+	if err != nil {
+		return nil, nil, "", apierror.NewInternalServer(fmt.Errorf("failed to encode audit details: %w", err))
+	}
+
+	var session *Session
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		redeemed, err := s.repo.RedeemActionLink(ctx, tx, &model.ActionLinkRedemption{
+			TokenID:           claims.TokenID,
+			EmployeeProfileID: employee.ProfileID,
+			ClinicID:          claims.ClinicID,
+			Action:            action,
+			EntityID:          claims.EntityID,
+			ExpiresAt:         claims.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		if !redeemed {
+			log.Warn().
+				Str("token_id", claims.TokenID.String()).
+				Str("employee_id", employee.ProfileID.String()).
+				Msg("Used action link presented again")
+			return apierror.NewUnauthorized(actionLinkInvalidMessage, nil)
+		}
+
+		// Re-read the status under lock: the employee may have been suspended since the link was sent.
+		status, err := s.repo.FindEmployeeStatusForUpdate(ctx, tx, claims.ClinicID, employee.ProfileID)
+		if err != nil {
+			return err
+		}
+		if status != model.EmployeeStatusActive {
+			return apierror.NewUnauthorized("account is not active", nil)
+		}
+
+		if err := s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   claims.ClinicID,
+			UserID:     employee.ProfileID,
+			Action:     model.AuditActionActionLinkRedeemed,
+			TableName:  "employees",
+			RecordID:   employee.ProfileID,
+			NewRecord:  details,
+			OccurredAt: time.Now(),
+		}); err != nil {
+			return err
+		}
+		session, err = s.issueSession(ctx, tx, employee, uuid.Must(uuid.NewV7()))
+		return err
+	})
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// The session is already issued; a failed history write must not fail the redemption.
+	if err := s.repo.RecordLogin(ctx, employee.ProfileID, req.IPAddress, req.UserAgent); err != nil {
+		log.Error().Err(err).Str("profile_id", employee.ProfileID.String()).Msg("Failed to record login")
+	} else {
+		now := time.Now()
+		employee.LastLoginAt = &now
+	}
+
+	return session, employee, redirectTo, nil
 }
@@ -0,0 +1,518 @@
+package iam
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/argon2"
+)
+
+const correctPassword = "correct horse battery staple"
+
+// fakeRepo serves employees by email. Methods the tests do not use panic through the nil
+// embedded Repository.
+type fakeRepo struct {
+	Repository
+	employees map[string]*model.Employee
+}
+
+func (r *fakeRepo) FindEmployeeByEmail(_ context.Context, _ uuid.UUID, email string) (*model.Employee, error) {
+	if e, ok := r.employees[email]; ok {
+		employee := *e
+		return &employee, nil
+	}
+	return nil, apierror.NewNotFound("Employee", nil)
+}
+
+// lockNotices records the account lock notices sent, by address.
+type lockNotices struct {
+	Notifier
+	sent chan string
+}
+
+func (n *lockNotices) SendAccountLocked(_ context.Context, to string, _ time.Time) error {
+	n.sent <- to
+	return nil
+}
+
+// newLoginService returns a service whose clinic has active employees with a password
+// (active@example.com and locked@example.com, whose account is locked) and an invited one
+// without (invited@example.com). Accounts lock after lockThreshold failures.
+func newLoginService(t *testing.T) *defaultService {
+	t.Helper()
+	hash, err := security.HashPassword(correctPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockedEmail := "locked@example.com"
+	repo := &fakeRepo{employees: map[string]*model.Employee{
+		"active@example.com":  {ProfileID: uuid.New(), PasswordHash: &hash, Status: model.EmployeeStatusActive},
+		"invited@example.com": {ProfileID: uuid.New(), Status: model.EmployeeStatusInvited},
+		lockedEmail: {ProfileID: uuid.New(), PasswordHash: &hash, Status: model.EmployeeStatusActive,
+			Profile: model.Profile{Email: &lockedEmail}},
+	}}
+	s := &defaultService{
+		repo:     repo,
+		lockout:  security.NewMemoryLockout(security.LockoutPolicy{Threshold: lockThreshold, Window: time.Hour, Duration: time.Hour}),
+		failures: NewAuthFailureRecorder(repo, []byte("test-key")),
+		notifier: &lockNotices{sent: make(chan string, 1)},
+	}
+	for range lockThreshold {
+		if _, err := s.lockout.RecordFailure(context.Background(), repo.employees[lockedEmail].ProfileID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+const lockThreshold = 3
+
+func login(s *defaultService, email, password string) error {
+	_, _, err := s.LoginEmployee(context.Background(), LoginEmployeeRequest{
+		ClinicID: uuid.New(),
+		Email:    &email,
+		Password: password,
+	})
+	return err
+}
+
+func TestLoginFailuresAreIndistinguishable(t *testing.T) {
+	s := newLoginService(t)
+	tests := []struct {
+		name     string
+		email    string
+		password string
+	}{
+		{"unknown email", "nobody@example.com", correctPassword},
+		{"wrong password", "active@example.com", "wrong password"},
+		{"no password set", "invited@example.com", correctPassword},
+		{"locked account", "locked@example.com", "wrong password"},
+	}
+
+	var want *apierror.APIError
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *apierror.APIError
+			if err := login(s, tt.email, tt.password); !errors.As(err, &apiErr) {
+				t.Fatalf("LoginEmployee error = %v, want an APIError", err)
+			}
+			if apiErr.StatusCode != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", apiErr.StatusCode, http.StatusUnauthorized)
+			}
+			if want == nil {
+				want = apiErr
+			}
+			if apiErr.StatusCode != want.StatusCode || apiErr.PublicMessage != want.PublicMessage || apiErr.Code != want.Code {
+				t.Errorf("got %d %q (%q), want %d %q (%q) like the other failures",
+					apiErr.StatusCode, apiErr.PublicMessage, apiErr.Code, want.StatusCode, want.PublicMessage, want.Code)
+			}
+		})
+	}
+}
+
+func TestLockedAccountIsRevealedOnlyToTheRightPassword(t *testing.T) {
+	s := newLoginService(t)
+
+	var apiErr *apierror.APIError
+	if err := login(s, "locked@example.com", correctPassword); !errors.As(err, &apiErr) {
+		t.Fatalf("LoginEmployee error = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != apierror.CodeAccountLocked {
+		t.Errorf("got %d (%q), want %d (%q)", apiErr.StatusCode, apiErr.Code, http.StatusTooManyRequests, apierror.CodeAccountLocked)
+	}
+}
+
+func TestLockingAnAccountEmailsTheEmployee(t *testing.T) {
+	s := newLoginService(t)
+	email := "locked@example.com"
+	// Start from an unlocked account.
+	if err := s.lockout.Clear(context.Background(), s.repo.(*fakeRepo).employees[email].ProfileID); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range lockThreshold {
+		var apiErr *apierror.APIError
+		if err := login(s, email, "wrong password"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: LoginEmployee error = %v, want 401", i+1, err)
+		}
+	}
+
+	select {
+	case to := <-s.notifier.(*lockNotices).sent:
+		if to != email {
+			t.Errorf("lock notice sent to %q, want %q", to, email)
+		}
+	case <-time.After(time.Second):
+		t.Error("no lock notice was sent")
+	}
+}
+
+// fakeTx runs closures directly.
+type fakeTx struct{}
+
+func (fakeTx) ExecTx(_ context.Context, fn func(tx pgx.Tx) error) error { return fn(nil) }
+func (fakeTx) ExecTxOpts(_ context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+func (fakeTx) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+// sessionRepo is a fakeRepo that also accepts the writes of a successful login and records
+// password hash upgrades.
+type sessionRepo struct {
+	*fakeRepo
+	upgrades []string
+}
+
+func (r *sessionRepo) FindRolesForEmployee(context.Context, database.Querier, uuid.UUID) ([]model.Role, error) {
+	return nil, nil
+}
+
+func (r *sessionRepo) IsSandboxClinic(context.Context, uuid.UUID) (bool, error) { return false, nil }
+
+func (r *sessionRepo) CreateRefreshToken(context.Context, pgx.Tx, *model.RefreshToken) error {
+	return nil
+}
+
+func (r *sessionRepo) RecordLogin(context.Context, uuid.UUID, string, string) error { return nil }
+
+func (r *sessionRepo) UpdatePasswordHash(_ context.Context, _ uuid.UUID, currentHash, newHash string) (bool, error) {
+	if currentHash != *r.employees["legacy@example.com"].PasswordHash {
+		return false, nil
+	}
+	r.upgrades = append(r.upgrades, newHash)
+	return true, nil
+}
+
+// legacyHash hashes password with a lower Argon2 cost than the current parameters, as older
+// accounts were.
+func legacyHash(password string) string {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte(password), salt, 1, 19*1024, 1, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 19*1024, 1, 1,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// withSessions lets s issue sessions, as a successful sign-in does, and returns its repository.
+func withSessions(t *testing.T, s *defaultService) *sessionRepo {
+	t.Helper()
+	repo := &sessionRepo{fakeRepo: s.repo.(*fakeRepo)}
+	s.repo = repo
+	s.BaseService = service.BaseService{Tx: fakeTx{}}
+	s.config = &config.Config{Security: config.SecurityConfig{
+		TokenMode:            security.TokenModeLocal,
+		PasetoKey:            "0123456789abcdef0123456789abcdef",
+		TokenIssuer:          "mastara-test",
+		TokenAudience:        "mastara-api",
+		TokenDuration:        time.Hour,
+		RefreshTokenDuration: 24 * time.Hour,
+	}}
+	sec, err := security.NewPasetoManager(s.config.Security)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.sec = sec
+	return repo
+}
+
+func TestLegacyPasswordHashIsUpgradedOnlyOnASuccessfulLogin(t *testing.T) {
+	s := newLoginService(t)
+	repo := withSessions(t, s)
+	hash := legacyHash(correctPassword)
+	repo.employees["legacy@example.com"] = &model.Employee{ProfileID: uuid.New(), PasswordHash: &hash, Status: model.EmployeeStatusActive}
+
+	if err := login(s, "legacy@example.com", "wrong password"); err == nil {
+		t.Fatal("LoginEmployee with a wrong password succeeded")
+	}
+	if len(repo.upgrades) != 0 {
+		t.Fatalf("a failed login stored %d upgraded hash(es)", len(repo.upgrades))
+	}
+
+	if err := login(s, "legacy@example.com", correctPassword); err != nil {
+		t.Fatalf("LoginEmployee: %v", err)
+	}
+	if len(repo.upgrades) != 1 {
+		t.Fatalf("a successful login stored %d upgraded hash(es), want 1", len(repo.upgrades))
+	}
+	needsRehash, err := security.ComparePasswordAndHashWithUpgrade(context.Background(), correctPassword, repo.upgrades[0])
+	if err != nil {
+		t.Fatalf("the upgraded hash does not verify: %v", err)
+	}
+	if needsRehash {
+		t.Error("the upgraded hash still uses weaker parameters than the current ones")
+	}
+
+	// A hash already at the current cost is left alone.
+	if err := login(s, "active@example.com", correctPassword); err != nil {
+		t.Fatalf("LoginEmployee: %v", err)
+	}
+	if len(repo.upgrades) != 1 {
+		t.Errorf("logging in with a current hash stored an upgrade")
+	}
+}
+
+// linkRepo serves the employees of a sessionRepo by ID, with a status that can change after a
+// link was sent, and remembers which links were redeemed.
+type linkRepo struct {
+	*sessionRepo
+	status   map[uuid.UUID]model.EmployeeStatus
+	redeemed map[uuid.UUID]bool
+}
+
+func (r *linkRepo) FindEmployeeByIDWithDetails(_ context.Context, _, profileID uuid.UUID) (*model.Employee, error) {
+	for _, e := range r.employees {
+		if e.ProfileID == profileID {
+			employee := *e
+			return &employee, nil
+		}
+	}
+	return nil, apierror.NewNotFound("Employee", nil)
+}
+
+func (r *linkRepo) RedeemActionLink(_ context.Context, _ pgx.Tx, redemption *model.ActionLinkRedemption) (bool, error) {
+	if r.redeemed[redemption.TokenID] {
+		return false, nil
+	}
+	r.redeemed[redemption.TokenID] = true
+	return true, nil
+}
+
+func (r *linkRepo) FindEmployeeStatusForUpdate(_ context.Context, _ pgx.Tx, _, profileID uuid.UUID) (model.EmployeeStatus, error) {
+	if status, ok := r.status[profileID]; ok {
+		return status, nil
+	}
+	return model.EmployeeStatusActive, nil
+}
+
+func (r *linkRepo) CreateAuditEvent(context.Context, pgx.Tx, *model.AuditEvent) error { return nil }
+
+// failingLockout cannot read lockout state.
+type failingLockout struct{ security.Lockout }
+
+func (failingLockout) Status(context.Context, uuid.UUID) (security.LockoutState, error) {
+	return security.LockoutState{}, errors.New("lockout store unavailable")
+}
+
+func TestRedeemActionLink(t *testing.T) {
+	clinicID := uuid.New()
+	newLink := func(t *testing.T, s *defaultService, profileID uuid.UUID, issuedAgo, lifetime time.Duration) string {
+		t.Helper()
+		claims, err := security.NewActionLinkClaims(profileID, clinicID, string(model.ActionLinkReviewReschedule), uuid.New(), lifetime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims.IssuedAt = claims.IssuedAt.Add(-issuedAgo)
+		claims.ExpiresAt = claims.ExpiresAt.Add(-issuedAgo)
+		token, err := s.sec.CreateActionLink(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	setup := func(t *testing.T) (*defaultService, *linkRepo, uuid.UUID) {
+		s := newLoginService(t)
+		repo := &linkRepo{sessionRepo: withSessions(t, s), status: map[uuid.UUID]model.EmployeeStatus{}, redeemed: map[uuid.UUID]bool{}}
+		s.repo = repo
+		return s, repo, repo.employees["active@example.com"].ProfileID
+	}
+	redeem := func(s *defaultService, token string) (string, error) {
+		_, _, redirectTo, err := s.RedeemActionLink(context.Background(), RedeemActionLinkRequest{ClinicID: clinicID, Token: token})
+		return redirectTo, err
+	}
+	assertStatus := func(t *testing.T, err error, want int) {
+		t.Helper()
+		var apiErr *apierror.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != want {
+			t.Errorf("RedeemActionLink error = %v, want a %d", err, want)
+		}
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		s, _, profileID := setup(t)
+		token := newLink(t, s, profileID, 0, time.Hour)
+		redirectTo, err := redeem(s, token)
+		if err != nil {
+			t.Fatalf("RedeemActionLink: %v", err)
+		}
+		if redirectTo == "" {
+			t.Error("no redirect for the link's action")
+		}
+		_, err = redeem(s, token)
+		assertStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s, repo, profileID := setup(t)
+		_, err := redeem(s, newLink(t, s, profileID, 2*time.Hour, time.Hour))
+		assertStatus(t, err, http.StatusUnauthorized)
+		if len(repo.redeemed) != 0 {
+			t.Error("an expired link was recorded as redeemed")
+		}
+	})
+
+	t.Run("employee suspended after the link was sent", func(t *testing.T) {
+		s, repo, profileID := setup(t)
+		token := newLink(t, s, profileID, 0, time.Hour)
+		repo.status[profileID] = model.EmployeeStatusSuspended
+		_, err := redeem(s, token)
+		assertStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("lockout store failing", func(t *testing.T) {
+		s, repo, profileID := setup(t)
+		s.lockout = failingLockout{}
+		_, err := redeem(s, newLink(t, s, profileID, 0, time.Hour))
+		assertStatus(t, err, http.StatusServiceUnavailable)
+		if len(repo.redeemed) != 0 {
+			t.Error("the link was redeemed although the lock could not be checked")
+		}
+	})
+}
+
+// inviteRepo holds one employee and their invitation tokens, which invalidating marks used.
+type inviteRepo struct {
+	Repository
+	employee *model.Employee
+	tokens   []*model.Invitation
+	used     map[uuid.UUID]bool
+	audits   []string
+}
+
+func (r *inviteRepo) FindEmployeeByIDWithDetails(_ context.Context, _, profileID uuid.UUID) (*model.Employee, error) {
+	if r.employee.ProfileID != profileID {
+		return nil, apierror.NewNotFound("Employee", nil)
+	}
+	employee := *r.employee
+	return &employee, nil
+}
+
+func (r *inviteRepo) FindEmployeeStatusForUpdate(context.Context, pgx.Tx, uuid.UUID, uuid.UUID) (model.EmployeeStatus, error) {
+	return r.employee.Status, nil
+}
+
+func (r *inviteRepo) InvalidateInviteTokens(_ context.Context, _ pgx.Tx, profileID uuid.UUID) error {
+	for _, token := range r.tokens {
+		if token.EmployeeProfileID == profileID {
+			r.used[token.ID] = true
+		}
+	}
+	return nil
+}
+
+func (r *inviteRepo) CreateInviteToken(_ context.Context, _ pgx.Tx, invitation *model.Invitation) error {
+	r.tokens = append(r.tokens, invitation)
+	return nil
+}
+
+func (r *inviteRepo) CreateAuditEvent(_ context.Context, _ pgx.Tx, event *model.AuditEvent) error {
+	r.audits = append(r.audits, event.Action)
+	return nil
+}
+
+// outboxTx records the events published in it.
+type outboxTx struct {
+	pgx.Tx
+	published *[]string
+}
+
+func (t outboxTx) Exec(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+	*t.published = append(*t.published, args[1].(string))
+	return pgconn.CommandTag{}, nil
+}
+
+// outboxTxManager runs closures on an outboxTx.
+type outboxTxManager struct{ published *[]string }
+
+func (m outboxTxManager) ExecTx(_ context.Context, fn func(tx pgx.Tx) error) error {
+	return fn(outboxTx{published: m.published})
+}
+func (m outboxTxManager) ExecTxOpts(ctx context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return m.ExecTx(ctx, fn)
+}
+func (outboxTxManager) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+func TestResendInvite(t *testing.T) {
+	clinicID, actorID := uuid.New(), uuid.New()
+	email := "invited@example.com"
+	setup := func(status model.EmployeeStatus) (*defaultService, *inviteRepo, *[]string) {
+		profileID := uuid.New()
+		repo := &inviteRepo{
+			employee: &model.Employee{ProfileID: profileID, Status: status, Profile: model.Profile{FullName: "Mona Adel", Email: &email}},
+			// The first invitation went unanswered and has expired.
+			tokens: []*model.Invitation{{ID: uuid.New(), EmployeeProfileID: profileID, ClinicID: clinicID, ExpiresAt: time.Now().Add(-time.Hour)}},
+			used:   map[uuid.UUID]bool{},
+		}
+		secrets := map[int16][]byte{1: []byte("0123456789abcdef0123456789abcdef")}
+		published := &[]string{}
+		s := &defaultService{
+			BaseService: service.BaseService{Tx: outboxTxManager{published: published}},
+			repo:        repo,
+			config:      &config.Config{Security: config.SecurityConfig{InviteTokenDuration: 72 * time.Hour, InviteURL: "https://app.example.com/invite"}},
+			outbox:      events.NewPublisher(security.NewKeyring(secrets, "mastara:outbox:payload", 1)),
+			keys:        security.NewKeyring(secrets, invitationSealPurpose, 1),
+		}
+		return s, repo, published
+	}
+
+	t.Run("expired invitation is replaced", func(t *testing.T) {
+		s, repo, published := setup(model.EmployeeStatusInvited)
+		expired := repo.tokens[0]
+
+		issued, err := s.ResendInvite(context.Background(), clinicID, actorID, repo.employee.ProfileID)
+		if err != nil {
+			t.Fatalf("ResendInvite: %v", err)
+		}
+		if !repo.used[expired.ID] {
+			t.Error("the expired invitation token was not invalidated")
+		}
+		if len(repo.tokens) != 2 {
+			t.Fatalf("%d invitation token(s) stored, want the expired one and a new one", len(repo.tokens))
+		}
+		fresh := repo.tokens[1]
+		if repo.used[fresh.ID] {
+			t.Error("the new invitation token was invalidated")
+		}
+		if !fresh.ExpiresAt.After(time.Now().Add(71*time.Hour)) || !issued.ExpiresAt.Equal(fresh.ExpiresAt) {
+			t.Errorf("new token expires at %s (issued %s), want a fresh InviteTokenDuration from now", fresh.ExpiresAt, issued.ExpiresAt)
+		}
+		if fresh.TokenHash != security.HashOpaqueToken(issued.Token) {
+			t.Error("the stored token hash does not match the issued token")
+		}
+		if fmt.Sprint(*published) != "["+EventInvitationIssued+"]" {
+			t.Errorf("published %v, want the invitation queued once", *published)
+		}
+		if fmt.Sprint(repo.audits) != "["+model.AuditActionInviteResent+"]" {
+			t.Errorf("audited %v, want the resend", repo.audits)
+		}
+	})
+
+	t.Run("already active", func(t *testing.T) {
+		s, repo, published := setup(model.EmployeeStatusActive)
+
+		_, err := s.ResendInvite(context.Background(), clinicID, actorID, repo.employee.ProfileID)
+		var apiErr *apierror.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+			t.Fatalf("ResendInvite error = %v, want a 409", err)
+		}
+		if len(repo.used) != 0 || len(repo.tokens) != 1 {
+			t.Errorf("an active employee's tokens were changed: %d invalidated, %d stored", len(repo.used), len(repo.tokens))
+		}
+		if len(*published) != 0 || len(repo.audits) != 0 {
+			t.Errorf("an active employee was re-invited: published %v, audited %v", *published, repo.audits)
+		}
+	})
+}
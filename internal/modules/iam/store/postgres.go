@@ -5,8 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -14,14 +18,6 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// Querier defines the common methods between pgx.Tx and *pgxpool.Pool.
-// This allows repository methods to be used both within and outside of transactions.
-type Querier interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-}
-
 // pgxRepository is the PostgreSQL implementation of the iam.Repository.
 type pgxRepository struct {
 	db *pgxpool.Pool
@@ -32,7 +28,7 @@ func NewPgxRepository(db *pgxpool.Pool) *pgxRepository {
 	return &pgxRepository{db: db}
 }
 
-// isUniqueViolationError checks if a given error is a PostgreSQL unique constraint violation (code 23505).
+// IsUniqueViolationError checks if a given error is a PostgreSQL unique constraint violation (code 23505).
 func IsUniqueViolationError(err error) bool {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
@@ -41,76 +37,22 @@ func IsUniqueViolationError(err error) bool {
 	return false
 }
 
-// FindOrCreateGuest atomically inserts a guest or retrieves the existing one.
-// It relies on a "Writeable CTE with UNION" pattern to be efficient and thread-safe.
-// This executes exactly ONE round trip to the DB and optimizes index usage.
-func (r *pgxRepository) FindOrCreateGuest(ctx context.Context, querier Querier, clinicID uuid.UUID, fullName string, phoneNumber string) (*model.Profile, error) {
-	profile := &model.Profile{}
-
-	// Generating ID here ensures it matches the INSERT arg if new
-	newID, err := uuid.NewV7()
-	if err != nil {
-		return nil, fmt.Errorf("profile_repo: failed to generate uuidv7: %w", err)
-	}
-
-	// 2. SQL QUERY (Hybrid Upsert Pattern)
-	// - Tries to Insert New ID.
-	// - If conflict, finds existing ID.
-	// - UNION ALL merges the logic into one set.
-	query := `
-        WITH new_row AS (
-            INSERT INTO profiles (id, clinic_id, full_name, phone_number, profile_status)
-            VALUES ($1, $2, $3, $4, 'GUEST')
-            ON CONFLICT (clinic_id, phone_number) WHERE deleted_at IS NULL DO NOTHING
-            RETURNING id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, profile_status, extended_data, created_at, updated_at, deleted_at
-        )
-        SELECT * FROM new_row
-        UNION ALL
-        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, profile_status, extended_data, created_at, updated_at, deleted_at
-        FROM profiles
-        WHERE clinic_id = $2 AND phone_number = $4 AND deleted_at IS NULL
-        LIMIT 1;
-    `
-
-	// 3. EXECUTION
-	// Note: profile.Email and profile.NationalID MUST be pointers (*string)
-	// in your model definition to handle the NULLs safely, or scanner will panic.
-	err = querier.QueryRow(ctx, query, newID, clinicID, fullName, phoneNumber).Scan(
-		&profile.ID,
-		&profile.ClinicID,
-		&profile.FullName,
-		&profile.PhoneNumber,
-		&profile.Email,      // Caution: Scan handles DB NULLs if this is *string
-		&profile.NationalID, // Caution: Scan handles DB NULLs if this is *string
-		&profile.DateOfBirth,
-		&profile.ProfileStatus,
-		&profile.ExtendedData,
-		&profile.CreatedAt,
-		&profile.UpdatedAt,
-		&profile.DeletedAt,
-	)
-
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			// Should be impossible due to UPSERT logic, but catch just in case.
-			return nil, fmt.Errorf("profile_repo: critical upsert failure for guest: %w", err)
-		}
-		return nil, fmt.Errorf("profile_repo: query execution failed: %w", err)
-	}
-
-	return profile, nil
-
-}
-
-// CreateUser inserts a new user record into the database.
-// CreateInvitedEmployee creates a profile and an employee record within a single transaction.
+// CreateInvitedEmployee creates a profile and its employee record in the caller's transaction.
+// Neither insert is committed here, so a failure on the employee row leaves no orphaned profile
+// once the caller rolls back.
 func (r *pgxRepository) CreateInvitedEmployee(ctx context.Context, tx pgx.Tx, profile *model.Profile, employee *model.Employee) error {
 	profileQuery := `
         INSERT INTO profiles (id, clinic_id, full_name, email, phone_number, profile_status)
         VALUES ($1, $2, $3, $4, $5, 'REGISTERED')`
 	if _, err := tx.Exec(ctx, profileQuery, profile.ID, profile.ClinicID, profile.FullName, profile.Email, profile.PhoneNumber); err != nil {
-		if IsUniqueViolationError(err) {
-			return apierror.NewBadRequest("A profile with this email or phone number already exists.", err)
+		if constraint, ok := database.UniqueViolation(err); ok {
+			switch constraint {
+			case "idx_profiles_unique_active_phone_per_clinic":
+				return apierror.NewConflict("A profile with this phone number already exists in this clinic.", err).WithCode(apierror.CodeDuplicatePhone)
+			case "idx_profiles_unique_active_email_per_clinic":
+				return apierror.NewConflict("A profile with this email already exists in this clinic.", err).WithCode(apierror.CodeDuplicateEmail)
+			}
+			return apierror.NewConflict("A profile with this email or phone number already exists.", err).WithCode(apierror.CodeDuplicateResource)
 		}
 		return fmt.Errorf("store.CreateInvitedEmployee: failed to insert profile: %w", err)
 	}
@@ -119,92 +61,114 @@ func (r *pgxRepository) CreateInvitedEmployee(ctx context.Context, tx pgx.Tx, pr
         INSERT INTO employees (profile_id, clinic_id, job_title, status, invited_by)
         VALUES ($1, $2, $3, $4, $5)`
 	if _, err := tx.Exec(ctx, employeeQuery, employee.ProfileID, employee.ClinicID, employee.JobTitle, employee.Status, employee.InvitedByID); err != nil {
+		if IsUniqueViolationError(err) {
+			return apierror.NewConflict("This profile is already an employee of the clinic.", err).WithCode(apierror.CodeDuplicateResource)
+		}
 		return fmt.Errorf("store.CreateInvitedEmployee: failed to insert employee: %w", err)
 	}
 
 	return nil
 }
 
-// FindEmployeeByEmail finds a user by their email within the specified clinic.
-func (r *pgxRepository) FindEmployeeByEmail(ctx context.Context, clinicID uuid.UUID, email string) (*model.Employee, error) {
+// employeeColumns selects an employee joined with its profile, in the order scanEmployee reads them.
+const employeeColumns = `
+        e.profile_id, e.clinic_id, p.email, p.phone_number, e.password_hash, p.full_name, e.job_title,
+        e.status, e.last_login_at, e.invited_by, e.created_at, e.updated_at, p.deleted_at`
+
+// scanEmployee reads a row selected with employeeColumns into an employee and its embedded profile.
+func scanEmployee(row pgx.Row) (*model.Employee, error) {
 	employee := &model.Employee{}
-	query := `
-        SELECT id, clinic_id, email, phone_number, password_hash, full_name, job_title, status, last_login_at, invited_by, created_at, updated_at, deleted_at
-        FROM users
-        WHERE clinic_id = $1 AND email = $2 AND deleted_at IS NULL
-    `
-	err := r.db.QueryRow(ctx, query, clinicID, email).Scan(
+	err := row.Scan(
 		&employee.ProfileID, &employee.ClinicID, &employee.Profile.Email, &employee.Profile.PhoneNumber, &employee.PasswordHash,
 		&employee.Profile.FullName, &employee.JobTitle, &employee.Status, &employee.LastLoginAt, &employee.InvitedByID,
 		&employee.CreatedAt, &employee.UpdatedAt, &employee.Profile.DeletedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	employee.Profile.ID = employee.ProfileID
+	employee.Profile.ClinicID = employee.ClinicID
+	return employee, nil
+}
+
+// FindEmployeeByEmail finds an employee by their email within the specified clinic.
+func (r *pgxRepository) FindEmployeeByEmail(ctx context.Context, clinicID uuid.UUID, email string) (*model.Employee, error) {
+	query := `
+        SELECT` + employeeColumns + `
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        WHERE e.clinic_id = $1 AND p.email = $2 AND e.deleted_at IS NULL AND p.deleted_at IS NULL`
+	employee, err := scanEmployee(r.db.QueryRow(ctx, query, clinicID, email))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, apierror.NewNotFound("user", err)
+			return nil, apierror.NewNotFound("employee", err)
 		}
-		return nil, fmt.Errorf("store.FindUserByEmail: failed to query user: %w", err)
+		return nil, fmt.Errorf("store.FindEmployeeByEmail: failed to query employee: %w", err)
 	}
 	return employee, nil
 }
 
-// FindEmployeeByPhone finds a user by their phone number within the specified clinic.
+// FindEmployeeByPhone finds an employee by their phone number within the specified clinic.
 func (r *pgxRepository) FindEmployeeByPhone(ctx context.Context, clinicID uuid.UUID, phone string) (*model.Employee, error) {
-	employee := &model.Employee{}
 	query := `
-        SELECT id, clinic_id, email, phone_number, password_hash, full_name, job_title, status, last_login_at, invited_by, created_at, updated_at, deleted_at
-        FROM users
-        WHERE clinic_id = $1 AND phone_number = $2 AND deleted_at IS NULL
-    `
-	err := r.db.QueryRow(ctx, query, clinicID, phone).Scan(
-		&employee.ProfileID, &employee.ClinicID, &employee.Profile.Email, &employee.Profile.PhoneNumber, &employee.PasswordHash,
-		&employee.Profile.FullName, &employee.JobTitle, &employee.Status, &employee.LastLoginAt, &employee.InvitedByID,
-		&employee.CreatedAt, &employee.UpdatedAt, &employee.Profile.DeletedAt,
-	)
+        SELECT` + employeeColumns + `
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        WHERE e.clinic_id = $1 AND p.phone_number = $2 AND e.deleted_at IS NULL AND p.deleted_at IS NULL`
+	employee, err := scanEmployee(r.db.QueryRow(ctx, query, clinicID, phone))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, apierror.NewNotFound("user", err)
+			return nil, apierror.NewNotFound("employee", err)
 		}
-		return nil, fmt.Errorf("store.FindUserByPhone: failed to query user: %w", err)
+		return nil, fmt.Errorf("store.FindEmployeeByPhone: failed to query employee: %w", err)
 	}
 	return employee, nil
 }
 
-// FindEmployeeByIDWithDetails finds a user by their ID within the specified clinic.
+// IsSandboxClinic reports whether the clinic is a sandbox (demo/trial) tenant.
+func (r *pgxRepository) IsSandboxClinic(ctx context.Context, clinicID uuid.UUID) (bool, error) {
+	var sandbox bool
+	err := r.db.QueryRow(ctx, `SELECT is_sandbox FROM clinics WHERE id = $1`, clinicID).Scan(&sandbox)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, apierror.NewNotFound("clinic", err)
+		}
+		return false, fmt.Errorf("store.IsSandboxClinic: failed to query clinic: %w", err)
+	}
+	return sandbox, nil
+}
+
+// FindEmployeeByIDWithDetails finds an employee by their profile ID within the specified clinic.
 func (r *pgxRepository) FindEmployeeByIDWithDetails(ctx context.Context, clinicID uuid.UUID, id uuid.UUID) (*model.Employee, error) {
-	employee := &model.Employee{}
 	query := `
-        SELECT id, clinic_id, email, phone_number, password_hash, full_name, job_title, status, last_login_at, invited_by, created_at, updated_at, deleted_at
-        FROM users
-        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL
-    `
-	err := r.db.QueryRow(ctx, query, clinicID, id).Scan(
-		&employee.ProfileID, &employee.ClinicID, &employee.Profile.Email, &employee.Profile.PhoneNumber, &employee.PasswordHash,
-		&employee.Profile.FullName, &employee.JobTitle, &employee.Status, &employee.LastLoginAt, &employee.InvitedByID,
-		&employee.CreatedAt, &employee.UpdatedAt, &employee.Profile.DeletedAt,
-	)
+        SELECT` + employeeColumns + `
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        WHERE e.clinic_id = $1 AND e.profile_id = $2 AND e.deleted_at IS NULL AND p.deleted_at IS NULL`
+	employee, err := scanEmployee(r.db.QueryRow(ctx, query, clinicID, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, apierror.NewNotFound("user", err)
+			return nil, apierror.NewNotFound("employee", err)
 		}
-		return nil, fmt.Errorf("store.FindUserByID: failed to query user: %w", err)
+		return nil, fmt.Errorf("store.FindEmployeeByIDWithDetails: failed to query employee: %w", err)
 	}
 	return employee, nil
 }
 
-// FindRolesForEmployee retrieves all roles (and their permissions) assigned to a user.
-func (r *pgxRepository) FindRolesForEmployee(ctx context.Context, userID uuid.UUID) ([]model.Role, error) {
+// FindRolesForEmployee retrieves all active roles (and their permissions) assigned to an employee.
+func (r *pgxRepository) FindRolesForEmployee(ctx context.Context, querier database.Querier, employeeProfileID uuid.UUID) ([]model.Role, error) {
 	query := `
         SELECT r.id, r.clinic_id, r.name, r.description, r.is_system_role,
                p.id, p.permission_key
         FROM roles r
-        JOIN user_roles ur ON r.id = ur.role_id
+        JOIN employee_roles er ON r.id = er.role_id
         LEFT JOIN role_permissions rp ON r.id = rp.role_id
         LEFT JOIN permissions p ON rp.permission_id = p.id
-        WHERE ur.user_id = $1
+        WHERE er.employee_profile_id = $1 AND r.deleted_at IS NULL
     `
-	rows, err := r.db.Query(ctx, query, userID)
+	rows, err := querier.Query(ctx, query, employeeProfileID)
 	if err != nil {
-		return nil, fmt.Errorf("store.FindRolesForUser: failed to query roles: %w", err)
+		return nil, fmt.Errorf("store.FindRolesForEmployee: failed to query roles: %w", err)
 	}
 	defer rows.Close()
 
@@ -215,7 +179,7 @@ func (r *pgxRepository) FindRolesForEmployee(ctx context.Context, userID uuid.UU
 		var pKey sql.NullString
 
 		if err := rows.Scan(&role.ID, &role.ClinicID, &role.Name, &role.Description, &role.IsSystemRole, &pID, &pKey); err != nil {
-			return nil, fmt.Errorf("store.FindRolesForUser: failed to scan row: %w", err)
+			return nil, fmt.Errorf("store.FindRolesForEmployee: failed to scan row: %w", err)
 		}
 
 		if _, ok := roleMap[role.ID]; !ok {
@@ -230,7 +194,7 @@ func (r *pgxRepository) FindRolesForEmployee(ctx context.Context, userID uuid.UU
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("store.FindRolesForUser: error iterating rows: %w", err)
+		return nil, fmt.Errorf("store.FindRolesForEmployee: error iterating rows: %w", err)
 	}
 
 	roles := make([]model.Role, 0, len(roleMap))
@@ -240,3 +204,1013 @@ func (r *pgxRepository) FindRolesForEmployee(ctx context.Context, userID uuid.UU
 
 	return roles, nil
 }
+
+// ListEmployees returns the clinic's employees with their profiles and the names of their
+// roles, ordered by name. Terminated employees are included; the caller filters by status.
+func (r *pgxRepository) ListEmployees(ctx context.Context, clinicID uuid.UUID) ([]model.Employee, error) {
+	query := `
+        SELECT e.profile_id, e.clinic_id, p.email, p.phone_number, p.full_name, e.job_title, e.status,
+               e.last_login_at, e.invited_by, e.created_at, e.updated_at,
+               COALESCE((
+                   SELECT json_agg(json_build_object('id', ro.id, 'name', ro.name) ORDER BY ro.name)
+                   FROM employee_roles er
+                   JOIN roles ro ON ro.id = er.role_id AND ro.deleted_at IS NULL
+                   WHERE er.employee_profile_id = e.profile_id
+               ), '[]'::json)
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        WHERE e.clinic_id = $1 AND e.deleted_at IS NULL AND p.deleted_at IS NULL
+        ORDER BY p.full_name, e.profile_id`
+	rows, err := r.db.Query(ctx, query, clinicID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListEmployees: failed to query employees: %w", err)
+	}
+	defer rows.Close()
+
+	var employees []model.Employee
+	for rows.Next() {
+		var e model.Employee
+		if err := rows.Scan(
+			&e.ProfileID, &e.ClinicID, &e.Profile.Email, &e.Profile.PhoneNumber, &e.Profile.FullName, &e.JobTitle, &e.Status,
+			&e.LastLoginAt, &e.InvitedByID, &e.CreatedAt, &e.UpdatedAt, &e.Roles,
+		); err != nil {
+			return nil, fmt.Errorf("store.ListEmployees: failed to scan employee: %w", err)
+		}
+		e.Profile.ID = e.ProfileID
+		e.Profile.ClinicID = e.ClinicID
+		employees = append(employees, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListEmployees: error iterating rows: %w", err)
+	}
+	return employees, nil
+}
+
+// ListEmployeesVersion probes the set ListEmployees returns in one aggregate. Role assignments
+// carry no timestamp, so each employee's (id, role id, role name) tuples are folded into a
+// checksum; assigning, removing or renaming a role changes it.
+func (r *pgxRepository) ListEmployeesVersion(ctx context.Context, clinicID uuid.UUID) (database.ListVersion, error) {
+	query := `
+        SELECT COUNT(*), GREATEST(MAX(e.updated_at), MAX(p.updated_at)), COALESCE(SUM(er.checksum), 0)::bigint
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        LEFT JOIN LATERAL (
+            SELECT SUM(hashtext(e.profile_id::text || ro.id::text || ro.name)) AS checksum
+            FROM employee_roles er
+            JOIN roles ro ON ro.id = er.role_id AND ro.deleted_at IS NULL
+            WHERE er.employee_profile_id = e.profile_id
+        ) er ON TRUE
+        WHERE e.clinic_id = $1 AND e.deleted_at IS NULL AND p.deleted_at IS NULL`
+	var version database.ListVersion
+	if err := r.db.QueryRow(ctx, query, clinicID).Scan(&version.Count, &version.LastUpdatedAt, &version.Checksum); err != nil {
+		return database.ListVersion{}, fmt.Errorf("store.ListEmployeesVersion: failed to probe employees: %w", err)
+	}
+	return version, nil
+}
+
+// CreateAuditEvent writes an application-level event to the audit log.
+func (r *pgxRepository) CreateAuditEvent(ctx context.Context, tx pgx.Tx, event *model.AuditEvent) error {
+	query := `
+        INSERT INTO audit_log (clinic_id, user_id, action, table_name, record_id, new_record, timestamp)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := tx.Exec(ctx, query, event.ClinicID, event.UserID, event.Action, event.TableName, event.RecordID, event.NewRecord, event.OccurredAt); err != nil {
+		return fmt.Errorf("store.CreateAuditEvent: failed to insert audit event: %w", err)
+	}
+	return nil
+}
+
+// CreateInviteToken persists the hash of a newly issued invitation token.
+func (r *pgxRepository) CreateInviteToken(ctx context.Context, tx pgx.Tx, invitation *model.Invitation) error {
+	query := `
+        INSERT INTO employee_invitations (id, employee_profile_id, clinic_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(ctx, query, invitation.ID, invitation.EmployeeProfileID, invitation.ClinicID, invitation.TokenHash, invitation.ExpiresAt); err != nil {
+		return fmt.Errorf("store.CreateInviteToken: failed to insert invitation: %w", err)
+	}
+	return nil
+}
+
+// InvalidateInviteTokens retires every unused invitation token of the employee.
+func (r *pgxRepository) InvalidateInviteTokens(ctx context.Context, tx pgx.Tx, profileID uuid.UUID) error {
+	query := `UPDATE employee_invitations SET consumed_at = NOW() WHERE employee_profile_id = $1 AND consumed_at IS NULL`
+	if _, err := tx.Exec(ctx, query, profileID); err != nil {
+		return fmt.Errorf("store.InvalidateInviteTokens: failed to invalidate invitations: %w", err)
+	}
+	return nil
+}
+
+// ConsumeInviteToken marks an invitation as used and returns it. The row is locked first so two
+// concurrent accepts of the same token cannot both succeed. Unknown, expired and already-used
+// tokens are reported as distinct 400 errors.
+func (r *pgxRepository) ConsumeInviteToken(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.Invitation, error) {
+	query := `
+        SELECT id, employee_profile_id, clinic_id, token_hash, expires_at, consumed_at, created_at
+        FROM employee_invitations
+        WHERE token_hash = $1
+        FOR UPDATE`
+	var inv model.Invitation
+	err := tx.QueryRow(ctx, query, tokenHash).Scan(
+		&inv.ID, &inv.EmployeeProfileID, &inv.ClinicID, &inv.TokenHash, &inv.ExpiresAt, &inv.ConsumedAt, &inv.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewBadRequest("This invitation link is invalid.", err)
+		}
+		return nil, fmt.Errorf("store.ConsumeInviteToken: failed to query invitation: %w", err)
+	}
+	if inv.ConsumedAt != nil {
+		return nil, apierror.NewBadRequest("This invitation has already been used.", nil)
+	}
+	if !time.Now().Before(inv.ExpiresAt) {
+		return nil, apierror.NewBadRequest("This invitation has expired. Ask your clinic administrator to send a new one.", nil)
+	}
+
+	if err := tx.QueryRow(ctx, `UPDATE employee_invitations SET consumed_at = NOW() WHERE id = $1 RETURNING consumed_at`, inv.ID).Scan(&inv.ConsumedAt); err != nil {
+		return nil, fmt.Errorf("store.ConsumeInviteToken: failed to consume invitation: %w", err)
+	}
+	return &inv, nil
+}
+
+// ActivateEmployee sets the password of an invited employee and flips them to ACTIVE.
+func (r *pgxRepository) ActivateEmployee(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, passwordHash string) error {
+	query := `
+        UPDATE employees SET password_hash = $3, status = 'ACTIVE'
+        WHERE profile_id = $1 AND clinic_id = $2 AND status = 'INVITED' AND deleted_at IS NULL`
+	tag, err := tx.Exec(ctx, query, profileID, clinicID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("store.ActivateEmployee: failed to activate employee: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewBadRequest("This invitation is no longer valid.", nil)
+	}
+	return nil
+}
+
+// CreateRefreshToken persists the hash of a newly issued refresh token.
+func (r *pgxRepository) CreateRefreshToken(ctx context.Context, tx pgx.Tx, token *model.RefreshToken) error {
+	query := `
+        INSERT INTO refresh_tokens (id, session_id, employee_profile_id, clinic_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := tx.Exec(ctx, query, token.ID, token.SessionID, token.EmployeeProfileID, token.ClinicID, token.TokenHash, token.ExpiresAt); err != nil {
+		return fmt.Errorf("store.CreateRefreshToken: failed to insert refresh token: %w", err)
+	}
+	return nil
+}
+
+// FindRefreshTokenForUpdate loads a refresh token by hash and locks it for rotation.
+func (r *pgxRepository) FindRefreshTokenForUpdate(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+        SELECT id, session_id, employee_profile_id, clinic_id, token_hash, created_at, expires_at, revoked_at, replaced_by
+        FROM refresh_tokens
+        WHERE token_hash = $1
+        FOR UPDATE`
+	var t model.RefreshToken
+	err := tx.QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID, &t.SessionID, &t.EmployeeProfileID, &t.ClinicID, &t.TokenHash, &t.CreatedAt, &t.ExpiresAt, &t.RevokedAt, &t.ReplacedBy,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewUnauthorized("invalid refresh token", err)
+		}
+		return nil, fmt.Errorf("store.FindRefreshTokenForUpdate: failed to query refresh token: %w", err)
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken revokes a token and records the token that replaced it.
+func (r *pgxRepository) RotateRefreshToken(ctx context.Context, tx pgx.Tx, oldID, newID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = $2 WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, oldID, newID); err != nil {
+		return fmt.Errorf("store.RotateRefreshToken: failed to rotate refresh token: %w", err)
+	}
+	return nil
+}
+
+// RevokeRefreshSession revokes every still-active refresh token of a session.
+func (r *pgxRepository) RevokeRefreshSession(ctx context.Context, tx pgx.Tx, sessionID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE session_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, query, sessionID); err != nil {
+		return fmt.Errorf("store.RevokeRefreshSession: failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeRefreshTokensForEmployee revokes every still-active refresh token of an employee.
+func (r *pgxRepository) RevokeRefreshTokensForEmployee(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) error {
+	query := `
+        UPDATE refresh_tokens SET revoked_at = NOW()
+        WHERE clinic_id = $1 AND employee_profile_id = $2 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, query, clinicID, profileID); err != nil {
+		return fmt.Errorf("store.RevokeRefreshTokensForEmployee: failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// CountActiveSessions returns how many of an employee's sessions still hold a usable refresh token.
+func (r *pgxRepository) CountActiveSessions(ctx context.Context, clinicID, profileID uuid.UUID) (int, error) {
+	query := `
+        SELECT COUNT(DISTINCT session_id)
+        FROM refresh_tokens
+        WHERE clinic_id = $1 AND employee_profile_id = $2
+          AND revoked_at IS NULL AND replaced_by IS NULL AND expires_at > NOW()`
+	var count int
+	if err := r.db.QueryRow(ctx, query, clinicID, profileID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("store.CountActiveSessions: failed to count sessions: %w", err)
+	}
+	return count, nil
+}
+
+// CreateRole inserts a new clinic role.
+func (r *pgxRepository) CreateRole(ctx context.Context, tx pgx.Tx, role *model.Role) error {
+	query := `
+        INSERT INTO roles (id, clinic_id, name, description, is_system_role)
+        VALUES ($1, $2, $3, $4, FALSE)
+        RETURNING created_at, updated_at`
+	err := tx.QueryRow(ctx, query, role.ID, role.ClinicID, role.Name, role.Description).Scan(&role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		if IsUniqueViolationError(err) {
+			return apierror.NewConflict("A role with this name already exists.", err).WithCode(apierror.CodeDuplicateResource)
+		}
+		return fmt.Errorf("store.CreateRole: failed to insert role: %w", err)
+	}
+	return nil
+}
+
+// UpdateRole changes a clinic role's name and description. System roles are never matched.
+func (r *pgxRepository) UpdateRole(ctx context.Context, tx pgx.Tx, role *model.Role) error {
+	query := `
+        UPDATE roles SET name = $3, description = $4
+        WHERE id = $1 AND clinic_id = $2 AND NOT is_system_role AND deleted_at IS NULL
+        RETURNING created_at, updated_at`
+	err := tx.QueryRow(ctx, query, role.ID, role.ClinicID, role.Name, role.Description).Scan(&role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewNotFound("role", err)
+		}
+		if IsUniqueViolationError(err) {
+			return apierror.NewConflict("A role with this name already exists.", err).WithCode(apierror.CodeDuplicateResource)
+		}
+		return fmt.Errorf("store.UpdateRole: failed to update role: %w", err)
+	}
+	return nil
+}
+
+// UpdateRolePermissions replaces the role's permission set with the given keys.
+// Platform permissions can never be granted to clinic roles. Unknown keys are rejected with a 400.
+func (r *pgxRepository) UpdateRolePermissions(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, permissionKeys []string) ([]model.Permission, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT id, permission_key, description FROM permissions
+        WHERE permission_key = ANY($1) AND permission_key NOT LIKE 'platform.%'
+        ORDER BY permission_key`, permissionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("store.UpdateRolePermissions: failed to resolve permissions: %w", err)
+	}
+	permissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[model.Permission])
+	if err != nil {
+		return nil, fmt.Errorf("store.UpdateRolePermissions: failed to scan permissions: %w", err)
+	}
+	if len(permissions) != len(permissionKeys) {
+		var unknown []string
+		for _, key := range permissionKeys {
+			if !slices.ContainsFunc(permissions, func(p model.Permission) bool { return p.PermissionKey == key }) {
+				unknown = append(unknown, key)
+			}
+		}
+		return nil, apierror.NewBadRequest(fmt.Sprintf("Unknown or non-assignable permissions: %s.", strings.Join(unknown, ", ")), nil)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return nil, fmt.Errorf("store.UpdateRolePermissions: failed to clear permissions: %w", err)
+	}
+	ids := make([]int16, len(permissions))
+	for i, p := range permissions {
+		ids[i] = p.ID
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO role_permissions (role_id, permission_id) SELECT $1, unnest($2::smallint[])`, roleID, ids); err != nil {
+		return nil, fmt.Errorf("store.UpdateRolePermissions: failed to insert permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// UpsertPermissions inserts the permissions and moves any existing id onto its catalog key and description.
+func (r *pgxRepository) UpsertPermissions(ctx context.Context, tx pgx.Tx, permissions []model.Permission) error {
+	ids := make([]int16, len(permissions))
+	keys := make([]string, len(permissions))
+	descriptions := make([]string, len(permissions))
+	for i, p := range permissions {
+		ids[i] = p.ID
+		keys[i] = p.PermissionKey
+		descriptions[i] = p.Description
+	}
+	query := `
+        INSERT INTO permissions (id, permission_key, description)
+        SELECT * FROM unnest($1::smallint[], $2::text[], $3::text[])
+        ON CONFLICT (id) DO UPDATE SET permission_key = EXCLUDED.permission_key, description = EXCLUDED.description
+        WHERE (permissions.permission_key, permissions.description) <> (EXCLUDED.permission_key, EXCLUDED.description)`
+	if _, err := tx.Exec(ctx, query, ids, keys, descriptions); err != nil {
+		return fmt.Errorf("store.UpsertPermissions: failed to upsert permissions: %w", err)
+	}
+	return nil
+}
+
+// ListPermissions returns every permission clinic roles can hold, ordered bytewise by key so
+// keys sharing a module prefix are adjacent.
+// Platform permissions are left out since they can never be granted to a clinic role.
+func (r *pgxRepository) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT id, permission_key, description FROM permissions
+        WHERE permission_key NOT LIKE 'platform.%'
+        ORDER BY permission_key COLLATE "C"`)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListPermissions: failed to query permissions: %w", err)
+	}
+	permissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[model.Permission])
+	if err != nil {
+		return nil, fmt.Errorf("store.ListPermissions: failed to scan permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// EnsureSystemRole creates the system role unless one with its name exists and sets role.ID
+// either way. An existing role's description is left as it is.
+func (r *pgxRepository) EnsureSystemRole(ctx context.Context, tx pgx.Tx, role *model.Role) (created bool, err error) {
+	// The outer SELECT cannot see the CTE's insert, so exactly one branch returns a row.
+	query := `
+        WITH inserted AS (
+            INSERT INTO roles (id, clinic_id, name, description, is_system_role)
+            VALUES ($1, NULL, $2, $3, TRUE)
+            ON CONFLICT (name) WHERE is_system_role AND deleted_at IS NULL DO NOTHING
+            RETURNING id
+        )
+        SELECT id, TRUE FROM inserted
+        UNION ALL
+        SELECT id, FALSE FROM roles WHERE is_system_role AND name = $2 AND deleted_at IS NULL`
+	if err := tx.QueryRow(ctx, query, role.ID, role.Name, role.Description).Scan(&role.ID, &created); err != nil {
+		return false, fmt.Errorf("store.EnsureSystemRole: failed to upsert role %q: %w", role.Name, err)
+	}
+	return created, nil
+}
+
+// GrantRolePermissions adds the permissions to the role, keeping those it already has,
+// and returns how many were new.
+func (r *pgxRepository) GrantRolePermissions(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, permissionKeys []string) (int64, error) {
+	query := `
+        INSERT INTO role_permissions (role_id, permission_id)
+        SELECT $1, id FROM permissions WHERE permission_key = ANY($2)
+        ON CONFLICT DO NOTHING`
+	tag, err := tx.Exec(ctx, query, roleID, permissionKeys)
+	if err != nil {
+		return 0, fmt.Errorf("store.GrantRolePermissions: failed to grant permissions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListRolesForClinic returns the system roles and the clinic's own active roles with their permissions.
+func (r *pgxRepository) ListRolesForClinic(ctx context.Context, clinicID uuid.UUID) ([]model.Role, error) {
+	return r.queryRoles(ctx, "store.ListRolesForClinic", `(r.clinic_id = $1 OR r.is_system_role)`, clinicID)
+}
+
+// FindRoleByID returns an active role visible to the clinic, with its permissions.
+func (r *pgxRepository) FindRoleByID(ctx context.Context, clinicID, roleID uuid.UUID) (*model.Role, error) {
+	roles, err := r.queryRoles(ctx, "store.FindRoleByID", `(r.clinic_id = $1 OR r.is_system_role) AND r.id = $2`, clinicID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, apierror.NewNotFound("role", nil)
+	}
+	return &roles[0], nil
+}
+
+// queryRoles loads roles matching the filter together with their permissions, ordered by name.
+func (r *pgxRepository) queryRoles(ctx context.Context, op, filter string, args ...any) ([]model.Role, error) {
+	query := `
+        SELECT r.id, r.clinic_id, r.name, r.description, r.is_system_role, r.created_at, r.updated_at,
+               p.id, p.permission_key
+        FROM roles r
+        LEFT JOIN role_permissions rp ON rp.role_id = r.id
+        LEFT JOIN permissions p ON p.id = rp.permission_id
+        WHERE r.deleted_at IS NULL AND ` + filter + `
+        ORDER BY r.is_system_role DESC, r.name, p.permission_key`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query roles: %w", op, err)
+	}
+	defer rows.Close()
+
+	roles := []model.Role{}
+	for rows.Next() {
+		var role model.Role
+		var pID sql.NullInt16
+		var pKey sql.NullString
+		if err := rows.Scan(&role.ID, &role.ClinicID, &role.Name, &role.Description, &role.IsSystemRole, &role.CreatedAt, &role.UpdatedAt, &pID, &pKey); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		if n := len(roles); n == 0 || roles[n-1].ID != role.ID {
+			role.Permissions = []model.Permission{}
+			roles = append(roles, role)
+		}
+		if pID.Valid && pKey.Valid {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, model.Permission{ID: pID.Int16, PermissionKey: pKey.String})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error iterating rows: %w", op, err)
+	}
+	return roles, nil
+}
+
+// UpdatePublicProfile changes the employee's public listing fields.
+func (r *pgxRepository) UpdatePublicProfile(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, profile *model.PublicProfile) error {
+	query := `
+        UPDATE employees
+        SET is_publicly_listed = $3, public_bio = $4, photo_url = $5, sort_index = $6
+        WHERE profile_id = $1 AND clinic_id = $2 AND deleted_at IS NULL`
+	tag, err := tx.Exec(ctx, query, profileID, clinicID, profile.IsPubliclyListed, profile.Bio, profile.PhotoURL, profile.SortIndex)
+	if err != nil {
+		return fmt.Errorf("store.UpdatePublicProfile: failed to update employee: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewNotFound("employee", nil)
+	}
+	return nil
+}
+
+// ListPublicPractitioners returns the clinic's active, publicly listed employees in display order.
+func (r *pgxRepository) ListPublicPractitioners(ctx context.Context, clinicID uuid.UUID) ([]model.Practitioner, error) {
+	query := `
+        SELECT e.profile_id, p.full_name, e.job_title, e.photo_url, e.public_bio
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        WHERE e.clinic_id = $1 AND e.is_publicly_listed AND e.status = 'ACTIVE'
+          AND e.deleted_at IS NULL AND p.deleted_at IS NULL
+        ORDER BY e.sort_index, p.full_name`
+	rows, err := r.db.Query(ctx, query, clinicID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListPublicPractitioners: failed to query practitioners: %w", err)
+	}
+	practitioners, err := pgx.CollectRows(rows, pgx.RowToStructByPos[model.Practitioner])
+	if err != nil {
+		return nil, fmt.Errorf("store.ListPublicPractitioners: failed to scan practitioners: %w", err)
+	}
+	return practitioners, nil
+}
+
+// FindRoleForUpdate loads an active role visible to the clinic (its own or a system role) and locks it
+// for the rest of the transaction so it cannot be deleted concurrently.
+func (r *pgxRepository) FindRoleForUpdate(ctx context.Context, tx pgx.Tx, clinicID, roleID uuid.UUID) (*model.Role, error) {
+	query := `
+        SELECT id, clinic_id, name, description, is_system_role, created_at, updated_at
+        FROM roles
+        WHERE id = $1 AND (clinic_id = $2 OR is_system_role) AND deleted_at IS NULL
+        FOR UPDATE`
+	var role model.Role
+	err := tx.QueryRow(ctx, query, roleID, clinicID).Scan(
+		&role.ID, &role.ClinicID, &role.Name, &role.Description, &role.IsSystemRole, &role.CreatedAt, &role.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("role", err)
+		}
+		return nil, fmt.Errorf("store.FindRoleForUpdate: failed to query role: %w", err)
+	}
+	return &role, nil
+}
+
+// CountRoleHolders returns how many of the clinic's employees hold the role.
+func (r *pgxRepository) CountRoleHolders(ctx context.Context, tx pgx.Tx, clinicID, roleID uuid.UUID) (int, error) {
+	query := `
+        SELECT COUNT(*)
+        FROM employee_roles er
+        JOIN employees e ON e.profile_id = er.employee_profile_id
+        WHERE er.role_id = $1 AND e.clinic_id = $2`
+	var count int
+	if err := tx.QueryRow(ctx, query, roleID, clinicID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("store.CountRoleHolders: failed to count role holders: %w", err)
+	}
+	return count, nil
+}
+
+// MoveRoleHolders moves the clinic's employees from one role to another in a single statement.
+// Employees who already hold the target role simply lose the source role, so the
+// (employee_profile_id, role_id) primary key is never violated. It returns the affected employees.
+func (r *pgxRepository) MoveRoleHolders(ctx context.Context, tx pgx.Tx, clinicID, fromRoleID, toRoleID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+        WITH moved AS (
+            DELETE FROM employee_roles er
+            USING employees e
+            WHERE er.role_id = $1
+              AND e.profile_id = er.employee_profile_id
+              AND e.clinic_id = $3
+            RETURNING er.employee_profile_id
+        ), inserted AS (
+            INSERT INTO employee_roles (employee_profile_id, role_id)
+            SELECT employee_profile_id, $2 FROM moved
+            ON CONFLICT (employee_profile_id, role_id) DO NOTHING
+        )
+        SELECT employee_profile_id FROM moved`
+	rows, err := tx.Query(ctx, query, fromRoleID, toRoleID, clinicID)
+	if err != nil {
+		return nil, fmt.Errorf("store.MoveRoleHolders: failed to move role holders: %w", err)
+	}
+	employeeIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("store.MoveRoleHolders: failed to collect moved employees: %w", err)
+	}
+	return employeeIDs, nil
+}
+
+// SoftDeleteRole marks a clinic-owned role as deleted. System roles cannot be deleted.
+func (r *pgxRepository) SoftDeleteRole(ctx context.Context, tx pgx.Tx, clinicID, roleID uuid.UUID) error {
+	query := `
+        UPDATE roles SET deleted_at = NOW()
+        WHERE id = $1 AND clinic_id = $2 AND NOT is_system_role AND deleted_at IS NULL`
+	tag, err := tx.Exec(ctx, query, roleID, clinicID)
+	if err != nil {
+		return fmt.Errorf("store.SoftDeleteRole: failed to delete role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewNotFound("role", nil)
+	}
+	return nil
+}
+
+// CreateAuthFailure records a rejected authentication attempt.
+func (r *pgxRepository) CreateAuthFailure(ctx context.Context, failure *model.AuthFailure) error {
+	query := `
+        INSERT INTO auth_failures (clinic_slug, identifier_hash, ip_address, user_agent, reason)
+        VALUES ($1, $2, NULLIF($3::text, '')::inet, $4, $5)`
+	var ip string
+	if failure.IPAddress != nil {
+		ip = *failure.IPAddress
+	}
+	if _, err := r.db.Exec(ctx, query, failure.ClinicSlug, failure.IdentifierHash, ip, failure.UserAgent, failure.Reason); err != nil {
+		return fmt.Errorf("store.CreateAuthFailure: failed to insert auth failure: %w", err)
+	}
+	return nil
+}
+
+// ResolveAuthFailureStreak links all open failures for an identifier to the successful login that ended them.
+func (r *pgxRepository) ResolveAuthFailureStreak(ctx context.Context, identifierHash string, profileID uuid.UUID) error {
+	query := `
+        UPDATE auth_failures
+        SET resolved_at = NOW(), resolved_profile_id = $2
+        WHERE identifier_hash = $1 AND resolved_at IS NULL`
+	if _, err := r.db.Exec(ctx, query, identifierHash, profileID); err != nil {
+		return fmt.Errorf("store.ResolveAuthFailureStreak: failed to resolve auth failures: %w", err)
+	}
+	return nil
+}
+
+// ListAuthFailures returns the most recent auth failures matching the filter.
+func (r *pgxRepository) ListAuthFailures(ctx context.Context, filter model.AuthFailureFilter) ([]model.AuthFailure, error) {
+	query := `
+        SELECT id, clinic_slug, identifier_hash, host(ip_address), user_agent, reason, resolved_at, resolved_profile_id, occurred_at
+        FROM auth_failures
+        WHERE ($1 = '' OR clinic_slug = $1)
+          AND ($2 = '' OR identifier_hash = $2)
+          AND ($3 = '' OR ip_address = NULLIF($3, '')::inet)
+          AND ($4 = '' OR reason = $4)
+          AND ($5::timestamptz IS NULL OR occurred_at >= $5)
+          AND ($6::timestamptz IS NULL OR occurred_at < $6)
+        ORDER BY occurred_at DESC
+        LIMIT $7
+    `
+	rows, err := r.db.Query(ctx, query,
+		filter.ClinicSlug, filter.IdentifierHash, filter.IPAddress, string(filter.Reason),
+		filter.From, filter.To, filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListAuthFailures: failed to query auth failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := []model.AuthFailure{}
+	for rows.Next() {
+		var f model.AuthFailure
+		if err := rows.Scan(
+			&f.ID, &f.ClinicSlug, &f.IdentifierHash, &f.IPAddress, &f.UserAgent, &f.Reason,
+			&f.ResolvedAt, &f.ResolvedProfileID, &f.OccurredAt,
+		); err != nil {
+			return nil, fmt.Errorf("store.ListAuthFailures: failed to scan row: %w", err)
+		}
+		failures = append(failures, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListAuthFailures: error iterating rows: %w", err)
+	}
+	return failures, nil
+}
+
+// DeleteAuthFailuresBefore removes auth failures older than the cutoff and returns how many were deleted.
+func (r *pgxRepository) DeleteAuthFailuresBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	cmdTag, err := r.db.Exec(ctx, `DELETE FROM auth_failures WHERE occurred_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("store.DeleteAuthFailuresBefore: failed to delete auth failures: %w", err)
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+// LockEmployee locks the clinic's employee row so concurrent role changes serialize.
+func (r *pgxRepository) LockEmployee(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) error {
+	query := `
+        SELECT profile_id FROM employees
+        WHERE profile_id = $1 AND clinic_id = $2 AND deleted_at IS NULL
+        FOR UPDATE`
+	var id uuid.UUID
+	if err := tx.QueryRow(ctx, query, profileID, clinicID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewNotFound("employee", err)
+		}
+		return fmt.Errorf("store.LockEmployee: failed to lock employee: %w", err)
+	}
+	return nil
+}
+
+// AddEmployeeRole grants the role and reports whether the employee did not already hold it.
+func (r *pgxRepository) AddEmployeeRole(ctx context.Context, tx pgx.Tx, profileID, roleID uuid.UUID) (bool, error) {
+	query := `
+        INSERT INTO employee_roles (employee_profile_id, role_id) VALUES ($1, $2)
+        ON CONFLICT (employee_profile_id, role_id) DO NOTHING`
+	tag, err := tx.Exec(ctx, query, profileID, roleID)
+	if err != nil {
+		return false, fmt.Errorf("store.AddEmployeeRole: failed to insert employee role: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RemoveEmployeeRole revokes the role and reports whether the employee held it.
+func (r *pgxRepository) RemoveEmployeeRole(ctx context.Context, tx pgx.Tx, profileID, roleID uuid.UUID) (bool, error) {
+	query := `DELETE FROM employee_roles WHERE employee_profile_id = $1 AND role_id = $2`
+	tag, err := tx.Exec(ctx, query, profileID, roleID)
+	if err != nil {
+		return false, fmt.Errorf("store.RemoveEmployeeRole: failed to delete employee role: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// FindEmployeeStatusForUpdate returns the employee's status and locks the row for the transaction.
+func (r *pgxRepository) FindEmployeeStatusForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (model.EmployeeStatus, error) {
+	query := `
+        SELECT status FROM employees
+        WHERE profile_id = $1 AND clinic_id = $2 AND deleted_at IS NULL
+        FOR UPDATE`
+	var status model.EmployeeStatus
+	if err := tx.QueryRow(ctx, query, profileID, clinicID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", apierror.NewNotFound("employee", err)
+		}
+		return "", fmt.Errorf("store.FindEmployeeStatusForUpdate: failed to query employee: %w", err)
+	}
+	return status, nil
+}
+
+// UpdateEmployeeStatus sets the employee's status. Terminating also records the employment end date.
+func (r *pgxRepository) UpdateEmployeeStatus(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, status model.EmployeeStatus) error {
+	query := `
+        UPDATE employees
+        SET status = $3,
+            employment_end_date = CASE WHEN $3 = 'TERMINATED' THEN CURRENT_DATE ELSE employment_end_date END
+        WHERE profile_id = $1 AND clinic_id = $2 AND deleted_at IS NULL`
+	tag, err := tx.Exec(ctx, query, profileID, clinicID, status)
+	if err != nil {
+		return fmt.Errorf("store.UpdateEmployeeStatus: failed to update employee: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewNotFound("employee", nil)
+	}
+	return nil
+}
+
+// UpdateEmployeeDetails writes the fields present in patch: the job title on the employee
+// row and the name on its profile. The caller is expected to have checked the employee exists.
+func (r *pgxRepository) UpdateEmployeeDetails(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, patch *model.EmployeePatch) error {
+	var employeeSet database.SetClause
+	database.SetOptional(&employeeSet, "job_title", patch.JobTitle)
+	if !employeeSet.IsEmpty() {
+		assignments, args := employeeSet.SQL()
+		query := fmt.Sprintf(`UPDATE employees SET %s WHERE profile_id = $%d AND clinic_id = $%d AND deleted_at IS NULL`,
+			assignments, len(args)+1, len(args)+2)
+		tag, err := tx.Exec(ctx, query, append(args, profileID, clinicID)...)
+		if err != nil {
+			return fmt.Errorf("store.UpdateEmployeeDetails: failed to update employee: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return apierror.NewNotFound("employee", nil)
+		}
+	}
+
+	var profileSet database.SetClause
+	database.SetOptional(&profileSet, "full_name", patch.FullName)
+	if !profileSet.IsEmpty() {
+		assignments, args := profileSet.SQL()
+		query := fmt.Sprintf(`UPDATE profiles SET %s WHERE id = $%d AND clinic_id = $%d AND deleted_at IS NULL`,
+			assignments, len(args)+1, len(args)+2)
+		tag, err := tx.Exec(ctx, query, append(args, profileID, clinicID)...)
+		if err != nil {
+			return fmt.Errorf("store.UpdateEmployeeDetails: failed to update profile: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return apierror.NewNotFound("employee", nil)
+		}
+	}
+	return nil
+}
+
+// RecordLogin stamps the employee's last login and appends a successful login event.
+func (r *pgxRepository) RecordLogin(ctx context.Context, profileID uuid.UUID, ip, userAgent string) error {
+	query := `
+        WITH stamped AS (
+            UPDATE employees SET last_login_at = NOW()
+            WHERE profile_id = $1
+            RETURNING clinic_id
+        )
+        INSERT INTO login_events (clinic_id, employee_profile_id, succeeded, ip_address, user_agent)
+        SELECT clinic_id, $1, TRUE, NULLIF($2::text, '')::inet, NULLIF($3, '') FROM stamped`
+	if _, err := r.db.Exec(ctx, query, profileID, ip, userAgent); err != nil {
+		return fmt.Errorf("store.RecordLogin: failed to record login: %w", err)
+	}
+	return nil
+}
+
+// RecordFailedLogin appends a rejected login event for a known employee.
+func (r *pgxRepository) RecordFailedLogin(ctx context.Context, profileID uuid.UUID, ip, userAgent string, reason model.AuthFailureReason) error {
+	query := `
+        INSERT INTO login_events (clinic_id, employee_profile_id, succeeded, failure_reason, ip_address, user_agent)
+        SELECT clinic_id, profile_id, FALSE, $2, NULLIF($3::text, '')::inet, NULLIF($4, '')
+        FROM employees WHERE profile_id = $1`
+	if _, err := r.db.Exec(ctx, query, profileID, reason, ip, userAgent); err != nil {
+		return fmt.Errorf("store.RecordFailedLogin: failed to record login: %w", err)
+	}
+	return nil
+}
+
+// ListLoginEvents returns the employee's most recent login events, newest first.
+func (r *pgxRepository) ListLoginEvents(ctx context.Context, clinicID, profileID uuid.UUID, limit int) ([]model.LoginEvent, error) {
+	var exists bool
+	existsQuery := `SELECT EXISTS (SELECT 1 FROM employees WHERE profile_id = $1 AND clinic_id = $2 AND deleted_at IS NULL)`
+	if err := r.db.QueryRow(ctx, existsQuery, profileID, clinicID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("store.ListLoginEvents: failed to query employee: %w", err)
+	}
+	if !exists {
+		return nil, apierror.NewNotFound("employee", nil)
+	}
+
+	query := `
+        SELECT id, clinic_id, employee_profile_id, succeeded, failure_reason, host(ip_address), user_agent, occurred_at
+        FROM login_events
+        WHERE clinic_id = $1 AND employee_profile_id = $2
+        ORDER BY occurred_at DESC
+        LIMIT $3`
+	rows, err := r.db.Query(ctx, query, clinicID, profileID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListLoginEvents: failed to query login events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByPos[model.LoginEvent])
+	if err != nil {
+		return nil, fmt.Errorf("store.ListLoginEvents: failed to scan login events: %w", err)
+	}
+	return events, nil
+}
+
+// emailChangeColumns lists the employee_email_changes columns read by scanEmailChange.
+const emailChangeColumns = `id, clinic_id, employee_profile_id, old_email, new_email, requested_by,
+        verify_expires_at, undo_expires_at, verified_at, cancelled_at, undone_at, created_at`
+
+func scanEmailChange(row pgx.Row) (*model.EmailChange, error) {
+	c := &model.EmailChange{}
+	err := row.Scan(
+		&c.ID, &c.ClinicID, &c.EmployeeProfileID, &c.OldEmail, &c.NewEmail, &c.RequestedBy,
+		&c.VerifyExpiresAt, &c.UndoExpiresAt, &c.VerifiedAt, &c.CancelledAt, &c.UndoneAt, &c.CreatedAt,
+	)
+	return c, err
+}
+
+// FindEmployeeEmailForUpdate returns the employee's current email and locks the employee row.
+func (r *pgxRepository) FindEmployeeEmailForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (*string, error) {
+	query := `
+        SELECT p.email
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        WHERE e.profile_id = $1 AND e.clinic_id = $2 AND e.deleted_at IS NULL
+        FOR UPDATE OF e`
+	var email *string
+	if err := tx.QueryRow(ctx, query, profileID, clinicID).Scan(&email); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("employee", err)
+		}
+		return nil, fmt.Errorf("store.FindEmployeeEmailForUpdate: failed to query employee: %w", err)
+	}
+	return email, nil
+}
+
+// IsEmailInUse reports whether an active profile in the clinic already uses email.
+func (r *pgxRepository) IsEmailInUse(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, email string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM profiles WHERE clinic_id = $1 AND lower(email) = lower($2) AND deleted_at IS NULL)`
+	var inUse bool
+	if err := tx.QueryRow(ctx, query, clinicID, email).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("store.IsEmailInUse: failed to query profiles: %w", err)
+	}
+	return inUse, nil
+}
+
+// UpdateProfileEmail rewrites the profile's email. A unique violation is reported as a 409.
+func (r *pgxRepository) UpdateProfileEmail(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, email *string) error {
+	query := `UPDATE profiles SET email = $3, updated_at = NOW() WHERE id = $1 AND clinic_id = $2 AND deleted_at IS NULL`
+	tag, err := tx.Exec(ctx, query, profileID, clinicID, email)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return apierror.NewConflict("This email address is already in use in this clinic.", err).WithCode(apierror.CodeDuplicateEmail)
+		}
+		return fmt.Errorf("store.UpdateProfileEmail: failed to update profile: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewNotFound("employee", nil)
+	}
+	return nil
+}
+
+// CreateEmailChange stores a new email change request.
+func (r *pgxRepository) CreateEmailChange(ctx context.Context, tx pgx.Tx, change *model.EmailChange) error {
+	query := `
+        INSERT INTO employee_email_changes (id, clinic_id, employee_profile_id, old_email, new_email, requested_by, verify_token_hash, verify_expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING created_at`
+	err := tx.QueryRow(ctx, query,
+		change.ID, change.ClinicID, change.EmployeeProfileID, change.OldEmail, change.NewEmail,
+		change.RequestedBy, change.VerifyTokenHash, change.VerifyExpiresAt,
+	).Scan(&change.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("store.CreateEmailChange: failed to insert email change: %w", err)
+	}
+	return nil
+}
+
+// FindPendingEmailChange returns the employee's open email change request.
+func (r *pgxRepository) FindPendingEmailChange(ctx context.Context, clinicID, profileID uuid.UUID) (*model.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + `
+        FROM employee_email_changes
+        WHERE clinic_id = $1 AND employee_profile_id = $2 AND verified_at IS NULL AND cancelled_at IS NULL`
+	change, err := scanEmailChange(r.db.QueryRow(ctx, query, clinicID, profileID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("email change", err)
+		}
+		return nil, fmt.Errorf("store.FindPendingEmailChange: failed to query email change: %w", err)
+	}
+	return change, nil
+}
+
+// CancelPendingEmailChange cancels the employee's open request and reports whether there was one.
+func (r *pgxRepository) CancelPendingEmailChange(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (bool, error) {
+	query := `
+        UPDATE employee_email_changes SET cancelled_at = NOW()
+        WHERE clinic_id = $1 AND employee_profile_id = $2 AND verified_at IS NULL AND cancelled_at IS NULL`
+	tag, err := tx.Exec(ctx, query, clinicID, profileID)
+	if err != nil {
+		return false, fmt.Errorf("store.CancelPendingEmailChange: failed to cancel email change: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// FindEmailChangeByVerifyTokenForUpdate locks the request a verification token belongs to.
+func (r *pgxRepository) FindEmailChangeByVerifyTokenForUpdate(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.EmailChange, error) {
+	return r.findEmailChangeForUpdate(ctx, tx, "verify_token_hash", tokenHash)
+}
+
+// FindEmailChangeByUndoTokenForUpdate locks the request an undo token belongs to.
+func (r *pgxRepository) FindEmailChangeByUndoTokenForUpdate(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.EmailChange, error) {
+	return r.findEmailChangeForUpdate(ctx, tx, "undo_token_hash", tokenHash)
+}
+
+// findEmailChangeForUpdate looks a request up by one of its token hash columns. column is never user input.
+func (r *pgxRepository) findEmailChangeForUpdate(ctx context.Context, tx pgx.Tx, column, tokenHash string) (*model.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + `
+        FROM employee_email_changes
+        WHERE ` + column + ` = $1
+        FOR UPDATE`
+	change, err := scanEmailChange(tx.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("email change", err)
+		}
+		return nil, fmt.Errorf("store.findEmailChangeForUpdate: failed to query email change: %w", err)
+	}
+	return change, nil
+}
+
+// MarkEmailChangeVerified records the verification and stores the undo token for the old address.
+func (r *pgxRepository) MarkEmailChangeVerified(ctx context.Context, tx pgx.Tx, change *model.EmailChange) error {
+	query := `
+        UPDATE employee_email_changes
+        SET verified_at = $2, undo_token_hash = NULLIF($3, ''), undo_expires_at = $4
+        WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, change.ID, change.VerifiedAt, change.UndoTokenHash, change.UndoExpiresAt); err != nil {
+		return fmt.Errorf("store.MarkEmailChangeVerified: failed to update email change: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailChangeUndone records that the old address reverted the change.
+func (r *pgxRepository) MarkEmailChangeUndone(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `UPDATE employee_email_changes SET undone_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("store.MarkEmailChangeUndone: failed to update email change: %w", err)
+	}
+	return nil
+}
+
+// CreatePasswordResetToken persists the hash of a new password reset token.
+func (r *pgxRepository) CreatePasswordResetToken(ctx context.Context, tx pgx.Tx, token *model.PasswordResetToken) error {
+	query := `
+        INSERT INTO password_reset_tokens (id, employee_profile_id, clinic_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(ctx, query, token.ID, token.EmployeeProfileID, token.ClinicID, token.TokenHash, token.ExpiresAt); err != nil {
+		return fmt.Errorf("store.CreatePasswordResetToken: failed to insert password reset token: %w", err)
+	}
+	return nil
+}
+
+// ConsumePasswordResetToken locks the token, checks it is still usable and marks it used.
+// Unknown, used and expired tokens all get the same error so the response reveals nothing.
+func (r *pgxRepository) ConsumePasswordResetToken(ctx context.Context, tx pgx.Tx, tokenHash string) (*model.PasswordResetToken, error) {
+	query := `
+        SELECT id, employee_profile_id, clinic_id, token_hash, expires_at, consumed_at, created_at
+        FROM password_reset_tokens
+        WHERE token_hash = $1
+        FOR UPDATE`
+	var t model.PasswordResetToken
+	err := tx.QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID, &t.EmployeeProfileID, &t.ClinicID, &t.TokenHash, &t.ExpiresAt, &t.ConsumedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewBadRequest("This password reset link is invalid or has expired.", err)
+		}
+		return nil, fmt.Errorf("store.ConsumePasswordResetToken: failed to query token: %w", err)
+	}
+	if t.ConsumedAt != nil || !time.Now().Before(t.ExpiresAt) {
+		return nil, apierror.NewBadRequest("This password reset link is invalid or has expired.", nil)
+	}
+
+	if err := tx.QueryRow(ctx, `UPDATE password_reset_tokens SET consumed_at = NOW() WHERE id = $1 RETURNING consumed_at`, t.ID).Scan(&t.ConsumedAt); err != nil {
+		return nil, fmt.Errorf("store.ConsumePasswordResetToken: failed to consume token: %w", err)
+	}
+	return &t, nil
+}
+
+// InvalidatePasswordResetTokens retires every unused reset token of the employee.
+func (r *pgxRepository) InvalidatePasswordResetTokens(ctx context.Context, tx pgx.Tx, profileID uuid.UUID) error {
+	query := `UPDATE password_reset_tokens SET consumed_at = NOW() WHERE employee_profile_id = $1 AND consumed_at IS NULL`
+	if _, err := tx.Exec(ctx, query, profileID); err != nil {
+		return fmt.Errorf("store.InvalidatePasswordResetTokens: failed to invalidate tokens: %w", err)
+	}
+	return nil
+}
+
+// UpdateEmployeePassword replaces the password of an active employee.
+func (r *pgxRepository) UpdateEmployeePassword(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, passwordHash string) error {
+	query := `
+        UPDATE employees SET password_hash = $3
+        WHERE profile_id = $1 AND clinic_id = $2 AND status = 'ACTIVE' AND deleted_at IS NULL`
+	tag, err := tx.Exec(ctx, query, profileID, clinicID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("store.UpdateEmployeePassword: failed to update password: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewBadRequest("This password reset link is invalid or has expired.", nil)
+	}
+	return nil
+}
+
+// UpdatePasswordHash replaces the employee's password hash if it still equals currentHash.
+func (r *pgxRepository) UpdatePasswordHash(ctx context.Context, profileID uuid.UUID, currentHash, newHash string) (bool, error) {
+	query := `UPDATE employees SET password_hash = $3 WHERE profile_id = $1 AND password_hash = $2`
+	tag, err := r.db.Exec(ctx, query, profileID, currentHash, newHash)
+	if err != nil {
+		return false, fmt.Errorf("store.UpdatePasswordHash: failed to update password hash: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RedeemActionLink records the action link's jti. It reports false when the link was redeemed
+// before; the primary key makes concurrent redemptions of the same link race to a single winner.
+func (r *pgxRepository) RedeemActionLink(ctx context.Context, tx pgx.Tx, redemption *model.ActionLinkRedemption) (bool, error) {
+	query := `
+        INSERT INTO action_link_redemptions (token_id, employee_profile_id, clinic_id, action, entity_id, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (token_id) DO NOTHING
+        RETURNING redeemed_at`
+	err := tx.QueryRow(ctx, query,
+		redemption.TokenID, redemption.EmployeeProfileID, redemption.ClinicID,
+		redemption.Action, redemption.EntityID, redemption.ExpiresAt,
+	).Scan(&redemption.RedeemedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("store.RedeemActionLink: failed to record redemption: %w", err)
+	}
+	return true, nil
+}
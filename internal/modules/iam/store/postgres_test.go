@@ -0,0 +1,245 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/fixtures"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedClinic applies the fixture and returns what it created.
+func seedClinic(t *testing.T, pool *pgxpool.Pool, fixture *fixtures.ClinicBuilder) *fixtures.Seeded {
+	t.Helper()
+	seeded, err := fixture.Apply(context.Background(), pool)
+	if err != nil {
+		t.Fatalf("seed clinic: %v", err)
+	}
+	return seeded
+}
+
+func TestListEmployeesReturnsOnlyTheClinicsStaff(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository(pool)
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithOwner().WithPractitioners(2).WithPatients(3))
+	seedClinic(t, pool, fixtures.Clinic().WithOwner().WithPractitioners(1))
+
+	employees, err := repo.ListEmployees(ctx, seeded.Clinic.ID)
+	if err != nil {
+		t.Fatalf("ListEmployees: %v", err)
+	}
+	var got []uuid.UUID
+	for _, e := range employees {
+		got = append(got, e.ProfileID)
+		if e.Status != model.EmployeeStatusActive {
+			t.Errorf("employee %s status = %s, want %s", e.ProfileID, e.Status, model.EmployeeStatusActive)
+		}
+	}
+	want := seeded.Staff()
+	slices.SortFunc(got, uuidCompare)
+	slices.SortFunc(want, uuidCompare)
+	if !slices.Equal(got, want) {
+		t.Errorf("ListEmployees = %v, want the owner and practitioners %v", got, want)
+	}
+
+	for _, e := range employees {
+		if e.ProfileID == seeded.Owner.ProfileID && (len(e.Roles) != 1 || e.Roles[0].Name != "Owner") {
+			t.Errorf("owner roles = %v, want only Owner", e.Roles)
+		}
+	}
+}
+
+func TestListPublicPractitionersReturnsOnlyListedStaffInOrder(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository(pool)
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithOwner().WithPractitioners(3))
+	other := seedClinic(t, pool, fixtures.Clinic().WithPractitioners(1))
+
+	bio := "Paediatric dentist."
+	publish := map[*model.Employee]*model.PublicProfile{
+		seeded.Practitioners[0]: {IsPubliclyListed: true, SortIndex: 2},
+		seeded.Practitioners[1]: {IsPubliclyListed: false, SortIndex: 0},
+		seeded.Practitioners[2]: {IsPubliclyListed: true, SortIndex: 1, Bio: &bio},
+		other.Practitioners[0]:  {IsPubliclyListed: true},
+	}
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		for e, profile := range publish {
+			if err := repo.UpdatePublicProfile(ctx, tx, e.ClinicID, e.ProfileID, profile); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdatePublicProfile: %v", err)
+	}
+
+	practitioners, err := repo.ListPublicPractitioners(ctx, seeded.Clinic.ID)
+	if err != nil {
+		t.Fatalf("ListPublicPractitioners: %v", err)
+	}
+	var got []uuid.UUID
+	for _, p := range practitioners {
+		got = append(got, p.ID)
+	}
+	// The owner and the unlisted practitioner stay out; sort_index orders the rest.
+	want := []uuid.UUID{seeded.Practitioners[2].ProfileID, seeded.Practitioners[0].ProfileID}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ListPublicPractitioners = %v, want %v", got, want)
+	}
+	if first := practitioners[0]; first.DisplayName != seeded.Practitioners[2].Profile.FullName || first.Bio == nil || *first.Bio != bio {
+		t.Errorf("first practitioner = %+v, want %s with the bio", first, seeded.Practitioners[2].Profile.FullName)
+	}
+}
+
+func TestInvitedEmployeeIsFoundByEmailPhoneAndID(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository(pool)
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithOwner())
+	other := seedClinic(t, pool, fixtures.Clinic())
+
+	email, phone, jobTitle := "nour@example.com", "+201005556677", "Hygienist"
+	profile := &model.Profile{ID: uuid.Must(uuid.NewV7()), ClinicID: seeded.Clinic.ID, FullName: "Nour Hassan", Email: &email, PhoneNumber: &phone}
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		return repo.CreateInvitedEmployee(ctx, tx, profile, &model.Employee{
+			ProfileID:   profile.ID,
+			ClinicID:    profile.ClinicID,
+			JobTitle:    &jobTitle,
+			Status:      model.EmployeeStatusInvited,
+			InvitedByID: &seeded.Owner.ProfileID,
+		})
+	})
+	if err != nil {
+		t.Fatalf("CreateInvitedEmployee: %v", err)
+	}
+
+	lookups := []struct {
+		name string
+		find func(clinicID uuid.UUID) (*model.Employee, error)
+	}{
+		{"email", func(clinicID uuid.UUID) (*model.Employee, error) {
+			return repo.FindEmployeeByEmail(ctx, clinicID, email)
+		}},
+		{"phone", func(clinicID uuid.UUID) (*model.Employee, error) {
+			return repo.FindEmployeeByPhone(ctx, clinicID, phone)
+		}},
+		{"id", func(clinicID uuid.UUID) (*model.Employee, error) {
+			return repo.FindEmployeeByIDWithDetails(ctx, clinicID, profile.ID)
+		}},
+	}
+	for _, lookup := range lookups {
+		t.Run(lookup.name, func(t *testing.T) {
+			employee, err := lookup.find(seeded.Clinic.ID)
+			if err != nil {
+				t.Fatalf("find by %s: %v", lookup.name, err)
+			}
+			if employee.ProfileID != profile.ID || employee.Profile.ID != profile.ID || employee.ClinicID != seeded.Clinic.ID {
+				t.Errorf("found %s (profile %s) in %s, want %s in %s", employee.ProfileID, employee.Profile.ID, employee.ClinicID, profile.ID, seeded.Clinic.ID)
+			}
+			if employee.Profile.FullName != profile.FullName || *employee.Profile.Email != email || *employee.Profile.PhoneNumber != phone {
+				t.Errorf("profile = %q %v %v, want %q %s %s", employee.Profile.FullName, employee.Profile.Email, employee.Profile.PhoneNumber, profile.FullName, email, phone)
+			}
+			if employee.Status != model.EmployeeStatusInvited || employee.JobTitle == nil || *employee.JobTitle != jobTitle ||
+				employee.InvitedByID == nil || *employee.InvitedByID != seeded.Owner.ProfileID {
+				t.Errorf("employee = %s, %v, invited by %v; want INVITED %s invited by the owner", employee.Status, employee.JobTitle, employee.InvitedByID, jobTitle)
+			}
+
+			// Another clinic's lookup does not see the employee.
+			_, err = lookup.find(other.Clinic.ID)
+			var apiErr *apierror.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+				t.Errorf("find by %s in another clinic: error = %v, want a 404", lookup.name, err)
+			}
+		})
+	}
+}
+
+func TestInviteRollsBackTheProfileWhenTheEmployeeInsertFails(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository(pool)
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithPractitioners(1))
+	existing := seeded.Practitioners[0]
+
+	email := "sara@example.com"
+	profile := &model.Profile{ID: uuid.Must(uuid.NewV7()), ClinicID: seeded.Clinic.ID, FullName: "Sara Mostafa", Email: &email}
+	// The profile insert succeeds; the employee row repeats an existing employee and fails.
+	err := database.NewTxManager(pool).ExecTx(ctx, func(tx pgx.Tx) error {
+		return repo.CreateInvitedEmployee(ctx, tx, profile, &model.Employee{
+			ProfileID: existing.ProfileID,
+			ClinicID:  seeded.Clinic.ID,
+			Status:    model.EmployeeStatusInvited,
+		})
+	})
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("CreateInvitedEmployee error = %v, want a 409", err)
+	}
+
+	var profiles int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM profiles WHERE id = $1 OR email = $2`, profile.ID, email).Scan(&profiles); err != nil {
+		t.Fatal(err)
+	}
+	if profiles != 0 {
+		t.Errorf("%d profile(s) left behind by the failed invite, want the insert rolled back", profiles)
+	}
+	if _, err := repo.FindEmployeeByIDWithDetails(ctx, seeded.Clinic.ID, existing.ProfileID); err != nil {
+		t.Errorf("the existing employee is gone after the failed invite: %v", err)
+	}
+}
+
+func TestListPermissionsReturnsTheGrantableCatalogInKeyOrder(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxRepository(pool)
+	if err := iam.SeedRBAC(ctx, database.NewTxManager(pool), repo); err != nil {
+		t.Fatalf("SeedRBAC: %v", err)
+	}
+
+	permissions, err := repo.ListPermissions(ctx)
+	if err != nil {
+		t.Fatalf("ListPermissions: %v", err)
+	}
+	listed := make(map[string]model.Permission, len(permissions))
+	for i, p := range permissions {
+		if strings.HasPrefix(p.PermissionKey, model.PlatformPermissionPrefix) {
+			t.Errorf("ListPermissions includes %s, which clinic roles cannot be granted", p.PermissionKey)
+		}
+		if i > 0 && permissions[i-1].PermissionKey >= p.PermissionKey {
+			t.Errorf("%s is listed after %s, want key order", p.PermissionKey, permissions[i-1].PermissionKey)
+		}
+		listed[p.PermissionKey] = p
+	}
+	for _, want := range model.PermissionCatalog {
+		if strings.HasPrefix(want.PermissionKey, model.PlatformPermissionPrefix) {
+			continue
+		}
+		got, ok := listed[want.PermissionKey]
+		if !ok {
+			t.Errorf("ListPermissions is missing %s", want.PermissionKey)
+			continue
+		}
+		if got.ID != want.ID || got.Description != want.Description {
+			t.Errorf("%s = id %d %q, want id %d %q", want.PermissionKey, got.ID, got.Description, want.ID, want.Description)
+		}
+	}
+}
+
+func uuidCompare(a, b uuid.UUID) int {
+	return slices.Compare(a[:], b[:])
+}
@@ -0,0 +1,16 @@
+// Package dto contains the Data Transfer Objects for the lookup module's API contract.
+package dto
+
+import "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/model"
+
+// LookupsResponse returns each requested list keyed by its type.
+type LookupsResponse struct {
+	Data    map[model.Type][]model.Item `json:"data"`
+	Notices []NoticeResponse            `json:"notices"`
+}
+
+// NoticeResponse explains why a requested type is missing from the response.
+type NoticeResponse struct {
+	Type    model.Type `json:"type"`
+	Message string     `json:"message"`
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler holds the dependencies for the lookup HTTP handlers.
+type Handler struct {
+	service lookup.Service
+}
+
+// NewHandler creates a new lookup handler with the given service.
+func NewHandler(service lookup.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetLookups returns the compact lists named in the comma-separated `types` query parameter.
+func (h *Handler) GetLookups(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var types []model.Type
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, model.Type(t))
+		}
+	}
+	if len(types) == 0 {
+		return apierror.NewBadRequest("The 'types' query parameter is required.", nil)
+	}
+
+	result, err := h.service.GetLookups(c.Request.Context(), payload.ClinicID, payload.Permissions, types)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	notices := make([]dto.NoticeResponse, len(result.Notices))
+	for i, n := range result.Notices {
+		notices[i] = dto.NoticeResponse{Type: n.Type, Message: n.Message}
+	}
+
+	c.JSON(http.StatusOK, dto.LookupsResponse{Data: result.Lists, Notices: notices})
+	return nil
+}
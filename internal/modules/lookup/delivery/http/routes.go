@@ -0,0 +1,21 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the lookup module in the router's startup log.
+func (h *Handler) Name() string {
+	return "lookup"
+}
+
+// RegisterPublicRoutes registers nothing: the lookup module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the lookup module.
+// All these routes are protected and require an authenticated staff member.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	// GET /api/v1/lookups?types=practitioners,services,roles
+	router.GET("/lookups", middleware.AllowQuery("types"), middleware.ErrorHandler(h.GetLookups))
+}
@@ -0,0 +1,35 @@
+// Package lookup serves lightweight reference lists (practitioners, services, roles) for front-end forms.
+package lookup
+
+import (
+	"context"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/model"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for assembling lookup lists.
+type Service interface {
+	// GetLookups returns the requested lists the caller is allowed to read.
+	// Types the caller lacks permission for are reported as notices rather than errors.
+	GetLookups(ctx context.Context, clinicID uuid.UUID, permissions []string, types []model.Type) (*Result, error)
+}
+
+// Repository defines the data access contract for the compact lookup lists.
+type Repository interface {
+	ListPractitioners(ctx context.Context, clinicID uuid.UUID) ([]model.Item, error)
+	ListActiveServices(ctx context.Context, clinicID uuid.UUID) ([]model.Item, error)
+	ListAssignableRoles(ctx context.Context, clinicID uuid.UUID) ([]model.Item, error)
+}
+
+// Result holds the assembled lists keyed by type and any omitted types.
+type Result struct {
+	Lists   map[model.Type][]model.Item
+	Notices []Notice
+}
+
+// Notice explains why a requested type was omitted from the result.
+type Notice struct {
+	Type    model.Type
+	Message string
+}
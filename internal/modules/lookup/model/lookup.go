@@ -0,0 +1,20 @@
+// Package model contains the compact reference-data models served to form dropdowns.
+package model
+
+import "github.com/google/uuid"
+
+// Type identifies a kind of reference list.
+type Type string
+
+const (
+	TypePractitioners Type = "practitioners"
+	TypeServices      Type = "services"
+	TypeRoles         Type = "roles"
+)
+
+// Item is a single dropdown entry. Extra carries a few type-specific fields.
+type Item struct {
+	ID    uuid.UUID      `json:"id"`
+	Label string         `json:"label"`
+	Extra map[string]any `json:"extra,omitempty"`
+}
@@ -0,0 +1,98 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/ttlcache"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+// cacheTTL is deliberately short: dropdowns tolerate a few seconds of staleness.
+const cacheTTL = 30 * time.Second
+
+type cacheKey struct {
+	clinicID uuid.UUID
+	kind     model.Type
+}
+
+// source describes how to load one lookup type and who may read it.
+type source struct {
+	permission string
+	load       func(ctx context.Context, clinicID uuid.UUID) ([]model.Item, error)
+}
+
+// defaultService is the concrete implementation of the lookup.Service interface.
+type defaultService struct {
+	sources map[model.Type]source
+	cache   *ttlcache.Cache[cacheKey, []model.Item]
+}
+
+// NewService creates a new instance of the lookup service.
+func NewService(repo Repository) Service {
+	return &defaultService{
+		sources: map[model.Type]source{
+			model.TypePractitioners: {permission: "employees.read", load: repo.ListPractitioners},
+			model.TypeServices:      {permission: "appointments.read", load: repo.ListActiveServices},
+			model.TypeRoles:         {permission: "roles.read", load: repo.ListAssignableRoles},
+		},
+		cache: ttlcache.New[cacheKey, []model.Item](cacheTTL),
+	}
+}
+
+// GetLookups loads the requested lists in parallel, serving from the per-clinic cache when possible.
+func (s *defaultService) GetLookups(ctx context.Context, clinicID uuid.UUID, permissions []string, types []model.Type) (*Result, error) {
+	result := &Result{Lists: make(map[model.Type][]model.Item, len(types))}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	for _, t := range types {
+		src, ok := s.sources[t]
+		if !ok {
+			return nil, apierror.NewBadRequest(fmt.Sprintf("Unknown lookup type '%s'.", t), nil)
+		}
+		if !slices.Contains(permissions, src.permission) {
+			result.Notices = append(result.Notices, Notice{
+				Type:    t,
+				Message: fmt.Sprintf("Omitted: requires the '%s' permission.", src.permission),
+			})
+			continue
+		}
+
+		g.Go(func() error {
+			items, err := s.load(gctx, clinicID, t, src)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			result.Lists[t] = items
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to load lookups: %w", err))
+	}
+	return result, nil
+}
+
+func (s *defaultService) load(ctx context.Context, clinicID uuid.UUID, t model.Type, src source) ([]model.Item, error) {
+	key := cacheKey{clinicID: clinicID, kind: t}
+	if items, ok := s.cache.Get(key); ok {
+		return items, nil
+	}
+
+	items, err := src.load(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(key, items)
+	return items, nil
+}
@@ -0,0 +1,113 @@
+// Package store provides the database implementation for the lookup repository.
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxRepository is the PostgreSQL implementation of the lookup.Repository.
+type pgxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPgxRepository creates a new instance of the lookup repository.
+func NewPgxRepository(db *pgxpool.Pool) *pgxRepository {
+	return &pgxRepository{db: db}
+}
+
+// ListPractitioners returns the clinic's active employees ordered by name.
+func (r *pgxRepository) ListPractitioners(ctx context.Context, clinicID uuid.UUID) ([]model.Item, error) {
+	query := `
+        SELECT e.profile_id, p.full_name, e.job_title
+        FROM employees e
+        JOIN profiles p ON p.id = e.profile_id
+        WHERE e.clinic_id = $1 AND e.status = 'ACTIVE' AND e.deleted_at IS NULL AND p.deleted_at IS NULL
+        ORDER BY p.full_name
+    `
+	rows, err := r.db.Query(ctx, query, clinicID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListPractitioners: failed to query employees: %w", err)
+	}
+	defer rows.Close()
+
+	items := []model.Item{}
+	for rows.Next() {
+		var item model.Item
+		var jobTitle *string
+		if err := rows.Scan(&item.ID, &item.Label, &jobTitle); err != nil {
+			return nil, fmt.Errorf("store.ListPractitioners: failed to scan row: %w", err)
+		}
+		item.Extra = map[string]any{"job_title": jobTitle}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListPractitioners: error iterating rows: %w", err)
+	}
+	return items, nil
+}
+
+// ListActiveServices returns the clinic's bookable services ordered by name.
+func (r *pgxRepository) ListActiveServices(ctx context.Context, clinicID uuid.UUID) ([]model.Item, error) {
+	query := `
+        SELECT id, name, slot_multiple, price::text
+        FROM services
+        WHERE clinic_id = $1 AND is_active AND deleted_at IS NULL
+        ORDER BY name
+    `
+	rows, err := r.db.Query(ctx, query, clinicID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListActiveServices: failed to query services: %w", err)
+	}
+	defer rows.Close()
+
+	items := []model.Item{}
+	for rows.Next() {
+		var item model.Item
+		var slotMultiple int
+		var price string
+		if err := rows.Scan(&item.ID, &item.Label, &slotMultiple, &price); err != nil {
+			return nil, fmt.Errorf("store.ListActiveServices: failed to scan row: %w", err)
+		}
+		item.Extra = map[string]any{"slot_multiple": slotMultiple, "price": price}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListActiveServices: error iterating rows: %w", err)
+	}
+	return items, nil
+}
+
+// ListAssignableRoles returns the system roles plus the clinic's own roles.
+func (r *pgxRepository) ListAssignableRoles(ctx context.Context, clinicID uuid.UUID) ([]model.Item, error) {
+	query := `
+        SELECT id, name, is_system_role
+        FROM roles
+        WHERE (clinic_id = $1 OR is_system_role) AND deleted_at IS NULL
+        ORDER BY is_system_role DESC, name
+    `
+	rows, err := r.db.Query(ctx, query, clinicID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListAssignableRoles: failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	items := []model.Item{}
+	for rows.Next() {
+		var item model.Item
+		var isSystemRole bool
+		if err := rows.Scan(&item.ID, &item.Label, &isSystemRole); err != nil {
+			return nil, fmt.Errorf("store.ListAssignableRoles: failed to scan row: %w", err)
+		}
+		item.Extra = map[string]any{"is_system_role": isSystemRole}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListAssignableRoles: error iterating rows: %w", err)
+	}
+	return items, nil
+}
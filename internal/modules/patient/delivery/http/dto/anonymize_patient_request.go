@@ -0,0 +1,7 @@
+package dto
+
+// AnonymizePatientRequest confirms an irreversible erasure of a patient's personal data.
+type AnonymizePatientRequest struct {
+	// Confirm must be true; it guards against erasing a patient by accident.
+	Confirm bool `json:"confirm"`
+}
@@ -1,11 +1,17 @@
 package dto
 
-import "time"
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+)
 
 // CompleteGuestRequest is used by staff to update a guest to a registered patient.
+// Omitted optional fields are left unchanged; null or empty values clear them.
 type CompleteGuestRequest struct {
-	FullName    string     `json:"full_name" binding:"required,min=2"`
-	Email       *string    `json:"email" binding:"omitempty,email"`
-	NationalID  *string    `json:"national_id"`
-	DateOfBirth *time.Time `json:"date_of_birth"`
+	FullName    string                          `json:"full_name" zog:"full_name"`
+	Email       optional.Optional[string]       `json:"email"`
+	NationalID  optional.Optional[string]       `json:"national_id" zog:"national_id"`
+	DateOfBirth optional.Optional[apitime.Date] `json:"date_of_birth" zog:"date_of_birth"`
+	// PreferredLanguage is an ISO 639-1 code, e.g. "ar" or "en".
+	PreferredLanguage optional.Optional[string] `json:"preferred_language" zog:"preferred_language"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+import "github.com/google/uuid"
+
+// MergePatientRequest folds a duplicate profile into the one named in the URL.
+type MergePatientRequest struct {
+	SourceProfileID uuid.UUID `json:"source_profile_id"`
+	// Force merges even if the profiles have different national IDs or dates of birth.
+	Force bool `json:"force"`
+}
@@ -1,21 +1,52 @@
 package dto
 
 import (
-	"time"
+	"encoding/json"
 
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
 	"github.com/google/uuid"
 )
 
-// ProfileResponse defines the publicly exposed fields of a patient profile.
+// ProfileResponse defines the publicly exposed fields of a patient profile (API version 1).
+// This shape is frozen: integrators pinned to version 1 depend on it exactly.
 type ProfileResponse struct {
-	ID            uuid.UUID  `json:"id"`
-	ClinicID      uuid.UUID  `json:"clinic_id"`
-	FullName      string     `json:"full_name"`
-	PhoneNumber   *string    `json:"phone_number"`
-	Email         *string    `json:"email"`
-	NationalID    *string    `json:"national_id"`
-	DateOfBirth   *time.Time `json:"date_of_birth"`
-	ProfileStatus string     `json:"profile_status"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID            uuid.UUID     `json:"id"`
+	ClinicID      uuid.UUID     `json:"clinic_id"`
+	FullName      string        `json:"full_name"`
+	PhoneNumber   *string       `json:"phone_number"`
+	Email         *string       `json:"email"`
+	NationalID    *string       `json:"national_id"`
+	DateOfBirth   *apitime.Time `json:"date_of_birth"` // Kept as a timestamp; version 2 uses a plain date.
+	ProfileStatus string        `json:"profile_status"`
+	CreatedAt     apitime.Time  `json:"created_at"`
+	UpdatedAt     apitime.Time  `json:"updated_at"`
+}
+
+// ProfileResponseV2 is the enriched profile shape (API version 2).
+type ProfileResponseV2 struct {
+	ID                uuid.UUID       `json:"id"`
+	ClinicID          uuid.UUID       `json:"clinic_id"`
+	FullName          string          `json:"full_name"`
+	PhoneNumber       *string         `json:"phone_number"`
+	Email             *string         `json:"email"`
+	NationalID        *string         `json:"national_id"`
+	DateOfBirth       *apitime.Date   `json:"date_of_birth"`
+	PreferredLanguage *string         `json:"preferred_language"`
+	ProfileStatus     string          `json:"profile_status"`
+	ExtendedData      json.RawMessage `json:"extended_data"`
+	CreatedAt         apitime.Time    `json:"created_at"`
+	UpdatedAt         apitime.Time    `json:"updated_at"`
+}
+
+// ProfileSearchResult is a search hit in the version 1 shape: the profile and how closely it
+// matched, from 0 to 1.
+type ProfileSearchResult struct {
+	ProfileResponse
+	Score float64 `json:"score"`
+}
+
+// ProfileSearchResultV2 is a search hit in the version 2 shape.
+type ProfileSearchResultV2 struct {
+	ProfileResponseV2
+	Score float64 `json:"score"`
 }
@@ -1,12 +1,14 @@
 package dto
 
-import "time"
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
 
 // RegisterPatientRequest is used by staff for in-clinic full registration.
 type RegisterPatientRequest struct {
-	FullName    string     `json:"full_name" binding:"required,min=2"`
-	PhoneNumber string     `json:"phone_number" binding:"required,e164"`
-	Email       *string    `json:"email" binding:"omitempty,email"`
-	NationalID  *string    `json:"national_id"`
-	DateOfBirth *time.Time `json:"date_of_birth"`
+	FullName    string        `json:"full_name" zog:"full_name" binding:"required,min=2"`
+	PhoneNumber string        `json:"phone_number" zog:"phone_number" binding:"required"`
+	Email       *string       `json:"email" binding:"omitempty,email"`
+	NationalID  *string       `json:"national_id" zog:"national_id"`
+	DateOfBirth *apitime.Date `json:"date_of_birth" zog:"date_of_birth"`
+	// PreferredLanguage is an ISO 639-1 code, e.g. "ar" or "en".
+	PreferredLanguage *string `json:"preferred_language" zog:"preferred_language"`
 }
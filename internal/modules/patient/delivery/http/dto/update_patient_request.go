@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+)
+
+// UpdatePatientRequest is a partial update of a patient's details by staff.
+// Omitted fields are left unchanged; null or empty values clear them.
+type UpdatePatientRequest struct {
+	FullName    optional.Optional[string]       `json:"full_name" zog:"full_name"`
+	PhoneNumber optional.Optional[string]       `json:"phone_number" zog:"phone_number"`
+	Email       optional.Optional[string]       `json:"email"`
+	NationalID  optional.Optional[string]       `json:"national_id" zog:"national_id"`
+	DateOfBirth optional.Optional[apitime.Date] `json:"date_of_birth" zog:"date_of_birth"`
+	// PreferredLanguage is an ISO 639-1 code, e.g. "ar" or "en".
+	PreferredLanguage optional.Optional[string] `json:"preferred_language" zog:"preferred_language"`
+}
@@ -1,19 +1,25 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/delivery/http/dto"
-	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/httpjson"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	z "github.com/Oudwins/zog"
-	"github.com/Oudwins/zog/zhttp"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 type Handler struct {
@@ -31,19 +37,22 @@ func (h *Handler) RegisterPatient(c *gin.Context) *apierror.APIError {
 		return apierror.NewInternalServer(err)
 	}
 
-	var req dto.RegisterPatientRequest
-	if issues := registerPatientSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"validation_errors": z.Issues.Flatten(issues)})
-		return nil
+	req, apiErr := httpjson.DecodeJSONGin[dto.RegisterPatientRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := registerPatientSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
 	}
 
 	serviceReq := patient.RegisterPatientRequest{
-		ClinicID:    payload.ClinicID,
-		FullName:    req.FullName,
-		PhoneNumber: req.PhoneNumber,
-		Email:       req.Email,
-		NationalID:  req.NationalID,
-		DateOfBirth: req.DateOfBirth,
+		ClinicID:          payload.ClinicID,
+		FullName:          req.FullName,
+		PhoneNumber:       req.PhoneNumber,
+		Email:             req.Email,
+		NationalID:        req.NationalID,
+		DateOfBirth:       req.DateOfBirth.TimePtr(),
+		PreferredLanguage: req.PreferredLanguage,
 	}
 
 	profile, err := h.service.RegisterNewPatient(c.Request.Context(), payload.ClinicID, serviceReq)
@@ -55,7 +64,7 @@ func (h *Handler) RegisterPatient(c *gin.Context) *apierror.APIError {
 		return apierror.NewInternalServer(err)
 	}
 
-	c.JSON(http.StatusCreated, toProfileResponse(profile))
+	httpjson.RespondData(c, http.StatusCreated, toProfileResponse(c.Request.Context(), profile))
 	return nil
 }
 
@@ -71,19 +80,23 @@ func (h *Handler) CompleteGuestProfile(c *gin.Context) *apierror.APIError {
 		return apierror.NewBadRequest("Invalid profile ID format.", err)
 	}
 
-	var req dto.CompleteGuestRequest
-	if issues := CompleteGuestProfile.Parse(zhttp.Request(c.Request), &req); issues != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"validation_errors": z.Issues.Flatten(issues)})
-		return nil
+	// Decoded with encoding/json rather than zog so that omitted and null fields stay distinguishable.
+	req, apiErr := httpjson.DecodeJSONGin[dto.CompleteGuestRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := completeGuestSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
 	}
 
 	serviceReq := patient.CompleteGuestRequest{
-		ClinicID:    payload.ClinicID,
-		ProfileID:   profileID,
-		FullName:    req.FullName,
-		Email:       req.Email,
-		NationalID:  req.NationalID,
-		DateOfBirth: req.DateOfBirth,
+		ClinicID:          payload.ClinicID,
+		ProfileID:         profileID,
+		FullName:          req.FullName,
+		Email:             optional.BlankAsNull(req.Email),
+		NationalID:        optional.BlankAsNull(req.NationalID),
+		DateOfBirth:       dateOfBirth(req.DateOfBirth),
+		PreferredLanguage: optional.BlankAsNull(req.PreferredLanguage),
 	}
 
 	profile, err := h.service.CompleteGuestRegistration(c.Request.Context(), payload.ClinicID, serviceReq)
@@ -95,7 +108,52 @@ func (h *Handler) CompleteGuestProfile(c *gin.Context) *apierror.APIError {
 		return apierror.NewInternalServer(err)
 	}
 
-	c.JSON(http.StatusOK, toProfileResponse(profile))
+	httpjson.RespondData(c, http.StatusOK, toProfileResponse(c.Request.Context(), profile))
+	return nil
+}
+
+// UpdatePatient applies a partial update to a patient's details.
+func (h *Handler) UpdatePatient(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	// Decoded with encoding/json rather than zog so that omitted and null fields stay distinguishable.
+	req, apiErr := httpjson.DecodeJSONGin[dto.UpdatePatientRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issues := updatePatientSchema.Validate(&req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := patient.UpdateProfileRequest{
+		ClinicID:          payload.ClinicID,
+		ProfileID:         profileID,
+		FullName:          optional.BlankAsNull(req.FullName),
+		PhoneNumber:       optional.BlankAsNull(req.PhoneNumber),
+		Email:             optional.BlankAsNull(req.Email),
+		NationalID:        optional.BlankAsNull(req.NationalID),
+		DateOfBirth:       dateOfBirth(req.DateOfBirth),
+		PreferredLanguage: optional.BlankAsNull(req.PreferredLanguage),
+	}
+
+	profile, err := h.service.UpdateProfile(c.Request.Context(), payload.ClinicID, serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toProfileResponse(c.Request.Context(), profile))
 	return nil
 }
 
@@ -111,7 +169,12 @@ func (h *Handler) GetPatient(c *gin.Context) *apierror.APIError {
 		return apierror.NewBadRequest("Invalid profile ID format.", err)
 	}
 
-	profile, err := h.service.GetProfileByID(c.Request.Context(), payload.ClinicID, profileID)
+	includeArchived, apiErr := parseIncludeArchived(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	profile, err := h.service.GetProfileByID(c.Request.Context(), payload.ClinicID, profileID, includeArchived)
 	if err != nil {
 		var apiErr *apierror.APIError
 		if errors.As(err, &apiErr) {
@@ -120,21 +183,39 @@ func (h *Handler) GetPatient(c *gin.Context) *apierror.APIError {
 		return apierror.NewInternalServer(err)
 	}
 
-	c.JSON(http.StatusOK, toProfileResponse(profile))
+	httpjson.RespondData(c, http.StatusOK, toProfileResponse(c.Request.Context(), profile))
 	return nil
 }
 
-// ListPatients retrieves a paginated list of patients for a clinic.
+// ListPatients retrieves a paginated list of patients for a clinic. HEAD requests and GETs with
+// a matching If-None-Match are answered from the list version without loading any rows.
 func (h *Handler) ListPatients(c *gin.Context) *apierror.APIError {
 	payload, err := middleware.GetAuthPayload(c.Request.Context())
 	if err != nil {
 		return apierror.NewInternalServer(err)
 	}
 
+	includeArchived, apiErr := parseIncludeArchived(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	version, err := h.service.ListProfilesVersion(c.Request.Context(), payload.ClinicID, includeArchived)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+	if middleware.ConditionalList(c, version) {
+		return nil
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "25"))
 
-	profiles, err := h.service.ListProfiles(c.Request.Context(), payload.ClinicID, page, pageSize)
+	profiles, err := h.service.ListProfiles(c.Request.Context(), payload.ClinicID, page, pageSize, includeArchived)
 	if err != nil {
 		var apiErr *apierror.APIError
 		if errors.As(err, &apiErr) {
@@ -143,27 +224,278 @@ func (h *Handler) ListPatients(c *gin.Context) *apierror.APIError {
 		return apierror.NewInternalServer(err)
 	}
 
-	response := make([]dto.ProfileResponse, len(profiles))
+	response := make([]any, len(profiles))
 	for i, p := range profiles {
-		response[i] = toProfileResponse(&p)
+		response[i] = toProfileResponse(c.Request.Context(), &p)
+	}
+
+	httpjson.RespondList(c, response, nil)
+	return nil
+}
+
+// MergePatient folds a duplicate profile into the patient in the URL and archives the duplicate.
+func (h *Handler) MergePatient(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.MergePatientRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if req.SourceProfileID == uuid.Nil {
+		return apierror.NewBadRequest("source_profile_id is required.", nil)
+	}
+
+	profile, err := h.service.MergeProfiles(c.Request.Context(), payload.ClinicID, payload.ActorID(), profileID, req.SourceProfileID, req.Force)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toProfileResponse(c.Request.Context(), profile))
+	return nil
+}
+
+// GetExtendedData returns a patient's clinic-specific custom fields.
+func (h *Handler) GetExtendedData(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	data, err := h.service.GetExtendedData(c.Request.Context(), payload.ClinicID, profileID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, data)
+	return nil
+}
+
+// PatchExtendedData merges a JSON merge patch (RFC 7396) into a patient's custom fields.
+func (h *Handler) PatchExtendedData(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	patch, apiErr := httpjson.DecodeJSONGin[json.RawMessage](c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	data, err := h.service.PatchExtendedData(c.Request.Context(), payload.ClinicID, profileID, patch)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, data)
+	return nil
+}
+
+// ArchivePatient soft-deletes a patient so they no longer appear in lists and lookups.
+func (h *Handler) ArchivePatient(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	if err := h.service.ArchiveProfile(c.Request.Context(), payload.ClinicID, profileID); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondNoContent(c)
+	return nil
+}
+
+// RestorePatient brings an archived patient back with the status they had before.
+func (h *Handler) RestorePatient(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	profile, err := h.service.RestoreProfile(c.Request.Context(), payload.ClinicID, profileID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	httpjson.RespondData(c, http.StatusOK, toProfileResponse(c.Request.Context(), profile))
+	return nil
+}
+
+// AnonymizePatient erases a patient's personal data on their request. The body must carry
+// "confirm": true, since the erasure cannot be undone.
+func (h *Handler) AnonymizePatient(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	req, apiErr := httpjson.DecodeJSONGin[dto.AnonymizePatientRequest](c)
+	if apiErr != nil {
+		return apiErr
+	}
+	if !req.Confirm {
+		return apierror.NewBadRequest("Erasing a patient cannot be undone; send \"confirm\": true to proceed.", nil)
+	}
+
+	// During impersonation the erasure is attributed to the real operator.
+	if err := h.service.AnonymizeProfile(c.Request.Context(), payload.ClinicID, payload.ActorID(), profileID); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": response})
+	httpjson.RespondNoContent(c)
 	return nil
 }
 
-// toProfileResponse maps the internal profile model to the public DTO.
-func toProfileResponse(profile *model.Profile) dto.ProfileResponse {
-	return dto.ProfileResponse{
-		ID:            profile.ID,
-		ClinicID:      profile.ClinicID,
-		FullName:      profile.FullName,
-		PhoneNumber:   profile.PhoneNumber,
-		Email:         profile.Email,
-		NationalID:    profile.NationalID,
-		DateOfBirth:   profile.DateOfBirth,
-		ProfileStatus: string(profile.ProfileStatus),
-		CreatedAt:     profile.CreatedAt,
-		UpdatedAt:     profile.UpdatedAt,
+// ExportPatient downloads everything the clinic holds about a patient as one JSON document. The
+// sections are collected before the response starts, so only a broken connection can cut it short.
+func (h *Handler) ExportPatient(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid profile ID format.", err)
+	}
+
+	// During impersonation the export is attributed to the real operator.
+	doc, err := h.service.ExportProfile(c.Request.Context(), payload.ClinicID, payload.ActorID(), profileID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="patient-%s.json"`, profileID))
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	if _, err := doc.WriteTo(c.Writer); err != nil {
+		log.Ctx(c.Request.Context()).Warn().Err(err).Stringer("profile_id", profileID).Msg("patient export: response cut short")
+	}
+	return nil
+}
+
+// SearchPatients finds patients by name, phone number or national ID for the front desk. Each
+// hit carries its match score so the UI can highlight close matches.
+func (h *Handler) SearchPatients(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	matches, err := h.service.SearchProfiles(c.Request.Context(), payload.ClinicID, c.Query("q"))
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	response := make([]any, len(matches))
+	for i, m := range matches {
+		response[i] = toSearchResult(c.Request.Context(), &m)
+	}
+
+	httpjson.RespondList(c, response, nil)
+	return nil
+}
+
+// parseIncludeArchived reads the include_archived query parameter. Only holders of patients.archive
+// may see archived patients.
+func parseIncludeArchived(c *gin.Context) (bool, *apierror.APIError) {
+	raw := c.Query("include_archived")
+	if raw == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, apierror.NewBadRequest("include_archived must be true or false.", err)
+	}
+	if !include {
+		return false, nil
+	}
+
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return false, apierror.NewInternalServer(err)
+	}
+	if !slices.Contains(payload.Permissions, "patients.archive") {
+		return false, apierror.NewForbidden("", nil)
+	}
+	return true, nil
+}
+
+// dateOfBirth converts a sent calendar date to the stored midnight-UTC form, keeping omitted and null as they are.
+func dateOfBirth(o optional.Optional[apitime.Date]) optional.Optional[time.Time] {
+	d, ok := o.Get()
+	if !ok {
+		return optional.Optional[time.Time]{}
+	}
+	if d == nil {
+		return optional.Null[time.Time]()
 	}
+	return optional.Some(d.Time())
 }
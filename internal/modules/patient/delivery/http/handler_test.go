@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubService records the update it was asked to apply and answers with the golden profile.
+type stubService struct {
+	patient.Service
+	update *patient.UpdateProfileRequest
+}
+
+func (s *stubService) UpdateProfile(_ context.Context, _ uuid.UUID, req patient.UpdateProfileRequest) (*model.Profile, error) {
+	s.update = &req
+	return goldenProfile(), nil
+}
+
+// patch sends body to PATCH /api/v1/patients/:id as a staff member allowed to update patients.
+func patch(t *testing.T, svc patient.Service, profileID uuid.UUID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	tokens, err := security.NewPasetoManager(config.SecurityConfig{
+		TokenMode:     security.TokenModeLocal,
+		PasetoKey:     "0123456789abcdef0123456789abcdef",
+		TokenIssuer:   "mastara-test",
+		TokenAudience: "mastara-api",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	NewHandler(svc).RegisterProtectedRoutes(engine.Group("/api/v1", middleware.Authenticator(tokens, security.NewMemoryDenylist())))
+
+	payload, err := security.NewAuthPayload(uuid.New(), uuid.New(), nil, []string{"patients.update"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tokens.CreateToken(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/patients/"+profileID.String(), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUpdatePatientTellsNullFromAbsent(t *testing.T) {
+	svc := &stubService{}
+	profileID := uuid.New()
+
+	rec := patch(t, svc, profileID, `{"full_name": "Mona Adel Hassan", "email": null, "national_id": ""}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH = %d %s, want 200", rec.Code, rec.Body)
+	}
+	req := svc.update
+	if req.ProfileID != profileID {
+		t.Errorf("updated profile %s, want %s", req.ProfileID, profileID)
+	}
+	if name, ok := req.FullName.Get(); !ok || name == nil || *name != "Mona Adel Hassan" {
+		t.Errorf("full_name = %v, want the new name", req.FullName)
+	}
+	// null and, for optional text, an empty string both clear the field.
+	if !req.Email.IsNull() || !req.NationalID.IsNull() {
+		t.Errorf("email = %v, national_id = %v; want both cleared", req.Email, req.NationalID)
+	}
+	// Fields left out of the body are left alone.
+	if req.PhoneNumber.IsSet() || req.DateOfBirth.IsSet() || req.PreferredLanguage.IsSet() {
+		t.Errorf("phone_number = %v, date_of_birth = %v, preferred_language = %v; want all absent",
+			req.PhoneNumber, req.DateOfBirth, req.PreferredLanguage)
+	}
+
+	rec = patch(t, svc, profileID, `{"date_of_birth": null}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if !svc.update.DateOfBirth.IsNull() || svc.update.FullName.IsSet() || svc.update.Email.IsSet() {
+		t.Errorf("after clearing only date_of_birth: %+v", *svc.update)
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+)
+
+// profileMappers holds one response mapper per supported API version.
+// Old mappers must never change; new fields go into a new version.
+var profileMappers = map[string]func(*model.Profile) any{
+	middleware.APIVersion1: func(p *model.Profile) any { return toProfileResponseV1(p) },
+	middleware.APIVersion2: func(p *model.Profile) any { return toProfileResponseV2(p) },
+}
+
+// toProfileResponse maps the profile to the shape of the API version negotiated for the request.
+func toProfileResponse(ctx context.Context, profile *model.Profile) any {
+	mapper, ok := profileMappers[middleware.GetAPIVersion(ctx)]
+	if !ok {
+		mapper = profileMappers[middleware.LatestAPIVersion]
+	}
+	return mapper(profile)
+}
+
+// searchResultMappers holds one search hit mapper per supported API version.
+var searchResultMappers = map[string]func(*model.ProfileMatch) any{
+	middleware.APIVersion1: func(m *model.ProfileMatch) any {
+		return dto.ProfileSearchResult{ProfileResponse: toProfileResponseV1(&m.Profile), Score: m.Score}
+	},
+	middleware.APIVersion2: func(m *model.ProfileMatch) any {
+		return dto.ProfileSearchResultV2{ProfileResponseV2: toProfileResponseV2(&m.Profile), Score: m.Score}
+	},
+}
+
+// toSearchResult maps the search hit to the shape of the API version negotiated for the request.
+func toSearchResult(ctx context.Context, match *model.ProfileMatch) any {
+	mapper, ok := searchResultMappers[middleware.GetAPIVersion(ctx)]
+	if !ok {
+		mapper = searchResultMappers[middleware.LatestAPIVersion]
+	}
+	return mapper(match)
+}
+
+// toProfileResponseV1 maps the internal profile model to the version 1 DTO.
+func toProfileResponseV1(profile *model.Profile) dto.ProfileResponse {
+	return dto.ProfileResponse{
+		ID:            profile.ID,
+		ClinicID:      profile.ClinicID,
+		FullName:      profile.FullName,
+		PhoneNumber:   profile.PhoneNumber,
+		Email:         profile.Email,
+		NationalID:    profile.NationalID,
+		DateOfBirth:   apitime.NewPtr(profile.DateOfBirth),
+		ProfileStatus: string(profile.ProfileStatus),
+		CreatedAt:     apitime.New(profile.CreatedAt),
+		UpdatedAt:     apitime.New(profile.UpdatedAt),
+	}
+}
+
+// toProfileResponseV2 maps the internal profile model, including custom fields, to the version 2 DTO.
+func toProfileResponseV2(profile *model.Profile) dto.ProfileResponseV2 {
+	extendedData := json.RawMessage(profile.ExtendedData)
+	if len(extendedData) == 0 {
+		extendedData = json.RawMessage("{}")
+	}
+
+	return dto.ProfileResponseV2{
+		ID:                profile.ID,
+		ClinicID:          profile.ClinicID,
+		FullName:          profile.FullName,
+		PhoneNumber:       profile.PhoneNumber,
+		Email:             profile.Email,
+		NationalID:        profile.NationalID,
+		DateOfBirth:       apitime.DatePtr(profile.DateOfBirth),
+		PreferredLanguage: profile.PreferredLanguage,
+		ProfileStatus:     string(profile.ProfileStatus),
+		ExtendedData:      extendedData,
+		CreatedAt:         apitime.New(profile.CreatedAt),
+		UpdatedAt:         apitime.New(profile.UpdatedAt),
+	}
+}
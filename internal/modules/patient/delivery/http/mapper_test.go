@@ -0,0 +1,48 @@
+package http
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/golden"
+	"github.com/google/uuid"
+)
+
+// goldenProfile sets every field a profile response can carry.
+func goldenProfile() *model.Profile {
+	phone, email, nationalID, language := "+201012345678", "mona@example.com", "29001011234567", "ar"
+	born := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &model.Profile{
+		ID:                uuid.MustParse("0190b5a0-0000-7000-8000-000000000001"),
+		ClinicID:          uuid.MustParse("0190b5a0-0000-7000-8000-0000000000c1"),
+		FullName:          "Mona Adel",
+		PhoneNumber:       &phone,
+		Email:             &email,
+		NationalID:        &nationalID,
+		DateOfBirth:       &born,
+		PreferredLanguage: &language,
+		ProfileStatus:     model.ProfileStatusRegistered,
+		ExtendedData:      []byte(`{"blood_type":"O+"}`),
+		CreatedAt:         time.Date(2025, 3, 1, 9, 30, 0, 123456789, time.UTC),
+		UpdatedAt:         time.Date(2025, 3, 2, 11, 0, 5, 0, time.FixedZone("EET", 2*60*60)),
+	}
+}
+
+// TestProfileResponseShapes pins the JSON of every API version's profile shape. A version's
+// golden file only changes when that version is deliberately changed.
+func TestProfileResponseShapes(t *testing.T) {
+	for _, version := range slices.Sorted(maps.Keys(profileMappers)) {
+		t.Run(version, func(t *testing.T) {
+			golden.AssertJSON(t, "profile_response.v"+version+".json", profileMappers[version](goldenProfile()))
+		})
+	}
+	for _, version := range slices.Sorted(maps.Keys(searchResultMappers)) {
+		t.Run("search "+version, func(t *testing.T) {
+			match := &model.ProfileMatch{Profile: *goldenProfile(), Score: 0.75}
+			golden.AssertJSON(t, "profile_search_result.v"+version+".json", searchResultMappers[version](match))
+		})
+	}
+}
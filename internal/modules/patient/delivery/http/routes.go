@@ -5,29 +5,56 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterRoutes sets up the routes for the Patient module.
+// Name identifies the patient module in the router's startup log.
+func (h *Handler) Name() string {
+	return "patient"
+}
+
+// RegisterPublicRoutes registers nothing: the patient module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the Patient module.
 // All these routes are protected and require an authenticated staff member.
-func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
 	patientGroup := router.Group("/patients")
 	{
 		// POST /api/v1/patients - Create a new, fully registered patient
-		patientGroup.POST("/", middleware.ErrorHandler(h.RegisterPatient))
+		patientGroup.POST("/", middleware.RequirePermission("patients.create"), middleware.ErrorHandler(h.RegisterPatient))
 
 		// PUT /api/v1/patients/:id/complete-registration - Upgrade a guest to registered
-		patientGroup.PUT("/:id/complete-registration", middleware.ErrorHandler(h.CompleteGuestProfile))
+		patientGroup.PUT("/:id/complete-registration", middleware.RequirePermission("patients.update"), middleware.ErrorHandler(h.CompleteGuestProfile))
 
-		patientGroup.GET("/", middleware.ErrorHandler(h.ListPatients))
-		patientGroup.GET("/:id", middleware.ErrorHandler(h.GetPatient))
+		// GET|HEAD /api/v1/patients?page=&pageSize=&include_archived= - List patients. Responses carry an ETag and
+		// X-Total-Count; HEAD returns only those, and a matching If-None-Match gets a 304.
+		// include_archived=true also lists archived patients and requires patients.archive.
+		patientGroup.GET("/", middleware.RequirePermission("patients.read"), middleware.AllowQuery("page", "pageSize", "include_archived"), middleware.ErrorHandler(h.ListPatients))
+		patientGroup.HEAD("/", middleware.RequirePermission("patients.read"), middleware.AllowQuery("page", "pageSize", "include_archived"), middleware.ErrorHandler(h.ListPatients))
+		// GET /api/v1/patients/search?q= - Find patients by name (typos tolerated from 3 characters), phone number or national ID, best match first, each with a match score.
+		patientGroup.GET("/search", middleware.RequirePermission("patients.read"), middleware.AllowQuery("q"), middleware.ErrorHandler(h.SearchPatients))
+		// GET /api/v1/patients/:id?include_archived= - Get a patient; archived ones need include_archived=true and patients.archive.
+		patientGroup.GET("/:id", middleware.RequirePermission("patients.read"), middleware.AllowQuery("include_archived"), middleware.ErrorHandler(h.GetPatient))
 
-		// We can add a DELETE "/:id" for archiving later.
-	}
+		// PATCH /api/v1/patients/:id - Update any subset of a patient's details; null clears a field
+		patientGroup.PATCH("/:id", middleware.RequirePermission("patients.update"), middleware.ErrorHandler(h.UpdatePatient))
 
-	// === PUBLIC ROUTES (NO AUTH) ===
-	// public := router.Group("/public")
-	{
-		// Here we would register the public booking and guest management handlers
-		// public.POST("/appointments", middleware.ErrorHandler(appointmentHandler.CreateGuestAppointment))
-		// public.GET("/appointments/manage", middleware.GuestTokenAuthenticator(), middleware.ErrorHandler(appointmentHandler.GetGuestAppointment))
-		// public.POST("/appointments/manage/cancel", middleware.GuestTokenAuthenticator(), middleware.ErrorHandler(appointmentHandler.CancelGuestAppointment))
+		// GET /api/v1/patients/:id/extended-data - Read a patient's clinic-specific custom fields
+		patientGroup.GET("/:id/extended-data", middleware.RequirePermission("patients.read"), middleware.ErrorHandler(h.GetExtendedData))
+		// PATCH /api/v1/patients/:id/extended-data - Merge a JSON merge patch (RFC 7396) into the custom fields
+		patientGroup.PATCH("/:id/extended-data", middleware.RequirePermission("patients.update"), middleware.ErrorHandler(h.PatchExtendedData))
+
+		// POST /api/v1/patients/:id/merge - Fold a duplicate profile into this one; the duplicate is archived
+		patientGroup.POST("/:id/merge", middleware.RequirePermission("patients.update", "patients.archive"), middleware.ErrorHandler(h.MergePatient))
+
+		// DELETE /api/v1/patients/:id - Archive a patient
+		patientGroup.DELETE("/:id", middleware.RequirePermission("patients.archive"), middleware.ErrorHandler(h.ArchivePatient))
+
+		// POST /api/v1/patients/:id/restore - Restore an archived patient to their previous status
+		patientGroup.POST("/:id/restore", middleware.RequirePermission("patients.archive"), middleware.ErrorHandler(h.RestorePatient))
+
+		// GET /api/v1/patients/:id/export - Download everything held about a patient as a JSON attachment; the export is audited
+		patientGroup.GET("/:id/export", middleware.RequirePermission("patients.read"), middleware.ErrorHandler(h.ExportPatient))
+
+		// POST /api/v1/patients/:id/anonymize - Irreversibly erase a patient's personal data; the body must be {"confirm": true}
+		patientGroup.POST("/:id/anonymize", middleware.RequirePermission("patient.erase"), middleware.ErrorHandler(h.AnonymizePatient))
 	}
 }
@@ -1,28 +1,38 @@
 package http
 
 import (
-	"regexp"
-	"time"
+	"strings"
 
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	z "github.com/Oudwins/zog"
 )
 
-var e164Regex = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+var preferredLanguageSchema = z.Ptr(z.String().Trim().OneOf(locale.Codes(), z.Message("Preferred language must be one of: "+strings.Join(locale.Codes(), ", ")+".")))
 
-// Schema for creating a new, fully registered patient by staff.
+// Schema for creating a new, fully registered patient by staff. The phone number may be in
+// national form; the service normalizes it with the clinic's default phone region. The date
+// of birth is checked while decoding, as apitime.Date only accepts "YYYY-MM-DD".
 var registerPatientSchema = z.Struct(z.Shape{
-	"full_name":     z.String().Min(4, z.Message("Full name must be at least 4 characters.")),
-	"phone_number":  z.String().Match(e164Regex, z.Message("A valid E.164 phone number is required.")),
-	"email":         z.String().Email(z.Message("A valid email address is required.")).Optional(),
-	"national_id":   z.String().Optional(),
-	"date_of_birth": z.Time(z.Time.Format(time.DateOnly)).Optional(), // Expects "YYYY-MM-DD"
+	"fullName":          z.String().Min(4, z.Message("Full name must be at least 4 characters.")),
+	"phoneNumber":       z.String().Trim().Required(z.Message("A phone number is required.")),
+	"email":             z.Ptr(z.String().Email(z.Message("A valid email address is required."))),
+	"preferredLanguage": preferredLanguageSchema,
 })
 
 // Schema for updating a patient's details (including completing a guest profile).
-var CompleteGuestProfile = z.Struct(z.Shape{
-	"full_name":   z.String().Min(4, z.Message("Full name must be at least 4 characters.")),
-	"email":       z.String().Email(z.Message("A valid email address is required.")).Optional(),
-	"national_id": z.String().Optional(),
-	// "date_of_birth": z.Time(z.TimeOpts{Layout: "2006-01-02"}).Optional(),
-	"date_of_birth": z.Time(z.Time.Format(time.DateOnly)).Optional(),
+// The request is decoded first and then validated, so optional fields are only
+// checked when a value was sent; null and blank values clear the field instead.
+var completeGuestSchema = z.Struct(z.Shape{
+	"fullName":          z.String().Trim().Min(4, z.Message("Full name must be at least 4 characters.")),
+	"email":             optional.String(z.String().Email(), true, "A valid email address is required."),
+	"preferredLanguage": optional.String(z.String().OneOf(locale.Codes()), true, "Preferred language must be one of: "+strings.Join(locale.Codes(), ", ")+"."),
+})
+
+// Schema for a partial update of a patient's details. Like completeGuestSchema it validates the
+// decoded request; the name cannot be cleared, and the database rejects clearing both contact methods.
+var updatePatientSchema = z.Struct(z.Shape{
+	"fullName":          optional.String(z.String().Trim().Min(4), false, "Full name must be at least 4 characters."),
+	"email":             optional.String(z.String().Email(), true, "A valid email address is required."),
+	"preferredLanguage": optional.String(z.String().OneOf(locale.Codes()), true, "Preferred language must be one of: "+strings.Join(locale.Codes(), ", ")+"."),
 })
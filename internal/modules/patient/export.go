@@ -0,0 +1,57 @@
+package patient
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// exportSection names the profile's part of a patient export.
+const exportSection = "profile"
+
+// profileExport is the profile as it appears in a patient export: every stored field, including
+// the clinic's custom fields.
+type profileExport struct {
+	ID                uuid.UUID       `json:"id"`
+	FullName          string          `json:"full_name"`
+	PhoneNumber       *string         `json:"phone_number"`
+	Email             *string         `json:"email"`
+	NationalID        *string         `json:"national_id"`
+	DateOfBirth       *time.Time      `json:"date_of_birth"`
+	PreferredLanguage *string         `json:"preferred_language"`
+	Status            string          `json:"status"`
+	RegisteredAt      *time.Time      `json:"registered_at"`
+	ExtendedData      json.RawMessage `json:"extended_data"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	ArchivedAt        *time.Time      `json:"archived_at"`
+}
+
+// exportProfile is the profile export section.
+func (s *defaultService) exportProfile(ctx context.Context, clinicID, profileID uuid.UUID) (any, error) {
+	p, err := s.repo.FindByID(ctx, s.db, clinicID, profileID, true)
+	if err != nil {
+		return nil, err
+	}
+	extended := json.RawMessage(p.ExtendedData)
+	if len(extended) == 0 {
+		extended = json.RawMessage("{}")
+	}
+	return profileExport{
+		ID:                p.ID,
+		FullName:          p.FullName,
+		PhoneNumber:       p.PhoneNumber,
+		Email:             p.Email,
+		NationalID:        p.NationalID,
+		DateOfBirth:       p.DateOfBirth,
+		PreferredLanguage: p.PreferredLanguage,
+		Status:            string(p.ProfileStatus),
+		RegisteredAt:      p.RegisteredAt,
+		ExtendedData:      extended,
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
+		ArchivedAt:        p.DeletedAt,
+	}, nil
+}
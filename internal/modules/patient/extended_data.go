@@ -0,0 +1,125 @@
+package patient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxExtendedDataBytes caps the encoded size of a profile's custom fields.
+const MaxExtendedDataBytes = 32 << 10
+
+// GetExtendedData returns the clinic-specific custom fields of a patient as a JSON object.
+func (s *defaultService) GetExtendedData(ctx context.Context, clinicID, profileID uuid.UUID) (json.RawMessage, error) {
+	profile, err := s.repo.FindByID(ctx, s.db, clinicID, profileID, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(profile.ExtendedData) == 0 {
+		return json.RawMessage("{}"), nil
+	}
+	return json.RawMessage(profile.ExtendedData), nil
+}
+
+// PatchExtendedData applies an RFC 7396 JSON merge patch to a patient's custom fields and
+// returns the result. The row is locked while the patch is applied, so concurrent patches of
+// different keys both survive.
+func (s *defaultService) PatchExtendedData(ctx context.Context, clinicID, profileID uuid.UUID, patch json.RawMessage) (json.RawMessage, error) {
+	patchDoc, err := decodeObject(patch)
+	if err != nil {
+		return nil, apierror.NewBadRequest("The merge patch must be a JSON object.", err)
+	}
+	if key, ok := findReservedKey(patchDoc); ok {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("Custom field names must not start with '$' (got %q).", key), nil)
+	}
+
+	var merged json.RawMessage
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		stored, err := s.repo.FindExtendedDataForUpdate(ctx, tx, clinicID, profileID)
+		if err != nil {
+			return err
+		}
+		current := map[string]any{}
+		if len(stored) > 0 {
+			if current, err = decodeObject(stored); err != nil {
+				return apierror.NewInternalServer(fmt.Errorf("patient: corrupt extended_data on profile %s: %w", profileID, err))
+			}
+		}
+
+		merged, err = json.Marshal(mergePatch(current, patchDoc))
+		if err != nil {
+			return apierror.NewInternalServer(fmt.Errorf("patient: failed to encode extended_data: %w", err))
+		}
+		if len(merged) > MaxExtendedDataBytes {
+			return apierror.NewBadRequest(fmt.Sprintf("Custom fields must not exceed %d KB in total.", MaxExtendedDataBytes>>10), nil)
+		}
+		return s.repo.UpdateExtendedData(ctx, tx, clinicID, profileID, merged)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// decodeObject parses data as a JSON object, keeping numbers exact.
+func decodeObject(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("expected a JSON object, got null")
+	}
+	return doc, nil
+}
+
+// mergePatch applies patch to target as RFC 7396 describes: null removes a key, objects merge
+// recursively and any other value replaces the target's.
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// findReservedKey returns the first key at any depth of doc that starts with '$'.
+func findReservedKey(doc any) (string, bool) {
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if strings.HasPrefix(key, "$") {
+				return key, true
+			}
+			if key, ok := findReservedKey(value); ok {
+				return key, true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if key, ok := findReservedKey(value); ok {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
@@ -3,12 +3,16 @@ package patient
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/store"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // Querier is an alias for the store's Querier interface.
@@ -24,27 +28,81 @@ type Service interface {
 	// CompleteGuestRegistration is used by staff to enrich a guest profile with full details.
 	CompleteGuestRegistration(ctx context.Context, clinicID uuid.UUID, req CompleteGuestRequest) (*model.Profile, error)
 
-	// UpdatePatientDetails(ctx context.Context, req CompleteGuestRequest) (*model.Profile, error)
-
-	// GetProfileByID retrieves a single patient profile.
-	GetProfileByID(ctx context.Context, clinicID, profileID uuid.UUID) (*model.Profile, error)
-
-	ListProfiles(ctx context.Context, clinicID uuid.UUID, page, pageSize int) ([]model.Profile, error)
+	// UpdateProfile applies a partial update to a guest or registered patient. A guest who now
+	// meets the clinic's registration minimum is promoted.
+	UpdateProfile(ctx context.Context, clinicID uuid.UUID, req UpdateProfileRequest) (*model.Profile, error)
+
+	// GetProfileByID retrieves a single patient profile from the primary, so a profile the
+	// client just wrote is never stale. Archived profiles are only returned when includeArchived is set.
+	GetProfileByID(ctx context.Context, clinicID, profileID uuid.UUID, includeArchived bool) (*model.Profile, error)
+
+	ListProfiles(ctx context.Context, clinicID uuid.UUID, page, pageSize int, includeArchived bool) ([]model.Profile, error)
+	// SearchProfiles finds up to 20 patients by name, phone number or national ID, best match first,
+	// scoring how closely each matched.
+	SearchProfiles(ctx context.Context, clinicID uuid.UUID, query string) ([]model.ProfileMatch, error)
+	// ListProfilesVersion summarizes the set ListProfiles pages through, for conditional requests.
+	ListProfilesVersion(ctx context.Context, clinicID uuid.UUID, includeArchived bool) (database.ListVersion, error)
+
+	// MergeProfiles folds the duplicate profile sourceID into targetID, archives the source and
+	// returns the merged target. Differing identity fields fail with 409 unless force is set.
+	MergeProfiles(ctx context.Context, clinicID, actorID, targetID, sourceID uuid.UUID, force bool) (*model.Profile, error)
+
+	// GetExtendedData returns a patient's clinic-specific custom fields as a JSON object.
+	GetExtendedData(ctx context.Context, clinicID, profileID uuid.UUID) (json.RawMessage, error)
+	// PatchExtendedData applies an RFC 7396 merge patch to a patient's custom fields.
+	PatchExtendedData(ctx context.Context, clinicID, profileID uuid.UUID, patch json.RawMessage) (json.RawMessage, error)
+
+	// ArchiveProfile hides a patient from lists and lookups and frees their phone number and email.
+	ArchiveProfile(ctx context.Context, clinicID, profileID uuid.UUID) error
+	// RestoreProfile undoes ArchiveProfile, returning the patient to their previous status.
+	// Anonymized patients cannot be restored.
+	RestoreProfile(ctx context.Context, clinicID, profileID uuid.UUID) (*model.Profile, error)
+	// AnonymizeProfile irreversibly erases a patient's personal data on their request, keeping
+	// the profile and its appointments for statistics, and records who erased it.
+	AnonymizeProfile(ctx context.Context, clinicID, actorID, profileID uuid.UUID) error
+	// ExportProfile collects everything the clinic holds about a patient, archived or not, from
+	// the sections registered for patient exports, and records who exported it.
+	ExportProfile(ctx context.Context, clinicID, actorID, profileID uuid.UUID) (*export.Document, error)
 
 	// Public/Guest-facing methods
-	FindOrCreateGuestForBooking(ctx context.Context, clinicID uuid.UUID, fullName string, phoneNumber string) (*model.Profile, error)
+	// A non-nil preferredLanguage records the language the guest booked in.
+	FindOrCreateGuestForBooking(ctx context.Context, clinicID uuid.UUID, fullName string, phoneNumber string, preferredLanguage *string) (*model.Profile, error)
 }
 
 // Repository defines the contract for data access operations for the Patient/Profile module.
 type Repository interface {
 	// FindOrCreateGuest atomically finds a profile by phone number or creates a new one if it doesn't exist.
 	// This is the core of the "Guest Checkout" booking flow.
-	FindOrCreateGuestForBooking(ctx context.Context, querier database.Querier, clinicID uuid.UUID, fullName string, phoneNumber string) (*model.Profile, error)
+	FindOrCreateGuestForBooking(ctx context.Context, querier database.Querier, clinicID uuid.UUID, fullName string, phoneNumber string, preferredLanguage *string) (*model.Profile, error)
 
-	FindByID(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (*model.Profile, error)
+	FindByID(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, includeArchived bool) (*model.Profile, error)
+	// FindByIDForUpdate loads an active profile and locks its row for the rest of the transaction.
+	FindByIDForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (*model.Profile, error)
 	Create(ctx context.Context, querier database.Querier, profile *model.Profile) error
 	Update(ctx context.Context, querier database.Querier, profile *model.Profile) error
-	List(ctx context.Context, querier database.Querier, clinicID uuid.UUID, offset, limit int) ([]model.Profile, error)
+	// UpdatePartial writes only the fields present in patch and returns the updated profile.
+	UpdatePartial(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, patch *model.ProfilePatch) (*model.Profile, error)
+	List(ctx context.Context, querier database.Querier, clinicID uuid.UUID, offset, limit int, includeArchived bool) ([]model.Profile, error)
+	// FindExtendedDataForUpdate loads an active profile's extended_data and locks the row.
+	FindExtendedDataForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) ([]byte, error)
+	// UpdateExtendedData replaces an active profile's extended_data.
+	UpdateExtendedData(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, data []byte) error
+	// Archive soft-deletes an active patient profile; it returns NotFound unless exactly one row changed.
+	Archive(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error
+	// Restore clears deleted_at and puts back the status the profile had when it was archived.
+	// Anonymized profiles are rejected with a conflict.
+	Restore(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (*model.Profile, error)
+	// Anonymize erases the profile's personal data, archives it and marks it so it can never be
+	// restored. It returns NotFound, or a conflict if it was already anonymized.
+	Anonymize(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error
+	// RedactAuditLog strips model.ErasedFields from the profile's audit entries.
+	RedactAuditLog(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error
+	// Search matches the phone number and national ID exactly and the name partially, or by
+	// similarity when query.Fuzzy is set, best match first.
+	Search(ctx context.Context, querier database.Querier, clinicID uuid.UUID, query model.ProfileSearch, limit int) ([]model.ProfileMatch, error)
+	// ListVersion counts the profiles List pages through and returns their latest update.
+	ListVersion(ctx context.Context, querier database.Querier, clinicID uuid.UUID, includeArchived bool) (database.ListVersion, error)
+	CreateAuditEvent(ctx context.Context, querier database.Querier, event *model.AuditEvent) error
 }
 
 // RegisterPatientRequest contains all data for creating a new, fully registered patient.
@@ -55,33 +113,46 @@ type RegisterPatientRequest struct {
 	Email       *string
 	NationalID  *string
 	DateOfBirth *time.Time
+	// PreferredLanguage is optional; nil leaves the stored preference unchanged.
+	PreferredLanguage *string
 }
 
-func (r RegisterPatientRequest) GetFullName() string        { return r.FullName }
-func (r RegisterPatientRequest) GetEmail() *string          { return r.Email }
-func (r RegisterPatientRequest) GetNationalID() *string     { return r.NationalID }
-func (r RegisterPatientRequest) GetDateOfBirth() *time.Time { return r.DateOfBirth }
+func (r RegisterPatientRequest) GetFullName() string           { return r.FullName }
+func (r RegisterPatientRequest) GetEmail() *string             { return r.Email }
+func (r RegisterPatientRequest) GetNationalID() *string        { return r.NationalID }
+func (r RegisterPatientRequest) GetDateOfBirth() *time.Time    { return r.DateOfBirth }
+func (r RegisterPatientRequest) GetPreferredLanguage() *string { return r.PreferredLanguage }
 
 // CompleteGuestRequest contains the data to upgrade a guest profile to a registered one.
+// Omitted optional fields keep their stored value; fields set to null are cleared.
 type CompleteGuestRequest struct {
-	ClinicID    uuid.UUID
-	ProfileID   uuid.UUID
-	FullName    string
-	Email       *string
-	NationalID  *string
-	DateOfBirth *time.Time
+	ClinicID          uuid.UUID
+	ProfileID         uuid.UUID
+	FullName          string
+	Email             optional.Optional[string]
+	NationalID        optional.Optional[string]
+	DateOfBirth       optional.Optional[time.Time]
+	PreferredLanguage optional.Optional[string]
 }
 
-func (r CompleteGuestRequest) GetFullName() string        { return r.FullName }
-func (r CompleteGuestRequest) GetEmail() *string          { return r.Email }
-func (r CompleteGuestRequest) GetNationalID() *string     { return r.NationalID }
-func (r CompleteGuestRequest) GetDateOfBirth() *time.Time { return r.DateOfBirth }
+// UpdateProfileRequest is a partial update of a patient's details. Omitted fields keep their
+// stored value; fields set to null are cleared.
+type UpdateProfileRequest struct {
+	ClinicID          uuid.UUID
+	ProfileID         uuid.UUID
+	FullName          optional.Optional[string]
+	PhoneNumber       optional.Optional[string]
+	Email             optional.Optional[string]
+	NationalID        optional.Optional[string]
+	DateOfBirth       optional.Optional[time.Time]
+	PreferredLanguage optional.Optional[string]
+}
 
-// ProfileUpdater is an interface that both Register and Update requests will satisfy.
-// This allows for a single, DRY upsert method in the service.
+// ProfileUpdater is satisfied by requests that replace a profile's details wholesale.
 type ProfileUpdater interface {
 	GetFullName() string
 	GetEmail() *string
 	GetNationalID() *string
 	GetDateOfBirth() *time.Time
+	GetPreferredLanguage() *string
 }
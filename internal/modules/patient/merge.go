@@ -0,0 +1,201 @@
+package patient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MergeProfiles folds the duplicate profile sourceID into targetID and returns the merged target.
+//
+// In one transaction it fills the target's empty fields and custom fields from the source,
+// publishes events.ProfilesMerged so other modules move their rows to the target, archives the
+// source and records the merge in the audit log. Differing national IDs or dates of birth mean
+// the profiles may be different people; they fail with 409 unless force is set, in which case
+// the target's values win.
+func (s *defaultService) MergeProfiles(ctx context.Context, clinicID, actorID, targetID, sourceID uuid.UUID, force bool) (*model.Profile, error) {
+	if targetID == sourceID {
+		return nil, apierror.NewBadRequest("A profile cannot be merged into itself.", nil)
+	}
+
+	var merged *model.Profile
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		target, source, err := s.lockMergePair(ctx, tx, clinicID, targetID, sourceID)
+		if err != nil {
+			return err
+		}
+
+		if conflicts := mergeConflicts(target, source); len(conflicts) > 0 && !force {
+			return apierror.NewConflict(fmt.Sprintf("The profiles have different %s. Check they are the same person and retry with force to keep this profile's values.", strings.Join(conflicts, " and ")), nil)
+		}
+
+		// The source goes first so its phone number and email are free for the target to take.
+		if err := s.repo.Archive(ctx, tx, clinicID, sourceID); err != nil {
+			return err
+		}
+		if err := events.Publish(ctx, s.bus, tx, events.ProfilesMerged{
+			ClinicID:        clinicID,
+			SourceProfileID: sourceID,
+			TargetProfileID: targetID,
+		}); err != nil {
+			return err
+		}
+
+		patch, copied := mergePatchFromSource(target, source)
+		merged, err = s.repo.UpdatePartial(ctx, tx, clinicID, targetID, patch)
+		if err != nil {
+			return err
+		}
+
+		extendedData, extendedCopied, err := mergeExtendedData(target.ExtendedData, source.ExtendedData)
+		if err != nil {
+			return apierror.NewInternalServer(fmt.Errorf("patient: failed to merge extended_data of %s into %s: %w", sourceID, targetID, err))
+		}
+		if len(extendedData) > MaxExtendedDataBytes {
+			return apierror.NewConflict(fmt.Sprintf("Together the profiles' custom fields exceed %d KB. Remove some from either profile and try again.", MaxExtendedDataBytes>>10), nil)
+		}
+		if len(extendedCopied) > 0 {
+			if err := s.repo.UpdateExtendedData(ctx, tx, clinicID, targetID, extendedData); err != nil {
+				return err
+			}
+			merged.ExtendedData = extendedData
+		}
+
+		details, err := json.Marshal(map[string]any{
+			"source_profile_id":    sourceID,
+			"copied_fields":        copied,
+			"copied_custom_fields": extendedCopied,
+			"forced":               force,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode merge audit details: %w", err)
+		}
+		if err := s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   clinicID,
+			UserID:     &actorID,
+			Action:     model.AuditActionProfilesMerged,
+			TableName:  "profiles",
+			RecordID:   targetID,
+			NewRecord:  details,
+			OccurredAt: time.Now(),
+		}); err != nil {
+			return err
+		}
+
+		merged, err = s.autoPromote(ctx, tx, merged, model.PromotionReasonProfilesMerged)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// lockMergePair loads both profiles for update, always locking the lower ID first so two
+// merges of the same pair in opposite directions cannot deadlock.
+func (s *defaultService) lockMergePair(ctx context.Context, tx pgx.Tx, clinicID, targetID, sourceID uuid.UUID) (target, source *model.Profile, err error) {
+	first, second := targetID, sourceID
+	if bytes.Compare(sourceID[:], targetID[:]) < 0 {
+		first, second = sourceID, targetID
+	}
+	locked := make(map[uuid.UUID]*model.Profile, 2)
+	for _, id := range []uuid.UUID{first, second} {
+		p, err := s.repo.FindByIDForUpdate(ctx, tx, clinicID, id)
+		if err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && id == sourceID {
+				return nil, nil, apierror.NewNotFound("source profile", err)
+			}
+			return nil, nil, err
+		}
+		locked[id] = p
+	}
+	return locked[targetID], locked[sourceID], nil
+}
+
+// mergeConflicts names the identity fields both profiles have set to different values.
+func mergeConflicts(target, source *model.Profile) []string {
+	var conflicts []string
+	if target.NationalID != nil && source.NationalID != nil && *target.NationalID != *source.NationalID {
+		conflicts = append(conflicts, "national IDs")
+	}
+	if target.DateOfBirth != nil && source.DateOfBirth != nil && !target.DateOfBirth.Equal(*source.DateOfBirth) {
+		conflicts = append(conflicts, "dates of birth")
+	}
+	return conflicts
+}
+
+// mergePatchFromSource fills the target's empty fields from the source and returns the columns it
+// copied. A registered source makes the target registered too.
+func mergePatchFromSource(target, source *model.Profile) (*model.ProfilePatch, []string) {
+	patch := &model.ProfilePatch{}
+	copied := []string{}
+	fill := func(column string, dst, src *string, field *optional.Optional[string]) {
+		if dst == nil && src != nil {
+			*field = optional.Some(*src)
+			copied = append(copied, column)
+		}
+	}
+	fill("phone_number", target.PhoneNumber, source.PhoneNumber, &patch.PhoneNumber)
+	fill("email", target.Email, source.Email, &patch.Email)
+	fill("national_id", target.NationalID, source.NationalID, &patch.NationalID)
+	fill("preferred_language", target.PreferredLanguage, source.PreferredLanguage, &patch.PreferredLanguage)
+	if target.DateOfBirth == nil && source.DateOfBirth != nil {
+		patch.DateOfBirth = optional.Some(*source.DateOfBirth)
+		copied = append(copied, "date_of_birth")
+	}
+
+	if target.ProfileStatus == model.ProfileStatusGuest && source.ProfileStatus == model.ProfileStatusRegistered {
+		registeredAt := time.Now()
+		if source.RegisteredAt != nil {
+			registeredAt = *source.RegisteredAt
+		}
+		patch.ProfileStatus = optional.Some(model.ProfileStatusRegistered)
+		patch.RegisteredAt = optional.Some(registeredAt)
+		copied = append(copied, "profile_status")
+	}
+	return patch, copied
+}
+
+// mergeExtendedData adds the source's top-level custom fields the target does not have and
+// returns the result with the keys it added.
+func mergeExtendedData(target, source []byte) ([]byte, []string, error) {
+	if len(source) == 0 {
+		return target, nil, nil
+	}
+	sourceDoc, err := decodeObject(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	targetDoc := map[string]any{}
+	if len(target) > 0 {
+		if targetDoc, err = decodeObject(target); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var added []string
+	for key, value := range sourceDoc {
+		if _, ok := targetDoc[key]; !ok {
+			targetDoc[key] = value
+			added = append(added, key)
+		}
+	}
+	if len(added) == 0 {
+		return target, nil, nil
+	}
+	data, err := json.Marshal(targetDoc)
+	return data, added, err
+}
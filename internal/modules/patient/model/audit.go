@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions written explicitly by the patient module (row changes are captured by triggers).
+const (
+	AuditActionProfileRegistered = "PROFILE_REGISTERED"
+	AuditActionProfilesMerged    = "PROFILES_MERGED"
+	AuditActionProfileAnonymized = "PROFILE_ANONYMIZED"
+	AuditActionProfileExported   = "PROFILE_EXPORTED"
+)
+
+// Reasons recorded with an automatic GUEST to REGISTERED promotion.
+const (
+	PromotionReasonProfileUpdated       = "profile_updated"
+	PromotionReasonAppointmentCompleted = "appointment_completed"
+	PromotionReasonProfilesMerged       = "profiles_merged"
+)
+
+// AuditEvent is an application-level entry in the 'audit_log' table.
+type AuditEvent struct {
+	ClinicID   uuid.UUID       `db:"clinic_id"`
+	UserID     *uuid.UUID      `db:"user_id"` // Nil for changes the system made on its own.
+	Action     string          `db:"action"`
+	TableName  string          `db:"table_name"`
+	RecordID   uuid.UUID       `db:"record_id"`
+	NewRecord  json.RawMessage `db:"new_record"`
+	OccurredAt time.Time       `db:"timestamp"`
+}
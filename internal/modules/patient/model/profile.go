@@ -4,6 +4,7 @@ package model
 import (
 	"time"
 
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
 	"github.com/google/uuid"
 )
 
@@ -19,16 +20,57 @@ const (
 // Profile represents an individual in the system, who can be a patient.
 // This struct maps directly to the 'profiles' table.
 type Profile struct {
-	ID            uuid.UUID     `db:"id"`
-	ClinicID      uuid.UUID     `db:"clinic_id"`
-	FullName      string        `db:"full_name"`
-	PhoneNumber   *string       `db:"phone_number"`
-	Email         *string       `db:"email"`
-	NationalID    *string       `db:"national_id"`
-	DateOfBirth   *time.Time    `db:"date_of_birth"`
-	ProfileStatus ProfileStatus `db:"profile_status"`
-	ExtendedData  []byte        `db:"extended_data"` // Stays as []byte for raw JSONB
-	CreatedAt     time.Time     `db:"created_at"`
-	UpdatedAt     time.Time     `db:"updated_at"`
-	DeletedAt     *time.Time    `db:"deleted_at"`
+	ID                uuid.UUID     `db:"id"`
+	ClinicID          uuid.UUID     `db:"clinic_id"`
+	FullName          string        `db:"full_name"`
+	PhoneNumber       *string       `db:"phone_number"`
+	Email             *string       `db:"email"`
+	NationalID        *string       `db:"national_id"`
+	DateOfBirth       *time.Time    `db:"date_of_birth"`
+	PreferredLanguage *string       `db:"preferred_language"` // nil defers to the request or clinic default
+	ProfileStatus     ProfileStatus `db:"profile_status"`
+	RegisteredAt      *time.Time    `db:"registered_at"` // When the profile became REGISTERED.
+	ExtendedData      []byte        `db:"extended_data"` // Stays as []byte for raw JSONB
+	CreatedAt         time.Time     `db:"created_at"`
+	UpdatedAt         time.Time     `db:"updated_at"`
+	DeletedAt         *time.Time    `db:"deleted_at"`
+}
+
+// AnonymizedName replaces the name of a patient whose personal data was erased.
+const AnonymizedName = "Deleted Patient"
+
+// ErasedFields are the profile columns anonymization clears; they are also stripped from the
+// profile's audit entries.
+var ErasedFields = []string{"full_name", "phone_number", "email", "national_id", "date_of_birth", "extended_data"}
+
+// ProfilePatch is a partial update of a profile. Omitted fields keep their stored value;
+// fields set to null clear the column.
+type ProfilePatch struct {
+	FullName          optional.Optional[string]
+	PhoneNumber       optional.Optional[string]
+	Email             optional.Optional[string]
+	NationalID        optional.Optional[string]
+	DateOfBirth       optional.Optional[time.Time]
+	PreferredLanguage optional.Optional[string]
+	ProfileStatus     optional.Optional[ProfileStatus]
+	RegisteredAt      optional.Optional[time.Time]
+}
+
+// ProfileSearch is a normalized front-desk search. Text is matched against the full name and,
+// exactly, the national ID; Phone, when the text reads as a phone number, against the phone number.
+type ProfileSearch struct {
+	Text  string
+	Phone *string
+	// Fuzzy matches the name by trigram similarity, tolerating typos, instead of by prefix.
+	Fuzzy bool
+	// Digits, when the text is only a phone number fragment, is matched anywhere in the phone number.
+	Digits *string
+}
+
+// ProfileMatch is a profile found by a search.
+type ProfileMatch struct {
+	Profile
+	// Score rates how closely the profile matched, from 0 to 1; an exact phone number or
+	// national ID scores 1.
+	Score float64
 }
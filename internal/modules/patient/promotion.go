@@ -0,0 +1,93 @@
+package patient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+	"github.com/jackc/pgx/v5"
+)
+
+// onAppointmentCompleted re-evaluates the patient once an appointment is completed, so guests
+// who keep attending are promoted as soon as staff have filled in their details.
+func (s *defaultService) onAppointmentCompleted(ctx context.Context, tx pgx.Tx, event events.AppointmentCompleted) error {
+	profile, err := s.repo.FindByID(ctx, tx, event.ClinicID, event.PatientProfileID, false)
+	if err != nil {
+		return err
+	}
+	_, err = s.autoPromote(ctx, tx, profile, model.PromotionReasonAppointmentCompleted)
+	return err
+}
+
+// autoPromote moves a guest to REGISTERED once the profile holds the minimum registered field
+// set, unless the clinic has turned auto-promotion off. Profile updates that leave a guest a
+// guest call it with PromotionReasonProfileUpdated. It returns the profile as now stored.
+func (s *defaultService) autoPromote(ctx context.Context, tx pgx.Tx, profile *model.Profile, reason string) (*model.Profile, error) {
+	if profile.ProfileStatus != model.ProfileStatusGuest {
+		return profile, nil
+	}
+
+	rules, _, err := settings.PatientRegistration.Get(ctx, s.settings, profile.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	if !rules.AutoPromoteGuests || !hasRegistrationMinimum(profile, rules.IdentityRequirement) {
+		return profile, nil
+	}
+
+	now := time.Now()
+	promoted, err := s.repo.UpdatePartial(ctx, tx, profile.ClinicID, profile.ID, &model.ProfilePatch{
+		ProfileStatus: optional.Some(model.ProfileStatusRegistered),
+		RegisteredAt:  optional.Some(now),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"profile_status": string(model.ProfileStatusRegistered),
+		"trigger":        "auto",
+		"reason":         reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode promotion audit details: %w", err)
+	}
+	if err := s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+		ClinicID:   profile.ClinicID,
+		Action:     model.AuditActionProfileRegistered,
+		TableName:  "profiles",
+		RecordID:   profile.ID,
+		NewRecord:  details,
+		OccurredAt: now,
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.publishRegistered(ctx, tx, profile.ClinicID, profile.ID, now); err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// hasRegistrationMinimum reports whether a profile has a name, a phone number and the
+// identity fields the clinic requires.
+func hasRegistrationMinimum(profile *model.Profile, identity string) bool {
+	if strings.TrimSpace(profile.FullName) == "" || profile.PhoneNumber == nil {
+		return false
+	}
+	hasDOB := profile.DateOfBirth != nil
+	hasNationalID := profile.NationalID != nil && *profile.NationalID != ""
+	switch identity {
+	case settings.IdentityDateOfBirth:
+		return hasDOB
+	case settings.IdentityNationalID:
+		return hasNationalID
+	default:
+		return hasDOB || hasNationalID
+	}
+}
@@ -3,38 +3,75 @@ package patient
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/phone"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Limits of the front-desk patient search. Queries of minFuzzySearchLength characters or more
+// match names by similarity; shorter ones, which have too few trigrams, by prefix.
+const (
+	minSearchLength      = 2
+	minFuzzySearchLength = 3
+	maxSearchResults     = 20
+)
+
 // defaultService is the concrete implementation of the patient.Service interface.
 type defaultService struct {
 	service.BaseService
 	repo Repository
 	db   *pgxpool.Pool
+	// reader serves plain lists and searches, possibly from a lagging read replica. Reads a
+	// client may make right after a write, such as fetching one profile, go to db.
+	reader   database.Querier
+	settings settings.Service
+	bus      *events.Bus
+	// exports assembles ExportProfile from the sections every module registered.
+	exports *export.Registry
 }
 
-// NewService creates a new instance of the patient service.
-func NewService(txManager database.TxManager, repo Repository, db *pgxpool.Pool) Service {
-	return &defaultService{
+// NewService creates a new instance of the patient service, subscribes it to the events on bus
+// that can promote a guest to a registered patient and registers the profile export section.
+func NewService(txManager database.TxManager, repo Repository, db *pgxpool.Pool, reader database.Querier, settingsSvc settings.Service, bus *events.Bus, exports *export.Registry) Service {
+	s := &defaultService{
 		BaseService: service.BaseService{Tx: txManager},
 		repo:        repo,
 		db:          db,
+		reader:      reader,
+		settings:    settingsSvc,
+		bus:         bus,
+		exports:     exports,
 	}
+	events.Subscribe(bus, s.onAppointmentCompleted)
+	exports.Register(exportSection, s.exportProfile)
+	return s
 }
 
 // FindOrCreateGuest orchestrates the "Smart Upsert" logic for guest bookings.
-func (s *defaultService) FindOrCreateGuestForBooking(ctx context.Context, clinicID uuid.UUID, fullName string, phoneNumber string) (*model.Profile, error) {
+func (s *defaultService) FindOrCreateGuestForBooking(ctx context.Context, clinicID uuid.UUID, fullName string, phoneNumber string, preferredLanguage *string) (*model.Profile, error) {
+	phoneNumber, err := s.normalizePhone(ctx, clinicID, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
 	var profile *model.Profile
-	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
-		p, err := s.repo.FindOrCreateGuestForBooking(ctx, tx, clinicID, fullName, phoneNumber)
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		p, err := s.repo.FindOrCreateGuestForBooking(ctx, tx, clinicID, fullName, phoneNumber, preferredLanguage)
 		if err != nil {
 			return err
 		}
@@ -46,9 +83,15 @@ func (s *defaultService) FindOrCreateGuestForBooking(ctx context.Context, clinic
 
 // RegisterNewPatient handles the creation of a fully-detailed patient profile by staff.
 func (s *defaultService) RegisterNewPatient(ctx context.Context, clinicID uuid.UUID, req RegisterPatientRequest) (*model.Profile, error) {
+	phoneNumber, err := s.normalizePhone(ctx, clinicID, req.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	req.PhoneNumber = phoneNumber
+
 	var profile *model.Profile
-	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
-		existing, err := s.repo.FindOrCreateGuestForBooking(ctx, tx, clinicID, req.FullName, req.PhoneNumber)
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		existing, err := s.repo.FindOrCreateGuestForBooking(ctx, tx, clinicID, req.FullName, req.PhoneNumber, nil)
 		if err != nil {
 			return fmt.Errorf("failed during profile lookup: %w", err)
 		}
@@ -57,45 +100,101 @@ func (s *defaultService) RegisterNewPatient(ctx context.Context, clinicID uuid.U
 		if existing.ProfileStatus == model.ProfileStatusRegistered {
 			return apierror.NewBadRequest("A registered patient with this phone number already exists.", nil)
 		}
-		profile.ProfileStatus = model.ProfileStatusRegistered
-
-		updatedProfile, updateErr := s.upsertProfile(ctx, tx, existing, req)
+		now := time.Now()
+		existing.ProfileStatus = model.ProfileStatusRegistered
+		existing.RegisteredAt = &now
 
+		updatedProfile, err := s.upsertProfile(ctx, tx, existing, req)
+		if err != nil {
+			return err
+		}
 		profile = updatedProfile
-		return updateErr
+		return s.publishRegistered(ctx, tx, profile.ClinicID, profile.ID, now)
 	})
 
 	return profile, err
 
 }
 
-// CompleteGuestRegistration transitions a guest profile to a registered state.
+// CompleteGuestRegistration transitions a guest profile to a registered state and applies
+// the provided details. Omitted fields are left untouched and null fields are cleared.
 func (s *defaultService) CompleteGuestRegistration(ctx context.Context, clinicID uuid.UUID, req CompleteGuestRequest) (*model.Profile, error) {
 	var profile *model.Profile
 	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
-		existing, err := s.repo.FindByID(ctx, s.db, req.ClinicID, req.ProfileID)
+		existing, err := s.repo.FindByID(ctx, tx, clinicID, req.ProfileID, false)
 		if err != nil {
 			return err
 		}
 
+		patch := &model.ProfilePatch{
+			FullName:          optional.Some(req.FullName),
+			Email:             req.Email,
+			NationalID:        req.NationalID,
+			DateOfBirth:       req.DateOfBirth,
+			PreferredLanguage: req.PreferredLanguage,
+		}
 		// If a guest is being updated, they become registered.
-		if profile.ProfileStatus == model.ProfileStatusGuest {
-			profile.ProfileStatus = model.ProfileStatusRegistered
+		if existing.ProfileStatus == model.ProfileStatusGuest {
+			patch.ProfileStatus = optional.Some(model.ProfileStatusRegistered)
+			patch.RegisteredAt = optional.Some(time.Now())
 		}
 
-		updatedProfile, updateErr := s.upsertProfile(ctx, tx, existing, req)
+		profile, err = s.repo.UpdatePartial(ctx, tx, clinicID, req.ProfileID, patch)
+		if err != nil {
+			return err
+		}
+		if registeredAt, ok := patch.RegisteredAt.Get(); ok && registeredAt != nil {
+			return s.publishRegistered(ctx, tx, clinicID, req.ProfileID, *registeredAt)
+		}
+		return nil
+	})
 
-		profile = updatedProfile
-		return updateErr
+	return profile, err
+}
 
+// publishRegistered announces, within tx, that the profile has just become REGISTERED.
+func (s *defaultService) publishRegistered(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID, registeredAt time.Time) error {
+	return events.Publish(ctx, s.bus, tx, events.PatientRegistered{
+		ClinicID:         clinicID,
+		PatientProfileID: profileID,
+		RegisteredAt:     registeredAt,
+	})
+}
+
+// UpdateProfile writes the fields present in req. Changing the phone number or email is
+// rejected with a conflict if another active patient in the clinic already uses it.
+func (s *defaultService) UpdateProfile(ctx context.Context, clinicID uuid.UUID, req UpdateProfileRequest) (*model.Profile, error) {
+	if raw, ok := req.PhoneNumber.Get(); ok && raw != nil {
+		phoneNumber, err := s.normalizePhone(ctx, clinicID, *raw)
+		if err != nil {
+			return nil, err
+		}
+		req.PhoneNumber = optional.Some(phoneNumber)
+	}
+
+	var profile *model.Profile
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		updated, err := s.repo.UpdatePartial(ctx, tx, clinicID, req.ProfileID, &model.ProfilePatch{
+			FullName:          req.FullName,
+			PhoneNumber:       req.PhoneNumber,
+			Email:             req.Email,
+			NationalID:        req.NationalID,
+			DateOfBirth:       req.DateOfBirth,
+			PreferredLanguage: req.PreferredLanguage,
+		})
+		if err != nil {
+			return err
+		}
+		profile, err = s.autoPromote(ctx, tx, updated, model.PromotionReasonProfileUpdated)
+		return err
 	})
 
 	return profile, err
 }
 
 // GetProfileByID retrieves a single patient profile.
-func (s *defaultService) GetProfileByID(ctx context.Context, clinicID, profileID uuid.UUID) (*model.Profile, error) {
-	profile, err := s.repo.FindByID(ctx, s.db, clinicID, profileID)
+func (s *defaultService) GetProfileByID(ctx context.Context, clinicID, profileID uuid.UUID, includeArchived bool) (*model.Profile, error) {
+	profile, err := s.repo.FindByID(ctx, s.db, clinicID, profileID, includeArchived)
 	if err != nil {
 		// The repository already returns a correctly typed apierror.NotFound
 		return nil, err
@@ -103,7 +202,7 @@ func (s *defaultService) GetProfileByID(ctx context.Context, clinicID, profileID
 	return profile, nil
 }
 
-func (s *defaultService) ListProfiles(ctx context.Context, clinicID uuid.UUID, page, pageSize int) ([]model.Profile, error) {
+func (s *defaultService) ListProfiles(ctx context.Context, clinicID uuid.UUID, page, pageSize int, includeArchived bool) ([]model.Profile, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -111,7 +210,158 @@ func (s *defaultService) ListProfiles(ctx context.Context, clinicID uuid.UUID, p
 		pageSize = 25
 	}
 	offset := (page - 1) * pageSize
-	return s.repo.List(ctx, s.db, clinicID, offset, pageSize)
+	return s.repo.List(ctx, s.reader, clinicID, offset, pageSize, includeArchived)
+}
+
+// ArchiveProfile soft-deletes a patient. Their phone number and email become free for a new
+// profile, which is why RestoreProfile can fail with a conflict.
+func (s *defaultService) ArchiveProfile(ctx context.Context, clinicID, profileID uuid.UUID) error {
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Archive(ctx, tx, clinicID, profileID)
+	})
+}
+
+// RestoreProfile brings an archived patient back with the status they had before.
+func (s *defaultService) RestoreProfile(ctx context.Context, clinicID, profileID uuid.UUID) (*model.Profile, error) {
+	var profile *model.Profile
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		p, err := s.repo.Restore(ctx, tx, clinicID, profileID)
+		if err != nil {
+			return err
+		}
+		profile = p
+		return nil
+	})
+	return profile, err
+}
+
+// AnonymizeProfile erases the patient's personal data, strips it from their audit history and
+// records the erasure, all in one transaction. The audit entry names who erased the patient but
+// none of the erased values.
+func (s *defaultService) AnonymizeProfile(ctx context.Context, clinicID, actorID, profileID uuid.UUID) error {
+	return s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.Anonymize(ctx, tx, clinicID, profileID); err != nil {
+			return err
+		}
+		if err := s.repo.RedactAuditLog(ctx, tx, clinicID, profileID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		details, err := json.Marshal(map[string]any{
+			"erased_fields": model.ErasedFields,
+			"anonymized_at": now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode anonymization audit details: %w", err)
+		}
+		return s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   clinicID,
+			UserID:     &actorID,
+			Action:     model.AuditActionProfileAnonymized,
+			TableName:  "profiles",
+			RecordID:   profileID,
+			NewRecord:  details,
+			OccurredAt: now,
+		})
+	})
+}
+
+// ExportProfile reads the profile from the primary, so a patient registered a moment ago can be
+// exported, then collects the sections and audits the export. Only the section names are audited.
+func (s *defaultService) ExportProfile(ctx context.Context, clinicID, actorID, profileID uuid.UUID) (*export.Document, error) {
+	if _, err := s.repo.FindByID(ctx, s.db, clinicID, profileID, true); err != nil {
+		return nil, err
+	}
+	doc, err := s.exports.Collect(ctx, clinicID, profileID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to export profile: %w", err))
+	}
+
+	details, err := json.Marshal(map[string]any{"sections": doc.Names()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export audit details: %w", err)
+	}
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.CreateAuditEvent(ctx, tx, &model.AuditEvent{
+			ClinicID:   clinicID,
+			UserID:     &actorID,
+			Action:     model.AuditActionProfileExported,
+			TableName:  "profiles",
+			RecordID:   profileID,
+			NewRecord:  details,
+			OccurredAt: doc.ExportedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// SearchProfiles finds patients by name, phone number or national ID for the front desk.
+// The query is trimmed and its whitespace collapsed; it must have at least minSearchLength characters.
+func (s *defaultService) SearchProfiles(ctx context.Context, clinicID uuid.UUID, query string) ([]model.ProfileMatch, error) {
+	text := strings.Join(strings.Fields(query), " ")
+	if utf8.RuneCountInString(text) < minSearchLength {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("Search for at least %d characters.", minSearchLength), nil)
+	}
+
+	loc, _, err := settings.Localization.Get(ctx, s.settings, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	search := model.ProfileSearch{Text: text, Fuzzy: utf8.RuneCountInString(text) >= minFuzzySearchLength}
+	if number, err := phone.Normalize(text, loc.DefaultPhoneRegion); err == nil {
+		search.Phone = &number
+	}
+	if digits, ok := phoneFragment(text); ok && len(digits) >= minFuzzySearchLength {
+		search.Digits = &digits
+	}
+	matches, err := s.repo.Search(ctx, s.reader, clinicID, search, maxSearchResults)
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
+	return matches, nil
+}
+
+// phoneFragment returns the digits of text if it is only a fragment of a phone number: digits,
+// possibly with a plus sign and separated by spaces, dashes or parentheses.
+func phoneFragment(text string) (string, bool) {
+	var digits strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '+':
+		default:
+			return "", false
+		}
+	}
+	return digits.String(), digits.Len() > 0
+}
+
+// normalizePhone returns raw in the canonical E.164 form, reading national numbers in the
+// clinic's default phone region. Numbers that cannot be normalized are a 400 naming the reason.
+func (s *defaultService) normalizePhone(ctx context.Context, clinicID uuid.UUID, raw string) (string, error) {
+	loc, _, err := settings.Localization.Get(ctx, s.settings, clinicID)
+	if err != nil {
+		return "", err
+	}
+	normalized, err := phone.Normalize(raw, loc.DefaultPhoneRegion)
+	if err != nil {
+		return "", apierror.NewBadRequest(fmt.Sprintf("Invalid phone number: %s.", err), err)
+	}
+	return normalized, nil
+}
+
+// ListProfilesVersion returns the count and latest update of the clinic's profiles.
+func (s *defaultService) ListProfilesVersion(ctx context.Context, clinicID uuid.UUID, includeArchived bool) (database.ListVersion, error) {
+	version, err := s.repo.ListVersion(ctx, s.reader, clinicID, includeArchived)
+	if err != nil {
+		return database.ListVersion{}, apierror.NewInternalServer(err)
+	}
+	return version, nil
 }
 
 func (s *defaultService) upsertProfile(ctx context.Context, tx pgx.Tx, profile *model.Profile, req ProfileUpdater) (*model.Profile, error) {
@@ -119,6 +369,9 @@ func (s *defaultService) upsertProfile(ctx context.Context, tx pgx.Tx, profile *
 	profile.Email = req.GetEmail()
 	profile.NationalID = req.GetNationalID()
 	profile.DateOfBirth = req.GetDateOfBirth()
+	if lang := req.GetPreferredLanguage(); lang != nil {
+		profile.PreferredLanguage = lang
+	}
 
 	// The calling method is responsible for setting the correct status.
 	if err := s.repo.Update(ctx, tx, profile); err != nil {
@@ -0,0 +1,291 @@
+package patient
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	settingsModel "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// replica stands in for the read replica; no query ever reaches it.
+type replica struct{ database.Querier }
+
+// defaultSettings serves every settings section with its defaults.
+type defaultSettings struct{ settings.Service }
+
+func (defaultSettings) GetSection(context.Context, uuid.UUID, string) (*settingsModel.SectionRecord, error) {
+	return &settingsModel.SectionRecord{}, nil
+}
+
+// queriedRepo records the querier each read was sent to.
+type queriedRepo struct {
+	Repository
+	used map[string]database.Querier
+}
+
+func (r *queriedRepo) FindByID(_ context.Context, querier database.Querier, _, profileID uuid.UUID, _ bool) (*model.Profile, error) {
+	r.used["FindByID"] = querier
+	return &model.Profile{ID: profileID}, nil
+}
+
+func (r *queriedRepo) List(_ context.Context, querier database.Querier, _ uuid.UUID, _, _ int, _ bool) ([]model.Profile, error) {
+	r.used["List"] = querier
+	return nil, nil
+}
+
+func (r *queriedRepo) Search(_ context.Context, querier database.Querier, _ uuid.UUID, _ model.ProfileSearch, _ int) ([]model.ProfileMatch, error) {
+	r.used["Search"] = querier
+	return nil, nil
+}
+
+func TestOnlyListsAndSearchesReadFromTheReplica(t *testing.T) {
+	repo := &queriedRepo{used: map[string]database.Querier{}}
+	reader := &replica{}
+	svc := NewService(nil, repo, nil, reader, defaultSettings{}, events.NewBus(), export.NewRegistry())
+	ctx := context.Background()
+	clinicID := uuid.New()
+
+	if _, err := svc.GetProfileByID(ctx, clinicID, uuid.New(), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ListProfiles(ctx, clinicID, 1, 25, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SearchProfiles(ctx, clinicID, "Mona"); err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.used["FindByID"] == reader {
+		t.Error("GetProfileByID read from the replica; a profile fetched right after a write must come from the primary")
+	}
+	for _, read := range []string{"List", "Search"} {
+		if repo.used[read] != reader {
+			t.Errorf("%s read from %v, want the replica", read, repo.used[read])
+		}
+	}
+}
+
+// fakeTx runs every transaction with a nil pgx.Tx, and after-commit hooks straight away.
+type fakeTx struct{}
+
+func (fakeTx) ExecTx(_ context.Context, fn func(tx pgx.Tx) error) error { return fn(nil) }
+func (fakeTx) ExecTxOpts(_ context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+func (fakeTx) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+// registrationSettings serves the given patient_registration section and defaults for the rest.
+type registrationSettings struct {
+	settings.Service
+	data string
+}
+
+func (s registrationSettings) GetSection(_ context.Context, _ uuid.UUID, section string) (*settingsModel.SectionRecord, error) {
+	if section == settings.PatientRegistration.Name {
+		return &settingsModel.SectionRecord{Data: json.RawMessage(s.data)}, nil
+	}
+	return &settingsModel.SectionRecord{}, nil
+}
+
+// profileRepo keeps a clinic's profiles in memory and records the audit events written.
+type profileRepo struct {
+	Repository
+	profiles map[uuid.UUID]model.Profile
+	audits   []*model.AuditEvent
+}
+
+func (r *profileRepo) FindByID(_ context.Context, _ database.Querier, _, profileID uuid.UUID, _ bool) (*model.Profile, error) {
+	profile := r.profiles[profileID]
+	return &profile, nil
+}
+
+func (r *profileRepo) UpdatePartial(_ context.Context, _ database.Querier, _, profileID uuid.UUID, patch *model.ProfilePatch) (*model.Profile, error) {
+	profile := r.profiles[profileID]
+	if name, ok := patch.FullName.Get(); ok {
+		profile.FullName = *name
+	}
+	patch.PhoneNumber.Apply(&profile.PhoneNumber)
+	patch.Email.Apply(&profile.Email)
+	patch.NationalID.Apply(&profile.NationalID)
+	patch.DateOfBirth.Apply(&profile.DateOfBirth)
+	if status, ok := patch.ProfileStatus.Get(); ok {
+		profile.ProfileStatus = *status
+	}
+	patch.RegisteredAt.Apply(&profile.RegisteredAt)
+	r.profiles[profileID] = profile
+	return &profile, nil
+}
+
+func (r *profileRepo) CreateAuditEvent(_ context.Context, _ database.Querier, event *model.AuditEvent) error {
+	r.audits = append(r.audits, event)
+	return nil
+}
+
+// newGuest stores a guest with a name and a phone number, as a guest booking leaves them.
+func (r *profileRepo) newGuest() uuid.UUID {
+	phone := "+201001234567"
+	id := uuid.New()
+	r.profiles[id] = model.Profile{ID: id, FullName: "Mona Adel", PhoneNumber: &phone, ProfileStatus: model.ProfileStatusGuest}
+	return id
+}
+
+func TestGuestsArePromotedOnceTheirProfileIsComplete(t *testing.T) {
+	ctx := context.Background()
+	clinicID := uuid.New()
+	born := time.Date(1990, 4, 12, 0, 0, 0, 0, time.UTC)
+
+	// Both hooks go through the same rule: staff completing the profile, and the appointment
+	// module announcing a completed visit on the bus, which the patient module subscribed to.
+	paths := []struct {
+		name   string
+		reason string
+		run    func(t *testing.T, svc Service, bus *events.Bus, repo *profileRepo, profileID uuid.UUID)
+	}{
+		{"profile update", model.PromotionReasonProfileUpdated, func(t *testing.T, svc Service, _ *events.Bus, _ *profileRepo, profileID uuid.UUID) {
+			_, err := svc.UpdateProfile(ctx, clinicID, UpdateProfileRequest{ClinicID: clinicID, ProfileID: profileID, DateOfBirth: optional.Some(born)})
+			if err != nil {
+				t.Fatalf("UpdateProfile: %v", err)
+			}
+		}},
+		{"appointment completed", model.PromotionReasonAppointmentCompleted, func(t *testing.T, _ Service, bus *events.Bus, repo *profileRepo, profileID uuid.UUID) {
+			// Staff filled in the date of birth earlier, while auto-promotion was off.
+			profile := repo.profiles[profileID]
+			profile.DateOfBirth = &born
+			repo.profiles[profileID] = profile
+			err := events.Publish(ctx, bus, nil, events.AppointmentCompleted{ClinicID: clinicID, AppointmentID: uuid.New(), PatientProfileID: profileID})
+			if err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+		}},
+	}
+	for _, path := range paths {
+		t.Run(path.name, func(t *testing.T) {
+			for _, enabled := range []bool{true, false} {
+				repo := &profileRepo{profiles: map[uuid.UUID]model.Profile{}}
+				bus := events.NewBus()
+				var registered []events.PatientRegistered
+				events.Subscribe(bus, func(_ context.Context, _ pgx.Tx, e events.PatientRegistered) error {
+					registered = append(registered, e)
+					return nil
+				})
+				rules := `{"auto_promote_guests": false, "identity_requirement": "date_of_birth_or_national_id"}`
+				if enabled {
+					rules = `{"auto_promote_guests": true, "identity_requirement": "date_of_birth_or_national_id"}`
+				}
+				svc := NewService(fakeTx{}, repo, nil, replica{}, registrationSettings{data: rules}, bus, export.NewRegistry())
+				profileID := repo.newGuest()
+
+				path.run(t, svc, bus, repo, profileID)
+
+				profile := repo.profiles[profileID]
+				if !enabled {
+					if profile.ProfileStatus != model.ProfileStatusGuest || len(repo.audits) != 0 || len(registered) != 0 {
+						t.Errorf("with auto-promotion off: status %s, %d audit event(s), %d registration(s); want a guest and nothing recorded",
+							profile.ProfileStatus, len(repo.audits), len(registered))
+					}
+					continue
+				}
+				if profile.ProfileStatus != model.ProfileStatusRegistered || profile.RegisteredAt == nil {
+					t.Fatalf("status = %s, registered at %v; want REGISTERED with a timestamp", profile.ProfileStatus, profile.RegisteredAt)
+				}
+				if len(repo.audits) != 1 || repo.audits[0].Action != model.AuditActionProfileRegistered {
+					t.Fatalf("audit events = %v, want one %s", repo.audits, model.AuditActionProfileRegistered)
+				}
+				var details map[string]string
+				if err := json.Unmarshal(repo.audits[0].NewRecord, &details); err != nil {
+					t.Fatal(err)
+				}
+				if details["trigger"] != "auto" || details["reason"] != path.reason {
+					t.Errorf("audit details = %v, want trigger auto and reason %s", details, path.reason)
+				}
+				if len(registered) != 1 || registered[0].PatientProfileID != profileID {
+					t.Errorf("PatientRegistered published %v, want once for the profile", registered)
+				}
+			}
+		})
+	}
+}
+
+func TestGuestsMissingTheRequiredIdentityStayGuests(t *testing.T) {
+	ctx := context.Background()
+	clinicID := uuid.New()
+	repo := &profileRepo{profiles: map[uuid.UUID]model.Profile{}}
+	rules := `{"auto_promote_guests": true, "identity_requirement": "national_id"}`
+	svc := NewService(fakeTx{}, repo, nil, replica{}, registrationSettings{data: rules}, events.NewBus(), export.NewRegistry())
+	profileID := repo.newGuest()
+
+	_, err := svc.UpdateProfile(ctx, clinicID, UpdateProfileRequest{
+		ClinicID:    clinicID,
+		ProfileID:   profileID,
+		DateOfBirth: optional.Some(time.Date(1990, 4, 12, 0, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if status := repo.profiles[profileID].ProfileStatus; status != model.ProfileStatusGuest {
+		t.Errorf("status = %s with a date of birth but no national ID, want GUEST", status)
+	}
+
+	_, err = svc.UpdateProfile(ctx, clinicID, UpdateProfileRequest{ClinicID: clinicID, ProfileID: profileID, NationalID: optional.Some("29004120101234")})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if status := repo.profiles[profileID].ProfileStatus; status != model.ProfileStatusRegistered {
+		t.Errorf("status = %s once the national ID is added, want REGISTERED", status)
+	}
+}
+
+func TestUpdateProfileClearsNullFieldsAndKeepsAbsentOnes(t *testing.T) {
+	ctx := context.Background()
+	clinicID := uuid.New()
+	repo := &profileRepo{profiles: map[uuid.UUID]model.Profile{}}
+	svc := NewService(fakeTx{}, repo, nil, replica{}, defaultSettings{}, events.NewBus(), export.NewRegistry())
+	stored := *goldenPatient()
+	repo.profiles[stored.ID] = stored
+
+	updated, err := svc.UpdateProfile(ctx, clinicID, UpdateProfileRequest{
+		ClinicID:  clinicID,
+		ProfileID: stored.ID,
+		FullName:  optional.Some("Mona Adel Hassan"),
+		Email:     optional.Null[string](),
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if updated.FullName != "Mona Adel Hassan" {
+		t.Errorf("full name = %q, want the new name", updated.FullName)
+	}
+	if updated.Email != nil {
+		t.Errorf("email = %q, want it cleared by null", *updated.Email)
+	}
+	if updated.PhoneNumber == nil || *updated.PhoneNumber != *stored.PhoneNumber ||
+		updated.NationalID == nil || *updated.NationalID != *stored.NationalID ||
+		updated.DateOfBirth == nil || !updated.DateOfBirth.Equal(*stored.DateOfBirth) {
+		t.Errorf("profile = %+v, want the fields absent from the request unchanged", updated)
+	}
+}
+
+// goldenPatient is a registered patient with every contact and identity field set.
+func goldenPatient() *model.Profile {
+	phone, email, nationalID := "+201001234567", "mona@example.com", "29004120101234"
+	born := time.Date(1990, 4, 12, 0, 0, 0, 0, time.UTC)
+	return &model.Profile{
+		ID:            uuid.New(),
+		FullName:      "Mona Adel",
+		PhoneNumber:   &phone,
+		Email:         &email,
+		NationalID:    &nationalID,
+		DateOfBirth:   &born,
+		ProfileStatus: model.ProfileStatusRegistered,
+	}
+}
@@ -0,0 +1,8 @@
+package store
+
+// Exported for the tests in package store_test, which seed through the fixtures package and so
+// cannot be part of package store.
+var (
+	FuzzySearchQuery = fuzzySearchQuery
+	EscapeLike       = escapeLike
+)
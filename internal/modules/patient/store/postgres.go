@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
@@ -36,83 +37,129 @@ func NewPgxProfileRepository(db *pgxpool.Pool) *pgxProfileRepository {
 // Create inserts a new profile record into the database.
 func (r *pgxProfileRepository) Create(ctx context.Context, querier database.Querier, profile *model.Profile) error {
 	query := `
-        INSERT INTO profiles (id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, profile_status, extended_data)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        INSERT INTO profiles (id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, extended_data)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
     `
 	_, err := querier.Exec(ctx, query,
 		profile.ID, profile.ClinicID, profile.FullName, profile.PhoneNumber, profile.Email,
-		profile.NationalID, profile.DateOfBirth, profile.ProfileStatus, profile.ExtendedData,
+		profile.NationalID, profile.DateOfBirth, profile.PreferredLanguage, profile.ProfileStatus, profile.ExtendedData,
 	)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return apierror.NewBadRequest("A patient with this phone number or email already exists in this clinic.", err)
+		if constraint, ok := database.UniqueViolation(err); ok {
+			return duplicateProfileError(constraint, err)
 		}
 		return fmt.Errorf("store.Create: failed to execute query: %w", err)
 	}
 	return nil
 }
 
-// FindOrCreateGuest atomically finds a profile by phone number for a given clinic,
-// or creates a new 'GUEST' profile if one does not exist. This is implemented
-// using a CTE with ON CONFLICT to ensure it is a single, race-condition-safe operation.
-func (r *pgxProfileRepository) FindOrCreateGuestForBooking(ctx context.Context, querier database.Querier, clinicID uuid.UUID, fullName string, phoneNumber string) (*model.Profile, error) {
-	profile := &model.Profile{}
-
-	// This query is the heart of the "Smart Upsert" logic.
-	// 1. `inserted` CTE: Attempts to insert a new guest profile.
-	//    `ON CONFLICT (clinic_id, phone_number) DO NOTHING` ensures that if a profile
-	//    with that phone number already exists for the clinic, the insert is silently ignored.
-	// 2. `SELECT`: We then select the profile that matches the phone number.
-	//    - If the insert succeeded, this select will find the newly created row.
-	//    - If the insert was ignored (due to conflict), this select will find the existing row.
+// FindOrCreateGuestForBooking atomically finds a profile by phone number for a given clinic,
+// or creates a new 'GUEST' profile if one does not exist, in a single round trip.
+// A non-nil preferredLanguage is stored on the new or existing profile.
+//
+// This is the find-or-insert pattern other modules should copy:
+//   - The ID is generated in Go with uuid.NewV7, so the query does not depend on a
+//     uuid_generate_v7() function being installed in the database.
+//   - The `inserted` CTE inserts with ON CONFLICT DO NOTHING. The conflict target must
+//     repeat the partial unique index's predicate, or Postgres cannot infer the index.
+//   - The result is `inserted` UNION ALL the existing row. All CTEs and the outer SELECT
+//     share one snapshot, so a plain SELECT after the insert would not see a new row.
+//   - When a concurrent transaction inserts the same phone number after our snapshot was
+//     taken, the insert is skipped and the SELECT cannot see the winner either. The statement
+//     is retried once; its new snapshot sees the committed row.
+func (r *pgxProfileRepository) FindOrCreateGuestForBooking(ctx context.Context, querier database.Querier, clinicID uuid.UUID, fullName string, phoneNumber string, preferredLanguage *string) (*model.Profile, error) {
+	// The `language_updated` CTE records the language chosen for this booking on an existing
+	// profile. It runs in the same snapshot, so the existing row is returned with its old
+	// value and is patched up below.
 	query := `
         WITH inserted AS (
-            INSERT INTO profiles (id, clinic_id, full_name, phone_number, profile_status)
-            VALUES (uuid_generate_v7(), $1, $2, $3, 'GUEST')
-            ON CONFLICT (clinic_id, phone_number) DO NOTHING
-            RETURNING *
+            INSERT INTO profiles (id, clinic_id, full_name, phone_number, preferred_language, profile_status)
+            VALUES ($1, $2, $3, $4, $5, 'GUEST')
+            ON CONFLICT (clinic_id, phone_number) WHERE phone_number IS NOT NULL AND deleted_at IS NULL DO NOTHING
+            RETURNING id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at
+        ), language_updated AS (
+            UPDATE profiles SET preferred_language = $5
+            WHERE $5::varchar IS NOT NULL AND clinic_id = $2 AND phone_number = $4 AND deleted_at IS NULL
         )
-        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, profile_status, extended_data, created_at, updated_at, deleted_at
+        SELECT * FROM inserted
+        UNION ALL
+        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at
         FROM profiles
-        WHERE clinic_id = $1 AND phone_number = $3 AND deleted_at IS NULL
+        WHERE clinic_id = $2 AND phone_number = $4 AND deleted_at IS NULL
+        LIMIT 1
     `
 
-	err := r.db.QueryRow(ctx, query, clinicID, fullName, phoneNumber).Scan(
+	for attempt := 1; ; attempt++ {
+		newID, err := uuid.NewV7()
+		if err != nil {
+			return nil, fmt.Errorf("store.FindOrCreateGuest: failed to generate uuidv7: %w", err)
+		}
+
+		profile := &model.Profile{}
+		err = querier.QueryRow(ctx, query, newID, clinicID, fullName, phoneNumber, preferredLanguage).Scan(
+			&profile.ID, &profile.ClinicID, &profile.FullName, &profile.PhoneNumber, &profile.Email,
+			&profile.NationalID, &profile.DateOfBirth, &profile.PreferredLanguage, &profile.ProfileStatus, &profile.RegisteredAt, &profile.ExtendedData,
+			&profile.CreatedAt, &profile.UpdatedAt, &profile.DeletedAt,
+		)
+		if errors.Is(err, pgx.ErrNoRows) && attempt < 2 {
+			// Lost the insert race to a transaction our snapshot cannot see yet.
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, apierror.NewInternalServer(fmt.Errorf("store.FindOrCreateGuest: guest profile neither inserted nor found: %w", err))
+			}
+			return nil, fmt.Errorf("store.FindOrCreateGuest: failed to execute query: %w", err)
+		}
+		if preferredLanguage != nil {
+			profile.PreferredLanguage = preferredLanguage
+		}
+		return profile, nil
+	}
+}
+
+// FindByID finds a profile by its ID, scoped to the given clinic. Archived profiles are
+// only found when includeArchived is set.
+func (r *pgxProfileRepository) FindByID(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, includeArchived bool) (*model.Profile, error) {
+	profile := &model.Profile{}
+	query := `
+        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at
+        FROM profiles
+        WHERE clinic_id = $1 AND id = $2 AND ($3 OR deleted_at IS NULL)
+    `
+	err := querier.QueryRow(ctx, query, clinicID, profileID, includeArchived).Scan(
 		&profile.ID, &profile.ClinicID, &profile.FullName, &profile.PhoneNumber, &profile.Email,
-		&profile.NationalID, &profile.DateOfBirth, &profile.ProfileStatus, &profile.ExtendedData,
+		&profile.NationalID, &profile.DateOfBirth, &profile.PreferredLanguage, &profile.ProfileStatus, &profile.RegisteredAt, &profile.ExtendedData,
 		&profile.CreatedAt, &profile.UpdatedAt, &profile.DeletedAt,
 	)
-
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			// This case should be practically impossible with the CTE logic, but is included for safety.
-			return nil, apierror.NewInternalServer(fmt.Errorf("failed to find or create guest profile, though this should not happen: %w", err))
+			return nil, apierror.NewNotFound("profile", err)
 		}
-		return nil, fmt.Errorf("store.FindOrCreateGuest: failed to execute query: %w", err)
+		return nil, fmt.Errorf("store.FindByID: failed to query profile: %w", err)
 	}
-
 	return profile, nil
 }
 
-// FindByID finds a profile by its ID, scoped to the given clinic.
-func (r *pgxProfileRepository) FindByID(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (*model.Profile, error) {
+// FindByIDForUpdate loads an active profile and locks its row for the rest of the transaction.
+func (r *pgxProfileRepository) FindByIDForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) (*model.Profile, error) {
 	profile := &model.Profile{}
 	query := `
-        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, profile_status, extended_data, created_at, updated_at, deleted_at
+        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at
         FROM profiles
         WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL
+        FOR UPDATE
     `
-	err := r.db.QueryRow(ctx, query, clinicID, profileID).Scan(
+	err := tx.QueryRow(ctx, query, clinicID, profileID).Scan(
 		&profile.ID, &profile.ClinicID, &profile.FullName, &profile.PhoneNumber, &profile.Email,
-		&profile.NationalID, &profile.DateOfBirth, &profile.ProfileStatus, &profile.ExtendedData,
+		&profile.NationalID, &profile.DateOfBirth, &profile.PreferredLanguage, &profile.ProfileStatus, &profile.RegisteredAt, &profile.ExtendedData,
 		&profile.CreatedAt, &profile.UpdatedAt, &profile.DeletedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apierror.NewNotFound("profile", err)
 		}
-		return nil, fmt.Errorf("store.FindByID: failed to query profile: %w", err)
+		return nil, fmt.Errorf("store.FindByIDForUpdate: failed to query profile: %w", err)
 	}
 	return profile, nil
 }
@@ -121,12 +168,12 @@ func (r *pgxProfileRepository) FindByID(ctx context.Context, querier database.Qu
 func (r *pgxProfileRepository) Update(ctx context.Context, querier database.Querier, profile *model.Profile) error {
 	query := `
         UPDATE profiles
-        SET full_name = $1, phone_number = $2, email = $3, national_id = $4, date_of_birth = $5, profile_status = $6, extended_data = $7
-        WHERE id = $8 AND clinic_id = $9
+        SET full_name = $1, phone_number = $2, email = $3, national_id = $4, date_of_birth = $5, preferred_language = $6, profile_status = $7, registered_at = $8, extended_data = $9
+        WHERE id = $10 AND clinic_id = $11
     `
 	cmdTag, err := querier.Exec(ctx, query,
 		profile.FullName, profile.PhoneNumber, profile.Email, profile.NationalID,
-		profile.DateOfBirth, profile.ProfileStatus, profile.ExtendedData,
+		profile.DateOfBirth, profile.PreferredLanguage, profile.ProfileStatus, profile.RegisteredAt, profile.ExtendedData,
 		profile.ID, profile.ClinicID,
 	)
 
@@ -139,16 +186,203 @@ func (r *pgxProfileRepository) Update(ctx context.Context, querier database.Quer
 	return nil
 }
 
-func (r *pgxProfileRepository) List(ctx context.Context, querier database.Querier, clinicID uuid.UUID, offset, limit int) ([]model.Profile, error) {
+// UpdatePartial writes only the fields present in patch and returns the updated profile.
+// An empty patch just returns the current profile.
+func (r *pgxProfileRepository) UpdatePartial(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, patch *model.ProfilePatch) (*model.Profile, error) {
+	var set database.SetClause
+	database.SetOptional(&set, "full_name", patch.FullName)
+	database.SetOptional(&set, "phone_number", patch.PhoneNumber)
+	database.SetOptional(&set, "email", patch.Email)
+	database.SetOptional(&set, "national_id", patch.NationalID)
+	database.SetOptional(&set, "date_of_birth", patch.DateOfBirth)
+	database.SetOptional(&set, "preferred_language", patch.PreferredLanguage)
+	database.SetOptional(&set, "profile_status", patch.ProfileStatus)
+	database.SetOptional(&set, "registered_at", patch.RegisteredAt)
+	if set.IsEmpty() {
+		return r.FindByID(ctx, querier, clinicID, profileID, false)
+	}
+
+	assignments, args := set.SQL()
+	query := fmt.Sprintf(`
+        UPDATE profiles
+        SET %s
+        WHERE clinic_id = $%d AND id = $%d AND deleted_at IS NULL
+        RETURNING id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at
+    `, assignments, len(args)+1, len(args)+2)
+	args = append(args, clinicID, profileID)
+
+	profile := &model.Profile{}
+	err := querier.QueryRow(ctx, query, args...).Scan(
+		&profile.ID, &profile.ClinicID, &profile.FullName, &profile.PhoneNumber, &profile.Email,
+		&profile.NationalID, &profile.DateOfBirth, &profile.PreferredLanguage, &profile.ProfileStatus, &profile.RegisteredAt, &profile.ExtendedData,
+		&profile.CreatedAt, &profile.UpdatedAt, &profile.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("profile", err)
+		}
+		if constraint, ok := database.UniqueViolation(err); ok {
+			return nil, duplicateProfileError(constraint, err)
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "chk_profile_contact_method" {
+			return nil, apierror.NewBadRequest("A patient needs a phone number or an email address.", err)
+		}
+		return nil, fmt.Errorf("store.UpdatePartial: failed to update profile: %w", err)
+	}
+	return profile, nil
+}
+
+// FindExtendedDataForUpdate loads an active profile's extended_data and locks its row for the
+// rest of the transaction.
+func (r *pgxProfileRepository) FindExtendedDataForUpdate(ctx context.Context, tx pgx.Tx, clinicID, profileID uuid.UUID) ([]byte, error) {
+	query := `
+        SELECT extended_data
+        FROM profiles
+        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL
+        FOR UPDATE
+    `
+	var data []byte
+	if err := tx.QueryRow(ctx, query, clinicID, profileID).Scan(&data); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("profile", err)
+		}
+		return nil, fmt.Errorf("store.FindExtendedDataForUpdate: failed to query profile: %w", err)
+	}
+	return data, nil
+}
+
+// UpdateExtendedData replaces an active profile's extended_data.
+func (r *pgxProfileRepository) UpdateExtendedData(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID, data []byte) error {
+	query := `UPDATE profiles SET extended_data = $3 WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL`
+	cmdTag, err := querier.Exec(ctx, query, clinicID, profileID, data)
+	if err != nil {
+		return fmt.Errorf("store.UpdateExtendedData: failed to update profile: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return apierror.NewNotFound("profile", nil)
+	}
+	return nil
+}
+
+// Archive soft-deletes an active patient profile, remembering its status for Restore.
+// Staff profiles cannot be archived here. It returns NotFound unless exactly one row changed.
+func (r *pgxProfileRepository) Archive(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error {
+	query := `
+        UPDATE profiles
+        SET deleted_at = NOW(), archived_from_status = profile_status, profile_status = 'ARCHIVED'
+        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NULL
+          AND NOT EXISTS (SELECT 1 FROM employees e WHERE e.profile_id = profiles.id)
+    `
+	cmdTag, err := querier.Exec(ctx, query, clinicID, profileID)
+	if err != nil {
+		return fmt.Errorf("store.Archive: failed to archive profile: %w", err)
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return apierror.NewNotFound("profile", nil)
+	}
+	return nil
+}
+
+// Restore brings an archived profile back with the status it had before it was archived.
+// It fails with a conflict if an active profile has since taken its phone number or email, or
+// if the profile was anonymized.
+func (r *pgxProfileRepository) Restore(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (*model.Profile, error) {
+	query := `
+        UPDATE profiles
+        SET deleted_at = NULL, profile_status = COALESCE(archived_from_status, 'GUEST'), archived_from_status = NULL
+        WHERE clinic_id = $1 AND id = $2 AND deleted_at IS NOT NULL AND anonymized_at IS NULL
+        RETURNING id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at
+    `
+	profile := &model.Profile{}
+	err := querier.QueryRow(ctx, query, clinicID, profileID).Scan(
+		&profile.ID, &profile.ClinicID, &profile.FullName, &profile.PhoneNumber, &profile.Email,
+		&profile.NationalID, &profile.DateOfBirth, &profile.PreferredLanguage, &profile.ProfileStatus, &profile.RegisteredAt, &profile.ExtendedData,
+		&profile.CreatedAt, &profile.UpdatedAt, &profile.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if anonymized, findErr := r.isAnonymized(ctx, querier, clinicID, profileID); findErr != nil {
+				return nil, findErr
+			} else if anonymized {
+				return nil, apierror.NewConflict("This patient's personal data was erased; the profile cannot be restored.", err)
+			}
+			return nil, apierror.NewNotFound("archived profile", err)
+		}
+		if constraint, ok := database.UniqueViolation(err); ok {
+			return nil, apierror.NewConflict("Another patient now uses this phone number or email. Change that patient's details before restoring this one.", err).WithCode(duplicateProfileError(constraint, err).Code)
+		}
+		return nil, fmt.Errorf("store.Restore: failed to restore profile: %w", err)
+	}
+	return profile, nil
+}
+
+// Anonymize erases a patient profile's personal data and archives it for good. Staff profiles
+// cannot be anonymized here. It fails with NotFound, or with a conflict if the profile was
+// already anonymized.
+func (r *pgxProfileRepository) Anonymize(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error {
+	query := `
+        UPDATE profiles
+        SET full_name = $3, phone_number = NULL, email = NULL, national_id = NULL, date_of_birth = NULL,
+            extended_data = '{}'::jsonb, profile_status = 'ARCHIVED', archived_from_status = NULL,
+            deleted_at = COALESCE(deleted_at, NOW()), anonymized_at = NOW()
+        WHERE clinic_id = $1 AND id = $2 AND anonymized_at IS NULL
+          AND NOT EXISTS (SELECT 1 FROM employees e WHERE e.profile_id = profiles.id)
+    `
+	cmdTag, err := querier.Exec(ctx, query, clinicID, profileID, model.AnonymizedName)
+	if err != nil {
+		return fmt.Errorf("store.Anonymize: failed to anonymize profile: %w", err)
+	}
+	if cmdTag.RowsAffected() == 1 {
+		return nil
+	}
+	anonymized, err := r.isAnonymized(ctx, querier, clinicID, profileID)
+	if err != nil {
+		return err
+	}
+	if anonymized {
+		return apierror.NewConflict("This patient's personal data was already erased.", nil)
+	}
+	return apierror.NewNotFound("profile", nil)
+}
+
+// isAnonymized reports whether the profile exists and was anonymized.
+func (r *pgxProfileRepository) isAnonymized(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error) {
+	var anonymized bool
+	query := `SELECT EXISTS (SELECT 1 FROM profiles WHERE clinic_id = $1 AND id = $2 AND anonymized_at IS NOT NULL)`
+	if err := querier.QueryRow(ctx, query, clinicID, profileID).Scan(&anonymized); err != nil {
+		return false, fmt.Errorf("store.isAnonymized: failed to query profile: %w", err)
+	}
+	return anonymized, nil
+}
+
+// RedactAuditLog removes the erased personal fields from every audit entry of the profile,
+// including the one the audit trigger wrote for the anonymization itself.
+func (r *pgxProfileRepository) RedactAuditLog(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) error {
+	query := `
+        UPDATE audit_log
+        SET old_record = old_record - $3::text[], new_record = new_record - $3::text[]
+        WHERE clinic_id = $1 AND table_name = 'profiles' AND record_id = $2
+          AND (old_record ?| $3::text[] OR new_record ?| $3::text[])
+    `
+	if _, err := querier.Exec(ctx, query, clinicID, profileID, model.ErasedFields); err != nil {
+		return fmt.Errorf("store.RedactAuditLog: failed to redact audit log: %w", err)
+	}
+	return nil
+}
+
+// List pages through the clinic's profiles, newest first. Archived profiles are only listed
+// when includeArchived is set.
+func (r *pgxProfileRepository) List(ctx context.Context, querier database.Querier, clinicID uuid.UUID, offset, limit int, includeArchived bool) ([]model.Profile, error) {
 	var profiles []model.Profile
 	query := `
-        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, profile_status, extended_data, created_at, updated_at, deleted_at
+        SELECT id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at
         FROM profiles
-        WHERE clinic_id = $1 AND deleted_at IS NULL
+        WHERE clinic_id = $1 AND ($4 OR deleted_at IS NULL)
         ORDER BY created_at DESC
         LIMIT $2 OFFSET $3
     `
-	rows, err := r.db.Query(ctx, query, clinicID, limit, offset)
+	rows, err := querier.Query(ctx, query, clinicID, limit, offset, includeArchived)
 	if err != nil {
 		return nil, fmt.Errorf("store.List: failed to query profiles: %w", err)
 	}
@@ -158,7 +392,7 @@ func (r *pgxProfileRepository) List(ctx context.Context, querier database.Querie
 		var profile model.Profile
 		if err := rows.Scan(
 			&profile.ID, &profile.ClinicID, &profile.FullName, &profile.PhoneNumber, &profile.Email,
-			&profile.NationalID, &profile.DateOfBirth, &profile.ProfileStatus, &profile.ExtendedData,
+			&profile.NationalID, &profile.DateOfBirth, &profile.PreferredLanguage, &profile.ProfileStatus, &profile.RegisteredAt, &profile.ExtendedData,
 			&profile.CreatedAt, &profile.UpdatedAt, &profile.DeletedAt,
 		); err != nil {
 			return nil, fmt.Errorf("store.List: failed to scan profile row: %w", err)
@@ -172,3 +406,119 @@ func (r *pgxProfileRepository) List(ctx context.Context, querier database.Querie
 
 	return profiles, nil
 }
+
+// searchColumns selects a profile and its match score, in the order Search scans them.
+const searchColumns = `
+        id, clinic_id, full_name, phone_number, email, national_id, date_of_birth, preferred_language, profile_status, registered_at, extended_data, created_at, updated_at, deleted_at`
+
+// fuzzySearchQuery matches a name when the query is similar to some part of it (the <% operator,
+// at pg_trgm's default word similarity threshold of 0.6) or is contained in it, and a phone
+// number when it contains the digits. Both forms are answered from the trigram indexes; scoring
+// with similarity() in the WHERE clause instead could not use them. Names rank by how well their
+// best-matching part fits the query, then by how close the whole name is.
+const fuzzySearchQuery = `
+        SELECT ` + searchColumns + `,
+            CASE
+                WHEN phone_number = $3::text OR national_id = $2 THEN 1
+                WHEN phone_number LIKE '%' || $5::text || '%' THEN word_similarity($5::text, phone_number)
+                ELSE word_similarity(lower($2), lower(full_name))
+            END AS score
+        FROM profiles
+        WHERE clinic_id = $1 AND deleted_at IS NULL
+          AND (phone_number = $3::text OR national_id = $2
+            OR lower($2) <% lower(full_name)
+            OR lower(full_name) LIKE '%' || lower($4) || '%'
+            OR phone_number LIKE '%' || $5::text || '%')
+        ORDER BY COALESCE(phone_number = $3::text OR national_id = $2, false) DESC,
+            score DESC, similarity(lower(full_name), lower($2)) DESC, full_name, id
+        LIMIT $6`
+
+// prefixSearchQuery matches a name starting with the query, or with a word starting with it.
+// Queries this short have too few trigrams to rank by similarity.
+const prefixSearchQuery = `
+        SELECT ` + searchColumns + `,
+            CASE
+                WHEN phone_number = $3::text OR national_id = $2 THEN 1
+                ELSE word_similarity(lower($2), lower(full_name))
+            END AS score
+        FROM profiles
+        WHERE clinic_id = $1 AND deleted_at IS NULL
+          AND (phone_number = $3::text OR national_id = $2
+            OR lower(full_name) LIKE lower($4) || '%'
+            OR lower(full_name) LIKE '% ' || lower($4) || '%')
+        ORDER BY
+            CASE
+                WHEN phone_number = $3::text OR national_id = $2 THEN 0
+                WHEN lower(full_name) = lower($2) THEN 1
+                WHEN lower(full_name) LIKE lower($4) || '%' THEN 2
+                ELSE 3
+            END,
+            full_name, id
+        LIMIT $5`
+
+// Search finds up to limit profiles matching the query, best match first: an exact phone number or
+// national ID, then names by similarity when query.Fuzzy is set, or else an exact name, a name
+// starting with the text and a name word starting with it. Archived (soft-deleted) profiles are
+// never returned.
+func (r *pgxProfileRepository) Search(ctx context.Context, querier database.Querier, clinicID uuid.UUID, query model.ProfileSearch, limit int) ([]model.ProfileMatch, error) {
+	sql, args := prefixSearchQuery, []any{clinicID, query.Text, query.Phone, escapeLike(query.Text), limit}
+	if query.Fuzzy {
+		sql, args = fuzzySearchQuery, []any{clinicID, query.Text, query.Phone, escapeLike(query.Text), query.Digits, limit}
+	}
+	rows, err := querier.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("store.Search: failed to query profiles: %w", err)
+	}
+	matches, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ProfileMatch, error) {
+		var match model.ProfileMatch
+		err := row.Scan(
+			&match.ID, &match.ClinicID, &match.FullName, &match.PhoneNumber, &match.Email,
+			&match.NationalID, &match.DateOfBirth, &match.PreferredLanguage, &match.ProfileStatus, &match.RegisteredAt, &match.ExtendedData,
+			&match.CreatedAt, &match.UpdatedAt, &match.DeletedAt, &match.Score,
+		)
+		return match, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store.Search: failed to scan profiles: %w", err)
+	}
+	return matches, nil
+}
+
+// duplicateProfileError reports a unique violation on profiles as a 409 naming the contact
+// detail another profile in the clinic already uses.
+func duplicateProfileError(constraint string, err error) *apierror.APIError {
+	switch constraint {
+	case "idx_profiles_unique_active_phone_per_clinic":
+		return apierror.NewConflict("A patient with this phone number already exists in this clinic.", err).WithCode(apierror.CodeDuplicatePhone)
+	case "idx_profiles_unique_active_email_per_clinic":
+		return apierror.NewConflict("A patient with this email already exists in this clinic.", err).WithCode(apierror.CodeDuplicateEmail)
+	default:
+		return apierror.NewConflict("A patient with these details already exists in this clinic.", err).WithCode(apierror.CodeDuplicateResource)
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ListVersion counts the profiles List pages through and returns their latest update in one aggregate.
+func (r *pgxProfileRepository) ListVersion(ctx context.Context, querier database.Querier, clinicID uuid.UUID, includeArchived bool) (database.ListVersion, error) {
+	query := `SELECT COUNT(*), MAX(updated_at) FROM profiles WHERE clinic_id = $1 AND ($2 OR deleted_at IS NULL)`
+	var version database.ListVersion
+	if err := querier.QueryRow(ctx, query, clinicID, includeArchived).Scan(&version.Count, &version.LastUpdatedAt); err != nil {
+		return database.ListVersion{}, fmt.Errorf("store.ListVersion: failed to probe profiles: %w", err)
+	}
+	return version, nil
+}
+
+// CreateAuditEvent writes an application-level event to the audit log.
+func (r *pgxProfileRepository) CreateAuditEvent(ctx context.Context, querier database.Querier, event *model.AuditEvent) error {
+	query := `
+        INSERT INTO audit_log (clinic_id, user_id, action, table_name, record_id, new_record, timestamp)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := querier.Exec(ctx, query, event.ClinicID, event.UserID, event.Action, event.TableName, event.RecordID, event.NewRecord, event.OccurredAt); err != nil {
+		return fmt.Errorf("store.CreateAuditEvent: failed to insert audit event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,475 @@
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/store"
+	shared "github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/fixtures"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedClinic applies the fixture and returns what it created.
+func seedClinic(t *testing.T, pool *pgxpool.Pool, fixture *fixtures.ClinicBuilder) *fixtures.Seeded {
+	t.Helper()
+	seeded, err := fixture.Apply(context.Background(), pool)
+	if err != nil {
+		t.Fatalf("seed clinic: %v", err)
+	}
+	return seeded
+}
+
+// createPatient adds a patient with the given contact details and every optional column set.
+func createPatient(t *testing.T, pool *pgxpool.Pool, clinicID uuid.UUID, phone, email string) *model.Profile {
+	t.Helper()
+	nationalID := "29001011234567"
+	profile := &model.Profile{
+		ID:            uuid.Must(uuid.NewV7()),
+		ClinicID:      clinicID,
+		FullName:      "Mona Adel",
+		PhoneNumber:   &phone,
+		Email:         &email,
+		NationalID:    &nationalID,
+		ProfileStatus: model.ProfileStatusRegistered,
+		ExtendedData:  []byte(`{"insurance_number": "INS-77"}`),
+	}
+	if err := store.NewPgxProfileRepository(pool).Create(context.Background(), pool, profile); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return profile
+}
+
+func assertAPIStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+		t.Fatalf("error = %v, want a %d apierror", err, status)
+	}
+}
+
+func TestFindOrCreateGuestForBooking(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	clinicID := dbtest.CreateClinic(t, pool)
+	otherClinicID := dbtest.CreateClinic(t, pool)
+	const phone = "+201001112233"
+
+	guest, err := repo.FindOrCreateGuestForBooking(ctx, pool, clinicID, "Mona Adel", phone, nil)
+	if err != nil {
+		t.Fatalf("FindOrCreateGuestForBooking on a fresh database: %v", err)
+	}
+	if guest.ID.Version() != 7 || guest.ProfileStatus != model.ProfileStatusGuest || guest.FullName != "Mona Adel" {
+		t.Errorf("new guest = %+v, want a GUEST named Mona Adel with a v7 ID", guest)
+	}
+
+	// The same phone number finds the same guest, keeps their name and records the new language.
+	arabic := "ar"
+	again, err := repo.FindOrCreateGuestForBooking(ctx, pool, clinicID, "M. Adel", phone, &arabic)
+	if err != nil {
+		t.Fatalf("FindOrCreateGuestForBooking again: %v", err)
+	}
+	if again.ID != guest.ID || again.FullName != "Mona Adel" {
+		t.Errorf("second booking found %s named %q, want %s named Mona Adel", again.ID, again.FullName, guest.ID)
+	}
+	stored, err := repo.FindByID(ctx, pool, clinicID, guest.ID, false)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if stored.PreferredLanguage == nil || *stored.PreferredLanguage != arabic {
+		t.Errorf("stored language = %v, want %s", stored.PreferredLanguage, arabic)
+	}
+
+	// Another clinic's guest with the same phone number is a different patient.
+	other, err := repo.FindOrCreateGuestForBooking(ctx, pool, otherClinicID, "Mona Adel", phone, nil)
+	if err != nil {
+		t.Fatalf("FindOrCreateGuestForBooking in another clinic: %v", err)
+	}
+	if other.ID == guest.ID || other.ClinicID != otherClinicID {
+		t.Errorf("other clinic's guest = %s in %s, want a new profile in %s", other.ID, other.ClinicID, otherClinicID)
+	}
+}
+
+func TestConcurrentGuestBookingsShareOneProfile(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	clinicID := dbtest.CreateClinic(t, pool)
+
+	const bookings = 8
+	ids := make([]uuid.UUID, bookings)
+	errs := make([]error, bookings)
+	var wg sync.WaitGroup
+	for i := range bookings {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			profile, err := repo.FindOrCreateGuestForBooking(ctx, pool, clinicID, "Karim Nabil", "+201004445566", nil)
+			if err == nil {
+				ids[i] = profile.ID
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	for i := range bookings {
+		if errs[i] != nil {
+			t.Fatalf("booking %d: %v", i, errs[i])
+		}
+		if ids[i] != ids[0] {
+			t.Errorf("booking %d got profile %s, want %s like the first", i, ids[i], ids[0])
+		}
+	}
+}
+
+func TestListVersionChangesWithTheList(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	seeded := seedClinic(t, pool, fixtures.Clinic().WithPatients(3))
+	seedClinic(t, pool, fixtures.Clinic().WithPatients(2))
+	clinicID := seeded.Clinic.ID
+
+	version := func(includeArchived bool) shared.ListVersion {
+		t.Helper()
+		v, err := repo.ListVersion(ctx, pool, clinicID, includeArchived)
+		if err != nil {
+			t.Fatalf("ListVersion: %v", err)
+		}
+		return v
+	}
+	before := version(false)
+	if before.Count != 3 || before.LastUpdatedAt == nil {
+		t.Fatalf("version = %d rows, last updated %v; want the clinic's 3 patients", before.Count, before.LastUpdatedAt)
+	}
+
+	if _, err := repo.UpdatePartial(ctx, pool, clinicID, seeded.Patients[0].ID, &model.ProfilePatch{FullName: optional.Some("Renamed Patient")}); err != nil {
+		t.Fatalf("UpdatePartial: %v", err)
+	}
+	updated := version(false)
+	if updated.Count != 3 || !updated.LastUpdatedAt.After(*before.LastUpdatedAt) {
+		t.Errorf("after an update: %d rows, last updated %v; want 3 rows updated after %v", updated.Count, updated.LastUpdatedAt, before.LastUpdatedAt)
+	}
+
+	if err := repo.Archive(ctx, pool, clinicID, seeded.Patients[1].ID); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if v := version(false); v.Count != 2 {
+		t.Errorf("after archiving: %d rows, want 2", v.Count)
+	}
+	if v := version(true); v.Count != 3 {
+		t.Errorf("after archiving, archived included: %d rows, want 3", v.Count)
+	}
+}
+
+func TestAnonymizeErasesProfileAndFreesContactDetails(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	clinicID := seedClinic(t, pool, fixtures.Clinic()).Clinic.ID
+	const phone, email = "+201012345678", "mona@example.com"
+
+	profile := createPatient(t, pool, clinicID, phone, email)
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Anonymize(ctx, tx, clinicID, profile.ID); err != nil {
+		t.Fatalf("Anonymize: %v", err)
+	}
+	if err := repo.RedactAuditLog(ctx, tx, clinicID, profile.ID); err != nil {
+		t.Fatalf("RedactAuditLog: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	erased, err := repo.FindByID(ctx, pool, clinicID, profile.ID, true)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if erased.FullName != model.AnonymizedName || erased.PhoneNumber != nil || erased.Email != nil ||
+		erased.NationalID != nil || erased.DateOfBirth != nil || string(erased.ExtendedData) != "{}" {
+		t.Errorf("profile after Anonymize = %+v, want its personal data erased", erased)
+	}
+	if erased.ProfileStatus != model.ProfileStatusArchived || erased.DeletedAt == nil {
+		t.Errorf("profile status = %s, deleted_at = %v; want ARCHIVED and set", erased.ProfileStatus, erased.DeletedAt)
+	}
+
+	var leaks int
+	err = pool.QueryRow(ctx, `
+        SELECT COUNT(*) FROM audit_log
+        WHERE record_id = $1 AND (COALESCE(old_record::text, '') || COALESCE(new_record::text, '')) ~ '(Mona|201012345678|mona@|29001011234567|INS-77)'`,
+		profile.ID).Scan(&leaks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaks != 0 {
+		t.Errorf("%d audit entries still hold the erased values", leaks)
+	}
+
+	// The same person registers again with the same phone number and email.
+	createPatient(t, pool, clinicID, phone, email)
+
+	_, err = repo.Restore(ctx, pool, clinicID, profile.ID)
+	assertAPIStatus(t, err, http.StatusConflict)
+
+	err = repo.Anonymize(ctx, pool, clinicID, profile.ID)
+	assertAPIStatus(t, err, http.StatusConflict)
+
+	err = repo.Anonymize(ctx, pool, clinicID, uuid.New())
+	assertAPIStatus(t, err, http.StatusNotFound)
+}
+
+func TestSearchRanksFuzzyNameMatches(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	clinicID := seedClinic(t, pool, fixtures.Clinic().WithPatients(500)).Clinic.ID
+
+	wanted := createPatient(t, pool, clinicID, "+201099988877", "m.abdelrahman@example.com")
+	if _, err := pool.Exec(ctx, `UPDATE profiles SET full_name = 'Mohamed Abdelrahman' WHERE id = $1`, wanted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A misspelt surname still finds the patient, first.
+	matches, err := repo.Search(ctx, pool, clinicID, model.ProfileSearch{Text: "abdelrahmen", Fuzzy: true}, 20)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) == 0 || matches[0].ID != wanted.ID {
+		t.Fatalf("Search(abdelrahmen) = %v, want %s first", matches, wanted.ID)
+	}
+	if score := matches[0].Score; score <= 0 || score >= 1 {
+		t.Errorf("score of a misspelt match = %v, want between 0 and 1", score)
+	}
+
+	// So does a fragment of the phone number, which the digits alone cannot normalize.
+	digits := "99988"
+	matches, err = repo.Search(ctx, pool, clinicID, model.ProfileSearch{Text: digits, Fuzzy: true, Digits: &digits}, 20)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) != 1 || matches[0].ID != wanted.ID {
+		t.Errorf("Search(%s) = %v, want only %s", digits, matches, wanted.ID)
+	}
+
+	// An exact national ID scores 1.
+	matches, err = repo.Search(ctx, pool, clinicID, model.ProfileSearch{Text: *wanted.NationalID, Fuzzy: true}, 20)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) == 0 || matches[0].ID != wanted.ID || matches[0].Score != 1 {
+		t.Errorf("Search(national ID) = %v, want %s first with score 1", matches, wanted.ID)
+	}
+}
+
+func TestFuzzySearchUsesTrigramIndexes(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	clinicID := seedClinic(t, pool, fixtures.Clinic().WithPatients(5000)).Clinic.ID
+	seedClinic(t, pool, fixtures.Clinic().WithSeed(2).WithPatients(5000))
+	if _, err := pool.Exec(ctx, `ANALYZE profiles`); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+	// On a table this small the planner may rightly prefer a scan; what matters is that every
+	// branch of the search condition can be answered from an index.
+	if _, err := tx.Exec(ctx, `SET LOCAL enable_seqscan = off`); err != nil {
+		t.Fatal(err)
+	}
+
+	digits := "0004242"
+	for _, tc := range []struct {
+		name  string
+		query model.ProfileSearch
+		index string
+	}{
+		{"name", model.ProfileSearch{Text: "youssef fathi", Fuzzy: true}, "idx_profiles_full_name_trgm"},
+		{"phone fragment", model.ProfileSearch{Text: digits, Fuzzy: true, Digits: &digits}, "idx_profiles_phone_number_trgm"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var plan string
+			err := tx.QueryRow(ctx, `EXPLAIN (FORMAT JSON) `+store.FuzzySearchQuery,
+				clinicID, tc.query.Text, tc.query.Phone, store.EscapeLike(tc.query.Text), tc.query.Digits, 20).Scan(&plan)
+			if err != nil {
+				t.Fatalf("EXPLAIN: %v", err)
+			}
+			if strings.Contains(plan, `"Seq Scan"`) || !strings.Contains(plan, tc.index) {
+				t.Errorf("search plan does not use %s:\n%s", tc.index, plan)
+			}
+		})
+	}
+}
+
+func TestSearchMatchesPartialNamesAndExactPhonesButNotArchivedProfiles(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	clinicID := seedClinic(t, pool, fixtures.Clinic()).Clinic.ID
+
+	rename := func(profile *model.Profile, name string) {
+		t.Helper()
+		if _, err := pool.Exec(ctx, `UPDATE profiles SET full_name = $2 WHERE id = $1`, profile.ID, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hoda := createPatient(t, pool, clinicID, "+201011112222", "hoda@example.com")
+	rename(hoda, "Hoda Mansour")
+	mansour := createPatient(t, pool, clinicID, "+201011112223", "karim@example.com")
+	rename(mansour, "Mansour Karim")
+	archived := createPatient(t, pool, clinicID, "+201033334444", "archived@example.com")
+	rename(archived, "Mansour Archived")
+	if err := repo.Archive(ctx, pool, clinicID, archived.ID); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	ids := func(matches []model.ProfileMatch) []uuid.UUID {
+		found := make([]uuid.UUID, len(matches))
+		for i, m := range matches {
+			found[i] = m.ID
+		}
+		return found
+	}
+
+	// A name prefix matches the start of the name first, then the start of a later word.
+	matches, err := repo.Search(ctx, pool, clinicID, model.ProfileSearch{Text: "mans"}, 20)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := ids(matches); len(got) != 2 || got[0] != mansour.ID || got[1] != hoda.ID {
+		t.Errorf("Search(mans) = %v, want %s then %s and not the archived profile", got, mansour.ID, hoda.ID)
+	}
+
+	// A phone number matches exactly: the neighbouring number is not returned.
+	phone := *hoda.PhoneNumber
+	matches, err = repo.Search(ctx, pool, clinicID, model.ProfileSearch{Text: phone, Phone: &phone}, 20)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := ids(matches); len(got) != 1 || got[0] != hoda.ID || matches[0].Score != 1 {
+		t.Errorf("Search(%s) = %v, want only %s with score 1", phone, matches, hoda.ID)
+	}
+
+	// The archived profile's own phone number finds nothing.
+	phone = *archived.PhoneNumber
+	matches, err = repo.Search(ctx, pool, clinicID, model.ProfileSearch{Text: phone, Phone: &phone, Fuzzy: true}, 20)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("Search(%s) = %v, want the archived profile excluded", phone, ids(matches))
+	}
+}
+
+func TestRestoredPatientKeepsThePhoneNumberUnique(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	clinicID := seedClinic(t, pool, fixtures.Clinic()).Clinic.ID
+	const phone = "+201055556666"
+
+	original := createPatient(t, pool, clinicID, phone, "first@example.com")
+	if err := repo.Archive(ctx, pool, clinicID, original.ID); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if _, err := repo.FindByID(ctx, pool, clinicID, original.ID, false); err == nil {
+		t.Error("FindByID returned an archived profile without includeArchived")
+	}
+
+	// Archiving freed the number, and a new patient took it: the original cannot come back.
+	replacement := createPatient(t, pool, clinicID, phone, "second@example.com")
+	_, err := repo.Restore(ctx, pool, clinicID, original.ID)
+	assertAPIStatus(t, err, http.StatusConflict)
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) && apiErr.Code != apierror.CodeDuplicatePhone {
+		t.Errorf("Restore error code = %q, want %q", apiErr.Code, apierror.CodeDuplicatePhone)
+	}
+
+	// Once the number is free again the original is restored with its previous status, and
+	// then holds the number against new patients.
+	if err := repo.Archive(ctx, pool, clinicID, replacement.ID); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	restored, err := repo.Restore(ctx, pool, clinicID, original.ID)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.ProfileStatus != model.ProfileStatusRegistered || restored.DeletedAt != nil {
+		t.Errorf("restored profile status = %s, deleted_at = %v; want REGISTERED and cleared", restored.ProfileStatus, restored.DeletedAt)
+	}
+	taken := phone
+	err = repo.Create(ctx, pool, &model.Profile{
+		ID:            uuid.Must(uuid.NewV7()),
+		ClinicID:      clinicID,
+		FullName:      "Salma Fawzy",
+		PhoneNumber:   &taken,
+		ProfileStatus: model.ProfileStatusGuest,
+		ExtendedData:  []byte(`{}`),
+	})
+	assertAPIStatus(t, err, http.StatusConflict)
+}
+
+func TestConcurrentExtendedDataPatchesOfDifferentKeysAllSurvive(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	repo := store.NewPgxProfileRepository(pool)
+	clinicID := seedClinic(t, pool, fixtures.Clinic()).Clinic.ID
+	profile := createPatient(t, pool, clinicID, "+201077778888", "fields@example.com")
+	svc := patient.NewService(database.NewTxManager(pool), repo, pool, pool, nil, events.NewBus(), export.NewRegistry())
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.PatchExtendedData(ctx, clinicID, profile.ID, json.RawMessage(fmt.Sprintf(`{"field_%d": %d}`, i, i)))
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("patch %d: %v", i, err)
+		}
+	}
+
+	data, err := svc.GetExtendedData(ctx, clinicID, profile.ID)
+	if err != nil {
+		t.Fatalf("GetExtendedData: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["insurance_number"] != "INS-77" {
+		t.Errorf("extended_data = %s, want the field stored before the patches kept", data)
+	}
+	for i := range writers {
+		if fields[fmt.Sprintf("field_%d", i)] != float64(i) {
+			t.Errorf("extended_data = %s, want field_%d from every concurrent patch", data, i)
+		}
+	}
+}
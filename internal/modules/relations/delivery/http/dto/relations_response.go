@@ -0,0 +1,26 @@
+// Package dto contains the Data Transfer Objects for the relations module's API contract.
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/model"
+	"github.com/google/uuid"
+)
+
+// RelatedResponse is the relation graph of a single entity.
+type RelatedResponse struct {
+	Entity    EntityResponse             `json:"entity"`
+	Relations map[string]*model.Relation `json:"relations"`
+	Notices   []NoticeResponse           `json:"notices"`
+}
+
+// EntityResponse identifies the record the relations hang off.
+type EntityResponse struct {
+	Type model.EntityType `json:"type"`
+	ID   uuid.UUID        `json:"id"`
+}
+
+// NoticeResponse explains why a relation is missing from the response.
+type NoticeResponse struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler holds the dependencies for the relations HTTP handlers.
+type Handler struct {
+	service relations.Service
+}
+
+// NewHandler creates a new relations handler with the given service.
+func NewHandler(service relations.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetRelated returns a handler listing everything linked to the entity identified by the `id` path parameter.
+// Optional query parameters: `kinds` (comma-separated), `limit` and `offset` (applied per relation).
+func (h *Handler) GetRelated(entity model.EntityType) middleware.APIHandlerFunc {
+	return func(c *gin.Context) *apierror.APIError {
+		payload, err := middleware.GetAuthPayload(c.Request.Context())
+		if err != nil {
+			return apierror.NewInternalServer(err)
+		}
+
+		entityID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return apierror.NewBadRequest("Invalid "+string(entity)+" ID format.", err)
+		}
+
+		var kinds []string
+		for _, k := range strings.Split(c.Query("kinds"), ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				kinds = append(kinds, k)
+			}
+		}
+
+		var page model.Page
+		if page.Limit, err = intQuery(c, "limit"); err != nil {
+			return apierror.NewBadRequest("The 'limit' query parameter must be a non-negative integer.", err)
+		}
+		if page.Offset, err = intQuery(c, "offset"); err != nil {
+			return apierror.NewBadRequest("The 'offset' query parameter must be a non-negative integer.", err)
+		}
+
+		result, err := h.service.GetRelated(c.Request.Context(), payload.ClinicID, payload.Permissions, entity, entityID, kinds, page)
+		if err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) {
+				return apiErr
+			}
+			return apierror.NewInternalServer(err)
+		}
+
+		notices := make([]dto.NoticeResponse, len(result.Notices))
+		for i, n := range result.Notices {
+			notices[i] = dto.NoticeResponse{Kind: n.Kind, Message: n.Message}
+		}
+
+		c.JSON(http.StatusOK, dto.RelatedResponse{
+			Entity:    dto.EntityResponse{Type: entity, ID: entityID},
+			Relations: result.Relations,
+			Notices:   notices,
+		})
+		return nil
+	}
+}
+
+func intQuery(c *gin.Context, name string) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New("negative value")
+	}
+	return n, nil
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the relations module in the router's startup log.
+func (h *Handler) Name() string {
+	return "relations"
+}
+
+// RegisterPublicRoutes registers nothing: the relations module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the relations module.
+// All these routes are protected and require an authenticated staff member.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	// GET /api/v1/patients/:id/related?kinds=appointments,audit_events&limit=&offset=
+	router.GET("/patients/:id/related", middleware.AllowQuery("kinds", "limit", "offset"), middleware.ErrorHandler(h.GetRelated(model.EntityPatient)))
+	// GET /api/v1/appointments/:id/related
+	router.GET("/appointments/:id/related", middleware.AllowQuery("kinds", "limit", "offset"), middleware.ErrorHandler(h.GetRelated(model.EntityAppointment)))
+}
@@ -0,0 +1,40 @@
+// Package relations assembles a graph of records linked to a patient or appointment for support staff.
+// Modules contribute relation providers through a registry, so no module needs to import another.
+package relations
+
+import (
+	"context"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/model"
+	"github.com/google/uuid"
+)
+
+// LoadFunc returns one relation of the entity, paginated by page.
+type LoadFunc func(ctx context.Context, clinicID, entityID uuid.UUID, page model.Page) (*model.Relation, error)
+
+// Service defines the contract for assembling relation graphs.
+type Service interface {
+	// Register adds a provider for the given entity type. The caller must hold permission to see it.
+	Register(entity model.EntityType, kind, permission string, load LoadFunc)
+	// GetRelated returns every registered relation the caller may read, or only those named in kinds.
+	GetRelated(ctx context.Context, clinicID uuid.UUID, permissions []string, entity model.EntityType, entityID uuid.UUID, kinds []string, page model.Page) (*Result, error)
+}
+
+// Repository defines the data access contract for the built-in relation providers.
+type Repository interface {
+	AppointmentsForPatient(ctx context.Context, clinicID, patientID uuid.UUID, page model.Page) (*model.Relation, error)
+	PatientForAppointment(ctx context.Context, clinicID, appointmentID uuid.UUID, page model.Page) (*model.Relation, error)
+	CountAuditEvents(ctx context.Context, clinicID uuid.UUID, tableName string, recordID uuid.UUID) (*model.Relation, error)
+}
+
+// Result holds the relations keyed by kind and any omitted kinds.
+type Result struct {
+	Relations map[string]*model.Relation
+	Notices   []Notice
+}
+
+// Notice explains why a relation was omitted from the result.
+type Notice struct {
+	Kind    string
+	Message string
+}
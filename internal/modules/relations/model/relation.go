@@ -0,0 +1,37 @@
+// Package model contains the compact relation-graph models used for support investigations.
+package model
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// EntityType identifies the kind of record whose relations are requested.
+type EntityType string
+
+const (
+	EntityPatient     EntityType = "patient"
+	EntityAppointment EntityType = "appointment"
+)
+
+// Page bounds how many items each relation returns.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// Summary is a compact view of one related record with a link to the full resource.
+type Summary struct {
+	ID         uuid.UUID     `json:"id"`
+	Label      string        `json:"label"`
+	Status     string        `json:"status,omitempty"`
+	OccurredAt *apitime.Time `json:"occurred_at,omitempty"`
+	Link       string        `json:"link,omitempty"`
+}
+
+// Relation is one kind of linked record. Count-only relations leave Items empty.
+type Relation struct {
+	Total      int       `json:"total"`
+	Items      []Summary `json:"items,omitempty"`
+	NextOffset *int      `json:"next_offset,omitempty"`
+}
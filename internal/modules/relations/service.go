@@ -0,0 +1,114 @@
+package relations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+// Response size caps. Heavy relations are paginated with Page.Offset.
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 50
+)
+
+// provider describes how to load one relation and who may read it.
+type provider struct {
+	permission string
+	load       LoadFunc
+}
+
+// defaultService is the concrete implementation of the relations.Service interface.
+type defaultService struct {
+	mu        sync.RWMutex
+	providers map[model.EntityType]map[string]provider
+}
+
+// NewService creates a relations service with the built-in providers backed by repo.
+func NewService(repo Repository) Service {
+	s := &defaultService{providers: make(map[model.EntityType]map[string]provider)}
+
+	s.Register(model.EntityPatient, "appointments", "appointments.read", repo.AppointmentsForPatient)
+	s.Register(model.EntityPatient, "audit_events", "patients.read", auditCount(repo, "profiles"))
+	s.Register(model.EntityAppointment, "patient", "patients.read", repo.PatientForAppointment)
+	s.Register(model.EntityAppointment, "audit_events", "appointments.read", auditCount(repo, "appointments"))
+	return s
+}
+
+// Register adds (or replaces) a relation provider for an entity type.
+func (s *defaultService) Register(entity model.EntityType, kind, permission string, load LoadFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.providers[entity] == nil {
+		s.providers[entity] = make(map[string]provider)
+	}
+	s.providers[entity][kind] = provider{permission: permission, load: load}
+}
+
+// GetRelated loads the requested relations in parallel, skipping any the caller lacks permission for.
+func (s *defaultService) GetRelated(ctx context.Context, clinicID uuid.UUID, permissions []string, entity model.EntityType, entityID uuid.UUID, kinds []string, page model.Page) (*Result, error) {
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
+	}
+	page.Limit = min(page.Limit, MaxPageLimit)
+	page.Offset = max(page.Offset, 0)
+
+	s.mu.RLock()
+	registered := s.providers[entity]
+	s.mu.RUnlock()
+
+	if len(kinds) == 0 {
+		for kind := range registered {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+	}
+
+	result := &Result{Relations: make(map[string]*model.Relation, len(kinds))}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	for _, kind := range kinds {
+		p, ok := registered[kind]
+		if !ok {
+			return nil, apierror.NewBadRequest(fmt.Sprintf("Unknown relation '%s' for %s.", kind, entity), nil)
+		}
+		if !slices.Contains(permissions, p.permission) {
+			result.Notices = append(result.Notices, Notice{
+				Kind:    kind,
+				Message: fmt.Sprintf("Omitted: requires the '%s' permission.", p.permission),
+			})
+			continue
+		}
+
+		g.Go(func() error {
+			relation, err := p.load(gctx, clinicID, entityID, page)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			result.Relations[kind] = relation
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to load relations: %w", err))
+	}
+	return result, nil
+}
+
+// auditCount adapts the audit counter to a LoadFunc for the given audited table.
+func auditCount(repo Repository, tableName string) LoadFunc {
+	return func(ctx context.Context, clinicID, entityID uuid.UUID, _ model.Page) (*model.Relation, error) {
+		return repo.CountAuditEvents(ctx, clinicID, tableName, entityID)
+	}
+}
@@ -0,0 +1,100 @@
+// Package store provides the database implementation for the relations repository.
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxRepository is the PostgreSQL implementation of the relations.Repository.
+type pgxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPgxRepository creates a new instance of the relations repository.
+func NewPgxRepository(db *pgxpool.Pool) *pgxRepository {
+	return &pgxRepository{db: db}
+}
+
+// AppointmentsForPatient returns the patient's appointments, newest first.
+func (r *pgxRepository) AppointmentsForPatient(ctx context.Context, clinicID, patientID uuid.UUID, page model.Page) (*model.Relation, error) {
+	query := `
+        SELECT a.id, COALESCE(s.name, 'Appointment'), a.status, a.start_time, COUNT(*) OVER ()
+        FROM appointments a
+        LEFT JOIN services s ON s.id = a.service_id
+        WHERE a.clinic_id = $1 AND a.patient_id = $2 AND a.deleted_at IS NULL
+        ORDER BY a.start_time DESC
+        LIMIT $3 OFFSET $4
+    `
+	rows, err := r.db.Query(ctx, query, clinicID, patientID, page.Limit, page.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("store.AppointmentsForPatient: failed to query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	relation := &model.Relation{Items: []model.Summary{}}
+	for rows.Next() {
+		var item model.Summary
+		var startTime time.Time
+		if err := rows.Scan(&item.ID, &item.Label, &item.Status, &startTime, &relation.Total); err != nil {
+			return nil, fmt.Errorf("store.AppointmentsForPatient: failed to scan row: %w", err)
+		}
+		item.OccurredAt = apitime.NewPtr(&startTime)
+		item.Link = "/api/v1/appointments/" + item.ID.String()
+		relation.Items = append(relation.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.AppointmentsForPatient: error iterating rows: %w", err)
+	}
+
+	if next := page.Offset + len(relation.Items); next < relation.Total {
+		relation.NextOffset = &next
+	}
+	return relation, nil
+}
+
+// PatientForAppointment returns the patient an appointment belongs to.
+func (r *pgxRepository) PatientForAppointment(ctx context.Context, clinicID, appointmentID uuid.UUID, _ model.Page) (*model.Relation, error) {
+	query := `
+        SELECT p.id, p.full_name, p.profile_status::text
+        FROM appointments a
+        JOIN profiles p ON p.id = a.patient_id
+        WHERE a.clinic_id = $1 AND a.id = $2
+    `
+	rows, err := r.db.Query(ctx, query, clinicID, appointmentID)
+	if err != nil {
+		return nil, fmt.Errorf("store.PatientForAppointment: failed to query patient: %w", err)
+	}
+	defer rows.Close()
+
+	relation := &model.Relation{Items: []model.Summary{}}
+	for rows.Next() {
+		var item model.Summary
+		if err := rows.Scan(&item.ID, &item.Label, &item.Status); err != nil {
+			return nil, fmt.Errorf("store.PatientForAppointment: failed to scan row: %w", err)
+		}
+		item.Link = "/api/v1/patients/" + item.ID.String()
+		relation.Items = append(relation.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.PatientForAppointment: error iterating rows: %w", err)
+	}
+	relation.Total = len(relation.Items)
+	return relation, nil
+}
+
+// CountAuditEvents counts audit log entries recorded for a row of the given table.
+func (r *pgxRepository) CountAuditEvents(ctx context.Context, clinicID uuid.UUID, tableName string, recordID uuid.UUID) (*model.Relation, error) {
+	query := `SELECT COUNT(*) FROM audit_log WHERE clinic_id = $1 AND table_name = $2 AND record_id = $3`
+	relation := &model.Relation{}
+	if err := r.db.QueryRow(ctx, query, clinicID, tableName, recordID).Scan(&relation.Total); err != nil {
+		return nil, fmt.Errorf("store.CountAuditEvents: failed to count audit events: %w", err)
+	}
+	return relation, nil
+}
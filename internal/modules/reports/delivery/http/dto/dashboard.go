@@ -0,0 +1,31 @@
+package dto
+
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
+// DashboardStatsResponse is the clinic's landing dashboard. Patients and AppointmentsToday
+// describe the present; PatientsRegistered and NoShowRatePct count within From through To.
+type DashboardStatsResponse struct {
+	From               apitime.Date       `json:"from"`
+	To                 apitime.Date       `json:"to"`
+	Timezone           string             `json:"timezone"`
+	PatientsRegistered int64              `json:"patients_registered"`
+	Patients           PatientMixResponse `json:"patients"`
+	AppointmentsToday  int64              `json:"appointments_today"`
+	Outcomes           OutcomesResponse   `json:"appointment_outcomes"`
+}
+
+// PatientMixResponse splits the clinic's active patients into guests and registered patients.
+// GuestSharePct is null when the clinic has no patients.
+type PatientMixResponse struct {
+	Guests        int64    `json:"guests"`
+	Registered    int64    `json:"registered"`
+	GuestSharePct *float64 `json:"guest_share_pct"`
+}
+
+// OutcomesResponse counts the range's appointments whose outcome is known. NoShowRatePct is
+// no-shows over completed and no-show appointments, and null when there were none.
+type OutcomesResponse struct {
+	Completed     int64    `json:"completed"`
+	NoShow        int64    `json:"no_show"`
+	NoShowRatePct *float64 `json:"no_show_rate_pct"`
+}
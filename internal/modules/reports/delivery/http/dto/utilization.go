@@ -0,0 +1,39 @@
+// Package dto contains the Data Transfer Objects for the reports module's API contract.
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// UtilizationResponse is a utilization report. Only the list matching GroupBy is present.
+type UtilizationResponse struct {
+	From          apitime.Date               `json:"from"`
+	To            apitime.Date               `json:"to"`
+	GroupBy       model.GroupBy              `json:"group_by"`
+	Timezone      string                     `json:"timezone"`
+	Practitioners []PractitionerWeekResponse `json:"practitioners,omitempty"`
+	Services      []ServiceBookingsResponse  `json:"services,omitempty"`
+}
+
+// PractitionerWeekResponse is one practitioner's week. UtilizationPct is booked over available
+// minutes and is null when the practitioner had no scheduled hours that week.
+type PractitionerWeekResponse struct {
+	PractitionerID   uuid.UUID    `json:"practitioner_id"`
+	PractitionerName string       `json:"practitioner_name"`
+	WeekStart        apitime.Date `json:"week_start"`
+	AvailableMinutes int64        `json:"available_minutes"`
+	BookedMinutes    int64        `json:"booked_minutes"`
+	Bookings         int64        `json:"bookings"`
+	UtilizationPct   *float64     `json:"utilization_pct"`
+}
+
+// ServiceBookingsResponse is one service's bookings. SharePct is its share of all bookings in the range.
+type ServiceBookingsResponse struct {
+	ServiceID     *uuid.UUID `json:"service_id"`
+	ServiceName   string     `json:"service_name"`
+	BookedMinutes int64      `json:"booked_minutes"`
+	Bookings      int64      `json:"bookings"`
+	SharePct      float64    `json:"share_pct"`
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler holds the dependencies for the reports HTTP handlers.
+type Handler struct {
+	service reports.Service
+}
+
+// NewHandler creates a new reports handler with the given service.
+func NewHandler(service reports.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetUtilization returns the utilization report for the `from`..`to` clinic-local dates (inclusive),
+// grouped by `group_by` (practitioner, the default, or service).
+func (h *Handler) GetUtilization(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	from, err := apitime.ParseDate(c.Query("from"))
+	if err != nil {
+		return apierror.NewBadRequest("The 'from' query parameter must be a date (YYYY-MM-DD).", err)
+	}
+	to, err := apitime.ParseDate(c.Query("to"))
+	if err != nil {
+		return apierror.NewBadRequest("The 'to' query parameter must be a date (YYYY-MM-DD).", err)
+	}
+	filter := model.UtilizationFilter{
+		From:    from.Time(),
+		To:      to.Time(),
+		GroupBy: model.GroupBy(c.Query("group_by")),
+	}
+
+	report, err := h.service.GetUtilization(c.Request.Context(), payload.ClinicID, filter)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toUtilizationResponse(report))
+	return nil
+}
+
+// GetDashboardStats returns the clinic's headline numbers for the `from`..`to` clinic-local dates
+// (inclusive). Either may be omitted; the range defaults to the current month.
+func (h *Handler) GetDashboardStats(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var filter model.DashboardFilter
+	if raw := c.Query("from"); raw != "" {
+		from, err := apitime.ParseDate(raw)
+		if err != nil {
+			return apierror.NewBadRequest("The 'from' query parameter must be a date (YYYY-MM-DD).", err)
+		}
+		filter.From = from.Time()
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := apitime.ParseDate(raw)
+		if err != nil {
+			return apierror.NewBadRequest("The 'to' query parameter must be a date (YYYY-MM-DD).", err)
+		}
+		filter.To = to.Time()
+	}
+
+	stats, err := h.service.GetDashboardStats(c.Request.Context(), payload.ClinicID, filter)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toDashboardStatsResponse(stats))
+	return nil
+}
+
+func toDashboardStatsResponse(stats *model.DashboardStats) dto.DashboardStatsResponse {
+	response := dto.DashboardStatsResponse{
+		From:               apitime.DateOf(stats.From),
+		To:                 apitime.DateOf(stats.To),
+		Timezone:           stats.Timezone,
+		PatientsRegistered: stats.PatientsRegistered,
+		Patients: dto.PatientMixResponse{
+			Guests:     stats.GuestPatients,
+			Registered: stats.RegisteredPatients,
+		},
+		AppointmentsToday: stats.AppointmentsToday,
+		Outcomes: dto.OutcomesResponse{
+			Completed: stats.CompletedAppointments,
+			NoShow:    stats.NoShowAppointments,
+		},
+	}
+	if total := stats.GuestPatients + stats.RegisteredPatients; total > 0 {
+		pct := percent(stats.GuestPatients, total)
+		response.Patients.GuestSharePct = &pct
+	}
+	if total := stats.CompletedAppointments + stats.NoShowAppointments; total > 0 {
+		pct := percent(stats.NoShowAppointments, total)
+		response.Outcomes.NoShowRatePct = &pct
+	}
+	return response
+}
+
+func toUtilizationResponse(report *model.Utilization) dto.UtilizationResponse {
+	response := dto.UtilizationResponse{
+		From:     apitime.DateOf(report.Filter.From),
+		To:       apitime.DateOf(report.Filter.To),
+		GroupBy:  report.Filter.GroupBy,
+		Timezone: report.Timezone,
+	}
+	switch report.Filter.GroupBy {
+	case model.GroupByPractitioner:
+		response.Practitioners = make([]dto.PractitionerWeekResponse, len(report.Practitioners))
+		for i, w := range report.Practitioners {
+			response.Practitioners[i] = dto.PractitionerWeekResponse{
+				PractitionerID:   w.PractitionerID,
+				PractitionerName: w.PractitionerName,
+				WeekStart:        apitime.DateOf(w.WeekStart),
+				AvailableMinutes: w.AvailableMinutes,
+				BookedMinutes:    w.BookedMinutes,
+				Bookings:         w.Bookings,
+			}
+			if w.AvailableMinutes > 0 {
+				pct := percent(w.BookedMinutes, w.AvailableMinutes)
+				response.Practitioners[i].UtilizationPct = &pct
+			}
+		}
+	case model.GroupByService:
+		var total int64
+		for _, s := range report.Services {
+			total += s.Bookings
+		}
+		response.Services = make([]dto.ServiceBookingsResponse, len(report.Services))
+		for i, s := range report.Services {
+			response.Services[i] = dto.ServiceBookingsResponse{
+				ServiceID:     s.ServiceID,
+				ServiceName:   s.ServiceName,
+				BookedMinutes: s.BookedMinutes,
+				Bookings:      s.Bookings,
+				SharePct:      percent(s.Bookings, total),
+			}
+		}
+	}
+	return response
+}
+
+// percent returns part/whole as a percentage rounded to one decimal place.
+func percent(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(whole)) / 10
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the reports module in the router's startup log.
+func (h *Handler) Name() string {
+	return "reports"
+}
+
+// RegisterPublicRoutes registers nothing: the reports module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the reports module.
+// All these routes are protected and require an authenticated staff member.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	// GET /api/v1/reports/utilization?from=&to=&group_by=practitioner|service
+	router.GET("/reports/utilization", middleware.RequirePermission(model.PermissionReportsRead), middleware.AllowQuery("from", "to", "group_by"), middleware.ErrorHandler(h.GetUtilization))
+	// GET /api/v1/dashboard/stats?from=&to= - Headline numbers for the landing dashboard; the range defaults to this month
+	router.GET("/dashboard/stats", middleware.RequirePermission(model.PermissionReportsRead), middleware.AllowQuery("from", "to"), middleware.ErrorHandler(h.GetDashboardStats))
+}
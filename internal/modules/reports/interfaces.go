@@ -0,0 +1,37 @@
+// Package reports computes read-only operational reports, such as appointment capacity and the
+// landing dashboard's headline numbers, for clinic managers.
+package reports
+
+import (
+	"context"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/model"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for clinic reports.
+type Service interface {
+	// GetUtilization returns booked against available time per practitioner and week, or
+	// bookings per service, for the filter's date range.
+	GetUtilization(ctx context.Context, clinicID uuid.UUID, filter model.UtilizationFilter) (*model.Utilization, error)
+	// GetDashboardStats returns the clinic's headline numbers for the filter's date range.
+	GetDashboardStats(ctx context.Context, clinicID uuid.UUID, filter model.DashboardFilter) (*model.DashboardStats, error)
+}
+
+// Repository defines the data access contract for reports. Every method aggregates in SQL;
+// timezone is the clinic's, in which the filter dates and weekly schedules are interpreted.
+type Repository interface {
+	// ClinicTimezone returns the IANA zone the clinic's schedules and dates are expressed in.
+	ClinicTimezone(ctx context.Context, clinicID uuid.UUID) (string, error)
+	PractitionerUtilization(ctx context.Context, clinicID uuid.UUID, timezone string, filter model.UtilizationFilter) ([]model.PractitionerWeek, error)
+	ServiceBookings(ctx context.Context, clinicID uuid.UUID, timezone string, filter model.UtilizationFilter) ([]model.ServiceBookings, error)
+
+	// CountPatientsRegistered counts the patients who became REGISTERED within period.
+	CountPatientsRegistered(ctx context.Context, clinicID uuid.UUID, period model.Period) (int64, error)
+	// CountPatientsByStatus counts the clinic's active guest and registered patients.
+	CountPatientsByStatus(ctx context.Context, clinicID uuid.UUID) (guests, registered int64, err error)
+	// CountAppointments counts the appointments starting within period that were not cancelled.
+	CountAppointments(ctx context.Context, clinicID uuid.UUID, period model.Period) (int64, error)
+	// CountAppointmentOutcomes counts the completed and no-show appointments starting within period.
+	CountAppointmentOutcomes(ctx context.Context, clinicID uuid.UUID, period model.Period) (completed, noShow int64, err error)
+}
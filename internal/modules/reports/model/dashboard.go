@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// DashboardFilter bounds the dashboard to the clinic-local dates From through To, inclusive.
+// A zero From or To defaults to the first or last day of the current month.
+type DashboardFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+// Period is the instants [Start, End) that a range of clinic-local dates covers.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// DashboardStats are the headline numbers of a clinic's landing dashboard. The patient mix and
+// today's appointments describe the present; the rest count within the filter's dates.
+type DashboardStats struct {
+	From     time.Time
+	To       time.Time
+	Timezone string
+	// PatientsRegistered counts the patients who became REGISTERED within the range.
+	PatientsRegistered int64
+	// GuestPatients and RegisteredPatients split the clinic's active patients by status.
+	GuestPatients      int64
+	RegisteredPatients int64
+	// AppointmentsToday counts today's appointments that were not cancelled.
+	AppointmentsToday int64
+	// CompletedAppointments and NoShowAppointments count the appointments within the range
+	// whose outcome is known.
+	CompletedAppointments int64
+	NoShowAppointments    int64
+}
@@ -0,0 +1,54 @@
+// Package model contains the aggregate models returned by clinic reports.
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PermissionReportsRead allows viewing clinic utilization reports and the dashboard.
+const PermissionReportsRead = "reports.read"
+
+// GroupBy selects how a utilization report is broken down.
+type GroupBy string
+
+const (
+	GroupByPractitioner GroupBy = "practitioner"
+	GroupByService      GroupBy = "service"
+)
+
+// UtilizationFilter bounds a report to the clinic-local dates From through To, inclusive.
+type UtilizationFilter struct {
+	From    time.Time
+	To      time.Time
+	GroupBy GroupBy
+}
+
+// PractitionerWeek is one practitioner's scheduled and booked time in one week.
+// Weeks start on Monday; the first and last may extend past the filter and only count days inside it.
+type PractitionerWeek struct {
+	PractitionerID   uuid.UUID
+	PractitionerName string
+	WeekStart        time.Time
+	AvailableMinutes int64
+	BookedMinutes    int64
+	Bookings         int64
+}
+
+// ServiceBookings is the bookings made for one service. ServiceID is nil for appointments
+// without a service.
+type ServiceBookings struct {
+	ServiceID     *uuid.UUID
+	ServiceName   string
+	BookedMinutes int64
+	Bookings      int64
+}
+
+// Utilization is a report in one of its two shapes; only the slice matching GroupBy is set.
+type Utilization struct {
+	Filter        UtilizationFilter
+	Timezone      string
+	Practitioners []PractitionerWeek
+	Services      []ServiceBookings
+}
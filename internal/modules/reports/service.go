@@ -0,0 +1,158 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/ttlcache"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+// MaxRangeDays caps a report's range so a single request cannot scan years of appointments.
+const MaxRangeDays = 92
+
+// cacheTTL is short: managers refreshing a dashboard tolerate a minute of staleness.
+const cacheTTL = time.Minute
+
+type cacheKey struct {
+	clinicID uuid.UUID
+	filter   model.UtilizationFilter
+}
+
+type dashboardKey struct {
+	clinicID uuid.UUID
+	filter   model.DashboardFilter
+}
+
+// defaultService is the concrete implementation of the reports.Service interface.
+type defaultService struct {
+	repo       Repository
+	cache      *ttlcache.Cache[cacheKey, *model.Utilization]
+	dashboards *ttlcache.Cache[dashboardKey, *model.DashboardStats]
+	// now is the service's clock; the dashboard's default range and "today" depend on it.
+	now func() time.Time
+}
+
+// NewService creates a new instance of the reports service.
+func NewService(repo Repository) Service {
+	return &defaultService{
+		repo:       repo,
+		cache:      ttlcache.New[cacheKey, *model.Utilization](cacheTTL),
+		dashboards: ttlcache.New[dashboardKey, *model.DashboardStats](cacheTTL),
+		now:        time.Now,
+	}
+}
+
+// GetUtilization validates the range, then serves the report from the per-clinic cache or the database.
+func (s *defaultService) GetUtilization(ctx context.Context, clinicID uuid.UUID, filter model.UtilizationFilter) (*model.Utilization, error) {
+	if filter.GroupBy == "" {
+		filter.GroupBy = model.GroupByPractitioner
+	}
+	if filter.GroupBy != model.GroupByPractitioner && filter.GroupBy != model.GroupByService {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("'group_by' must be '%s' or '%s'.", model.GroupByPractitioner, model.GroupByService), nil)
+	}
+	if filter.To.Before(filter.From) {
+		return nil, apierror.NewBadRequest("'from' must not be after 'to'.", nil)
+	}
+	if days := int(filter.To.Sub(filter.From).Hours()/24) + 1; days > MaxRangeDays {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("The report range cannot exceed %d days.", MaxRangeDays), nil)
+	}
+
+	key := cacheKey{clinicID: clinicID, filter: filter}
+	if report, ok := s.cache.Get(key); ok {
+		return report, nil
+	}
+
+	timezone, err := s.repo.ClinicTimezone(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	report := &model.Utilization{Filter: filter, Timezone: timezone}
+	switch filter.GroupBy {
+	case model.GroupByPractitioner:
+		report.Practitioners, err = s.repo.PractitionerUtilization(ctx, clinicID, timezone, filter)
+	case model.GroupByService:
+		report.Services, err = s.repo.ServiceBookings(ctx, clinicID, timezone, filter)
+	}
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to compute utilization report: %w", err))
+	}
+
+	s.cache.Set(key, report)
+	return report, nil
+}
+
+// GetDashboardStats serves the stats from the per-clinic cache, or reads the clinic's timezone to
+// resolve the range and today, then runs the counts concurrently. A zero From or To is the first
+// or last day of the current month in the clinic's timezone.
+func (s *defaultService) GetDashboardStats(ctx context.Context, clinicID uuid.UUID, filter model.DashboardFilter) (*model.DashboardStats, error) {
+	key := dashboardKey{clinicID: clinicID, filter: filter}
+	if stats, ok := s.dashboards.Get(key); ok {
+		return stats, nil
+	}
+
+	timezone, err := s.repo.ClinicTimezone(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Warn().Err(err).Str("clinic_id", clinicID.String()).Str("timezone", timezone).Msg("Unknown clinic timezone; using UTC")
+		loc = time.UTC
+	}
+	now := s.now().In(loc)
+	if filter.From.IsZero() {
+		filter.From = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if filter.To.IsZero() {
+		filter.To = time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	}
+	if filter.To.Before(filter.From) {
+		return nil, apierror.NewBadRequest("'from' must not be after 'to'.", nil)
+	}
+	if days := int(filter.To.Sub(filter.From).Hours()/24) + 1; days > MaxRangeDays {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("The dashboard range cannot exceed %d days.", MaxRangeDays), nil)
+	}
+	period := periodOf(filter.From, filter.To, loc)
+	today := periodOf(now, now, loc)
+
+	// Each count writes its own fields, so the goroutines need no lock.
+	stats := &model.DashboardStats{From: filter.From, To: filter.To, Timezone: timezone}
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		stats.PatientsRegistered, err = s.repo.CountPatientsRegistered(gctx, clinicID, period)
+		return err
+	})
+	g.Go(func() (err error) {
+		stats.GuestPatients, stats.RegisteredPatients, err = s.repo.CountPatientsByStatus(gctx, clinicID)
+		return err
+	})
+	g.Go(func() (err error) {
+		stats.AppointmentsToday, err = s.repo.CountAppointments(gctx, clinicID, today)
+		return err
+	})
+	g.Go(func() (err error) {
+		stats.CompletedAppointments, stats.NoShowAppointments, err = s.repo.CountAppointmentOutcomes(gctx, clinicID, period)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to compute dashboard stats: %w", err))
+	}
+
+	s.dashboards.Set(key, stats)
+	return stats, nil
+}
+
+// periodOf returns the instants the calendar dates from through to cover in loc, from midnight
+// starting from until midnight ending to. Only the dates of from and to are used.
+func periodOf(from, to time.Time, loc *time.Location) model.Period {
+	return model.Period{
+		Start: time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc),
+		End:   time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc),
+	}
+}
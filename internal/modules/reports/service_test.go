@@ -0,0 +1,183 @@
+package reports
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/model"
+	"github.com/google/uuid"
+)
+
+// fakeRepo counts the registrations and appointments it holds that fall within the period asked for.
+type fakeRepo struct {
+	Repository
+	timezone      string
+	registrations []time.Time
+	appointments  []time.Time
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeRepo) ClinicTimezone(context.Context, uuid.UUID) (string, error) {
+	return f.timezone, nil
+}
+
+func (f *fakeRepo) CountPatientsRegistered(_ context.Context, _ uuid.UUID, period model.Period) (int64, error) {
+	f.count()
+	return within(f.registrations, period), nil
+}
+
+func (f *fakeRepo) CountPatientsByStatus(context.Context, uuid.UUID) (int64, int64, error) {
+	f.count()
+	return 3, 1, nil
+}
+
+func (f *fakeRepo) CountAppointments(_ context.Context, _ uuid.UUID, period model.Period) (int64, error) {
+	f.count()
+	return within(f.appointments, period), nil
+}
+
+func (f *fakeRepo) CountAppointmentOutcomes(context.Context, uuid.UUID, model.Period) (int64, int64, error) {
+	f.count()
+	return 9, 1, nil
+}
+
+func (f *fakeRepo) count() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+}
+
+func within(instants []time.Time, period model.Period) int64 {
+	var n int64
+	for _, t := range instants {
+		if !t.Before(period.Start) && t.Before(period.End) {
+			n++
+		}
+	}
+	return n
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%s): %v", name, err)
+	}
+	return loc
+}
+
+func newDashboardService(repo *fakeRepo, now time.Time) *defaultService {
+	s := NewService(repo).(*defaultService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestDashboardCountsLateNightRegistrationInItsLocalMonth(t *testing.T) {
+	cairo := mustLocation(t, "Africa/Cairo")
+	// 23:30 on 31 January in Cairo is still January in UTC, but 00:10 on 1 February in Cairo
+	// is too; only the clinic's calendar decides.
+	lastEvening := time.Date(2026, time.January, 31, 23, 30, 0, 0, cairo)
+	nextMorning := time.Date(2026, time.February, 1, 0, 10, 0, 0, cairo)
+	if nextMorning.UTC().Month() != time.January {
+		t.Fatalf("test premise: %s should still be January in UTC", nextMorning.UTC())
+	}
+	repo := &fakeRepo{
+		timezone:      "Africa/Cairo",
+		registrations: []time.Time{lastEvening, nextMorning},
+		appointments:  []time.Time{lastEvening, nextMorning},
+	}
+
+	for _, tc := range []struct {
+		name           string
+		filter         model.DashboardFilter
+		wantFrom       time.Time
+		wantTo         time.Time
+		wantRegistered int64
+	}{
+		{
+			name:           "default range is the current local month",
+			wantFrom:       time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:         time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC),
+			wantRegistered: 1,
+		},
+		{
+			name: "explicit range ends on the last local day",
+			filter: model.DashboardFilter{
+				From: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC),
+			},
+			wantFrom:       time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:         time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC),
+			wantRegistered: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newDashboardService(repo, lastEvening.Add(10*time.Minute))
+			stats, err := s.GetDashboardStats(context.Background(), uuid.New(), tc.filter)
+			if err != nil {
+				t.Fatalf("GetDashboardStats: %v", err)
+			}
+			if !stats.From.Equal(tc.wantFrom) || !stats.To.Equal(tc.wantTo) {
+				t.Errorf("range = %s..%s, want %s..%s", stats.From, stats.To, tc.wantFrom, tc.wantTo)
+			}
+			if stats.PatientsRegistered != tc.wantRegistered {
+				t.Errorf("PatientsRegistered = %d, want %d", stats.PatientsRegistered, tc.wantRegistered)
+			}
+			if stats.AppointmentsToday != 1 {
+				t.Errorf("AppointmentsToday = %d, want only the one on 31 January", stats.AppointmentsToday)
+			}
+		})
+	}
+}
+
+func TestDashboardDefaultsToLocalMonthAfterLocalMidnight(t *testing.T) {
+	cairo := mustLocation(t, "Africa/Cairo")
+	// Shortly after midnight on 1 February in Cairo, UTC is still on 31 January.
+	now := time.Date(2026, time.February, 1, 0, 30, 0, 0, cairo)
+	repo := &fakeRepo{
+		timezone:      "Africa/Cairo",
+		registrations: []time.Time{time.Date(2026, time.January, 31, 23, 30, 0, 0, cairo), now},
+	}
+
+	stats, err := newDashboardService(repo, now).GetDashboardStats(context.Background(), uuid.New(), model.DashboardFilter{})
+	if err != nil {
+		t.Fatalf("GetDashboardStats: %v", err)
+	}
+	if stats.From.Month() != time.February || stats.To.Day() != 28 {
+		t.Errorf("range = %s..%s, want February", stats.From, stats.To)
+	}
+	if stats.PatientsRegistered != 1 {
+		t.Errorf("PatientsRegistered = %d, want only the February one", stats.PatientsRegistered)
+	}
+}
+
+func TestDashboardStatsAreCachedPerClinic(t *testing.T) {
+	repo := &fakeRepo{timezone: "UTC"}
+	s := newDashboardService(repo, time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC))
+	clinicA, clinicB := uuid.New(), uuid.New()
+
+	for _, clinicID := range []uuid.UUID{clinicA, clinicA, clinicB} {
+		if _, err := s.GetDashboardStats(context.Background(), clinicID, model.DashboardFilter{}); err != nil {
+			t.Fatalf("GetDashboardStats: %v", err)
+		}
+	}
+	// Four counts per computation: clinic A once, then from the cache, and clinic B once.
+	if repo.calls != 8 {
+		t.Errorf("repository counted %d times, want 8", repo.calls)
+	}
+}
+
+func TestDashboardRejectsInvertedRange(t *testing.T) {
+	s := newDashboardService(&fakeRepo{timezone: "UTC"}, time.Now())
+	_, err := s.GetDashboardStats(context.Background(), uuid.New(), model.DashboardFilter{
+		From: time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err == nil {
+		t.Fatal("GetDashboardStats accepted 'from' after 'to'")
+	}
+}
@@ -0,0 +1,8 @@
+package store
+
+// Report queries, exported for the plan tests in package store_test, which seed through the
+// fixtures package and so cannot be part of package store.
+const (
+	PractitionerUtilizationQuery = practitionerUtilizationQuery
+	ServiceBookingsQuery         = serviceBookingsQuery
+)
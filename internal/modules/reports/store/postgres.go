@@ -0,0 +1,186 @@
+// Package store provides the database implementation for the reports repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxRepository is the PostgreSQL implementation of the reports.Repository.
+type pgxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPgxRepository creates a new instance of the reports repository.
+func NewPgxRepository(db *pgxpool.Pool) *pgxRepository {
+	return &pgxRepository{db: db}
+}
+
+// bookedAppointments selects the clinic's non-cancelled appointments starting within the
+// clinic-local dates $2 through $3, expressed as a range on start_time so the
+// (clinic_id, start_time) index is used. $4 is the clinic's timezone.
+const bookedAppointments = `
+        SELECT a.*
+        FROM appointments a
+        WHERE a.clinic_id = $1
+          AND a.start_time >= ($2::date::timestamp AT TIME ZONE $4)
+          AND a.start_time < (($3::date + 1)::timestamp AT TIME ZONE $4)
+          AND a.deleted_at IS NULL
+          AND a.status <> 'CANCELLED'`
+
+// practitionerUtilizationQuery sums scheduled and booked minutes per practitioner per week.
+// The schedules are found through the (clinic_id, day_of_week) index.
+const practitionerUtilizationQuery = `
+        WITH available AS (
+            SELECT s.doctor_id, date_trunc('week', d.day)::date AS week_start,
+                   SUM(EXTRACT(EPOCH FROM s.end_time - s.start_time))::bigint / 60 AS minutes
+            FROM generate_series($2::date, $3::date, interval '1 day') AS d(day)
+            JOIN doctor_schedules s ON s.clinic_id = $1 AND s.day_of_week = EXTRACT(DOW FROM d.day)
+            GROUP BY 1, 2
+        ),
+        booked AS (
+            SELECT b.doctor_id, date_trunc('week', b.start_time AT TIME ZONE $4)::date AS week_start,
+                   SUM(EXTRACT(EPOCH FROM b.end_time - b.start_time))::bigint / 60 AS minutes,
+                   COUNT(*) AS bookings
+            FROM (` + bookedAppointments + `) b
+            GROUP BY 1, 2
+        )
+        SELECT p.id, p.full_name, COALESCE(av.week_start, bk.week_start),
+               COALESCE(av.minutes, 0), COALESCE(bk.minutes, 0), COALESCE(bk.bookings, 0)
+        FROM available av
+        FULL JOIN booked bk ON bk.doctor_id = av.doctor_id AND bk.week_start = av.week_start
+        JOIN profiles p ON p.id = COALESCE(av.doctor_id, bk.doctor_id)
+        ORDER BY p.full_name, p.id, 3`
+
+// serviceBookingsQuery counts bookings and booked minutes per service.
+const serviceBookingsQuery = `
+        SELECT b.service_id, COALESCE(s.name, 'No service'),
+               SUM(EXTRACT(EPOCH FROM b.end_time - b.start_time))::bigint / 60, COUNT(*)
+        FROM (` + bookedAppointments + `) b
+        LEFT JOIN services s ON s.id = b.service_id
+        GROUP BY b.service_id, s.name
+        ORDER BY COUNT(*) DESC, 2`
+
+// ClinicTimezone returns the clinic's IANA timezone.
+func (r *pgxRepository) ClinicTimezone(ctx context.Context, clinicID uuid.UUID) (string, error) {
+	var timezone string
+	if err := r.db.QueryRow(ctx, `SELECT timezone FROM clinics WHERE id = $1`, clinicID).Scan(&timezone); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", apierror.NewNotFound("clinic", err)
+		}
+		return "", fmt.Errorf("store.ClinicTimezone: failed to query clinic: %w", err)
+	}
+	return timezone, nil
+}
+
+// PractitionerUtilization sums each practitioner's scheduled minutes over the days in the range
+// and their booked minutes, both per week. Practitioners with only one of the two still appear.
+func (r *pgxRepository) PractitionerUtilization(ctx context.Context, clinicID uuid.UUID, timezone string, filter model.UtilizationFilter) ([]model.PractitionerWeek, error) {
+	rows, err := r.db.Query(ctx, practitionerUtilizationQuery, clinicID, filter.From, filter.To, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("store.PractitionerUtilization: failed to query utilization: %w", err)
+	}
+	defer rows.Close()
+
+	weeks := []model.PractitionerWeek{}
+	for rows.Next() {
+		var w model.PractitionerWeek
+		if err := rows.Scan(&w.PractitionerID, &w.PractitionerName, &w.WeekStart, &w.AvailableMinutes, &w.BookedMinutes, &w.Bookings); err != nil {
+			return nil, fmt.Errorf("store.PractitionerUtilization: failed to scan row: %w", err)
+		}
+		weeks = append(weeks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.PractitionerUtilization: error iterating rows: %w", err)
+	}
+	return weeks, nil
+}
+
+// ServiceBookings counts bookings and booked minutes per service, busiest first.
+func (r *pgxRepository) ServiceBookings(ctx context.Context, clinicID uuid.UUID, timezone string, filter model.UtilizationFilter) ([]model.ServiceBookings, error) {
+	rows, err := r.db.Query(ctx, serviceBookingsQuery, clinicID, filter.From, filter.To, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("store.ServiceBookings: failed to query bookings: %w", err)
+	}
+	defer rows.Close()
+
+	services := []model.ServiceBookings{}
+	for rows.Next() {
+		var sb model.ServiceBookings
+		if err := rows.Scan(&sb.ServiceID, &sb.ServiceName, &sb.BookedMinutes, &sb.Bookings); err != nil {
+			return nil, fmt.Errorf("store.ServiceBookings: failed to scan row: %w", err)
+		}
+		services = append(services, sb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ServiceBookings: error iterating rows: %w", err)
+	}
+	return services, nil
+}
+
+// CountPatientsRegistered counts the patients, archived ones included, whose registration falls
+// within the period.
+func (r *pgxRepository) CountPatientsRegistered(ctx context.Context, clinicID uuid.UUID, period model.Period) (int64, error) {
+	query := `
+        SELECT COUNT(*)
+        FROM profiles p
+        WHERE p.clinic_id = $1 AND p.registered_at >= $2 AND p.registered_at < $3
+          AND NOT EXISTS (SELECT 1 FROM employees e WHERE e.profile_id = p.id)`
+	var count int64
+	if err := r.db.QueryRow(ctx, query, clinicID, period.Start, period.End).Scan(&count); err != nil {
+		return 0, fmt.Errorf("store.CountPatientsRegistered: failed to count patients: %w", err)
+	}
+	return count, nil
+}
+
+// CountPatientsByStatus counts the clinic's active patients that are guests and that are registered.
+func (r *pgxRepository) CountPatientsByStatus(ctx context.Context, clinicID uuid.UUID) (int64, int64, error) {
+	query := `
+        SELECT COUNT(*) FILTER (WHERE p.profile_status = 'GUEST'),
+               COUNT(*) FILTER (WHERE p.profile_status = 'REGISTERED')
+        FROM profiles p
+        WHERE p.clinic_id = $1 AND p.deleted_at IS NULL
+          AND NOT EXISTS (SELECT 1 FROM employees e WHERE e.profile_id = p.id)`
+	var guests, registered int64
+	if err := r.db.QueryRow(ctx, query, clinicID).Scan(&guests, &registered); err != nil {
+		return 0, 0, fmt.Errorf("store.CountPatientsByStatus: failed to count patients: %w", err)
+	}
+	return guests, registered, nil
+}
+
+// CountAppointments counts the appointments starting within the period that were not cancelled.
+func (r *pgxRepository) CountAppointments(ctx context.Context, clinicID uuid.UUID, period model.Period) (int64, error) {
+	query := `
+        SELECT COUNT(*)
+        FROM appointments a
+        WHERE a.clinic_id = $1 AND a.start_time >= $2 AND a.start_time < $3
+          AND a.deleted_at IS NULL AND a.status <> 'CANCELLED'`
+	var count int64
+	if err := r.db.QueryRow(ctx, query, clinicID, period.Start, period.End).Scan(&count); err != nil {
+		return 0, fmt.Errorf("store.CountAppointments: failed to count appointments: %w", err)
+	}
+	return count, nil
+}
+
+// CountAppointmentOutcomes counts the appointments starting within the period that were
+// completed and that the patient did not show up to.
+func (r *pgxRepository) CountAppointmentOutcomes(ctx context.Context, clinicID uuid.UUID, period model.Period) (int64, int64, error) {
+	query := `
+        SELECT COUNT(*) FILTER (WHERE a.status = 'COMPLETED'),
+               COUNT(*) FILTER (WHERE a.status = 'NO_SHOW')
+        FROM appointments a
+        WHERE a.clinic_id = $1 AND a.start_time >= $2 AND a.start_time < $3
+          AND a.deleted_at IS NULL`
+	var completed, noShow int64
+	if err := r.db.QueryRow(ctx, query, clinicID, period.Start, period.End).Scan(&completed, &noShow); err != nil {
+		return 0, 0, fmt.Errorf("store.CountAppointmentOutcomes: failed to count appointments: %w", err)
+	}
+	return completed, noShow, nil
+}
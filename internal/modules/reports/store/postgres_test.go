@@ -0,0 +1,98 @@
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/fixtures"
+)
+
+// planNode is the part of an EXPLAIN (FORMAT JSON) node the tests look at.
+type planNode struct {
+	IndexName string     `json:"Index Name"`
+	Plans     []planNode `json:"Plans"`
+}
+
+// indexesUsed returns the names of the indexes anywhere in the plan.
+func (n planNode) indexesUsed() []string {
+	var names []string
+	if n.IndexName != "" {
+		names = append(names, n.IndexName)
+	}
+	for _, child := range n.Plans {
+		names = append(names, child.indexesUsed()...)
+	}
+	return names
+}
+
+// TestUtilizationQueriesUseTheirIndexes checks the report queries can be answered from the
+// (clinic_id, start_time) appointment index and the (clinic_id, day_of_week) schedule index.
+// A test database is too small for the planner to prefer an index on its own, so sequential
+// scans are switched off: an index the query cannot use still fails the test.
+func TestUtilizationQueriesUseTheirIndexes(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	var clinicIDs []any
+	for range 3 {
+		seeded, err := fixtures.Clinic().WithPractitioners(2).WithPatients(4).WithAppointmentsToday(6).Apply(ctx, pool)
+		if err != nil {
+			t.Fatalf("seed clinic: %v", err)
+		}
+		for _, p := range seeded.Practitioners {
+			_, err := pool.Exec(ctx, `
+                INSERT INTO doctor_schedules (clinic_id, doctor_id, day_of_week, start_time, end_time)
+                SELECT $1, $2, d, '09:00', '17:00' FROM generate_series(0, 6) AS d`, seeded.Clinic.ID, p.ProfileID)
+			if err != nil {
+				t.Fatalf("failed to create working hours: %v", err)
+			}
+		}
+		clinicIDs = append(clinicIDs, seeded.Clinic.ID)
+	}
+	if _, err := pool.Exec(ctx, `ANALYZE appointments, doctor_schedules`); err != nil {
+		t.Fatal(err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"practitioner", store.PractitionerUtilizationQuery, []string{"idx_appointments_clinic_start_time", "idx_doctor_schedules_clinic_day"}},
+		{"service", store.ServiceBookingsQuery, []string{"idx_appointments_clinic_start_time"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := pool.Begin(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback(ctx)
+			if _, err := tx.Exec(ctx, `SET LOCAL enable_seqscan = off`); err != nil {
+				t.Fatal(err)
+			}
+			var plan []struct {
+				Plan planNode `json:"Plan"`
+			}
+			var raw []byte
+			err = tx.QueryRow(ctx, `EXPLAIN (FORMAT JSON) `+tt.query, clinicIDs[0], today.AddDate(0, 0, -7), today.AddDate(0, 0, 7), "Africa/Cairo").Scan(&raw)
+			if err != nil {
+				t.Fatalf("EXPLAIN: %v", err)
+			}
+			if err := json.Unmarshal(raw, &plan); err != nil || len(plan) != 1 {
+				t.Fatalf("failed to decode the plan %s: %v", raw, err)
+			}
+			used := plan[0].Plan.indexesUsed()
+			for _, index := range tt.want {
+				if !slices.Contains(used, index) {
+					t.Errorf("plan uses indexes %v, want %s among them; plan: %s", used, index, raw)
+				}
+			}
+		})
+	}
+}
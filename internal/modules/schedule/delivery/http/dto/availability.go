@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// SlotResponse is one free slot.
+type SlotResponse struct {
+	StartsAt apitime.Time `json:"starts_at"`
+	EndsAt   apitime.Time `json:"ends_at"`
+}
+
+// AvailabilityResponse lists a practitioner's free slots on one day. Timezone is the clinic's,
+// in which Date is read.
+type AvailabilityResponse struct {
+	PractitionerID uuid.UUID      `json:"practitioner_id"`
+	Date           apitime.Date   `json:"date"`
+	Timezone       string         `json:"timezone"`
+	Slots          []SlotResponse `json:"slots"`
+}
@@ -0,0 +1,28 @@
+// Package dto contains the Data Transfer Objects for the schedule module's API contract.
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// WorkingHoursRequest creates or replaces a block of a practitioner's weekly working hours.
+// Times are "HH:MM" wall-clock times in the clinic's timezone.
+type WorkingHoursRequest struct {
+	Weekday     int    `json:"weekday"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	SlotMinutes int    `json:"slot_minutes"`
+}
+
+// WorkingHoursResponse defines the publicly exposed fields of a block of working hours.
+type WorkingHoursResponse struct {
+	ID             uuid.UUID    `json:"id"`
+	PractitionerID uuid.UUID    `json:"practitioner_id"`
+	Weekday        int          `json:"weekday"`
+	StartTime      string       `json:"start_time"`
+	EndTime        string       `json:"end_time"`
+	SlotMinutes    int          `json:"slot_minutes"`
+	CreatedAt      apitime.Time `json:"created_at"`
+	UpdatedAt      apitime.Time `json:"updated_at"`
+}
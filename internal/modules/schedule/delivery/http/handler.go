@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	z "github.com/Oudwins/zog"
+	"github.com/Oudwins/zog/zhttp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler holds the dependencies for the schedule HTTP handlers.
+type Handler struct {
+	service schedule.Service
+}
+
+// NewHandler creates a new schedule handler.
+func NewHandler(service schedule.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListWorkingHours returns a practitioner's weekly working hours.
+func (h *Handler) ListWorkingHours(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	practitionerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid practitioner ID format.", err)
+	}
+
+	hours, err := h.service.ListWorkingHours(c.Request.Context(), payload.ClinicID, practitionerID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toWorkingHoursResponses(hours))
+	return nil
+}
+
+// CreateWorkingHours adds a block of working hours for a practitioner.
+func (h *Handler) CreateWorkingHours(c *gin.Context) *apierror.APIError {
+	req, apiErr := parseWorkingHoursRequest(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	created, err := h.service.CreateWorkingHours(c.Request.Context(), *req)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusCreated, toWorkingHoursResponse(created))
+	return nil
+}
+
+// UpdateWorkingHours replaces a block of a practitioner's working hours.
+func (h *Handler) UpdateWorkingHours(c *gin.Context) *apierror.APIError {
+	hoursID, err := uuid.Parse(c.Param("hoursId"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid working hours ID format.", err)
+	}
+	req, apiErr := parseWorkingHoursRequest(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	updated, err := h.service.UpdateWorkingHours(c.Request.Context(), hoursID, *req)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toWorkingHoursResponse(updated))
+	return nil
+}
+
+// DeleteWorkingHours removes a block of a practitioner's working hours.
+func (h *Handler) DeleteWorkingHours(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	practitionerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid practitioner ID format.", err)
+	}
+	hoursID, err := uuid.Parse(c.Param("hoursId"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid working hours ID format.", err)
+	}
+
+	if err := h.service.DeleteWorkingHours(c.Request.Context(), payload.ClinicID, practitionerID, hoursID); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// GetAvailability returns a practitioner's free slots on a date of the resolved clinic.
+func (h *Handler) GetAvailability(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	practitionerID, err := uuid.Parse(c.Query("practitioner_id"))
+	if err != nil {
+		return apierror.NewBadRequest("The 'practitioner_id' parameter is required and must be a valid ID.", err)
+	}
+	day, err := apitime.ParseDate(c.Query("date"))
+	if err != nil {
+		return apierror.NewBadRequest("The 'date' parameter is required in YYYY-MM-DD format.", err)
+	}
+
+	availability, err := h.service.GetAvailability(c.Request.Context(), clinicID, practitionerID, day.Time())
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toAvailabilityResponse(availability))
+	return nil
+}
+
+// parseWorkingHoursRequest reads the practitioner from the path and the block from the body.
+func parseWorkingHoursRequest(c *gin.Context) (*schedule.WorkingHoursRequest, *apierror.APIError) {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
+	practitionerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, apierror.NewBadRequest("Invalid practitioner ID format.", err)
+	}
+
+	var req dto.WorkingHoursRequest
+	if issues := workingHoursSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return nil, apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	return &schedule.WorkingHoursRequest{
+		ClinicID:       payload.ClinicID,
+		PractitionerID: practitionerID,
+		Weekday:        time.Weekday(req.Weekday),
+		StartMinute:    parseClock(req.StartTime),
+		EndMinute:      parseClock(req.EndTime),
+		SlotMinutes:    req.SlotMinutes,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+)
+
+// parseClock converts a validated "HH:MM" to minutes after midnight.
+func parseClock(s string) int {
+	t, _ := time.Parse("15:04", s)
+	return t.Hour()*60 + t.Minute()
+}
+
+// formatClock converts minutes after midnight to "HH:MM".
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func toWorkingHoursResponse(h *model.WorkingHours) dto.WorkingHoursResponse {
+	return dto.WorkingHoursResponse{
+		ID:             h.ID,
+		PractitionerID: h.PractitionerID,
+		Weekday:        int(h.Weekday),
+		StartTime:      formatClock(h.StartMinute),
+		EndTime:        formatClock(h.EndMinute),
+		SlotMinutes:    h.SlotMinutes,
+		CreatedAt:      apitime.New(h.CreatedAt),
+		UpdatedAt:      apitime.New(h.UpdatedAt),
+	}
+}
+
+func toWorkingHoursResponses(hours []model.WorkingHours) []dto.WorkingHoursResponse {
+	responses := make([]dto.WorkingHoursResponse, len(hours))
+	for i := range hours {
+		responses[i] = toWorkingHoursResponse(&hours[i])
+	}
+	return responses
+}
+
+func toAvailabilityResponse(a *model.Availability) dto.AvailabilityResponse {
+	slots := make([]dto.SlotResponse, len(a.Slots))
+	for i, s := range a.Slots {
+		slots[i] = dto.SlotResponse{StartsAt: apitime.New(s.Start), EndsAt: apitime.New(s.End)}
+	}
+	return dto.AvailabilityResponse{
+		PractitionerID: a.PractitionerID,
+		Date:           apitime.DateOf(a.Date),
+		Timezone:       a.Timezone,
+		Slots:          slots,
+	}
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the schedule module in the router's startup log.
+func (h *Handler) Name() string {
+	return "schedule"
+}
+
+// RegisterProtectedRoutes sets up the staff routes for practitioners' working hours.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	hoursGroup := router.Group("/practitioners/:id/working-hours")
+	{
+		// GET /api/v1/practitioners/:id/working-hours - List the practitioner's weekly working hours
+		hoursGroup.GET("/", middleware.RequirePermission(model.PermissionAppointmentsRead), middleware.ErrorHandler(h.ListWorkingHours))
+		// POST /api/v1/practitioners/:id/working-hours - Add a block of working hours
+		hoursGroup.POST("/", middleware.RequirePermission(model.PermissionSchedulesManage), middleware.ErrorHandler(h.CreateWorkingHours))
+		// PUT /api/v1/practitioners/:id/working-hours/:hoursId - Replace a block of working hours
+		hoursGroup.PUT("/:hoursId", middleware.RequirePermission(model.PermissionSchedulesManage), middleware.ErrorHandler(h.UpdateWorkingHours))
+		// DELETE /api/v1/practitioners/:id/working-hours/:hoursId - Remove a block of working hours
+		hoursGroup.DELETE("/:hoursId", middleware.RequirePermission(model.PermissionSchedulesManage), middleware.ErrorHandler(h.DeleteWorkingHours))
+	}
+}
+
+// RegisterPublicRoutes sets up the unauthenticated availability route. The clinic is resolved
+// from the :slug path parameter.
+func (h *Handler) RegisterPublicRoutes(router *gin.RouterGroup) {
+	// GET /public/clinics/:slug/availability?practitioner_id=&date=YYYY-MM-DD - Free slots on one day
+	router.GET("/clinics/:slug/availability", middleware.AllowQuery("practitioner_id", "date"), middleware.ErrorHandler(h.GetAvailability))
+}
@@ -0,0 +1,18 @@
+package http
+
+import (
+	"regexp"
+
+	z "github.com/Oudwins/zog"
+)
+
+// clockRegex matches a wall-clock time from 00:00 to 23:59.
+var clockRegex = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// Schema for creating or replacing a block of working hours.
+var workingHoursSchema = z.Struct(z.Shape{
+	"weekday":     z.Int().GTE(0, z.Message("weekday must be between 0 (Sunday) and 6 (Saturday).")).LTE(6, z.Message("weekday must be between 0 (Sunday) and 6 (Saturday).")).Required(z.Message("A weekday is required.")),
+	"startTime":   z.String().Trim().Match(clockRegex, z.Message("start_time must be a time such as 09:00.")).Required(z.Message("A start_time is required.")),
+	"endTime":     z.String().Trim().Match(clockRegex, z.Message("end_time must be a time such as 17:30.")).Required(z.Message("An end_time is required.")),
+	"slotMinutes": z.Int().GTE(5, z.Message("slot_minutes must be at least 5.")).LTE(480, z.Message("slot_minutes cannot exceed 480.")).Required(z.Message("A slot_minutes is required.")),
+})
@@ -0,0 +1,53 @@
+// Package schedule contains the business logic for practitioners' weekly working hours and the
+// bookable slots derived from them.
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/interval"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for the schedule module's business logic.
+type Service interface {
+	// ListWorkingHours returns the practitioner's working hours by weekday and start time.
+	ListWorkingHours(ctx context.Context, clinicID, practitionerID uuid.UUID) ([]model.WorkingHours, error)
+	// CreateWorkingHours adds a block of working hours for an active employee. Blocks on the
+	// same weekday may not overlap.
+	CreateWorkingHours(ctx context.Context, req WorkingHoursRequest) (*model.WorkingHours, error)
+	// UpdateWorkingHours replaces the block's weekday, times and slot length.
+	UpdateWorkingHours(ctx context.Context, id uuid.UUID, req WorkingHoursRequest) (*model.WorkingHours, error)
+	// DeleteWorkingHours removes a block of the practitioner's working hours.
+	DeleteWorkingHours(ctx context.Context, clinicID, practitionerID, id uuid.UUID) error
+	// GetAvailability returns the practitioner's free slots on day, a calendar date in the
+	// clinic's timezone, within the clinic's booking notice and horizon.
+	GetAvailability(ctx context.Context, clinicID, practitionerID uuid.UUID, day time.Time) (*model.Availability, error)
+}
+
+// WorkingHoursRequest contains the data for creating or updating a block of working hours.
+type WorkingHoursRequest struct {
+	ClinicID       uuid.UUID
+	PractitionerID uuid.UUID
+	Weekday        time.Weekday
+	StartMinute    int
+	EndMinute      int
+	SlotMinutes    int
+}
+
+// Repository defines the data access contract for working hours.
+type Repository interface {
+	// ListWorkingHours returns the practitioner's working hours; a non-nil weekday narrows them to that day.
+	ListWorkingHours(ctx context.Context, querier database.Querier, clinicID, practitionerID uuid.UUID, weekday *time.Weekday) ([]model.WorkingHours, error)
+	CreateWorkingHours(ctx context.Context, querier database.Querier, hours *model.WorkingHours) error
+	UpdateWorkingHours(ctx context.Context, querier database.Querier, hours *model.WorkingHours) error
+	DeleteWorkingHours(ctx context.Context, querier database.Querier, clinicID, practitionerID, id uuid.UUID) error
+
+	// PractitionerExists reports whether profileID is an active employee of the clinic.
+	PractitionerExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error)
+	// ListBusy returns the practitioner's live appointments overlapping [from, to).
+	ListBusy(ctx context.Context, querier database.Querier, clinicID, practitionerID uuid.UUID, from, to time.Time) ([]interval.Interval, error)
+}
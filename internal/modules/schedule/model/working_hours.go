@@ -0,0 +1,38 @@
+// Package model contains the domain models for practitioners' working hours and availability.
+package model
+
+import (
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/interval"
+	"github.com/google/uuid"
+)
+
+// Permissions checked by the schedule routes. Reading working hours needs appointments.read.
+const (
+	PermissionSchedulesManage  = "schedules.manage"
+	PermissionAppointmentsRead = "appointments.read"
+)
+
+// WorkingHours is one weekly block of a practitioner's time, divided into bookable slots of
+// SlotMinutes from StartMinute on. Times are wall-clock minutes after midnight in the clinic's timezone.
+type WorkingHours struct {
+	ID       uuid.UUID `db:"id"`
+	ClinicID uuid.UUID `db:"clinic_id"`
+	// PractitionerID is the employee's profile.
+	PractitionerID uuid.UUID    `db:"doctor_id"`
+	Weekday        time.Weekday `db:"day_of_week"`
+	StartMinute    int          `db:"start_time"`
+	EndMinute      int          `db:"end_time"`
+	SlotMinutes    int          `db:"slot_minutes"`
+	CreatedAt      time.Time    `db:"created_at"`
+	UpdatedAt      time.Time    `db:"updated_at"`
+}
+
+// Availability lists a practitioner's free slots on one clinic-local day.
+type Availability struct {
+	PractitionerID uuid.UUID
+	Date           time.Time
+	Timezone       string
+	Slots          []interval.Interval
+}
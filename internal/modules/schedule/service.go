@@ -0,0 +1,192 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/interval"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultService is the concrete implementation of the schedule.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo Repository
+	db   database.Querier
+	// clinics supplies the timezone and booking rules availability is computed under.
+	clinics clinic.Service
+}
+
+// NewService creates a new instance of the schedule service.
+func NewService(txManager database.TxManager, repo Repository, db database.Querier, clinics clinic.Service) Service {
+	return &defaultService{
+		BaseService: service.BaseService{Tx: txManager},
+		repo:        repo,
+		db:          db,
+		clinics:     clinics,
+	}
+}
+
+// ListWorkingHours returns the practitioner's working hours.
+func (s *defaultService) ListWorkingHours(ctx context.Context, clinicID, practitionerID uuid.UUID) ([]model.WorkingHours, error) {
+	hours, err := s.repo.ListWorkingHours(ctx, s.db, clinicID, practitionerID, nil)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list working hours: %w", err))
+	}
+	return hours, nil
+}
+
+// CreateWorkingHours checks the block is well-formed and belongs to an active employee, then adds it.
+func (s *defaultService) CreateWorkingHours(ctx context.Context, req WorkingHoursRequest) (*model.WorkingHours, error) {
+	if err := validateWorkingHours(req); err != nil {
+		return nil, err
+	}
+
+	hours := &model.WorkingHours{
+		ID:             uuid.Must(uuid.NewV7()),
+		ClinicID:       req.ClinicID,
+		PractitionerID: req.PractitionerID,
+		Weekday:        req.Weekday,
+		StartMinute:    req.StartMinute,
+		EndMinute:      req.EndMinute,
+		SlotMinutes:    req.SlotMinutes,
+	}
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		ok, err := s.repo.PractitionerExists(ctx, tx, req.ClinicID, req.PractitionerID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return apierror.NewUnprocessable("The practitioner is not an active employee of this clinic.", nil)
+		}
+		return s.repo.CreateWorkingHours(ctx, tx, hours)
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to create working hours: %w", err))
+	}
+	return hours, nil
+}
+
+// UpdateWorkingHours replaces one of the practitioner's blocks.
+func (s *defaultService) UpdateWorkingHours(ctx context.Context, id uuid.UUID, req WorkingHoursRequest) (*model.WorkingHours, error) {
+	if err := validateWorkingHours(req); err != nil {
+		return nil, err
+	}
+
+	hours := &model.WorkingHours{
+		ID:             id,
+		ClinicID:       req.ClinicID,
+		PractitionerID: req.PractitionerID,
+		Weekday:        req.Weekday,
+		StartMinute:    req.StartMinute,
+		EndMinute:      req.EndMinute,
+		SlotMinutes:    req.SlotMinutes,
+	}
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpdateWorkingHours(ctx, tx, hours)
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to update working hours: %w", err))
+	}
+	return hours, nil
+}
+
+// DeleteWorkingHours removes one of the practitioner's blocks.
+func (s *defaultService) DeleteWorkingHours(ctx context.Context, clinicID, practitionerID, id uuid.UUID) error {
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.DeleteWorkingHours(ctx, tx, clinicID, practitionerID, id)
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(fmt.Errorf("failed to delete working hours: %w", err))
+	}
+	return nil
+}
+
+// GetAvailability slices the day's working hours into slots and drops those that overlap a live
+// appointment, start within the clinic's minimum notice, or start past its booking horizon.
+func (s *defaultService) GetAvailability(ctx context.Context, clinicID, practitionerID uuid.UUID, day time.Time) (*model.Availability, error) {
+	clinicSettings, err := s.clinics.GetSettings(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	rules := clinicSettings.BookingRules
+	if !rules.GuestBookingEnabled {
+		return nil, apierror.NewForbidden("This clinic does not accept online bookings.", nil)
+	}
+	loc, err := time.LoadLocation(clinicSettings.Timezone)
+	if err != nil {
+		log.Warn().Err(err).Str("clinic_id", clinicID.String()).Str("timezone", clinicSettings.Timezone).Msg("Unknown clinic timezone; using UTC")
+		loc = time.UTC
+	}
+
+	availability := &model.Availability{
+		PractitionerID: practitionerID,
+		Date:           day,
+		Timezone:       loc.String(),
+		Slots:          []interval.Interval{},
+	}
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+	now := time.Now()
+	earliest := now.Add(time.Duration(rules.MinNoticeMinutes) * time.Minute)
+	latest := now.AddDate(0, 0, rules.MaxAdvanceDays)
+	if !to.After(earliest) || from.After(latest) {
+		return availability, nil
+	}
+
+	weekday := from.Weekday()
+	hours, err := s.repo.ListWorkingHours(ctx, s.db, clinicID, practitionerID, &weekday)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list working hours: %w", err))
+	}
+	if len(hours) == 0 {
+		return availability, nil
+	}
+	busy, err := s.repo.ListBusy(ctx, s.db, clinicID, practitionerID, from, to)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list appointments: %w", err))
+	}
+
+	for _, slot := range freeSlots(candidateSlots(from, loc, hours), busy) {
+		if slot.Start.Before(earliest) || slot.Start.After(latest) {
+			continue
+		}
+		availability.Slots = append(availability.Slots, slot)
+	}
+	return availability, nil
+}
+
+// validateWorkingHours checks the block fits in one day and holds at least one slot.
+func validateWorkingHours(req WorkingHoursRequest) error {
+	if req.Weekday < time.Sunday || req.Weekday > time.Saturday {
+		return apierror.NewBadRequest("The weekday must be between 0 (Sunday) and 6 (Saturday).", nil)
+	}
+	if req.StartMinute < 0 || req.EndMinute >= 24*60 || req.StartMinute >= req.EndMinute {
+		return apierror.NewBadRequest("The working hours must end after they start, on the same day.", nil)
+	}
+	if req.SlotMinutes > req.EndMinute-req.StartMinute {
+		return apierror.NewBadRequest("The slot length cannot exceed the working hours.", nil)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/interval"
+)
+
+// candidateSlots divides the working hours into slots on day, a calendar date read in loc.
+// Slots are laid out in wall-clock time, so a 09:00 slot stays at 09:00 across DST changes.
+// A slot that starts or ends at a local time skipped by a spring-forward transition does not
+// exist that day and is dropped; on a fall-back day a slot spanning the repeated hour lasts
+// an hour longer than its nominal length.
+func candidateSlots(day time.Time, loc *time.Location, hours []model.WorkingHours) []interval.Interval {
+	year, month, date := day.Date()
+	slots := []interval.Interval{}
+	for _, h := range hours {
+		if h.SlotMinutes <= 0 {
+			continue
+		}
+		for minute := h.StartMinute; minute+h.SlotMinutes <= h.EndMinute; minute += h.SlotMinutes {
+			start, ok := wallClock(year, month, date, minute, loc)
+			if !ok {
+				continue
+			}
+			end, ok := wallClock(year, month, date, minute+h.SlotMinutes, loc)
+			if !ok {
+				continue
+			}
+			slots = append(slots, interval.Interval{Start: start, End: end})
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	return slots
+}
+
+// wallClock returns the instant the clock in loc shows minute minutes after midnight on the
+// date, and false when that local time does not occur. Minute 1440 is midnight of the next day.
+func wallClock(year int, month time.Month, day, minute int, loc *time.Location) (time.Time, bool) {
+	t := time.Date(year, month, day, 0, minute, 0, 0, loc)
+	// time.Date normalizes a skipped local time by moving it; reading it back exposes that.
+	want := time.Date(year, month, day, 0, minute, 0, 0, time.UTC)
+	return t, t.Year() == want.Year() && t.YearDay() == want.YearDay() && t.Hour() == want.Hour() && t.Minute() == want.Minute()
+}
+
+// freeSlots keeps the candidates that no busy interval overlaps, even partially.
+func freeSlots(candidates, busy []interval.Interval) []interval.Interval {
+	free := []interval.Interval{}
+	for _, c := range candidates {
+		if !interval.AnyOverlap(c, busy) {
+			free = append(free, c)
+		}
+	}
+	return free
+}
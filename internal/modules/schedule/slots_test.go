@@ -0,0 +1,106 @@
+package schedule
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/interval"
+)
+
+// In New York in 2026 clocks spring forward from 02:00 to 03:00 on 8 March and fall back
+// from 02:00 to 01:00 on 1 November.
+func newYork(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	return loc
+}
+
+func TestWallClock(t *testing.T) {
+	loc := newYork(t)
+	tests := []struct {
+		name   string
+		month  time.Month
+		day    int
+		minute int
+		want   string // UTC; empty when the local time does not occur
+	}{
+		{"ordinary morning", time.March, 7, 9 * 60, "2026-03-07T14:00:00Z"},
+		{"midnight ending the day", time.March, 7, 24 * 60, "2026-03-08T05:00:00Z"},
+		{"before spring forward", time.March, 8, 1*60 + 59, "2026-03-08T06:59:00Z"},
+		{"skipped by spring forward", time.March, 8, 2 * 60, ""},
+		{"inside the skipped hour", time.March, 8, 2*60 + 30, ""},
+		{"after spring forward", time.March, 8, 3 * 60, "2026-03-08T07:00:00Z"},
+		{"spring-forward morning", time.March, 8, 9 * 60, "2026-03-08T13:00:00Z"},
+		{"before fall back", time.November, 1, 0, "2026-11-01T04:00:00Z"},
+		// A repeated local time resolves to its first occurrence, still on daylight time.
+		{"repeated by fall back", time.November, 1, 1*60 + 30, "2026-11-01T05:30:00Z"},
+		{"after fall back", time.November, 1, 2 * 60, "2026-11-01T07:00:00Z"},
+		{"fall-back morning", time.November, 1, 9 * 60, "2026-11-01T14:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := wallClock(2026, tt.month, tt.day, tt.minute, loc)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("wallClock = %v, true; want the local time not to occur", got)
+				}
+				return
+			}
+			if !ok || got.UTC().Format(time.RFC3339) != tt.want {
+				t.Errorf("wallClock = %v, %t; want %s", got.UTC(), ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandidateSlotsAcrossDST(t *testing.T) {
+	loc := newYork(t)
+	smallHours := []model.WorkingHours{{StartMinute: 0, EndMinute: 4 * 60, SlotMinutes: 30}}
+	clinicHours := []model.WorkingHours{{StartMinute: 9 * 60, EndMinute: 11 * 60, SlotMinutes: 60}}
+	tests := []struct {
+		name  string
+		month time.Month
+		day   int
+		hours []model.WorkingHours
+		want  []string // UTC start-end of each slot
+	}{
+		{"ordinary night", time.March, 7, smallHours, []string{
+			"05:00-05:30", "05:30-06:00", "06:00-06:30", "06:30-07:00",
+			"07:00-07:30", "07:30-08:00", "08:00-08:30", "08:30-09:00",
+		}},
+		// 01:30-02:00 ends and 02:00-02:30 and 02:30-03:00 start at a time that is skipped.
+		{"spring-forward night", time.March, 8, smallHours, []string{
+			"05:00-05:30", "05:30-06:00", "06:00-06:30", "07:00-07:30", "07:30-08:00",
+		}},
+		// 01:30-02:00 spans the repeated hour and lasts 90 minutes.
+		{"fall-back night", time.November, 1, smallHours, []string{
+			"04:00-04:30", "04:30-05:00", "05:00-05:30", "05:30-07:00",
+			"07:00-07:30", "07:30-08:00", "08:00-08:30", "08:30-09:00",
+		}},
+		// Daytime slots keep their wall-clock times on either side of a change.
+		{"spring-forward day", time.March, 8, clinicHours, []string{"13:00-14:00", "14:00-15:00"}},
+		{"fall-back day", time.November, 1, clinicHours, []string{"14:00-15:00", "15:00-16:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := time.Date(2026, tt.month, tt.day, 0, 0, 0, 0, loc)
+			got := formatSlots(candidateSlots(day, loc, tt.hours))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("candidateSlots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func formatSlots(slots []interval.Interval) []string {
+	formatted := make([]string, len(slots))
+	for i, s := range slots {
+		formatted[i] = s.Start.UTC().Format("15:04") + "-" + s.End.UTC().Format("15:04")
+	}
+	return formatted
+}
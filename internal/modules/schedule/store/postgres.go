@@ -0,0 +1,170 @@
+// Package store provides the database implementation for the schedule repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/interval"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pgxRepository is the PostgreSQL implementation of the schedule.Repository. Working hours are
+// the doctor_schedules table; TIME columns are read and written as minutes after midnight.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the schedule repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+// workingHoursColumns selects working hours in the order scanWorkingHours reads them.
+const workingHoursColumns = `
+        id, clinic_id, doctor_id, day_of_week,
+        (EXTRACT(EPOCH FROM start_time) / 60)::int, (EXTRACT(EPOCH FROM end_time) / 60)::int,
+        slot_minutes, created_at, updated_at`
+
+func scanWorkingHours(row pgx.Row) (*model.WorkingHours, error) {
+	var (
+		h       model.WorkingHours
+		weekday int
+	)
+	err := row.Scan(&h.ID, &h.ClinicID, &h.PractitionerID, &weekday, &h.StartMinute, &h.EndMinute,
+		&h.SlotMinutes, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	h.Weekday = time.Weekday(weekday)
+	return &h, nil
+}
+
+// overlappingHours is returned when a block would overlap another on the same weekday.
+func overlappingHours(err error) error {
+	return apierror.NewConflict("These working hours overlap another block on the same day.", err).WithCode(apierror.CodeDuplicateResource)
+}
+
+// ListWorkingHours returns the practitioner's working hours ordered by weekday and start time.
+func (r *pgxRepository) ListWorkingHours(ctx context.Context, querier database.Querier, clinicID, practitionerID uuid.UUID, weekday *time.Weekday) ([]model.WorkingHours, error) {
+	query := `SELECT ` + workingHoursColumns + `
+        FROM doctor_schedules
+        WHERE clinic_id = $1 AND doctor_id = $2 AND ($3::int IS NULL OR day_of_week = $3)
+        ORDER BY day_of_week, start_time`
+	// time.Weekday is a Stringer, which pgx would encode as "Monday".
+	var day *int
+	if weekday != nil {
+		d := int(*weekday)
+		day = &d
+	}
+	rows, err := querier.Query(ctx, query, clinicID, practitionerID, day)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListWorkingHours: failed to query working hours: %w", err)
+	}
+	defer rows.Close()
+
+	hours := []model.WorkingHours{}
+	for rows.Next() {
+		h, err := scanWorkingHours(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store.ListWorkingHours: failed to scan working hours: %w", err)
+		}
+		hours = append(hours, *h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListWorkingHours: error iterating rows: %w", err)
+	}
+	return hours, nil
+}
+
+// CreateWorkingHours inserts the block and fills in its timestamps.
+func (r *pgxRepository) CreateWorkingHours(ctx context.Context, querier database.Querier, h *model.WorkingHours) error {
+	query := `
+        INSERT INTO doctor_schedules (id, clinic_id, doctor_id, day_of_week, start_time, end_time, slot_minutes)
+        VALUES ($1, $2, $3, $4, make_interval(mins => $5)::time, make_interval(mins => $6)::time, $7)
+        RETURNING created_at, updated_at`
+	err := querier.QueryRow(ctx, query, h.ID, h.ClinicID, h.PractitionerID, int(h.Weekday), h.StartMinute, h.EndMinute, h.SlotMinutes).
+		Scan(&h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		if _, ok := database.ExclusionViolation(err); ok {
+			return overlappingHours(err)
+		}
+		return fmt.Errorf("store.CreateWorkingHours: failed to insert working hours: %w", err)
+	}
+	return nil
+}
+
+// UpdateWorkingHours saves the block's weekday, times and slot length.
+func (r *pgxRepository) UpdateWorkingHours(ctx context.Context, querier database.Querier, h *model.WorkingHours) error {
+	query := `
+        UPDATE doctor_schedules
+        SET day_of_week = $4, start_time = make_interval(mins => $5)::time, end_time = make_interval(mins => $6)::time, slot_minutes = $7
+        WHERE clinic_id = $1 AND doctor_id = $2 AND id = $3
+        RETURNING created_at, updated_at`
+	err := querier.QueryRow(ctx, query, h.ClinicID, h.PractitionerID, h.ID, int(h.Weekday), h.StartMinute, h.EndMinute, h.SlotMinutes).
+		Scan(&h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewNotFound("working hours", err)
+		}
+		if _, ok := database.ExclusionViolation(err); ok {
+			return overlappingHours(err)
+		}
+		return fmt.Errorf("store.UpdateWorkingHours: failed to update working hours: %w", err)
+	}
+	return nil
+}
+
+// DeleteWorkingHours removes the block.
+func (r *pgxRepository) DeleteWorkingHours(ctx context.Context, querier database.Querier, clinicID, practitionerID, id uuid.UUID) error {
+	tag, err := querier.Exec(ctx, `DELETE FROM doctor_schedules WHERE clinic_id = $1 AND doctor_id = $2 AND id = $3`, clinicID, practitionerID, id)
+	if err != nil {
+		return fmt.Errorf("store.DeleteWorkingHours: failed to delete working hours: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewNotFound("working hours", nil)
+	}
+	return nil
+}
+
+// PractitionerExists reports whether profileID is an active employee of the clinic.
+func (r *pgxRepository) PractitionerExists(ctx context.Context, querier database.Querier, clinicID, profileID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM employees WHERE clinic_id = $1 AND profile_id = $2 AND status = 'ACTIVE')`
+	if err := querier.QueryRow(ctx, query, clinicID, profileID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("store.PractitionerExists: failed to query employee: %w", err)
+	}
+	return exists, nil
+}
+
+// ListBusy returns the practitioner's live appointments overlapping [from, to), by start time.
+// Cancelled appointments no longer hold their slot.
+func (r *pgxRepository) ListBusy(ctx context.Context, querier database.Querier, clinicID, practitionerID uuid.UUID, from, to time.Time) ([]interval.Interval, error) {
+	query := `
+        SELECT start_time, end_time FROM appointments
+        WHERE clinic_id = $1 AND doctor_id = $2 AND deleted_at IS NULL AND status <> 'CANCELLED'
+          AND tstzrange(start_time, end_time) && tstzrange($3, $4)
+        ORDER BY start_time`
+	rows, err := querier.Query(ctx, query, clinicID, practitionerID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListBusy: failed to query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	busy := []interval.Interval{}
+	for rows.Next() {
+		var s interval.Interval
+		if err := rows.Scan(&s.Start, &s.End); err != nil {
+			return nil, fmt.Errorf("store.ListBusy: failed to scan appointment: %w", err)
+		}
+		busy = append(busy, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListBusy: error iterating rows: %w", err)
+	}
+	return busy, nil
+}
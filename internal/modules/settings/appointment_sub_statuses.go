@@ -0,0 +1,207 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CoreAppointmentStatuses are the statuses of the appointment state machine. Sub-statuses only
+// refine one of them for display; transitions and reports always work on the core status.
+var CoreAppointmentStatuses = []string{"SCHEDULED", "CONFIRMED", "CHECKED_IN", "COMPLETED", "CANCELLED", "NO_SHOW"}
+
+// Limits that keep the calendar legend short.
+const (
+	MaxAppointmentSubStatuses = 30
+	MaxSubStatusLabelLength   = 50
+)
+
+// maxSubStatusesInUseReported caps how many blocking sub-statuses a rejected save names.
+const maxSubStatusesInUseReported = 5
+
+// AppointmentSubStatus is a clinic-defined display state such as "Awaiting X-ray".
+// ID is stored on appointments; the label may change freely.
+type AppointmentSubStatus struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	CoreStatus string `json:"core_status"`
+	// Default is applied when an appointment moves into CoreStatus. At most one per core status.
+	Default bool `json:"default"`
+}
+
+// AppointmentSubStatusSettings holds the clinic's sub-statuses in display order.
+type AppointmentSubStatusSettings struct {
+	SubStatuses []AppointmentSubStatus `json:"sub_statuses"`
+}
+
+// AppointmentSubStatuses is the typed accessor for the "appointment_sub_statuses" section.
+// Saving it fails with 409 if it drops a sub-status appointments still use; use
+// Service.DeleteAppointmentSubStatus to move them to a replacement first.
+var AppointmentSubStatuses = register(Section[AppointmentSubStatusSettings]{
+	Name: "appointment_sub_statuses",
+	Defaults: func() AppointmentSubStatusSettings {
+		return AppointmentSubStatusSettings{SubStatuses: []AppointmentSubStatus{}}
+	},
+	Validate:   validateAppointmentSubStatuses,
+	BeforeSave: guardAppointmentSubStatusRemoval,
+})
+
+func validateAppointmentSubStatuses(s AppointmentSubStatusSettings) error {
+	if len(s.SubStatuses) > MaxAppointmentSubStatuses {
+		return fmt.Errorf("at most %d sub-statuses are allowed", MaxAppointmentSubStatuses)
+	}
+
+	seen := make(map[string]bool, len(s.SubStatuses))
+	defaults := make(map[string]string)
+	for _, sub := range s.SubStatuses {
+		if !questionIDRegex.MatchString(sub.ID) {
+			return fmt.Errorf("sub-status id %q must be lowercase letters, digits or underscores", sub.ID)
+		}
+		if seen[sub.ID] {
+			return fmt.Errorf("sub-status id %q is used twice", sub.ID)
+		}
+		seen[sub.ID] = true
+
+		if label := strings.TrimSpace(sub.Label); label == "" || utf8.RuneCountInString(label) > MaxSubStatusLabelLength {
+			return fmt.Errorf("sub-status %q needs a label of 1-%d characters", sub.ID, MaxSubStatusLabelLength)
+		}
+		if !slices.Contains(CoreAppointmentStatuses, sub.CoreStatus) {
+			return fmt.Errorf("sub-status %q must map to one of: %s", sub.ID, strings.Join(CoreAppointmentStatuses, ", "))
+		}
+		if sub.Default {
+			if other, ok := defaults[sub.CoreStatus]; ok {
+				return fmt.Errorf("sub-statuses %q and %q are both the default for %s", other, sub.ID, sub.CoreStatus)
+			}
+			defaults[sub.CoreStatus] = sub.ID
+		}
+	}
+	return nil
+}
+
+// Find returns the sub-status with the given ID.
+func (s AppointmentSubStatusSettings) Find(id string) (AppointmentSubStatus, bool) {
+	i := slices.IndexFunc(s.SubStatuses, func(sub AppointmentSubStatus) bool { return sub.ID == id })
+	if i < 0 {
+		return AppointmentSubStatus{}, false
+	}
+	return s.SubStatuses[i], true
+}
+
+// ValidateSubStatus checks that subStatus exists and refines coreStatus, for an appointment
+// update that sets it explicitly.
+func (s AppointmentSubStatusSettings) ValidateSubStatus(subStatus, coreStatus string) error {
+	sub, ok := s.Find(subStatus)
+	if !ok {
+		msg := fmt.Sprintf("Unknown appointment sub-status '%s'.", subStatus)
+		return apierror.NewBadRequest(msg, errors.New(msg))
+	}
+	if sub.CoreStatus != coreStatus {
+		msg := fmt.Sprintf("Sub-status '%s' belongs to %s, not %s.", sub.Label, sub.CoreStatus, coreStatus)
+		return apierror.NewBadRequest(msg, errors.New(msg))
+	}
+	return nil
+}
+
+// ForTransition returns the sub-status an appointment carries after moving to coreStatus:
+// the current one if it still refines coreStatus, otherwise coreStatus's default, otherwise none.
+func (s AppointmentSubStatusSettings) ForTransition(current *string, coreStatus string) *string {
+	if current != nil {
+		if sub, ok := s.Find(*current); ok && sub.CoreStatus == coreStatus {
+			return current
+		}
+	}
+	i := slices.IndexFunc(s.SubStatuses, func(sub AppointmentSubStatus) bool { return sub.Default && sub.CoreStatus == coreStatus })
+	if i < 0 {
+		return nil
+	}
+	id := s.SubStatuses[i].ID
+	return &id
+}
+
+// guardAppointmentSubStatusRemoval rejects a save that drops sub-statuses still set on appointments.
+func guardAppointmentSubStatusRemoval(ctx context.Context, tx pgx.Tx, repo Repository, clinicID uuid.UUID, previous, next AppointmentSubStatusSettings) error {
+	var removed []string
+	for _, sub := range previous.SubStatuses {
+		if _, ok := next.Find(sub.ID); !ok {
+			removed = append(removed, sub.ID)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	inUse, err := repo.ListAppointmentSubStatusesInUse(ctx, tx, clinicID, removed)
+	if err != nil {
+		return err
+	}
+	if len(inUse) == 0 {
+		return nil
+	}
+	if len(inUse) > maxSubStatusesInUseReported {
+		inUse = inUse[:maxSubStatusesInUseReported]
+	}
+	return apierror.NewConflict(fmt.Sprintf("Sub-statuses still used by appointments cannot be removed without a replacement: %s.", strings.Join(inUse, ", ")), nil)
+}
+
+// DeleteAppointmentSubStatus removes a sub-status, first moving the appointments that carry it
+// to replacementID, which must refine the same core status. The replacement may be empty only
+// if no appointment uses the sub-status.
+func (s *defaultService) DeleteAppointmentSubStatus(ctx context.Context, clinicID uuid.UUID, updatedBy *uuid.UUID, id, replacementID string, expectedVersion int) (*model.SectionRecord, error) {
+	var record *model.SectionRecord
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		stored, err := s.repo.FindSection(ctx, tx, clinicID, AppointmentSubStatuses.Name)
+		if err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return apierror.NewNotFound("appointment sub-status", err)
+			}
+			return err
+		}
+		if stored.Version != expectedVersion {
+			return apierror.NewConflict("These settings were changed by someone else. Reload and try again.", nil)
+		}
+		current, err := AppointmentSubStatuses.decode(stored.Data)
+		if err != nil {
+			return apierror.NewInternalServer(fmt.Errorf("settings: corrupt %q section for clinic %s: %w", AppointmentSubStatuses.Name, clinicID, err))
+		}
+
+		deleted, ok := current.Find(id)
+		if !ok {
+			return apierror.NewNotFound("appointment sub-status", nil)
+		}
+		if replacementID != "" {
+			replacement, ok := current.Find(replacementID)
+			if !ok || replacementID == id {
+				return apierror.NewBadRequest(fmt.Sprintf("Unknown replacement sub-status '%s'.", replacementID), nil)
+			}
+			if replacement.CoreStatus != deleted.CoreStatus {
+				return apierror.NewBadRequest(fmt.Sprintf("The replacement must also belong to %s.", deleted.CoreStatus), nil)
+			}
+			if _, err := s.repo.ReplaceAppointmentSubStatus(ctx, tx, clinicID, id, replacementID); err != nil {
+				return err
+			}
+		}
+
+		current.SubStatuses = slices.DeleteFunc(current.SubStatuses, func(sub AppointmentSubStatus) bool { return sub.ID == id })
+		data, err := json.Marshal(current)
+		if err != nil {
+			return apierror.NewInternalServer(fmt.Errorf("settings: failed to encode %q section: %w", AppointmentSubStatuses.Name, err))
+		}
+		record, err = s.SaveSectionTx(ctx, tx, clinicID, updatedBy, AppointmentSubStatuses.Name, data, expectedVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
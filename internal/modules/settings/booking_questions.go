@@ -0,0 +1,144 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+)
+
+// QuestionType is the input kind of a booking question.
+type QuestionType string
+
+const (
+	QuestionTypeText   QuestionType = "text"
+	QuestionTypeSelect QuestionType = "select"
+)
+
+// Limits that keep the public booking form and the stored answers small.
+const (
+	MaxBookingQuestions    = 5
+	MaxQuestionLabelLength = 200
+	MaxQuestionOptions     = 20
+	MaxTextAnswerLength    = 500
+)
+
+var questionIDRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// BookingQuestion is a custom question guests answer when booking online.
+// ID is a stable key; labels and options may change without affecting stored answers.
+type BookingQuestion struct {
+	ID       string       `json:"id"`
+	Label    string       `json:"label"`
+	Type     QuestionType `json:"type"`
+	Required bool         `json:"required"`
+	Options  []string     `json:"options,omitempty"` // Only for select questions.
+}
+
+// BookingQuestionSettings holds the clinic's booking questions in display order.
+type BookingQuestionSettings struct {
+	Questions []BookingQuestion `json:"questions"`
+}
+
+// BookingAnswer is an answer as stored on the appointment. The question's label and type are
+// snapshotted so historical answers stay readable after the definition changes or is removed.
+type BookingAnswer struct {
+	QuestionID string       `json:"question_id"`
+	Label      string       `json:"label"`
+	Type       QuestionType `json:"type"`
+	Value      string       `json:"value"`
+}
+
+// BookingQuestions is the typed accessor for the "booking_questions" section.
+var BookingQuestions = register(Section[BookingQuestionSettings]{
+	Name: "booking_questions",
+	Defaults: func() BookingQuestionSettings {
+		return BookingQuestionSettings{Questions: []BookingQuestion{}}
+	},
+	Validate: validateBookingQuestions,
+})
+
+func validateBookingQuestions(s BookingQuestionSettings) error {
+	if len(s.Questions) > MaxBookingQuestions {
+		return fmt.Errorf("at most %d booking questions are allowed", MaxBookingQuestions)
+	}
+
+	seen := make(map[string]bool, len(s.Questions))
+	for _, q := range s.Questions {
+		if !questionIDRegex.MatchString(q.ID) {
+			return fmt.Errorf("question id %q must be lowercase letters, digits or underscores", q.ID)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("question id %q is used twice", q.ID)
+		}
+		seen[q.ID] = true
+
+		if label := strings.TrimSpace(q.Label); label == "" || utf8.RuneCountInString(label) > MaxQuestionLabelLength {
+			return fmt.Errorf("question %q needs a label of 1-%d characters", q.ID, MaxQuestionLabelLength)
+		}
+
+		switch q.Type {
+		case QuestionTypeText:
+			if len(q.Options) > 0 {
+				return fmt.Errorf("text question %q cannot have options", q.ID)
+			}
+		case QuestionTypeSelect:
+			if len(q.Options) == 0 || len(q.Options) > MaxQuestionOptions {
+				return fmt.Errorf("select question %q needs 1-%d options", q.ID, MaxQuestionOptions)
+			}
+			if slices.ContainsFunc(q.Options, func(o string) bool { return strings.TrimSpace(o) == "" }) {
+				return fmt.Errorf("select question %q has an empty option", q.ID)
+			}
+		default:
+			return fmt.Errorf("question %q has unknown type %q", q.ID, q.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateAnswers checks submitted answers (keyed by question ID) against the current definitions
+// and returns them in question order, ready to be stored on the appointment.
+// Unknown question IDs are rejected so typos in the widget surface immediately.
+func (s BookingQuestionSettings) ValidateAnswers(submitted map[string]string) ([]BookingAnswer, error) {
+	var problems []string
+	known := make(map[string]bool, len(s.Questions))
+	answers := make([]BookingAnswer, 0, len(s.Questions))
+
+	for _, q := range s.Questions {
+		known[q.ID] = true
+		value := strings.TrimSpace(submitted[q.ID])
+
+		switch {
+		case value == "":
+			if q.Required {
+				problems = append(problems, fmt.Sprintf("'%s' is required", q.Label))
+			}
+			continue
+		case q.Type == QuestionTypeSelect && !slices.Contains(q.Options, value):
+			problems = append(problems, fmt.Sprintf("'%s' must be one of: %s", q.Label, strings.Join(q.Options, ", ")))
+			continue
+		case q.Type == QuestionTypeText && utf8.RuneCountInString(value) > MaxTextAnswerLength:
+			problems = append(problems, fmt.Sprintf("'%s' cannot exceed %d characters", q.Label, MaxTextAnswerLength))
+			continue
+		}
+
+		answers = append(answers, BookingAnswer{QuestionID: q.ID, Label: q.Label, Type: q.Type, Value: value})
+	}
+
+	for id := range submitted {
+		if !known[id] {
+			problems = append(problems, fmt.Sprintf("unknown question '%s'", id))
+		}
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		msg := "Invalid booking answers: " + strings.Join(problems, "; ") + "."
+		return nil, apierror.NewBadRequest(msg, errors.New(msg))
+	}
+	return answers, nil
+}
@@ -0,0 +1,50 @@
+// Package settings owns typed, versioned per-clinic configuration.
+// Other modules read and write settings through this package, never through raw JSONB.
+package settings
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Service defines the contract for the settings module. Callers normally use the
+// typed Section accessors (e.g. BookingRules.Get) rather than these raw methods.
+type Service interface {
+	// GetSection returns the stored section. Version is 0 and Data is nil if it was never saved.
+	GetSection(ctx context.Context, clinicID uuid.UUID, section string) (*model.SectionRecord, error)
+
+	// SaveSection validates and stores a section, failing with 409 if expectedVersion is stale.
+	SaveSection(ctx context.Context, clinicID uuid.UUID, updatedBy *uuid.UUID, section string, data json.RawMessage, expectedVersion int) (*model.SectionRecord, error)
+
+	// SaveSectionTx is SaveSection inside the caller's transaction, for writes that must commit
+	// together with other changes.
+	SaveSectionTx(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, updatedBy *uuid.UUID, section string, data json.RawMessage, expectedVersion int) (*model.SectionRecord, error)
+
+	// DeleteAppointmentSubStatus removes a sub-status definition, moving the appointments that
+	// carry it to replacementID first. It fails with 409 if the sub-status is in use and no
+	// replacement is given.
+	DeleteAppointmentSubStatus(ctx context.Context, clinicID uuid.UUID, updatedBy *uuid.UUID, id, replacementID string, expectedVersion int) (*model.SectionRecord, error)
+
+	// Subscribe registers a callback invoked for every committed settings change.
+	Subscribe(fn func(model.ChangeEvent))
+
+	// Listen relays change notifications from the database to subscribers until ctx is cancelled.
+	Listen(ctx context.Context) error
+}
+
+// Repository defines the data access contract for settings sections.
+type Repository interface {
+	FindSection(ctx context.Context, querier database.Querier, clinicID uuid.UUID, section string) (*model.SectionRecord, error)
+	SaveSection(ctx context.Context, querier database.Querier, record *model.SectionRecord, expectedVersion int) error
+	NotifyChange(ctx context.Context, querier database.Querier, event model.ChangeEvent) error
+
+	// Appointment sub-statuses.
+	// ListAppointmentSubStatusesInUse returns which of ids are set on at least one of the clinic's appointments.
+	ListAppointmentSubStatusesInUse(ctx context.Context, querier database.Querier, clinicID uuid.UUID, ids []string) ([]string, error)
+	ReplaceAppointmentSubStatus(ctx context.Context, querier database.Querier, clinicID uuid.UUID, from, to string) (int64, error)
+}
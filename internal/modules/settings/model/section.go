@@ -0,0 +1,27 @@
+// Package model contains the domain models for the clinic settings module.
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SectionRecord is the stored form of one settings section for one clinic.
+// A zero Version means the clinic has never saved this section and defaults apply.
+type SectionRecord struct {
+	ClinicID  uuid.UUID       `db:"clinic_id"`
+	Section   string          `db:"section"`
+	Data      json.RawMessage `db:"data"`
+	Version   int             `db:"version"`
+	UpdatedBy *uuid.UUID      `db:"updated_by"`
+	UpdatedAt time.Time       `db:"updated_at"`
+}
+
+// ChangeEvent is published whenever a clinic's settings section changes.
+type ChangeEvent struct {
+	ClinicID uuid.UUID `json:"clinic_id"`
+	Section  string    `json:"section"`
+	Version  int       `json:"version"`
+}
@@ -0,0 +1,331 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/phone"
+	z "github.com/Oudwins/zog"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// sectionDef is the type-erased view of a Section used by the service.
+type sectionDef interface {
+	// normalize decodes data over the defaults, validates it and re-encodes it.
+	normalize(data json.RawMessage) (json.RawMessage, error)
+	// beforeSave runs the section's BeforeSave hook against the stored value, if it has one.
+	beforeSave(ctx context.Context, tx pgx.Tx, repo Repository, clinicID uuid.UUID, next json.RawMessage) error
+}
+
+// registry holds every known section keyed by name.
+var registry = map[string]sectionDef{}
+
+// Section is a typed settings section. Values are decoded over Defaults() so keys
+// missing from storage always have a sane value, and are validated with Schema on write.
+type Section[T any] struct {
+	Name     string
+	Defaults func() T
+	Schema   *z.StructSchema // Optional.
+	// Validate runs cross-field checks the schema cannot express. Optional.
+	Validate func(T) error
+	// BeforeSave checks a normalized value against the stored one and the clinic's data,
+	// inside the saving transaction. Optional.
+	BeforeSave func(ctx context.Context, tx pgx.Tx, repo Repository, clinicID uuid.UUID, previous, next T) error
+}
+
+// register adds a section to the registry; it panics on duplicate names since that is a programming error.
+func register[T any](s Section[T]) Section[T] {
+	if _, exists := registry[s.Name]; exists {
+		panic(fmt.Sprintf("settings: section %q registered twice", s.Name))
+	}
+	registry[s.Name] = s
+	return s
+}
+
+// RegisteredSections returns the names of all registered sections in sorted order.
+func RegisteredSections() []string {
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// Normalize decodes data for the named section over its defaults, validates it and re-encodes it,
+// so two payloads with the same effective settings normalize to the same bytes. Nil data yields
+// the defaults.
+func Normalize(section string, data json.RawMessage) (json.RawMessage, error) {
+	def, ok := registry[section]
+	if !ok {
+		return nil, apierror.NewNotFound("settings section", nil)
+	}
+	return def.normalize(data)
+}
+
+// Get returns the clinic's value for this section with defaults applied, plus its version.
+func (s Section[T]) Get(ctx context.Context, svc Service, clinicID uuid.UUID) (T, int, error) {
+	record, err := svc.GetSection(ctx, clinicID, s.Name)
+	if err != nil {
+		var zero T
+		return zero, 0, err
+	}
+
+	value, err := s.decode(record.Data)
+	if err != nil {
+		var zero T
+		return zero, 0, apierror.NewInternalServer(fmt.Errorf("settings: corrupt %q section for clinic %s: %w", s.Name, clinicID, err))
+	}
+	return value, record.Version, nil
+}
+
+// Set validates and stores the clinic's value for this section, returning the new version.
+func (s Section[T]) Set(ctx context.Context, svc Service, clinicID uuid.UUID, updatedBy *uuid.UUID, value T, expectedVersion int) (int, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0, apierror.NewInternalServer(fmt.Errorf("settings: failed to encode %q section: %w", s.Name, err))
+	}
+
+	record, err := svc.SaveSection(ctx, clinicID, updatedBy, s.Name, data, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return record.Version, nil
+}
+
+// SetTx is Set inside the caller's transaction.
+func (s Section[T]) SetTx(ctx context.Context, svc Service, tx pgx.Tx, clinicID uuid.UUID, updatedBy *uuid.UUID, value T, expectedVersion int) (int, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0, apierror.NewInternalServer(fmt.Errorf("settings: failed to encode %q section: %w", s.Name, err))
+	}
+
+	record, err := svc.SaveSectionTx(ctx, tx, clinicID, updatedBy, s.Name, data, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return record.Version, nil
+}
+
+func (s Section[T]) decode(data json.RawMessage) (T, error) {
+	value := s.Defaults()
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return value, err
+		}
+	}
+	return value, nil
+}
+
+func (s Section[T]) normalize(data json.RawMessage) (json.RawMessage, error) {
+	value, err := s.decode(data)
+	if err != nil {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("Invalid '%s' settings payload.", s.Name), err)
+	}
+
+	if s.Schema != nil {
+		if issues := s.Schema.Validate(&value); issues != nil {
+			return nil, apierror.NewBadRequest(fmt.Sprintf("Invalid '%s' settings: %s", s.Name, formatIssues(issues)), nil)
+		}
+	}
+	if s.Validate != nil {
+		if err := s.Validate(value); err != nil {
+			return nil, apierror.NewBadRequest(fmt.Sprintf("Invalid '%s' settings: %s", s.Name, err.Error()), err)
+		}
+	}
+
+	return json.Marshal(value)
+}
+
+func (s Section[T]) beforeSave(ctx context.Context, tx pgx.Tx, repo Repository, clinicID uuid.UUID, next json.RawMessage) error {
+	if s.BeforeSave == nil {
+		return nil
+	}
+
+	var stored json.RawMessage
+	record, err := repo.FindSection(ctx, tx, clinicID, s.Name)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			return err
+		}
+	} else {
+		stored = record.Data
+	}
+
+	previous, err := s.decode(stored)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("settings: corrupt %q section for clinic %s: %w", s.Name, clinicID, err))
+	}
+	value, err := s.decode(next)
+	if err != nil {
+		return apierror.NewInternalServer(fmt.Errorf("settings: failed to decode normalized %q section: %w", s.Name, err))
+	}
+	return s.BeforeSave(ctx, tx, repo, clinicID, previous, value)
+}
+
+// formatIssues renders zog issues as a stable, human-readable sentence.
+func formatIssues(issues z.ZogIssueList) string {
+	flat := z.Issues.Flatten(issues)
+	parts := make([]string, 0, len(flat))
+	for _, field := range slices.Sorted(maps.Keys(flat)) {
+		parts = append(parts, fmt.Sprintf("%s: %s", field, strings.Join(flat[field], "; ")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// --- Registered Sections ---
+
+// BookingRulesSettings controls how and when appointments may be booked.
+type BookingRulesSettings struct {
+	GuestBookingEnabled     bool `json:"guest_booking_enabled"`
+	MinNoticeMinutes        int  `json:"min_notice_minutes"`
+	MaxAdvanceDays          int  `json:"max_advance_days"`
+	CancellationCutoffHours int  `json:"cancellation_cutoff_hours"`
+}
+
+// BookingRules is the typed accessor for the "booking_rules" section.
+var BookingRules = register(Section[BookingRulesSettings]{
+	Name: "booking_rules",
+	Defaults: func() BookingRulesSettings {
+		return BookingRulesSettings{
+			GuestBookingEnabled:     true,
+			MinNoticeMinutes:        60,
+			MaxAdvanceDays:          30,
+			CancellationCutoffHours: 24,
+		}
+	},
+	Schema: z.Struct(z.Shape{
+		"minNoticeMinutes":        z.Int().GTE(0, z.Message("min_notice_minutes cannot be negative.")),
+		"maxAdvanceDays":          z.Int().GTE(1, z.Message("max_advance_days must be at least 1.")).LTE(365, z.Message("max_advance_days cannot exceed 365.")),
+		"cancellationCutoffHours": z.Int().GTE(0, z.Message("cancellation_cutoff_hours cannot be negative.")),
+	}),
+})
+
+// NotificationSettings controls patient-facing notifications.
+type NotificationSettings struct {
+	RemindersEnabled   bool `json:"reminders_enabled"`
+	ReminderLeadHours  int  `json:"reminder_lead_hours"`
+	UseCustomTemplates bool `json:"use_custom_templates"`
+}
+
+// Notifications is the typed accessor for the "notifications" section.
+var Notifications = register(Section[NotificationSettings]{
+	Name: "notifications",
+	Defaults: func() NotificationSettings {
+		return NotificationSettings{
+			RemindersEnabled:  true,
+			ReminderLeadHours: 24,
+		}
+	},
+	Schema: z.Struct(z.Shape{
+		"reminderLeadHours": z.Int().GTE(1, z.Message("reminder_lead_hours must be at least 1.")).LTE(168, z.Message("reminder_lead_hours cannot exceed 168.")),
+	}),
+})
+
+// LocalizationSettings controls the language of patient-facing content.
+type LocalizationSettings struct {
+	// DefaultLanguage is used when neither the patient profile nor the request names a supported language.
+	DefaultLanguage string `json:"default_language"`
+	// DefaultPhoneRegion is the ISO 3166-1 country whose national format locally typed phone numbers are read in.
+	DefaultPhoneRegion string `json:"default_phone_region"`
+}
+
+// Localization is the typed accessor for the "localization" section.
+var Localization = register(Section[LocalizationSettings]{
+	Name: "localization",
+	Defaults: func() LocalizationSettings {
+		return LocalizationSettings{DefaultLanguage: string(locale.Fallback), DefaultPhoneRegion: phone.DefaultRegion}
+	},
+	Schema: z.Struct(z.Shape{
+		"defaultLanguage":    z.String().OneOf(locale.Codes(), z.Message("default_language must be one of: "+strings.Join(locale.Codes(), ", ")+".")),
+		"defaultPhoneRegion": z.String().OneOf(phone.RegionCodes(), z.Message("default_phone_region must be one of: "+strings.Join(phone.RegionCodes(), ", ")+".")),
+	}),
+})
+
+// PhoneRegionResolver looks up a clinic's default phone region from its settings.
+type PhoneRegionResolver struct {
+	svc Service
+}
+
+// NewPhoneRegionResolver creates a PhoneRegionResolver backed by the settings service.
+func NewPhoneRegionResolver(svc Service) *PhoneRegionResolver {
+	return &PhoneRegionResolver{svc: svc}
+}
+
+// DefaultPhoneRegion returns the clinic's configured default phone region.
+func (r *PhoneRegionResolver) DefaultPhoneRegion(ctx context.Context, clinicID uuid.UUID) (string, error) {
+	s, _, err := Localization.Get(ctx, r.svc, clinicID)
+	if err != nil {
+		return "", err
+	}
+	return s.DefaultPhoneRegion, nil
+}
+
+// LanguageResolver looks up a clinic's default content language from its settings.
+type LanguageResolver struct {
+	svc Service
+}
+
+// NewLanguageResolver creates a LanguageResolver backed by the settings service.
+func NewLanguageResolver(svc Service) *LanguageResolver {
+	return &LanguageResolver{svc: svc}
+}
+
+// DefaultLanguage returns the clinic's configured default language.
+func (r *LanguageResolver) DefaultLanguage(ctx context.Context, clinicID uuid.UUID) (locale.Language, error) {
+	s, _, err := Localization.Get(ctx, r.svc, clinicID)
+	if err != nil {
+		return "", err
+	}
+	return locale.Resolve(s.DefaultLanguage), nil
+}
+
+// Identity requirements for automatic guest promotion: which of date of birth and national ID
+// a guest profile needs, on top of a name and phone number, before it is promoted.
+const (
+	IdentityDateOfBirthOrNationalID = "date_of_birth_or_national_id"
+	IdentityDateOfBirth             = "date_of_birth"
+	IdentityNationalID              = "national_id"
+)
+
+// PatientRegistrationSettings controls when guest profiles become registered patients.
+type PatientRegistrationSettings struct {
+	// AutoPromoteGuests promotes a guest to REGISTERED once their profile holds the minimum fields.
+	AutoPromoteGuests   bool   `json:"auto_promote_guests"`
+	IdentityRequirement string `json:"identity_requirement"`
+}
+
+// PatientRegistration is the typed accessor for the "patient_registration" section.
+var PatientRegistration = register(Section[PatientRegistrationSettings]{
+	Name: "patient_registration",
+	Defaults: func() PatientRegistrationSettings {
+		return PatientRegistrationSettings{
+			AutoPromoteGuests:   true,
+			IdentityRequirement: IdentityDateOfBirthOrNationalID,
+		}
+	},
+	Schema: z.Struct(z.Shape{
+		"identityRequirement": z.String().OneOf(
+			[]string{IdentityDateOfBirthOrNationalID, IdentityDateOfBirth, IdentityNationalID},
+			z.Message("identity_requirement must be one of: date_of_birth_or_national_id, date_of_birth, national_id."),
+		),
+	}),
+})
+
+// FeatureFlagSettings holds per-clinic feature toggles.
+type FeatureFlagSettings struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+// FeatureFlags is the typed accessor for the "feature_flags" section.
+var FeatureFlags = register(Section[FeatureFlagSettings]{
+	Name: "feature_flags",
+	Defaults: func() FeatureFlagSettings {
+		return FeatureFlagSettings{Flags: map[string]bool{}}
+	},
+})
@@ -0,0 +1,39 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRegisteredSectionDefaultsRoundTrip(t *testing.T) {
+	sections := RegisteredSections()
+	if len(sections) == 0 {
+		t.Fatal("no settings sections are registered")
+	}
+	for _, name := range sections {
+		t.Run(name, func(t *testing.T) {
+			// Never-saved sections read as their defaults, which must pass their own validation.
+			defaults, err := Normalize(name, nil)
+			if err != nil {
+				t.Fatalf("defaults are invalid: %v", err)
+			}
+			// Saving the defaults and reading them back changes nothing.
+			again, err := Normalize(name, defaults)
+			if err != nil {
+				t.Fatalf("stored defaults are invalid: %v", err)
+			}
+			if !bytes.Equal(again, defaults) {
+				t.Errorf("defaults changed on a round trip:\n got %s\nwant %s", again, defaults)
+			}
+			// A stored empty object, such as a row saved before any key existed, reads as the defaults.
+			empty, err := Normalize(name, json.RawMessage(`{}`))
+			if err != nil {
+				t.Fatalf("an empty section is invalid: %v", err)
+			}
+			if !bytes.Equal(empty, defaults) {
+				t.Errorf("an empty section normalizes to %s, want the defaults %s", empty, defaults)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultService is the concrete implementation of the settings.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo Repository
+	db   *pgxpool.Pool
+
+	mu          sync.RWMutex
+	subscribers []func(model.ChangeEvent)
+}
+
+// NewService creates a new instance of the settings service.
+func NewService(txManager database.TxManager, repo Repository, db *pgxpool.Pool) Service {
+	return &defaultService{
+		BaseService: service.BaseService{Tx: txManager},
+		repo:        repo,
+		db:          db,
+	}
+}
+
+// GetSection returns the stored section, or an empty version-0 record if the clinic never saved it.
+func (s *defaultService) GetSection(ctx context.Context, clinicID uuid.UUID, section string) (*model.SectionRecord, error) {
+	if _, ok := registry[section]; !ok {
+		return nil, apierror.NewNotFound("settings section", nil)
+	}
+
+	record, err := s.repo.FindSection(ctx, s.db, clinicID, section)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return &model.SectionRecord{ClinicID: clinicID, Section: section}, nil
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// SaveSection validates the payload against the section's schema and stores it with optimistic locking.
+// The change notification is queued inside the same transaction so subscribers only hear about committed writes.
+func (s *defaultService) SaveSection(ctx context.Context, clinicID uuid.UUID, updatedBy *uuid.UUID, section string, data json.RawMessage, expectedVersion int) (*model.SectionRecord, error) {
+	var record *model.SectionRecord
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		record, err = s.SaveSectionTx(ctx, tx, clinicID, updatedBy, section, data, expectedVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// SaveSectionTx validates and stores a section inside the caller's transaction.
+func (s *defaultService) SaveSectionTx(ctx context.Context, tx pgx.Tx, clinicID uuid.UUID, updatedBy *uuid.UUID, section string, data json.RawMessage, expectedVersion int) (*model.SectionRecord, error) {
+	normalized, err := Normalize(section, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := registry[section].beforeSave(ctx, tx, s.repo, clinicID, normalized); err != nil {
+		return nil, err
+	}
+
+	record := &model.SectionRecord{
+		ClinicID:  clinicID,
+		Section:   section,
+		Data:      normalized,
+		UpdatedBy: updatedBy,
+	}
+	if err := s.repo.SaveSection(ctx, tx, record, expectedVersion); err != nil {
+		return nil, err
+	}
+	if err := s.repo.NotifyChange(ctx, tx, model.ChangeEvent{
+		ClinicID: clinicID,
+		Section:  section,
+		Version:  record.Version,
+	}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Subscribe registers a callback invoked for every committed settings change.
+func (s *defaultService) Subscribe(fn func(model.ChangeEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Listen holds a dedicated connection on the change channel and fans notifications out to subscribers.
+// It reconnects after transient failures and returns only when ctx is cancelled.
+func (s *defaultService) Listen(ctx context.Context) error {
+	for {
+		err := s.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Warn().Err(err).Msg("settings: change listener disconnected, retrying")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (s *defaultService) listenOnce(ctx context.Context) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire listener connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+store.ChangeChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", store.ChangeChannel, err)
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed waiting for notification: %w", err)
+		}
+
+		var event model.ChangeEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Error().Err(err).Str("payload", notification.Payload).Msg("settings: malformed change notification")
+			continue
+		}
+		s.publish(event)
+	}
+}
+
+func (s *defaultService) publish(event model.ChangeEvent) {
+	s.mu.RLock()
+	subscribers := append([]func(model.ChangeEvent){}, s.subscribers...)
+	s.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
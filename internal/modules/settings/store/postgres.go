@@ -0,0 +1,117 @@
+// Package store provides the database implementation for the clinic settings repository.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ChangeChannel is the Postgres NOTIFY channel on which settings changes are announced.
+const ChangeChannel = "clinic_settings_changed"
+
+// pgxRepository is the PostgreSQL implementation of the settings.Repository.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the settings repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+// FindSection returns the stored section, or a NotFound apierror if the clinic never saved it.
+func (r *pgxRepository) FindSection(ctx context.Context, querier database.Querier, clinicID uuid.UUID, section string) (*model.SectionRecord, error) {
+	record := &model.SectionRecord{}
+	query := `
+        SELECT clinic_id, section, data, version, updated_by, updated_at
+        FROM clinic_settings
+        WHERE clinic_id = $1 AND section = $2
+    `
+	err := querier.QueryRow(ctx, query, clinicID, section).Scan(
+		&record.ClinicID, &record.Section, &record.Data, &record.Version, &record.UpdatedBy, &record.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("settings section", err)
+		}
+		return nil, fmt.Errorf("store.FindSection: failed to query settings: %w", err)
+	}
+	return record, nil
+}
+
+// SaveSection writes the section if its stored version still equals expectedVersion
+// (0 meaning "never saved") and returns the new version. A stale version yields a 409 apierror.
+func (r *pgxRepository) SaveSection(ctx context.Context, querier database.Querier, record *model.SectionRecord, expectedVersion int) error {
+	var query string
+	args := []any{record.ClinicID, record.Section, record.Data, record.UpdatedBy}
+	if expectedVersion == 0 {
+		query = `
+            INSERT INTO clinic_settings (clinic_id, section, data, version, updated_by)
+            VALUES ($1, $2, $3, 1, $4)
+            ON CONFLICT (clinic_id, section) DO NOTHING
+            RETURNING version, updated_at
+        `
+	} else {
+		query = `
+            UPDATE clinic_settings
+            SET data = $3, updated_by = $4, version = version + 1
+            WHERE clinic_id = $1 AND section = $2 AND version = $5
+            RETURNING version, updated_at
+        `
+		args = append(args, expectedVersion)
+	}
+
+	err := querier.QueryRow(ctx, query, args...).Scan(&record.Version, &record.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewConflict("These settings were changed by someone else. Reload and try again.", err)
+		}
+		return fmt.Errorf("store.SaveSection: failed to save settings: %w", err)
+	}
+	return nil
+}
+
+// NotifyChange queues a change notification that Postgres delivers only if the transaction commits.
+func (r *pgxRepository) NotifyChange(ctx context.Context, querier database.Querier, event model.ChangeEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("store.NotifyChange: failed to marshal event: %w", err)
+	}
+	if _, err := querier.Exec(ctx, "SELECT pg_notify($1, $2)", ChangeChannel, string(payload)); err != nil {
+		return fmt.Errorf("store.NotifyChange: failed to notify: %w", err)
+	}
+	return nil
+}
+
+// ListAppointmentSubStatusesInUse returns, sorted, which of ids are set on at least one of the clinic's
+// appointments. Soft-deleted appointments count, since they may be restored.
+func (r *pgxRepository) ListAppointmentSubStatusesInUse(ctx context.Context, querier database.Querier, clinicID uuid.UUID, ids []string) ([]string, error) {
+	query := `
+        SELECT DISTINCT sub_status FROM appointments
+        WHERE clinic_id = $1 AND sub_status = ANY($2)
+        ORDER BY sub_status`
+	rows, err := querier.Query(ctx, query, clinicID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListAppointmentSubStatusesInUse: failed to query appointments: %w", err)
+	}
+	inUse, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("store.ListAppointmentSubStatusesInUse: failed to scan sub-statuses: %w", err)
+	}
+	return inUse, nil
+}
+
+// ReplaceAppointmentSubStatus moves every clinic appointment from one sub-status to another.
+func (r *pgxRepository) ReplaceAppointmentSubStatus(ctx context.Context, querier database.Querier, clinicID uuid.UUID, from, to string) (int64, error) {
+	tag, err := querier.Exec(ctx, `UPDATE appointments SET sub_status = $3 WHERE clinic_id = $1 AND sub_status = $2`, clinicID, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("store.ReplaceAppointmentSubStatus: failed to update appointments: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// CreateUploadRequest declares the file a client is about to upload in chunks.
+type CreateUploadRequest struct {
+	FileName    *string `json:"file_name"`
+	ContentType string  `json:"content_type"`
+	Size        int64   `json:"size"`
+	// Checksum is the hex-encoded SHA-256 of the whole file.
+	Checksum string `json:"checksum"`
+}
+
+// UploadResponse describes an upload session. Clients resume by sending every chunk
+// index from 0 to chunk_count-1 that is missing from received_chunks.
+type UploadResponse struct {
+	ID             uuid.UUID           `json:"id"`
+	FileName       *string             `json:"file_name,omitempty"`
+	ContentType    string              `json:"content_type"`
+	Size           int64               `json:"size"`
+	Checksum       string              `json:"checksum"`
+	ChunkSize      int64               `json:"chunk_size"`
+	ChunkCount     int                 `json:"chunk_count"`
+	ReceivedChunks []int               `json:"received_chunks"`
+	Status         model.SessionStatus `json:"status"`
+	StorageKey     *string             `json:"storage_key,omitempty"`
+	ExpiresAt      apitime.Time        `json:"expires_at"`
+	CompletedAt    *apitime.Time       `json:"completed_at,omitempty"`
+	CreatedAt      apitime.Time        `json:"created_at"`
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	z "github.com/Oudwins/zog"
+	"github.com/Oudwins/zog/zhttp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler holds the dependencies for the upload HTTP handlers.
+type Handler struct {
+	service upload.Service
+}
+
+// NewHandler creates a new upload handler with the given service.
+func NewHandler(service upload.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateUpload opens a chunked upload session for a declared file.
+func (h *Handler) CreateUpload(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var req dto.CreateUploadRequest
+	if issues := createUploadSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	serviceReq := upload.CreateSessionRequest{
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		Checksum:    req.Checksum,
+	}
+
+	session, err := h.service.CreateSession(c.Request.Context(), payload.ClinicID, payload.ActorID(), serviceReq)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusCreated, toUploadResponse(session, nil))
+	return nil
+}
+
+// GetUpload returns an upload session with the chunks received so far, for resuming.
+func (h *Handler) GetUpload(c *gin.Context) *apierror.APIError {
+	payload, sessionID, apiErr := uploadTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	session, received, err := h.service.GetSession(c.Request.Context(), payload.ClinicID, sessionID)
+	if err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toUploadResponse(session, received))
+	return nil
+}
+
+// PutChunk stores one chunk from the raw request body. It answers 201 when the chunk is
+// new and 200 when an identical chunk was already stored, so clients can retry blindly.
+func (h *Handler) PutChunk(c *gin.Context) *apierror.APIError {
+	payload, sessionID, apiErr := uploadTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	n, err := strconv.Atoi(c.Param("n"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid chunk index.", err)
+	}
+
+	created, err := h.service.PutChunk(c.Request.Context(), payload.ClinicID, sessionID, n, c.Request.Body)
+	if err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	if created {
+		c.Status(http.StatusCreated)
+	} else {
+		c.Status(http.StatusOK)
+	}
+	return nil
+}
+
+// CompleteUpload assembles and verifies the file, returning the session with its storage key.
+func (h *Handler) CompleteUpload(c *gin.Context) *apierror.APIError {
+	payload, sessionID, apiErr := uploadTarget(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	session, err := h.service.CompleteSession(c.Request.Context(), payload.ClinicID, sessionID)
+	if err != nil {
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toUploadResponse(session, nil))
+	return nil
+}
+
+// uploadTarget reads the caller and the upload session ID from the request.
+func uploadTarget(c *gin.Context) (*security.AuthPayload, uuid.UUID, *apierror.APIError) {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return nil, uuid.Nil, apierror.NewInternalServer(err)
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, uuid.Nil, apierror.NewBadRequest("Invalid upload ID format.", err)
+	}
+	return payload, sessionID, nil
+}
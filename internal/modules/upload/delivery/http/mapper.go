@@ -0,0 +1,29 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+)
+
+// toUploadResponse maps an upload session and its received chunk indexes to the API representation.
+func toUploadResponse(s *model.Session, received []int) dto.UploadResponse {
+	if received == nil {
+		received = []int{}
+	}
+	return dto.UploadResponse{
+		ID:             s.ID,
+		FileName:       s.FileName,
+		ContentType:    s.ContentType,
+		Size:           s.Size,
+		Checksum:       s.Checksum,
+		ChunkSize:      s.ChunkSize,
+		ChunkCount:     s.ChunkCount(),
+		ReceivedChunks: received,
+		Status:         s.Status,
+		StorageKey:     s.StorageKey,
+		ExpiresAt:      apitime.New(s.ExpiresAt),
+		CompletedAt:    apitime.NewPtr(s.CompletedAt),
+		CreatedAt:      apitime.New(s.CreatedAt),
+	}
+}
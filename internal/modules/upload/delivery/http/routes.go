@@ -0,0 +1,34 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the upload module in the router's startup log.
+func (h *Handler) Name() string {
+	return "upload"
+}
+
+// RegisterPublicRoutes registers nothing: the upload module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for the upload module.
+// Uploads feed patient photos and documents, so they require patient write access.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	uploadGroup := router.Group("/uploads")
+	uploadGroup.Use(middleware.RequireAnyPermission("patients.create", "patients.update"))
+	{
+		// POST /api/v1/uploads - Open an upload session
+		uploadGroup.POST("", middleware.ErrorHandler(h.CreateUpload))
+
+		// GET /api/v1/uploads/:id - Session state and received chunks, for resuming
+		uploadGroup.GET("/:id", middleware.ErrorHandler(h.GetUpload))
+
+		// PUT /api/v1/uploads/:id/chunks/:n - Send chunk n; safe to retry
+		uploadGroup.PUT("/:id/chunks/:n", middleware.ErrorHandler(h.PutChunk))
+
+		// POST /api/v1/uploads/:id/complete - Assemble, verify and store the file
+		uploadGroup.POST("/:id/complete", middleware.ErrorHandler(h.CompleteUpload))
+	}
+}
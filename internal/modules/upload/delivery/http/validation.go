@@ -0,0 +1,18 @@
+package http
+
+import (
+	"regexp"
+
+	z "github.com/Oudwins/zog"
+)
+
+var sha256HexRegex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Schema for the create upload session endpoint. Size and type limits are
+// configuration, so they are enforced by the service rather than here.
+var createUploadSchema = z.Struct(z.Shape{
+	"fileName":    z.String().Trim().Max(255, z.Message("file_name cannot exceed 255 characters.")).Optional(),
+	"contentType": z.String().Trim().Required(z.Message("content_type is required.")),
+	"size":        z.Int64().Required(z.Message("size is required.")),
+	"checksum":    z.String().Trim().Match(sha256HexRegex, z.Message("checksum must be a hex-encoded SHA-256 digest.")).Required(z.Message("checksum is required.")),
+})
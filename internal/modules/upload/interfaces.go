@@ -0,0 +1,69 @@
+// Package upload implements resumable, chunked file uploads. A client opens a session
+// declaring the whole file, sends its chunks in any order (re-sending is safe), then
+// completes the session to receive a storage key other modules can reference the file by.
+package upload
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for the upload module.
+type Service interface {
+	// CreateSession opens an upload session after checking the declared file against the configured limits.
+	CreateSession(ctx context.Context, clinicID, actorID uuid.UUID, req CreateSessionRequest) (*model.Session, error)
+
+	// GetSession returns a session with the indexes of the chunks received so far, so clients can resume.
+	GetSession(ctx context.Context, clinicID, sessionID uuid.UUID) (*model.Session, []int, error)
+
+	// PutChunk stores chunk n. Re-sending an identical chunk succeeds without changes and reports
+	// created as false; sending different content for a stored chunk fails with 409.
+	PutChunk(ctx context.Context, clinicID, sessionID uuid.UUID, n int, body io.Reader) (created bool, err error)
+
+	// CompleteSession assembles the chunks, verifies the declared checksum and returns the
+	// completed session with its storage key. Completing an already completed session is a no-op.
+	CompleteSession(ctx context.Context, clinicID, sessionID uuid.UUID) (*model.Session, error)
+
+	// CleanupExpired removes pending sessions past their expiry together with their stored chunks.
+	CleanupExpired(ctx context.Context) (int, error)
+
+	// Run calls CleanupExpired every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// Repository defines the data access contract for upload sessions and their chunks.
+type Repository interface {
+	CreateSession(ctx context.Context, querier database.Querier, session *model.Session) error
+	FindSession(ctx context.Context, querier database.Querier, clinicID, sessionID uuid.UUID) (*model.Session, error)
+	// LockSession is FindSession with a row lock, serializing chunk writes against completion.
+	LockSession(ctx context.Context, querier database.Querier, clinicID, sessionID uuid.UUID) (*model.Session, error)
+	CompleteSession(ctx context.Context, querier database.Querier, session *model.Session) error
+	DeleteExpiredSessions(ctx context.Context, querier database.Querier, now time.Time, limit int) ([]model.ExpiredSession, error)
+
+	ListChunks(ctx context.Context, querier database.Querier, sessionID uuid.UUID) ([]model.Chunk, error)
+	FindChunk(ctx context.Context, querier database.Querier, sessionID uuid.UUID, n int) (*model.Chunk, error)
+	CreateChunk(ctx context.Context, querier database.Querier, chunk *model.Chunk) error
+	DeleteChunks(ctx context.Context, querier database.Querier, sessionID uuid.UUID) error
+}
+
+// Storage is the file store chunks and assembled files are written to.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// CreateSessionRequest declares the file a client is about to upload.
+type CreateSessionRequest struct {
+	FileName    *string
+	ContentType string
+	Size        int64
+	// Checksum is the hex-encoded SHA-256 of the whole file.
+	Checksum string
+}
@@ -0,0 +1,67 @@
+// Package model contains the domain models for the upload module.
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionStatus is the lifecycle state of an upload session.
+type SessionStatus string
+
+const (
+	SessionStatusPending   SessionStatus = "PENDING"
+	SessionStatusCompleted SessionStatus = "COMPLETED"
+)
+
+// Session is a chunked upload of one file. The client declares the file's size and
+// SHA-256 checksum up front and sends it in ChunkSize pieces, the last one possibly shorter.
+type Session struct {
+	ID          uuid.UUID     `db:"id"`
+	ClinicID    uuid.UUID     `db:"clinic_id"`
+	CreatedBy   uuid.UUID     `db:"created_by"`
+	FileName    *string       `db:"file_name"`
+	ContentType string        `db:"content_type"`
+	Size        int64         `db:"size"`
+	Checksum    string        `db:"checksum"`
+	ChunkSize   int64         `db:"chunk_size"`
+	Status      SessionStatus `db:"status"`
+	StorageKey  *string       `db:"storage_key"`
+	ExpiresAt   time.Time     `db:"expires_at"`
+	CompletedAt *time.Time    `db:"completed_at"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+// ChunkCount is the number of chunks the file is split into.
+func (s *Session) ChunkCount() int {
+	return int((s.Size + s.ChunkSize - 1) / s.ChunkSize)
+}
+
+// ChunkLength is the exact size chunk n must have.
+func (s *Session) ChunkLength(n int) int64 {
+	if n == s.ChunkCount()-1 {
+		return s.Size - int64(n)*s.ChunkSize
+	}
+	return s.ChunkSize
+}
+
+// IsExpired reports whether a pending session can no longer accept chunks.
+func (s *Session) IsExpired(now time.Time) bool {
+	return s.Status == SessionStatusPending && !now.Before(s.ExpiresAt)
+}
+
+// Chunk records one received piece of an upload.
+type Chunk struct {
+	SessionID  uuid.UUID `db:"session_id"`
+	Index      int       `db:"chunk_index"`
+	Size       int64     `db:"size"`
+	Checksum   string    `db:"checksum"`
+	ReceivedAt time.Time `db:"received_at"`
+}
+
+// ExpiredSession identifies a session removed by cleanup, so its stored chunks can be deleted.
+type ExpiredSession struct {
+	ID       uuid.UUID
+	ClinicID uuid.UUID
+}
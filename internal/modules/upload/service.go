@@ -0,0 +1,372 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// cleanupBatchSize bounds how many expired sessions one cleanup statement deletes.
+const cleanupBatchSize = 100
+
+// defaultService is the concrete implementation of the upload.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo    Repository
+	db      *pgxpool.Pool
+	storage Storage
+	cfg     config.UploadConfig
+}
+
+// NewService creates a new instance of the upload service.
+func NewService(txManager database.TxManager, repo Repository, db *pgxpool.Pool, storage Storage, cfg config.UploadConfig) Service {
+	return &defaultService{
+		BaseService: service.BaseService{Tx: txManager},
+		repo:        repo,
+		db:          db,
+		storage:     storage,
+		cfg:         cfg,
+	}
+}
+
+// stagingPrefix is where a session's chunks are kept until it completes or expires.
+func stagingPrefix(clinicID, sessionID uuid.UUID) string {
+	return fmt.Sprintf("uploads/%s/%s", clinicID, sessionID)
+}
+
+// chunkKey includes the chunk's checksum so concurrent writes of different content for
+// the same index never overwrite the file the recorded chunk row points at.
+func chunkKey(session *model.Session, n int, checksum string) string {
+	return fmt.Sprintf("%s/%d-%s", stagingPrefix(session.ClinicID, session.ID), n, checksum)
+}
+
+// fileKey is the permanent storage key of a completed upload.
+func fileKey(session *model.Session) string {
+	return fmt.Sprintf("clinics/%s/files/%s", session.ClinicID, session.ID)
+}
+
+// CreateSession checks the declared file against the configured limits and opens a session.
+func (s *defaultService) CreateSession(ctx context.Context, clinicID, actorID uuid.UUID, req CreateSessionRequest) (*model.Session, error) {
+	if req.Size <= 0 {
+		return nil, apierror.NewBadRequest("The file size must be greater than zero.", nil)
+	}
+	if req.Size > s.cfg.MaxSize {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("The file exceeds the maximum upload size of %d bytes.", s.cfg.MaxSize), nil)
+	}
+
+	contentType, _, err := mime.ParseMediaType(req.ContentType)
+	if err != nil || !slices.Contains(s.cfg.AllowedTypes, contentType) {
+		return nil, apierror.NewBadRequest(fmt.Sprintf("Files of type '%s' cannot be uploaded. Allowed types: %s.", req.ContentType, strings.Join(s.cfg.AllowedTypes, ", ")), err)
+	}
+
+	session := &model.Session{
+		ClinicID:    clinicID,
+		CreatedBy:   actorID,
+		FileName:    req.FileName,
+		ContentType: contentType,
+		Size:        req.Size,
+		Checksum:    strings.ToLower(req.Checksum),
+		ChunkSize:   s.cfg.ChunkSize,
+		ExpiresAt:   time.Now().Add(s.cfg.SessionTTL),
+	}
+	if err := s.repo.CreateSession(ctx, s.db, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetSession returns the session and the indexes of the chunks received so far.
+func (s *defaultService) GetSession(ctx context.Context, clinicID, sessionID uuid.UUID) (*model.Session, []int, error) {
+	session, err := s.repo.FindSession(ctx, s.db, clinicID, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chunks, err := s.repo.ListChunks(ctx, s.db, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	received := make([]int, len(chunks))
+	for i, c := range chunks {
+		received[i] = c.Index
+	}
+	return session, received, nil
+}
+
+// PutChunk stores chunk n. The data is written to storage before the chunk is recorded,
+// so a recorded chunk always has its file; files left behind by failed or losing
+// requests sit under the staging prefix and are removed with it.
+func (s *defaultService) PutChunk(ctx context.Context, clinicID, sessionID uuid.UUID, n int, body io.Reader) (bool, error) {
+	session, err := s.repo.FindSession(ctx, s.db, clinicID, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if err := checkAcceptingChunks(session); err != nil {
+		return false, err
+	}
+	if n < 0 || n >= session.ChunkCount() {
+		return false, apierror.NewBadRequest(fmt.Sprintf("Chunk index must be between 0 and %d.", session.ChunkCount()-1), nil)
+	}
+
+	expected := session.ChunkLength(n)
+	data, err := io.ReadAll(io.LimitReader(body, expected+1))
+	if err != nil {
+		return false, apierror.NewBadRequest("Failed to read the chunk body.", err)
+	}
+	if int64(len(data)) != expected {
+		return false, apierror.NewBadRequest(fmt.Sprintf("Chunk %d must be exactly %d bytes.", n, expected), nil)
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	// Fast path for retries: the chunk is already stored.
+	if created, err := s.matchExistingChunk(ctx, s.db, session.ID, n, checksum); err != nil || !created {
+		return false, err
+	}
+
+	if _, err := s.storage.Put(ctx, chunkKey(session, n, checksum), bytes.NewReader(data)); err != nil {
+		return false, fmt.Errorf("upload.PutChunk: failed to store chunk: %w", err)
+	}
+
+	created := false
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		// Lock the session so the chunk cannot land after a concurrent completion.
+		session, err := s.repo.LockSession(ctx, tx, clinicID, sessionID)
+		if err != nil {
+			return err
+		}
+		if err := checkAcceptingChunks(session); err != nil {
+			return err
+		}
+		if created, err = s.matchExistingChunk(ctx, tx, session.ID, n, checksum); err != nil || !created {
+			return err
+		}
+		return s.repo.CreateChunk(ctx, tx, &model.Chunk{
+			SessionID: session.ID,
+			Index:     n,
+			Size:      expected,
+			Checksum:  checksum,
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+// matchExistingChunk reports whether chunk n still needs to be recorded. An identical
+// stored chunk returns false; a stored chunk with different content is a 409.
+func (s *defaultService) matchExistingChunk(ctx context.Context, querier database.Querier, sessionID uuid.UUID, n int, checksum string) (bool, error) {
+	existing, err := s.repo.FindChunk(ctx, querier, sessionID, n)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return true, nil
+		}
+		return false, err
+	}
+	if existing.Checksum != checksum {
+		return false, apierror.NewConflict(fmt.Sprintf("Chunk %d was already received with different content.", n), nil)
+	}
+	return false, nil
+}
+
+// CompleteSession assembles the chunks into the final file while hashing it, and completes the
+// session only if the result matches the declared size and checksum. On a mismatch the chunks
+// are discarded so the client can upload them again within the same session.
+func (s *defaultService) CompleteSession(ctx context.Context, clinicID, sessionID uuid.UUID) (*model.Session, error) {
+	var (
+		session  *model.Session
+		mismatch bool
+	)
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		session, err = s.repo.LockSession(ctx, tx, clinicID, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status == model.SessionStatusCompleted {
+			return nil
+		}
+		if session.IsExpired(time.Now()) {
+			return errSessionExpired()
+		}
+
+		chunks, err := s.repo.ListChunks(ctx, tx, session.ID)
+		if err != nil {
+			return err
+		}
+		if missing := session.ChunkCount() - len(chunks); missing > 0 {
+			return apierror.NewBadRequest(fmt.Sprintf("%d of %d chunks have not been received yet.", missing, session.ChunkCount()), nil)
+		}
+
+		key := fileKey(session)
+		hasher := sha256.New()
+		assembled := &chunkReader{ctx: ctx, storage: s.storage, session: session, chunks: chunks}
+		defer assembled.Close()
+		written, err := s.storage.Put(ctx, key, io.TeeReader(assembled, hasher))
+		if err != nil {
+			return fmt.Errorf("upload.CompleteSession: failed to assemble file: %w", err)
+		}
+
+		if written != session.Size || hex.EncodeToString(hasher.Sum(nil)) != session.Checksum {
+			mismatch = true
+			if err := s.storage.Delete(ctx, key); err != nil {
+				log.Error().Err(err).Str("upload_session_id", session.ID.String()).Msg("Failed to delete mismatched upload")
+			}
+		} else {
+			session.StorageKey = &key
+			if err := s.repo.CompleteSession(ctx, tx, session); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.DeleteChunks(ctx, tx, session.ID); err != nil {
+			return err
+		}
+		s.AfterCommit(tx, func() { s.deleteStaged(context.WithoutCancel(ctx), session.ClinicID, session.ID) })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if mismatch {
+		return nil, apierror.NewBadRequest("The uploaded file does not match the declared checksum. Upload the chunks again.", nil)
+	}
+	return session, nil
+}
+
+// CleanupExpired deletes expired pending sessions in batches, then their staged chunks.
+func (s *defaultService) CleanupExpired(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		expired, err := s.repo.DeleteExpiredSessions(ctx, s.db, time.Now(), cleanupBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, e := range expired {
+			s.deleteStaged(ctx, e.ClinicID, e.ID)
+		}
+		total += len(expired)
+		if len(expired) < cleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// Run removes expired sessions every interval until ctx is cancelled.
+func (s *defaultService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.cleanup(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+func (s *defaultService) cleanup(ctx context.Context) {
+	deleted, err := s.CleanupExpired(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("upload cleanup: failed to delete expired sessions")
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int("deleted", deleted).Msg("upload cleanup: deleted expired sessions")
+	}
+}
+
+// deleteStaged removes a session's staged chunk files. Failures are only logged: the
+// files are unreachable once the rows are gone and cost nothing but disk space.
+func (s *defaultService) deleteStaged(ctx context.Context, clinicID, sessionID uuid.UUID) {
+	if err := s.storage.DeletePrefix(ctx, stagingPrefix(clinicID, sessionID)); err != nil {
+		log.Error().Err(err).Str("upload_session_id", sessionID.String()).Msg("Failed to delete staged upload chunks")
+	}
+}
+
+func errSessionExpired() error {
+	return apierror.NewConflict("This upload session has expired. Start a new upload.", nil)
+}
+
+// checkAcceptingChunks rejects chunks for completed or expired sessions.
+func checkAcceptingChunks(session *model.Session) error {
+	if session.Status == model.SessionStatusCompleted {
+		return apierror.NewConflict("This upload has already been completed.", nil)
+	}
+	if session.IsExpired(time.Now()) {
+		return errSessionExpired()
+	}
+	return nil
+}
+
+// chunkReader reads a session's chunks from storage back to back, in index order.
+type chunkReader struct {
+	ctx     context.Context
+	storage Storage
+	session *model.Session
+	chunks  []model.Chunk
+	current io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+			c := r.chunks[0]
+			r.chunks = r.chunks[1:]
+			f, err := r.storage.Open(r.ctx, chunkKey(r.session, c.Index, c.Checksum))
+			if err != nil {
+				return 0, fmt.Errorf("opening chunk %d: %w", c.Index, err)
+			}
+			r.current = f
+		}
+
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			return n, nil
+		}
+		return n, err
+	}
+}
+
+// Close releases the chunk currently being read, if any.
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
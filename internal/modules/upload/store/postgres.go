@@ -0,0 +1,199 @@
+// Package store provides the database implementation for the upload repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const sessionColumns = `id, clinic_id, created_by, file_name, content_type, size, checksum, chunk_size,
+        status, storage_key, expires_at, completed_at, created_at`
+
+// pgxRepository is the PostgreSQL implementation of the upload.Repository.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the upload repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+func scanSession(row pgx.Row) (*model.Session, error) {
+	s := &model.Session{}
+	err := row.Scan(
+		&s.ID, &s.ClinicID, &s.CreatedBy, &s.FileName, &s.ContentType, &s.Size, &s.Checksum, &s.ChunkSize,
+		&s.Status, &s.StorageKey, &s.ExpiresAt, &s.CompletedAt, &s.CreatedAt,
+	)
+	return s, err
+}
+
+// CreateSession inserts a new upload session and fills in its generated fields.
+func (r *pgxRepository) CreateSession(ctx context.Context, querier database.Querier, session *model.Session) error {
+	query := `
+        INSERT INTO upload_sessions (clinic_id, created_by, file_name, content_type, size, checksum, chunk_size, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, status, created_at
+    `
+	err := querier.QueryRow(ctx, query,
+		session.ClinicID, session.CreatedBy, session.FileName, session.ContentType,
+		session.Size, session.Checksum, session.ChunkSize, session.ExpiresAt,
+	).Scan(&session.ID, &session.Status, &session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("store.CreateSession: failed to insert upload session: %w", err)
+	}
+	return nil
+}
+
+// FindSession returns the clinic's upload session, or a NotFound apierror.
+func (r *pgxRepository) FindSession(ctx context.Context, querier database.Querier, clinicID, sessionID uuid.UUID) (*model.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM upload_sessions WHERE id = $1 AND clinic_id = $2`
+	session, err := scanSession(querier.QueryRow(ctx, query, sessionID, clinicID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("upload session", err)
+		}
+		return nil, fmt.Errorf("store.FindSession: failed to query upload session: %w", err)
+	}
+	return session, nil
+}
+
+// LockSession is FindSession with a FOR UPDATE row lock; it must run inside a transaction.
+func (r *pgxRepository) LockSession(ctx context.Context, querier database.Querier, clinicID, sessionID uuid.UUID) (*model.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM upload_sessions WHERE id = $1 AND clinic_id = $2 FOR UPDATE`
+	session, err := scanSession(querier.QueryRow(ctx, query, sessionID, clinicID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("upload session", err)
+		}
+		return nil, fmt.Errorf("store.LockSession: failed to lock upload session: %w", err)
+	}
+	return session, nil
+}
+
+// CompleteSession marks a pending session completed with its storage key.
+func (r *pgxRepository) CompleteSession(ctx context.Context, querier database.Querier, session *model.Session) error {
+	query := `
+        UPDATE upload_sessions
+        SET status = 'COMPLETED', storage_key = $2, completed_at = NOW()
+        WHERE id = $1 AND status = 'PENDING'
+        RETURNING status, completed_at
+    `
+	err := querier.QueryRow(ctx, query, session.ID, session.StorageKey).Scan(&session.Status, &session.CompletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewConflict("This upload has already been completed.", err)
+		}
+		return fmt.Errorf("store.CompleteSession: failed to complete upload session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredSessions deletes up to limit pending sessions that expired at or before now,
+// along with their chunk rows, and returns what was deleted. Locked rows are skipped so
+// cleanup never waits on, or races, an in-flight completion.
+func (r *pgxRepository) DeleteExpiredSessions(ctx context.Context, querier database.Querier, now time.Time, limit int) ([]model.ExpiredSession, error) {
+	query := `
+        DELETE FROM upload_sessions
+        WHERE id IN (
+            SELECT id FROM upload_sessions
+            WHERE status = 'PENDING' AND expires_at <= $1
+            ORDER BY expires_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, clinic_id
+    `
+	rows, err := querier.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("store.DeleteExpiredSessions: failed to delete upload sessions: %w", err)
+	}
+	defer rows.Close()
+
+	expired := []model.ExpiredSession{}
+	for rows.Next() {
+		var e model.ExpiredSession
+		if err := rows.Scan(&e.ID, &e.ClinicID); err != nil {
+			return nil, fmt.Errorf("store.DeleteExpiredSessions: failed to scan upload session: %w", err)
+		}
+		expired = append(expired, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.DeleteExpiredSessions: failed to read upload sessions: %w", err)
+	}
+	return expired, nil
+}
+
+// ListChunks returns the session's received chunks ordered by index.
+func (r *pgxRepository) ListChunks(ctx context.Context, querier database.Querier, sessionID uuid.UUID) ([]model.Chunk, error) {
+	query := `
+        SELECT session_id, chunk_index, size, checksum, received_at
+        FROM upload_chunks
+        WHERE session_id = $1
+        ORDER BY chunk_index
+    `
+	rows, err := querier.Query(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListChunks: failed to query upload chunks: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := []model.Chunk{}
+	for rows.Next() {
+		var c model.Chunk
+		if err := rows.Scan(&c.SessionID, &c.Index, &c.Size, &c.Checksum, &c.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("store.ListChunks: failed to scan upload chunk: %w", err)
+		}
+		chunks = append(chunks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListChunks: failed to read upload chunks: %w", err)
+	}
+	return chunks, nil
+}
+
+// FindChunk returns chunk n of the session, or a NotFound apierror if it has not been received.
+func (r *pgxRepository) FindChunk(ctx context.Context, querier database.Querier, sessionID uuid.UUID, n int) (*model.Chunk, error) {
+	c := &model.Chunk{}
+	query := `
+        SELECT session_id, chunk_index, size, checksum, received_at
+        FROM upload_chunks
+        WHERE session_id = $1 AND chunk_index = $2
+    `
+	err := querier.QueryRow(ctx, query, sessionID, n).Scan(&c.SessionID, &c.Index, &c.Size, &c.Checksum, &c.ReceivedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("upload chunk", err)
+		}
+		return nil, fmt.Errorf("store.FindChunk: failed to query upload chunk: %w", err)
+	}
+	return c, nil
+}
+
+// CreateChunk records a received chunk.
+func (r *pgxRepository) CreateChunk(ctx context.Context, querier database.Querier, chunk *model.Chunk) error {
+	query := `
+        INSERT INTO upload_chunks (session_id, chunk_index, size, checksum)
+        VALUES ($1, $2, $3, $4)
+        RETURNING received_at
+    `
+	err := querier.QueryRow(ctx, query, chunk.SessionID, chunk.Index, chunk.Size, chunk.Checksum).Scan(&chunk.ReceivedAt)
+	if err != nil {
+		return fmt.Errorf("store.CreateChunk: failed to insert upload chunk: %w", err)
+	}
+	return nil
+}
+
+// DeleteChunks removes every chunk row of the session.
+func (r *pgxRepository) DeleteChunks(ctx context.Context, querier database.Querier, sessionID uuid.UUID) error {
+	if _, err := querier.Exec(ctx, `DELETE FROM upload_chunks WHERE session_id = $1`, sessionID); err != nil {
+		return fmt.Errorf("store.DeleteChunks: failed to delete upload chunks: %w", err)
+	}
+	return nil
+}
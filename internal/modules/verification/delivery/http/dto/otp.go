@@ -0,0 +1,25 @@
+package dto
+
+import "github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+
+// RequestCodeRequest asks for a verification code to be texted to a phone number.
+type RequestCodeRequest struct {
+	PhoneNumber string `json:"phone_number"`
+}
+
+// RequestCodeResponse acknowledges a texted code. The code itself is only ever sent by SMS.
+type RequestCodeResponse struct {
+	ExpiresAt apitime.Time `json:"expires_at"`
+}
+
+// VerifyCodeRequest submits the code texted to a phone number.
+type VerifyCodeRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Code        string `json:"code"`
+}
+
+// VerifyCodeResponse carries the phone_verified token public booking requires.
+type VerifyCodeResponse struct {
+	PhoneVerificationToken string       `json:"phone_verification_token"`
+	ExpiresAt              apitime.Time `json:"expires_at"`
+}
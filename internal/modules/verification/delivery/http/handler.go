@@ -0,0 +1,83 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	z "github.com/Oudwins/zog"
+	"github.com/Oudwins/zog/zhttp"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler holds the dependencies for the verification HTTP handlers.
+type Handler struct {
+	service verification.Service
+}
+
+// NewHandler creates a new verification handler.
+func NewHandler(service verification.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RequestCode texts a verification code to the guest's phone in the request's language.
+func (h *Handler) RequestCode(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var req dto.RequestCodeRequest
+	if issues := requestCodeSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	lang, ok := middleware.GetLocale(c.Request.Context())
+	if !ok {
+		lang = locale.Fallback
+	}
+	sent, err := h.service.RequestCode(c.Request.Context(), clinicID, req.PhoneNumber, lang)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusAccepted, dto.RequestCodeResponse{ExpiresAt: apitime.New(sent.ExpiresAt)})
+	return nil
+}
+
+// VerifyCode exchanges a texted code for the token that lets the guest book with the phone number.
+func (h *Handler) VerifyCode(c *gin.Context) *apierror.APIError {
+	clinicID, err := middleware.GetClinicID(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	var req dto.VerifyCodeRequest
+	if issues := verifyCodeSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	verified, err := h.service.VerifyCode(c.Request.Context(), clinicID, req.PhoneNumber, req.Code)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, dto.VerifyCodeResponse{
+		PhoneVerificationToken: verified.Token,
+		ExpiresAt:              apitime.New(verified.ExpiresAt),
+	})
+	return nil
+}
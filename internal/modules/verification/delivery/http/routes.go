@@ -0,0 +1,26 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the verification module in the router's startup log.
+func (h *Handler) Name() string {
+	return "verification"
+}
+
+// RegisterProtectedRoutes registers nothing: the verification module has no staff routes.
+func (h *Handler) RegisterProtectedRoutes(*gin.RouterGroup) {}
+
+// RegisterPublicRoutes sets up the unauthenticated phone verification routes. The router resolves
+// the clinic and the request's language before these run.
+func (h *Handler) RegisterPublicRoutes(router *gin.RouterGroup) {
+	otpGroup := router.Group("/otp")
+	{
+		// POST /public/otp/request - Text a 6-digit code to the guest's phone
+		otpGroup.POST("/request", middleware.ErrorHandler(h.RequestCode))
+		// POST /public/otp/verify - Exchange the code for a phone_verified token for booking
+		otpGroup.POST("/verify", middleware.ErrorHandler(h.VerifyCode))
+	}
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"regexp"
+
+	z "github.com/Oudwins/zog"
+)
+
+// Guests type their own number, so it must be in international form, as booking requires.
+var (
+	e164Regex = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	codeRegex = regexp.MustCompile(`^\d{6}$`)
+)
+
+// Schema for texting a verification code to a guest.
+var requestCodeSchema = z.Struct(z.Shape{
+	"phoneNumber": z.String().Trim().Match(e164Regex, z.Message("A valid E.164 phone number is required, e.g. +201012345678.")).Required(z.Message("A phone_number is required.")),
+})
+
+// Schema for entering a texted code.
+var verifyCodeSchema = z.Struct(z.Shape{
+	"phoneNumber": z.String().Trim().Match(e164Regex, z.Message("A valid E.164 phone number is required, e.g. +201012345678.")).Required(z.Message("A phone_number is required.")),
+	"code":        z.String().Trim().Match(codeRegex, z.Message("The code must be 6 digits.")).Required(z.Message("A code is required.")),
+})
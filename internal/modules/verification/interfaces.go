@@ -0,0 +1,66 @@
+// Package verification proves that a guest booking online holds the phone number they book with.
+// A six-digit code is texted to the number; entering it yields a short-lived, single-use
+// phone_verified token that public booking requires.
+package verification
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for the verification module.
+type Service interface {
+	// RequestCode texts a new code to phoneNumber in lang, replacing any code sent before. A phone
+	// number gets a limited number of codes per window; further requests are rejected with 429.
+	RequestCode(ctx context.Context, clinicID uuid.UUID, phoneNumber string, lang locale.Language) (*CodeRequest, error)
+	// VerifyCode checks the latest code sent to phoneNumber and, if it matches, returns a
+	// phone_verified token for the number. Each wrong code counts against the code's attempts;
+	// an expired, locked or already used code is rejected.
+	VerifyCode(ctx context.Context, clinicID uuid.UUID, phoneNumber, code string) (*VerifiedPhone, error)
+	// ConsumeToken checks that token is a phone_verified token for phoneNumber in the clinic and
+	// marks it used. Called with a context from database.WithTx, the token is only used up if
+	// that transaction commits. A token that was already used is rejected with 401.
+	ConsumeToken(ctx context.Context, clinicID uuid.UUID, token, phoneNumber string) error
+	// CleanupExpired deletes verifications whose code and token have both expired.
+	CleanupExpired(ctx context.Context) (int, error)
+	// Run calls CleanupExpired every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// CodeRequest acknowledges a texted code.
+type CodeRequest struct {
+	ExpiresAt time.Time
+}
+
+// VerifiedPhone carries the token that proves the guest holds the phone number.
+type VerifiedPhone struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Repository defines the data access contract for phone verifications.
+type Repository interface {
+	// LockPhone serializes code requests for one phone number in the clinic for the rest of the transaction.
+	LockPhone(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string) error
+	// CountRequestedSince counts the codes sent to the phone number since since, and returns
+	// when the oldest of them was sent.
+	CountRequestedSince(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string, since time.Time) (int, time.Time, error)
+	// ExpireOpen expires the phone number's unverified codes, so only the newest can be entered.
+	ExpireOpen(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string, now time.Time) error
+	Create(ctx context.Context, querier database.Querier, verification *model.PhoneVerification) error
+	// FindLatestForUpdate returns and locks the newest code sent to the phone number.
+	FindLatestForUpdate(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string) (*model.PhoneVerification, error)
+	// RecordFailedAttempt increments the verification's Attempts.
+	RecordFailedAttempt(ctx context.Context, querier database.Querier, verification *model.PhoneVerification) error
+	// MarkVerified saves the verification's VerifiedAt, TokenID and TokenExpiresAt.
+	MarkVerified(ctx context.Context, querier database.Querier, verification *model.PhoneVerification) error
+	// ConsumeToken marks the token used and reports whether it was still unused.
+	ConsumeToken(ctx context.Context, querier database.Querier, clinicID, tokenID uuid.UUID, now time.Time) (bool, error)
+	// DeleteExpired deletes up to limit verifications whose code and token both expired before now.
+	DeleteExpired(ctx context.Context, querier database.Querier, now time.Time, limit int) (int, error)
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneVerification is a one-time code texted to a guest's phone, and the token issued once the
+// guest entered it.
+type PhoneVerification struct {
+	ID          uuid.UUID
+	ClinicID    uuid.UUID
+	PhoneNumber string
+	CodeHash    string
+	// Attempts counts the wrong codes entered so far.
+	Attempts   int
+	ExpiresAt  time.Time
+	VerifiedAt *time.Time
+	// TokenID is the jti of the phone_verified token issued on verification.
+	TokenID        *uuid.UUID
+	TokenExpiresAt *time.Time
+	// ConsumedAt is set when a booking used the token.
+	ConsumedAt *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,304 @@
+package verification
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	sharedNotification "github.com/Ebrahim-hamdy/mastara-saas/internal/shared/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// codeHashPurpose derives the key codes are hashed with from the server's secret.
+const codeHashPurpose = "phone-verification-code"
+
+// codeSendTimeout bounds one SMS; the phone number stays locked while it is sent.
+const codeSendTimeout = 10 * time.Second
+
+// cleanupBatchSize bounds how many expired verifications one cleanup statement deletes.
+const cleanupBatchSize = 500
+
+// defaultService is the concrete implementation of the verification.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo     Repository
+	notifier notification.Notifier
+	sec      *security.PasetoManager
+	// hashKey keys the HMAC codes are stored as.
+	hashKey       []byte
+	booking       config.BookingConfig
+	tokenDuration time.Duration
+	// now is the service's clock.
+	now func() time.Time
+}
+
+// NewService creates a new instance of the verification service that texts codes through notifier.
+func NewService(txManager database.TxManager, repo Repository, notifier notification.Notifier, sec *security.PasetoManager, config *config.Config) Service {
+	return &defaultService{
+		BaseService:   service.BaseService{Tx: txManager},
+		repo:          repo,
+		notifier:      notifier,
+		sec:           sec,
+		hashKey:       security.DeriveKey([]byte(config.Security.PasetoKey), codeHashPurpose),
+		booking:       config.Booking,
+		tokenDuration: config.Security.PhoneTokenDuration,
+		now:           time.Now,
+	}
+}
+
+// RequestCode texts a new code within the transaction that records it, so a code that could not
+// be sent is neither stored nor counted against the phone number's limit.
+func (s *defaultService) RequestCode(ctx context.Context, clinicID uuid.UUID, phoneNumber string, lang locale.Language) (*CodeRequest, error) {
+	var request *CodeRequest
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.repo.LockPhone(ctx, tx, clinicID, phoneNumber); err != nil {
+			return err
+		}
+		now := s.now()
+		sent, oldest, err := s.repo.CountRequestedSince(ctx, tx, clinicID, phoneNumber, now.Add(-s.booking.OTPRequestWindow))
+		if err != nil {
+			return err
+		}
+		if sent >= s.booking.OTPRequestLimit {
+			retryAfter := oldest.Add(s.booking.OTPRequestWindow).Sub(now)
+			return apierror.NewTooManyRequests("Too many codes were requested for this phone number. Please try again later.", retryAfter, nil)
+		}
+		if err := s.repo.ExpireOpen(ctx, tx, clinicID, phoneNumber, now); err != nil {
+			return err
+		}
+
+		code, err := security.NewOTPCode()
+		if err != nil {
+			return err
+		}
+		verification := &model.PhoneVerification{
+			ID:          uuid.Must(uuid.NewV7()),
+			ClinicID:    clinicID,
+			PhoneNumber: phoneNumber,
+			ExpiresAt:   now.Add(s.booking.OTPTTL),
+		}
+		verification.CodeHash = security.HashOTPCode(s.hashKey, verification.ID, code)
+		if err := s.repo.Create(ctx, tx, verification); err != nil {
+			return err
+		}
+		if err := s.sendCode(ctx, phoneNumber, code, lang); err != nil {
+			return err
+		}
+		request = &CodeRequest{ExpiresAt: verification.ExpiresAt}
+		return nil
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to request verification code: %w", err))
+	}
+	return request, nil
+}
+
+// VerifyCode commits a wrong attempt before rejecting it, so attempts count even though the
+// request fails.
+func (s *defaultService) VerifyCode(ctx context.Context, clinicID uuid.UUID, phoneNumber, code string) (*VerifiedPhone, error) {
+	var (
+		verified *VerifiedPhone
+		rejected error
+	)
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		verification, err := s.repo.FindLatestForUpdate(ctx, tx, clinicID, phoneNumber)
+		if err != nil {
+			var apiErr *apierror.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return errNoCode()
+			}
+			return err
+		}
+		now := s.now()
+		if err := checkVerifiable(verification, s.booking.OTPMaxAttempts, now); err != nil {
+			return err
+		}
+
+		hash := security.HashOTPCode(s.hashKey, verification.ID, code)
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(verification.CodeHash)) != 1 {
+			if err := s.repo.RecordFailedAttempt(ctx, tx, verification); err != nil {
+				return err
+			}
+			rejected = wrongCodeError(verification, s.booking.OTPMaxAttempts)
+			return nil
+		}
+
+		payload, err := security.NewPhonePayload(clinicID, phoneNumber, security.PhonePurposeVerified, s.tokenDuration)
+		if err != nil {
+			return err
+		}
+		token, err := s.sec.CreatePhoneToken(payload)
+		if err != nil {
+			return fmt.Errorf("failed to create phone token: %w", err)
+		}
+		verification.VerifiedAt = &now
+		verification.TokenID = &payload.TokenID
+		verification.TokenExpiresAt = &payload.ExpiresAt
+		if err := s.repo.MarkVerified(ctx, tx, verification); err != nil {
+			return err
+		}
+		verified = &VerifiedPhone{Token: token, ExpiresAt: payload.ExpiresAt}
+		return nil
+	})
+	if err == nil {
+		err = rejected
+	}
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to verify code: %w", err))
+	}
+	return verified, nil
+}
+
+// ConsumeToken rejects tokens for another clinic or phone number as if they were invalid.
+func (s *defaultService) ConsumeToken(ctx context.Context, clinicID uuid.UUID, token, phoneNumber string) error {
+	payload, err := s.sec.VerifyPhoneToken(token)
+	if err != nil {
+		return apierror.NewUnauthorized("The phone verification is invalid or has expired. Please verify your phone number again.", err).WithCode(apierror.CodeTokenExpired)
+	}
+	if err := checkPhonePayload(payload, clinicID, phoneNumber); err != nil {
+		return err
+	}
+
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		unused, err := s.repo.ConsumeToken(ctx, tx, clinicID, payload.TokenID, s.now())
+		if err != nil {
+			return err
+		}
+		if !unused {
+			return apierror.NewUnauthorized("This phone verification was already used. Please verify your phone number again.", nil).WithCode(apierror.CodeTokenExpired)
+		}
+		return nil
+	})
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(fmt.Errorf("failed to use phone verification: %w", err))
+	}
+	return nil
+}
+
+// CleanupExpired deletes expired verifications in batches until none are left.
+func (s *defaultService) CleanupExpired(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		var deleted int
+		err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+			var err error
+			deleted, err = s.repo.DeleteExpired(ctx, tx, s.now(), cleanupBatchSize)
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < cleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// Run calls CleanupExpired every interval until ctx is cancelled.
+func (s *defaultService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.cleanup(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+func (s *defaultService) cleanup(ctx context.Context) {
+	deleted, err := s.CleanupExpired(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("phone verification cleanup: failed to delete expired codes")
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int("deleted", deleted).Msg("phone verification cleanup: deleted expired codes")
+	}
+}
+
+// sendCode renders the code in lang and texts it.
+func (s *defaultService) sendCode(ctx context.Context, phoneNumber, code string, lang locale.Language) error {
+	body, err := sharedNotification.Render(sharedNotification.TemplatePhoneVerificationCode, lang, lang, sharedNotification.PhoneCodeData{
+		Code:          code,
+		ExpiresInMins: int(s.booking.OTPTTL.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, codeSendTimeout)
+	defer cancel()
+	if err := s.notifier.SendSMS(sendCtx, notification.SMS{To: phoneNumber, Body: body}); err != nil {
+		if errors.Is(err, notification.ErrCircuitOpen) {
+			return apierror.NewServiceUnavailable("Text messages cannot be sent right now. Please try again shortly.", err)
+		}
+		return fmt.Errorf("failed to send verification SMS: %w", err)
+	}
+	return nil
+}
+
+// checkVerifiable reports why the code may not be entered any more, if it may not: it was already
+// entered, it expired, or too many wrong codes were entered for it.
+func checkVerifiable(verification *model.PhoneVerification, maxAttempts int, now time.Time) error {
+	if verification.VerifiedAt != nil {
+		return errNoCode()
+	}
+	if !now.Before(verification.ExpiresAt) {
+		return apierror.NewUnauthorized("The code has expired. Please request a new one.", nil).WithCode(apierror.CodeTokenExpired)
+	}
+	if verification.Attempts >= maxAttempts {
+		return apierror.NewTooManyRequests("Too many wrong codes were entered. Please request a new one.", 0, nil)
+	}
+	return nil
+}
+
+// wrongCodeError rejects a wrong code, telling the guest how many attempts remain.
+func wrongCodeError(verification *model.PhoneVerification, maxAttempts int) error {
+	remaining := max(maxAttempts-verification.Attempts, 0)
+	return apierror.NewUnauthorized("The code is incorrect.", nil).
+		WithDetails(map[string]any{"attempts_remaining": remaining})
+}
+
+// checkPhonePayload rejects a phone token minted for another purpose, clinic or phone number.
+func checkPhonePayload(payload *security.PhonePayload, clinicID uuid.UUID, phoneNumber string) error {
+	if payload.Purpose != security.PhonePurposeVerified || payload.ClinicID != clinicID || payload.PhoneNumber != phoneNumber {
+		return apierror.NewUnauthorized("The phone verification does not match this booking. Please verify your phone number again.", nil)
+	}
+	return nil
+}
+
+// errNoCode rejects a verification attempt for a phone number with no code waiting to be entered.
+func errNoCode() error {
+	return apierror.NewUnauthorized("No code is waiting for this phone number. Please request a new one.", nil)
+}
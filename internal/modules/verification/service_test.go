@@ -0,0 +1,339 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const testPhone = "+201012345678"
+
+// fakeTx runs closures directly; the fake repository keeps its rows in memory.
+type fakeTx struct{}
+
+func (fakeTx) ExecTx(ctx context.Context, fn func(tx pgx.Tx) error) error { return fn(nil) }
+func (fakeTx) ExecTxOpts(ctx context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+func (fakeTx) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+type fakeRepo struct {
+	mu   sync.Mutex
+	now  *time.Time
+	rows []*model.PhoneVerification
+}
+
+func (r *fakeRepo) LockPhone(context.Context, database.Querier, uuid.UUID, string) error {
+	return nil
+}
+
+func (r *fakeRepo) CountRequestedSince(_ context.Context, _ database.Querier, clinicID uuid.UUID, phoneNumber string, since time.Time) (int, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count, oldest := 0, since
+	for _, v := range r.rows {
+		if v.ClinicID == clinicID && v.PhoneNumber == phoneNumber && !v.CreatedAt.Before(since) {
+			if count == 0 || v.CreatedAt.Before(oldest) {
+				oldest = v.CreatedAt
+			}
+			count++
+		}
+	}
+	return count, oldest, nil
+}
+
+func (r *fakeRepo) ExpireOpen(_ context.Context, _ database.Querier, clinicID uuid.UUID, phoneNumber string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.rows {
+		if v.ClinicID == clinicID && v.PhoneNumber == phoneNumber && v.VerifiedAt == nil && v.ExpiresAt.After(now) {
+			v.ExpiresAt = now
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) Create(_ context.Context, _ database.Querier, v *model.PhoneVerification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v.CreatedAt = *r.now
+	copied := *v
+	r.rows = append(r.rows, &copied)
+	return nil
+}
+
+func (r *fakeRepo) FindLatestForUpdate(_ context.Context, _ database.Querier, clinicID uuid.UUID, phoneNumber string) (*model.PhoneVerification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.rows) - 1; i >= 0; i-- {
+		if v := r.rows[i]; v.ClinicID == clinicID && v.PhoneNumber == phoneNumber {
+			copied := *v
+			return &copied, nil
+		}
+	}
+	return nil, apierror.NewNotFound("phone verification", nil)
+}
+
+func (r *fakeRepo) find(id uuid.UUID) *model.PhoneVerification {
+	for _, v := range r.rows {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) RecordFailedAttempt(_ context.Context, _ database.Querier, v *model.PhoneVerification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(v.ID)
+	row.Attempts++
+	v.Attempts = row.Attempts
+	return nil
+}
+
+func (r *fakeRepo) MarkVerified(_ context.Context, _ database.Querier, v *model.PhoneVerification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(v.ID)
+	row.VerifiedAt, row.TokenID, row.TokenExpiresAt = v.VerifiedAt, v.TokenID, v.TokenExpiresAt
+	return nil
+}
+
+func (r *fakeRepo) ConsumeToken(_ context.Context, _ database.Querier, clinicID, tokenID uuid.UUID, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.rows {
+		if v.ClinicID == clinicID && v.TokenID != nil && *v.TokenID == tokenID && v.ConsumedAt == nil {
+			v.ConsumedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepo) DeleteExpired(context.Context, database.Querier, time.Time, int) (int, error) {
+	return 0, nil
+}
+
+// fakeNotifier records the texts it is asked to send.
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []notification.SMS
+}
+
+func (n *fakeNotifier) SendEmail(context.Context, notification.Email) error { return nil }
+
+func (n *fakeNotifier) SendSMS(_ context.Context, sms notification.SMS) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, sms)
+	return nil
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func (n *fakeNotifier) lastCode(t *testing.T) string {
+	t.Helper()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.sent) == 0 {
+		t.Fatal("no SMS was sent")
+	}
+	code := codePattern.FindString(n.sent[len(n.sent)-1].Body)
+	if code == "" {
+		t.Fatalf("no code in SMS %q", n.sent[len(n.sent)-1].Body)
+	}
+	return code
+}
+
+// newTestService returns a service whose clock the test controls through the returned pointer.
+func newTestService(t *testing.T) (*defaultService, *fakeNotifier, *time.Time) {
+	t.Helper()
+	cfg := &config.Config{
+		Security: config.SecurityConfig{
+			PasetoKey:          "0123456789abcdef0123456789abcdef",
+			TokenMode:          security.TokenModeLocal,
+			TokenIssuer:        "mastara",
+			TokenAudience:      "mastara-api",
+			PhoneTokenDuration: 15 * time.Minute,
+		},
+		Booking: config.BookingConfig{
+			OTPTTL:           5 * time.Minute,
+			OTPMaxAttempts:   5,
+			OTPRequestLimit:  3,
+			OTPRequestWindow: 15 * time.Minute,
+		},
+	}
+	sec, err := security.NewPasetoManager(cfg.Security)
+	if err != nil {
+		t.Fatalf("NewPasetoManager: %v", err)
+	}
+	now := time.Now()
+	notifier := &fakeNotifier{}
+	svc := NewService(fakeTx{}, &fakeRepo{now: &now}, notifier, sec, cfg).(*defaultService)
+	svc.now = func() time.Time { return now }
+	return svc, notifier, &now
+}
+
+func statusOf(t *testing.T, err error) int {
+	t.Helper()
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	return apiErr.StatusCode
+}
+
+func TestVerifyCodeIssuesSingleUseToken(t *testing.T) {
+	svc, notifier, _ := newTestService(t)
+	ctx := context.Background()
+	clinicID := uuid.New()
+
+	if _, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	verified, err := svc.VerifyCode(ctx, clinicID, testPhone, notifier.lastCode(t))
+	if err != nil {
+		t.Fatalf("VerifyCode: %v", err)
+	}
+
+	if err := svc.ConsumeToken(ctx, clinicID, verified.Token, testPhone); err != nil {
+		t.Fatalf("first ConsumeToken: %v", err)
+	}
+	err = svc.ConsumeToken(ctx, clinicID, verified.Token, testPhone)
+	if got := statusOf(t, err); got != http.StatusUnauthorized {
+		t.Fatalf("replayed token: got status %d, want 401", got)
+	}
+}
+
+func TestConsumeTokenRejectsOtherPhoneOrClinic(t *testing.T) {
+	svc, notifier, _ := newTestService(t)
+	ctx := context.Background()
+	clinicID := uuid.New()
+
+	if _, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	verified, err := svc.VerifyCode(ctx, clinicID, testPhone, notifier.lastCode(t))
+	if err != nil {
+		t.Fatalf("VerifyCode: %v", err)
+	}
+
+	if err := svc.ConsumeToken(ctx, clinicID, verified.Token, "+201099999999"); statusOf(t, err) != http.StatusUnauthorized {
+		t.Fatalf("token for another phone number was accepted")
+	}
+	if err := svc.ConsumeToken(ctx, uuid.New(), verified.Token, testPhone); statusOf(t, err) != http.StatusUnauthorized {
+		t.Fatalf("token for another clinic was accepted")
+	}
+	// Neither rejection used the token up.
+	if err := svc.ConsumeToken(ctx, clinicID, verified.Token, testPhone); err != nil {
+		t.Fatalf("ConsumeToken after rejections: %v", err)
+	}
+}
+
+func TestVerifyCodeRejectsExpiredCode(t *testing.T) {
+	svc, notifier, now := newTestService(t)
+	ctx := context.Background()
+	clinicID := uuid.New()
+
+	if _, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	*now = now.Add(5 * time.Minute)
+
+	_, err := svc.VerifyCode(ctx, clinicID, testPhone, notifier.lastCode(t))
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != apierror.CodeTokenExpired {
+		t.Fatalf("expired code: got %v, want 401 %s", err, apierror.CodeTokenExpired)
+	}
+}
+
+func TestVerifyCodeLocksAfterMaxAttempts(t *testing.T) {
+	svc, notifier, _ := newTestService(t)
+	ctx := context.Background()
+	clinicID := uuid.New()
+
+	if _, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	code := notifier.lastCode(t)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < svc.booking.OTPMaxAttempts; i++ {
+		if _, err := svc.VerifyCode(ctx, clinicID, testPhone, wrong); statusOf(t, err) != http.StatusUnauthorized {
+			t.Fatalf("wrong code %d: got %v, want 401", i+1, err)
+		}
+	}
+	// The right code no longer helps once the code is locked.
+	if _, err := svc.VerifyCode(ctx, clinicID, testPhone, code); statusOf(t, err) != http.StatusTooManyRequests {
+		t.Fatalf("locked code: got %v, want 429", err)
+	}
+}
+
+func TestVerifyCodeOnlyAcceptsLatestCode(t *testing.T) {
+	svc, notifier, now := newTestService(t)
+	ctx := context.Background()
+	clinicID := uuid.New()
+
+	if _, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	first := notifier.lastCode(t)
+	*now = now.Add(time.Second)
+	if _, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English); err != nil {
+		t.Fatalf("second RequestCode: %v", err)
+	}
+	second := notifier.lastCode(t)
+
+	if first != second {
+		if _, err := svc.VerifyCode(ctx, clinicID, testPhone, first); err == nil {
+			t.Fatal("a replaced code was accepted")
+		}
+	}
+	if _, err := svc.VerifyCode(ctx, clinicID, testPhone, second); err != nil {
+		t.Fatalf("latest code: %v", err)
+	}
+	// A code cannot be entered twice.
+	if _, err := svc.VerifyCode(ctx, clinicID, testPhone, second); statusOf(t, err) != http.StatusUnauthorized {
+		t.Fatalf("reused code: got %v, want 401", err)
+	}
+}
+
+func TestRequestCodeRateLimitsPhone(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+	clinicID := uuid.New()
+
+	for i := 0; i < svc.booking.OTPRequestLimit; i++ {
+		if _, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English); err != nil {
+			t.Fatalf("RequestCode %d: %v", i+1, err)
+		}
+	}
+	_, err := svc.RequestCode(ctx, clinicID, testPhone, locale.English)
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter <= 0 {
+		t.Fatalf("over the limit: got %v, want 429 with Retry-After", err)
+	}
+	// Another clinic has its own limit.
+	if _, err := svc.RequestCode(ctx, uuid.New(), testPhone, locale.English); err != nil {
+		t.Fatalf("RequestCode for another clinic: %v", err)
+	}
+}
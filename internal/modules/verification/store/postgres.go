@@ -0,0 +1,153 @@
+// Package store provides the database implementation for the verification repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pgxRepository is the PostgreSQL implementation of the verification.Repository.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the verification repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+// verificationColumns selects verifications in the order scanVerification reads them.
+const verificationColumns = `
+        id, clinic_id, phone_number, code_hash, attempts, expires_at, verified_at,
+        token_id, token_expires_at, consumed_at, created_at`
+
+func scanVerification(row pgx.Row) (*model.PhoneVerification, error) {
+	var v model.PhoneVerification
+	err := row.Scan(&v.ID, &v.ClinicID, &v.PhoneNumber, &v.CodeHash, &v.Attempts, &v.ExpiresAt, &v.VerifiedAt,
+		&v.TokenID, &v.TokenExpiresAt, &v.ConsumedAt, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// LockPhone takes a transaction-scoped advisory lock on the clinic and phone number. Rows cannot
+// be locked instead because the first request for a number has none yet.
+func (r *pgxRepository) LockPhone(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string) error {
+	_, err := querier.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text || ':' || $2, 0))`, clinicID, phoneNumber)
+	if err != nil {
+		return fmt.Errorf("store.LockPhone: failed to lock phone number: %w", err)
+	}
+	return nil
+}
+
+// CountRequestedSince counts the codes sent to the phone number since since.
+func (r *pgxRepository) CountRequestedSince(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string, since time.Time) (int, time.Time, error) {
+	query := `
+        SELECT COUNT(*), COALESCE(MIN(created_at), $3)
+        FROM phone_verifications
+        WHERE clinic_id = $1 AND phone_number = $2 AND created_at >= $3`
+	var (
+		count  int
+		oldest time.Time
+	)
+	if err := querier.QueryRow(ctx, query, clinicID, phoneNumber, since).Scan(&count, &oldest); err != nil {
+		return 0, time.Time{}, fmt.Errorf("store.CountRequestedSince: failed to count codes: %w", err)
+	}
+	return count, oldest, nil
+}
+
+// ExpireOpen expires the phone number's codes that were not entered yet.
+func (r *pgxRepository) ExpireOpen(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string, now time.Time) error {
+	query := `
+        UPDATE phone_verifications SET expires_at = $3
+        WHERE clinic_id = $1 AND phone_number = $2 AND verified_at IS NULL AND expires_at > $3`
+	if _, err := querier.Exec(ctx, query, clinicID, phoneNumber, now); err != nil {
+		return fmt.Errorf("store.ExpireOpen: failed to expire codes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts the verification and fills in its creation time.
+func (r *pgxRepository) Create(ctx context.Context, querier database.Querier, v *model.PhoneVerification) error {
+	query := `
+        INSERT INTO phone_verifications (id, clinic_id, phone_number, code_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING created_at`
+	err := querier.QueryRow(ctx, query, v.ID, v.ClinicID, v.PhoneNumber, v.CodeHash, v.ExpiresAt).Scan(&v.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("store.Create: failed to insert phone verification: %w", err)
+	}
+	return nil
+}
+
+// FindLatestForUpdate returns and locks the newest code sent to the phone number.
+func (r *pgxRepository) FindLatestForUpdate(ctx context.Context, querier database.Querier, clinicID uuid.UUID, phoneNumber string) (*model.PhoneVerification, error) {
+	query := `SELECT ` + verificationColumns + `
+        FROM phone_verifications
+        WHERE clinic_id = $1 AND phone_number = $2
+        ORDER BY created_at DESC, id DESC
+        LIMIT 1
+        FOR UPDATE`
+	v, err := scanVerification(querier.QueryRow(ctx, query, clinicID, phoneNumber))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("phone verification", err)
+		}
+		return nil, fmt.Errorf("store.FindLatestForUpdate: failed to query phone verification: %w", err)
+	}
+	return v, nil
+}
+
+// RecordFailedAttempt increments the verification's attempts.
+func (r *pgxRepository) RecordFailedAttempt(ctx context.Context, querier database.Querier, v *model.PhoneVerification) error {
+	query := `UPDATE phone_verifications SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts`
+	if err := querier.QueryRow(ctx, query, v.ID).Scan(&v.Attempts); err != nil {
+		return fmt.Errorf("store.RecordFailedAttempt: failed to record attempt: %w", err)
+	}
+	return nil
+}
+
+// MarkVerified saves the verification's VerifiedAt, TokenID and TokenExpiresAt.
+func (r *pgxRepository) MarkVerified(ctx context.Context, querier database.Querier, v *model.PhoneVerification) error {
+	query := `UPDATE phone_verifications SET verified_at = $2, token_id = $3, token_expires_at = $4 WHERE id = $1`
+	if _, err := querier.Exec(ctx, query, v.ID, v.VerifiedAt, v.TokenID, v.TokenExpiresAt); err != nil {
+		return fmt.Errorf("store.MarkVerified: failed to mark phone verified: %w", err)
+	}
+	return nil
+}
+
+// ConsumeToken marks the token used unless it already was; a concurrent use waits on the row
+// lock and then finds it used.
+func (r *pgxRepository) ConsumeToken(ctx context.Context, querier database.Querier, clinicID, tokenID uuid.UUID, now time.Time) (bool, error) {
+	query := `
+        UPDATE phone_verifications SET consumed_at = $3
+        WHERE clinic_id = $1 AND token_id = $2 AND consumed_at IS NULL`
+	tag, err := querier.Exec(ctx, query, clinicID, tokenID, now)
+	if err != nil {
+		return false, fmt.Errorf("store.ConsumeToken: failed to consume phone token: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteExpired deletes up to limit verifications whose code and token both expired before now.
+func (r *pgxRepository) DeleteExpired(ctx context.Context, querier database.Querier, now time.Time, limit int) (int, error) {
+	query := `
+        DELETE FROM phone_verifications
+        WHERE id IN (
+            SELECT id FROM phone_verifications
+            WHERE GREATEST(expires_at, COALESCE(token_expires_at, expires_at)) < $1
+            LIMIT $2
+        )`
+	tag, err := querier.Exec(ctx, query, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("store.DeleteExpired: failed to delete expired verifications: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,237 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	outbox "github.com/Ebrahim-hamdy/mastara-saas/internal/infra/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Headers sent with every delivery.
+const (
+	// HeaderSignature carries "t=<unix seconds>,v1=<hex HMAC-SHA256>", the HMAC keyed with the
+	// webhook's secret over "<unix seconds>.<request body>". Receivers should recompute it and
+	// reject stale timestamps.
+	HeaderSignature = "X-Mastara-Signature"
+	// HeaderEvent is the event type.
+	HeaderEvent = "X-Mastara-Event"
+	// HeaderDelivery identifies the delivery; it is the same on every attempt, so receivers can
+	// discard repeats.
+	HeaderDelivery = "X-Mastara-Delivery"
+)
+
+// maxErrorLength caps the error stored with a failed attempt.
+const maxErrorLength = 500
+
+// deliveryBody is the JSON POSTed to a webhook. ID is the event's, shared by every webhook
+// the event is delivered to.
+type deliveryBody struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	ClinicID   uuid.UUID       `json:"clinic_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// DeliveryWorker POSTs pending deliveries to their webhooks. Any number of instances may run
+// one: a claimed delivery stays locked until its attempt is recorded. The POST happens before
+// that commit, so a worker stopping in between repeats the delivery.
+type DeliveryWorker struct {
+	tx   database.TxManager
+	repo Repository
+	// secretKeys opens signing secrets and keys opens delivery bodies.
+	secretKeys *security.Keyring
+	keys       *security.Keyring
+	client     *http.Client
+	cfg        config.WebhookConfig
+	// now is the worker's clock.
+	now func() time.Time
+}
+
+// NewDeliveryWorker creates a worker that sends deliveries with the configured timeout, retry
+// limit and backoff.
+func NewDeliveryWorker(txManager database.TxManager, repo Repository, config *config.Config) *DeliveryWorker {
+	return &DeliveryWorker{
+		tx:         txManager,
+		repo:       repo,
+		secretKeys: newSecretKeyring(config),
+		keys:       outbox.NewPayloadKeyring(config),
+		client:     newDeliveryClient(config.Webhooks.Timeout),
+		cfg:        config.Webhooks,
+		now:        time.Now,
+	}
+}
+
+// Run sends due deliveries every PollInterval until ctx is cancelled.
+func (w *DeliveryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	w.deliver(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.deliver(ctx)
+		}
+	}
+}
+
+func (w *DeliveryWorker) deliver(ctx context.Context) {
+	if _, err := w.DeliverPending(ctx); err != nil {
+		log.Error().Err(err).Msg("webhooks: failed to send deliveries")
+	}
+}
+
+// DeliverPending sends the deliveries due now, a batch per transaction, and returns how many
+// succeeded. Cancelling ctx stops it between batches; a batch in progress is finished and
+// committed so its POSTs are not repeated because of a shutdown.
+func (w *DeliveryWorker) DeliverPending(ctx context.Context) (int, error) {
+	batchCtx := context.WithoutCancel(ctx)
+
+	total := 0
+	for ctx.Err() == nil {
+		var claimed, delivered int
+		err := w.tx.ExecTx(batchCtx, func(tx pgx.Tx) error {
+			due, err := w.repo.LockDueDeliveries(batchCtx, tx, w.now(), w.cfg.BatchSize)
+			if err != nil {
+				return err
+			}
+			claimed = len(due)
+			for i := range due {
+				w.attempt(batchCtx, &due[i])
+				if err := w.repo.RecordAttempt(batchCtx, tx, &due[i].Delivery); err != nil {
+					return err
+				}
+				if due[i].Status == model.DeliveryDelivered {
+					delivered++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += delivered
+		if claimed < w.cfg.BatchSize {
+			return total, nil
+		}
+	}
+	return total, nil
+}
+
+// attempt POSTs the delivery once and records the outcome on it: DELIVERED on a 2xx response,
+// otherwise another attempt after a backoff, or FAILED once MaxAttempts POSTs have failed.
+func (w *DeliveryWorker) attempt(ctx context.Context, due *model.DueDelivery) {
+	d := &due.Delivery
+	statusCode, sendErr := w.post(ctx, due)
+
+	now := w.now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = nil
+	if statusCode != 0 {
+		d.ResponseStatus = &statusCode
+	}
+	if sendErr == nil {
+		d.Status = model.DeliveryDelivered
+		d.DeliveredAt = &now
+		d.LastError = nil
+		return
+	}
+
+	message := sendErr.Error()
+	if len(message) > maxErrorLength {
+		message = strings.ToValidUTF8(message[:maxErrorLength], "")
+	}
+	d.LastError = &message
+	d.NextAttemptAt = now.Add(w.backoff(d.Attempts))
+	if d.Attempts >= w.cfg.MaxAttempts {
+		d.Status = model.DeliveryFailed
+	}
+	log.Warn().Err(sendErr).
+		Str("delivery_id", d.ID.String()).
+		Str("webhook_id", d.WebhookID.String()).
+		Str("event_type", d.EventType).
+		Int("attempts", d.Attempts).
+		Bool("failed", d.Status == model.DeliveryFailed).
+		Msg("webhooks: delivery attempt failed")
+}
+
+// post sends the signed delivery and returns the response status, zero when there was none.
+func (w *DeliveryWorker) post(ctx context.Context, due *model.DueDelivery) (int, error) {
+	secret, err := w.secretKeys.Open(due.SecretKeyVersion, due.SecretSealed)
+	if err != nil {
+		return 0, err
+	}
+	payload := due.Payload
+	if due.PayloadSealed != nil {
+		if payload, err = w.keys.Open(*due.KeyVersion, *due.PayloadSealed); err != nil {
+			return 0, fmt.Errorf("failed to open payload: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, due.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mastara-Webhooks/1")
+	req.Header.Set(HeaderEvent, due.EventType)
+	req.Header.Set(HeaderDelivery, due.ID.String())
+	req.Header.Set(HeaderSignature, Sign(secret, w.now(), payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Draining a little of the body lets the connection be reused; the content is not kept.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the wait after the given number of failed attempts: RetryBackoff doubled for each
+// failure after the first, capped at MaxRetryBackoff.
+func (w *DeliveryWorker) backoff(attempts int) time.Duration {
+	wait := w.cfg.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if w.cfg.MaxRetryBackoff > 0 && wait >= w.cfg.MaxRetryBackoff {
+			return w.cfg.MaxRetryBackoff
+		}
+	}
+	return wait
+}
+
+// Sign returns the X-Mastara-Signature value for body sent at t.
+func Sign(secret []byte, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
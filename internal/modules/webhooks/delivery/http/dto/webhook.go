@@ -0,0 +1,51 @@
+// Package dto contains the Data Transfer Objects for the webhooks module's API contract.
+package dto
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+	"github.com/google/uuid"
+)
+
+// WebhookRequest registers or updates a webhook. Active defaults to true.
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookResponse defines the publicly exposed fields of a webhook. The secret is never returned.
+type WebhookResponse struct {
+	ID         uuid.UUID    `json:"id"`
+	URL        string       `json:"url"`
+	EventTypes []string     `json:"event_types"`
+	Active     bool         `json:"active"`
+	CreatedBy  *uuid.UUID   `json:"created_by"`
+	CreatedAt  apitime.Time `json:"created_at"`
+	UpdatedAt  apitime.Time `json:"updated_at"`
+}
+
+// CreatedWebhookResponse is a newly registered webhook with its signing secret, which is shown
+// only in this response.
+type CreatedWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+// DeliveryResponse is one entry of a webhook's delivery log. The body sent is not shown, since
+// it can name patients; the entry only says what was sent where.
+type DeliveryResponse struct {
+	ID        uuid.UUID `json:"id"`
+	EventID   uuid.UUID `json:"event_id"`
+	EventType string    `json:"event_type"`
+	// Recipient is the host of the webhook's URL; the path and query can hold credentials.
+	Recipient     string        `json:"recipient"`
+	Status        string        `json:"status"`
+	Attempts      int           `json:"attempts"`
+	NextAttemptAt *apitime.Time `json:"next_attempt_at"`
+	LastAttemptAt *apitime.Time `json:"last_attempt_at"`
+	// ResponseStatus is null when the endpoint could not be reached.
+	ResponseStatus *int          `json:"response_status"`
+	LastError      *string       `json:"last_error"`
+	DeliveredAt    *apitime.Time `json:"delivered_at"`
+	CreatedAt      apitime.Time  `json:"created_at"`
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	z "github.com/Oudwins/zog"
+	"github.com/Oudwins/zog/zhttp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler holds the dependencies for the webhooks HTTP handlers.
+type Handler struct {
+	service webhooks.Service
+}
+
+// NewHandler creates a new webhooks handler.
+func NewHandler(service webhooks.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateWebhook registers a webhook and returns it with its signing secret.
+func (h *Handler) CreateWebhook(c *gin.Context) *apierror.APIError {
+	req, apiErr := parseWebhookRequest(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	created, err := h.service.CreateWebhook(c.Request.Context(), *req)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusCreated, dto.CreatedWebhookResponse{
+		WebhookResponse: toWebhookResponse(created.Webhook),
+		Secret:          created.Secret,
+	})
+	return nil
+}
+
+// ListWebhooks returns the clinic's webhooks.
+func (h *Handler) ListWebhooks(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+
+	list, err := h.service.ListWebhooks(c.Request.Context(), payload.ClinicID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponses(list))
+	return nil
+}
+
+// GetWebhook returns one of the clinic's webhooks.
+func (h *Handler) GetWebhook(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid webhook ID format.", err)
+	}
+
+	webhook, err := h.service.GetWebhook(c.Request.Context(), payload.ClinicID, webhookID)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(webhook))
+	return nil
+}
+
+// UpdateWebhook replaces a webhook's URL, event types and active flag.
+func (h *Handler) UpdateWebhook(c *gin.Context) *apierror.APIError {
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid webhook ID format.", err)
+	}
+	req, apiErr := parseWebhookRequest(c)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	updated, err := h.service.UpdateWebhook(c.Request.Context(), webhookID, *req)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(updated))
+	return nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log.
+func (h *Handler) DeleteWebhook(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid webhook ID format.", err)
+	}
+
+	if err := h.service.DeleteWebhook(c.Request.Context(), payload.ClinicID, webhookID); err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// ListDeliveries returns a page of a webhook's delivery log, newest first.
+func (h *Handler) ListDeliveries(c *gin.Context) *apierror.APIError {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return apierror.NewInternalServer(err)
+	}
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apierror.NewBadRequest("Invalid webhook ID format.", err)
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "25"))
+
+	deliveryLog, err := h.service.ListDeliveries(c.Request.Context(), payload.ClinicID, webhookID, page, pageSize)
+	if err != nil {
+		var apiErr *apierror.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr
+		}
+		return apierror.NewInternalServer(err)
+	}
+
+	c.JSON(http.StatusOK, toDeliveryResponses(deliveryLog))
+	return nil
+}
+
+// parseWebhookRequest reads the webhook from the body and the clinic and employee from the token.
+func parseWebhookRequest(c *gin.Context) (*webhooks.WebhookRequest, *apierror.APIError) {
+	payload, err := middleware.GetAuthPayload(c.Request.Context())
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
+
+	var req dto.WebhookRequest
+	if issues := webhookSchema.Parse(zhttp.Request(c.Request), &req); issues != nil {
+		return nil, apierror.NewValidation(z.Issues.Flatten(issues))
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return &webhooks.WebhookRequest{
+		ClinicID:   payload.ClinicID,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Active:     active,
+		CreatedBy:  payload.ActorID(),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/url"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/delivery/http/dto"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apitime"
+)
+
+func toWebhookResponse(w *model.Webhook) dto.WebhookResponse {
+	return dto.WebhookResponse{
+		ID:         w.ID,
+		URL:        w.URL,
+		EventTypes: w.EventTypes,
+		Active:     w.Active,
+		CreatedBy:  w.CreatedBy,
+		CreatedAt:  apitime.New(w.CreatedAt),
+		UpdatedAt:  apitime.New(w.UpdatedAt),
+	}
+}
+
+func toWebhookResponses(webhooks []model.Webhook) []dto.WebhookResponse {
+	responses := make([]dto.WebhookResponse, len(webhooks))
+	for i := range webhooks {
+		responses[i] = toWebhookResponse(&webhooks[i])
+	}
+	return responses
+}
+
+func toDeliveryResponses(deliveryLog *webhooks.DeliveryLog) []dto.DeliveryResponse {
+	recipient := recipientHost(deliveryLog.Webhook.URL)
+	responses := make([]dto.DeliveryResponse, len(deliveryLog.Deliveries))
+	for i, d := range deliveryLog.Deliveries {
+		responses[i] = dto.DeliveryResponse{
+			ID:             d.ID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Recipient:      recipient,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastAttemptAt:  apitime.NewPtr(d.LastAttemptAt),
+			ResponseStatus: d.ResponseStatus,
+			LastError:      d.LastError,
+			DeliveredAt:    apitime.NewPtr(d.DeliveredAt),
+			CreatedAt:      apitime.New(d.CreatedAt),
+		}
+		// Only a pending delivery has another attempt coming.
+		if d.Status == model.DeliveryPending {
+			responses[i].NextAttemptAt = apitime.NewPtr(&d.NextAttemptAt)
+		}
+	}
+	return responses
+}
+
+// recipientHost returns the host of a webhook URL without its path, query or credentials.
+func recipientHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Name identifies the webhooks module in the router's startup log.
+func (h *Handler) Name() string {
+	return "webhooks"
+}
+
+// RegisterPublicRoutes registers nothing: the webhooks module has no public routes.
+func (h *Handler) RegisterPublicRoutes(*gin.RouterGroup) {}
+
+// RegisterProtectedRoutes sets up the routes for managing the clinic's webhooks.
+// All these routes are protected and require the webhooks.manage permission.
+func (h *Handler) RegisterProtectedRoutes(router *gin.RouterGroup) {
+	webhookGroup := router.Group("/webhooks")
+	webhookGroup.Use(middleware.RequirePermission(model.PermissionWebhooksManage))
+	{
+		// GET /api/v1/webhooks - List the clinic's webhooks
+		webhookGroup.GET("/", middleware.ErrorHandler(h.ListWebhooks))
+		// POST /api/v1/webhooks - Register a webhook; the response carries its signing secret
+		webhookGroup.POST("/", middleware.ErrorHandler(h.CreateWebhook))
+		// GET /api/v1/webhooks/:id - Get a webhook
+		webhookGroup.GET("/:id", middleware.ErrorHandler(h.GetWebhook))
+		// PUT /api/v1/webhooks/:id - Replace a webhook's URL, event types and active flag
+		webhookGroup.PUT("/:id", middleware.ErrorHandler(h.UpdateWebhook))
+		// DELETE /api/v1/webhooks/:id - Remove a webhook and its delivery log
+		webhookGroup.DELETE("/:id", middleware.ErrorHandler(h.DeleteWebhook))
+		// GET /api/v1/webhooks/:id/deliveries?page=&pageSize= - The webhook's deliveries, newest first
+		webhookGroup.GET("/:id/deliveries", middleware.AllowQuery("page", "pageSize"), middleware.ErrorHandler(h.ListDeliveries))
+	}
+}
@@ -0,0 +1,17 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	z "github.com/Oudwins/zog"
+)
+
+// Schema for registering or updating a webhook. Whether the URL is reachable and public is
+// checked by the service.
+var webhookSchema = z.Struct(z.Shape{
+	"url": z.String().Trim().Max(2048, z.Message("url cannot exceed 2048 characters.")).Required(z.Message("A url is required.")),
+	"eventTypes": z.Slice(z.String().OneOf(model.EventTypes, z.Message("event_types must be among: "+strings.Join(model.EventTypes, ", ")+"."))).
+		Min(1, z.Message("At least one event type is required.")).Required(z.Message("event_types is required.")),
+	"active": z.Ptr(z.Bool()),
+})
@@ -0,0 +1,153 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeTx runs closures directly.
+type fakeTx struct{}
+
+func (fakeTx) ExecTx(_ context.Context, fn func(tx pgx.Tx) error) error { return fn(nil) }
+func (fakeTx) ExecTxOpts(_ context.Context, _ pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+func (fakeTx) AfterCommit(_ pgx.Tx, fn func()) { fn() }
+
+// deliveryRepo holds a single delivery and hands it out while it is due.
+type deliveryRepo struct {
+	Repository
+	due model.DueDelivery
+}
+
+func (r *deliveryRepo) LockDueDeliveries(_ context.Context, _ database.Querier, now time.Time, _ int) ([]model.DueDelivery, error) {
+	if r.due.Status != model.DeliveryPending || r.due.NextAttemptAt.After(now) {
+		return nil, nil
+	}
+	return []model.DueDelivery{r.due}, nil
+}
+
+func (r *deliveryRepo) RecordAttempt(_ context.Context, _ database.Querier, delivery *model.Delivery) error {
+	r.due.Delivery = *delivery
+	return nil
+}
+
+func TestDeliveryIsRetriedWithBackoffUntilTheEndpointAccepts(t *testing.T) {
+	secret := []byte("whsec_test")
+	var (
+		mu         sync.Mutex
+		deliveries []string
+		signatures []string
+	)
+	// The endpoint fails twice, then accepts.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries = append(deliveries, r.Header.Get(HeaderDelivery))
+		signatures = append(signatures, r.Header.Get(HeaderSignature))
+		if len(deliveries) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	keys := security.NewKeyring(map[int16][]byte{1: []byte("0123456789abcdef0123456789abcdef")}, secretSealPurpose, 1)
+	version, sealed, err := keys.Seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	repo := &deliveryRepo{due: model.DueDelivery{
+		Delivery: model.Delivery{
+			ID:            uuid.New(),
+			WebhookID:     uuid.New(),
+			EventType:     "appointment.booked",
+			Payload:       []byte(`{"id":"evt"}`),
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+		},
+		URL:              server.URL,
+		SecretSealed:     sealed,
+		SecretKeyVersion: version,
+	}}
+	cfg := config.WebhookConfig{BatchSize: 10, MaxAttempts: 5, RetryBackoff: time.Minute, MaxRetryBackoff: time.Hour}
+	// The test server listens on loopback, which the production client refuses to dial.
+	w := &DeliveryWorker{tx: fakeTx{}, repo: repo, secretKeys: keys, client: server.Client(), cfg: cfg, now: func() time.Time { return now }}
+
+	steps := []struct {
+		status     string
+		attempts   int
+		response   int
+		nextWait   time.Duration
+		wantErrSet bool
+	}{
+		{model.DeliveryPending, 1, http.StatusServiceUnavailable, time.Minute, true},
+		{model.DeliveryPending, 2, http.StatusServiceUnavailable, 2 * time.Minute, true},
+		{model.DeliveryDelivered, 3, http.StatusNoContent, 0, false},
+	}
+	var sentAt []time.Time
+	for i, step := range steps {
+		attemptAt := now
+		sentAt = append(sentAt, attemptAt)
+		delivered, err := w.DeliverPending(context.Background())
+		if err != nil {
+			t.Fatalf("attempt %d: DeliverPending: %v", i+1, err)
+		}
+		d := repo.due.Delivery
+		if d.Status != step.status || d.Attempts != step.attempts {
+			t.Errorf("attempt %d: status %s after %d attempt(s), want %s after %d", i+1, d.Status, d.Attempts, step.status, step.attempts)
+		}
+		if d.ResponseStatus == nil || *d.ResponseStatus != step.response {
+			t.Errorf("attempt %d: response status = %v, want %d", i+1, d.ResponseStatus, step.response)
+		}
+		if (d.LastError != nil) != step.wantErrSet {
+			t.Errorf("attempt %d: last error = %v, want set = %t", i+1, d.LastError, step.wantErrSet)
+		}
+		if d.LastAttemptAt == nil || !d.LastAttemptAt.Equal(attemptAt) {
+			t.Errorf("attempt %d: last attempt at %v, want %s", i+1, d.LastAttemptAt, attemptAt)
+		}
+		if step.status == model.DeliveryDelivered {
+			if delivered != 1 || d.DeliveredAt == nil {
+				t.Errorf("attempt %d: %d delivered, delivered at %v; want 1 and set", i+1, delivered, d.DeliveredAt)
+			}
+			break
+		}
+		if want := attemptAt.Add(step.nextWait); !d.NextAttemptAt.Equal(want) {
+			t.Errorf("attempt %d: next attempt at %s, want %s", i+1, d.NextAttemptAt, want)
+		}
+
+		// Nothing is sent again before the backoff has passed.
+		if _, err := w.DeliverPending(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) != i+1 {
+			t.Fatalf("endpoint received %d requests before the backoff passed, want %d", len(deliveries), i+1)
+		}
+		now = d.NextAttemptAt
+	}
+
+	if len(deliveries) != 3 {
+		t.Fatalf("endpoint received %d requests, want 3", len(deliveries))
+	}
+	for i, id := range deliveries {
+		if id != repo.due.ID.String() {
+			t.Errorf("request %d: %s = %q, want the same delivery ID on every attempt", i+1, HeaderDelivery, id)
+		}
+		if want := Sign(secret, sentAt[i], repo.due.Payload); signatures[i] != want {
+			t.Errorf("request %d: %s = %q, want %q", i+1, HeaderSignature, signatures[i], want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package webhooks notifies clinics' integrators of events such as a patient registering or an
+// appointment being booked. Events reach the outbox from the in-process bus; each one becomes a
+// delivery per matching webhook, which a worker POSTs with a signature until it succeeds or
+// runs out of attempts.
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/google/uuid"
+)
+
+// Service defines the contract for managing a clinic's webhooks.
+type Service interface {
+	// CreateWebhook registers an endpoint and generates its signing secret, which is returned
+	// only here. The URL must resolve to public addresses.
+	CreateWebhook(ctx context.Context, req WebhookRequest) (*CreatedWebhook, error)
+	// ListWebhooks returns the clinic's webhooks, oldest first.
+	ListWebhooks(ctx context.Context, clinicID uuid.UUID) ([]model.Webhook, error)
+	GetWebhook(ctx context.Context, clinicID, id uuid.UUID) (*model.Webhook, error)
+	// UpdateWebhook replaces the webhook's URL, event types and active flag. The secret is kept.
+	UpdateWebhook(ctx context.Context, id uuid.UUID, req WebhookRequest) (*model.Webhook, error)
+	// DeleteWebhook removes the webhook together with its deliveries.
+	DeleteWebhook(ctx context.Context, clinicID, id uuid.UUID) error
+	// ListDeliveries returns the webhook with a page of its deliveries, newest first.
+	ListDeliveries(ctx context.Context, clinicID, webhookID uuid.UUID, page, pageSize int) (*DeliveryLog, error)
+}
+
+// WebhookRequest contains the data for registering or updating a webhook.
+type WebhookRequest struct {
+	ClinicID   uuid.UUID
+	URL        string
+	EventTypes []string
+	Active     bool
+	// CreatedBy is the employee registering the webhook; updates ignore it.
+	CreatedBy uuid.UUID
+}
+
+// CreatedWebhook is a newly registered webhook with its signing secret in plaintext.
+type CreatedWebhook struct {
+	Webhook *model.Webhook
+	Secret  string
+}
+
+// DeliveryLog is a page of a webhook's deliveries.
+type DeliveryLog struct {
+	Webhook    *model.Webhook
+	Deliveries []model.Delivery
+}
+
+// Repository defines the data access contract for webhooks and their deliveries.
+type Repository interface {
+	CreateWebhook(ctx context.Context, querier database.Querier, webhook *model.Webhook) error
+	ListWebhooks(ctx context.Context, querier database.Querier, clinicID uuid.UUID) ([]model.Webhook, error)
+	FindWebhook(ctx context.Context, querier database.Querier, clinicID, id uuid.UUID) (*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, querier database.Querier, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, querier database.Querier, clinicID, id uuid.UUID) error
+	// ListSubscribers returns the clinic's active webhooks subscribed to eventType.
+	ListSubscribers(ctx context.Context, querier database.Querier, clinicID uuid.UUID, eventType string) ([]model.Webhook, error)
+	// LockSecretsSealedBefore locks up to limit webhooks of any clinic whose secret is sealed
+	// under a key version older than version.
+	LockSecretsSealedBefore(ctx context.Context, querier database.Querier, version int16, limit int) ([]model.Webhook, error)
+	// UpdateSecret saves the webhook's sealed secret and its key version.
+	UpdateSecret(ctx context.Context, querier database.Querier, webhook *model.Webhook) error
+
+	// CreateDeliveries inserts the deliveries, skipping any whose webhook already has one for the event.
+	CreateDeliveries(ctx context.Context, querier database.Querier, deliveries []model.Delivery) error
+	ListDeliveries(ctx context.Context, querier database.Querier, clinicID, webhookID uuid.UUID, limit, offset int) ([]model.Delivery, error)
+	// LockDueDeliveries locks up to limit pending deliveries of active webhooks due at now,
+	// skipping deliveries another worker has locked.
+	LockDueDeliveries(ctx context.Context, querier database.Querier, now time.Time, limit int) ([]model.DueDelivery, error)
+	// RecordAttempt saves the delivery's status, attempt count and the outcome of its latest attempt.
+	RecordAttempt(ctx context.Context, querier database.Querier, delivery *model.Delivery) error
+}
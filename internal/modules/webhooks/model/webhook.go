@@ -0,0 +1,78 @@
+// Package model contains the domain models for clinics' webhook subscriptions and deliveries.
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PermissionWebhooksManage is checked by every webhook route.
+const PermissionWebhooksManage = "webhooks.manage"
+
+// Event types a webhook can subscribe to. They double as the outbox event types the webhooks
+// module publishes.
+const (
+	EventPatientRegistered = "patient.registered"
+	EventAppointmentBooked = "appointment.booked"
+)
+
+// EventTypes lists every event type a webhook can subscribe to.
+var EventTypes = []string{EventPatientRegistered, EventAppointmentBooked}
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryFailed    = "FAILED"
+)
+
+// Webhook is an integrator endpoint subscribed to some of a clinic's events.
+type Webhook struct {
+	ID         uuid.UUID `db:"id"`
+	ClinicID   uuid.UUID `db:"clinic_id"`
+	URL        string    `db:"url"`
+	EventTypes []string  `db:"event_types"`
+	// SecretSealed is the signing secret, sealed under SecretKeyVersion; the plaintext is shown
+	// once, at creation.
+	SecretSealed     string     `db:"secret_sealed"`
+	SecretKeyVersion int16      `db:"secret_key_version"`
+	Active           bool       `db:"is_active"`
+	CreatedBy        *uuid.UUID `db:"created_by"`
+	CreatedAt        time.Time  `db:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at"`
+}
+
+// Delivery is one event POSTed to one webhook, with the outcome of its latest attempt.
+type Delivery struct {
+	ID        uuid.UUID `db:"id"`
+	WebhookID uuid.UUID `db:"webhook_id"`
+	ClinicID  uuid.UUID `db:"clinic_id"`
+	EventID   uuid.UUID `db:"event_id"`
+	EventType string    `db:"event_type"`
+	// Payload is the body POSTed to the webhook. It is stored sealed, in PayloadSealed under
+	// KeyVersion, and only opened to be sent; deliveries created before bodies were sealed keep
+	// it in plaintext.
+	Payload       json.RawMessage `db:"payload"`
+	PayloadSealed *string         `db:"payload_sealed"`
+	KeyVersion    *int16          `db:"key_version"`
+	Status        string          `db:"status"`
+	// Attempts is how many POSTs have been made.
+	Attempts      int        `db:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	LastAttemptAt *time.Time `db:"last_attempt_at"`
+	// ResponseStatus is the HTTP status of the last response, nil when the endpoint was not reached.
+	ResponseStatus *int       `db:"response_status"`
+	LastError      *string    `db:"last_error"`
+	DeliveredAt    *time.Time `db:"delivered_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+// DueDelivery is a claimed delivery with the endpoint it goes to.
+type DueDelivery struct {
+	Delivery
+	URL              string
+	SecretSealed     string
+	SecretKeyVersion int16
+}
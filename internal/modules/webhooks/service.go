@@ -0,0 +1,345 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	outbox "github.com/Ebrahim-hamdy/mastara-saas/internal/infra/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/service"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// secretSealPurpose keys the encryption of stored signing secrets.
+const secretSealPurpose = "mastara:webhooks:secret"
+
+// secretPrefix marks signing secrets so they are recognisable in integrators' configuration.
+const secretPrefix = "whsec_"
+
+// Page size limits of the delivery log.
+const (
+	defaultDeliveryPageSize = 25
+	maxDeliveryPageSize     = 100
+)
+
+// envelope is the payload of the outbox events the module publishes and the body POSTed to
+// webhooks, apart from the delivery's ID and type.
+type envelope struct {
+	ClinicID   uuid.UUID       `json:"clinic_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// patientRegisteredData is the data of a patient.registered event. Integrators fetch the
+// patient's details through the API rather than receive them in the webhook.
+type patientRegisteredData struct {
+	PatientID    uuid.UUID `json:"patient_id"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// appointmentBookedData is the data of an appointment.booked event.
+type appointmentBookedData struct {
+	AppointmentID  uuid.UUID `json:"appointment_id"`
+	PatientID      uuid.UUID `json:"patient_id"`
+	PractitionerID uuid.UUID `json:"practitioner_id"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	Status         string    `json:"status"`
+}
+
+// defaultService is the concrete implementation of the webhooks.Service interface.
+type defaultService struct {
+	service.BaseService
+	repo   Repository
+	db     database.Querier
+	outbox *outbox.Publisher
+	// secretKeys seals signing secrets and keys seals delivery bodies.
+	secretKeys *security.Keyring
+	keys       *security.Keyring
+}
+
+// NewService creates a new instance of the webhooks service. It forwards the bus events
+// webhooks can subscribe to into the outbox, and registers with dispatcher to turn them into
+// deliveries.
+func NewService(txManager database.TxManager, repo Repository, db database.Querier, config *config.Config, bus *events.Bus, publisher *outbox.Publisher, dispatcher *outbox.Dispatcher) Service {
+	s := &defaultService{
+		BaseService: service.BaseService{Tx: txManager},
+		repo:        repo,
+		db:          db,
+		outbox:      publisher,
+		secretKeys:  newSecretKeyring(config),
+		keys:        outbox.NewPayloadKeyring(config),
+	}
+	events.Subscribe(bus, s.onPatientRegistered)
+	events.Subscribe(bus, s.onAppointmentBooked)
+	for _, eventType := range model.EventTypes {
+		dispatcher.Register(eventType, s.createDeliveries)
+	}
+	return s
+}
+
+// newSecretKeyring returns the keyring signing secrets are stored under.
+func newSecretKeyring(config *config.Config) *security.Keyring {
+	return security.NewSealKeyring(config.Security, secretSealPurpose)
+}
+
+// ResealSecrets reseals the signing secrets sealed under an older key version than the current
+// one, batchSize webhooks per transaction, and returns how many it changed. It works across all
+// clinics, so ctx must carry database.WithPlatformAccess.
+func ResealSecrets(ctx context.Context, txManager database.TxManager, repo Repository, config *config.Config, batchSize int) (int, error) {
+	keys := newSecretKeyring(config)
+	total := 0
+	for {
+		var changed int
+		err := txManager.ExecTx(ctx, func(tx pgx.Tx) error {
+			webhooks, err := repo.LockSecretsSealedBefore(ctx, tx, keys.Current(), batchSize)
+			if err != nil {
+				return err
+			}
+			for i := range webhooks {
+				w := &webhooks[i]
+				secret, err := keys.Open(w.SecretKeyVersion, w.SecretSealed)
+				if err != nil {
+					return fmt.Errorf("failed to open the secret of webhook %s: %w", w.ID, err)
+				}
+				if w.SecretKeyVersion, w.SecretSealed, err = keys.Seal(secret); err != nil {
+					return err
+				}
+				if err := repo.UpdateSecret(ctx, tx, w); err != nil {
+					return err
+				}
+			}
+			changed = len(webhooks)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += changed
+		if changed < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// CreateWebhook validates the target and stores the webhook with a new signing secret.
+func (s *defaultService) CreateWebhook(ctx context.Context, req WebhookRequest) (*CreatedWebhook, error) {
+	target, err := validateTargetURL(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
+	keyVersion, sealed, err := s.secretKeys.Seal([]byte(secret))
+	if err != nil {
+		return nil, apierror.NewInternalServer(err)
+	}
+
+	createdBy := req.CreatedBy
+	webhook := &model.Webhook{
+		ID:               uuid.Must(uuid.NewV7()),
+		ClinicID:         req.ClinicID,
+		URL:              target.String(),
+		EventTypes:       normalizeEventTypes(req.EventTypes),
+		SecretSealed:     sealed,
+		SecretKeyVersion: keyVersion,
+		Active:           req.Active,
+		CreatedBy:        &createdBy,
+	}
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.CreateWebhook(ctx, tx, webhook)
+	})
+	if err != nil {
+		return nil, wrapError(err, "failed to create webhook")
+	}
+	return &CreatedWebhook{Webhook: webhook, Secret: secret}, nil
+}
+
+// ListWebhooks returns the clinic's webhooks.
+func (s *defaultService) ListWebhooks(ctx context.Context, clinicID uuid.UUID) ([]model.Webhook, error) {
+	webhooks, err := s.repo.ListWebhooks(ctx, s.db, clinicID)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list webhooks: %w", err))
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns one of the clinic's webhooks.
+func (s *defaultService) GetWebhook(ctx context.Context, clinicID, id uuid.UUID) (*model.Webhook, error) {
+	webhook, err := s.repo.FindWebhook(ctx, s.db, clinicID, id)
+	if err != nil {
+		return nil, wrapError(err, "failed to get webhook")
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook validates the new target and replaces the webhook's settings.
+func (s *defaultService) UpdateWebhook(ctx context.Context, id uuid.UUID, req WebhookRequest) (*model.Webhook, error) {
+	target, err := validateTargetURL(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhook *model.Webhook
+	err = s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		existing, err := s.repo.FindWebhook(ctx, tx, req.ClinicID, id)
+		if err != nil {
+			return err
+		}
+		existing.URL = target.String()
+		existing.EventTypes = normalizeEventTypes(req.EventTypes)
+		existing.Active = req.Active
+		if err := s.repo.UpdateWebhook(ctx, tx, existing); err != nil {
+			return err
+		}
+		webhook = existing
+		return nil
+	})
+	if err != nil {
+		return nil, wrapError(err, "failed to update webhook")
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes one of the clinic's webhooks.
+func (s *defaultService) DeleteWebhook(ctx context.Context, clinicID, id uuid.UUID) error {
+	err := s.RunInTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.DeleteWebhook(ctx, tx, clinicID, id)
+	})
+	if err != nil {
+		return wrapError(err, "failed to delete webhook")
+	}
+	return nil
+}
+
+// ListDeliveries returns a page of the webhook's delivery log.
+func (s *defaultService) ListDeliveries(ctx context.Context, clinicID, webhookID uuid.UUID, page, pageSize int) (*DeliveryLog, error) {
+	if pageSize <= 0 {
+		pageSize = defaultDeliveryPageSize
+	}
+	pageSize = min(pageSize, maxDeliveryPageSize)
+	page = max(page, 1)
+
+	webhook, err := s.repo.FindWebhook(ctx, s.db, clinicID, webhookID)
+	if err != nil {
+		return nil, wrapError(err, "failed to get webhook")
+	}
+	deliveries, err := s.repo.ListDeliveries(ctx, s.db, clinicID, webhookID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, apierror.NewInternalServer(fmt.Errorf("failed to list webhook deliveries: %w", err))
+	}
+	return &DeliveryLog{Webhook: webhook, Deliveries: deliveries}, nil
+}
+
+// onPatientRegistered queues a patient.registered event for the clinic's webhooks.
+func (s *defaultService) onPatientRegistered(ctx context.Context, tx pgx.Tx, event events.PatientRegistered) error {
+	return s.publish(ctx, tx, model.EventPatientRegistered, event.ClinicID, event.RegisteredAt, patientRegisteredData{
+		PatientID:    event.PatientProfileID,
+		RegisteredAt: event.RegisteredAt,
+	})
+}
+
+// onAppointmentBooked queues an appointment.booked event for the clinic's webhooks.
+func (s *defaultService) onAppointmentBooked(ctx context.Context, tx pgx.Tx, event events.AppointmentBooked) error {
+	return s.publish(ctx, tx, model.EventAppointmentBooked, event.ClinicID, time.Now(), appointmentBookedData{
+		AppointmentID:  event.AppointmentID,
+		PatientID:      event.PatientProfileID,
+		PractitionerID: event.PractitionerID,
+		StartTime:      event.StartTime,
+		EndTime:        event.EndTime,
+		Status:         event.Status,
+	})
+}
+
+func (s *defaultService) publish(ctx context.Context, tx pgx.Tx, eventType string, clinicID uuid.UUID, occurredAt time.Time, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return s.outbox.Publish(ctx, tx, eventType, envelope{ClinicID: clinicID, OccurredAt: occurredAt, Data: encoded})
+}
+
+// createDeliveries turns an outbox event into a pending delivery for each of the clinic's active
+// webhooks subscribed to it. A redelivered event creates no duplicates.
+func (s *defaultService) createDeliveries(ctx context.Context, tx pgx.Tx, event outbox.Event) error {
+	var env envelope
+	if err := json.Unmarshal(event.Payload, &env); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+	subscribers, err := s.repo.ListSubscribers(ctx, tx, env.ClinicID, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(deliveryBody{
+		ID:         event.ID,
+		Type:       event.Type,
+		ClinicID:   env.ClinicID,
+		OccurredAt: env.OccurredAt,
+		Data:       env.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s delivery: %w", event.Type, err)
+	}
+	version, sealed, err := s.keys.Seal(body)
+	if err != nil {
+		return fmt.Errorf("failed to seal %s delivery: %w", event.Type, err)
+	}
+	deliveries := make([]model.Delivery, len(subscribers))
+	for i, webhook := range subscribers {
+		deliveries[i] = model.Delivery{
+			ID:            uuid.Must(uuid.NewV7()),
+			WebhookID:     webhook.ID,
+			ClinicID:      webhook.ClinicID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			PayloadSealed: &sealed,
+			KeyVersion:    &version,
+			Status:        model.DeliveryPending,
+		}
+	}
+	return s.repo.CreateDeliveries(ctx, tx, deliveries)
+}
+
+// generateSecret returns a new signing secret with 256 bits of entropy.
+func generateSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// normalizeEventTypes sorts the validated event types and drops duplicates.
+func normalizeEventTypes(eventTypes []string) []string {
+	normalized := slices.Clone(eventTypes)
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// wrapError passes API errors through and reports anything else as an internal error.
+func wrapError(err error, action string) error {
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return apierror.NewInternalServer(fmt.Errorf("%s: %w", action, err))
+}
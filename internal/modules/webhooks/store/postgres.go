@@ -0,0 +1,242 @@
+// Package store provides the database implementation for the webhooks repository.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/model"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pgxRepository is the PostgreSQL implementation of the webhooks.Repository.
+type pgxRepository struct{}
+
+// NewPgxRepository creates a new instance of the webhooks repository.
+func NewPgxRepository() *pgxRepository {
+	return &pgxRepository{}
+}
+
+// webhookColumns selects webhooks in the order scanWebhook reads them.
+const webhookColumns = `id, clinic_id, url, event_types, secret_sealed, secret_key_version, is_active, created_by, created_at, updated_at`
+
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var w model.Webhook
+	err := row.Scan(&w.ID, &w.ClinicID, &w.URL, &w.EventTypes, &w.SecretSealed, &w.SecretKeyVersion, &w.Active, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// deliveryColumns selects deliveries, aliased d, in the order deliveryFields lists them.
+const deliveryColumns = `
+        d.id, d.webhook_id, d.clinic_id, d.event_id, d.event_type, d.payload, d.payload_sealed, d.key_version, d.status, d.attempts,
+        d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error, d.delivered_at, d.created_at`
+
+func deliveryFields(d *model.Delivery) []any {
+	return []any{&d.ID, &d.WebhookID, &d.ClinicID, &d.EventID, &d.EventType, &d.Payload, &d.PayloadSealed, &d.KeyVersion, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastAttemptAt, &d.ResponseStatus, &d.LastError, &d.DeliveredAt, &d.CreatedAt}
+}
+
+// CreateWebhook inserts the webhook and fills in its timestamps.
+func (r *pgxRepository) CreateWebhook(ctx context.Context, querier database.Querier, w *model.Webhook) error {
+	query := `
+        INSERT INTO webhooks (id, clinic_id, url, event_types, secret_sealed, secret_key_version, is_active, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING created_at, updated_at`
+	err := querier.QueryRow(ctx, query, w.ID, w.ClinicID, w.URL, w.EventTypes, w.SecretSealed, w.SecretKeyVersion, w.Active, w.CreatedBy).
+		Scan(&w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("store.CreateWebhook: failed to insert webhook: %w", err)
+	}
+	return nil
+}
+
+// ListWebhooks returns the clinic's webhooks, oldest first.
+func (r *pgxRepository) ListWebhooks(ctx context.Context, querier database.Querier, clinicID uuid.UUID) ([]model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE clinic_id = $1 ORDER BY created_at, id`
+	return r.queryWebhooks(ctx, querier, "store.ListWebhooks", query, clinicID)
+}
+
+// FindWebhook returns one of the clinic's webhooks.
+func (r *pgxRepository) FindWebhook(ctx context.Context, querier database.Querier, clinicID, id uuid.UUID) (*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE clinic_id = $1 AND id = $2`
+	w, err := scanWebhook(querier.QueryRow(ctx, query, clinicID, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apierror.NewNotFound("webhook", err)
+		}
+		return nil, fmt.Errorf("store.FindWebhook: failed to query webhook: %w", err)
+	}
+	return w, nil
+}
+
+// UpdateWebhook saves the webhook's URL, event types and active flag.
+func (r *pgxRepository) UpdateWebhook(ctx context.Context, querier database.Querier, w *model.Webhook) error {
+	query := `
+        UPDATE webhooks SET url = $3, event_types = $4, is_active = $5
+        WHERE clinic_id = $1 AND id = $2
+        RETURNING updated_at`
+	err := querier.QueryRow(ctx, query, w.ClinicID, w.ID, w.URL, w.EventTypes, w.Active).Scan(&w.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NewNotFound("webhook", err)
+		}
+		return fmt.Errorf("store.UpdateWebhook: failed to update webhook: %w", err)
+	}
+	return nil
+}
+
+// LockSecretsSealedBefore locks up to limit webhooks whose secret is sealed under a key version
+// older than version, across all clinics, skipping webhooks another transaction has locked.
+func (r *pgxRepository) LockSecretsSealedBefore(ctx context.Context, querier database.Querier, version int16, limit int) ([]model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks
+        WHERE secret_key_version < $1
+        ORDER BY id
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED`
+	return r.queryWebhooks(ctx, querier, "store.LockSecretsSealedBefore", query, version, limit)
+}
+
+// UpdateSecret saves the webhook's resealed signing secret and its key version.
+func (r *pgxRepository) UpdateSecret(ctx context.Context, querier database.Querier, w *model.Webhook) error {
+	query := `UPDATE webhooks SET secret_sealed = $2, secret_key_version = $3 WHERE id = $1`
+	if _, err := querier.Exec(ctx, query, w.ID, w.SecretSealed, w.SecretKeyVersion); err != nil {
+		return fmt.Errorf("store.UpdateSecret: failed to update webhook secret: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook removes the webhook; its deliveries go with it.
+func (r *pgxRepository) DeleteWebhook(ctx context.Context, querier database.Querier, clinicID, id uuid.UUID) error {
+	tag, err := querier.Exec(ctx, `DELETE FROM webhooks WHERE clinic_id = $1 AND id = $2`, clinicID, id)
+	if err != nil {
+		return fmt.Errorf("store.DeleteWebhook: failed to delete webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apierror.NewNotFound("webhook", nil)
+	}
+	return nil
+}
+
+// ListSubscribers returns the clinic's active webhooks subscribed to eventType.
+func (r *pgxRepository) ListSubscribers(ctx context.Context, querier database.Querier, clinicID uuid.UUID, eventType string) ([]model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + `
+        FROM webhooks
+        WHERE clinic_id = $1 AND is_active AND $2 = ANY (event_types)
+        ORDER BY created_at, id`
+	return r.queryWebhooks(ctx, querier, "store.ListSubscribers", query, clinicID, eventType)
+}
+
+func (r *pgxRepository) queryWebhooks(ctx context.Context, querier database.Querier, op, query string, args ...any) ([]model.Webhook, error) {
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query webhooks: %w", op, err)
+	}
+	defer rows.Close()
+
+	webhooks := []model.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan webhook: %w", op, err)
+		}
+		webhooks = append(webhooks, *w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error iterating rows: %w", op, err)
+	}
+	return webhooks, nil
+}
+
+// CreateDeliveries inserts the deliveries with their sealed bodies; a webhook's existing delivery
+// of the same event is kept.
+func (r *pgxRepository) CreateDeliveries(ctx context.Context, querier database.Querier, deliveries []model.Delivery) error {
+	query := `
+        INSERT INTO webhook_deliveries (id, webhook_id, clinic_id, event_id, event_type, payload_sealed, key_version, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (webhook_id, event_id) DO NOTHING`
+	for _, d := range deliveries {
+		if _, err := querier.Exec(ctx, query, d.ID, d.WebhookID, d.ClinicID, d.EventID, d.EventType, d.PayloadSealed, d.KeyVersion, d.Status); err != nil {
+			return fmt.Errorf("store.CreateDeliveries: failed to insert delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListDeliveries returns a page of the webhook's deliveries, newest first.
+func (r *pgxRepository) ListDeliveries(ctx context.Context, querier database.Querier, clinicID, webhookID uuid.UUID, limit, offset int) ([]model.Delivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+        FROM webhook_deliveries d
+        WHERE d.clinic_id = $1 AND d.webhook_id = $2
+        ORDER BY d.created_at DESC, d.id DESC
+        LIMIT $3 OFFSET $4`
+	rows, err := querier.Query(ctx, query, clinicID, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("store.ListDeliveries: failed to query deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []model.Delivery{}
+	for rows.Next() {
+		var d model.Delivery
+		if err := rows.Scan(deliveryFields(&d)...); err != nil {
+			return nil, fmt.Errorf("store.ListDeliveries: failed to scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.ListDeliveries: error iterating rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+// LockDueDeliveries locks pending deliveries of active webhooks that are due, oldest first.
+// Deliveries of a paused webhook wait until it is reactivated.
+func (r *pgxRepository) LockDueDeliveries(ctx context.Context, querier database.Querier, now time.Time, limit int) ([]model.DueDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `, w.url, w.secret_sealed, w.secret_key_version
+        FROM webhook_deliveries d
+        JOIN webhooks w ON w.id = d.webhook_id
+        WHERE d.status = 'PENDING' AND d.next_attempt_at <= $1 AND w.is_active
+        ORDER BY d.next_attempt_at
+        LIMIT $2
+        FOR UPDATE OF d SKIP LOCKED`
+	rows, err := querier.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("store.LockDueDeliveries: failed to query deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	due := []model.DueDelivery{}
+	for rows.Next() {
+		var d model.DueDelivery
+		if err := rows.Scan(append(deliveryFields(&d.Delivery), &d.URL, &d.SecretSealed, &d.SecretKeyVersion)...); err != nil {
+			return nil, fmt.Errorf("store.LockDueDeliveries: failed to scan delivery: %w", err)
+		}
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.LockDueDeliveries: error iterating rows: %w", err)
+	}
+	return due, nil
+}
+
+// RecordAttempt saves the outcome of the delivery's latest attempt.
+func (r *pgxRepository) RecordAttempt(ctx context.Context, querier database.Querier, d *model.Delivery) error {
+	query := `
+        UPDATE webhook_deliveries
+        SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+            response_status = $6, last_error = $7, delivered_at = $8
+        WHERE id = $1`
+	_, err := querier.Exec(ctx, query, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastAttemptAt, d.ResponseStatus, d.LastError, d.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("store.RecordAttempt: failed to update delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+)
+
+// resolveTimeout bounds the DNS lookup of a URL being registered.
+const resolveTimeout = 5 * time.Second
+
+// blockedPrefixes are special-purpose ranges that are not covered by the netip predicates below
+// but must not be reached either: shared address space, IETF protocol assignments, benchmarking,
+// reserved space and NAT64.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// publicAddr reports whether ip is a public unicast address a webhook may be sent to.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTargetURL checks raw is an HTTPS URL without credentials whose host resolves only to
+// public addresses, so a webhook cannot be pointed at the clinic platform's own network.
+func validateTargetURL(ctx context.Context, raw string) (*url.URL, error) {
+	invalid := func(err error) error {
+		return apierror.NewUnprocessable("The webhook URL must be an https:// address on the public internet.", err)
+	}
+
+	target, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, invalid(err)
+	}
+	if target.Scheme != "https" || target.User != nil || target.Hostname() == "" || target.Fragment != "" {
+		return nil, invalid(nil)
+	}
+
+	host := target.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(ip) {
+			return nil, invalid(nil)
+		}
+		return target, nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return nil, invalid(nil)
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(lookupCtx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return nil, apierror.NewUnprocessable("The webhook URL's host could not be resolved.", err)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return nil, invalid(fmt.Errorf("%s resolves to %s", host, addr))
+		}
+	}
+	return target, nil
+}
+
+// newDeliveryClient returns the client deliveries are POSTed with. It refuses to connect to
+// non-public addresses, since a host that resolved to a public address at registration can
+// later resolve elsewhere, and it does not follow redirects, which count as failures.
+func newDeliveryClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("webhooks: refusing to connect to non-public address %s", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
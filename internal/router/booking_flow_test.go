@@ -0,0 +1,442 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database/dbtest"
+	outbox "github.com/Ebrahim-hamdy/mastara-saas/internal/infra/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/notification"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment"
+	appointmentHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/delivery/http"
+	appointmentStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic"
+	clinicHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/delivery/http"
+	clinicStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient"
+	patientHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/delivery/http"
+	patientStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule"
+	scheduleHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/delivery/http"
+	scheduleStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings"
+	settingsStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/settings/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification"
+	verificationHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/delivery/http"
+	verificationStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks"
+	webhooksHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/delivery/http"
+	webhooksStore "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/store"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/router"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/events"
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/shared/export"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const guestPhone = "+201012345678"
+
+var otpCodeRegex = regexp.MustCompile(`\b\d{6}\b`)
+
+// textedCodes records the SMS messages the app sends instead of sending them.
+type textedCodes struct {
+	mu       sync.Mutex
+	messages []notification.SMS
+}
+
+func (n *textedCodes) SendEmail(context.Context, notification.Email) error { return nil }
+
+func (n *textedCodes) SendSMS(_ context.Context, sms notification.SMS) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, sms)
+	return nil
+}
+
+// lastCode returns the code in the latest message texted to phone.
+func (n *textedCodes) lastCode(t *testing.T, phone string) string {
+	t.Helper()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := len(n.messages) - 1; i >= 0; i-- {
+		if n.messages[i].To == phone {
+			if code := otpCodeRegex.FindString(n.messages[i].Body); code != "" {
+				return code
+			}
+		}
+	}
+	t.Fatalf("no verification code was texted to %s", phone)
+	return ""
+}
+
+func bookingFlowConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{QueryParams: string(middleware.QueryParamsReject)},
+		Security: config.SecurityConfig{
+			TokenDuration:      15 * time.Minute,
+			PasetoKey:          "0123456789abcdef0123456789abcdef",
+			SealKeys:           []string{"v1:fedcba9876543210fedcba9876543210"},
+			SealKeyVersion:     1,
+			TokenMode:          security.TokenModeLocal,
+			TokenIssuer:        "mastara-test",
+			TokenAudience:      "mastara-api",
+			TokenLeeway:        30 * time.Second,
+			GuestTokenDuration: 7 * 24 * time.Hour,
+			PhoneTokenDuration: 15 * time.Minute,
+		},
+		Booking: config.BookingConfig{
+			MaxPendingPerPhone: 3,
+			OTPTTL:             5 * time.Minute,
+			OTPMaxAttempts:     5,
+			OTPRequestLimit:    3,
+			OTPRequestWindow:   15 * time.Minute,
+		},
+		Outbox: config.OutboxConfig{
+			PollInterval:    time.Second,
+			BatchSize:       10,
+			MaxAttempts:     3,
+			RetryBackoff:    time.Second,
+			MaxRetryBackoff: time.Minute,
+		},
+	}
+}
+
+// newBookingApp wires the modules the public booking journey touches the way cmd/api does and
+// returns the router with every middleware in place.
+func newBookingApp(t *testing.T, pool *pgxpool.Pool, cfg *config.Config, notifier notification.Notifier) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tokenManager, err := security.NewPasetoManager(cfg.Security)
+	if err != nil {
+		t.Fatalf("NewPasetoManager: %v", err)
+	}
+	txManager := database.NewTxManager(pool)
+	payloadKeys := outbox.NewPayloadKeyring(cfg)
+	outboxPublisher := outbox.NewPublisher(payloadKeys)
+	outboxDispatcher := outbox.NewDispatcher(txManager, cfg.Outbox, payloadKeys)
+	eventBus := events.NewBus()
+	patientExports := export.NewRegistry()
+
+	settingsSvc := settings.NewService(txManager, settingsStore.NewPgxRepository(), pool)
+	clinicSvc := clinic.NewService(txManager, clinicStore.NewPgxRepository(pool), settingsSvc, tokenManager, cfg.Security.TokenDuration)
+	patientSvc := patient.NewService(txManager, patientStore.NewPgxProfileRepository(pool), pool, pool, settingsSvc, eventBus, patientExports)
+	verificationSvc := verification.NewService(txManager, verificationStore.NewPgxRepository(), notifier, tokenManager, cfg)
+	appointmentSvc := appointment.NewService(txManager, appointmentStore.NewPgxRepository(), pool, patientSvc, verificationSvc, clinicSvc, tokenManager, cfg, eventBus, patientExports)
+	scheduleSvc := schedule.NewService(txManager, scheduleStore.NewPgxRepository(), pool, clinicSvc)
+	webhooksSvc := webhooks.NewService(txManager, webhooksStore.NewPgxRepository(), pool, cfg, eventBus, outboxPublisher, outboxDispatcher)
+
+	return router.New(cfg, nil, tokenManager, security.NewMemoryDenylist(), clinicSvc, settings.NewLanguageResolver(settingsSvc),
+		clinicHttp.NewHandler(clinicSvc),
+		patientHttp.NewHandler(patientSvc),
+		verificationHttp.NewHandler(verificationSvc),
+		appointmentHttp.NewHandler(appointmentSvc, tokenManager),
+		scheduleHttp.NewHandler(scheduleSvc),
+		webhooksHttp.NewHandler(webhooksSvc),
+	)
+}
+
+// seedPractitioner adds an active practitioner to the clinic who works 09:00-17:00 in 30-minute
+// slots on weekday.
+func seedPractitioner(t *testing.T, pool *pgxpool.Pool, clinicID uuid.UUID, weekday time.Weekday) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	id := uuid.Must(uuid.NewV7())
+	_, err := pool.Exec(ctx, `
+        INSERT INTO profiles (id, clinic_id, full_name, email, profile_status)
+        VALUES ($1, $2, 'Dr. Hala Samir', 'hala@example.com', 'REGISTERED')`, id, clinicID)
+	if err != nil {
+		t.Fatalf("failed to create practitioner profile: %v", err)
+	}
+	_, err = pool.Exec(ctx, `
+        INSERT INTO employees (profile_id, clinic_id, job_title, password_hash, status, is_publicly_listed)
+        VALUES ($1, $2, 'Dentist', 'not-a-hash', 'ACTIVE', TRUE)`, id, clinicID)
+	if err != nil {
+		t.Fatalf("failed to create practitioner: %v", err)
+	}
+	_, err = pool.Exec(ctx, `
+        INSERT INTO doctor_schedules (clinic_id, doctor_id, day_of_week, start_time, end_time, slot_minutes)
+        VALUES ($1, $2, $3, '09:00', '17:00', 30)`, clinicID, id, int(weekday))
+	if err != nil {
+		t.Fatalf("failed to create working hours: %v", err)
+	}
+	return id
+}
+
+// call sends a JSON request for the clinic to the app and decodes the JSON response into out,
+// failing the test unless the response has the wanted status.
+func call(t *testing.T, app http.Handler, method, path, slug, token string, body any, want int, out any) {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if slug != "" {
+		req.Header.Set(middleware.ClinicSlugHeader, slug)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	if rec.Code != want {
+		t.Fatalf("%s %s = %d %s, want %d", method, path, rec.Code, rec.Body.String(), want)
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: failed to decode %s: %v", method, path, rec.Body.String(), err)
+		}
+	}
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Message   string `json:"message"`
+		Code      int    `json:"code"`
+		RequestID string `json:"request_id"`
+	} `json:"error"`
+}
+
+func TestGuestBookingJourney(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	clinicID := dbtest.CreateClinic(t, pool)
+	var slug string
+	if err := pool.QueryRow(ctx, `SELECT slug FROM clinics WHERE id = $1`, clinicID).Scan(&slug); err != nil {
+		t.Fatal(err)
+	}
+	// Two days out clears the minimum notice and the cancellation cutoff of the default rules.
+	day := time.Now().UTC().AddDate(0, 0, 2)
+	practitionerID := seedPractitioner(t, pool, clinicID, day.Weekday())
+	texts := &textedCodes{}
+	app := newBookingApp(t, pool, bookingFlowConfig(), texts)
+
+	// The availability route names the clinic in its path.
+	var availability struct {
+		Slots []struct {
+			StartsAt time.Time `json:"starts_at"`
+			EndsAt   time.Time `json:"ends_at"`
+		} `json:"slots"`
+	}
+	call(t, app, http.MethodGet, "/public/clinics/"+slug+"/availability?practitioner_id="+practitionerID.String()+"&date="+day.Format(time.DateOnly),
+		"", "", nil, http.StatusOK, &availability)
+	if len(availability.Slots) != 16 {
+		t.Fatalf("availability has %d slots, want 16 half-hour slots from 09:00 to 17:00", len(availability.Slots))
+	}
+	slot := availability.Slots[0]
+
+	call(t, app, http.MethodPost, "/public/otp/request", slug, "", map[string]string{"phone_number": guestPhone}, http.StatusAccepted, nil)
+	var verified struct {
+		PhoneVerificationToken string `json:"phone_verification_token"`
+	}
+	call(t, app, http.MethodPost, "/public/otp/verify", slug, "", map[string]string{
+		"phone_number": guestPhone,
+		"code":         texts.lastCode(t, guestPhone),
+	}, http.StatusOK, &verified)
+
+	// Requests the validation rejects get the error envelope, with the request ID to quote.
+	var invalid errorEnvelope
+	call(t, app, http.MethodPost, "/public/appointments/", slug, "", map[string]string{"phone_number": guestPhone}, http.StatusBadRequest, &invalid)
+	if invalid.Error.Message == "" || invalid.Error.Code != http.StatusBadRequest || invalid.Error.RequestID == "" {
+		t.Errorf("validation error = %+v, want the error envelope", invalid.Error)
+	}
+
+	var booking struct {
+		AppointmentID   uuid.UUID `json:"appointment_id"`
+		Status          string    `json:"status"`
+		ManagementToken string    `json:"management_token"`
+	}
+	call(t, app, http.MethodPost, "/public/appointments/", slug, "", map[string]any{
+		"full_name":                "Mona Adel",
+		"phone_number":             guestPhone,
+		"phone_verification_token": verified.PhoneVerificationToken,
+		"practitioner_id":          practitionerID,
+		"starts_at":                slot.StartsAt.Format(time.RFC3339),
+		"ends_at":                  slot.EndsAt.Format(time.RFC3339),
+		"reason":                   "Toothache",
+	}, http.StatusCreated, &booking)
+	if booking.ManagementToken == "" {
+		t.Fatal("the booking response carries no management token")
+	}
+
+	var viewed struct {
+		ID       uuid.UUID `json:"id"`
+		StartsAt time.Time `json:"starts_at"`
+		Status   string    `json:"status"`
+	}
+	call(t, app, http.MethodGet, "/public/appointments/manage", slug, booking.ManagementToken, nil, http.StatusOK, &viewed)
+	if viewed.ID != booking.AppointmentID || !viewed.StartsAt.Equal(slot.StartsAt) || viewed.Status != "SCHEDULED" {
+		t.Errorf("viewed appointment = %+v, want the booked one, scheduled at %s", viewed, slot.StartsAt)
+	}
+
+	// Rejections by the middleware share the error envelope with the handlers' errors.
+	var unauthorized errorEnvelope
+	call(t, app, http.MethodGet, "/public/appointments/manage", slug, "", nil, http.StatusUnauthorized, &unauthorized)
+	if unauthorized.Error.Message == "" || unauthorized.Error.Code != http.StatusUnauthorized || unauthorized.Error.RequestID == "" {
+		t.Errorf("missing token error = %+v, want the error envelope", unauthorized.Error)
+	}
+
+	var cancelled struct {
+		Status string `json:"status"`
+	}
+	call(t, app, http.MethodPost, "/public/appointments/manage/cancel", slug, booking.ManagementToken, nil, http.StatusOK, &cancelled)
+	if cancelled.Status != "CANCELLED" {
+		t.Errorf("status after cancelling = %q, want CANCELLED", cancelled.Status)
+	}
+
+	var patientID uuid.UUID
+	var status string
+	err := pool.QueryRow(ctx, `SELECT patient_id FROM appointments WHERE id = $1`, booking.AppointmentID).Scan(&patientID)
+	if err != nil {
+		t.Fatalf("failed to load the appointment: %v", err)
+	}
+	if err := pool.QueryRow(ctx, `SELECT profile_status FROM profiles WHERE id = $1`, patientID).Scan(&status); err != nil {
+		t.Fatalf("failed to load the patient: %v", err)
+	}
+	if status != "GUEST" {
+		t.Errorf("patient profile status = %s, want GUEST", status)
+	}
+
+	var confirmations int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM outbox_events WHERE event_type = 'appointment.booked'`).Scan(&confirmations); err != nil {
+		t.Fatal(err)
+	}
+	if confirmations != 1 {
+		t.Errorf("outbox holds %d appointment.booked events, want 1", confirmations)
+	}
+
+	for _, entry := range []struct {
+		table, action string
+		recordID      uuid.UUID
+	}{
+		{"profiles", "INSERT", patientID},
+		{"appointments", "INSERT", booking.AppointmentID},
+		{"appointments", "UPDATE", booking.AppointmentID},
+	} {
+		var n int
+		err := pool.QueryRow(ctx, `
+            SELECT count(*) FROM audit_log
+            WHERE clinic_id = $1 AND table_name = $2 AND action = $3 AND record_id = $4`,
+			clinicID, entry.table, entry.action, entry.recordID).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			t.Errorf("audit log has no %s of %s %s", entry.action, entry.table, entry.recordID)
+		}
+	}
+	var cancelledInAudit int
+	err = pool.QueryRow(ctx, `
+        SELECT count(*) FROM audit_log
+        WHERE table_name = 'appointments' AND action = 'UPDATE' AND record_id = $1 AND new_record ->> 'status' = 'CANCELLED'`,
+		booking.AppointmentID).Scan(&cancelledInAudit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelledInAudit != 1 {
+		t.Errorf("audit log records the cancellation %d times, want once", cancelledInAudit)
+	}
+}
+
+func TestGuestBookingsReuseTheGuestProfileAndAreCappedPerPhone(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	clinicID := dbtest.CreateClinic(t, pool)
+	var slug string
+	if err := pool.QueryRow(ctx, `SELECT slug FROM clinics WHERE id = $1`, clinicID).Scan(&slug); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Now().UTC().AddDate(0, 0, 2)
+	practitionerID := seedPractitioner(t, pool, clinicID, day.Weekday())
+	cfg := bookingFlowConfig()
+	cfg.Booking.MaxPendingPerPhone = 2
+	texts := &textedCodes{}
+	app := newBookingApp(t, pool, cfg, texts)
+
+	// verifyPhone proves the guest holds the phone and returns the single-use token that says so.
+	verifyPhone := func() string {
+		t.Helper()
+		call(t, app, http.MethodPost, "/public/otp/request", slug, "", map[string]string{"phone_number": guestPhone}, http.StatusAccepted, nil)
+		var verified struct {
+			PhoneVerificationToken string `json:"phone_verification_token"`
+		}
+		call(t, app, http.MethodPost, "/public/otp/verify", slug, "", map[string]string{
+			"phone_number": guestPhone,
+			"code":         texts.lastCode(t, guestPhone),
+		}, http.StatusOK, &verified)
+		return verified.PhoneVerificationToken
+	}
+	// book asks for the half-hour slot starting at hour UTC on date and expects the want status.
+	book := func(slug, phoneToken string, date time.Time, hour, want int) uuid.UUID {
+		t.Helper()
+		start := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, time.UTC)
+		var booking struct {
+			AppointmentID uuid.UUID `json:"appointment_id"`
+		}
+		var out any = &booking
+		if want != http.StatusCreated {
+			out = &errorEnvelope{}
+		}
+		call(t, app, http.MethodPost, "/public/appointments/", slug, "", map[string]any{
+			"full_name":                "Mona Adel",
+			"phone_number":             guestPhone,
+			"phone_verification_token": phoneToken,
+			"practitioner_id":          practitionerID,
+			"starts_at":                start.Format(time.RFC3339),
+			"ends_at":                  start.Add(30 * time.Minute).Format(time.RFC3339),
+		}, want, out)
+		return booking.AppointmentID
+	}
+
+	first := book(slug, verifyPhone(), day, 9, http.StatusCreated)
+	second := book(slug, verifyPhone(), day, 10, http.StatusCreated)
+
+	// The clinic middleware refuses a booking that names no clinic before any handler runs.
+	token := verifyPhone()
+	book("", token, day, 11, http.StatusBadRequest)
+	// Slots in the past are refused without using up the phone token.
+	book(slug, token, time.Now().UTC().AddDate(0, 0, -1), 9, http.StatusUnprocessableEntity)
+	// Two upcoming bookings is the most one phone number may hold.
+	book(slug, token, day, 11, http.StatusTooManyRequests)
+
+	var guests, patients int
+	err := pool.QueryRow(ctx, `
+        SELECT count(*) FROM profiles WHERE clinic_id = $1 AND phone_number = $2`, clinicID, guestPhone).Scan(&guests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guests != 1 {
+		t.Errorf("the phone number has %d profiles, want the one guest profile", guests)
+	}
+	err = pool.QueryRow(ctx, `
+        SELECT count(DISTINCT patient_id) FROM appointments WHERE id = ANY($1)`, []uuid.UUID{first, second}).Scan(&patients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patients != 1 {
+		t.Errorf("the bookings belong to %d patients, want both to the same guest", patients)
+	}
+	var appointments int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM appointments WHERE clinic_id = $1`, clinicID).Scan(&appointments); err != nil {
+		t.Fatal(err)
+	}
+	if appointments != 2 {
+		t.Errorf("clinic has %d appointments, want the 2 accepted bookings", appointments)
+	}
+}
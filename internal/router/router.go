@@ -3,66 +3,147 @@ package router
 
 import (
 	"context"
+	"expvar"
+	"maps"
+	"net/http"
+	"slices"
 	"time"
 
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/database"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/security"
 	"github.com/Ebrahim-hamdy/mastara-saas/internal/middleware" // <-- Import new middleware
-	iamHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/delivery/http"
-	patientHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/delivery/http"
-	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror" // <-- Import new apierror
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"        // <-- Import new apierror
+	"github.com/rs/zerolog/log"
 
 	"github.com/gin-gonic/gin"
 )
 
-// New creates and returns a new Gin engine with all the application routes configured.
-func New(dbProvider *database.Provider, tokenManager *security.PasetoManager, iamHandler *iamHttp.Handler, patientHandler *patientHttp.Handler) *gin.Engine {
+// Module is a feature module's HTTP surface. Public routes run after the clinic and language are
+// resolved from the request; protected routes require an authenticated staff member. A module
+// without routes of one kind registers nothing for it.
+type Module interface {
+	// Name identifies the module in the startup log.
+	Name() string
+	RegisterPublicRoutes(router *gin.RouterGroup)
+	RegisterProtectedRoutes(router *gin.RouterGroup)
+}
+
+// SignupModule is a Module with public routes that must run before any clinic exists, such as
+// signing up a new clinic.
+type SignupModule interface {
+	RegisterSignupRoutes(router *gin.RouterGroup)
+}
+
+// InternalModule is a Module with routes for the platform's support tooling.
+type InternalModule interface {
+	RegisterInternalRoutes(router *gin.RouterGroup)
+}
+
+// routeGroups are the groups modules register their routes in.
+type routeGroups struct {
+	public, signup, protected, internal *gin.RouterGroup
+}
+
+// mountedModule is how many routes a module registered.
+type mountedModule struct {
+	name   string
+	routes int
+}
+
+// New creates and returns a new Gin engine with the routes of every module configured.
+func New(cfg *config.Config, dbProvider *database.Provider, tokenManager *security.PasetoManager, denylist security.Denylist, clinicResolver middleware.ClinicResolver, languageResolver middleware.LanguageResolver, modules ...Module) *gin.Engine {
 	router := gin.New()
 
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AccessLog())
 	router.Use(gin.Recovery())
 	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.BodyLimiter(1_048_576)) // 1MB limit
+	router.Use(middleware.BodyLimiter(1_048_576)) // 1MB limit; large files go through chunked uploads
+	router.Use(middleware.APIVersion())
+	if dbProvider != nil && dbProvider.HasReplica() {
+		router.Use(middleware.ReadYourWrites(security.DeriveKey([]byte(cfg.Security.PasetoKey), "read-your-writes"), cfg.Database.ReadYourWritesWindow))
+	}
 
 	// Health check handler now uses our centralized error handler.
+	// It is registered outside the limited groups so it keeps answering under load.
 	router.GET("/health", middleware.ErrorHandler(healthCheckHandler(dbProvider)))
 
+	// Concurrency limits protect the database pool. Public traffic has its own, smaller cap
+	// inside the global one so a booking-widget burst cannot starve staff requests, and
+	// configured route groups, such as exports, have caps of their own.
+	concurrency := cfg.Server.Concurrency
+	globalLimiter := middleware.NewConcurrencyLimiter("global", concurrency.Global, concurrency.WaitTimeout, concurrency.RetryAfter)
+	publicLimiter := middleware.NewConcurrencyLimiter("public", concurrency.Public, concurrency.WaitTimeout, concurrency.RetryAfter)
+	// Route limits were validated with the rest of the configuration.
+	routeLimits, _ := concurrency.RouteLimits()
+	routeLimiters := make([]middleware.RouteLimiter, 0, len(routeLimits))
+	for _, prefix := range slices.Sorted(maps.Keys(routeLimits)) {
+		routeLimiters = append(routeLimiters, middleware.RouteLimiter{
+			Prefix:  prefix,
+			Limiter: middleware.NewConcurrencyLimiter("route:"+prefix, routeLimits[prefix], concurrency.WaitTimeout, concurrency.RetryAfter),
+		})
+	}
+	routeLimiter := middleware.RouteLimits(routeLimiters)
+
+	// Routes declare the query parameters they accept; typos like ?pagesize= are logged or rejected.
+	strictQuery := middleware.StrictQuery(middleware.QueryParamMode(cfg.Server.QueryParams))
+
 	// === PUBLIC ROUTES (NO AUTH) ===
+	// Every public route is tenant-scoped, so the clinic is resolved up front, followed by
+	// the language patient-facing messages are rendered in.
 	public := router.Group("/public")
-	if iamHandler != nil {
-		iamHandler.RegisterPublicRoutes(public)
-	}
+	public.Use(globalLimiter.Middleware(), publicLimiter.Middleware(), routeLimiter, strictQuery)
+	public.Use(middleware.ResolveClinic(clinicResolver, cfg.Server.BaseDomain))
+	public.Use(middleware.Locale(languageResolver))
 
-	// Public patient/booking routes will be registered here later.
+	// Signup creates the tenant, so it shares the public limits but not the clinic resolution.
+	signup := router.Group("/public")
+	signup.Use(globalLimiter.Middleware(), publicLimiter.Middleware(), routeLimiter, strictQuery)
 
 	// === AUTHENTICATED STAFF ROUTES ===
 	v1 := router.Group("/api/v1")
-	v1.Use(middleware.Authenticator(tokenManager))
-	{
-
-		// Example of a protected route
-		// v1.GET("/me", func(c *gin.Context) {
-		// 	payload := middleware.GetAuthPayload(c.Request.Context())
-		// 	if payload == nil {
-		// 		// This should technically be unreachable if middleware is working
-		// 		err := apierror.NewUnauthorized("Not authenticated", nil)
-		// 		c.AbortWithStatusJSON(err.StatusCode, gin.H{"error": err.PublicMessage})
-		// 		return
-		// 	}
-		// 	c.JSON(http.StatusOK, gin.H{"data": payload})
-		// })
-
-		// Register routes for each module.
-		if iamHandler != nil {
-			iamHandler.RegisterProtectedRoutes(v1)
-		}
-		if patientHandler != nil {
-			patientHandler.RegisterRoutes(v1)
+	v1.Use(globalLimiter.Middleware(), routeLimiter, strictQuery)
+	v1.Use(middleware.Authenticator(tokenManager, denylist))
+	v1.Use(middleware.ImpersonationGuard(cfg.Security.ImpersonationReadOnly))
+
+	// === INTERNAL PLATFORM ROUTES (SUPPORT TOOLING) ===
+	internal := router.Group("/internal/v1")
+	internal.Use(globalLimiter.Middleware(), routeLimiter, strictQuery)
+	internal.Use(middleware.Authenticator(tokenManager, denylist))
+	// GET /internal/v1/vars - Process metrics, including in-flight request counts per limiter.
+	internal.GET("/vars", middleware.RequirePermission("platform.metrics.read"), gin.WrapH(expvar.Handler()))
+
+	groups := routeGroups{public: public, signup: signup, protected: v1, internal: internal}
+	for _, m := range mountModules(router, groups, modules) {
+		if m.routes == 0 {
+			log.Warn().Str("module", m.name).Msg("Module registered no routes")
+			continue
 		}
+		log.Info().Str("module", m.name).Int("routes", m.routes).Msg("Module routes registered")
 	}
 
 	return router
 }
 
+// mountModules registers the routes of each module, in order, and reports how many each added.
+func mountModules(engine *gin.Engine, groups routeGroups, modules []Module) []mountedModule {
+	mounted := make([]mountedModule, 0, len(modules))
+	for _, m := range modules {
+		before := len(engine.Routes())
+		m.RegisterPublicRoutes(groups.public)
+		if s, ok := m.(SignupModule); ok {
+			s.RegisterSignupRoutes(groups.signup)
+		}
+		m.RegisterProtectedRoutes(groups.protected)
+		if i, ok := m.(InternalModule); ok {
+			i.RegisterInternalRoutes(groups.internal)
+		}
+		mounted = append(mounted, mountedModule{name: m.Name(), routes: len(engine.Routes()) - before})
+	}
+	return mounted
+}
+
 // healthCheckHandler now returns an *apierror.APIError, simplifying its logic.
 func healthCheckHandler(db *database.Provider) middleware.APIHandlerFunc {
 	return func(c *gin.Context) *apierror.APIError {
@@ -75,7 +156,17 @@ func healthCheckHandler(db *database.Provider) middleware.APIHandlerFunc {
 			return apierror.NewInternalServer(err)
 		}
 
-		c.JSON(200, gin.H{"status": "healthy"})
+		// Requests queueing for connections mean this instance should take no new traffic
+		// until the pool drains, even though the database itself answers.
+		if db.Degraded() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":              "degraded",
+				"acquire_wait_p95_ms": db.AcquireWaitP95().Milliseconds(),
+			})
+			return nil
+		}
+
+		c.JSON(200, gin.H{"status": "healthy", "read_replica": db.HasReplica()})
 		return nil // On success, return nil.
 	}
 }
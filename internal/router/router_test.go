@@ -0,0 +1,97 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/infra/config"
+	appointmentHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/appointment/delivery/http"
+	auditHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/audit/delivery/http"
+	clinicHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinic/delivery/http"
+	clinicConfigHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/clinicconfig/delivery/http"
+	documentHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/document/delivery/http"
+	iamHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/iam/delivery/http"
+	lookupHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/lookup/delivery/http"
+	patientHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/patient/delivery/http"
+	relationsHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/relations/delivery/http"
+	reportsHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/reports/delivery/http"
+	scheduleHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/schedule/delivery/http"
+	uploadHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/upload/delivery/http"
+	verificationHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/verification/delivery/http"
+	webhooksHttp "github.com/Ebrahim-hamdy/mastara-saas/internal/modules/webhooks/delivery/http"
+	"github.com/gin-gonic/gin"
+)
+
+// allModules returns every module's handler. Registering routes never calls the services, so
+// none are needed.
+func allModules() []Module {
+	return []Module{
+		clinicHttp.NewHandler(nil),
+		iamHttp.NewHandler(nil),
+		patientHttp.NewHandler(nil),
+		lookupHttp.NewHandler(nil),
+		relationsHttp.NewHandler(nil),
+		reportsHttp.NewHandler(nil),
+		uploadHttp.NewHandler(nil),
+		documentHttp.NewHandler(nil, config.DocumentConfig{}),
+		clinicConfigHttp.NewHandler(nil),
+		verificationHttp.NewHandler(nil),
+		appointmentHttp.NewHandler(nil, nil),
+		scheduleHttp.NewHandler(nil),
+		webhooksHttp.NewHandler(nil),
+		auditHttp.NewHandler(nil),
+	}
+}
+
+func testGroups(engine *gin.Engine) routeGroups {
+	return routeGroups{
+		public:    engine.Group("/public"),
+		signup:    engine.Group("/public"),
+		protected: engine.Group("/api/v1"),
+		internal:  engine.Group("/internal/v1"),
+	}
+}
+
+func TestEveryModuleContributesRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	modules := allModules()
+
+	mounted := mountModules(engine, testGroups(engine), modules)
+	if len(mounted) != len(modules) {
+		t.Fatalf("mounted %d modules, want %d", len(mounted), len(modules))
+	}
+	seen := make(map[string]bool)
+	total := 0
+	for _, m := range mounted {
+		if m.routes == 0 {
+			t.Errorf("module %q registered no routes", m.name)
+		}
+		if m.name == "" || seen[m.name] {
+			t.Errorf("module name %q is empty or not unique", m.name)
+		}
+		seen[m.name] = true
+		total += m.routes
+	}
+	if got := len(engine.Routes()); got != total {
+		t.Errorf("engine has %d routes, modules reported %d", got, total)
+	}
+}
+
+// emptyModule registers no routes at all.
+type emptyModule struct{}
+
+func (emptyModule) Name() string                             { return "empty" }
+func (emptyModule) RegisterPublicRoutes(*gin.RouterGroup)    {}
+func (emptyModule) RegisterProtectedRoutes(*gin.RouterGroup) {}
+
+func TestMountModulesReportsModuleWithoutRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	mounted := mountModules(engine, testGroups(engine), []Module{patientHttp.NewHandler(nil), emptyModule{}})
+	if mounted[1].name != "empty" || mounted[1].routes != 0 {
+		t.Errorf("empty module mounted as %+v, want 0 routes", mounted[1])
+	}
+	if mounted[0].routes == 0 {
+		t.Errorf("patient module mounted as %+v, want its routes counted", mounted[0])
+	}
+}
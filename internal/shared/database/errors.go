@@ -0,0 +1,52 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// BusyError is returned, wrapped, by queries and transactions that could not get a pooled
+// connection within the acquire timeout. It does not wrap context.DeadlineExceeded: the
+// request is still alive, the pool is just saturated, and the HTTP layer answers with a 503.
+type BusyError struct {
+	// Waited is how long the caller waited for a connection.
+	Waited time.Duration
+	// RetryAfter is the delay clients are asked to wait before retrying.
+	RetryAfter time.Duration
+}
+
+// Error satisfies the standard error interface.
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("database: no pooled connection became free within %s", e.Waited)
+}
+
+// UniqueViolation reports whether err is a unique constraint violation (SQLSTATE 23505) and,
+// if so, the name of the violated constraint or unique index.
+func UniqueViolation(err error) (constraint string, ok bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return pgErr.ConstraintName, true
+	}
+	return "", false
+}
+
+// SerializationFailure reports whether err is a serialization failure (SQLSTATE 40001) or a
+// deadlock (40P01): the transaction was aborted to keep the schedule consistent and can
+// succeed if run again.
+func SerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
+
+// ExclusionViolation reports whether err is an exclusion constraint violation (SQLSTATE 23P01)
+// and, if so, the name of the violated constraint.
+func ExclusionViolation(err error) (constraint string, ok bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23P01" {
+		return pgErr.ConstraintName, true
+	}
+	return "", false
+}
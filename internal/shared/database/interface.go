@@ -3,13 +3,85 @@ package database
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // TxManager handles the transaction lifecycle.
 type TxManager interface {
+	// ExecTx runs fn in a read-committed, read-write transaction. See ExecTxOpts.
+	// When ctx carries a transaction (see WithTx), fn instead runs in a savepoint of it: an
+	// error from fn rolls back only the savepoint and is returned to the outer closure, which
+	// may handle it and carry on.
 	ExecTx(ctx context.Context, fn func(tx pgx.Tx) error) error
+	// ExecTxOpts runs fn in a transaction begun with opts, e.g. Serializable or read-only.
+	// When the database aborts the transaction with a serialization failure or deadlock, fn is
+	// run again on a fresh transaction a few times, so fn must only have database side effects;
+	// anything else belongs in AfterCommit.
+	ExecTxOpts(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error
+	// AfterCommit defers fn until the transaction tx (as passed to an ExecTx closure) commits.
+	// Callbacks registered inside a savepoint (tx.Begin) are dropped if the savepoint rolls back.
+	// Nothing runs if the outer transaction rolls back or fails to commit.
+	AfterCommit(tx pgx.Tx, fn func())
+}
+
+type txKey struct{}
+
+// WithTx returns a context carrying tx, a transaction from an ExecTx closure. Pass it to
+// services called inside the closure so their own RunInTransaction joins tx as a savepoint
+// instead of opening a separate transaction.
+func WithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction stored by WithTx, if any.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+type clinicKey struct{}
+
+// WithClinic returns a context whose transactions act for clinicID, overriding the caller's
+// own clinic for row-level security. Platform operations on another clinic's data use it.
+func WithClinic(ctx context.Context, clinicID uuid.UUID) context.Context {
+	return context.WithValue(ctx, clinicKey{}, clinicID)
+}
+
+// ClinicFromContext returns the clinic set by WithClinic, if any.
+func ClinicFromContext(ctx context.Context) (uuid.UUID, bool) {
+	clinicID, ok := ctx.Value(clinicKey{}).(uuid.UUID)
+	return clinicID, ok
+}
+
+type platformAccessKey struct{}
+
+// WithPlatformAccess returns a context whose transactions run as the platform role, which
+// row-level security does not apply to. Only platform jobs that work across clinics, such as
+// the reminder scheduler, use it; work for a single clinic uses WithClinic instead.
+func WithPlatformAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, platformAccessKey{}, true)
+}
+
+// PlatformAccess reports whether transactions for ctx run as the platform role.
+func PlatformAccess(ctx context.Context) bool {
+	platform, _ := ctx.Value(platformAccessKey{}).(bool)
+	return platform
+}
+
+type primaryReadsKey struct{}
+
+// WithPrimaryReads returns a context whose reads go to the primary even where a read replica
+// would serve them, so a client that has just written sees its own changes.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryReads reports whether reads for ctx must go to the primary.
+func PrimaryReads(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return pinned
 }
 
 // Querier is the Common Interface for both *pgxpool.Pool and pgx.Tx.
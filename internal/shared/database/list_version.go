@@ -0,0 +1,14 @@
+package database
+
+import "time"
+
+// ListVersion summarizes the filtered set behind a list endpoint. Repositories compute it in
+// one aggregate query, so clients can ask "did anything change" without loading the rows:
+// inserts and soft deletes change Count, and updates move LastUpdatedAt.
+type ListVersion struct {
+	Count         int64
+	LastUpdatedAt *time.Time
+	// Checksum covers rows the list embeds that have no updated_at of their own, such as
+	// join-table assignments. Zero when the list embeds nothing else.
+	Checksum int64
+}
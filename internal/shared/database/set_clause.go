@@ -0,0 +1,39 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/optional"
+)
+
+// SetClause builds the SET list of a partial UPDATE, so only the columns a request
+// actually changes are written. Column names must be constants, never client input.
+type SetClause struct {
+	columns []string
+	args    []any
+}
+
+// Set adds "column = value".
+func (s *SetClause) Set(column string, value any) {
+	s.args = append(s.args, value)
+	s.columns = append(s.columns, fmt.Sprintf("%s = $%d", column, len(s.args)))
+}
+
+// SetOptional adds the column only if o was present; an explicit null writes NULL.
+func SetOptional[T any](s *SetClause, column string, o optional.Optional[T]) {
+	if value, ok := o.Get(); ok {
+		s.Set(column, value)
+	}
+}
+
+// IsEmpty reports whether no column was added.
+func (s *SetClause) IsEmpty() bool {
+	return len(s.columns) == 0
+}
+
+// SQL returns the comma-separated assignments and their arguments. Placeholders are
+// numbered from $1, so WHERE arguments follow at $len(args)+1 onwards.
+func (s *SetClause) SQL() (string, []any) {
+	return strings.Join(s.columns, ", "), s.args
+}
@@ -0,0 +1,105 @@
+// Package events is an in-process hook registry. A module publishes a domain event and other
+// modules react to it without either importing the other; only this package is shared.
+package events
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Bus delivers published events to their subscribers synchronously, inside the publisher's
+// transaction, so a subscriber's writes commit or roll back together with the publisher's.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]func(ctx context.Context, tx pgx.Tx, event any) error
+}
+
+// NewBus creates an empty bus.
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[reflect.Type][]func(ctx context.Context, tx pgx.Tx, event any) error)}
+}
+
+// Subscribe registers fn for every published event of type E. Subscriptions are made at startup.
+func Subscribe[E any](b *Bus, fn func(ctx context.Context, tx pgx.Tx, event E) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := reflect.TypeFor[E]()
+	b.handlers[t] = append(b.handlers[t], func(ctx context.Context, tx pgx.Tx, event any) error {
+		return fn(ctx, tx, event.(E))
+	})
+}
+
+// Publish runs every subscriber of E in registration order and stops at the first error,
+// which the publisher should return so its transaction rolls back. A nil bus publishes nothing.
+func Publish[E any](ctx context.Context, b *Bus, tx pgx.Tx, event E) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	handlers := b.handlers[reflect.TypeFor[E]()]
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, tx, event); err != nil {
+			return fmt.Errorf("events: %T subscriber failed: %w", event, err)
+		}
+	}
+	return nil
+}
+
+// --- Events ---
+
+// AppointmentBooked is published inside the transaction that books an appointment, by staff or
+// by a guest.
+type AppointmentBooked struct {
+	ClinicID         uuid.UUID
+	AppointmentID    uuid.UUID
+	PatientProfileID uuid.UUID
+	PractitionerID   uuid.UUID
+	StartTime        time.Time
+	EndTime          time.Time
+	Status           string
+}
+
+// AppointmentCompleted is published when an appointment is marked as completed.
+type AppointmentCompleted struct {
+	ClinicID         uuid.UUID
+	AppointmentID    uuid.UUID
+	PatientProfileID uuid.UUID
+}
+
+// AppointmentStatusChanged is published inside the transaction that moves an appointment from
+// one status to another, staff or guest. ChangedBy is the employee, or nil when the guest did.
+type AppointmentStatusChanged struct {
+	ClinicID         uuid.UUID
+	AppointmentID    uuid.UUID
+	PatientProfileID uuid.UUID
+	PractitionerID   uuid.UUID
+	From             string
+	To               string
+	ChangedBy        *uuid.UUID
+	ChangedAt        time.Time
+}
+
+// PatientRegistered is published inside the transaction that makes a patient profile REGISTERED,
+// whether staff registered the patient or a guest was promoted.
+type PatientRegistered struct {
+	ClinicID         uuid.UUID
+	PatientProfileID uuid.UUID
+	RegisteredAt     time.Time
+}
+
+// ProfilesMerged is published inside the merge transaction when a duplicate patient profile is
+// folded into another. Modules that reference patients move their rows from SourceProfileID to
+// TargetProfileID; the source is archived afterwards.
+type ProfilesMerged struct {
+	ClinicID        uuid.UUID
+	SourceProfileID uuid.UUID
+	TargetProfileID uuid.UUID
+}
@@ -0,0 +1,143 @@
+// Package export assembles a patient's data export from sections the modules that hold the
+// patient's data contribute, so the module serving the export imports none of them.
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SectionFunc returns a module's data about the patient, encoded as JSON under the section's name.
+type SectionFunc func(ctx context.Context, clinicID, profileID uuid.UUID) (any, error)
+
+// Registry holds the sections of a patient export in registration order.
+type Registry struct {
+	mu       sync.RWMutex
+	sections []registeredSection
+}
+
+type registeredSection struct {
+	name string
+	fn   SectionFunc
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds the section name, produced by fn. Sections are registered at startup; a name may
+// be registered only once.
+func (r *Registry) Register(name string, fn SectionFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sections {
+		if s.name == name {
+			panic(fmt.Sprintf("export: section %q registered twice", name))
+		}
+	}
+	r.sections = append(r.sections, registeredSection{name: name, fn: fn})
+}
+
+// Collect runs every section for the patient in registration order and stops at the first
+// error. Sections are gathered before anything is written, so a failure can still be reported
+// with a proper status. A nil registry collects no sections.
+func (r *Registry) Collect(ctx context.Context, clinicID, profileID uuid.UUID) (*Document, error) {
+	doc := &Document{ProfileID: profileID, ExportedAt: time.Now().UTC()}
+	if r == nil {
+		return doc, nil
+	}
+	r.mu.RLock()
+	sections := append([]registeredSection(nil), r.sections...)
+	r.mu.RUnlock()
+
+	for _, s := range sections {
+		data, err := s.fn(ctx, clinicID, profileID)
+		if err != nil {
+			return nil, fmt.Errorf("export: section %q failed: %w", s.name, err)
+		}
+		doc.Sections = append(doc.Sections, Section{Name: s.name, Data: data})
+	}
+	return doc, nil
+}
+
+// Document is a collected patient export.
+type Document struct {
+	ProfileID  uuid.UUID
+	ExportedAt time.Time
+	Sections   []Section
+}
+
+// Section is one module's part of a Document.
+type Section struct {
+	Name string
+	Data any
+}
+
+// Names returns the names of the document's sections, in order.
+func (d *Document) Names() []string {
+	names := make([]string, len(d.Sections))
+	for i, s := range d.Sections {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// WriteTo writes the document as one JSON object,
+//
+//	{"profile_id": ..., "exported_at": ..., "sections": {"<name>": ..., ...}}
+//
+// encoding one section at a time so a large export is never held encoded in memory.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	header, err := json.Marshal(struct {
+		ProfileID  uuid.UUID `json:"profile_id"`
+		ExportedAt time.Time `json:"exported_at"`
+	}{d.ProfileID, d.ExportedAt})
+	if err != nil {
+		return cw.n, fmt.Errorf("export: failed to encode header: %w", err)
+	}
+	// Reopen the header object to append the sections to it.
+	bw.Write(header[:len(header)-1])
+	bw.WriteString(`,"sections":{`)
+
+	for i, s := range d.Sections {
+		name, _ := json.Marshal(s.Name)
+		data, err := json.Marshal(s.Data)
+		if err != nil {
+			return cw.n, fmt.Errorf("export: failed to encode section %q: %w", s.Name, err)
+		}
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.Write(name)
+		bw.WriteByte(':')
+		bw.Write(data)
+	}
+	bw.WriteString("}}\n")
+	if err := bw.Flush(); err != nil {
+		return cw.n, fmt.Errorf("export: failed to write document: %w", err)
+	}
+	return cw.n, nil
+}
+
+// countingWriter counts the bytes written through it, for WriteTo's result.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
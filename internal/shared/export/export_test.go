@@ -0,0 +1,103 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRegistryCollectsEverySection(t *testing.T) {
+	clinicID, profileID := uuid.New(), uuid.New()
+	registry := NewRegistry()
+	registry.Register("visits", func(_ context.Context, c, p uuid.UUID) (any, error) {
+		if c != clinicID || p != profileID {
+			t.Errorf("visits got clinic %s, profile %s", c, p)
+		}
+		return []map[string]string{{"reason": "checkup"}}, nil
+	})
+	registry.Register("notes", func(context.Context, uuid.UUID, uuid.UUID) (any, error) {
+		return map[string]int{"count": 2}, nil
+	})
+
+	doc, err := registry.Collect(context.Background(), clinicID, profileID)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+
+	var got struct {
+		ProfileID uuid.UUID `json:"profile_id"`
+		Sections  struct {
+			Visits []map[string]string `json:"visits"`
+			Notes  map[string]int      `json:"notes"`
+		} `json:"sections"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if got.ProfileID != profileID {
+		t.Errorf("profile_id = %s, want %s", got.ProfileID, profileID)
+	}
+	if len(got.Sections.Visits) != 1 || got.Sections.Visits[0]["reason"] != "checkup" {
+		t.Errorf("visits section = %v", got.Sections.Visits)
+	}
+	if got.Sections.Notes["count"] != 2 {
+		t.Errorf("notes section = %v", got.Sections.Notes)
+	}
+	if names := doc.Names(); len(names) != 2 || names[0] != "visits" || names[1] != "notes" {
+		t.Errorf("Names() = %v, want registration order", names)
+	}
+}
+
+func TestRegistryCollectStopsAtFailingSection(t *testing.T) {
+	boom := errors.New("boom")
+	registry := NewRegistry()
+	registry.Register("broken", func(context.Context, uuid.UUID, uuid.UUID) (any, error) {
+		return nil, boom
+	})
+	registry.Register("never", func(context.Context, uuid.UUID, uuid.UUID) (any, error) {
+		t.Error("a section after a failing one ran")
+		return nil, nil
+	})
+
+	if _, err := registry.Collect(context.Background(), uuid.New(), uuid.New()); !errors.Is(err, boom) {
+		t.Errorf("Collect error = %v, want %v", err, boom)
+	}
+}
+
+func TestRegistryRejectsDuplicateSection(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("profile", func(context.Context, uuid.UUID, uuid.UUID) (any, error) { return nil, nil })
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a section name twice did not panic")
+		}
+	}()
+	registry.Register("profile", func(context.Context, uuid.UUID, uuid.UUID) (any, error) { return nil, nil })
+}
+
+func TestEmptyDocumentIsValidJSON(t *testing.T) {
+	var registry *Registry
+	doc, err := registry.Collect(context.Background(), uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("output is not valid JSON: %s", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+// Package notification renders patient-facing message templates in the patient's language.
+package notification
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/locale"
+	"github.com/rs/zerolog/log"
+)
+
+// Template keys. Every key ships one file per supported language, named "<key>.<lang>.tmpl".
+const (
+	TemplateAppointmentConfirmation = "appointment_confirmation"
+	TemplateAppointmentReminder     = "appointment_reminder"
+	TemplatePhoneVerificationCode   = "phone_verification_code"
+)
+
+// AppointmentData is the data passed to the appointment templates. StartsAt is
+// preformatted by the caller in the clinic's time zone.
+type AppointmentData struct {
+	ClinicName       string
+	ClinicPhone      string
+	PractitionerName string
+	StartsAt         string
+}
+
+// PhoneCodeData is the data passed to the phone verification template.
+type PhoneCodeData struct {
+	Code          string
+	ExpiresInMins int
+}
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templates maps a template key to its parsed variants by language.
+var templates = mustLoadTemplates()
+
+func mustLoadTemplates() map[string]map[locale.Language]*template.Template {
+	files, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		panic(fmt.Sprintf("notification: listing templates: %v", err))
+	}
+
+	loaded := make(map[string]map[locale.Language]*template.Template)
+	for _, file := range files {
+		name := strings.TrimSuffix(strings.TrimPrefix(file, "templates/"), ".tmpl")
+		key, code, ok := strings.Cut(name, ".")
+		lang, supported := locale.Parse(code)
+		if !ok || !supported {
+			panic(fmt.Sprintf("notification: template %q is not named <key>.<lang>.tmpl", file))
+		}
+
+		tmpl := template.Must(template.ParseFS(templateFS, file)).Option("missingkey=error")
+		if loaded[key] == nil {
+			loaded[key] = make(map[locale.Language]*template.Template)
+		}
+		loaded[key][lang] = tmpl
+	}
+	return loaded
+}
+
+// Render executes the template key in lang. If that language has no variant, the
+// clinic's default language is used instead and the gap is logged so it can be filled.
+func Render(key string, lang, clinicDefault locale.Language, data any) (string, error) {
+	variants, ok := templates[key]
+	if !ok {
+		return "", fmt.Errorf("notification: unknown template %q", key)
+	}
+
+	tmpl, ok := variants[lang]
+	if !ok {
+		log.Warn().Str("template", key).Str("language", string(lang)).Str("fallback", string(clinicDefault)).Msg("Template missing for language, falling back to clinic default")
+		if tmpl, ok = variants[clinicDefault]; !ok {
+			tmpl, ok = variants[locale.Fallback]
+		}
+		if !ok {
+			return "", fmt.Errorf("notification: template %q has no %q, %q or %q variant", key, lang, clinicDefault, locale.Fallback)
+		}
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("notification: rendering %q: %w", key, err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
@@ -15,6 +15,19 @@ type BaseService struct {
 
 // RunInTransaction wraps the atomic business operation.
 // This allows you to combine multiple Repo calls into one Atomic Unit of Work.
+// Called with a context from database.WithTx, it joins that transaction as a savepoint.
 func (s *BaseService) RunInTransaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
 	return s.Tx.ExecTx(ctx, fn)
 }
+
+// RunInTransactionOpts is RunInTransaction with explicit transaction options, for operations
+// that need a stronger isolation level or a read-only snapshot. fn may run more than once.
+func (s *BaseService) RunInTransactionOpts(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return s.Tx.ExecTxOpts(ctx, opts, fn)
+}
+
+// AfterCommit schedules side effects (cache invalidation, in-process events) that must only
+// happen once the surrounding RunInTransaction commits.
+func (s *BaseService) AfterCommit(tx pgx.Tx, fn func()) {
+	s.Tx.AfterCommit(tx, fn)
+}
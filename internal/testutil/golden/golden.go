@@ -0,0 +1,50 @@
+// Package golden compares what a test produces with a file checked in under the package's
+// testdata directory, so a change to a wire format shows up as a failing test and a diff in review:
+//
+//	golden.AssertJSON(t, "profile_response.v1.json", response)
+//
+// Run the tests with -update to rewrite the files after an intended change.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// AssertJSON fails the test unless v, encoded as indented JSON, matches testdata/name.
+func AssertJSON(t testing.TB, name string, v any) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("golden: failed to encode %s: %v", name, err)
+	}
+	Assert(t, name, append(got, '\n'))
+}
+
+// Assert fails the test unless got matches testdata/name byte for byte.
+func Assert(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden: %v (run the tests with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match its golden file:\n got %s\nwant %s", path, got, want)
+	}
+}
@@ -0,0 +1,4 @@
+-- This migration removes the platform-level permissions.
+
+DELETE FROM role_permissions WHERE permission_id = 90;
+DELETE FROM permissions WHERE id = 90;
@@ -0,0 +1,6 @@
+-- This migration introduces platform-level permissions used by internal support tooling.
+-- They are only ever granted to roles held by platform staff, never to clinic roles.
+
+INSERT INTO permissions (id, permission_key) VALUES
+(90, 'platform.impersonate')
+ON CONFLICT (id) DO NOTHING;
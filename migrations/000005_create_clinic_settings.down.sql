@@ -0,0 +1,4 @@
+-- This migration removes the per-section clinic settings storage.
+
+DROP TRIGGER IF EXISTS set_timestamp ON clinic_settings;
+DROP TABLE IF EXISTS clinic_settings;
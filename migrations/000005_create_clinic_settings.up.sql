@@ -0,0 +1,18 @@
+-- This migration introduces per-clinic, per-section settings storage.
+-- Each section is versioned independently so concurrent edits to different
+-- sections never conflict, while edits to the same section are detected.
+
+CREATE TABLE clinic_settings (
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    section VARCHAR(100) NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}'::jsonb,
+    version INT NOT NULL DEFAULT 1,
+    updated_by UUID REFERENCES profiles(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (clinic_id, section)
+);
+COMMENT ON TABLE clinic_settings IS 'Typed clinic configuration, one JSONB document per settings section.';
+COMMENT ON COLUMN clinic_settings.version IS 'Incremented on every write; used for optimistic concurrency control.';
+
+CREATE TRIGGER set_timestamp BEFORE UPDATE ON clinic_settings FOR EACH ROW EXECUTE FUNCTION trigger_set_timestamp();
@@ -0,0 +1,6 @@
+-- This migration removes the authentication failure log.
+
+DELETE FROM role_permissions WHERE permission_id = 91;
+DELETE FROM permissions WHERE id = 91;
+
+DROP TABLE IF EXISTS auth_failures;
@@ -0,0 +1,24 @@
+-- This migration introduces a lightweight record of rejected authentication attempts
+-- for investigating credential stuffing. Identifiers are stored as keyed hashes only.
+
+CREATE TABLE auth_failures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    clinic_slug VARCHAR(100),
+    identifier_hash CHAR(64) NOT NULL, -- hex HMAC-SHA256 of the normalized email/phone
+    ip_address INET,
+    user_agent TEXT,
+    reason VARCHAR(50) NOT NULL,
+    -- Set when a later successful login with the same identifier ends the failure streak.
+    resolved_at TIMESTAMPTZ,
+    resolved_profile_id UUID REFERENCES profiles(id) ON DELETE SET NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE auth_failures IS 'Rejected login/OTP attempts. The raw identifier is never stored.';
+
+CREATE INDEX idx_auth_failures_occurred_at ON auth_failures (occurred_at);
+CREATE INDEX idx_auth_failures_identifier_open ON auth_failures (identifier_hash) WHERE resolved_at IS NULL;
+CREATE INDEX idx_auth_failures_ip ON auth_failures (ip_address, occurred_at);
+
+INSERT INTO permissions (id, permission_key) VALUES
+(91, 'platform.auth_failures.read')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,5 @@
+-- This migration removes the clinic slug.
+
+DROP INDEX IF EXISTS idx_clinics_unique_active_slug;
+ALTER TABLE clinics DROP CONSTRAINT IF EXISTS chk_clinics_slug_format;
+ALTER TABLE clinics DROP COLUMN IF EXISTS slug;
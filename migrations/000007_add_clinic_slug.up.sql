@@ -0,0 +1,15 @@
+-- This migration gives every clinic a URL-safe slug used to resolve the tenant
+-- on unauthenticated requests (subdomain or X-Clinic-Slug header), e.g. login.
+
+ALTER TABLE clinics ADD COLUMN slug VARCHAR(63);
+
+-- Backfill existing clinics with a stable, unique placeholder they can rename later.
+UPDATE clinics SET slug = 'clinic-' || substr(replace(id::text, '-', ''), 1, 12) WHERE slug IS NULL;
+
+ALTER TABLE clinics ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE clinics ADD CONSTRAINT chk_clinics_slug_format CHECK (slug ~ '^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$');
+
+COMMENT ON COLUMN clinics.slug IS 'Lowercase DNS label identifying the clinic (e.g. the subdomain in clinic-a.mastara.com).';
+
+-- Soft-delete aware unique index; also serves the per-login slug lookup.
+CREATE UNIQUE INDEX idx_clinics_unique_active_slug ON clinics (slug) WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- This migration removes sandbox clinic support.
+
+DELETE FROM role_permissions WHERE permission_id = 50;
+DELETE FROM permissions WHERE id = 50;
+DROP INDEX IF EXISTS idx_clinics_non_sandbox;
+ALTER TABLE clinics DROP COLUMN IF EXISTS is_sandbox;
@@ -0,0 +1,13 @@
+-- This migration adds sandbox clinics: demo/trial tenants filled with fake data that can be wiped and reseeded.
+
+ALTER TABLE clinics ADD COLUMN is_sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN clinics.is_sandbox IS 'Demo/trial tenant. Excluded from platform metrics and billing; its data may be reset.';
+
+-- Partial index so platform reporting can cheaply skip sandbox tenants.
+CREATE INDEX idx_clinics_non_sandbox ON clinics (id) WHERE NOT is_sandbox AND deleted_at IS NULL;
+
+-- Intended for the clinic owner role only.
+INSERT INTO permissions (id, permission_key) VALUES
+(50, 'clinic.reset')
+ON CONFLICT (id) DO NOTHING;
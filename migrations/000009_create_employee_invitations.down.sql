@@ -0,0 +1,3 @@
+-- This migration removes employee invitation tokens.
+
+DROP TABLE IF EXISTS employee_invitations;
@@ -0,0 +1,18 @@
+-- This migration stores single-use invitation tokens that let invited employees
+-- activate their account and choose a password. Only a SHA-256 hash of each token
+-- is stored, so a database leak cannot be used to hijack pending invitations.
+
+CREATE TABLE employee_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    employee_profile_id UUID NOT NULL REFERENCES employees(profile_id) ON DELETE CASCADE,
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE employee_invitations IS 'Single-use tokens for activating invited employees.';
+COMMENT ON COLUMN employee_invitations.token_hash IS 'Hex SHA-256 of the opaque token sent to the invitee.';
+
+CREATE UNIQUE INDEX idx_employee_invitations_token_hash ON employee_invitations (token_hash);
+CREATE INDEX idx_employee_invitations_employee ON employee_invitations (employee_profile_id);
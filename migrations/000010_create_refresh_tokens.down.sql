@@ -0,0 +1,3 @@
+-- This migration removes refresh tokens.
+
+DROP TABLE IF EXISTS refresh_tokens;
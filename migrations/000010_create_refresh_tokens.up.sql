@@ -0,0 +1,21 @@
+-- This migration stores long-lived refresh tokens so staff sessions survive access-token expiry.
+-- Tokens are opaque and stored as SHA-256 hashes. Every refresh rotates the token; all tokens
+-- minted from one login share a session_id so a whole session can be revoked at once.
+
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    session_id UUID NOT NULL,
+    employee_profile_id UUID NOT NULL REFERENCES employees(profile_id) ON DELETE CASCADE,
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    replaced_by UUID REFERENCES refresh_tokens(id) ON DELETE SET NULL
+);
+COMMENT ON TABLE refresh_tokens IS 'Rotating, revocable refresh tokens for staff sessions.';
+COMMENT ON COLUMN refresh_tokens.replaced_by IS 'The token issued when this one was rotated; presenting a rotated token again revokes the session.';
+
+CREATE UNIQUE INDEX idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX idx_refresh_tokens_session ON refresh_tokens (session_id);
+CREATE INDEX idx_refresh_tokens_employee ON refresh_tokens (employee_profile_id);
@@ -0,0 +1,3 @@
+-- This migration removes stored booking answers.
+
+ALTER TABLE appointments DROP COLUMN IF EXISTS booking_answers;
@@ -0,0 +1,7 @@
+-- This migration stores guests' answers to the clinic's custom booking questions.
+-- Each answer snapshots the question label and type, so editing or removing a
+-- question never invalidates historical answers.
+
+ALTER TABLE appointments ADD COLUMN booking_answers JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+COMMENT ON COLUMN appointments.booking_answers IS 'Array of {question_id, label, type, value} captured at booking time.';
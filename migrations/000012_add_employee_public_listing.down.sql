@@ -0,0 +1,8 @@
+-- This migration removes the public practitioner listing fields.
+
+DROP INDEX IF EXISTS idx_employees_public_listing;
+ALTER TABLE employees
+    DROP COLUMN IF EXISTS sort_index,
+    DROP COLUMN IF EXISTS photo_url,
+    DROP COLUMN IF EXISTS public_bio,
+    DROP COLUMN IF EXISTS is_publicly_listed;
@@ -0,0 +1,13 @@
+-- This migration lets clinics publish selected practitioners in the public booking widget.
+-- Only the columns added here (plus the name and job title) are ever exposed publicly.
+
+ALTER TABLE employees
+    ADD COLUMN is_publicly_listed BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN public_bio TEXT,
+    ADD COLUMN photo_url TEXT,
+    ADD COLUMN sort_index INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN employees.is_publicly_listed IS 'Whether the employee appears in the public practitioner directory.';
+COMMENT ON COLUMN employees.sort_index IS 'Ascending display order in the public practitioner directory.';
+
+CREATE INDEX idx_employees_public_listing ON employees (clinic_id, sort_index) WHERE is_publicly_listed AND deleted_at IS NULL;
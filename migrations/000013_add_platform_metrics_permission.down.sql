@@ -0,0 +1,4 @@
+-- This migration removes the platform metrics permission.
+
+DELETE FROM role_permissions WHERE permission_id = 92;
+DELETE FROM permissions WHERE id = 92;
@@ -0,0 +1,5 @@
+-- This migration adds the platform permission for reading process metrics from the internal API.
+
+INSERT INTO permissions (id, permission_key) VALUES
+(92, 'platform.metrics.read')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,5 @@
+-- This migration removes the patient language preference.
+
+ALTER TABLE profiles
+    DROP CONSTRAINT IF EXISTS chk_profiles_preferred_language,
+    DROP COLUMN IF EXISTS preferred_language;
@@ -0,0 +1,8 @@
+-- This migration records the language each patient wants to receive patient-facing content in.
+-- NULL means no preference: the request's Accept-Language or the clinic default applies.
+
+ALTER TABLE profiles
+    ADD COLUMN preferred_language VARCHAR(8),
+    ADD CONSTRAINT chk_profiles_preferred_language CHECK (preferred_language IN ('ar', 'en'));
+
+COMMENT ON COLUMN profiles.preferred_language IS 'ISO 639-1 code of the patient''s preferred language for notifications and public pages.';
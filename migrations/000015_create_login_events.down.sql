@@ -0,0 +1,3 @@
+-- This migration removes the employee login history.
+
+DROP TABLE IF EXISTS login_events;
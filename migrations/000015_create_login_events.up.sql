@@ -0,0 +1,17 @@
+-- This migration adds a per-employee login history so clinic admins can audit access.
+-- Unlike auth_failures, events are only written once the identifier matched an employee,
+-- so they carry the profile and clinic rather than a hashed identifier.
+
+CREATE TABLE login_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    employee_profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    succeeded BOOLEAN NOT NULL,
+    failure_reason VARCHAR(50), -- NULL for successful logins
+    ip_address INET,
+    user_agent TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE login_events IS 'Successful and rejected login attempts for known employees.';
+
+CREATE INDEX idx_login_events_employee ON login_events (employee_profile_id, occurred_at DESC);
@@ -0,0 +1,3 @@
+-- This migration removes the employee email change requests.
+
+DROP TABLE IF EXISTS employee_email_changes;
@@ -0,0 +1,26 @@
+-- This migration supports two-step employee email changes. The new address must be
+-- verified before profiles.email is rewritten, and the old address receives an undo
+-- link afterwards. As with invitations, only SHA-256 hashes of the tokens are stored.
+
+CREATE TABLE employee_email_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    employee_profile_id UUID NOT NULL REFERENCES employees(profile_id) ON DELETE CASCADE,
+    old_email VARCHAR(255),
+    new_email VARCHAR(255) NOT NULL,
+    requested_by UUID REFERENCES profiles(id) ON DELETE SET NULL,
+    verify_token_hash CHAR(64) NOT NULL,
+    verify_expires_at TIMESTAMPTZ NOT NULL,
+    undo_token_hash CHAR(64),
+    undo_expires_at TIMESTAMPTZ,
+    verified_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    undone_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE employee_email_changes IS 'Pending and completed employee email changes with their verification and undo tokens.';
+
+CREATE UNIQUE INDEX idx_employee_email_changes_verify_token ON employee_email_changes (verify_token_hash);
+CREATE UNIQUE INDEX idx_employee_email_changes_undo_token ON employee_email_changes (undo_token_hash) WHERE undo_token_hash IS NOT NULL;
+-- At most one open request per employee; a new request cancels the previous one first.
+CREATE UNIQUE INDEX idx_employee_email_changes_pending ON employee_email_changes (employee_profile_id) WHERE verified_at IS NULL AND cancelled_at IS NULL;
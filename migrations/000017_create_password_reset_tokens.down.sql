@@ -0,0 +1,3 @@
+-- This migration removes the password reset tokens.
+
+DROP TABLE IF EXISTS password_reset_tokens;
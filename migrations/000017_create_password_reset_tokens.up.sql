@@ -0,0 +1,17 @@
+-- This migration stores single-use password reset tokens for the forgot-password flow.
+-- As with invitations, only a SHA-256 hash of each token is stored.
+
+CREATE TABLE password_reset_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    employee_profile_id UUID NOT NULL REFERENCES employees(profile_id) ON DELETE CASCADE,
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    -- Set when the token is used, or when a newer token or a password change supersedes it.
+    consumed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE password_reset_tokens IS 'Single-use tokens for resetting a forgotten employee password.';
+
+CREATE UNIQUE INDEX idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+CREATE INDEX idx_password_reset_tokens_employee_open ON password_reset_tokens (employee_profile_id) WHERE consumed_at IS NULL;
@@ -0,0 +1,4 @@
+-- This migration removes chunked upload sessions.
+
+DROP TABLE IF EXISTS upload_chunks;
+DROP TABLE IF EXISTS upload_sessions;
@@ -0,0 +1,36 @@
+-- This migration adds resumable, chunked upload sessions.
+-- A session declares the whole file up front; chunks are stored as they arrive and
+-- assembled into a single stored file once every chunk is present.
+
+CREATE TABLE upload_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL,
+    file_name VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL CHECK (size > 0),
+    -- Hex-encoded SHA-256 of the whole file, as declared by the client.
+    checksum CHAR(64) NOT NULL,
+    chunk_size BIGINT NOT NULL CHECK (chunk_size > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'COMPLETED')),
+    -- Set once the session completes; the key other modules reference the file by.
+    storage_key TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE upload_sessions IS 'Chunked file uploads; pending sessions past expires_at are removed with their chunks.';
+
+CREATE INDEX idx_upload_sessions_clinic_id ON upload_sessions (clinic_id);
+CREATE INDEX idx_upload_sessions_pending_expiry ON upload_sessions (expires_at) WHERE status = 'PENDING';
+
+CREATE TABLE upload_chunks (
+    session_id UUID NOT NULL REFERENCES upload_sessions(id) ON DELETE CASCADE,
+    chunk_index INT NOT NULL CHECK (chunk_index >= 0),
+    size BIGINT NOT NULL,
+    -- Hex-encoded SHA-256 of the chunk, used to make re-sent chunks idempotent.
+    checksum CHAR(64) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (session_id, chunk_index)
+);
+COMMENT ON TABLE upload_chunks IS 'Chunks received so far for a pending upload session.';
@@ -0,0 +1,4 @@
+-- This migration removes the registration timestamp from profiles.
+
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS registered_at;
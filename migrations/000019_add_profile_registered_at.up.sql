@@ -0,0 +1,7 @@
+-- This migration records when each patient profile became REGISTERED, whether staff registered it
+-- or it was promoted automatically. Profiles registered before this migration keep a NULL.
+
+ALTER TABLE profiles
+    ADD COLUMN registered_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN profiles.registered_at IS 'When the profile moved from GUEST to REGISTERED; NULL for guests and profiles registered before tracking began.';
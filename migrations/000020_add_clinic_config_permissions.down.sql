@@ -0,0 +1,4 @@
+-- This migration removes the clinic configuration bundle permissions.
+
+DELETE FROM role_permissions WHERE permission_id IN (51, 52);
+DELETE FROM permissions WHERE id IN (51, 52);
@@ -0,0 +1,7 @@
+-- This migration adds the permissions for exporting and importing a clinic's configuration bundle.
+-- Intended for the clinic owner role only.
+
+INSERT INTO permissions (id, permission_key) VALUES
+(51, 'clinic.config.export'),
+(52, 'clinic.config.import')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,4 @@
+-- This migration removes the employee contact details permission.
+
+DELETE FROM role_permissions WHERE permission_id = 5;
+DELETE FROM permissions WHERE id = 5;
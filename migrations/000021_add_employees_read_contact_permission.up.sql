@@ -0,0 +1,10 @@
+-- This migration adds the permission to see colleagues' contact details in employee responses.
+-- Roles that can already edit employees keep seeing them.
+
+INSERT INTO permissions (id, permission_key) VALUES
+(5, 'employees.read_contact')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rp.role_id, 5 FROM role_permissions rp WHERE rp.permission_id = 3
+ON CONFLICT DO NOTHING;
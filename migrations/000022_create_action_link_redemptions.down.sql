@@ -0,0 +1,3 @@
+-- This migration removes the action link redemption log.
+
+DROP TABLE IF EXISTS action_link_redemptions;
@@ -0,0 +1,17 @@
+-- This migration records used action links, the signed links in staff notification emails.
+-- The links themselves are stateless PASETO tokens; a row here is what makes each one single-use.
+
+CREATE TABLE action_link_redemptions (
+    -- The jti of the redeemed token.
+    token_id UUID PRIMARY KEY,
+    employee_profile_id UUID NOT NULL REFERENCES employees(profile_id) ON DELETE CASCADE,
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    action TEXT NOT NULL,
+    entity_id UUID NOT NULL,
+    -- Rows may be deleted once the token has expired; it is rejected on expiry anyway.
+    expires_at TIMESTAMPTZ NOT NULL,
+    redeemed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE action_link_redemptions IS 'Action links that have been used, keyed by token jti.';
+
+CREATE INDEX idx_action_link_redemptions_expires_at ON action_link_redemptions (expires_at);
@@ -0,0 +1,7 @@
+-- This migration removes the utilization report permission and indexes.
+
+DELETE FROM role_permissions WHERE permission_id = 60;
+DELETE FROM permissions WHERE id = 60;
+
+DROP INDEX IF EXISTS idx_doctor_schedules_clinic_day;
+DROP INDEX IF EXISTS idx_appointments_clinic_start_time;
@@ -0,0 +1,14 @@
+-- This migration supports the utilization report: indexes for its range scans over
+-- appointments and weekly schedules, and the permission to view it.
+-- Roles that can already view finance reports get it too.
+
+CREATE INDEX IF NOT EXISTS idx_appointments_clinic_start_time ON appointments (clinic_id, start_time) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_doctor_schedules_clinic_day ON doctor_schedules (clinic_id, day_of_week);
+
+INSERT INTO permissions (id, permission_key) VALUES
+(60, 'reports.read')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rp.role_id, 60 FROM role_permissions rp WHERE rp.permission_id = 33
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+-- This migration removes the unique index on system role names.
+
+DROP INDEX IF EXISTS idx_roles_unique_system_name;
@@ -0,0 +1,4 @@
+-- This migration makes system role names unique. The clinic role index cannot do it because
+-- system roles have a NULL clinic_id, and the RBAC seeder upserts system roles by name.
+
+CREATE UNIQUE INDEX idx_roles_unique_system_name ON roles (name) WHERE is_system_role AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+-- This migration removes appointment sub-statuses.
+
+DROP INDEX IF EXISTS idx_appointments_clinic_sub_status;
+ALTER TABLE appointments DROP COLUMN IF EXISTS sub_status;
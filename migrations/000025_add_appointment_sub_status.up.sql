@@ -0,0 +1,10 @@
+-- This migration adds clinic-defined sub-statuses to appointments. The definitions live in the
+-- "appointment_sub_statuses" settings section; each refines exactly one core status, which stays
+-- the only thing transitions and reports look at.
+
+ALTER TABLE appointments ADD COLUMN sub_status VARCHAR(50);
+
+COMMENT ON COLUMN appointments.sub_status IS 'Id of a clinic sub-status refining status, e.g. awaiting_xray. NULL when none applies.';
+
+-- Lets a settings change find appointments still using a sub-status it removes.
+CREATE INDEX idx_appointments_clinic_sub_status ON appointments (clinic_id, sub_status) WHERE sub_status IS NOT NULL;
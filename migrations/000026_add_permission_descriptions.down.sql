@@ -0,0 +1,3 @@
+-- This migration removes permission descriptions.
+
+ALTER TABLE permissions DROP COLUMN IF EXISTS description;
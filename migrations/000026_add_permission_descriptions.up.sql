@@ -0,0 +1,37 @@
+-- This migration gives every permission a human-readable description for role editors.
+-- The RBAC seeder keeps descriptions in sync with the permission catalog in code afterwards.
+
+ALTER TABLE permissions ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+UPDATE permissions p SET description = v.description
+FROM (VALUES
+('employees.invite', 'Invite new staff members to the clinic.'),
+('employees.read', 'See colleagues'' names, job titles and status.'),
+('employees.update', 'Edit colleagues'' details and public profiles.'),
+('employees.deactivate', 'Suspend, reactivate or terminate staff, sign them out and lift lockouts.'),
+('employees.read_contact', 'See colleagues'' email, phone number, last login and inviter.'),
+('patients.create', 'Register new patients.'),
+('patients.read', 'Look up patients and view their records.'),
+('patients.update', 'Edit patients'' records.'),
+('patients.delete', 'Delete patients.'),
+('appointments.create', 'Book appointments.'),
+('appointments.read', 'View the appointment calendar.'),
+('appointments.update', 'Reschedule appointments and change their status.'),
+('appointments.delete', 'Delete appointments.'),
+('finance.invoice.create', 'Issue invoices.'),
+('finance.invoice.read', 'View invoices.'),
+('finance.payment.record', 'Record payments against invoices.'),
+('finance.reports.view', 'View financial reports.'),
+('roles.create', 'Create clinic roles.'),
+('roles.read', 'View roles and their permissions.'),
+('roles.update', 'Edit roles and grant or remove them from staff.'),
+('roles.delete', 'Delete clinic roles.'),
+('clinic.reset', 'Wipe and reseed a sandbox clinic''s data.'),
+('clinic.config.export', 'Export the clinic''s configuration bundle.'),
+('clinic.config.import', 'Import a configuration bundle into the clinic.'),
+('reports.read', 'View operational reports such as appointment utilization.'),
+('platform.impersonate', 'Act as a clinic employee for support. Platform staff only.'),
+('platform.auth_failures.read', 'Investigate rejected login attempts. Platform staff only.'),
+('platform.metrics.read', 'Read process metrics from the internal API. Platform staff only.')
+) AS v (permission_key, description)
+WHERE p.permission_key = v.permission_key;
@@ -0,0 +1,6 @@
+-- This migration removes patient archiving support. Archived profiles stay archived.
+
+DELETE FROM role_permissions WHERE permission_id = 14;
+DELETE FROM permissions WHERE id = 14;
+
+ALTER TABLE profiles DROP COLUMN IF EXISTS archived_from_status;
@@ -0,0 +1,16 @@
+-- This migration lets staff archive and restore patients. Archiving sets deleted_at and the
+-- ARCHIVED status and remembers the status it replaced, so a restore can put it back.
+-- Roles that can delete patients may also see archived ones.
+
+ALTER TABLE profiles
+    ADD COLUMN archived_from_status profile_status;
+
+COMMENT ON COLUMN profiles.archived_from_status IS 'The profile_status an archived profile had before it was archived; NULL while active.';
+
+INSERT INTO permissions (id, permission_key, description) VALUES
+(14, 'patients.archive', 'Archive and restore patients and see archived records.')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rp.role_id, 14 FROM role_permissions rp WHERE rp.permission_id = 13
+ON CONFLICT DO NOTHING;
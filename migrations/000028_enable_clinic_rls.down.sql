@@ -0,0 +1,11 @@
+-- This migration removes the clinic row-level security policies from profiles and employees.
+
+DROP POLICY IF EXISTS employees_clinic_isolation ON employees;
+ALTER TABLE employees NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE employees DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS profiles_clinic_isolation ON profiles;
+ALTER TABLE profiles NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE profiles DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS app_current_clinic_id();
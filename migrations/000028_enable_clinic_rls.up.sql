@@ -0,0 +1,24 @@
+-- This migration enables row-level security on profiles and employees as defense in depth
+-- against queries that forget their clinic_id predicate. The API sets app.clinic_id to the
+-- caller's clinic in every transaction and in clinic-scoped reads; other clinics' rows are
+-- then invisible and cannot be written. Without the setting (migrations, background jobs,
+-- platform tooling) the policies allow every row, so only clinic-bound work is narrowed.
+
+CREATE FUNCTION app_current_clinic_id() RETURNS UUID
+    LANGUAGE sql STABLE AS
+$$
+    SELECT NULLIF(current_setting('app.clinic_id', true), '')::uuid
+$$;
+
+COMMENT ON FUNCTION app_current_clinic_id() IS 'The clinic the current transaction acts for (app.clinic_id), or NULL when unrestricted.';
+
+ALTER TABLE profiles ENABLE ROW LEVEL SECURITY;
+-- FORCE applies the policy to the table owner too, which is the role the API connects as.
+ALTER TABLE profiles FORCE ROW LEVEL SECURITY;
+CREATE POLICY profiles_clinic_isolation ON profiles
+    USING (app_current_clinic_id() IS NULL OR clinic_id = app_current_clinic_id());
+
+ALTER TABLE employees ENABLE ROW LEVEL SECURITY;
+ALTER TABLE employees FORCE ROW LEVEL SECURITY;
+CREATE POLICY employees_clinic_isolation ON employees
+    USING (app_current_clinic_id() IS NULL OR clinic_id = app_current_clinic_id());
@@ -0,0 +1,4 @@
+-- This migration removes the clinic settings permission.
+
+DELETE FROM role_permissions WHERE permission_id = 53;
+DELETE FROM permissions WHERE id = 53;
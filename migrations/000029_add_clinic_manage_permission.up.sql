@@ -0,0 +1,11 @@
+-- This migration adds the permission for changing a clinic's settings (timezone, language,
+-- phone region and booking rules) and grants it to the Owner system role.
+
+INSERT INTO permissions (id, permission_key, description) VALUES
+(53, 'clinic.manage', 'Change the clinic''s timezone, language, phone region and booking rules.')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, 53 FROM roles r
+WHERE r.name = 'Owner' AND r.is_system_role AND r.clinic_id IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+-- This migration removes the appointment reason.
+
+ALTER TABLE appointments DROP COLUMN IF EXISTS reason;
@@ -0,0 +1,6 @@
+-- This migration records why the patient is coming, as given when the appointment is booked.
+-- Staff notes stay in the separate notes column.
+
+ALTER TABLE appointments ADD COLUMN reason TEXT;
+
+COMMENT ON COLUMN appointments.reason IS 'Reason for the visit given at booking time, e.g. "toothache".';
@@ -0,0 +1,9 @@
+-- This migration restores the overlap constraint that covers every appointment. It fails if
+-- cancelled appointments now overlap live ones.
+
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS excl_appointments_practitioner_overlap;
+
+ALTER TABLE appointments ADD EXCLUDE USING GIST (
+    doctor_id WITH =,
+    tstzrange(start_time, end_time) WITH &&
+);
@@ -0,0 +1,22 @@
+-- This migration lets cancelled and deleted appointments free their slot. The overlap
+-- constraint from the scheduling schema covered every row, so a cancelled booking blocked
+-- the practitioner's time forever. It is replaced by a named, partial constraint.
+
+DO $$
+DECLARE
+    overlap_constraint TEXT;
+BEGIN
+    -- The original constraint was declared without a name.
+    SELECT conname INTO overlap_constraint
+    FROM pg_constraint
+    WHERE conrelid = 'appointments'::regclass AND contype = 'x';
+
+    IF overlap_constraint IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE appointments DROP CONSTRAINT %I', overlap_constraint);
+    END IF;
+END $$;
+
+ALTER TABLE appointments ADD CONSTRAINT excl_appointments_practitioner_overlap EXCLUDE USING GIST (
+    doctor_id WITH =,
+    tstzrange(start_time, end_time) WITH &&
+) WHERE (status <> 'CANCELLED' AND deleted_at IS NULL);
@@ -0,0 +1,8 @@
+-- This migration removes the slot length of working hours and the permission for managing them.
+
+DELETE FROM role_permissions WHERE permission_id = 24;
+DELETE FROM permissions WHERE id = 24;
+
+ALTER TABLE doctor_schedules
+    DROP CONSTRAINT IF EXISTS chk_schedule_slot_minutes,
+    DROP COLUMN IF EXISTS slot_minutes;
@@ -0,0 +1,17 @@
+-- This migration lets each block of a practitioner's weekly working hours (doctor_schedules)
+-- set the length of the slots it is booked in, and adds the permission for managing them.
+
+ALTER TABLE doctor_schedules
+    ADD COLUMN slot_minutes INT NOT NULL DEFAULT 30,
+    ADD CONSTRAINT chk_schedule_slot_minutes CHECK (slot_minutes BETWEEN 5 AND 480);
+
+COMMENT ON COLUMN doctor_schedules.slot_minutes IS 'Length of the bookable slots the block is divided into, starting at start_time.';
+
+INSERT INTO permissions (id, permission_key, description) VALUES
+(24, 'schedules.manage', 'Set practitioners'' weekly working hours.')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, 24 FROM roles r
+WHERE r.name IN ('Owner', 'Receptionist') AND r.is_system_role AND r.clinic_id IS NULL
+ON CONFLICT DO NOTHING;
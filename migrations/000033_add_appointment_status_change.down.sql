@@ -0,0 +1,5 @@
+-- This migration removes the record of appointment status changes.
+
+ALTER TABLE appointments
+    DROP COLUMN IF EXISTS status_changed_by,
+    DROP COLUMN IF EXISTS status_changed_at;
@@ -0,0 +1,9 @@
+-- This migration records when an appointment last changed status and which employee changed it.
+-- Both stay NULL for appointments that have kept their booking status.
+
+ALTER TABLE appointments
+    ADD COLUMN status_changed_at TIMESTAMPTZ,
+    ADD COLUMN status_changed_by UUID REFERENCES profiles(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN appointments.status_changed_at IS 'When status last changed.';
+COMMENT ON COLUMN appointments.status_changed_by IS 'Profile of the employee who last changed status; NULL when the guest did or nobody has.';
@@ -0,0 +1,5 @@
+-- This migration removes the record of sent appointment reminders.
+
+DROP INDEX IF EXISTS idx_appointments_reminder_due;
+
+ALTER TABLE appointments DROP COLUMN IF EXISTS reminder_sent_at;
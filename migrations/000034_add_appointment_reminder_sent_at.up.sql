@@ -0,0 +1,10 @@
+-- This migration records when an appointment's reminder was sent, so the reminder scheduler
+-- sends each one once however many API instances run it.
+
+ALTER TABLE appointments ADD COLUMN reminder_sent_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN appointments.reminder_sent_at IS 'When the reminder SMS was sent; NULL until then.';
+
+-- Lets the scheduler find upcoming appointments still waiting for their reminder.
+CREATE INDEX idx_appointments_reminder_due ON appointments (start_time)
+    WHERE reminder_sent_at IS NULL AND deleted_at IS NULL AND status IN ('SCHEDULED', 'CONFIRMED');
@@ -0,0 +1,3 @@
+-- This migration removes the transactional outbox.
+
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,21 @@
+-- This migration adds the transactional outbox. Services insert an event in the same transaction
+-- as the change it describes; a dispatcher delivers it to the event's handlers after commit,
+-- retrying with backoff and giving up (DEAD) after too many failures.
+
+CREATE TABLE outbox_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+    CONSTRAINT chk_outbox_events_status CHECK (status IN ('PENDING', 'PROCESSED', 'DEAD'))
+);
+COMMENT ON TABLE outbox_events IS 'Domain events awaiting delivery to their handlers after the publishing transaction committed.';
+COMMENT ON COLUMN outbox_events.attempts IS 'Failed deliveries so far; the event is DEAD once it reaches the configured maximum.';
+
+-- Lets the dispatcher claim the events that are due, oldest first.
+CREATE INDEX idx_outbox_events_due ON outbox_events (next_attempt_at) WHERE status = 'PENDING';
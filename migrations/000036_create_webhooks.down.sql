@@ -0,0 +1,7 @@
+-- This migration removes webhook subscriptions, their deliveries and the permission.
+
+DELETE FROM role_permissions WHERE permission_id = 54;
+DELETE FROM permissions WHERE id = 54;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,57 @@
+-- This migration adds per-clinic webhook subscriptions and their deliveries, and the permission
+-- for managing them. Each event a subscription matches gets one delivery, POSTed until it
+-- succeeds or runs out of attempts.
+
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    -- The signing secret, encrypted with a key derived from the server's secret.
+    secret_sealed TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES profiles(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_webhooks_event_types CHECK (cardinality(event_types) > 0)
+);
+COMMENT ON TABLE webhooks IS 'Integrator endpoints a clinic has subscribed to events; deliveries are signed with the secret.';
+
+CREATE INDEX idx_webhooks_clinic_id ON webhooks (clinic_id);
+CREATE TRIGGER set_timestamp BEFORE UPDATE ON webhooks FOR EACH ROW EXECUTE FUNCTION trigger_set_timestamp();
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    -- The outbox event delivered; a redelivered event does not create a second delivery.
+    event_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    -- HTTP status of the last response, NULL when the endpoint could not be reached.
+    response_status INT,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_webhook_deliveries_event UNIQUE (webhook_id, event_id),
+    CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED'))
+);
+COMMENT ON TABLE webhook_deliveries IS 'One event POSTed to one webhook, with the outcome of its latest attempt.';
+COMMENT ON COLUMN webhook_deliveries.attempts IS 'POSTs made so far; the delivery is FAILED once it reaches the configured maximum.';
+
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at DESC);
+-- Lets the delivery worker claim the deliveries that are due, oldest first.
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+
+INSERT INTO permissions (id, permission_key, description) VALUES
+(54, 'webhooks.manage', 'Register webhook endpoints and inspect their deliveries.')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, 54 FROM roles r
+WHERE r.name = 'Owner' AND r.is_system_role AND r.clinic_id IS NULL
+ON CONFLICT DO NOTHING;
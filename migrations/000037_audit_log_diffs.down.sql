@@ -0,0 +1,37 @@
+-- This migration restores the audit trigger that records whole rows. Scrubbed credentials
+-- are not restored.
+
+DELETE FROM role_permissions WHERE permission_id = 70;
+DELETE FROM permissions WHERE id = 70;
+
+DROP INDEX IF EXISTS idx_audit_log_clinic_record;
+-- The original trigger inserts whole audit_log rows, so the column goes first.
+ALTER TABLE audit_log DROP COLUMN IF EXISTS impersonated_user_id;
+
+CREATE OR REPLACE FUNCTION log_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    audit_record audit_log;
+    user_payload JSONB;
+BEGIN
+    BEGIN
+        user_payload := current_setting('app.audit_context', true)::jsonb;
+    EXCEPTION WHEN OTHERS THEN
+        user_payload := '{}'::jsonb;
+    END;
+    audit_record = ROW(uuid_generate_v7(),(user_payload->>'clinic_id')::UUID,(user_payload->>'user_id')::UUID,TG_OP,TG_TABLE_NAME,NULL,NULL,NULL,NOW());
+    IF (TG_OP = 'UPDATE') THEN
+        audit_record.record_id := NEW.id;
+        audit_record.old_record := to_jsonb(OLD);
+        audit_record.new_record := to_jsonb(NEW);
+    ELSIF (TG_OP = 'DELETE') THEN
+        audit_record.record_id := OLD.id;
+        audit_record.old_record := to_jsonb(OLD);
+    ELSIF (TG_OP = 'INSERT') THEN
+        audit_record.record_id := NEW.id;
+        audit_record.new_record := to_jsonb(NEW);
+    END IF;
+    INSERT INTO audit_log VALUES (audit_record.*);
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
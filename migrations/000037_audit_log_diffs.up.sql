@@ -0,0 +1,85 @@
+-- This migration makes the audit trigger record what changed instead of whole rows. An update
+-- now stores only the changed columns' old and new values and is skipped when nothing but
+-- bookkeeping columns changed; credentials are never copied into the log, and the ones
+-- copied so far are scrubbed. The trigger also stops assuming every table has an id column
+-- (employees are keyed by profile_id), falls back to the row's clinic when the transaction has
+-- no audit context, and records the impersonated employee. It adds the permission for
+-- reading the log.
+
+ALTER TABLE audit_log ADD COLUMN impersonated_user_id UUID;
+COMMENT ON COLUMN audit_log.impersonated_user_id IS 'The employee a platform operator (user_id) was acting as, if any.';
+
+-- Serves the audit log API, which lists one record's history newest first.
+CREATE INDEX idx_audit_log_clinic_record ON audit_log (clinic_id, table_name, record_id, timestamp DESC);
+
+UPDATE audit_log
+SET old_record = old_record - 'password_hash', new_record = new_record - 'password_hash'
+WHERE old_record ? 'password_hash' OR new_record ? 'password_hash';
+
+CREATE OR REPLACE FUNCTION log_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    -- Never copied into the log.
+    excluded CONSTANT TEXT[] := ARRAY['password_hash'];
+    -- Updated as a side effect of other work; a change to these alone is not logged.
+    bookkeeping CONSTANT TEXT[] := ARRAY['updated_at', 'last_login_at'];
+    user_payload JSONB;
+    row_data JSONB;
+    old_row JSONB;
+    new_row JSONB;
+    old_diff JSONB := '{}'::jsonb;
+    new_diff JSONB := '{}'::jsonb;
+    col TEXT;
+BEGIN
+    BEGIN
+        user_payload := NULLIF(current_setting('app.audit_context', true), '')::jsonb;
+    EXCEPTION WHEN OTHERS THEN
+        user_payload := NULL;
+    END;
+    user_payload := COALESCE(user_payload, '{}'::jsonb);
+
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD) - excluded;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW) - excluded;
+    END IF;
+    row_data := COALESCE(new_row, old_row);
+
+    IF TG_OP = 'UPDATE' THEN
+        FOR col IN SELECT jsonb_object_keys(new_row) LOOP
+            IF col <> ALL (bookkeeping) AND new_row -> col IS DISTINCT FROM old_row -> col THEN
+                old_diff := old_diff || jsonb_build_object(col, old_row -> col);
+                new_diff := new_diff || jsonb_build_object(col, new_row -> col);
+            END IF;
+        END LOOP;
+        IF new_diff = '{}'::jsonb THEN
+            RETURN NEW;
+        END IF;
+        old_row := old_diff;
+        new_row := new_diff;
+    END IF;
+
+    INSERT INTO audit_log (clinic_id, user_id, impersonated_user_id, action, table_name, record_id, old_record, new_record)
+    VALUES (
+        COALESCE((user_payload ->> 'clinic_id')::uuid, (row_data ->> 'clinic_id')::uuid),
+        (user_payload ->> 'user_id')::uuid,
+        (user_payload ->> 'impersonated_user_id')::uuid,
+        TG_OP,
+        TG_TABLE_NAME,
+        COALESCE(row_data ->> 'id', row_data ->> 'profile_id')::uuid,
+        old_row,
+        new_row
+    );
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO permissions (id, permission_key, description) VALUES
+(70, 'audit.read', 'Read the audit log of changes to patients, staff, roles and appointments.')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, 70 FROM roles r
+WHERE r.name = 'Owner' AND r.is_system_role AND r.clinic_id IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+-- This migration removes the guest phone verification codes.
+
+DROP TABLE IF EXISTS phone_verifications;
@@ -0,0 +1,28 @@
+-- This migration stores the one-time codes texted to guests before they may book online. A code
+-- proves the guest holds the phone number; verifying it yields a short-lived phone_verified
+-- token, and the row records that token so a booking can use it only once.
+
+CREATE TABLE phone_verifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    -- E.164, as the guest will book with it.
+    phone_number VARCHAR(20) NOT NULL,
+    -- HMAC-SHA256 of the code under a key derived from the server's secret; the code itself is never stored.
+    code_hash TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    verified_at TIMESTAMPTZ,
+    -- The jti of the phone_verified token issued on verification.
+    token_id UUID UNIQUE,
+    token_expires_at TIMESTAMPTZ,
+    consumed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE phone_verifications IS 'One-time codes texted to guests, and the single-use tokens issued for them.';
+COMMENT ON COLUMN phone_verifications.attempts IS 'Wrong codes entered so far; the code is locked once it reaches the configured maximum.';
+COMMENT ON COLUMN phone_verifications.consumed_at IS 'When a booking used the issued token; NULL until then.';
+
+-- Serves the per-phone rate limit and the lookup of the latest code.
+CREATE INDEX idx_phone_verifications_phone ON phone_verifications (clinic_id, phone_number, created_at DESC);
+-- Lets the cleanup find rows whose code and token have both expired.
+CREATE INDEX idx_phone_verifications_expiry ON phone_verifications (GREATEST(expires_at, COALESCE(token_expires_at, expires_at)));
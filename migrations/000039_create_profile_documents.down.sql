@@ -0,0 +1,3 @@
+-- This migration removes patient documents. Their blobs are left in the blob store.
+
+DROP TABLE IF EXISTS profile_documents;
@@ -0,0 +1,21 @@
+-- This migration adds documents attached to patient profiles, such as scanned ID cards and lab
+-- results. The files live in the blob store; a row holds their metadata and storage key.
+
+CREATE TABLE profile_documents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    -- The name the file was uploaded with, for display only.
+    file_name TEXT NOT NULL,
+    -- Detected from the file's content, not taken from the client.
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    -- Hex SHA-256 of the content.
+    checksum CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    uploaded_by UUID REFERENCES profiles(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE profile_documents IS 'Files attached to a patient profile; the content is kept in the blob store under storage_key.';
+
+CREATE INDEX idx_profile_documents_profile ON profile_documents (clinic_id, profile_id, created_at DESC);
@@ -0,0 +1,11 @@
+-- This migration removes patient anonymization. Anonymized profiles keep their placeholder
+-- name; the contact check is only restored for the profiles that still have a contact method.
+
+DELETE FROM role_permissions WHERE permission_id = 15;
+DELETE FROM permissions WHERE id = 15;
+
+ALTER TABLE profiles DROP CONSTRAINT chk_profile_contact_method;
+ALTER TABLE profiles ADD CONSTRAINT chk_profile_contact_method
+    CHECK (email IS NOT NULL OR phone_number IS NOT NULL) NOT VALID;
+
+ALTER TABLE profiles DROP COLUMN anonymized_at;
@@ -0,0 +1,20 @@
+-- This migration supports erasing a patient on request. Anonymizing a profile replaces the
+-- name, clears the other personal details and archives it for good; anonymized_at marks it
+-- so it can never be restored. An anonymized profile has no contact method left, so the
+-- contact check now exempts it. It adds the permission for erasing patients.
+
+ALTER TABLE profiles ADD COLUMN anonymized_at TIMESTAMPTZ;
+COMMENT ON COLUMN profiles.anonymized_at IS 'When the patient''s personal data was erased; an anonymized profile cannot be restored.';
+
+ALTER TABLE profiles DROP CONSTRAINT chk_profile_contact_method;
+ALTER TABLE profiles ADD CONSTRAINT chk_profile_contact_method
+    CHECK (email IS NOT NULL OR phone_number IS NOT NULL OR anonymized_at IS NOT NULL);
+
+INSERT INTO permissions (id, permission_key, description) VALUES
+(15, 'patient.erase', 'Permanently erase a patient''s personal data on request.')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, 15 FROM roles r
+WHERE r.name = 'Owner' AND r.is_system_role AND r.clinic_id IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- This migration removes the search indexes. The pg_trgm extension stays installed, since
+-- other objects may have come to depend on it.
+
+DROP INDEX IF EXISTS idx_profiles_national_id;
+DROP INDEX IF EXISTS idx_profiles_phone_number_trgm;
+DROP INDEX IF EXISTS idx_profiles_full_name_trgm;
@@ -0,0 +1,19 @@
+-- This migration lets the front-desk search find patients by a misspelt or partial name, and
+-- by a fragment of their phone number, without scanning every profile of a large clinic.
+-- Names are indexed lower-cased, as the search compares them; phone numbers are stored in
+-- E.164 already. The search ORs these with an exact national ID, which needs an index too or
+-- the whole condition falls back to a scan. Archived profiles are never searched, so they are
+-- left out of every index.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_profiles_full_name_trgm ON profiles
+    USING gin (lower(full_name) gin_trgm_ops)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_profiles_phone_number_trgm ON profiles
+    USING gin (phone_number gin_trgm_ops)
+    WHERE phone_number IS NOT NULL AND deleted_at IS NULL;
+
+CREATE INDEX idx_profiles_national_id ON profiles (clinic_id, national_id)
+    WHERE national_id IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- This migration removes the sealed payload columns. Sealed rows cannot be decrypted in SQL, so
+-- run "api seal-payloads --unseal" first; the down migration fails while any row is still sealed.
+
+ALTER TABLE webhook_deliveries
+    DROP CONSTRAINT IF EXISTS chk_webhook_deliveries_payload,
+    DROP COLUMN IF EXISTS key_version,
+    DROP COLUMN IF EXISTS payload_sealed,
+    ALTER COLUMN payload SET NOT NULL;
+
+ALTER TABLE outbox_events
+    DROP CONSTRAINT IF EXISTS chk_outbox_events_payload,
+    DROP COLUMN IF EXISTS key_version,
+    DROP COLUMN IF EXISTS payload_sealed,
+    ALTER COLUMN payload SET NOT NULL;
@@ -0,0 +1,24 @@
+-- This migration lets outbox events and webhook deliveries store their payloads sealed
+-- (AES-256-GCM) instead of as plaintext JSON, since both can sit in the database for days when
+-- delivery keeps failing. key_version records the key a row was sealed under. Rows written before
+-- this migration keep their plaintext payload until "api seal-payloads" seals them.
+
+ALTER TABLE outbox_events
+    ALTER COLUMN payload DROP NOT NULL,
+    ADD COLUMN payload_sealed TEXT,
+    ADD COLUMN key_version SMALLINT,
+    ADD CONSTRAINT chk_outbox_events_payload CHECK (
+        (payload IS NULL) <> (payload_sealed IS NULL) AND (payload_sealed IS NULL) = (key_version IS NULL)
+    );
+COMMENT ON COLUMN outbox_events.payload IS 'Plaintext payload of an event written before payloads were sealed; NULL once sealed.';
+COMMENT ON COLUMN outbox_events.payload_sealed IS 'The JSON payload sealed under key_version of the outbox payload keyring.';
+
+ALTER TABLE webhook_deliveries
+    ALTER COLUMN payload DROP NOT NULL,
+    ADD COLUMN payload_sealed TEXT,
+    ADD COLUMN key_version SMALLINT,
+    ADD CONSTRAINT chk_webhook_deliveries_payload CHECK (
+        (payload IS NULL) <> (payload_sealed IS NULL) AND (payload_sealed IS NULL) = (key_version IS NULL)
+    );
+COMMENT ON COLUMN webhook_deliveries.payload IS 'Plaintext body of a delivery created before bodies were sealed; NULL once sealed.';
+COMMENT ON COLUMN webhook_deliveries.payload_sealed IS 'The POSTed body sealed under key_version of the outbox payload keyring.';
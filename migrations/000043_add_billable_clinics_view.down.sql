@@ -0,0 +1,3 @@
+-- This migration removes the billable_clinics view.
+
+DROP VIEW IF EXISTS billable_clinics;
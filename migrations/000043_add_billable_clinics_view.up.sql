@@ -0,0 +1,8 @@
+-- This migration adds the billable_clinics view: the live, non-sandbox clinics. Platform metrics
+-- and billing read clinics through it so sandbox tenants are never counted or charged.
+
+CREATE VIEW billable_clinics AS
+    SELECT * FROM clinics
+    WHERE NOT is_sandbox AND deleted_at IS NULL;
+
+COMMENT ON VIEW billable_clinics IS 'Live clinics that are not sandboxes. Use for platform metrics and billing.';
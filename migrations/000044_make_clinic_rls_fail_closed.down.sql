@@ -0,0 +1,19 @@
+-- This migration restores the fail-open clinic policies, under which a session without
+-- app.clinic_id sees every row, and revokes the mastara_platform role's privileges. The role
+-- itself is shared by every database on the cluster and is left in place.
+
+DROP POLICY employees_clinic_isolation ON employees;
+CREATE POLICY employees_clinic_isolation ON employees
+    USING (app_current_clinic_id() IS NULL OR clinic_id = app_current_clinic_id());
+
+DROP POLICY profiles_clinic_isolation ON profiles;
+CREATE POLICY profiles_clinic_isolation ON profiles
+    USING (app_current_clinic_id() IS NULL OR clinic_id = app_current_clinic_id());
+
+COMMENT ON FUNCTION app_current_clinic_id() IS 'The clinic the current transaction acts for (app.clinic_id), or NULL when unrestricted.';
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE USAGE, SELECT ON SEQUENCES FROM mastara_platform;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM mastara_platform;
+REVOKE USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public FROM mastara_platform;
+REVOKE SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public FROM mastara_platform;
+REVOKE USAGE ON SCHEMA public FROM mastara_platform;
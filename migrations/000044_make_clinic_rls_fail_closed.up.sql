@@ -0,0 +1,50 @@
+-- This migration makes the clinic row-level security on profiles and employees fail closed: a
+-- session without app.clinic_id now sees and writes no rows instead of every row, so a query
+-- that runs outside a clinic's context can no longer leak another clinic's data.
+--
+-- Work that legitimately spans clinics runs as the mastara_platform role, which has BYPASSRLS:
+-- platform jobs switch to it per transaction (database.WithPlatformAccess), and data migrations
+-- that read or write profiles or employees must start with SET LOCAL ROLE mastara_platform and
+-- RESET ROLE before any DDL. Only a superuser can grant BYPASSRLS, so where the migrating role
+-- is not one the role must be created beforehand:
+--
+--     CREATE ROLE mastara_platform NOLOGIN BYPASSRLS;
+--     GRANT mastara_platform TO <api role>;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'mastara_platform') THEN
+        CREATE ROLE mastara_platform NOLOGIN BYPASSRLS;
+    END IF;
+EXCEPTION
+    -- Another database on the cluster created it concurrently.
+    WHEN duplicate_object THEN NULL;
+    WHEN insufficient_privilege THEN
+        RAISE EXCEPTION 'role mastara_platform does not exist and % may not create it', current_user
+            USING HINT = 'As a superuser run: CREATE ROLE mastara_platform NOLOGIN BYPASSRLS; GRANT mastara_platform TO ' || current_user || ';';
+END
+$$;
+
+DO $$
+BEGIN
+    IF NOT pg_has_role(current_user, 'mastara_platform', 'MEMBER') THEN
+        EXECUTE format('GRANT mastara_platform TO %I', current_user);
+    END IF;
+END
+$$;
+
+GRANT USAGE ON SCHEMA public TO mastara_platform;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO mastara_platform;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO mastara_platform;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO mastara_platform;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO mastara_platform;
+
+COMMENT ON FUNCTION app_current_clinic_id() IS 'The clinic the current transaction acts for (app.clinic_id), or NULL when none is set, in which case the clinic policies match no rows.';
+
+DROP POLICY profiles_clinic_isolation ON profiles;
+CREATE POLICY profiles_clinic_isolation ON profiles
+    USING (clinic_id = app_current_clinic_id());
+
+DROP POLICY employees_clinic_isolation ON employees;
+CREATE POLICY employees_clinic_isolation ON employees
+    USING (clinic_id = app_current_clinic_id());
@@ -0,0 +1,4 @@
+-- This migration removes the platform clinic reset permission.
+
+DELETE FROM role_permissions WHERE permission_id = 93;
+DELETE FROM permissions WHERE id = 93;
@@ -0,0 +1,6 @@
+-- This migration adds the platform permission for forcing a clinic reset from the internal API.
+-- Clinic staff keep clinic.reset, which only ever resets a sandbox clinic.
+
+INSERT INTO permissions (id, permission_key, description) VALUES
+(93, 'platform.clinic.reset', 'Wipe and reseed any clinic, including live ones, from the internal API. Platform staff only.')
+ON CONFLICT (id) DO NOTHING;
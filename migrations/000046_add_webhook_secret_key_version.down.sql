@@ -0,0 +1,4 @@
+-- This migration removes the webhook signing secret key version. Run "api seal-payloads" with
+-- SECURITY_SEALKEYVERSION=1 first, so every secret is sealed under version 1 again.
+
+ALTER TABLE webhooks DROP COLUMN secret_key_version;
@@ -0,0 +1,5 @@
+-- This migration records the seal key version each webhook's signing secret is sealed under, so
+-- the seal keys can be rotated. Existing secrets were sealed under version 1.
+
+ALTER TABLE webhooks ADD COLUMN secret_key_version SMALLINT NOT NULL DEFAULT 1;
+COMMENT ON COLUMN webhooks.secret_key_version IS 'The seal key version secret_sealed is sealed under.';
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migration files so the binary knows which schema version it expects.
+package migrations
+
+import "embed"
+
+// FS holds every *.up.sql / *.down.sql migration, named "<version>_<description>.<direction>.sql".
+//
+//go:embed *.sql
+var FS embed.FS
@@ -3,17 +3,56 @@
 package apierror
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
+// StatusClientClosedRequest is the non-standard (nginx) status recorded when the client
+// disconnected before a response could be written.
+const StatusClientClosedRequest = 499
+
 // APIError is a structured error type for all API responses.
 type APIError struct {
 	StatusCode    int
 	PublicMessage string
+	// Code is an optional machine-readable reason, sent alongside the status code.
+	Code string
+	// Fields maps each invalid request field to its problems. Only NewValidation sets it.
+	Fields map[string][]string
+	// Details carries extra machine-readable context about the error, sent as "details".
+	Details map[string]any
+	// RetryAfter, when set, is sent as the Retry-After header.
+	RetryAfter    time.Duration
 	internalError error
 }
 
+// Machine-readable error codes, sent as "error_code" so clients can tell apart errors that
+// share a status code.
+const (
+	// CodeDatabaseBusy marks a 503 caused by the database connection pool being exhausted.
+	CodeDatabaseBusy = "database_busy"
+	// CodeDuplicatePhone and CodeDuplicateEmail mark a 409 for a contact detail another
+	// profile in the clinic already uses.
+	CodeDuplicatePhone = "duplicate_phone"
+	CodeDuplicateEmail = "duplicate_email"
+	// CodeDuplicateResource marks any other 409 for a resource that already exists.
+	CodeDuplicateResource = "duplicate_resource"
+	// CodeTokenExpired marks a rejected token or single-use link that is past its expiry.
+	CodeTokenExpired = "token_expired"
+	// CodeAccountLocked marks a 429 for an account locked after repeated failed logins.
+	CodeAccountLocked = "account_locked"
+	// CodeValidationFailed marks a 400 whose Fields name each invalid request field.
+	CodeValidationFailed = "validation_failed"
+	// CodeSlotTaken marks a 409 for an appointment that overlaps another booking of the same practitioner.
+	CodeSlotTaken = "slot_taken"
+	// CodeInvalidStatusTransition marks a 409 for a status change the resource's lifecycle does not
+	// allow; Details carry the current status.
+	CodeInvalidStatusTransition = "invalid_status_transition"
+)
+
 // Error satisfies the standard error interface.
 func (e *APIError) Error() string {
 	if e.internalError != nil {
@@ -27,6 +66,18 @@ func (e *APIError) Unwrap() error {
 	return e.internalError
 }
 
+// WithCode sets the machine-readable error code and returns e, for chaining onto a factory.
+func (e *APIError) WithCode(code string) *APIError {
+	e.Code = code
+	return e
+}
+
+// WithDetails sets the error's details and returns e, for chaining onto a factory.
+func (e *APIError) WithDetails(details map[string]any) *APIError {
+	e.Details = details
+	return e
+}
+
 // --- Factory Functions ---
 
 // NewBadRequest creates a new APIError for HTTP 400 Bad Request responses.
@@ -41,6 +92,17 @@ func NewBadRequest(message string, internalErr error) *APIError {
 	}
 }
 
+// NewValidation creates a 400 for a request whose fields failed validation, keyed by field name,
+// e.g. the result of zog's Issues.Flatten.
+func NewValidation(fields map[string][]string) *APIError {
+	return &APIError{
+		StatusCode:    http.StatusBadRequest,
+		PublicMessage: "Some fields are invalid.",
+		Code:          CodeValidationFailed,
+		Fields:        fields,
+	}
+}
+
 // NewUnauthorized creates a new APIError for HTTP 401 Unauthorized responses.
 func NewUnauthorized(message string, internalErr error) *APIError {
 	if message == "" {
@@ -53,6 +115,18 @@ func NewUnauthorized(message string, internalErr error) *APIError {
 	}
 }
 
+// NewForbidden creates a new APIError for HTTP 403 Forbidden responses.
+func NewForbidden(message string, internalErr error) *APIError {
+	if message == "" {
+		message = "You do not have permission to perform this action."
+	}
+	return &APIError{
+		StatusCode:    http.StatusForbidden,
+		PublicMessage: message,
+		internalError: internalErr,
+	}
+}
+
 // NewNotFound creates a new APIError for HTTP 404 Not Found responses.
 func NewNotFound(resource string, internalErr error) *APIError {
 	return &APIError{
@@ -62,6 +136,69 @@ func NewNotFound(resource string, internalErr error) *APIError {
 	}
 }
 
+// NewConflict creates a new APIError for HTTP 409 Conflict responses.
+func NewConflict(message string, internalErr error) *APIError {
+	if message == "" {
+		message = "The request conflicts with the current state of the resource."
+	}
+	return &APIError{
+		StatusCode:    http.StatusConflict,
+		PublicMessage: message,
+		internalError: internalErr,
+	}
+}
+
+// NewUnprocessable creates a new APIError for HTTP 422 Unprocessable Entity responses: the
+// request is well-formed but breaks a business rule.
+func NewUnprocessable(message string, internalErr error) *APIError {
+	if message == "" {
+		message = "The request could not be processed."
+	}
+	return &APIError{
+		StatusCode:    http.StatusUnprocessableEntity,
+		PublicMessage: message,
+		internalError: internalErr,
+	}
+}
+
+// NewPayloadTooLarge creates a new APIError for HTTP 413 Content Too Large responses.
+func NewPayloadTooLarge(message string, internalErr error) *APIError {
+	if message == "" {
+		message = "The request body is too large."
+	}
+	return &APIError{
+		StatusCode:    http.StatusRequestEntityTooLarge,
+		PublicMessage: message,
+		internalError: internalErr,
+	}
+}
+
+// NewUnsupportedMediaType creates a new APIError for HTTP 415 Unsupported Media Type responses.
+func NewUnsupportedMediaType(message string, internalErr error) *APIError {
+	if message == "" {
+		message = "The content type of the request is not supported."
+	}
+	return &APIError{
+		StatusCode:    http.StatusUnsupportedMediaType,
+		PublicMessage: message,
+		internalError: internalErr,
+	}
+}
+
+// NewTooManyRequests creates a new APIError for HTTP 429 Too Many Requests responses.
+// A positive retryAfter is sent as the Retry-After header.
+func NewTooManyRequests(message string, retryAfter time.Duration, internalErr error) *APIError {
+	if message == "" {
+		message = "Too many requests. Please retry later."
+	}
+	return &APIError{
+		StatusCode:    http.StatusTooManyRequests,
+		PublicMessage: message,
+		RetryAfter:    retryAfter,
+		internalError: internalErr,
+	}
+}
+
 // NewInternalServer creates a new APIError for HTTP 500 Internal Server Error responses.
 // The public message is always generic to avoid leaking information.
 func NewInternalServer(internalErr error) *APIError {
@@ -71,3 +208,60 @@ func NewInternalServer(internalErr error) *APIError {
 		internalError: internalErr,
 	}
 }
+
+// NewClientClosedRequest creates an APIError for requests abandoned by the client.
+// No one reads the response, so it only exists for logging and metrics.
+func NewClientClosedRequest(internalErr error) *APIError {
+	return &APIError{
+		StatusCode:    StatusClientClosedRequest,
+		PublicMessage: "The client closed the request.",
+		internalError: internalErr,
+	}
+}
+
+// NewServiceUnavailable creates a new APIError for HTTP 503 Service Unavailable responses,
+// used when the server sheds load rather than failing.
+func NewServiceUnavailable(message string, internalErr error) *APIError {
+	if message == "" {
+		message = "The server is temporarily unable to handle the request. Please retry shortly."
+	}
+	return &APIError{
+		StatusCode:    http.StatusServiceUnavailable,
+		PublicMessage: message,
+		internalError: internalErr,
+	}
+}
+
+// NewDatabaseBusy creates a 503 for requests that could not get a database connection in time.
+// The load is transient, so clients are told when to retry.
+func NewDatabaseBusy(retryAfter time.Duration, internalErr error) *APIError {
+	return &APIError{
+		StatusCode:    http.StatusServiceUnavailable,
+		PublicMessage: "The server is busy. Please retry shortly.",
+		Code:          CodeDatabaseBusy,
+		RetryAfter:    retryAfter,
+		internalError: internalErr,
+	}
+}
+
+// NewGatewayTimeout creates a new APIError for HTTP 504 Gateway Timeout responses.
+func NewGatewayTimeout(internalErr error) *APIError {
+	return &APIError{
+		StatusCode:    http.StatusGatewayTimeout,
+		PublicMessage: "The request took too long to complete.",
+		internalError: internalErr,
+	}
+}
+
+// FromContext reclassifies an error caused by a context ending, however deeply it is wrapped:
+// a cancellation of the request context (client disconnect) becomes a 499, and any exceeded
+// deadline becomes a 504. Other errors are returned unchanged with ok set to false.
+func FromContext(requestCtx context.Context, err error) (apiErr *APIError, ok bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewGatewayTimeout(err), true
+	case errors.Is(err, context.Canceled) && errors.Is(requestCtx.Err(), context.Canceled):
+		return NewClientClosedRequest(err), true
+	}
+	return nil, false
+}
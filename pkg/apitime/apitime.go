@@ -0,0 +1,131 @@
+// Package apitime defines the wire formats for times and dates in API payloads.
+// Every instant leaves the API as RFC 3339 in UTC with second precision, and every
+// calendar date (date of birth, absence days) as YYYY-MM-DD, independent of the
+// server's time zone or the precision Postgres returns.
+package apitime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Time is an instant serialized as RFC 3339 UTC with second precision, e.g. "2025-03-01T09:30:00Z".
+type Time struct {
+	time.Time
+}
+
+// New normalizes t for the API.
+func New(t time.Time) Time {
+	return Time{Time: t.UTC().Truncate(time.Second)}
+}
+
+// NewPtr normalizes an optional instant; nil stays nil.
+func NewPtr(t *time.Time) *Time {
+	if t == nil {
+		return nil
+	}
+	v := New(*t)
+	return &v
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Truncate(time.Second).Format(time.RFC3339))
+}
+
+// UnmarshalJSON accepts any RFC 3339 timestamp and converts it to UTC.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("apitime: time must be a string: %w", err)
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("apitime: time must be RFC 3339: %w", err)
+	}
+	*t = New(parsed)
+	return nil
+}
+
+// Date is a calendar date without a time of day or zone, serialized as "YYYY-MM-DD".
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the calendar date of t as seen in t's own location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// DatePtr converts an optional date-valued time; nil stays nil.
+func DatePtr(t *time.Time) *Date {
+	if t == nil {
+		return nil
+	}
+	d := DateOf(*t)
+	return &d
+}
+
+// ParseDate parses a "YYYY-MM-DD" string.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("apitime: date must be YYYY-MM-DD: %w", err)
+	}
+	return DateOf(t), nil
+}
+
+// Time returns midnight UTC of the date, the form in which dates are stored.
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// TimePtr converts an optional date to midnight UTC of that date; nil stays nil.
+func (d *Date) TimePtr() *time.Time {
+	if d == nil {
+		return nil
+	}
+	t := d.Time()
+	return &t
+}
+
+// String formats the date as "YYYY-MM-DD".
+func (d Date) String() string {
+	return d.Time().Format(time.DateOnly)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A malformed date is reported as a
+// *json.UnmarshalTypeError, so decoders can name the offending field.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeFor[Date]()}
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: "string " + strconv.Quote(s), Type: reflect.TypeFor[Date]()}
+	}
+	*d = parsed
+	return nil
+}
+
+// NormalizeDate reduces an optional date-valued time to midnight UTC of its calendar date,
+// so a date parsed in any zone is stored as the same DATE value.
+func NormalizeDate(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := DateOf(*t).Time()
+	return &v
+}
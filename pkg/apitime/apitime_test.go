@@ -0,0 +1,71 @@
+package apitime
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/internal/testutil/golden"
+)
+
+// TestWireFormats pins how instants and dates leave the API, whatever zone and precision
+// they were read with.
+func TestWireFormats(t *testing.T) {
+	cairo, err := time.LoadLocation("Africa/Cairo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	evening := time.Date(2025, 3, 1, 23, 30, 15, 987654321, cairo)
+	// Half past midnight in Cairo is still the previous day in UTC.
+	justAfterMidnight := time.Date(2025, 3, 2, 0, 30, 0, 0, cairo)
+
+	golden.AssertJSON(t, "formats.json", struct {
+		UTC            Time  `json:"utc"`
+		SubSecond      Time  `json:"sub_second"`
+		OtherZone      Time  `json:"other_zone"`
+		PreviousUTCDay Time  `json:"previous_utc_day"`
+		Missing        *Time `json:"missing"`
+		Date           Date  `json:"date"`
+		DateOfZoned    Date  `json:"date_of_zoned"`
+		MissingDate    *Date `json:"missing_date"`
+	}{
+		UTC:            New(time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)),
+		SubSecond:      New(time.Date(2025, 3, 1, 9, 30, 0, 999999999, time.UTC)),
+		OtherZone:      New(evening),
+		PreviousUTCDay: New(justAfterMidnight),
+		Date:           Date{Year: 1990, Month: time.January, Day: 1},
+		// A date keeps the calendar day of its own zone rather than UTC's.
+		DateOfZoned: DateOf(justAfterMidnight),
+	})
+}
+
+func TestRoundTrips(t *testing.T) {
+	var v struct {
+		At  Time `json:"at"`
+		Day Date `json:"day"`
+	}
+	if err := json.Unmarshal([]byte(`{"at": "2025-03-01T11:30:00.5+02:00", "day": "2024-02-29"}`), &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if want := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC); !v.At.Equal(want) || v.At.Location() != time.UTC {
+		t.Errorf("time = %s, want %s in UTC without the fraction", v.At, want)
+	}
+	if v.Day != (Date{Year: 2024, Month: time.February, Day: 29}) {
+		t.Errorf("date = %s, want 2024-02-29", v.Day)
+	}
+
+	for _, bad := range []string{`{"day": "2025-02-29"}`, `{"day": "2025-03-01T00:00:00Z"}`, `{"day": 20250301}`} {
+		var typeErr *json.UnmarshalTypeError
+		if err := json.Unmarshal([]byte(bad), &v); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want an error", bad)
+		} else if !errors.As(err, &typeErr) {
+			t.Errorf("Unmarshal(%s) error = %v, want a *json.UnmarshalTypeError", bad, err)
+		}
+	}
+	for _, bad := range []string{`{"at": "2025-03-01 09:30:00"}`, `{"at": 1740821400}`} {
+		if err := json.Unmarshal([]byte(bad), &v); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want an error", bad)
+		}
+	}
+}
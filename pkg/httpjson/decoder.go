@@ -6,26 +6,39 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"strings"
 
 	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
 )
 
-const defaultMaxBodyBytes = 1_048_576 // 1 MB
+// DefaultMaxBodyBytes is the largest request body DecodeJSON and DecodeJSONGin accept.
+const DefaultMaxBodyBytes = 1_048_576 // 1 MB
 
 // DecodeJSON provides a secure way to decode JSON from an HTTP request body.
 // It enforces a request body size limit, checks for the correct Content-Type,
 // and prevents unknown fields in the JSON payload.
 func DecodeJSON[T any](w http.ResponseWriter, r *http.Request) (T, *apierror.APIError) {
+	return DecodeJSONLimit[T](w, r, DefaultMaxBodyBytes)
+}
+
+// DecodeJSONGin is DecodeJSON for gin handlers.
+func DecodeJSONGin[T any](c *gin.Context) (T, *apierror.APIError) {
+	return DecodeJSONLimit[T](c.Writer, c.Request, DefaultMaxBodyBytes)
+}
+
+// DecodeJSONLimit is DecodeJSON with a body size limit of maxBytes rather than the default.
+func DecodeJSONLimit[T any](w http.ResponseWriter, r *http.Request, maxBytes int64) (T, *apierror.APIError) {
 	var dest T
 
 	// Enforce a max body size to prevent DoS attacks.
-	r.Body = http.MaxBytesReader(w, r.Body, defaultMaxBodyBytes)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 
 	// Check for the correct Content-Type header.
-	if r.Header.Get("Content-Type") != "application/json" {
-		return dest, apierror.NewBadRequest("Content-Type header must be 'application/json'", nil)
+	if apiErr := checkContentType(r.Header.Get("Content-Type")); apiErr != nil {
+		return dest, apiErr
 	}
 
 	dec := json.NewDecoder(r.Body)
@@ -55,7 +68,7 @@ func DecodeJSON[T any](w http.ResponseWriter, r *http.Request) (T, *apierror.API
 
 		case errors.As(err, &maxBytesError):
 			msg := fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesError.Limit)
-			return dest, apierror.NewBadRequest(msg, err)
+			return dest, apierror.NewPayloadTooLarge(msg, err)
 
 		case errors.Is(err, io.EOF):
 			return dest, apierror.NewBadRequest("Request body must not be empty", err)
@@ -72,3 +85,17 @@ func DecodeJSON[T any](w http.ResponseWriter, r *http.Request) (T, *apierror.API
 
 	return dest, nil
 }
+
+// checkContentType accepts application/json with, at most, a UTF-8 charset parameter. JSON is
+// always UTF-8 (RFC 8259), and vendor types such as application/vnd.api+json carry semantics
+// this decoder does not implement, so both are refused.
+func checkContentType(header string) *apierror.APIError {
+	mediaType, params, err := mime.ParseMediaType(header)
+	if err != nil || mediaType != "application/json" {
+		return apierror.NewUnsupportedMediaType("Content-Type header must be 'application/json'", err)
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return apierror.NewUnsupportedMediaType("Request body must be encoded as UTF-8", nil)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package httpjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type decodeTarget struct {
+	Name string `json:"name"`
+}
+
+func newJSONRequest(contentType, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestDecodeJSONContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		wantStatus  int
+	}{
+		{"application/json", 0},
+		{"application/json; charset=utf-8", 0},
+		{"application/json;charset=UTF-8", 0},
+		{"Application/JSON", 0},
+		{"application/json; charset=iso-8859-1", http.StatusUnsupportedMediaType},
+		{"application/vnd.api+json", http.StatusUnsupportedMediaType},
+		{"text/plain", http.StatusUnsupportedMediaType},
+		{"application/json; charset", http.StatusUnsupportedMediaType},
+		{"", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			r := newJSONRequest(tt.contentType, `{"name":"Amal"}`)
+			got, apiErr := DecodeJSON[decodeTarget](httptest.NewRecorder(), r)
+			if tt.wantStatus == 0 {
+				if apiErr != nil {
+					t.Fatalf("DecodeJSON: %v", apiErr)
+				}
+				if got.Name != "Amal" {
+					t.Errorf("Name = %q, want %q", got.Name, "Amal")
+				}
+				return
+			}
+			if apiErr == nil || apiErr.StatusCode != tt.wantStatus {
+				t.Errorf("DecodeJSON error = %v, want status %d", apiErr, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONRejectsBadBodies(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"trailing object", `{"name":"Amal"}{"name":"Omar"}`, http.StatusBadRequest},
+		{"trailing empty object", `{"name":"Amal"} {}`, http.StatusBadRequest},
+		{"trailing garbage", `{"name":"Amal"} x`, http.StatusBadRequest},
+		{"unknown field", `{"name":"Amal","role":"admin"}`, http.StatusBadRequest},
+		{"empty body", ``, http.StatusBadRequest},
+		{"wrong type", `{"name":42}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newJSONRequest("application/json", tt.body)
+			if _, apiErr := DecodeJSON[decodeTarget](httptest.NewRecorder(), r); apiErr == nil || apiErr.StatusCode != tt.wantStatus {
+				t.Errorf("DecodeJSON error = %v, want status %d", apiErr, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONLimit(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("a", 64) + `"}`
+
+	r := newJSONRequest("application/json", body)
+	if _, apiErr := DecodeJSONLimit[decodeTarget](httptest.NewRecorder(), r, 32); apiErr == nil || apiErr.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("DecodeJSONLimit error = %v, want status %d", apiErr, http.StatusRequestEntityTooLarge)
+	}
+
+	r = newJSONRequest("application/json", body)
+	if _, apiErr := DecodeJSON[decodeTarget](httptest.NewRecorder(), r); apiErr != nil {
+		t.Errorf("DecodeJSON with the default limit: %v", apiErr)
+	}
+}
+
+func TestDecodeJSONGin(t *testing.T) {
+	c, _ := newTestContext()
+	c.Request = newJSONRequest("application/json; charset=utf-8", `{"name":"Amal"}`)
+
+	got, apiErr := DecodeJSONGin[decodeTarget](c)
+	if apiErr != nil {
+		t.Fatalf("DecodeJSONGin: %v", apiErr)
+	}
+	if got.Name != "Amal" {
+		t.Errorf("Name = %q, want %q", got.Name, "Amal")
+	}
+}
@@ -0,0 +1,73 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/Ebrahim-hamdy/mastara-saas/pkg/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// contentType is the Content-Type of every JSON response.
+const contentType = "application/json; charset=utf-8"
+
+// envelope is the shape of every successful JSON response: the payload under data and anything
+// describing it, such as paging, under meta.
+type envelope struct {
+	Data any            `json:"data"`
+	Meta map[string]any `json:"meta"`
+}
+
+// RespondData writes data as {"data": ..., "meta": {}} with the given status. A nil slice is
+// written as [] so clients never have to tell an empty list from a missing one.
+func RespondData(c *gin.Context, status int, data any) {
+	respond(c, status, envelope{Data: normalize(data), Meta: map[string]any{}})
+}
+
+// RespondList writes items as {"data": [...], "meta": {...}} with status 200. items is always
+// written as an array, and meta as an object, even when they are nil.
+func RespondList[T any](c *gin.Context, items []T, meta map[string]any) {
+	if items == nil {
+		items = []T{}
+	}
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	respond(c, http.StatusOK, envelope{Data: items, Meta: meta})
+}
+
+// RespondNoContent writes an empty 204 response.
+func RespondNoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// respond encodes body before writing anything, so a value that cannot be encoded turns into a
+// 500 error envelope rather than a truncated 200.
+func respond(c *gin.Context, status int, body envelope) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		apiErr := apierror.NewInternalServer(err)
+		log.Ctx(c.Request.Context()).Error().
+			Err(err).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Msg("Failed to encode JSON response")
+		encoded, _ = json.Marshal(gin.H{"error": gin.H{
+			"message": apiErr.PublicMessage,
+			"code":    apiErr.StatusCode,
+		}})
+		status = apiErr.StatusCode
+	}
+	c.Data(status, contentType, encoded)
+}
+
+// normalize replaces a nil slice with an empty one of the same type.
+func normalize(data any) any {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return data
+}
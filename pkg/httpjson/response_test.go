@@ -0,0 +1,112 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	return c, rec
+}
+
+func TestRespondDataWrapsPayload(t *testing.T) {
+	c, rec := newTestContext()
+	RespondData(c, http.StatusCreated, map[string]string{"id": "42"})
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+	if got, want := rec.Body.String(), `{"data":{"id":"42"},"meta":{}}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestRespondDataNil(t *testing.T) {
+	c, rec := newTestContext()
+	RespondData(c, http.StatusOK, nil)
+
+	if got, want := rec.Body.String(), `{"data":null,"meta":{}}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestEmptySlicesAreArrays(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(c *gin.Context)
+	}{
+		{"nil slice as data", func(c *gin.Context) { RespondData(c, http.StatusOK, []string(nil)) }},
+		{"empty slice as data", func(c *gin.Context) { RespondData(c, http.StatusOK, []string{}) }},
+		{"nil list", func(c *gin.Context) { RespondList[string](c, nil, nil) }},
+		{"empty list", func(c *gin.Context) { RespondList(c, []int{}, nil) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newTestContext()
+			tt.respond(c)
+			if got, want := rec.Body.String(), `{"data":[],"meta":{}}`; got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRespondListKeepsMeta(t *testing.T) {
+	c, rec := newTestContext()
+	RespondList(c, []int{1, 2}, map[string]any{"page": 1})
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got, want := rec.Body.String(), `{"data":[1,2],"meta":{"page":1}}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestRespondDataEncodeFailure(t *testing.T) {
+	c, rec := newTestContext()
+	RespondData(c, http.StatusOK, map[string]any{"callback": func() {}})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if body.Error.Code != http.StatusInternalServerError || body.Error.Message == "" {
+		t.Errorf("error envelope = %+v", body.Error)
+	}
+}
+
+func TestRespondNoContent(t *testing.T) {
+	c, rec := newTestContext()
+	RespondNoContent(c)
+	c.Writer.WriteHeaderNow()
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %q, want empty", rec.Body.String())
+	}
+}
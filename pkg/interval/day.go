@@ -0,0 +1,42 @@
+package interval
+
+import "time"
+
+// StartOfDay returns midnight of t's calendar day in loc.
+// It uses time.Date rather than Truncate so DST transitions are handled correctly.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// Day returns the interval covering t's calendar day in loc. On DST transition
+// days the result is 23 or 25 hours long.
+func Day(t time.Time, loc *time.Location) Interval {
+	start := StartOfDay(t, loc)
+	return Interval{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+// AtClock returns the instant on day's calendar date (in loc) at the given wall-clock offset
+// from midnight, e.g. 9*time.Hour for 09:00. Wall-clock times are resolved with time.Date,
+// so a time inside a DST gap is normalized forward.
+func AtClock(day time.Time, loc *time.Location, clock time.Duration) time.Time {
+	start := StartOfDay(day, loc)
+	h := int(clock / time.Hour)
+	m := int(clock % time.Hour / time.Minute)
+	s := int(clock % time.Minute / time.Second)
+	return time.Date(start.Year(), start.Month(), start.Day(), h, m, s, 0, loc)
+}
+
+// SplitByDay cuts the interval at local midnights in loc, so each piece lies within one calendar day.
+func (i Interval) SplitByDay(loc *time.Location) []Interval {
+	if i.IsEmpty() {
+		return nil
+	}
+	var pieces []Interval
+	for start := i.Start; start.Before(i.End); {
+		end := minTime(Day(start, loc).End, i.End)
+		pieces = append(pieces, Interval{Start: start, End: end})
+		start = end
+	}
+	return pieces
+}
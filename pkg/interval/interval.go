@@ -0,0 +1,158 @@
+// Package interval implements half-open time ranges [Start, End) and the set operations
+// scheduling features need (overlap checks, subtraction, merging and slotting).
+// Every overlap decision in the codebase should go through this package so that
+// "touching" ranges (one ends exactly when the next begins) are treated consistently
+// as non-overlapping, matching Postgres' default '[)' tstzrange bounds.
+package interval
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrInvalid is returned when an interval's end is before its start.
+var ErrInvalid = errors.New("interval: end is before start")
+
+// Interval is the half-open time range [Start, End).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// New creates an interval, rejecting ranges whose end precedes their start.
+func New(start, end time.Time) (Interval, error) {
+	if end.Before(start) {
+		return Interval{}, ErrInvalid
+	}
+	return Interval{Start: start, End: end}, nil
+}
+
+// Duration returns the length of the interval.
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// IsEmpty reports whether the interval contains no instants.
+func (i Interval) IsEmpty() bool {
+	return !i.Start.Before(i.End)
+}
+
+// Overlaps reports whether the two intervals share at least one instant.
+// Adjacent intervals ([09:00, 10:00) and [10:00, 11:00)) do not overlap.
+func (i Interval) Overlaps(other Interval) bool {
+	if i.IsEmpty() || other.IsEmpty() {
+		return false
+	}
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// Contains reports whether other lies entirely within i.
+func (i Interval) Contains(other Interval) bool {
+	return !other.Start.Before(i.Start) && !other.End.After(i.End)
+}
+
+// ContainsTime reports whether t lies within [Start, End).
+func (i Interval) ContainsTime(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// Intersect returns the common part of two intervals and whether it is non-empty.
+func (i Interval) Intersect(other Interval) (Interval, bool) {
+	result := Interval{Start: maxTime(i.Start, other.Start), End: minTime(i.End, other.End)}
+	if result.IsEmpty() {
+		return Interval{}, false
+	}
+	return result, true
+}
+
+// Subtract removes other from i and returns the zero, one or two remaining pieces in order.
+func (i Interval) Subtract(other Interval) []Interval {
+	if i.IsEmpty() {
+		return nil
+	}
+	if !i.Overlaps(other) {
+		return []Interval{i}
+	}
+	var result []Interval
+	if i.Start.Before(other.Start) {
+		result = append(result, Interval{Start: i.Start, End: other.Start})
+	}
+	if other.End.Before(i.End) {
+		result = append(result, Interval{Start: other.End, End: i.End})
+	}
+	return result
+}
+
+// Split cuts the interval into consecutive slots of length d, starting at Start.
+// A trailing remainder shorter than d is discarded. Non-positive durations yield no slots.
+func (i Interval) Split(d time.Duration) []Interval {
+	if d <= 0 || i.IsEmpty() {
+		return nil
+	}
+	var slots []Interval
+	for start := i.Start; !start.Add(d).After(i.End); start = start.Add(d) {
+		slots = append(slots, Interval{Start: start, End: start.Add(d)})
+	}
+	return slots
+}
+
+// Merge sorts the intervals and coalesces any that overlap or touch. Empty intervals are dropped.
+func Merge(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, in := range intervals {
+		if !in.IsEmpty() {
+			sorted = append(sorted, in)
+		}
+	}
+	slices.SortFunc(sorted, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	var merged []Interval
+	for _, in := range sorted {
+		if n := len(merged); n > 0 && !in.Start.After(merged[n-1].End) {
+			merged[n-1].End = maxTime(merged[n-1].End, in.End)
+			continue
+		}
+		merged = append(merged, in)
+	}
+	return merged
+}
+
+// SubtractAll removes every interval in cut from each interval in base and returns the merged remainder.
+func SubtractAll(base, cut []Interval) []Interval {
+	remaining := Merge(base)
+	for _, c := range Merge(cut) {
+		var next []Interval
+		for _, r := range remaining {
+			next = append(next, r.Subtract(c)...)
+		}
+		remaining = next
+	}
+	return remaining
+}
+
+// AnyOverlap reports whether candidate overlaps any of the given intervals.
+func AnyOverlap(candidate Interval, intervals []Interval) bool {
+	for _, in := range intervals {
+		if candidate.Overlaps(in) {
+			return true
+		}
+	}
+	return false
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,215 @@
+package interval
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC)
+
+// at returns the interval between two clock times on base's day, in hours.
+func at(start, end float64) Interval {
+	return Interval{
+		Start: base.Add(time.Duration(start * float64(time.Hour))),
+		End:   base.Add(time.Duration(end * float64(time.Hour))),
+	}
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%s): %v", name, err)
+	}
+	return loc
+}
+
+func equal(a, b []Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Start.Equal(b[i].Start) || !a[i].End.Equal(b[i].End) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNewRejectsReversedRange(t *testing.T) {
+	if _, err := New(base.Add(time.Hour), base); err != ErrInvalid {
+		t.Errorf("New(reversed) error = %v, want ErrInvalid", err)
+	}
+	if in, err := New(base, base); err != nil || !in.IsEmpty() {
+		t.Errorf("New(empty) = %v, %v; want an empty interval", in, err)
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Interval
+		want bool
+	}{
+		{"touching", at(9, 10), at(10, 11), false},
+		{"touching reversed", at(10, 11), at(9, 10), false},
+		{"disjoint", at(9, 10), at(11, 12), false},
+		{"partial", at(9, 10.5), at(10, 11), true},
+		{"nested", at(9, 12), at(10, 11), true},
+		{"enclosing", at(10, 11), at(9, 12), true},
+		{"identical", at(9, 10), at(9, 10), true},
+		{"empty inside", at(9, 12), at(10, 10), false},
+		{"empty against empty", at(10, 10), at(10, 10), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Overlaps(tt.b); got != tt.want {
+				t.Errorf("Overlaps = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Interval
+		want []Interval
+	}{
+		{"touching before", at(10, 12), at(9, 10), []Interval{at(10, 12)}},
+		{"touching after", at(10, 12), at(12, 13), []Interval{at(10, 12)}},
+		{"nested", at(9, 12), at(10, 11), []Interval{at(9, 10), at(11, 12)}},
+		{"same start", at(9, 12), at(9, 10), []Interval{at(10, 12)}},
+		{"same end", at(9, 12), at(11, 12), []Interval{at(9, 11)}},
+		{"covered", at(10, 11), at(9, 12), nil},
+		{"identical", at(9, 10), at(9, 10), nil},
+		{"empty base", at(10, 10), at(9, 12), nil},
+		{"empty cut", at(9, 12), at(10, 10), []Interval{at(9, 12)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Subtract(tt.b); !equal(got, tt.want) {
+				t.Errorf("Subtract = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeCoalescesTouchingAndDropsEmpty(t *testing.T) {
+	got := Merge([]Interval{at(11, 12), at(9, 10), at(10, 11), at(13, 13), at(14, 15), at(14.5, 14.75)})
+	if want := []Interval{at(9, 12), at(14, 15)}; !equal(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+}
+
+func TestSubtractAll(t *testing.T) {
+	got := SubtractAll([]Interval{at(9, 12), at(13, 17)}, []Interval{at(10, 11), at(11, 11.5), at(12, 13), at(16, 18)})
+	if want := []Interval{at(9, 10), at(11.5, 12), at(13, 16)}; !equal(got, want) {
+		t.Errorf("SubtractAll = %v, want %v", got, want)
+	}
+}
+
+func TestSplitDropsShortRemainder(t *testing.T) {
+	got := at(9, 10.75).Split(30 * time.Minute)
+	if want := []Interval{at(9, 9.5), at(9.5, 10), at(10, 10.5)}; !equal(got, want) {
+		t.Errorf("Split = %v, want %v", got, want)
+	}
+	if got := at(9, 10).Split(0); got != nil {
+		t.Errorf("Split(0) = %v, want none", got)
+	}
+}
+
+// TestSubtractProperties checks on random intervals that the pieces left by Subtract lie within
+// the base, miss the cut and add up to the base minus their intersection.
+func TestSubtractProperties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() Interval {
+		start := rng.Intn(24 * 4)
+		return at(float64(start)/4, float64(start+rng.Intn(12))/4)
+	}
+	for range 2000 {
+		a, b := random(), random()
+		pieces := a.Subtract(b)
+
+		var total time.Duration
+		for _, p := range pieces {
+			if p.IsEmpty() || !a.Contains(p) || p.Overlaps(b) {
+				t.Fatalf("%v minus %v left the piece %v", a, b, p)
+			}
+			total += p.Duration()
+		}
+		want := a.Duration()
+		if common, ok := a.Intersect(b); ok {
+			want -= common.Duration()
+		}
+		if a.IsEmpty() {
+			want = 0
+		}
+		if total != want {
+			t.Fatalf("%v minus %v left %v in total, want %v", a, b, total, want)
+		}
+		if a.Overlaps(b) != b.Overlaps(a) {
+			t.Fatalf("Overlaps is not symmetric for %v and %v", a, b)
+		}
+	}
+}
+
+func TestDayOnDSTTransitions(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	tests := []struct {
+		name string
+		day  time.Time
+		want time.Duration
+	}{
+		{"spring forward", time.Date(2025, time.March, 30, 12, 0, 0, 0, berlin), 23 * time.Hour},
+		{"fall back", time.Date(2025, time.October, 26, 12, 0, 0, 0, berlin), 25 * time.Hour},
+		{"ordinary day", time.Date(2025, time.June, 2, 12, 0, 0, 0, berlin), 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := Day(tt.day, berlin)
+			if got := day.Duration(); got != tt.want {
+				t.Errorf("Day lasts %v, want %v", got, tt.want)
+			}
+			if h, m, _ := day.Start.In(berlin).Clock(); h != 0 || m != 0 {
+				t.Errorf("Day starts at %v, want local midnight", day.Start)
+			}
+		})
+	}
+}
+
+func TestAtClockOnDSTTransitions(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	spring := time.Date(2025, time.March, 30, 12, 0, 0, 0, berlin)
+	fall := time.Date(2025, time.October, 26, 12, 0, 0, 0, berlin)
+
+	// 02:30 does not exist on the spring-forward day and is normalized forward to 03:30.
+	if got := AtClock(spring, berlin, 2*time.Hour+30*time.Minute); got.In(berlin).Hour() != 3 {
+		t.Errorf("AtClock(02:30) = %v, want 03:30", got.In(berlin))
+	}
+	// Nine on the clock is eight hours after midnight on the spring-forward day, ten on the fall-back day.
+	if got := AtClock(spring, berlin, 9*time.Hour).Sub(StartOfDay(spring, berlin)); got != 8*time.Hour {
+		t.Errorf("09:00 on the spring-forward day is %v after midnight, want 8h", got)
+	}
+	if got := AtClock(fall, berlin, 9*time.Hour).Sub(StartOfDay(fall, berlin)); got != 10*time.Hour {
+		t.Errorf("09:00 on the fall-back day is %v after midnight, want 10h", got)
+	}
+}
+
+func TestSplitByDayAcrossDST(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	start := time.Date(2025, time.October, 25, 18, 0, 0, 0, berlin)
+	end := time.Date(2025, time.October, 27, 6, 0, 0, 0, berlin)
+
+	pieces := Interval{Start: start, End: end}.SplitByDay(berlin)
+	want := []time.Duration{6 * time.Hour, 25 * time.Hour, 6 * time.Hour}
+	if len(pieces) != len(want) {
+		t.Fatalf("SplitByDay = %v, want %d pieces", pieces, len(want))
+	}
+	for i, p := range pieces {
+		if p.Duration() != want[i] {
+			t.Errorf("piece %d lasts %v, want %v", i, p.Duration(), want[i])
+		}
+	}
+}
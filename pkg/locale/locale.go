@@ -0,0 +1,82 @@
+// Package locale decides which language patient-facing content is rendered in.
+// A patient's stored preference wins, then the request's Accept-Language header,
+// then the clinic's configured default.
+package locale
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Language is a supported content language, as an ISO 639-1 code.
+type Language string
+
+const (
+	Arabic  Language = "ar"
+	English Language = "en"
+)
+
+// Fallback is used when neither the patient, the request nor the clinic names a supported language.
+const Fallback = English
+
+// Supported lists every language templates and public messages ship in.
+var Supported = []Language{Arabic, English}
+
+// Codes returns the supported languages as plain strings, e.g. for validation schemas.
+func Codes() []string {
+	codes := make([]string, len(Supported))
+	for i, l := range Supported {
+		codes[i] = string(l)
+	}
+	return codes
+}
+
+// Parse maps a language tag such as "ar", "AR" or "en-GB" to a supported language.
+func Parse(tag string) (Language, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	base, _, _ := strings.Cut(tag, "-")
+	base, _, _ = strings.Cut(base, "_")
+	l := Language(base)
+	if !slices.Contains(Supported, l) {
+		return "", false
+	}
+	return l, true
+}
+
+// FromAcceptLanguage returns the supported language with the highest q-value in an
+// Accept-Language header. Ties keep the header's order.
+func FromAcceptLanguage(header string) (Language, bool) {
+	var (
+		best  Language
+		bestQ float64
+	)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		l, ok := Parse(tag)
+		if !ok || q <= 0 || q <= bestQ {
+			continue
+		}
+		best, bestQ = l, q
+	}
+	return best, best != ""
+}
+
+// Resolve returns the first candidate that names a supported language, or Fallback.
+// Candidates are given in precedence order; empty and unsupported values are skipped.
+func Resolve(candidates ...string) Language {
+	for _, c := range candidates {
+		if l, ok := Parse(c); ok {
+			return l
+		}
+	}
+	return Fallback
+}
@@ -0,0 +1,38 @@
+package locale
+
+// messages holds translations of public error messages, keyed by the English text
+// the apierror constructors are called with. Only messages reachable from public,
+// patient-facing routes are listed; anything missing is returned untranslated.
+var messages = map[Language]map[string]string{
+	Arabic: {
+		// Generic messages produced by the apierror package.
+		"An unexpected error occurred on the server.":                                     "حدث خطأ غير متوقع في الخادم.",
+		"The request took too long to complete.":                                          "استغرق الطلب وقتًا أطول من اللازم.",
+		"The server is temporarily unable to handle the request. Please retry shortly.":   "الخادم غير قادر على معالجة الطلب مؤقتًا. يرجى المحاولة مرة أخرى بعد قليل.",
+		"The requested resource 'clinic' was not found.":                                  "لم يتم العثور على العيادة المطلوبة.",
+		"The requested resource 'profile' was not found.":                                 "لم يتم العثور على الملف المطلوب.",
+		"Unable to determine the clinic for this request.":                                "تعذر تحديد العيادة لهذا الطلب.",
+		"A clinic slug is required.":                                                      "يجب تحديد العيادة.",
+		"A registered patient with this phone number already exists.":                     "يوجد مريض مسجل بهذا الرقم بالفعل.",
+		"A patient with this phone number or email already exists in this clinic.":        "يوجد مريض بهذا الرقم أو البريد الإلكتروني في هذه العيادة بالفعل.",
+		"This invitation link is invalid.":                                                "رابط الدعوة غير صالح.",
+		"This invitation is no longer valid.":                                             "هذه الدعوة لم تعد صالحة.",
+		"This invitation has already been used.":                                          "تم استخدام هذه الدعوة بالفعل.",
+		"This invitation has expired. Ask your clinic administrator to send a new one.":   "انتهت صلاحية هذه الدعوة. اطلب من مسؤول العيادة إرسال دعوة جديدة.",
+		"This verification link is invalid.":                                              "رابط التحقق غير صالح.",
+		"This verification link is invalid or has expired.":                               "رابط التحقق غير صالح أو منتهي الصلاحية.",
+		"This undo link is invalid.":                                                      "رابط التراجع غير صالح.",
+		"This undo link is invalid or has expired.":                                       "رابط التراجع غير صالح أو منتهي الصلاحية.",
+		"This password reset link is invalid or has expired.":                             "رابط إعادة تعيين كلمة المرور غير صالح أو منتهي الصلاحية.",
+		"This email address is already in use in this clinic.":                            "عنوان البريد الإلكتروني هذا مستخدم بالفعل في هذه العيادة.",
+		"Password must contain upper-case and lower-case letters and at least one digit.": "يجب أن تحتوي كلمة المرور على أحرف كبيرة وصغيرة ورقم واحد على الأقل.",
+	},
+}
+
+// Translate returns msg in lang, or msg unchanged when no translation exists.
+func Translate(lang Language, msg string) string {
+	if translated, ok := messages[lang][msg]; ok {
+		return translated
+	}
+	return msg
+}
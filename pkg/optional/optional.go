@@ -0,0 +1,99 @@
+// Package optional provides a tri-state value for partial updates. A JSON field can be
+// omitted (leave the stored value alone), explicitly null (clear it) or set to a value;
+// a plain pointer cannot tell the first two apart.
+package optional
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Optional holds a field of an update request. The zero value is "omitted".
+type Optional[T any] struct {
+	set   bool
+	value *T
+}
+
+// Some returns an Optional set to v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{set: true, value: &v}
+}
+
+// Null returns an Optional explicitly set to null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{set: true}
+}
+
+// FromPtr returns an Optional set to *p, or explicitly null when p is nil.
+func FromPtr[T any](p *T) Optional[T] {
+	return Optional[T]{set: true, value: p}
+}
+
+// IsSet reports whether the field was present, including as null.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull reports whether the field was present and explicitly null.
+func (o Optional[T]) IsNull() bool {
+	return o.set && o.value == nil
+}
+
+// Get returns the value, which is nil when the field was null, and whether the field was present.
+func (o Optional[T]) Get() (*T, bool) {
+	return o.value, o.set
+}
+
+// Apply copies the field onto dst when it was present, clearing dst when it was null.
+func (o Optional[T]) Apply(dst **T) {
+	if o.set {
+		*dst = o.value
+	}
+}
+
+// Valid reports whether a present, non-null value passes check. Omitted and null
+// fields are always valid here; whether a field may be cleared is the caller's decision.
+func (o Optional[T]) Valid(check func(T) bool) bool {
+	if o.value == nil {
+		return true
+	}
+	return check(*o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for fields present in
+// the payload, which is what marks the Optional as set.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.value = nil
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.value = &v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Omitted and null fields both encode as null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if o.value == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(*o.value)
+}
+
+// BlankAsNull trims a present string and turns an empty result into an explicit null,
+// so clients clearing a text input and clients sending null get the same result.
+func BlankAsNull(o Optional[string]) Optional[string] {
+	if o.value == nil {
+		return o
+	}
+	if trimmed := strings.TrimSpace(*o.value); trimmed != "" {
+		return Some(trimmed)
+	}
+	return Null[string]()
+}
@@ -0,0 +1,33 @@
+package optional
+
+import z "github.com/Oudwins/zog"
+
+// String adapts a zog string schema to an Optional[string] struct field, for use with
+// StructSchema.Validate on an already decoded request. Omitted fields always pass; null
+// and blank values pass only if nullable; anything else must satisfy schema.
+func String(schema *z.StringSchema[string], nullable bool, message string) *z.Custom[Optional[string]] {
+	return z.CustomFunc(func(o *Optional[string], _ z.Ctx) bool {
+		v := BlankAsNull(*o)
+		if v.IsNull() {
+			return nullable
+		}
+		return v.Valid(func(s string) bool { return schema.Validate(&s) == nil })
+	}, z.Message(message))
+}
+
+// Int adapts a zog int schema to an Optional[int] struct field the way String does for strings.
+func Int(schema *z.NumberSchema[int], nullable bool, message string) *z.Custom[Optional[int]] {
+	return z.CustomFunc(func(o *Optional[int], _ z.Ctx) bool {
+		if o.IsNull() {
+			return nullable
+		}
+		return o.Valid(func(n int) bool { return schema.Validate(&n) == nil })
+	}, z.Message(message))
+}
+
+// Bool checks an Optional[bool] struct field, which fails only when null and not nullable.
+func Bool(nullable bool, message string) *z.Custom[Optional[bool]] {
+	return z.CustomFunc(func(o *Optional[bool], _ z.Ctx) bool {
+		return nullable || !o.IsNull()
+	}, z.Message(message))
+}
@@ -0,0 +1,152 @@
+// Package phone normalizes the phone numbers staff and patients type into canonical E.164,
+// the only form numbers are stored and compared in. Numbers in international form are accepted
+// for any country; numbers in national form are read using the clinic's default region.
+package phone
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Region describes how numbers are dialled within one country.
+type Region struct {
+	// CallingCode is the country calling code without the leading +.
+	CallingCode string
+	// TrunkPrefix is dialled before national numbers inside the country, e.g. "0".
+	TrunkPrefix string
+	// Lengths are the valid national significant number lengths.
+	Lengths []int
+}
+
+// Regions are the countries whose national number format is understood, by ISO 3166-1 alpha-2 code.
+var Regions = map[string]Region{
+	"AE": {CallingCode: "971", TrunkPrefix: "0", Lengths: []int{8, 9}},
+	"BH": {CallingCode: "973", Lengths: []int{8}},
+	"EG": {CallingCode: "20", TrunkPrefix: "0", Lengths: []int{8, 9, 10}},
+	"GB": {CallingCode: "44", TrunkPrefix: "0", Lengths: []int{9, 10}},
+	"JO": {CallingCode: "962", TrunkPrefix: "0", Lengths: []int{8, 9}},
+	"KW": {CallingCode: "965", Lengths: []int{8}},
+	"OM": {CallingCode: "968", Lengths: []int{8}},
+	"QA": {CallingCode: "974", Lengths: []int{8}},
+	"SA": {CallingCode: "966", TrunkPrefix: "0", Lengths: []int{8, 9}},
+	"US": {CallingCode: "1", Lengths: []int{10}},
+}
+
+// DefaultRegion is assumed for clinics that have not configured one.
+const DefaultRegion = "EG"
+
+// E.164 limits the full number, calling code included, to 15 digits. Shorter than 7 is never a
+// real subscriber number.
+const (
+	minDigits = 7
+	maxDigits = 15
+)
+
+// Reasons a number cannot be normalized. The messages are safe to show to users.
+var (
+	ErrEmpty              = errors.New("phone number is empty")
+	ErrInvalidCharacter   = errors.New("phone number may only contain digits, spaces, dashes, dots, brackets and a leading +")
+	ErrTooShort           = errors.New("phone number is too short")
+	ErrTooLong            = errors.New("phone number is too long")
+	ErrInvalidLength      = errors.New("phone number has the wrong number of digits for its country")
+	ErrInvalidCountryCode = errors.New("phone number does not start with a valid country code")
+	ErrUnknownRegion      = errors.New("phone number is not in international form and the region is unknown")
+)
+
+// RegionCodes returns the supported region codes, sorted, e.g. for validation schemas.
+func RegionCodes() []string {
+	codes := make([]string, 0, len(Regions))
+	for code := range Regions {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Normalize returns raw in E.164 form. International numbers ("+20 101 234 5678" or
+// "0020 1012345678") keep their country; national numbers ("010-1234-5678") are read as
+// numbers of defaultRegion. Spaces, dashes, dots and brackets are ignored.
+func Normalize(raw, defaultRegion string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", ErrEmpty
+	}
+
+	international := false
+	if rest, ok := strings.CutPrefix(s, "+"); ok {
+		international, s = true, rest
+	}
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidCharacter
+		}
+	}
+	number := digits.String()
+	if !international {
+		if rest, ok := strings.CutPrefix(number, "00"); ok {
+			international, number = true, rest
+		}
+	}
+
+	if international {
+		return normalizeInternational(number)
+	}
+	return normalizeNational(number, defaultRegion)
+}
+
+// normalizeInternational validates digits that start with a calling code. Calling codes are
+// prefix-free, so at most one region matches; its numbers must also have a valid national length.
+func normalizeInternational(digits string) (string, error) {
+	if err := checkLength(digits); err != nil {
+		return "", err
+	}
+	if digits[0] == '0' {
+		return "", ErrInvalidCountryCode
+	}
+	for _, region := range Regions {
+		if national, ok := strings.CutPrefix(digits, region.CallingCode); ok {
+			if !slices.Contains(region.Lengths, len(national)) {
+				return "", ErrInvalidLength
+			}
+			break
+		}
+	}
+	return "+" + digits, nil
+}
+
+// normalizeNational prefixes digits dialled inside regionCode with its calling code.
+func normalizeNational(digits, regionCode string) (string, error) {
+	region, ok := Regions[strings.ToUpper(regionCode)]
+	if !ok {
+		return "", ErrUnknownRegion
+	}
+	if region.TrunkPrefix != "" {
+		digits = strings.TrimPrefix(digits, region.TrunkPrefix)
+	}
+	if len(digits) < slices.Min(region.Lengths) {
+		return "", ErrTooShort
+	}
+	if len(digits) > slices.Max(region.Lengths) {
+		return "", ErrTooLong
+	}
+	if !slices.Contains(region.Lengths, len(digits)) {
+		return "", ErrInvalidLength
+	}
+	return "+" + region.CallingCode + digits, nil
+}
+
+func checkLength(digits string) error {
+	switch {
+	case len(digits) < minDigits:
+		return ErrTooShort
+	case len(digits) > maxDigits:
+		return ErrTooLong
+	}
+	return nil
+}
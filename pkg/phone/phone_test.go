@@ -0,0 +1,67 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw, region string
+		want        string
+		wantErr     error
+	}{
+		// National numbers gain the region's calling code in place of the trunk prefix.
+		{"01012345678", "EG", "+201012345678", nil},
+		{"0501234567", "SA", "+966501234567", nil},
+		{"51234567", "KW", "+96551234567", nil},
+		{"2025550123", "US", "+12025550123", nil},
+		{"01012345678", "eg", "+201012345678", nil},
+		// Separators people type are ignored.
+		{"010 1234 5678", "EG", "+201012345678", nil},
+		{"010-1234-5678", "EG", "+201012345678", nil},
+		{"(010) 1234.5678", "EG", "+201012345678", nil},
+		{"  01012345678  ", "EG", "+201012345678", nil},
+		// International numbers keep their country whatever the region.
+		{"+201012345678", "SA", "+201012345678", nil},
+		{"+20 101 234 5678", "EG", "+201012345678", nil},
+		{"0020 1012345678", "EG", "+201012345678", nil},
+		{"+33612345678", "EG", "+33612345678", nil},
+		// Invalid numbers.
+		{"", "EG", "", ErrEmpty},
+		{"   ", "EG", "", ErrEmpty},
+		{"0101", "EG", "", ErrTooShort},
+		{"+2010", "EG", "", ErrTooShort},
+		{"010123456789", "EG", "", ErrTooLong},
+		{"+2010123456789012", "EG", "", ErrTooLong},
+		{"+2010123", "EG", "", ErrInvalidLength},
+		{"+0201012345678", "EG", "", ErrInvalidCountryCode},
+		{"010/1234/5678", "EG", "", ErrInvalidCharacter},
+		{"+20 101 abc 5678", "EG", "", ErrInvalidCharacter},
+		{"01012345678", "FR", "", ErrUnknownRegion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw+"/"+tt.region, func(t *testing.T) {
+			got, err := Normalize(tt.raw, tt.region)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize(%q, %q) error = %v, want %v", tt.raw, tt.region, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q, %q) = %q, want %q", tt.raw, tt.region, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeIsIdempotent(t *testing.T) {
+	for _, raw := range []string{"01012345678", "+966501234567", "0020 1012345678"} {
+		once, err := Normalize(raw, "EG")
+		if err != nil {
+			t.Fatalf("Normalize(%q): %v", raw, err)
+		}
+		twice, err := Normalize(once, "SA")
+		if err != nil || twice != once {
+			t.Errorf("Normalize(%q) = %q, %v; want it unchanged", once, twice, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package ttlcache provides a small, concurrency-safe in-memory cache with per-entry expiry.
+// It is intended for short-lived caching of hot, tenant-scoped lookups within a single process.
+package ttlcache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache is a generic key/value cache whose entries expire after a fixed TTL.
+type Cache[K comparable, V any] struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[K]entry[V]
+}
+
+// New creates a cache whose entries live for the given duration.
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:     ttl,
+		entries: make(map[K]entry[V]),
+	}
+}
+
+// Get returns the cached value for key if present and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key, replacing any previous entry and resetting its expiry.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purgeExpiredLocked()
+	c.entries[key] = entry[V]{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// Delete removes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// DeleteFunc removes every entry whose key matches the predicate.
+func (c *Cache[K, V]) DeleteFunc(match func(K) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if match(k) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// purgeExpiredLocked drops expired entries so the map cannot grow without bound.
+// The caller must hold the write lock.
+func (c *Cache[K, V]) purgeExpiredLocked() {
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}